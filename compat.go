@@ -0,0 +1,120 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ekle/protoc-gen-goweb/compat"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// compatMain implements
+//
+//	protoc-gen-goweb compat old.pb new.pb
+//
+// It prints the breaking changes between the two descriptor sets and
+// returns the exit status: 0 if there are none, 1 if there are, 2 on error.
+func compatMain(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: protoc-gen-goweb compat old.pb new.pb")
+		return 2
+	}
+	sets, ok := readSets(args)
+	if !ok {
+		return 2
+	}
+	changes := compat.Check(sets[0], sets[1])
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// changelogMain implements
+//
+//	protoc-gen-goweb changelog [-json] old.pb new.pb
+//
+// It prints the changelog between the two descriptor sets as markdown, or
+// as JSON with -json, and returns the exit status: 0, or 2 on error.
+func changelogMain(args []string) int {
+	asJSON := len(args) > 0 && args[0] == "-json"
+	if asJSON {
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: protoc-gen-goweb changelog [-json] old.pb new.pb")
+		return 2
+	}
+	sets, ok := readSets(args)
+	if !ok {
+		return 2
+	}
+	l := compat.NewChangelog(sets[0], sets[1])
+	out := l.Markdown()
+	if asJSON {
+		var err error
+		if out, err = json.MarshalIndent(l, "", "  "); err != nil {
+			fmt.Fprintln(os.Stderr, "protoc-gen-goweb: error:", err)
+			return 2
+		}
+		out = append(out, '\n')
+	}
+	os.Stdout.Write(out)
+	return 0
+}
+
+// readSets reads the descriptor sets of the files names, reporting the
+// errors.
+func readSets(names []string) ([2]*descriptor.FileDescriptorSet, bool) {
+	var sets [2]*descriptor.FileDescriptorSet
+	for i, name := range names {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "protoc-gen-goweb: error:", err)
+			return sets, false
+		}
+		sets[i] = new(descriptor.FileDescriptorSet)
+		if err := proto.Unmarshal(data, sets[i]); err != nil {
+			fmt.Fprintln(os.Stderr, "protoc-gen-goweb: error: parsing", name+":", err)
+			return sets, false
+		}
+	}
+	return sets, true
+}
@@ -0,0 +1,165 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package compat
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// A Changelog lists the changes of the HTTP API between two versions of a
+// descriptor set, for release notes and release tooling.
+type Changelog struct {
+	Breaking   []Change `json:"breaking"`   // the changes of Check
+	Added      []Change `json:"added"`      // new routes and fields
+	Deprecated []Change `json:"deprecated"` // routes and fields deprecated since old
+}
+
+// NewChangelog returns the changes between old and new: those of Check,
+// the routes and the fields of the requests and responses of the routes
+// of both that new adds, and those new deprecates. Additions and
+// deprecations are ordered by the declaration order in new.
+func NewChangelog(old, new *pb.FileDescriptorSet) *Changelog {
+	c := &checker{
+		oldMsgs: goweb.IndexMessages(old.GetFile()),
+		newMsgs: goweb.IndexMessages(new.GetFile()),
+		seen:    make(map[string]bool),
+	}
+	oldMethods := make(map[string]*pb.MethodDescriptorProto)
+	oldRoutes := make(map[string]goweb.Route)
+	for _, f := range old.GetFile() {
+		for _, s := range f.GetService() {
+			for _, m := range s.GetMethod() {
+				r := goweb.RouteOf(f, s, m)
+				oldMethods[r.FullMethod()], oldRoutes[r.FullMethod()] = m, r
+			}
+		}
+	}
+	for _, f := range new.GetFile() {
+		for _, s := range f.GetService() {
+			for _, m := range s.GetMethod() {
+				n := goweb.RouteOf(f, s, m)
+				subject := n.Service + "." + n.Method
+				om, ok := oldMethods[n.FullMethod()]
+				switch {
+				case !ok:
+					c.add(RouteAdded, subject, "", verbName(n.Verb)+" /"+n.Path)
+					continue
+				case m.GetOptions().GetDeprecated() && !om.GetOptions().GetDeprecated():
+					c.add(RouteDeprecated, subject, "", "")
+				}
+				o := oldRoutes[n.FullMethod()]
+				if o.Input == n.Input {
+					c.addedFields(n.Input)
+				}
+				if o.Output == n.Output {
+					c.addedFields(n.Output)
+				}
+			}
+		}
+	}
+	l := &Changelog{Breaking: Check(old, new)}
+	for _, ch := range c.changes {
+		if ch.Kind == RouteDeprecated || ch.Kind == FieldDeprecated {
+			l.Deprecated = append(l.Deprecated, ch)
+		} else {
+			l.Added = append(l.Added, ch)
+		}
+	}
+	return l
+}
+
+// addedFields records the fields added to and deprecated in the message
+// called name, descending into message-typed fields.
+func (c *checker) addedFields(name string) {
+	if c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+	o, n := c.oldMsgs[name], c.newMsgs[name]
+	if o == nil || n == nil {
+		return
+	}
+	oldFields := make(map[int32]*pb.FieldDescriptorProto)
+	for _, f := range o.Field {
+		oldFields[f.GetNumber()] = f
+	}
+	for _, nf := range n.Field {
+		subject := name[1:] + "." + nf.GetName()
+		of, ok := oldFields[nf.GetNumber()]
+		switch {
+		case !ok:
+			c.add(FieldAdded, subject, "", fieldType(nf))
+			continue
+		case nf.GetOptions().GetDeprecated() && !of.GetOptions().GetDeprecated():
+			c.add(FieldDeprecated, subject, "", "")
+		}
+		if fieldType(of) == fieldType(nf) && (nf.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE || nf.GetType() == pb.FieldDescriptorProto_TYPE_GROUP) {
+			c.addedFields(nf.GetTypeName())
+		}
+	}
+}
+
+// Empty reports whether there are no changes.
+func (l *Changelog) Empty() bool {
+	return len(l.Breaking) == 0 && len(l.Added) == 0 && len(l.Deprecated) == 0
+}
+
+// Markdown returns the changelog as a markdown document, with a section
+// per kind of change.
+func (l *Changelog) Markdown() []byte {
+	var b bytes.Buffer
+	b.WriteString("# API changes\n")
+	if l.Empty() {
+		b.WriteString("\nNo changes.\n")
+	}
+	for _, section := range []struct {
+		title   string
+		changes []Change
+	}{
+		{"Breaking changes", l.Breaking},
+		{"Additions", l.Added},
+		{"Deprecations", l.Deprecated},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		for _, c := range section.changes {
+			fmt.Fprintf(&b, "- `%s`: %s\n", c.Subject, c.String()[len(c.Subject)+2:])
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,80 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package compat
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestChangelog(t *testing.T) {
+	new := api()
+	f := new.File[0]
+	f.Service[0].Method = append(f.Service[0].Method[1:], method("New", ".api.Req", ".api.Res"))
+	f.Service[0].Method[0].Options = &pb.MethodOptions{Deprecated: proto.Bool(true)}
+	f.MessageType[1].Field = append(f.MessageType[1].Field, field("tags", 2, pb.FieldDescriptorProto_TYPE_STRING, ""))
+	f.MessageType[1].Field[0].Options = &pb.FieldOptions{Deprecated: proto.Bool(true)}
+	l := NewChangelog(api(), new)
+	want := &Changelog{
+		Breaking:   []Change{{RouteRemoved, "api.Things.Get", "things/get", ""}},
+		Added:      []Change{{FieldAdded, "api.Filter.tags", "", "TYPE_STRING"}, {RouteAdded, "api.Things.New", "", "any /things/new"}},
+		Deprecated: []Change{{RouteDeprecated, "api.Things.List", "", ""}, {FieldDeprecated, "api.Filter.query", "", ""}},
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("NewChangelog() = %+v, want %+v", l, want)
+	}
+
+	md := string(l.Markdown())
+	for _, s := range []string{"## Breaking changes\n\n- `api.Things.Get`: route removed (was things/get)\n", "- `api.Things.New`: route added (any /things/new)\n", "## Deprecations\n"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Markdown() = %s, missing %q", md, s)
+		}
+	}
+	if md := string(NewChangelog(api(), api()).Markdown()); md != "# API changes\n\nNo changes.\n" {
+		t.Errorf("Markdown() without changes = %q", md)
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil || !strings.Contains(string(b), `{"kind":"route removed","subject":"api.Things.Get","old":"things/get"}`) {
+		t.Errorf("json = %s, %v", b, err)
+	}
+	for k := Kind(0); k <= FieldDeprecated; k++ {
+		if k.Breaking() != (k < RouteAdded) {
+			t.Errorf("%v.Breaking() = %v", k, k.Breaking())
+		}
+	}
+}
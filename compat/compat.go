@@ -0,0 +1,250 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package compat compares two versions of a set of .proto files and reports
+// the changes that break the HTTP contract of the services generated by
+// protoc-gen-goweb: removed routes, changed paths, verbs and streaming
+// modes, and removed, renamed or retyped fields of request and response
+// messages.
+//
+// The inputs are FileDescriptorSets as written by
+//
+//	protoc --include_imports --descriptor_set_out=api.pb api.proto
+package compat
+
+import (
+	"fmt"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Kind classifies a change; those up to FieldTypeChanged break the
+// contract, see Breaking.
+type Kind int
+
+const (
+	RouteRemoved     Kind = iota // a method is no longer served
+	PathChanged                  // a method moved to another path
+	VerbChanged                  // a method is served with another HTTP verb
+	StreamingChanged             // a method changed its streaming mode
+	InputChanged                 // a method takes another request type
+	OutputChanged                // a method returns another response type
+	FieldRemoved                 // a field of a request or response message is gone
+	FieldRenamed                 // a field kept its number but changed its name
+	FieldTypeChanged             // a field changed its type or label
+	RouteAdded                   // a method is served
+	FieldAdded                   // a field of a request or response message is new
+	RouteDeprecated              // a method became deprecated
+	FieldDeprecated              // a field of a request or response message became deprecated
+)
+
+var kindNames = map[Kind]string{
+	RouteRemoved:     "route removed",
+	PathChanged:      "path changed",
+	VerbChanged:      "verb changed",
+	StreamingChanged: "streaming changed",
+	InputChanged:     "input type changed",
+	OutputChanged:    "output type changed",
+	FieldRemoved:     "field removed",
+	FieldRenamed:     "field renamed",
+	FieldTypeChanged: "field type changed",
+	RouteAdded:       "route added",
+	FieldAdded:       "field added",
+	RouteDeprecated:  "route deprecated",
+	FieldDeprecated:  "field deprecated",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// MarshalText encodes k as its String, e.g. in the JSON of a Changelog.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Breaking reports whether changes of kind k break the contract.
+func (k Kind) Breaking() bool {
+	return k <= FieldTypeChanged
+}
+
+// A Change is a single difference between two descriptor sets.
+type Change struct {
+	Kind    Kind   `json:"kind"`
+	Subject string `json:"subject"`       // "pkg.Service.Method" for routes, "pkg.Message.field" for fields
+	Old     string `json:"old,omitempty"` // the old value, if any
+	New     string `json:"new,omitempty"` // the new value, if any
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Old == "" && c.New == "":
+		return fmt.Sprintf("%s: %s", c.Subject, c.Kind)
+	case c.New == "":
+		return fmt.Sprintf("%s: %s (was %s)", c.Subject, c.Kind, c.Old)
+	case c.Old == "":
+		return fmt.Sprintf("%s: %s (%s)", c.Subject, c.Kind, c.New)
+	}
+	return fmt.Sprintf("%s: %s from %s to %s", c.Subject, c.Kind, c.Old, c.New)
+}
+
+// Check reports the breaking changes of the HTTP contract between old and new.
+// Additions are never breaking and are not reported.
+// The result is ordered by the declaration order in old.
+func Check(old, new *pb.FileDescriptorSet) []Change {
+	c := &checker{
+		oldMsgs: goweb.IndexMessages(old.GetFile()),
+		newMsgs: goweb.IndexMessages(new.GetFile()),
+		seen:    make(map[string]bool),
+	}
+	newRoutes := make(map[string]goweb.Route)
+	for _, f := range new.GetFile() {
+		for _, r := range goweb.RoutesOf(f) {
+			newRoutes[r.FullMethod()] = r
+		}
+	}
+	for _, f := range old.GetFile() {
+		for _, o := range goweb.RoutesOf(f) {
+			c.checkRoute(o, newRoutes)
+		}
+	}
+	return c.changes
+}
+
+type checker struct {
+	oldMsgs, newMsgs map[string]*pb.DescriptorProto
+	seen             map[string]bool // messages already compared
+	changes          []Change
+}
+
+func (c *checker) add(kind Kind, subject, old, new string) {
+	c.changes = append(c.changes, Change{Kind: kind, Subject: subject, Old: old, New: new})
+}
+
+func (c *checker) checkRoute(o goweb.Route, newRoutes map[string]goweb.Route) {
+	subject := o.Service + "." + o.Method
+	n, ok := newRoutes[o.FullMethod()]
+	if !ok {
+		c.add(RouteRemoved, subject, o.Path, "")
+		return
+	}
+	if o.Path != n.Path {
+		c.add(PathChanged, subject, o.Path, n.Path)
+	}
+	if o.Verb != n.Verb {
+		c.add(VerbChanged, subject, verbName(o.Verb), verbName(n.Verb))
+	}
+	if o.ClientStreaming != n.ClientStreaming || o.ServerStreaming != n.ServerStreaming {
+		c.add(StreamingChanged, subject, streamingName(o), streamingName(n))
+	}
+	if o.Input != n.Input {
+		c.add(InputChanged, subject, o.Input, n.Input)
+	} else {
+		c.checkMessage(o.Input)
+	}
+	if o.Output != n.Output {
+		c.add(OutputChanged, subject, o.Output, n.Output)
+	} else {
+		c.checkMessage(o.Output)
+	}
+}
+
+// checkMessage compares the message called name in both sets,
+// descending into message-typed fields.
+func (c *checker) checkMessage(name string) {
+	if c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+	o, n := c.oldMsgs[name], c.newMsgs[name]
+	if o == nil || n == nil {
+		// Unknown in old means there is nothing to break; gone from new
+		// means the referencing field or route already reported a change.
+		return
+	}
+	newFields := make(map[int32]*pb.FieldDescriptorProto)
+	for _, f := range n.Field {
+		newFields[f.GetNumber()] = f
+	}
+	for _, of := range o.Field {
+		subject := name[1:] + "." + of.GetName()
+		nf, ok := newFields[of.GetNumber()]
+		if !ok {
+			c.add(FieldRemoved, subject, "", "")
+			continue
+		}
+		if of.GetName() != nf.GetName() {
+			c.add(FieldRenamed, subject, of.GetName(), nf.GetName())
+		}
+		if ot, nt := fieldType(of), fieldType(nf); ot != nt {
+			c.add(FieldTypeChanged, subject, ot, nt)
+			continue
+		}
+		if of.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE || of.GetType() == pb.FieldDescriptorProto_TYPE_GROUP {
+			c.checkMessage(of.GetTypeName())
+		}
+	}
+}
+
+// fieldType describes the type of a field in .proto syntax, e.g. "repeated .pkg.Msg".
+func fieldType(f *pb.FieldDescriptorProto) string {
+	s := ""
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		s = "repeated "
+	}
+	if n := f.GetTypeName(); n != "" {
+		return s + n
+	}
+	return s + f.GetType().String()
+}
+
+func verbName(verb string) string {
+	if verb == "" {
+		return "any"
+	}
+	return verb
+}
+
+func streamingName(r goweb.Route) string {
+	switch {
+	case r.ClientStreaming && r.ServerStreaming:
+		return "bidi"
+	case r.ClientStreaming:
+		return "client"
+	case r.ServerStreaming:
+		return "server"
+	}
+	return "unary"
+}
@@ -0,0 +1,128 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package compat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func field(name string, number int32, typ pb.FieldDescriptorProto_Type, typeName string) *pb.FieldDescriptorProto {
+	f := &pb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  pb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func method(name, in, out string) *pb.MethodDescriptorProto {
+	return &pb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(in),
+		OutputType: proto.String(out),
+	}
+}
+
+// api returns a small descriptor set that the tests mutate.
+func api() *pb.FileDescriptorSet {
+	return &pb.FileDescriptorSet{File: []*pb.FileDescriptorProto{{
+		Name:    proto.String("api.proto"),
+		Package: proto.String("api"),
+		MessageType: []*pb.DescriptorProto{
+			{Name: proto.String("Req"), Field: []*pb.FieldDescriptorProto{
+				field("id", 1, pb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("filter", 2, pb.FieldDescriptorProto_TYPE_MESSAGE, ".api.Filter"),
+			}},
+			{Name: proto.String("Filter"), Field: []*pb.FieldDescriptorProto{
+				field("query", 1, pb.FieldDescriptorProto_TYPE_STRING, ""),
+			}},
+			{Name: proto.String("Res"), Field: []*pb.FieldDescriptorProto{
+				field("count", 1, pb.FieldDescriptorProto_TYPE_INT32, ""),
+			}},
+		},
+		Service: []*pb.ServiceDescriptorProto{{
+			Name: proto.String("Things"),
+			Method: []*pb.MethodDescriptorProto{
+				method("Get", ".api.Req", ".api.Res"),
+				method("List", ".api.Req", ".api.Res"),
+			},
+		}},
+	}}}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(f *pb.FileDescriptorProto)
+		want   []Change
+	}{
+		{"unchanged", func(f *pb.FileDescriptorProto) {}, nil},
+		{"added method and field", func(f *pb.FileDescriptorProto) {
+			f.Service[0].Method = append(f.Service[0].Method, method("New", ".api.Req", ".api.Res"))
+			f.MessageType[2].Field = append(f.MessageType[2].Field, field("extra", 2, pb.FieldDescriptorProto_TYPE_BOOL, ""))
+		}, nil},
+		{"removed method", func(f *pb.FileDescriptorProto) {
+			f.Service[0].Method = f.Service[0].Method[:1]
+		}, []Change{{RouteRemoved, "api.Things.List", "things/list", ""}}},
+		{"streaming", func(f *pb.FileDescriptorProto) {
+			f.Service[0].Method[0].ServerStreaming = proto.Bool(true)
+		}, []Change{{StreamingChanged, "api.Things.Get", "unary", "server"}}},
+		{"input type", func(f *pb.FileDescriptorProto) {
+			f.Service[0].Method[1].InputType = proto.String(".api.Filter")
+		}, []Change{{InputChanged, "api.Things.List", ".api.Req", ".api.Filter"}}},
+		{"nested field renamed", func(f *pb.FileDescriptorProto) {
+			f.MessageType[1].Field[0].Name = proto.String("q")
+		}, []Change{{FieldRenamed, "api.Filter.query", "query", "q"}}},
+		{"field removed and retyped", func(f *pb.FileDescriptorProto) {
+			f.MessageType[0].Field = f.MessageType[0].Field[:1]
+			f.MessageType[2].Field[0].Type = pb.FieldDescriptorProto_TYPE_INT64.Enum()
+		}, []Change{
+			{FieldRemoved, "api.Req.filter", "", ""},
+			{FieldTypeChanged, "api.Res.count", "TYPE_INT32", "TYPE_INT64"},
+		}},
+	}
+	for _, tc := range tests {
+		new := api()
+		tc.mutate(new.File[0])
+		if got := Check(api(), new); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Check() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
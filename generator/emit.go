@@ -0,0 +1,252 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package generator
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The helpers in this file let plugins emit declarations instead of raw
+// lines: blocks always close their braces, and the packages obtained with
+// Import are imported by every file that uses them, with no need for a
+// GenerateImports of the plugin.
+
+// A GoPackage is the name a generated file refers to an imported package by.
+type GoPackage string
+
+// Ident returns the identifier name qualified by the package, e.g. "goweb.Route".
+func (p GoPackage) Ident(name string) string { return string(p) + "." + name }
+
+// Import records that the file being generated uses the package with the
+// Go import path importPath, and returns its name in the file: the last
+// element of the path, made unique if another package of the file has it.
+// An import that a plugin also declares itself in GenerateImports, with
+// the same name, is only kept once.
+func (g *Generator) Import(importPath string) GoPackage {
+	importPath = path.Join(g.ImportPrefix, importPath)
+	if name, ok := g.imports[importPath]; ok {
+		return name
+	}
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, path.Base(importPath))
+	name := GoPackage(base)
+	for i := 1; g.importNameTaken(name); i++ {
+		name = GoPackage(base + strconv.Itoa(i))
+	}
+	if g.imports == nil {
+		g.imports = map[string]GoPackage{}
+	}
+	g.imports[importPath] = name
+	return name
+}
+
+func (g *Generator) importNameTaken(name GoPackage) bool {
+	for _, n := range g.imports {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// generateRecordedImports declares the packages recorded with Import.
+func (g *Generator) generateRecordedImports() {
+	var paths []string
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		g.P("import ", string(g.imports[p]), " ", strconv.Quote(p))
+	}
+	g.P()
+}
+
+// dedupImports drops the import declarations of f that repeat a later
+// one with the same name and path, so the declarations of the plugins
+// are kept as written.
+func dedupImports(f *ast.File) {
+	seen := map[string]bool{}
+	for i := len(f.Decls) - 1; i >= 0; i-- {
+		gd, ok := f.Decls[i].(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		var specs []ast.Spec
+		for j := len(gd.Specs) - 1; j >= 0; j-- {
+			is := gd.Specs[j].(*ast.ImportSpec)
+			if key := importName(is) + " " + is.Path.Value; !seen[key] {
+				seen[key] = true
+				specs = append([]ast.Spec{is}, specs...)
+			}
+		}
+		if len(specs) == 0 {
+			f.Decls = append(f.Decls[:i], f.Decls[i+1:]...)
+			continue
+		}
+		gd.Specs = specs
+	}
+}
+
+// pruneImports drops the imports of f that it does not use, so plugins
+// can import the packages of all the code they may generate, e.g. for a
+// service without methods. Blank and dot imports are kept.
+func pruneImports(f *ast.File) {
+	used := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	for i := len(f.Decls) - 1; i >= 0; i-- {
+		gd, ok := f.Decls[i].(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		var specs []ast.Spec
+		for _, s := range gd.Specs {
+			is := s.(*ast.ImportSpec)
+			name := importName(is)
+			if name == "_" || name == "." || used[name] {
+				specs = append(specs, s)
+			}
+		}
+		if len(specs) == 0 {
+			f.Decls = append(f.Decls[:i], f.Decls[i+1:]...)
+			continue
+		}
+		gd.Specs = specs
+	}
+}
+
+// importName returns the name is declares in the file.
+func importName(is *ast.ImportSpec) string {
+	if is.Name != nil {
+		return is.Name.Name
+	}
+	return path.Base(strings.Trim(is.Path.Value, `"`))
+}
+
+// Block prints header followed by an indented block with the output of
+// body, e.g. g.Block("if err != nil", func() { g.P("return err") }).
+func (g *Generator) Block(header string, body func()) {
+	g.P(header, " {")
+	g.In()
+	if body != nil {
+		body()
+	}
+	g.Out()
+	g.P("}")
+}
+
+// printDoc prints doc as a comment, line by line.
+func (g *Generator) printDoc(doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(doc, "\n"), "\n") {
+		g.P("// ", line)
+	}
+}
+
+// A Func describes a function or method for EmitFunc.
+type Func struct {
+	Doc     string // without the comment markers; may span lines
+	Recv    string // e.g. "s *Server"; empty for functions
+	Name    string
+	Params  string // e.g. "ctx context.Context, id string"
+	Results string // e.g. "(*User, error)"; empty for none
+	Body    func() // prints the statements of the body
+}
+
+// EmitFunc prints the function f followed by an empty line.
+func (g *Generator) EmitFunc(f Func) {
+	g.printDoc(f.Doc)
+	header := "func "
+	if f.Recv != "" {
+		header += "(" + f.Recv + ") "
+	}
+	header += f.Name + "(" + f.Params + ")"
+	if f.Results != "" {
+		header += " " + f.Results
+	}
+	g.Block(header, f.Body)
+	g.P()
+}
+
+// A Field is a field of a Struct.
+type Field struct {
+	Name    string // empty for embedded fields
+	Type    string
+	Tag     string // without the back quotes, e.g. `json:"id"`
+	Comment string // printed at the end of the line
+}
+
+// A Struct describes a struct type for EmitStruct.
+type Struct struct {
+	Doc    string
+	Name   string
+	Fields []Field
+}
+
+// EmitStruct prints the struct type s followed by an empty line.
+func (g *Generator) EmitStruct(s Struct) {
+	g.printDoc(s.Doc)
+	g.Block("type "+s.Name+" struct", func() {
+		for _, f := range s.Fields {
+			line := f.Type
+			if f.Name != "" {
+				line = f.Name + " " + line
+			}
+			if f.Tag != "" {
+				line += " `" + f.Tag + "`"
+			}
+			if f.Comment != "" {
+				line += " // " + f.Comment
+			}
+			g.P(line)
+		}
+	})
+	g.P()
+}
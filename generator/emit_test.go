@@ -0,0 +1,127 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	g := New()
+	if p := g.Import("github.com/a/goweb"); p != "goweb" || p.Ident("Route") != "goweb.Route" {
+		t.Errorf("Import = %q", p)
+	}
+	if p := g.Import("github.com/a/goweb"); p != "goweb" {
+		t.Errorf("second Import = %q", p)
+	}
+	if p := g.Import("github.com/b/goweb"); p != "goweb1" {
+		t.Errorf("Import of another goweb = %q", p)
+	}
+	if p := g.Import("gopkg.in/yaml.v2"); p != "yaml_v2" {
+		t.Errorf("Import = %q", p)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	g := New()
+	fmtPkg := g.Import("fmt")
+	g.P("package p")
+	g.P()
+	g.generateRecordedImports()
+	g.P(`import "fmt"`)
+	g.P()
+	g.EmitStruct(Struct{
+		Doc:    "T is a test.",
+		Name:   "T",
+		Fields: []Field{{Name: "ID", Type: "string", Tag: `json:"id"`, Comment: "the id"}},
+	})
+	g.EmitFunc(Func{
+		Doc:     "String returns the id.\nIt is quoted.",
+		Recv:    "t *T",
+		Name:    "String",
+		Results: "string",
+		Body: func() {
+			g.Block("if t == nil", func() { g.P(`return "nil"`) })
+			g.P("return ", fmtPkg.Ident("Sprintf"), `("%q", t.ID)`)
+		},
+	})
+	out, err := format(g.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+import "fmt"
+
+// T is a test.
+type T struct {
+	ID string ` + "`" + `json:"id"` + "`" + ` // the id
+}
+
+// String returns the id.
+// It is quoted.
+func (t *T) String() string {
+	if t == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%q", t.ID)
+}
+`
+	if got := string(out); strings.TrimSpace(got) != strings.TrimSpace(want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPruneImports(t *testing.T) {
+	src := `package p
+
+import (
+	"fmt"
+	"log"
+	_ "net/http/pprof"
+)
+
+import json "encoding/json"
+
+func f() { fmt.Println() }
+`
+	out, err := format([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	if !strings.Contains(got, `"fmt"`) || !strings.Contains(got, `_ "net/http/pprof"`) ||
+		strings.Contains(got, `"log"`) || strings.Contains(got, "json") {
+		t.Errorf("got\n%s", got)
+	}
+}
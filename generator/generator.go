@@ -45,8 +45,10 @@ import (
 	"log"
 	"os"
 	"path"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
@@ -65,7 +67,9 @@ type Plugin interface {
 	// code generation begins.
 	Init(g *Generator)
 	// Generate produces the code generated by the plugin for this file,
-	// except for the imports, by calling the generator's methods P, In, and Out.
+	// except for the imports, by calling the generator's methods P, In, and Out,
+	// or Block, EmitFunc and EmitStruct. Packages recorded with Import are
+	// imported without GenerateImports.
 	Generate(file *FileDescriptor)
 	// GenerateImports produces the import declarations for this file.
 	// It is called after Generate.
@@ -80,6 +84,17 @@ func RegisterPlugin(p Plugin) {
 	plugins = append(plugins, p)
 }
 
+var reservedPackageNames []string
+
+// ReservePackageNames keeps imported proto packages from taking the given
+// names, which plugins use for the packages they import themselves, e.g.
+// "log" for the log package: a dependency with the proto package log is
+// referred to as log1 instead. It is typically called during
+// initialization.
+func ReservePackageNames(names ...string) {
+	reservedPackageNames = append(reservedPackageNames, names...)
+}
+
 // Each type we import as a protocol buffer (other than FileDescriptorProto) needs
 // a pointer to the FileDescriptorProto that represents it.  These types achieve that
 // wrapping by placing each Proto inside a struct with the pointer to its File. The
@@ -451,12 +466,19 @@ type Generator struct {
 	typeNameToObject map[string]Object // Key is a fully-qualified name in input syntax.
 	init             []string          // Lines to emit in the init function.
 	indent           string
+	spare            *bytes.Buffer        // Reused for the header of every file; see generate.
+	num              [64]byte             // Scratch space for the numbers of P.
+	imports          map[string]GoPackage // Packages recorded with Import, by import path.
+
+	files   []*plugin.CodeGeneratorResponse_File // Files other than Go source; see AddFile.
+	plugins []Plugin                             // The plugins run by this generator; see fork.
 }
 
 // New creates a new generator and allocates the request and response protobufs.
 func New() *Generator {
 	g := new(Generator)
 	g.Buffer = new(bytes.Buffer)
+	g.spare = new(bytes.Buffer)
 	g.Request = new(plugin.CodeGeneratorRequest)
 	g.Response = new(plugin.CodeGeneratorResponse)
 	return g
@@ -544,8 +566,9 @@ var pkgNamesInUse = make(map[string]bool)
 // Pkg is the candidate name.  If f is nil, it's a builtin package like "proto" and
 // has no file descriptor.
 func RegisterUniquePackageName(pkg string, f *FileDescriptor) string {
-	// Convert dots to underscores before finding a unique alias.
-	pkg = strings.Map(badToUnderscore, pkg)
+	// Convert dots to underscores, and keywords and leading digits to
+	// names starting with an underscore, before finding a unique alias.
+	pkg = cleanPackageName(pkg)
 
 	for i, orig := 1, pkg; pkgNamesInUse[pkg]; i++ {
 		// It's a duplicate; must rename.
@@ -598,6 +621,11 @@ func (g *Generator) defaultGoPackage() string {
 		return ""
 	}
 
+	return cleanPackageName(p)
+}
+
+// cleanPackageName makes p a valid package name, like protoc-gen-go.
+func cleanPackageName(p string) string {
 	p = strings.Map(badToUnderscore, p)
 	// Identifier must not be keyword: insert _.
 	if isGoKeyword[p] {
@@ -660,6 +688,11 @@ func (g *Generator) SetPackageNames() {
 		"math":  RegisterUniquePackageName("math", nil),
 		"proto": RegisterUniquePackageName("proto", nil),
 	}
+	for _, name := range reservedPackageNames {
+		if !pkgNamesInUse[name] {
+			RegisterUniquePackageName(name, nil)
+		}
+	}
 
 AllFiles:
 	for _, f := range g.allFiles {
@@ -947,19 +980,19 @@ func (g *Generator) P(str ...interface{}) {
 		case *string:
 			g.WriteString(*s)
 		case bool:
-			g.WriteString(fmt.Sprintf("%t", s))
+			g.Write(strconv.AppendBool(g.num[:0], s))
 		case *bool:
-			g.WriteString(fmt.Sprintf("%t", *s))
+			g.Write(strconv.AppendBool(g.num[:0], *s))
 		case int:
-			g.WriteString(fmt.Sprintf("%d", s))
+			g.Write(strconv.AppendInt(g.num[:0], int64(s), 10))
 		case *int32:
-			g.WriteString(fmt.Sprintf("%d", *s))
+			g.Write(strconv.AppendInt(g.num[:0], int64(*s), 10))
 		case *int64:
-			g.WriteString(fmt.Sprintf("%d", *s))
+			g.Write(strconv.AppendInt(g.num[:0], *s, 10))
 		case float64:
-			g.WriteString(fmt.Sprintf("%g", s))
+			g.Write(strconv.AppendFloat(g.num[:0], s, 'g', -1, 64))
 		case *float64:
-			g.WriteString(fmt.Sprintf("%g", *s))
+			g.Write(strconv.AppendFloat(g.num[:0], *s, 'g', -1, 64))
 		default:
 			g.Fail(fmt.Sprintf("unknown type in printer: %T", v))
 		}
@@ -986,7 +1019,8 @@ func (g *Generator) Out() {
 // GenerateAllFiles generates the output for all the files we're outputting.
 func (g *Generator) GenerateAllFiles() {
 	// Initialize the plugins
-	for _, p := range plugins {
+	g.plugins = plugins
+	for _, p := range g.plugins {
 		p.Init(g)
 	}
 	// Generate the output. The generator runs for every file, even the files
@@ -996,23 +1030,167 @@ func (g *Generator) GenerateAllFiles() {
 	for _, file := range g.genFiles {
 		genFileMap[file] = true
 	}
+	out := make([]fileOutput, len(g.allFiles))
+	if workers := g.workers(); workers > 1 && g.forkable() {
+		g.generateConcurrently(workers, genFileMap, out)
+	} else {
+		for i, file := range g.allFiles {
+			out[i] = g.generateFile(file, genFileMap[file])
+		}
+	}
+	// The output is assembled in the order of the files, however they
+	// were generated.
 	i := 0
-	for _, file := range g.allFiles {
-		g.Reset()
-		g.generate(file)
-		if _, ok := genFileMap[file]; !ok {
+	for _, o := range out {
+		if o.err != nil {
+			g.Fail(o.err.Error(), string(o.src))
+		}
+		if o.name == "" {
 			continue
 		}
 		g.Response.File[i] = new(plugin.CodeGeneratorResponse_File)
-		g.Response.File[i].Name = proto.String(goFileName(*file.Name))
-		g.Response.File[i].Content = proto.String(g.String())
+		g.Response.File[i].Name = proto.String(o.name)
+		g.Response.File[i].Content = proto.String(string(o.src))
 		i++
 	}
+	for _, o := range out {
+		g.Response.File = append(g.Response.File, o.files...)
+	}
+}
+
+// AddFile adds a file other than Go source to the output of the file being
+// generated, e.g. a document describing its services; name is relative to
+// the output directory, like the names of the generated Go files.
+func (g *Generator) AddFile(name, content string) {
+	g.files = append(g.files, &plugin.CodeGeneratorResponse_File{
+		Name:    proto.String(name),
+		Content: proto.String(content),
+	})
+}
+
+// A ForkablePlugin can generate several files at once. Fork returns an
+// instance of the plugin for the generator g of another worker, sharing
+// what Init computed but none of the state of the file being generated.
+type ForkablePlugin interface {
+	Plugin
+	Fork(g *Generator) Plugin
+}
+
+// fileOutput is the output of the generation of a file.
+type fileOutput struct {
+	name  string                               // of the Go file, empty if it is not generated
+	src   []byte                               // reformatted, or as generated if err is set
+	err   error                                // from reformatting src
+	files []*plugin.CodeGeneratorResponse_File // see AddFile
+}
+
+// generateFile generates file and, if gen is set, returns its output.
+func (g *Generator) generateFile(file *FileDescriptor, gen bool) fileOutput {
+	g.Reset()
+	g.files = nil
+	g.generate(file)
+	if !gen {
+		return fileOutput{}
+	}
+	o := fileOutput{name: goFileName(*file.Name), files: g.files}
+	if o.src, o.err = format(g.Bytes()); o.err != nil {
+		o.src = append([]byte(nil), g.Bytes()...)
+	}
+	return o
+}
+
+// workers returns the number of files to generate at once, the workers
+// parameter or by default one per CPU.
+func (g *Generator) workers() int {
+	v, ok := g.Param["workers"]
+	if !ok {
+		return runtime.GOMAXPROCS(0)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		g.Fail("parameter workers must be a positive integer, not", strconv.Quote(v))
+	}
+	return n
+}
+
+// forkable reports whether all plugins are ForkablePlugins.
+func (g *Generator) forkable() bool {
+	for _, p := range g.plugins {
+		if _, ok := p.(ForkablePlugin); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fork returns a generator for another worker, with its own buffers and
+// plugins.
+func (g *Generator) fork() *Generator {
+	w := *g
+	w.Buffer = new(bytes.Buffer)
+	w.spare = new(bytes.Buffer)
+	w.file, w.usedPackages, w.imports, w.init, w.indent, w.files = nil, nil, nil, nil, "", nil
+	w.plugins = make([]Plugin, len(g.plugins))
+	for i, p := range g.plugins {
+		w.plugins[i] = p.(ForkablePlugin).Fork(&w)
+	}
+	return &w
+}
+
+// generateConcurrently generates the files with a pool of workers, each
+// with a fork of g, into out. A file waits for the files it imports
+// publicly, whose exported symbols it aliases; as they come first in
+// allFiles, the files being waited for are always being generated.
+func (g *Generator) generateConcurrently(workers int, genFileMap map[*FileDescriptor]bool, out []fileOutput) {
+	index := make(map[*FileDescriptor]int, len(g.allFiles))
+	done := make([]chan struct{}, len(g.allFiles))
+	for i, file := range g.allFiles {
+		index[file] = i
+		done[i] = make(chan struct{})
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < workers && n < len(g.allFiles); n++ {
+		wg.Add(1)
+		go func(w *Generator) {
+			defer wg.Done()
+			for i := range next {
+				file := g.allFiles[i]
+				for _, id := range file.imp {
+					<-done[index[g.FileOf(id.o.File())]]
+				}
+				out[i] = w.generateFile(file, genFileMap[file])
+				close(done[i])
+			}
+		}(g.fork())
+	}
+	for i := range g.allFiles {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// format reformats the generated Go source src.
+func format(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	ast, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("bad Go source code was generated: %v", err)
+	}
+	dedupImports(ast)
+	pruneImports(ast)
+	var out bytes.Buffer
+	err = (&printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}).Fprint(&out, fset, ast)
+	if err != nil {
+		return nil, fmt.Errorf("generated Go source code could not be reformatted: %v", err)
+	}
+	return out.Bytes(), nil
 }
 
 // Run all the plugins associated with the file.
 func (g *Generator) runPlugins(file *FileDescriptor) {
-	for _, p := range plugins {
+	for _, p := range g.plugins {
 		p.Generate(file)
 	}
 }
@@ -1028,18 +1206,17 @@ func (g *Generator) FileOf(fd *descriptor.FileDescriptorProto) *FileDescriptor {
 	return nil
 }
 
-// Fill the response protocol buffer with the generated output for all the files we're
-// supposed to generate.
+// Generate the output for file into the buffer, unformatted; see generateFile.
 func (g *Generator) generate(file *FileDescriptor) {
 	g.file = g.FileOf(file.FileDescriptorProto)
 	g.usedPackages = make(map[string]bool)
+	g.imports = nil
+	g.Grow(estimateSize(file))
 
 	for _, td := range g.file.imp {
 		g.generateImported(td)
 	}
-	for _, enum := range g.file.enum {
-		g.generateEnum(enum)
-	}
+	// The enums and messages are protoc-gen-go's, in the same package.
 	for _, desc := range g.file.desc {
 		// Don't generate virtual messages for maps.
 		if desc.GetOptions().GetMapEntry() {
@@ -1056,26 +1233,25 @@ func (g *Generator) generate(file *FileDescriptor) {
 	g.runPlugins(file)
 
 	// Generate header and imports last, though they appear first in the output.
+	// The buffers are swapped, so their memory is reused for the next file.
 	rem := g.Buffer
-	g.Buffer = new(bytes.Buffer)
+	g.Buffer = g.spare
+	g.Reset()
+	g.Grow(rem.Len() + 4096)
 	g.generateHeader()
 	g.generateImports()
 	g.Write(rem.Bytes())
+	g.spare = rem
+}
 
-	tmp := g.Buffer.Bytes()
-
-	// Reformat generated code.
-	fset := token.NewFileSet()
-	ast, err := parser.ParseFile(fset, "", g, parser.ParseComments)
-	if err != nil {
-		g.Fail("bad Go source code was generated:", err.Error(), string(tmp))
-		return
-	}
-	g.Reset()
-	err = (&printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}).Fprint(g, fset, ast)
-	if err != nil {
-		g.Fail("generated Go source code could not be reformatted:", err.Error())
+// estimateSize returns the approximate size of the output for file, to
+// allocate the buffer once even for services with hundreds of methods.
+func estimateSize(file *FileDescriptor) int {
+	n := 4096 + 2048*len(file.desc) + 512*len(file.enum)
+	for _, s := range file.Service {
+		n += 4096 * len(s.Method)
 	}
+	return n
 }
 
 // Generate the header, including package definition
@@ -1193,7 +1369,10 @@ func (g *Generator) generateImports() {
 	}
 	g.P()
 	// TODO: may need to worry about uniqueness across plugins
-	for _, p := range plugins {
+	// Before the imports of the plugins, which may be followed by other
+	// declarations.
+	g.generateRecordedImports()
+	for _, p := range g.plugins {
 		p.GenerateImports(g.file)
 		g.P()
 	}
@@ -1311,6 +1490,7 @@ func (g *Generator) generateEnum(enum *EnumDescriptor) {
 // The tag is a string like "varint,2,opt,name=fieldname,def=7" that
 // identifies details of the field for the protocol buffer marshaling and unmarshaling
 // code.  The fields are:
+//
 //	wire encoding
 //	protocol tag number
 //	opt,req,rep for optional, required, or repeated
@@ -1319,6 +1499,7 @@ func (g *Generator) generateEnum(enum *EnumDescriptor) {
 //	enum= the name of the enum type if it is an enum-typed field.
 //	proto3 if this field is in a proto3 message
 //	def= string representation of the default value, if any.
+//
 // The default value must be in a representation that can be used at run-time
 // to generate the default value. Thus bools become 0 and 1, for instance.
 func (g *Generator) goTag(message *Descriptor, field *descriptor.FieldDescriptorProto, wiretype string) string {
@@ -1845,9 +2026,6 @@ func (g *Generator) generateExtension(ext *ExtensionDescriptor) {
 }
 
 func (g *Generator) generateInitFunction() {
-	for _, enum := range g.file.enum {
-		g.generateEnumRegistration(enum)
-	}
 	for _, d := range g.file.desc {
 		for _, ext := range d.ext {
 			g.generateExtensionRegistration(ext)
@@ -0,0 +1,108 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// ManifestName is the name of the manifest file written with the manifest
+// parameter.
+const ManifestName = "goweb.manifest.json"
+
+// A Manifest records the SHA-256 of the content of every generated file,
+// by file name.
+type Manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+// NewManifest returns the manifest of files.
+func NewManifest(files []*plugin.CodeGeneratorResponse_File) *Manifest {
+	m := &Manifest{Files: map[string]string{}}
+	for _, f := range files {
+		sum := sha256.Sum256([]byte(f.GetContent()))
+		m.Files[f.GetName()] = hex.EncodeToString(sum[:])
+	}
+	return m
+}
+
+// Changed returns the files whose content differs from the one recorded
+// in m, or that m does not list.
+func (m *Manifest) Changed(files []*plugin.CodeGeneratorResponse_File) []*plugin.CodeGeneratorResponse_File {
+	now := NewManifest(files)
+	var changed []*plugin.CodeGeneratorResponse_File
+	for _, f := range files {
+		if m.Files[f.GetName()] != now.Files[f.GetName()] {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// ApplyManifest handles the manifest parameters once all files are
+// generated: with manifest, it adds ManifestName to the response; with
+// changed_only=<path of the previous manifest>, it also drops the files
+// that did not change since, which protoc then leaves untouched, so build
+// tools do not see them as modified.
+func (g *Generator) ApplyManifest() {
+	prev, changedOnly := g.Param["changed_only"]
+	if _, ok := g.Param["manifest"]; !ok && !changedOnly {
+		return
+	}
+	files := g.Response.File
+	if changedOnly {
+		var m Manifest
+		data, err := ioutil.ReadFile(prev)
+		if err == nil {
+			err = json.Unmarshal(data, &m)
+		}
+		// Without a previous manifest, e.g. on the first run, every file
+		// is written.
+		if err == nil {
+			g.Response.File = m.Changed(files)
+		}
+	}
+	data, err := json.MarshalIndent(NewManifest(files), "", "  ")
+	if err != nil {
+		g.Error(err, "encoding the manifest")
+	}
+	g.Response.File = append(g.Response.File, &plugin.CodeGeneratorResponse_File{
+		Name:    proto.String(ManifestName),
+		Content: proto.String(string(data) + "\n"),
+	})
+}
@@ -0,0 +1,93 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package generator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func file(name, content string) *plugin.CodeGeneratorResponse_File {
+	return &plugin.CodeGeneratorResponse_File{Name: proto.String(name), Content: proto.String(content)}
+}
+
+func names(files []*plugin.CodeGeneratorResponse_File) []string {
+	var s []string
+	for _, f := range files {
+		s = append(s, f.GetName())
+	}
+	return s
+}
+
+func TestManifestChangedOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prev := NewManifest([]*plugin.CodeGeneratorResponse_File{file("a.pb.go", "a"), file("b.pb.go", "b")})
+	data, _ := json.Marshal(prev)
+	path := filepath.Join(dir, ManifestName)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := New()
+	g.Param = map[string]string{"changed_only": path}
+	g.Response.File = []*plugin.CodeGeneratorResponse_File{file("a.pb.go", "a"), file("b.pb.go", "b2"), file("c.pb.go", "c")}
+	g.ApplyManifest()
+	if got := names(g.Response.File); len(got) != 3 || got[0] != "b.pb.go" || got[1] != "c.pb.go" || got[2] != ManifestName {
+		t.Fatalf("files = %v", got)
+	}
+	var m Manifest
+	if err := json.Unmarshal([]byte(g.Response.File[2].GetContent()), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 3 || m.Files["a.pb.go"] != prev.Files["a.pb.go"] || m.Files["b.pb.go"] == prev.Files["b.pb.go"] {
+		t.Errorf("manifest = %v", m.Files)
+	}
+
+	// Without a previous manifest every file is written.
+	g = New()
+	g.Param = map[string]string{"changed_only": filepath.Join(dir, "missing.json")}
+	g.Response.File = []*plugin.CodeGeneratorResponse_File{file("a.pb.go", "a")}
+	g.ApplyManifest()
+	if got := names(g.Response.File); len(got) != 2 {
+		t.Errorf("files = %v", got)
+	}
+}
@@ -54,3 +54,19 @@ func TestCamelCase(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterUniquePackageName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"func", "_func"},
+		{"func", "_func1"},
+		{"3d", "_3d"},
+		{"foo.bar", "foo_bar"},
+	}
+	for _, tc := range tests {
+		if got := RegisterUniquePackageName(tc.in, nil); got != tc.want {
+			t.Errorf("RegisterUniquePackageName(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
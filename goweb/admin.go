@@ -0,0 +1,104 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// AdminAuth authorizes the requests of the admin UIs of the admin
+// parameter, e.g. by checking that the session of the request has an
+// admin role. Its errors are answered as by WriteRequestError; while it is
+// unset, all requests are denied.
+var AdminAuth func(r *http.Request) error
+
+// An AdminResource is a resource that an admin UI lists and edits with the
+// standard methods of its service, see AdminHandler. The field names are
+// those of the JSON of the routes.
+type AdminResource struct {
+	Name          string          `json:"name"`                      // the message, e.g. "pkg.Book"
+	Key           string          `json:"key"`                       // the field naming a resource, also in the Get and Delete requests
+	Field         string          `json:"field,omitempty"`           // the field of the Create and Update requests holding the resource, "" if they are resources
+	Items         string          `json:"items"`                     // the field of the List responses holding the resources
+	PageToken     string          `json:"page_token,omitempty"`      // the page token field of the List requests, if paged
+	NextPageToken string          `json:"next_page_token,omitempty"` // the next page token field of the List responses, if paged
+	Schema        json.RawMessage `json:"schema"`                    // the JSON schema of the message, with those it refers to under components
+	List          *Route          `json:"list"`
+	Get           *Route          `json:"get"`
+	Create        *Route          `json:"create,omitempty"`
+	Update        *Route          `json:"update,omitempty"`
+	Delete        *Route          `json:"delete,omitempty"`
+}
+
+//go:embed admin.html
+var adminPage []byte
+
+// AdminHandler returns the handler of the admin UI of resources, for a mux
+// serving them below prefix: the page at <prefix>/_admin/ and the resources
+// at <prefix>/_admin/resources.json. The page lists, shows, creates, edits
+// and deletes the resources by calling their routes from the browser, so
+// the routes authorize the calls themselves; AdminAuth only guards the UI.
+func AdminHandler(prefix string, resources []AdminResource) http.Handler {
+	base := JoinPath(prefix, "_admin") + "/"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AdminAuth == nil {
+			WriteRequestError(w, r, Errorf(http.StatusForbidden, "PERMISSION_DENIED", "goweb.AdminAuth is not set"))
+			return
+		}
+		if err := AdminAuth(r); err != nil {
+			WriteRequestError(w, r, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		switch r.URL.Path {
+		case strings.TrimSuffix(base, "/"):
+			http.Redirect(w, r, base, http.StatusMovedPermanently)
+		case base:
+			h := w.Header()
+			h.Set("Content-Type", "text/html; charset=utf-8")
+			h.Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; frame-ancestors 'none'")
+			h.Set("X-Content-Type-Options", "nosniff")
+			w.Write(adminPage)
+		case base + "resources.json":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(struct {
+				Prefix    string          `json:"prefix"`
+				Resources []AdminResource `json:"resources"`
+			}{JoinPath(prefix, ""), resources})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	defer func(auth func(r *http.Request) error) { AdminAuth = auth }(AdminAuth)
+	get := &Route{Service: "pkg.Books", Method: "GetBook", Verb: "GET", Path: "v1/{name=books/*}"}
+	h := AdminHandler("/api", []AdminResource{{Name: "pkg.Book", Key: "name", Items: "books", Schema: []byte(`{"type":"object"}`), List: get, Get: get}})
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	AdminAuth = nil
+	if w := serve("/api/_admin/"); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "AdminAuth is not set") {
+		t.Errorf("unset AdminAuth: %d %s", w.Code, w.Body)
+	}
+	AdminAuth = func(r *http.Request) error { return errors.New("no") }
+	if w := serve("/api/_admin/resources.json"); w.Code != http.StatusInternalServerError {
+		t.Errorf("denied: %d %s", w.Code, w.Body)
+	}
+
+	AdminAuth = func(r *http.Request) error { return nil }
+	if w := serve("/api/_admin"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/_admin/" {
+		t.Errorf("redirect: %d %v", w.Code, w.Header())
+	}
+	w := serve("/api/_admin/")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "text/html; charset=utf-8" || !strings.Contains(w.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'") ||
+		!strings.Contains(w.Body.String(), `fetch("resources.json"`) {
+		t.Errorf("page: %d %v", w.Code, w.Header())
+	}
+	w = serve("/api/_admin/resources.json")
+	var out struct {
+		Prefix    string                   `json:"prefix"`
+		Resources []map[string]interface{} `json:"resources"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || out.Prefix != "/api/" || len(out.Resources) != 1 ||
+		out.Resources[0]["get"].(map[string]interface{})["path"] != "v1/{name=books/*}" || out.Resources[0]["create"] != nil {
+		t.Errorf("resources: %s %v", w.Body, err)
+	}
+	if w := serve("/api/_admin/x"); w.Code != http.StatusNotFound {
+		t.Errorf("other: %d", w.Code)
+	}
+}
@@ -0,0 +1,284 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// TypeURLPrefix is the prefix of the type URLs of the messages packed by
+// MarshalAny whose type was not registered with a prefix of its own.
+var TypeURLPrefix = "type.googleapis.com/"
+
+type anyType struct {
+	prefix string
+	typ    reflect.Type
+}
+
+var anyTypes = struct {
+	sync.RWMutex
+	m map[string]anyType
+}{m: map[string]anyType{}}
+
+// RegisterAnyTypes registers the types of msgs for packing into and
+// resolving from google.protobuf.Any values, with the type URL prefix
+// (TypeURLPrefix if empty). The generated code registers the messages of
+// every file that has services, with the prefix of the type_url_prefix
+// parameter; other types are resolved from the proto registry.
+func RegisterAnyTypes(prefix string, msgs ...proto.Message) {
+	anyTypes.Lock()
+	defer anyTypes.Unlock()
+	for _, m := range msgs {
+		anyTypes.m[proto.MessageName(m)] = anyType{prefix, reflect.TypeOf(m)}
+	}
+}
+
+// ResolveAny returns a new message of the type named by the last path
+// element of the type URL url, e.g. "type.googleapis.com/pkg.User".
+func ResolveAny(url string) (proto.Message, error) {
+	name := url[strings.LastIndex(url, "/")+1:]
+	anyTypes.RLock()
+	t := anyTypes.m[name].typ
+	anyTypes.RUnlock()
+	if t == nil {
+		t = proto.MessageType(name)
+	}
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("goweb: unknown type %q in Any", url)
+	}
+	m, ok := reflect.New(t.Elem()).Interface().(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("goweb: type %q in Any is not a message", url)
+	}
+	return m, nil
+}
+
+// MarshalAny packs m into an Any, with the type URL prefix of its type.
+func MarshalAny(m proto.Message) (*any.Any, error) {
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	name := proto.MessageName(m)
+	anyTypes.RLock()
+	prefix := anyTypes.m[name].prefix
+	anyTypes.RUnlock()
+	if prefix == "" {
+		prefix = TypeURLPrefix
+	}
+	return &any.Any{TypeUrl: strings.TrimSuffix(prefix, "/") + "/" + name, Value: value}, nil
+}
+
+// UnmarshalAny unpacks the message of a.
+func UnmarshalAny(a *any.Any) (proto.Message, error) {
+	m, err := ResolveAny(a.TypeUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(a.Value, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarshalAnyJSON encodes v like DeterministicJSON, but with the Any
+// values in it in the JSON mapping of protobuf: the fields of the packed
+// message next to its type URL as "@type",
+//
+//	{"@type": "type.googleapis.com/pkg.User", "id": "1"}
+//
+// instead of the "type_url" and base64 "value" of their Go structs.
+func MarshalAnyJSON(v interface{}) ([]byte, error) {
+	b, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(b, []byte(`"type_url"`)) {
+		return DeterministicJSON(json.RawMessage(b))
+	}
+	tree, err := decodeTree(b)
+	if err != nil {
+		return nil, err
+	}
+	if tree, err = unpackAnys(tree); err != nil {
+		return nil, err
+	}
+	return DeterministicJSON(tree)
+}
+
+// UnmarshalAnyJSON decodes data into v like UnmarshalJSON, reading the
+// Any values in it from the JSON mapping of protobuf (see MarshalAnyJSON).
+func UnmarshalAnyJSON(data []byte, v interface{}) error {
+	if !bytes.Contains(data, []byte(`"@type"`)) {
+		return UnmarshalJSON(data, v)
+	}
+	tree, err := decodeTree(data)
+	if err != nil {
+		return err
+	}
+	if tree, err = packAnys(tree); err != nil {
+		return err
+	}
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return UnmarshalJSON(b, v)
+}
+
+// marshalJSON is json.Marshal, but with the Any values in v in their
+// protobuf JSON mapping, see MarshalAnyJSON.
+func marshalJSON(v interface{}) ([]byte, error) {
+	b, err := MarshalJSON(v)
+	if err != nil || !bytes.Contains(b, []byte(`"type_url"`)) {
+		return b, err
+	}
+	b, err = MarshalAnyJSON(v)
+	return bytes.TrimSuffix(b, []byte("\n")), err
+}
+
+func decodeTree(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var tree interface{}
+	err := d.Decode(&tree)
+	return tree, err
+}
+
+// unpackAnys replaces the Go encoding of the Any values in tree by their
+// protobuf JSON mapping.
+func unpackAnys(tree interface{}) (interface{}, error) {
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		if url, ok := t["type_url"].(string); ok && len(t) <= 2 && (len(t) == 1 || t["value"] != nil) {
+			value, _ := t["value"].(string)
+			return unpackAny(url, value)
+		}
+		for k, v := range t {
+			v, err := unpackAnys(v)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = v
+		}
+	case []interface{}:
+		for i, v := range t {
+			v, err := unpackAnys(v)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = v
+		}
+	}
+	return tree, nil
+}
+
+func unpackAny(url, value string) (interface{}, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	m, err := UnmarshalAny(&any.Any{TypeUrl: url, Value: data})
+	if err != nil {
+		return nil, err
+	}
+	b, err := MarshalAnyJSON(m)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := decodeTree(b)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := tree.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("goweb: message %q in Any is not a JSON object", url)
+	}
+	fields["@type"] = url
+	return fields, nil
+}
+
+// packAnys replaces the protobuf JSON mapping of the Any values in tree
+// by their Go encoding.
+func packAnys(tree interface{}) (interface{}, error) {
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		if url, ok := t["@type"].(string); ok {
+			return packAny(url, t)
+		}
+		for k, v := range t {
+			v, err := packAnys(v)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = v
+		}
+	case []interface{}:
+		for i, v := range t {
+			v, err := packAnys(v)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = v
+		}
+	}
+	return tree, nil
+}
+
+func packAny(url string, fields map[string]interface{}) (interface{}, error) {
+	m, err := ResolveAny(url)
+	if err != nil {
+		return nil, err
+	}
+	delete(fields, "@type")
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalAnyJSON(b, m); err != nil {
+		return nil, err
+	}
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type_url": url, "value": value}, nil
+}
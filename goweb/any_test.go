@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+type anyHolder struct {
+	Item  *any.Any   `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Items []*any.Any `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (m *anyHolder) Reset()         { *m = anyHolder{} }
+func (m *anyHolder) String() string { return proto.CompactTextString(m) }
+func (*anyHolder) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*anyHolder)(nil), "goweb.AnyHolder")
+}
+
+func TestAnyJSON(t *testing.T) {
+	RegisterAnyTypes("type.example.com", (*CapturedCall)(nil))
+	call, err := MarshalAny(&CapturedCall{Method: "m"})
+	if err != nil || call.TypeUrl != "type.example.com/goweb.CapturedCall" {
+		t.Fatalf("MarshalAny = %v, %v", call, err)
+	}
+	inner, err := MarshalAny(&anyHolder{Item: call})
+	if err != nil || inner.TypeUrl != "type.googleapis.com/goweb.AnyHolder" {
+		t.Fatalf("MarshalAny = %v, %v", inner, err)
+	}
+	b, err := MarshalAnyJSON(&anyHolder{Item: call, Items: []*any.Any{inner}})
+	want := `{"item":{"@type":"type.example.com/goweb.CapturedCall","method":"m"},"items":[{"@type":"type.googleapis.com/goweb.AnyHolder","item":{"@type":"type.example.com/goweb.CapturedCall","method":"m"}}]}` + "\n"
+	if err != nil || string(b) != want {
+		t.Fatalf("MarshalAnyJSON = %s, %v", b, err)
+	}
+	var h anyHolder
+	if err := UnmarshalAnyJSON(b, &h); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := UnmarshalAny(h.Item); err != nil || m.(*CapturedCall).Method != "m" {
+		t.Errorf("item = %v, %v", m, err)
+	}
+	m, err := UnmarshalAny(h.Items[0])
+	if err != nil || !proto.Equal(m, &anyHolder{Item: call}) {
+		t.Errorf("items[0] = %v, %v", m, err)
+	}
+	if err := UnmarshalAnyJSON([]byte(`{"item":{"@type":"x/pkg.Unknown"}}`), &h); err == nil || !strings.Contains(err.Error(), "pkg.Unknown") {
+		t.Errorf("unknown type: %v", err)
+	}
+	if b, _ := MarshalAnyJSON(&CapturedCall{Method: "<m>"}); string(b) != `{"method":"<m>"}`+"\n" {
+		t.Errorf("MarshalAnyJSON without Any = %s", b)
+	}
+}
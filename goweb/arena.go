@@ -0,0 +1,112 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// An Arena hands out the messages of a call and recycles them once its
+// response is written, for the experimental arena parameter: handlers
+// generated with it decode the request into a message of the arena of
+// the call, which implementations get with ArenaFrom, e.g. for their
+// responses and the messages nested in them:
+//
+//	out := goweb.ArenaFrom(ctx).Get((*pb.User)(nil)).(*pb.User)
+//
+// Messages are reset and kept in a sync.Pool per type, which cuts the
+// allocations per call, and so the GC pauses, of services with large
+// messages. Nothing may keep a message of the arena, or a value of one of
+// its fields, after the call: not the implementation, nor interceptors
+// or sinks, and no goroutine started by them.
+type Arena struct {
+	msgs []proto.Message
+}
+
+var (
+	arenas     = sync.Pool{New: func() interface{} { return new(Arena) }}
+	arenaPools sync.Map // reflect.Type -> *sync.Pool
+)
+
+// NewArena returns an empty arena, to release with Release.
+func NewArena() *Arena { return arenas.Get().(*Arena) }
+
+// Get returns an empty message of the type of typ, which may be a nil
+// pointer, e.g. (*pb.User)(nil). A nil arena allocates a new message.
+func (a *Arena) Get(typ proto.Message) proto.Message {
+	t := reflect.TypeOf(typ)
+	if a == nil {
+		return reflect.New(t.Elem()).Interface().(proto.Message)
+	}
+	m := arenaPool(t).Get().(proto.Message)
+	a.msgs = append(a.msgs, m)
+	return m
+}
+
+// Release resets the messages of the arena and returns them, and the
+// arena, to their pools.
+func (a *Arena) Release() {
+	for i, m := range a.msgs {
+		m.Reset()
+		arenaPool(reflect.TypeOf(m)).Put(m)
+		a.msgs[i] = nil
+	}
+	a.msgs = a.msgs[:0]
+	arenas.Put(a)
+}
+
+func arenaPool(t reflect.Type) *sync.Pool {
+	if p, ok := arenaPools.Load(t); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := arenaPools.LoadOrStore(t, &sync.Pool{New: func() interface{} { return reflect.New(t.Elem()).Interface() }})
+	return p.(*sync.Pool)
+}
+
+type arenaKey struct{}
+
+// WithArena returns a copy of ctx carrying the arena a.
+func WithArena(ctx context.Context, a *Arena) context.Context {
+	return context.WithValue(ctx, arenaKey{}, a)
+}
+
+// ArenaFrom returns the arena of the call of ctx, or nil, whose Get
+// allocates as usual, if its method is not generated with the arena
+// parameter.
+func ArenaFrom(ctx context.Context) *Arena {
+	a, _ := ctx.Value(arenaKey{}).(*Arena)
+	return a
+}
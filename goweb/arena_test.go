@@ -0,0 +1,72 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"testing"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"golang.org/x/net/context"
+)
+
+func TestArena(t *testing.T) {
+	var none *Arena
+	if m := none.Get((*pb.DescriptorProto)(nil)); m == nil {
+		t.Fatalf("Get of a nil arena = nil")
+	}
+	if ArenaFrom(context.Background()) != nil {
+		t.Errorf("ArenaFrom without arena")
+	}
+	a := NewArena()
+	ctx := WithArena(context.Background(), a)
+	m := ArenaFrom(ctx).Get((*pb.DescriptorProto)(nil)).(*pb.DescriptorProto)
+	m.Name = new(string)
+	*m.Name = "used"
+	a.Release()
+	if m.Name != nil {
+		t.Errorf("Release did not reset the messages")
+	}
+	a = NewArena()
+	if m := a.Get((*pb.DescriptorProto)(nil)).(*pb.DescriptorProto); m.Name != nil {
+		t.Errorf("Get = %v, want an empty message", m)
+	}
+	a.Release()
+}
+
+func BenchmarkArena(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a := NewArena()
+		a.Get((*pb.DescriptorProto)(nil))
+		a.Release()
+	}
+}
@@ -0,0 +1,269 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// AuditRecord is a signed record of a call of an auditable method; see
+// audit.proto.
+type AuditRecord struct {
+	Sequence     uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Method       string `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Path         string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Principal    []byte `protobuf:"bytes,4,opt,name=principal,proto3" json:"principal,omitempty"`
+	RequestId    string `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ClientIp     string `protobuf:"bytes,6,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
+	Request      []byte `protobuf:"bytes,7,opt,name=request,proto3" json:"request,omitempty"`
+	Error        string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	TimeUnixNano int64  `protobuf:"varint,9,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	PrevHash     []byte `protobuf:"bytes,10,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	KeyId        string `protobuf:"bytes,11,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Signature    []byte `protobuf:"bytes,12,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *AuditRecord) Reset()         { *m = AuditRecord{} }
+func (m *AuditRecord) String() string { return proto.CompactTextString(m) }
+func (*AuditRecord) ProtoMessage()    {}
+
+// signed returns the encoding of m without its signature, which is
+// what the signature is over.
+func (m *AuditRecord) signed() ([]byte, error) {
+	c := *m
+	c.Signature = nil
+	return proto.Marshal(&c)
+}
+
+// An AuditKey signs audit records and verifies their signatures.
+type AuditKey interface {
+	ID() string
+	Sign(data []byte) ([]byte, error)
+	Verify(data, sig []byte) bool
+}
+
+// HMACAuditKey is an AuditKey signing with HMAC-SHA256 and the secret.
+type HMACAuditKey struct {
+	KeyID  string
+	Secret []byte
+}
+
+func (k HMACAuditKey) ID() string { return k.KeyID }
+
+func (k HMACAuditKey) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k.Secret)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (k HMACAuditKey) Verify(data, sig []byte) bool {
+	want, _ := k.Sign(data)
+	return hmac.Equal(want, sig)
+}
+
+// Ed25519AuditKey is an AuditKey signing with Ed25519, so that auditors
+// only need the public key to verify a log. Without a private key it
+// only verifies.
+type Ed25519AuditKey struct {
+	KeyID   string
+	Private ed25519.PrivateKey // nil for verification only
+	Public  ed25519.PublicKey  // from Private if nil
+}
+
+func (k Ed25519AuditKey) ID() string { return k.KeyID }
+
+func (k Ed25519AuditKey) Sign(data []byte) ([]byte, error) {
+	if k.Private == nil {
+		return nil, errors.New("goweb: audit key " + k.KeyID + " has no private key")
+	}
+	return ed25519.Sign(k.Private, data), nil
+}
+
+func (k Ed25519AuditKey) Verify(data, sig []byte) bool {
+	pub := k.Public
+	if pub == nil && k.Private != nil {
+		pub = k.Private.Public().(ed25519.PublicKey)
+	}
+	return pub != nil && ed25519.Verify(pub, data, sig)
+}
+
+// An AuditLog writes signed, chained audit records in the audit log
+// format to a writer, e.g. an append-only file or a WORM store.
+type AuditLog struct {
+	mu   sync.Mutex
+	w    io.Writer
+	key  AuditKey
+	seq  uint64
+	prev []byte
+}
+
+// NewAuditLog returns an AuditLog starting a new chain in w, signed with
+// key.
+func NewAuditLog(w io.Writer, key AuditKey) *AuditLog {
+	return &AuditLog{w: w, key: key}
+}
+
+// Continue makes l continue the chain of the record last, the last one of
+// an existing log that l appends to.
+func (l *AuditLog) Continue(last *AuditRecord) error {
+	b, err := proto.Marshal(last)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(b)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq, l.prev = last.Sequence, h[:]
+	return nil
+}
+
+// Write chains, signs and writes the record r, setting its Sequence,
+// PrevHash, KeyId and Signature.
+func (l *AuditLog) Write(r *AuditRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r.Sequence, r.PrevHash, r.KeyId = l.seq+1, l.prev, l.key.ID()
+	data, err := r.signed()
+	if err != nil {
+		return err
+	}
+	if r.Signature, err = l.key.Sign(data); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(r)
+	if err != nil {
+		return err
+	}
+	buf := proto.NewBuffer(nil)
+	buf.EncodeRawBytes(b)
+	if _, err := l.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	h := sha256.Sum256(b)
+	l.seq, l.prev = r.Sequence, h[:]
+	return nil
+}
+
+// Audit is the log of the calls of auditable methods. While it is nil,
+// such calls fail.
+var Audit *AuditLog
+
+// AuditCall writes the record of the call of the method of route with the
+// request in and the error err to Audit. Generated handlers of auditable
+// methods call it before answering, and answer calls they fail to audit
+// with 500, so no success is reported for a call missing from the log.
+func AuditCall(ctx context.Context, route Route, in proto.Message, err error) error {
+	if Audit == nil {
+		return Errorf(500, "INTERNAL", "audit log is not set")
+	}
+	request, merr := json.Marshal(in)
+	if merr != nil {
+		return merr
+	}
+	r := &AuditRecord{
+		Method:       route.FullMethod(),
+		Path:         route.Path,
+		RequestId:    RequestID(ctx),
+		ClientIp:     ClientIP(ctx),
+		Request:      request,
+		TimeUnixNano: time.Now().UnixNano(),
+	}
+	if p := PrincipalFrom(ctx); p != nil {
+		r.Principal, _ = json.Marshal(p)
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if werr := Audit.Write(r); werr != nil {
+		return Errorf(500, "INTERNAL", "audit log: %v", werr)
+	}
+	return nil
+}
+
+// ReadAuditLog reads all records in the audit log format from r.
+func ReadAuditLog(r io.Reader) ([]*AuditRecord, error) {
+	var records []*AuditRecord
+	err := readDelimited(r, func(b []byte) error {
+		m := new(AuditRecord)
+		if err := proto.Unmarshal(b, m); err != nil {
+			return err
+		}
+		records = append(records, m)
+		return nil
+	})
+	return records, err
+}
+
+// VerifyAuditLog checks that the records, the whole log or the part of
+// it from its first record, form an unbroken chain signed with the keys
+// of their key ids.
+func VerifyAuditLog(records []*AuditRecord, keys map[string]AuditKey) error {
+	var prev []byte
+	for i, r := range records {
+		if r.Sequence != uint64(i+1) {
+			return fmt.Errorf("goweb: audit record %d has sequence %d", i+1, r.Sequence)
+		}
+		if !bytes.Equal(r.PrevHash, prev) {
+			return fmt.Errorf("goweb: audit record %d does not follow its predecessor", r.Sequence)
+		}
+		key := keys[r.KeyId]
+		if key == nil {
+			return fmt.Errorf("goweb: audit record %d: unknown key %q", r.Sequence, r.KeyId)
+		}
+		data, err := r.signed()
+		if err != nil {
+			return err
+		}
+		if !key.Verify(data, r.Signature) {
+			return fmt.Errorf("goweb: audit record %d: invalid signature", r.Sequence)
+		}
+		b, err := proto.Marshal(r)
+		if err != nil {
+			return err
+		}
+		h := sha256.Sum256(b)
+		prev = h[:]
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestAuditLog(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	hmacKey := HMACAuditKey{KeyID: "h1", Secret: []byte("secret")}
+	keys := map[string]AuditKey{"h1": hmacKey, "e1": Ed25519AuditKey{KeyID: "e1", Public: pub}}
+
+	var buf bytes.Buffer
+	defer func(a *AuditLog) { Audit = a }(Audit)
+	Audit = nil
+	route := Route{Service: "pkg.Users", Method: "Delete", Path: "users/delete"}
+	if err := AuditCall(context.Background(), route, &CapturedCall{}, nil); err == nil {
+		t.Errorf("AuditCall without Audit succeeded")
+	}
+	Audit = NewAuditLog(&buf, hmacKey)
+	ctx := WithRequestID(WithPrincipal(context.Background(), map[string]string{"sub": "jane"}), "r1")
+	if err := AuditCall(ctx, route, &CapturedCall{Method: "x"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := AuditCall(ctx, route, &CapturedCall{}, errors.New("denied")); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadAuditLog(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(records) != 2 {
+		t.Fatalf("ReadAuditLog = %v, %v", records, err)
+	}
+	r := records[0]
+	if r.Method != "/pkg.Users/Delete" || string(r.Principal) != `{"sub":"jane"}` || r.RequestId != "r1" || string(r.Request) != `{"method":"x"}` || records[1].Error != "denied" {
+		t.Errorf("records = %v", records)
+	}
+
+	// Continue the log with another key.
+	Audit = NewAuditLog(&buf, Ed25519AuditKey{KeyID: "e1", Private: priv})
+	if err := Audit.Continue(records[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := AuditCall(ctx, route, &CapturedCall{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if records, err = ReadAuditLog(bytes.NewReader(buf.Bytes())); err != nil || len(records) != 3 {
+		t.Fatalf("ReadAuditLog = %v, %v", records, err)
+	}
+	if err := VerifyAuditLog(records, keys); err != nil {
+		t.Errorf("VerifyAuditLog: %v", err)
+	}
+
+	records[1].Error = ""
+	if err := VerifyAuditLog(records, keys); err == nil {
+		t.Errorf("VerifyAuditLog accepted a changed record")
+	}
+	records[1].Error = "denied"
+	if err := VerifyAuditLog(append(records[:1:1], records[2:]...), keys); err == nil {
+		t.Errorf("VerifyAuditLog accepted a removed record")
+	}
+	if err := VerifyAuditLog(records, map[string]AuditKey{"h1": hmacKey}); err == nil {
+		t.Errorf("VerifyAuditLog accepted an unknown key")
+	}
+}
@@ -0,0 +1,104 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ProtobufContentType is the media type of messages in the binary wire
+// format of protobuf, which handlers generated with the protobuf parameter
+// accept and answer besides JSON.
+const ProtobufContentType = "application/x-protobuf"
+
+// IsProtobuf reports whether the media type contentType, e.g. the
+// Content-Type of a request, is the binary wire format of protobuf:
+// application/x-protobuf, application/protobuf or
+// application/vnd.google.protobuf.
+func IsProtobuf(contentType string) bool {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch typ {
+	case ProtobufContentType, "application/protobuf", "application/vnd.google.protobuf":
+		return true
+	}
+	return false
+}
+
+// AcceptsProtobuf reports whether the request prefers a response in the
+// binary wire format of protobuf: of the media types of its Accept header,
+// the one with the highest quality (the first of them on a tie) is a
+// protobuf type. Requests without an Accept header get JSON.
+func AcceptsProtobuf(r *http.Request) bool {
+	return IsProtobuf(preferredType(r))
+}
+
+// preferredType returns the media type of the Accept header of r with the
+// highest quality, the first of them on a tie.
+func preferredType(r *http.Request) string {
+	best, bestQ := "", 0.0
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		typ, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = typ, q
+		}
+	}
+	return best
+}
+
+// WriteProtobuf writes the message m as the response, in the binary wire
+// format of protobuf.
+func WriteProtobuf(w http.ResponseWriter, m proto.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ProtobufContentType)
+	w.Write(b)
+	return nil
+}
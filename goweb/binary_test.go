@@ -0,0 +1,88 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestAcceptsProtobuf(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                          false,
+		"application/json":          false,
+		"application/x-protobuf":    true,
+		"application/protobuf, */*": true,
+		"application/json, application/x-protobuf":        false,
+		"application/json;q=0.5, application/x-protobuf":  true,
+		"application/x-protobuf;q=0, application/json":    false,
+		"application/vnd.google.protobuf; proto=pkg.User": true,
+	} {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set("Accept", accept)
+		if got := AcceptsProtobuf(r); got != want {
+			t.Errorf("AcceptsProtobuf(%q) = %v", accept, got)
+		}
+	}
+}
+
+func TestUpstreamProtobuf(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var in pb.FieldDescriptorProto
+		if !IsProtobuf(r.Header.Get("Content-Type")) || proto.Unmarshal(body, &in) != nil {
+			w.WriteHeader(415)
+			return
+		}
+		in.Name = proto.String(in.GetName() + "!")
+		if AcceptsProtobuf(r) {
+			WriteProtobuf(w, &in)
+			return
+		}
+		w.Write([]byte(`{"name":"json"}`))
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, Protobuf: true}
+	var out pb.FieldDescriptorProto
+	if err := u.Call(context.Background(), Route{Path: "echo"}, &pb.FieldDescriptorProto{Name: proto.String("a")}, &out); err != nil || out.GetName() != "a!" {
+		t.Errorf("Call = %v, %v", &out, err)
+	}
+	if err := u.Call(context.Background(), Route{Path: "echo"}, struct{}{}, &out); err == nil {
+		t.Errorf("Call with a non-message did not fail")
+	}
+}
@@ -0,0 +1,327 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// BindPath sets the fields of the request m named by the field paths of
+// the variables of a path template, e.g. "user_id" or "book.name", to
+// their values in params, converted to the types of the fields. Generated
+// handlers of methods with google.api.http annotations bind the URLParams
+// of their context with it, see TemplatePattern.
+func BindPath(m interface{}, params map[string]string) error {
+	for _, field := range sortedKeys(params) {
+		if err := bindField(reflect.ValueOf(m), field, []string{params[field]}); err != nil {
+			return fmt.Errorf("goweb: path variable %s: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// BindQuery sets the fields of the request m named by the parameters of
+// the query q, e.g. "?page_size=10&filter.tags=a&filter.tags=b", as
+// google.api.http annotations have it for the fields not bound by the path
+// or the body: repeated parameters become repeated fields. The parameters
+// of unknown fields, and of the fields in skip or nested in them (the body
+// field and the path variables), are ignored.
+func BindQuery(m interface{}, q map[string][]string, skip ...string) error {
+	for _, field := range sortedKeys(q) {
+		skipped := false
+		for _, s := range skip {
+			if field == s || strings.HasPrefix(field, s+".") {
+				skipped = true
+			}
+		}
+		if skipped {
+			continue
+		}
+		err := bindField(reflect.ValueOf(m), field, q[field])
+		if err == errNoField {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("goweb: query parameter %s: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// QueryFlag returns the value of the bool query parameter name of q, e.g.
+// "?validate_only=true" or just "?validate_only", and whether it is set.
+func QueryFlag(q map[string][]string, name string) (v, ok bool, err error) {
+	values, ok := q[name]
+	if !ok || len(values) == 0 {
+		return false, false, nil
+	}
+	if values[0] == "" {
+		return true, true, nil
+	}
+	if v, err = strconv.ParseBool(values[0]); err != nil {
+		return false, false, fmt.Errorf("goweb: query parameter %s: %v", name, err)
+	}
+	return v, true, nil
+}
+
+// WrapBody returns the JSON object with the request body as the field,
+// so that the body selector of a google.api.http annotation naming a field
+// decodes like a whole request.
+func WrapBody(field string, body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return []byte("{}")
+	}
+	return append(append([]byte(`{"`+field+`":`), body...), '}')
+}
+
+// templateRequest returns the verb, the URL and the body of the request of
+// route, whose path is a template, with the JSON request body: the path
+// variables are expanded in the URL target, the fields of the body
+// selector are sent as the body and the others as query parameters, the
+// way TemplatePattern, BindPath and BindQuery take them. A target not
+// ending with the path of route, as rewritten by Upstream.Rewrite, is
+// kept.
+func templateRequest(route Route, target string, body []byte) (string, []byte, error) {
+	t, err := route.Template()
+	if err != nil {
+		return "", nil, err
+	}
+	tree, err := decodeTree(body)
+	obj, ok := tree.(map[string]interface{})
+	if err != nil || !ok {
+		return "", nil, fmt.Errorf("goweb: request of %s is not a JSON object", route.FullMethod())
+	}
+	vars := map[string]string{}
+	for _, field := range t.Fields() {
+		v := lookupTree(obj, field)
+		if v == nil || reflect.TypeOf(v).Kind() == reflect.Map || reflect.TypeOf(v).Kind() == reflect.Slice {
+			return "", nil, fmt.Errorf("goweb: request of %s has no value for the path variable %s", route.FullMethod(), field)
+		}
+		vars[field] = fmt.Sprint(v)
+	}
+	path, err := t.Expand(vars)
+	if err != nil {
+		return "", nil, err
+	}
+	if strings.HasSuffix(target, route.Path) {
+		target = strings.TrimSuffix(target, route.Path) + path
+	}
+	switch route.Body {
+	case "*":
+		return target, body, nil
+	case "":
+		body = nil
+	default:
+		if body, err = json.Marshal(obj[route.Body]); err != nil {
+			return "", nil, err
+		}
+		delete(obj, route.Body)
+	}
+	q := url.Values{}
+	queryValues(q, "", obj, t.Fields())
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	return target, body, nil
+}
+
+// lookupTree returns the value at the dotted path in the JSON object obj.
+func lookupTree(obj map[string]interface{}, path string) interface{} {
+	var v interface{} = obj
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// queryValues adds the scalars of the JSON tree v at path to q, but not
+// the fields skip.
+func queryValues(q url.Values, path string, v interface{}, skip []string) {
+	for _, s := range skip {
+		if path == s {
+			return
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, f := range v {
+			if path != "" {
+				k = path + "." + k
+			}
+			queryValues(q, k, f, skip)
+		}
+	case []interface{}:
+		for _, e := range v {
+			if e != nil && reflect.TypeOf(e).Kind() != reflect.Map && reflect.TypeOf(e).Kind() != reflect.Slice {
+				q.Add(path, fmt.Sprint(e))
+			}
+		}
+	default:
+		q.Add(path, fmt.Sprint(v))
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var errNoField = fmt.Errorf("no such field")
+
+// bindField sets the field at the dotted path of proto field names in the
+// message v to values.
+func bindField(v reflect.Value, path string, values []string) error {
+	var tag reflect.StructTag
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return errNoField
+		}
+		i, ok := fieldByName(v.Type(), name)
+		if !ok {
+			return errNoField
+		}
+		v, tag = v.Field(i), v.Type().Field(i).Tag
+	}
+	enum := enumOf(tag, "protobuf")
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(s.Index(i), enum, value); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("given %d times for a singular field", len(values))
+	}
+	return setValue(v, enum, values[0])
+}
+
+// setValue sets the scalar v, of the enum if not "", to s. Wrapper
+// messages, such as google.protobuf.StringValue, are set to their value.
+func setValue(v reflect.Value, enum, s string) error {
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	case reflect.Int32, reflect.Int64:
+		if n, ok := proto.EnumValueMap(enum)[s]; ok && enum != "" {
+			v.SetInt(int64(n))
+			return nil
+		}
+		var n int64
+		n, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+	case reflect.Uint32, reflect.Uint64:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+	case reflect.Slice:
+		var b []byte
+		if b, err = base64.StdEncoding.DecodeString(s); err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		v.SetBytes(b)
+	case reflect.Ptr:
+		w := reflect.New(v.Type().Elem())
+		fields := map[string]int{}
+		if w.Elem().Kind() == reflect.Struct {
+			fields = jsonFields(w.Elem().Type())
+		}
+		i, ok := fields["value"]
+		if !ok || len(fields) != 1 {
+			return fmt.Errorf("a %s cannot be given as a string", v.Type().Elem())
+		}
+		if err := setValue(w.Elem().Field(i), "", s); err != nil {
+			return err
+		}
+		v.Set(w)
+	default:
+		return fmt.Errorf("a %s cannot be given as a string", v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("bad value %q", s)
+	}
+	return nil
+}
+
+// fieldByName returns the index of the field of the generated struct t
+// with the proto or JSON name name.
+func fieldByName(t reflect.Type, name string) (int, bool) {
+	if i, ok := jsonFields(t)[name]; ok {
+		return i, true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		for _, opt := range strings.Split(t.Field(i).Tag.Get("protobuf"), ",") {
+			if opt == "json="+name {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
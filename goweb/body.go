@@ -0,0 +1,266 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxBodyBytes limits the request bodies of the muxes without
+// WithMaxBodyBytes.
+const DefaultMaxBodyBytes = 4 << 20
+
+// WithMaxBodyBytes limits the request bodies of the mux to n bytes, also
+// after decompression; larger ones are answered with 413. A negative n
+// removes the limit.
+func WithMaxBodyBytes(n int64) ServerOption {
+	return func(o *ServerOptions) { o.MaxBodyBytes = n }
+}
+
+// WithGzip compresses the responses of at least minBytes bytes with gzip
+// for the clients accepting it.
+func WithGzip(minBytes int) ServerOption {
+	return func(o *ServerOptions) { o.GzipMinBytes = minBytes }
+}
+
+// maxBody returns the limit of the request bodies, or 0 for none.
+func (o ServerOptions) maxBody() int64 {
+	switch {
+	case o.MaxBodyBytes == 0:
+		return DefaultMaxBodyBytes
+	case o.MaxBodyBytes < 0:
+		return 0
+	}
+	return o.MaxBodyBytes
+}
+
+// buffers are the buffers of request and response bodies.
+var buffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity above which buffers are not pooled, so
+// that one large body does not pin its memory.
+const maxPooledBuffer = 1 << 20
+
+func getBuffer() *bytes.Buffer { return buffers.Get().(*bytes.Buffer) }
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		buf.Reset()
+		buffers.Put(buf)
+	}
+}
+
+// body returns the decompressed body of r, failing with an
+// *http.MaxBytesError beyond the limit of o.
+func (o ServerOptions) body(w http.ResponseWriter, r *http.Request) (io.Reader, error) {
+	max := o.maxBody()
+	var body io.Reader = r.Body
+	if max > 0 {
+		body = http.MaxBytesReader(w, r.Body, max)
+	}
+	decoded, err := decodeBody(r.Header.Get("Content-Encoding"), body)
+	if err != nil || max == 0 || decoded == body {
+		return decoded, err
+	}
+	return &maxReader{r: decoded, n: max, limit: max}, nil
+}
+
+// maxReader limits a decompressed body like http.MaxBytesReader.
+type maxReader struct {
+	r     io.Reader
+	n     int64 // bytes left
+	limit int64
+}
+
+func (l *maxReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) <= l.n {
+		l.n -= int64(n)
+		return n, err
+	}
+	n, l.n = int(l.n), 0
+	return n, &http.MaxBytesError{Limit: l.limit}
+}
+
+// maxPreallocated bounds the space reserved for a request body after its
+// Content-Length, which the client chooses; larger bodies grow the
+// buffer as they are read.
+const maxPreallocated = 64 << 10
+
+// ReadPooledBody reads the body of r, within the limit of o, into a
+// pooled buffer. The content is only valid until release is called,
+// which returns the buffer to the pool; release is never nil. Generated
+// handlers read the requests they decode with it.
+func (o ServerOptions) ReadPooledBody(w http.ResponseWriter, r *http.Request) (content []byte, release func(), err error) {
+	body, err := o.body(w, r)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	buf := getBuffer()
+	if n := r.ContentLength; n > 0 {
+		if n > maxPreallocated {
+			n = maxPreallocated
+		}
+		buf.Grow(int(n) + bytes.MinRead)
+	}
+	_, err = buf.ReadFrom(body)
+	return buf.Bytes(), func() { putBuffer(buf) }, err
+}
+
+// ReadBody is like ReadPooledBody, but returns a copy of the body, which
+// stays valid.
+func (o ServerOptions) ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	content, release, err := o.ReadPooledBody(w, r)
+	defer release()
+	return append([]byte(nil), content...), err
+}
+
+// errTrailingData reports a request body with more than one JSON value.
+var errTrailingData = errors.New("goweb: data after the JSON value of the request body")
+
+// DecodeBody decodes the JSON body of r into v with encoding/json while
+// it is read, within the limit of o. Errors reading the body are returned
+// as *Error with the status of BodyStatus, those of the JSON as they are.
+func (o ServerOptions) DecodeBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	body, err := o.body(w, r)
+	if err != nil {
+		return bodyError(err)
+	}
+	rd := &recordingReader{r: body}
+	dec := json.NewDecoder(rd)
+	err = dec.Decode(v)
+	switch {
+	case rd.err != nil:
+		return bodyError(rd.err)
+	case err == io.EOF:
+		return io.ErrUnexpectedEOF
+	case err != nil:
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if rd.err != nil {
+			return bodyError(rd.err)
+		}
+		return errTrailingData
+	}
+	return nil
+}
+
+// recordingReader records the error of its reader other than io.EOF.
+type recordingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// bodyError returns the *Error answering a request whose body could not
+// be read with err, see BodyStatus.
+func bodyError(err error) *Error {
+	e := &Error{Status: BodyStatus(err), Message: err.Error()}
+	e.Code = GRPCCodeName(GRPCCode(e))
+	return e
+}
+
+// acceptsGzip reports whether r accepts gzip responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params := enc, ""
+		if i := strings.IndexByte(enc, ';'); i >= 0 {
+			name, params = enc[:i], enc[i+1:]
+		}
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		if q := strings.TrimPrefix(params, "q="); q != params {
+			f, err := strconv.ParseFloat(q, 64)
+			return err == nil && f > 0
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriters are the writers of WriteBody.
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// WriteBody writes the response body out, compressed with gzip if o
+// compresses responses (WithGzip), out is large enough and r accepts it.
+func (o ServerOptions) WriteBody(w http.ResponseWriter, r *http.Request, out []byte) {
+	if o.GzipMinBytes <= 0 {
+		w.Write(out)
+		return
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	if len(out) < o.GzipMinBytes || !acceptsGzip(r) || w.Header().Get("Content-Encoding") != "" {
+		w.Write(out)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	zw := gzipWriters.Get().(*gzip.Writer)
+	zw.Reset(w)
+	zw.Write(out)
+	zw.Close()
+	gzipWriters.Put(zw)
+}
+
+// WriteJSON writes v as JSON and a newline, like json.Encoder, encoded in
+// a pooled buffer and written with WriteBody. It writes nothing if v
+// cannot be encoded.
+func (o ServerOptions) WriteJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	o.WriteBody(w, r, buf.Bytes())
+	return nil
+}
@@ -0,0 +1,158 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadBodyLimit(t *testing.T) {
+	opts := NewServerOptions(WithMaxBodyBytes(40))
+	for _, c := range []struct {
+		body     []byte
+		encoding string
+		status   int
+	}{
+		{[]byte(`{"a":1}`), "", 0},
+		{[]byte(`{"a":` + strings.Repeat("1", 40) + `}`), "", 413},
+		{gzipBytes([]byte(`{"a":1}`)), "gzip", 0},
+		{gzipBytes(bytes.Repeat([]byte(" "), 1000)), "gzip", 413},
+		{append(gzipBytes([]byte(`{"a":1}`)), bytes.Repeat([]byte{0}, 40)...), "gzip", 413},
+	} {
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(c.body))
+		r.Header.Set("Content-Encoding", c.encoding)
+		content, err := opts.ReadBody(httptest.NewRecorder(), r)
+		if c.status == 0 && (err != nil || string(content) != `{"a":1}`) || c.status != 0 && (err == nil || BodyStatus(err) != c.status) {
+			t.Errorf("ReadBody(%q) = %q, %v", c.body, content, err)
+		}
+	}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat(" ", DefaultMaxBodyBytes+1)))
+	if _, err := (ServerOptions{}).ReadBody(httptest.NewRecorder(), r); BodyStatus(err) != 413 {
+		t.Errorf("default limit: %v", err)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat(" ", DefaultMaxBodyBytes+1)))
+	if _, err := NewServerOptions(WithMaxBodyBytes(-1)).ReadBody(httptest.NewRecorder(), r); err != nil {
+		t.Errorf("no limit: %v", err)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	opts := NewServerOptions(WithMaxBodyBytes(32))
+	for body, want := range map[string]string{
+		`{"method":"a"}`:          "",
+		` {"method":"a"} ` + "\n": "",
+		`{"method":"a"} {}`:       errTrailingData.Error(),
+		``:                        "unexpected EOF",
+		`{"method":`:              "unexpected EOF",
+		`{"method":1}`:            "json: cannot unmarshal number",
+		`{"method":"` + strings.Repeat("a", 40) + `"}`: "request body too large",
+	} {
+		var in CapturedCall
+		err := opts.DecodeBody(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)), &in)
+		if want == "" && (err != nil || in.Method != "a") || want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("DecodeBody(%q) = %v, want %q", body, err, want)
+		}
+	}
+	err := opts.DecodeBody(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat(" ", 40))), new(CapturedCall))
+	if e, ok := err.(*Error); !ok || e.Status != 413 {
+		t.Errorf("DecodeBody of a large body = %#v", err)
+	}
+}
+
+func TestWriteBodyGzip(t *testing.T) {
+	opts := NewServerOptions(WithGzip(10))
+	out := []byte(strings.Repeat("x", 100))
+	for _, c := range []struct {
+		accept string
+		out    []byte
+		gzip   bool
+	}{
+		{"gzip, deflate", out, true},
+		{"br;q=1.0, gzip;q=0.5", out, true},
+		{"gzip;q=0", out, false},
+		{"", out, false},
+		{"gzip", out[:5], false},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", c.accept)
+		w := httptest.NewRecorder()
+		opts.WriteBody(w, r, c.out)
+		body := w.Body.Bytes()
+		if c.gzip {
+			if w.Header().Get("Content-Encoding") != "gzip" {
+				t.Errorf("%q: not compressed", c.accept)
+				continue
+			}
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ = ioutil.ReadAll(zr)
+		}
+		if !bytes.Equal(body, c.out) || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%q: body %q, header %v", c.accept, body, w.Header())
+		}
+	}
+	w := httptest.NewRecorder()
+	if err := (ServerOptions{}).WriteJSON(w, httptest.NewRequest("GET", "/", nil), map[string]int{"a": 1}); err != nil || w.Body.String() != "{\"a\":1}\n" || w.Header().Get("Vary") != "" {
+		t.Errorf("WriteJSON = %q, %v", w.Body, err)
+	}
+	if err := (ServerOptions{}).WriteJSON(w, httptest.NewRequest("GET", "/", nil), func() {}); err == nil {
+		t.Error("WriteJSON of a func succeeds")
+	}
+}
+
+func TestReadPooledBody(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1}`))
+	// a Content-Length far beyond the body does not reserve its size
+	r.ContentLength = 1 << 30
+	content, release, err := NewServerOptions(WithMaxBodyBytes(-1)).ReadPooledBody(httptest.NewRecorder(), r)
+	if string(content) != `{"a":1}` || err != nil {
+		t.Errorf("ReadPooledBody = %q, %v", content, err)
+	}
+	if cap(content) > 2*maxPooledBuffer {
+		t.Errorf("reserved %d bytes", cap(content))
+	}
+	release()
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 100)))
+	_, release, err = NewServerOptions(WithMaxBodyBytes(10)).ReadPooledBody(httptest.NewRecorder(), r)
+	if BodyStatus(err) != 413 || release == nil {
+		t.Errorf("over the limit: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,146 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// CapturedCall is a recorded call of a unary method; see capture.proto.
+type CapturedCall struct {
+	Method        string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Request       []byte `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	Response      []byte `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	TimeUnixNano  int64  `protobuf:"varint,6,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	DurationNanos int64  `protobuf:"varint,7,opt,name=duration_nanos,json=durationNanos,proto3" json:"duration_nanos,omitempty"`
+}
+
+func (m *CapturedCall) Reset()         { *m = CapturedCall{} }
+func (m *CapturedCall) String() string { return proto.CompactTextString(m) }
+func (*CapturedCall) ProtoMessage()    {}
+
+// A CaptureWriter writes calls in the capture format. It is a SampleSink,
+// so a Sampler can record production traffic for later replay.
+type CaptureWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewCaptureWriter returns a CaptureWriter writing to w.
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	return &CaptureWriter{w: w}
+}
+
+// Write writes the call c.
+func (cw *CaptureWriter) Write(c *CapturedCall) error {
+	buf := proto.NewBuffer(nil)
+	if err := buf.EncodeMessage(c); err != nil {
+		return err
+	}
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	_, err := cw.w.Write(buf.Bytes())
+	return err
+}
+
+// Store writes the sample s; write errors are dropped.
+func (cw *CaptureWriter) Store(ctx context.Context, s *Sample) {
+	cw.Write(&CapturedCall{
+		Method:        s.Route.FullMethod(),
+		Path:          s.Route.Path,
+		Request:       s.Request,
+		Response:      s.Response,
+		Error:         s.Error,
+		TimeUnixNano:  s.Time.UnixNano(),
+		DurationNanos: int64(s.Duration),
+	})
+}
+
+// ReadCapture reads all calls in the capture format from r.
+func ReadCapture(r io.Reader) ([]*CapturedCall, error) {
+	var calls []*CapturedCall
+	err := readDelimited(r, func(b []byte) error {
+		c := new(CapturedCall)
+		if err := proto.Unmarshal(b, c); err != nil {
+			return err
+		}
+		calls = append(calls, c)
+		return nil
+	})
+	return calls, err
+}
+
+// readDelimited calls f with each message of r, a stream of messages
+// preceded by their length as a varint.
+func readDelimited(r io.Reader, f func(b []byte) error) error {
+	br := bufio.NewReader(r)
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return err
+		}
+		if err := f(b); err != nil {
+			return err
+		}
+	}
+}
+
+// Replay posts the request of every call to h, at its path below prefix,
+// and passes the status and body of each response to check, which can
+// compare them with the recorded response.
+func Replay(h http.Handler, prefix string, calls []*CapturedCall, check func(c *CapturedCall, status int, body []byte)) {
+	for _, c := range calls {
+		req := httptest.NewRequest("POST", JoinPath(prefix, c.Path), bytes.NewReader(c.Request))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		check(c, rec.Code, rec.Body.Bytes())
+	}
+}
@@ -0,0 +1,77 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestCaptureReplay(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewCaptureWriter(&buf)
+	route := Route{Service: "pkg.Users", Method: "Get", Path: "users/get"}
+	for _, req := range []string{`{"id":"1"}`, `{"id":"2"}`} {
+		cw.Store(context.Background(), &Sample{Route: route, Time: time.Now(), Request: []byte(req), Response: []byte(req)})
+	}
+	calls, err := ReadCapture(&buf)
+	if err != nil || len(calls) != 2 {
+		t.Fatalf("ReadCapture = %v, %v", calls, err)
+	}
+	if calls[1].Method != "/pkg.Users/Get" || string(calls[1].Request) != `{"id":"2"}` {
+		t.Errorf("call = %v", calls[1])
+	}
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users/get" {
+			w.WriteHeader(404)
+			return
+		}
+		buf.ReadFrom(r.Body)
+		w.Write(buf.Bytes())
+		buf.Reset()
+	})
+	n := 0
+	Replay(echo, "/api", calls, func(c *CapturedCall, status int, body []byte) {
+		n++
+		if status != 200 || !bytes.Equal(body, c.Response) {
+			t.Errorf("replay of %s: %d %s", c.Request, status, body)
+		}
+	})
+	if n != 2 {
+		t.Errorf("replayed %d calls, want 2", n)
+	}
+}
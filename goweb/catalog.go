@@ -0,0 +1,97 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A Catalog holds the localized message templates of error codes.
+type Catalog interface {
+	// Message returns the template of the message of the error code in
+	// locale (e.g. "de" or "de-CH"), or false if it has none.
+	Message(code, locale string) (string, bool)
+}
+
+// ErrorCatalog, if set, localizes the messages of the *Error values
+// returned by implementations: the message of an error is replaced by the
+// template of its code for the locale of the request (Accept-Language),
+// where "{message}", "{domain}" and "{code}" stand for the original
+// message, domain and code. A region tag falls back to its language
+// ("de-CH" to "de") and then to the "" locale; errors without a template
+// keep their message.
+var ErrorCatalog Catalog
+
+// A MapCatalog is a Catalog of templates by locale and code, e.g.
+//
+//	goweb.MapCatalog{"de": {"NOT_FOUND": "Nicht gefunden: {message}"}}
+type MapCatalog map[string]map[string]string
+
+// Message implements Catalog.
+func (c MapCatalog) Message(code, locale string) (string, bool) {
+	m, ok := c[locale][code]
+	return m, ok
+}
+
+// localize returns a copy of e with the message of the locale of r and
+// sets the Content-Language of the response.
+func localize(w http.ResponseWriter, r *http.Request, e *Error) *Error {
+	locale := requestLocale(r)
+	for _, l := range fallbackLocales(locale) {
+		tmpl, ok := ErrorCatalog.Message(e.Code, l)
+		if !ok {
+			continue
+		}
+		c := *e
+		c.Message = strings.NewReplacer("{message}", e.Message, "{domain}", e.Domain, "{code}", e.Code).Replace(tmpl)
+		if l != "" {
+			w.Header().Set("Content-Language", l)
+		}
+		return &c
+	}
+	return e
+}
+
+// fallbackLocales returns locale, its language and "".
+func fallbackLocales(locale string) []string {
+	var ls []string
+	for locale != "" {
+		ls = append(ls, locale)
+		i := strings.LastIndexAny(locale, "-_")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return append(ls, "")
+}
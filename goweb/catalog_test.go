@@ -0,0 +1,66 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorCatalog(t *testing.T) {
+	ErrorCatalog = MapCatalog{
+		"":   {"NOT_FOUND": "{message}"},
+		"de": {"NOT_FOUND": "Nicht gefunden ({domain}): {message}"},
+	}
+	defer func() { ErrorCatalog = nil }()
+	for _, test := range []struct {
+		lang, lcontent, msg string
+	}{
+		{"de-CH, en;q=0.5", "de", "Nicht gefunden (pkg.Users): user"},
+		{"fr", "", "user"},
+		{"", "", "user"},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(""))
+		r.Header.Set("Accept-Language", test.lang)
+		w := httptest.NewRecorder()
+		err := &Error{Status: 404, Code: "NOT_FOUND", Message: "user", Domain: "pkg.Users"}
+		WriteRequestError(w, r, err)
+		e, _ := DecodeError(w.Code, w.Body.Bytes()).(*Error)
+		if e == nil || e.Message != test.msg || w.Header().Get("Content-Language") != test.lcontent {
+			t.Errorf("%q: %+v, %q", test.lang, e, w.Header().Get("Content-Language"))
+		}
+		if err.Message != "user" {
+			t.Errorf("%q: error changed to %q", test.lang, err.Message)
+		}
+	}
+}
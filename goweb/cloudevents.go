@@ -0,0 +1,182 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// An EventPublisher publishes events to a topic, for example through a
+// Kafka producer; it is the counterpart of EventConsumer.
+type EventPublisher interface {
+	Publish(ctx context.Context, topic string, key, value []byte) error
+}
+
+var eventTypes = struct {
+	sync.RWMutex
+	m map[string]reflect.Type
+}{m: map[string]reflect.Type{}}
+
+// RegisterEventType registers the message type of m as the data of the
+// events of type typ, e.g. "pkg.Service.Method". The generated code of
+// the events parameter registers the request types of the event methods.
+func RegisterEventType(typ string, m proto.Message) {
+	eventTypes.Lock()
+	defer eventTypes.Unlock()
+	eventTypes.m[typ] = reflect.TypeOf(m)
+}
+
+// NewEvent returns a new message of the data type of the events of type
+// typ, or nil if typ is not registered.
+func NewEvent(typ string) proto.Message {
+	eventTypes.RLock()
+	t := eventTypes.m[typ]
+	eventTypes.RUnlock()
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil
+	}
+	m, _ := reflect.New(t.Elem()).Interface().(proto.Message)
+	return m
+}
+
+// A CloudEvent is the envelope of an event in the structured JSON format
+// of CloudEvents 1.0.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// NewCloudEvent returns the CloudEvent of type typ from source with the
+// JSON of m as data, a random id and the current time.
+func NewCloudEvent(source, typ string, m interface{}) (*CloudEvent, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          source,
+		Type:            typ,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
+
+// Message decodes the data of e into a new message of its registered
+// type.
+func (e *CloudEvent) Message() (proto.Message, error) {
+	m := NewEvent(e.Type)
+	if m == nil {
+		return nil, fmt.Errorf("goweb: unknown event type %q", e.Type)
+	}
+	if err := json.Unmarshal(e.Data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// An EventCodec is a Codec that encodes events with their type.
+type EventCodec interface {
+	Codec
+	MarshalEvent(typ string, v interface{}) ([]byte, error)
+}
+
+// MarshalEvent encodes the event v of type typ with c, with its type if
+// c is an EventCodec.
+func MarshalEvent(c Codec, typ string, v interface{}) ([]byte, error) {
+	if ec, ok := c.(EventCodec); ok {
+		return ec.MarshalEvent(typ, v)
+	}
+	return c.Marshal(v)
+}
+
+// CloudEventsCodec returns an EventCodec encoding events as CloudEvents
+// from source (e.g. "//users.example.com"), for consumers on other
+// eventing systems. Unmarshal accepts any CloudEvent with JSON data.
+func CloudEventsCodec(source string) EventCodec {
+	return cloudEventsCodec{source}
+}
+
+type cloudEventsCodec struct{ source string }
+
+func (c cloudEventsCodec) MarshalEvent(typ string, v interface{}) ([]byte, error) {
+	e, err := NewCloudEvent(c.source, typ, v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(e)
+}
+
+// Marshal encodes v with the type of its message, since it is not
+// given; use MarshalEvent where the event type is known.
+func (c cloudEventsCodec) Marshal(v interface{}) ([]byte, error) {
+	typ := ""
+	if m, ok := v.(proto.Message); ok {
+		typ = proto.MessageName(m)
+	}
+	return c.MarshalEvent(typ, v)
+}
+
+func (cloudEventsCodec) Unmarshal(data []byte, v interface{}) error {
+	var e CloudEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if e.SpecVersion != "1.0" {
+		return fmt.Errorf("goweb: unsupported CloudEvents version %q", e.SpecVersion)
+	}
+	if e.DataContentType != "" && e.DataContentType != "application/json" {
+		return fmt.Errorf("goweb: unsupported CloudEvents data content type %q", e.DataContentType)
+	}
+	return json.Unmarshal(e.Data, v)
+}
@@ -0,0 +1,68 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCloudEvents(t *testing.T) {
+	RegisterEventType("pkg.Audit.Recorded", (*CapturedCall)(nil))
+	c := CloudEventsCodec("//test")
+	data, err := MarshalEvent(c, "pkg.Audit.Recorded", &CapturedCall{Method: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e CloudEvent
+	if err := json.Unmarshal(data, &e); err != nil || e.SpecVersion != "1.0" || e.Type != "pkg.Audit.Recorded" ||
+		e.Source != "//test" || e.ID == "" || e.Time == "" || string(e.Data) != `{"method":"m"}` {
+		t.Fatalf("event %s: %v", data, err)
+	}
+	m, err := e.Message()
+	if err != nil || m.(*CapturedCall).Method != "m" {
+		t.Errorf("Message() = %v, %v", m, err)
+	}
+	var got CapturedCall
+	if err := c.Unmarshal(data, &got); err != nil || got.Method != "m" {
+		t.Errorf("Unmarshal = %v, %v", got, err)
+	}
+	if err := c.Unmarshal([]byte(`{"specversion":"0.3","data":{}}`), &got); err == nil {
+		t.Errorf("Unmarshal accepted CloudEvents 0.3")
+	}
+	if _, err := (&CloudEvent{Type: "pkg.Unknown"}).Message(); err == nil {
+		t.Errorf("Message of an unknown type succeeded")
+	}
+	if b, _ := MarshalEvent(JSONCodec, "t", &CapturedCall{Method: "m"}); string(b) != `{"method":"m"}` {
+		t.Errorf("MarshalEvent(JSONCodec) = %s", b)
+	}
+}
@@ -0,0 +1,140 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// A Coalescer absorbs bursts of identical requests to an expensive read
+// method, generated for the methods with the coalesce_ms option: the calls
+// with the same request (by CanonicalHash) and tenant share the call of
+// the implementation in flight, and its response is served for TTL after
+// it returns. Unlike a cache it holds responses for a fraction of a
+// second, so it only suits methods whose responses depend on nothing but
+// the request and the tenant. Failed calls are only shared by the calls
+// waiting for them.
+type Coalescer struct {
+	// TTL is how long responses are served after their call returns.
+	TTL time.Duration
+
+	mu     sync.Mutex
+	calls  map[string]*coalescedCall
+	count  int64
+	shared int64
+}
+
+// coalescedCall is a call of the implementation and the calls sharing it.
+type coalescedCall struct {
+	done chan struct{}
+	res  interface{}
+	err  error
+}
+
+// CoalescerStats counts the calls of a Coalescer.
+type CoalescerStats struct {
+	// Calls is the number of calls, Shared the number of them answered by
+	// the call of another one.
+	Calls, Shared int64
+}
+
+// NewCoalescer returns a Coalescer with the ttl.
+func NewCoalescer(ttl time.Duration) *Coalescer {
+	return &Coalescer{TTL: ttl}
+}
+
+// Stats returns the counts of the calls so far.
+func (c *Coalescer) Stats() CoalescerStats {
+	return CoalescerStats{Calls: atomic.LoadInt64(&c.count), Shared: atomic.LoadInt64(&c.shared)}
+}
+
+// Do returns a clone of the response of call for the request in of route,
+// or of the one of an identical call in flight or returned within the TTL.
+// The calls sharing a call stop waiting for it when their ctx is done.
+// Requests that cannot be hashed are not coalesced.
+func (c *Coalescer) Do(ctx context.Context, route Route, in interface{}, call func(context.Context) (interface{}, error)) (interface{}, error) {
+	atomic.AddInt64(&c.count, 1)
+	hash, err := CanonicalHash(in)
+	if err != nil {
+		return call(ctx)
+	}
+	key := route.FullMethod() + "\x00" + TenantFrom(ctx) + "\x00" + hash
+	c.mu.Lock()
+	if cc, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		atomic.AddInt64(&c.shared, 1)
+		select {
+		case <-cc.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return cc.result()
+	}
+	if c.calls == nil {
+		c.calls = map[string]*coalescedCall{}
+	}
+	cc := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = cc
+	c.mu.Unlock()
+
+	cc.res, cc.err = call(ctx)
+	close(cc.done)
+	if cc.err != nil || c.TTL <= 0 {
+		c.forget(key, cc)
+	} else {
+		time.AfterFunc(c.TTL, func() { c.forget(key, cc) })
+	}
+	return cc.result()
+}
+
+// result returns the response of the call, cloned, since the callers
+// sharing it may change theirs, or its error.
+func (cc *coalescedCall) result() (interface{}, error) {
+	if m, ok := cc.res.(proto.Message); ok && cc.err == nil {
+		return Clone(m), nil
+	}
+	return cc.res, cc.err
+}
+
+// forget removes the call cc of key, unless it was replaced.
+func (c *Coalescer) forget(key string, cc *coalescedCall) {
+	c.mu.Lock()
+	if c.calls[key] == cc {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+}
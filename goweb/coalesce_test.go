@@ -0,0 +1,133 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestCoalescer(t *testing.T) {
+	c := NewCoalescer(50 * time.Millisecond)
+	route := Route{Service: "goweb.Captures", Method: "List"}
+	var calls int64
+	release := make(chan struct{})
+	call := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt64(&calls, 1)
+		<-release
+		return &CapturedCall{Method: "list"}, nil
+	}
+	var wg sync.WaitGroup
+	results := make([]*CapturedCall, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := c.Do(context.Background(), route, &CapturedCall{Method: "a"}, call)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i], _ = res.(*CapturedCall)
+		}(i)
+	}
+	for c.Stats().Calls < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if calls != 1 || c.Stats() != (CoalescerStats{Calls: 10, Shared: 9}) {
+		t.Errorf("%d calls, stats %+v", calls, c.Stats())
+	}
+	for i, res := range results {
+		if res == nil || res.Method != "list" || i > 0 && res == results[0] {
+			t.Errorf("result %d = %v", i, res)
+		}
+	}
+
+	// Served within the TTL, for the same request and tenant only.
+	c.Do(context.Background(), route, &CapturedCall{Method: "a"}, call)
+	c.Do(WithTenant(context.Background(), "t"), route, &CapturedCall{Method: "a"}, call)
+	c.Do(context.Background(), route, &CapturedCall{Method: "b"}, call)
+	if calls != 3 {
+		t.Errorf("%d calls within the TTL", calls)
+	}
+	time.Sleep(100 * time.Millisecond)
+	c.Do(context.Background(), route, &CapturedCall{Method: "a"}, call)
+	if calls != 4 {
+		t.Errorf("%d calls after the TTL", calls)
+	}
+}
+
+func TestCoalescerErrors(t *testing.T) {
+	c := NewCoalescer(time.Minute)
+	route := Route{Service: "goweb.Captures", Method: "List"}
+	calls := 0
+	fail := func(ctx context.Context) (interface{}, error) {
+		calls++
+		return nil, errors.New("boom")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(context.Background(), route, &CapturedCall{}, fail); err == nil {
+			t.Error("no error")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("failed call served %d times", 2-calls)
+	}
+
+	// Waiting calls give up with their context.
+	release := make(chan struct{})
+	defer close(release)
+	go c.Do(context.Background(), route, &CapturedCall{Method: "slow"}, func(ctx context.Context) (interface{}, error) {
+		<-release
+		return &CapturedCall{}, nil
+	})
+	for {
+		c.mu.Lock()
+		n := len(c.calls)
+		c.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Do(ctx, route, &CapturedCall{Method: "slow"}, fail); err != context.DeadlineExceeded {
+		t.Errorf("waiting call = %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"log"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// A Method is a row of the method table of a service generated with the
+// compact parameter: the plain unary methods of such services are served
+// by MethodHandler from their rows rather than by a handler function each,
+// which keeps the code of large APIs small.
+type Method struct {
+	// Route is the route of the method.
+	Route *Route
+
+	// Skip lists the fields bound from the body and the path variables,
+	// which the query parameters cannot set, see BindQuery.
+	Skip []string
+
+	// New returns a new, empty request.
+	New func() interface{}
+
+	// Call calls the method of the implementation srv with the request in.
+	Call func(srv interface{}, ctx context.Context, in interface{}) (interface{}, error)
+
+	// NewResponse returns the empty response of the dry runs of the
+	// method, see WithValidateOnly, or is nil if the implementation handles
+	// them itself.
+	NewResponse func() interface{}
+
+	// WriteError answers the errors of the method, WriteRequestError if
+	// nil.
+	WriteError func(w http.ResponseWriter, r *http.Request, err error)
+
+	// JSON encodes and decodes the requests and responses unless the
+	// route has LegacyJSON set.
+	JSON Proto3JSON
+}
+
+// MethodHandler returns the handler of the unary method m of the
+// implementation srv, which does what the handler generated for m would
+// without the compact parameter: it counts the calls of deprecated methods
+// (see Unsupported), decodes the JSON request with its query parameters
+// and path variables, validates it (see Validate), calls the method
+// through the interceptor of opts and writes the JSON response.
+func MethodHandler(m *Method, srv interface{}, opts ServerOptions) http.Handler {
+	writeError := m.WriteError
+	if writeError == nil {
+		writeError = WriteRequestError
+	}
+	call := func(ctx context.Context, in interface{}) (interface{}, error) {
+		if m.NewResponse != nil && DryRun(ctx) {
+			return m.NewResponse(), nil
+		}
+		return m.Call(srv, ctx, in)
+	}
+	deprecated := m.Route.Options["deprecated"] == true
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RouteHashHeader, m.Route.Hash)
+		if deprecated {
+			Unsupported(r, *m.Route, UnsupportedDeprecated)
+		}
+		content, release, err := opts.ReadPooledBody(w, r)
+		defer release()
+		defer r.Body.Close()
+		if err != nil {
+			w.WriteHeader(BodyStatus(err))
+			w.Write([]byte(err.Error()))
+			log.Println(err.Error())
+			return
+		}
+		if m.Route.Verb != "" {
+			switch m.Route.Body {
+			case "*":
+			case "":
+				content = []byte("{}")
+			default:
+				content = WrapBody(m.Route.Body, content)
+			}
+		}
+		in := m.New()
+		if m.Route.LegacyJSON {
+			err = UnmarshalJSON(content, in)
+		} else {
+			err = m.JSON.Unmarshal(content, in)
+		}
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte(err.Error()))
+			log.Println(err.Error())
+			return
+		}
+		if m.Route.Verb != "" {
+			if m.Route.Body != "*" {
+				err = BindQuery(in, r.URL.Query(), m.Skip...)
+			}
+			if err == nil {
+				err = BindPath(in, PathParams(r))
+			}
+			if err != nil {
+				w.WriteHeader(400)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+		if err := Validate(in); err != nil {
+			opts.WriteError(w, r, err, writeError)
+			return
+		}
+		ctx, cancel, err := opts.NewContext(w, r)
+		if err != nil {
+			opts.WriteError(w, r, err, writeError)
+			return
+		}
+		defer cancel()
+		var res interface{}
+		if opts.Interceptor == nil {
+			res, err = call(ctx, in)
+		} else {
+			res, err = opts.Interceptor(ctx, *m.Route, in, call)
+		}
+		if err != nil {
+			opts.WriteError(w, r, err, writeError)
+			return
+		}
+		var out []byte
+		if m.Route.LegacyJSON {
+			out, err = MarshalJSON(res)
+		} else {
+			out, err = m.JSON.Marshal(res)
+		}
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			log.Println(err.Error())
+			return
+		}
+		opts.WriteBody(w, r, append(out, '\n'))
+	})
+}
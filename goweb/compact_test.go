@@ -0,0 +1,115 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestMethodHandler(t *testing.T) {
+	route := &Route{Service: "goweb.Captures", Method: "Get", Verb: "POST", Path: "captures/{method}", Body: "error", Hash: "h"}
+	m := &Method{
+		Route: route,
+		Skip:  []string{"error", "method"},
+		New:   func() interface{} { return new(CapturedCall) },
+		Call: func(srv interface{}, ctx context.Context, in interface{}) (interface{}, error) {
+			c := in.(*CapturedCall)
+			if c.Error == "fail" {
+				return nil, &Error{Status: 409, Code: "ABORTED"}
+			}
+			c.Path = srv.(string)
+			return c, nil
+		},
+	}
+	var seen string
+	opts := NewServerOptions(UnaryInterceptor(func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+		seen = route.FullMethod()
+		return next(ctx, in)
+	}))
+	h := MethodHandler(m, "srv", opts)
+	for _, c := range []struct {
+		path, body, params string
+		code               int
+		want               string
+	}{
+		{"/captures/get?time_unix_nano=5&method=x", `"boom"`, "get", 200, `{"method":"get","path":"srv","error":"boom","timeUnixNano":"5"}`},
+		{"/captures/get", `"fail"`, "get", 409, `{"code":"ABORTED","message":""}`},
+		{"/captures/get?time_unix_nano=x", `""`, "get", 400, ""},
+		{"/captures/get", `{`, "get", 400, ""},
+	} {
+		r := WithPathParams(httptest.NewRequest("POST", c.path, strings.NewReader(c.body)), map[string]string{"method": c.params})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.code || c.want != "" && strings.TrimSpace(w.Body.String()) != c.want || w.Header().Get(RouteHashHeader) != "h" {
+			t.Errorf("POST %s %s = %d %s", c.path, c.body, w.Code, w.Body)
+		}
+	}
+	if seen != "/goweb.Captures/Get" {
+		t.Errorf("interceptor saw %q", seen)
+	}
+	route.LegacyJSON = true
+	r := WithPathParams(httptest.NewRequest("POST", "/captures/get?time_unix_nano=5", strings.NewReader(`"boom"`)), map[string]string{"method": "get"})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if want := `{"method":"get","path":"srv","error":"boom","time_unix_nano":5}`; strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("legacy JSON response %s, want %s", w.Body, want)
+	}
+}
+
+// The calls of deprecated methods are counted like by generated handlers.
+func TestMethodHandlerDeprecated(t *testing.T) {
+	defer func(log func(*http.Request, UnsupportedUse, int64)) { UnsupportedLog = log }(UnsupportedLog)
+	UnsupportedLog = nil
+	route := &Route{Service: "goweb.Captures", Method: "Old", Path: "captures/old", Options: map[string]interface{}{"deprecated": true}}
+	m := &Method{
+		Route: route,
+		New:   func() interface{} { return new(CapturedCall) },
+		Call: func(srv interface{}, ctx context.Context, in interface{}) (interface{}, error) {
+			return in, nil
+		},
+	}
+	use := UnsupportedUse{Method: "/goweb.Captures/Old", Kind: UnsupportedDeprecated}
+	before := UnsupportedUses()[use]
+	w := httptest.NewRecorder()
+	MethodHandler(m, nil, NewServerOptions()).ServeHTTP(w, httptest.NewRequest("POST", "/captures/old", strings.NewReader("{}")))
+	if w.Code != 200 {
+		t.Fatalf("call of a deprecated method: %d %s", w.Code, w.Body)
+	}
+	if n := UnsupportedUses()[use] - before; n != 1 {
+		t.Errorf("%d calls of the deprecated method counted, want 1", n)
+	}
+}
@@ -0,0 +1,124 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrUnsupportedEncoding is returned by ReadBody for requests with a
+// Content-Encoding other than gzip.
+var ErrUnsupportedEncoding = errors.New("goweb: unsupported content encoding")
+
+// ReadBody reads the body of r, decompressing it if it is sent with
+// Content-Encoding gzip, as clients do for bodies above their
+// Upstream.GzipThreshold.
+func ReadBody(r *http.Request) ([]byte, error) {
+	body, err := decodeBody(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(body)
+}
+
+// BodyStatus returns the status answering a request whose body could not
+// be read with err: 413 beyond the limit of the mux, 415 for unsupported
+// encodings, 400 for corrupt compressed bodies and 408 otherwise.
+func BodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return 413
+	case err == ErrUnsupportedEncoding:
+		return 415
+	case err == gzip.ErrChecksum:
+		return 400
+	}
+	if _, ok := err.(corruptBody); ok {
+		return 400
+	}
+	return 408
+}
+
+// corruptBody reports a compressed body that cannot be decompressed.
+type corruptBody struct{ err error }
+
+func (e corruptBody) Error() string { return "goweb: corrupt request body: " + e.err.Error() }
+func (e corruptBody) Unwrap() error { return e.err }
+
+// decodeBody returns a reader decompressing body sent with the
+// Content-Encoding encoding.
+func decodeBody(encoding string, body io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, corruptBody{err}
+		}
+		return zr, nil
+	}
+	return nil, ErrUnsupportedEncoding
+}
+
+// gzipResponse wraps the body of res if it is still compressed, because
+// the request set Accept-Encoding itself.
+func gzipResponse(res *http.Response) error {
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{zr, res.Body}
+	res.Header.Del("Content-Encoding")
+	return nil
+}
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestReadBody(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(gzipBytes([]byte(`{"a":1}`))))
+	r.Header.Set("Content-Encoding", "gzip")
+	if b, err := ReadBody(r); err != nil || string(b) != `{"a":1}` {
+		t.Errorf("gzip: %q %v", b, err)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader("x"))
+	r.Header.Set("Content-Encoding", "br")
+	if _, err := ReadBody(r); BodyStatus(err) != 415 {
+		t.Errorf("br: %v", err)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader("not gzip"))
+	r.Header.Set("Content-Encoding", "gzip")
+	if _, err := ReadBody(r); BodyStatus(err) != 400 {
+		t.Errorf("corrupt gzip: %v", err)
+	}
+}
+
+func TestUpstreamGzip(t *testing.T) {
+	var encoding string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		body, err := ReadBody(r)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write(body)
+		zw.Close()
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, GzipThreshold: 10, AcceptGzip: true}
+	for _, in := range []string{"short", "long enough to compress"} {
+		var out string
+		if err := u.Call(context.Background(), Route{Path: "/echo"}, in, &out); err != nil || out != in {
+			t.Errorf("%q: %q %v", in, out, err)
+		}
+		b, _ := json.Marshal(in)
+		if want := map[bool]string{true: "gzip"}[len(b) >= 10]; encoding != want {
+			t.Errorf("%q sent with Content-Encoding %q", in, encoding)
+		}
+	}
+}
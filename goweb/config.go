@@ -0,0 +1,141 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A MuxConfig is the runtime configuration of the muxes of a service,
+// which operators can change without a restart, see MuxSettings.
+type MuxConfig struct {
+	// Maintenance answers every call with 503 and MaintenanceMessage.
+	Maintenance        bool   `json:"maintenance,omitempty"`
+	MaintenanceMessage string `json:"maintenance_message,omitempty"`
+
+	// Disabled methods, by name ("GetUser") or full name
+	// ("/pkg.Users/GetUser"), are answered with 503.
+	Disabled map[string]bool `json:"disabled,omitempty"`
+
+	// Timeout, if positive, is the deadline of the context of unary calls.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// RateLimit, if positive, limits the calls per second and key (see
+	// RateLimiter), allowing RateBurst calls at once.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+	RateBurst int     `json:"rate_burst,omitempty"`
+
+	// Flags are feature flags for the implementation, see
+	// MuxSettings.Flag.
+	Flags map[string]bool `json:"flags,omitempty"`
+}
+
+// MuxSettings hold the MuxConfig of a service, swapped atomically by
+// Apply while calls are served. The generated <Service>Config variables
+// are MuxSettings, which the handlers generated with the hot_config
+// parameter consult on every call.
+type MuxSettings struct {
+	v atomic.Value // of *muxState
+}
+
+type muxState struct {
+	config MuxConfig
+	limit  *RateLimiter
+}
+
+// Apply validates c and makes it the configuration of the next calls.
+// The maps of c must not be changed afterwards.
+func (s *MuxSettings) Apply(c MuxConfig) error {
+	if c.Timeout < 0 || c.RateLimit < 0 || c.RateBurst < 0 {
+		return errors.New("goweb: negative timeout or rate limit in MuxConfig")
+	}
+	st := &muxState{config: c}
+	if c.RateLimit > 0 {
+		st.limit = &RateLimiter{Rate: c.RateLimit, Burst: c.RateBurst}
+	}
+	s.v.Store(st)
+	return nil
+}
+
+func (s *MuxSettings) state() *muxState {
+	st, _ := s.v.Load().(*muxState)
+	if st == nil {
+		return &muxState{}
+	}
+	return st
+}
+
+// Config returns the current configuration.
+func (s *MuxSettings) Config() MuxConfig {
+	return s.state().config
+}
+
+// Flag reports whether the feature flag name is set.
+func (s *MuxSettings) Flag(name string) bool {
+	return s.state().config.Flags[name]
+}
+
+// Admit reports whether a call of route answering r may proceed under the
+// current configuration, and answers it otherwise.
+func (s *MuxSettings) Admit(w http.ResponseWriter, r *http.Request, route Route) bool {
+	st := s.state()
+	c := &st.config
+	switch {
+	case c.Maintenance:
+		msg := c.MaintenanceMessage
+		if msg == "" {
+			msg = "down for maintenance"
+		}
+		WriteRequestError(w, r, &Error{Status: 503, Code: "UNAVAILABLE", Message: msg, Retryable: true})
+		return false
+	case c.Disabled[route.Method] || c.Disabled[route.FullMethod()]:
+		WriteRequestError(w, r, &Error{Status: 503, Code: "UNAVAILABLE", Message: route.Method + " is disabled"})
+		return false
+	case st.limit != nil:
+		return st.limit.admit(w, r)
+	}
+	return true
+}
+
+// WithTimeout returns ctx with the deadline of the configured Timeout, if
+// any, and the function releasing it.
+func (s *MuxSettings) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t := s.state().config.Timeout; t > 0 {
+		return context.WithTimeout(ctx, t)
+	}
+	return ctx, func() {}
+}
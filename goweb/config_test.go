@@ -0,0 +1,77 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMuxSettings(t *testing.T) {
+	var s MuxSettings
+	route := Route{Service: "pkg.Users", Method: "GetUser"}
+	admit := func() int {
+		w := httptest.NewRecorder()
+		if s.Admit(w, httptest.NewRequest("POST", "/", strings.NewReader("")), route) {
+			return 200
+		}
+		return w.Code
+	}
+	if admit() != 200 || s.Flag("beta") {
+		t.Error("zero MuxSettings not permissive")
+	}
+	s.Apply(MuxConfig{Maintenance: true})
+	if got := admit(); got != 503 {
+		t.Errorf("maintenance: %d", got)
+	}
+	s.Apply(MuxConfig{Disabled: map[string]bool{"/pkg.Users/GetUser": true}})
+	if got := admit(); got != 503 {
+		t.Errorf("disabled: %d", got)
+	}
+	s.Apply(MuxConfig{RateLimit: 0.001, RateBurst: 1, Flags: map[string]bool{"beta": true}})
+	if a, b := admit(), admit(); a != 200 || b != 429 || !s.Flag("beta") {
+		t.Errorf("rate limit: %d, %d", a, b)
+	}
+	s.Apply(MuxConfig{Timeout: time.Minute})
+	ctx, cancel := s.WithTimeout(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("no deadline")
+	}
+	if err := s.Apply(MuxConfig{Timeout: -1}); err == nil {
+		t.Error("negative timeout applied")
+	}
+}
@@ -0,0 +1,113 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// A TestReporter reports failed checks; *testing.T is one.
+type TestReporter interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// A Call calls a method of an implementation with a fixed request.
+type Call func() (proto.Message, error)
+
+// panicError is the error of a call that panicked.
+type panicError struct{ v interface{} }
+
+func (e panicError) Error() string { return fmt.Sprint("panic: ", e.v) }
+
+// protect runs call, turning a panic into a panicError.
+func protect(call Call) (m proto.Message, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = panicError{p}
+		}
+	}()
+	return call()
+}
+
+// CheckEmpty checks that the method handles an empty request, i.e. that
+// call, which passes one, does not panic and does not return a nil
+// response without an error.
+func CheckEmpty(t TestReporter, method string, call Call) {
+	t.Helper()
+	m, err := protect(call)
+	if _, ok := err.(panicError); ok {
+		t.Errorf("%s with an empty request: %v", method, err)
+		return
+	}
+	if err == nil && isNil(m) {
+		t.Errorf("%s with an empty request: nil response without an error", method)
+	}
+}
+
+// CheckValidate checks that the method rejects the request in if it has a
+// Validate method that fails: call, which passes in, must return an error.
+func CheckValidate(t TestReporter, method string, in interface{}, call Call) {
+	t.Helper()
+	v, ok := in.(interface {
+		Validate() error
+	})
+	if !ok || v.Validate() == nil {
+		return
+	}
+	if _, err := protect(call); err == nil {
+		t.Errorf("%s accepted a request that fails Validate", method)
+	}
+}
+
+// CheckIdempotent checks that two identical calls of a method that is
+// declared free of side effects or idempotent get the same answer.
+func CheckIdempotent(t TestReporter, method string, call Call) {
+	t.Helper()
+	m1, err1 := protect(call)
+	m2, err2 := protect(call)
+	switch {
+	case (err1 == nil) != (err2 == nil):
+		t.Errorf("%s is idempotent, but failed only once: %v, %v", method, err1, err2)
+	case err1 == nil && !isNil(m1) && !isNil(m2) && !proto.Equal(m1, m2):
+		t.Errorf("%s is idempotent, but answered a repeated call differently:\n%v\n%v", method, m1, m2)
+	}
+}
+
+// isNil reports whether m is nil or a nil pointer.
+func isNil(m proto.Message) bool {
+	v := reflect.ValueOf(m)
+	return m == nil || v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,90 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+type recorder struct{ errors []string }
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type validated struct{ CapturedCall }
+
+func (validated) Validate() error { return errors.New("method is required") }
+
+func TestConformanceChecks(t *testing.T) {
+	n := 0
+	for i, c := range []struct {
+		check func(t TestReporter)
+		fails bool
+	}{
+		{func(t TestReporter) {
+			CheckEmpty(t, "M", func() (proto.Message, error) { panic("nil map") })
+		}, true},
+		{func(t TestReporter) {
+			CheckEmpty(t, "M", func() (proto.Message, error) { return (*CapturedCall)(nil), nil })
+		}, true},
+		{func(t TestReporter) {
+			CheckEmpty(t, "M", func() (proto.Message, error) { return nil, errors.New("not found") })
+		}, false},
+		{func(t TestReporter) {
+			CheckValidate(t, "M", &validated{}, func() (proto.Message, error) { return &CapturedCall{}, nil })
+		}, true},
+		{func(t TestReporter) {
+			CheckValidate(t, "M", &CapturedCall{}, func() (proto.Message, error) { return &CapturedCall{}, nil })
+		}, false},
+		{func(t TestReporter) {
+			CheckIdempotent(t, "M", func() (proto.Message, error) {
+				n++
+				return &CapturedCall{DurationNanos: int64(n)}, nil
+			})
+		}, true},
+		{func(t TestReporter) {
+			CheckIdempotent(t, "M", func() (proto.Message, error) { return &CapturedCall{Method: "m"}, nil })
+		}, false},
+	} {
+		r := &recorder{}
+		c.check(r)
+		if (len(r.errors) > 0) != c.fails {
+			t.Errorf("check %d: %q, want failure: %v", i, r.errors, c.fails)
+		}
+	}
+}
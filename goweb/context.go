@@ -0,0 +1,90 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+type requestKey struct{}
+
+// NewContext returns the context of the request r, carrying r: the one
+// of r, canceled when the client goes away, see also
+// ServerOptions.NewContext.
+func NewContext(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), requestKey{}, r)
+}
+
+// RequestFrom returns the http request of a context created by NewContext,
+// or nil.
+func RequestFrom(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+type rawBodyKey struct{}
+
+// WithRawBody returns a copy of ctx carrying the raw request body b.
+func WithRawBody(ctx context.Context, b []byte) context.Context {
+	return context.WithValue(ctx, rawBodyKey{}, b)
+}
+
+// RawBody returns the bytes of the request body in ctx, as received, e.g.
+// to re-verify a signature or to audit the request. It is only set for
+// methods with the goweb.raw_body option, and nil otherwise.
+func RawBody(ctx context.Context) []byte {
+	b, _ := ctx.Value(rawBodyKey{}).([]byte)
+	return b
+}
+
+// ShowRedacted reports whether the caller of the request in ctx may see
+// the fields marked with the goweb.redact option. If it is nil, those
+// fields are cleared from every response.
+var ShowRedacted func(ctx context.Context) bool
+
+// Clone returns a deep copy of m, so that generated handlers can change
+// responses without touching the values returned by the implementation.
+func Clone(m proto.Message) proto.Message {
+	return proto.Clone(m)
+}
+
+// Redacted clones the response m for redaction, unless ShowRedacted allows
+// the caller to see its secret fields, in which case it returns nil.
+func Redacted(ctx context.Context, m proto.Message) proto.Message {
+	if ShowRedacted != nil && ShowRedacted(ctx) {
+		return nil
+	}
+	return Clone(m)
+}
@@ -0,0 +1,66 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestRedacted(t *testing.T) {
+	defer func(show func(context.Context) bool) { ShowRedacted = show }(ShowRedacted)
+	res := &pb.FileDescriptorProto{Name: proto.String("a.proto")}
+	ShowRedacted = nil
+	if c := Redacted(context.Background(), res); c == nil || c == proto.Message(res) || !proto.Equal(c, res) {
+		t.Errorf("Redacted() = %v, want a copy of the response", c)
+	}
+	type admin struct{}
+	ShowRedacted = func(ctx context.Context) bool { return ctx.Value(admin{}) != nil }
+	if c := Redacted(context.WithValue(context.Background(), admin{}, true), res); c != nil {
+		t.Errorf("Redacted() = %v for a privileged caller", c)
+	}
+	if c := Redacted(context.Background(), res); c == nil {
+		t.Error("Redacted() = nil for another caller")
+	}
+}
+
+func TestRawBody(t *testing.T) {
+	if b := RawBody(context.Background()); b != nil {
+		t.Errorf("RawBody() = %q without a body", b)
+	}
+	if b := RawBody(WithRawBody(context.Background(), []byte(`{"a":1}`))); string(b) != `{"a":1}` {
+		t.Errorf("RawBody() = %q", b)
+	}
+}
@@ -0,0 +1,200 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"golang.org/x/net/context"
+)
+
+// A Crypter encrypts and decrypts the values of the fields with the
+// goweb.encrypt option; field is the full name of the field, e.g.
+// "pkg.User.ssn", for key selection and as associated data.
+type Crypter interface {
+	Encrypt(ctx context.Context, field string, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, field string, ciphertext []byte) ([]byte, error)
+}
+
+// FieldCrypter encrypts the fields with the goweb.encrypt option in the
+// responses of generated handlers and decrypts them in their requests.
+// Calls with such fields fail with ErrNoCrypter while it is nil.
+var FieldCrypter Crypter
+
+// ErrNoCrypter reports a call with encrypted fields without FieldCrypter.
+var ErrNoCrypter = errors.New("goweb: no FieldCrypter for an encrypted field")
+
+// EncryptBytes encrypts the value v of the field with FieldCrypter; empty
+// values stay empty.
+func EncryptBytes(ctx context.Context, field string, v []byte) ([]byte, error) {
+	if len(v) == 0 {
+		return v, nil
+	}
+	if FieldCrypter == nil {
+		return nil, ErrNoCrypter
+	}
+	return FieldCrypter.Encrypt(ctx, field, v)
+}
+
+// ErrBadCiphertext reports a ciphertext that is malformed or fails its
+// authentication, e.g. because it was altered. Crypters return it, or an
+// error wrapping it, for the values that clients sent wrong, e.g. when the
+// key service rejects the wrapped key of a value as invalid.
+var ErrBadCiphertext = errors.New("malformed or unauthenticated ciphertext")
+
+// DecryptBytes decrypts the value v of the field with FieldCrypter; empty
+// values stay empty. Values that do not decrypt, with ErrBadCiphertext,
+// fail with a 400 *Error; *Errors of the Crypter are returned as they are,
+// and its other errors, e.g. of a key service that is down, are logged
+// and fail with a retryable 503 *Error.
+func DecryptBytes(ctx context.Context, field string, v []byte) ([]byte, error) {
+	if len(v) == 0 {
+		return v, nil
+	}
+	if FieldCrypter == nil {
+		return nil, ErrNoCrypter
+	}
+	p, err := FieldCrypter.Decrypt(ctx, field, v)
+	var e *Error
+	switch {
+	case err == nil:
+		return p, nil
+	case errors.Is(err, ErrBadCiphertext):
+		return nil, Errorf(400, "INVALID_ARGUMENT", "field %s: cannot decrypt: %v", field, err)
+	case errors.As(err, &e):
+		return nil, err
+	}
+	log.Printf("goweb: decrypting field %s: %v", field, err)
+	return nil, &Error{Status: 503, Code: "UNAVAILABLE", Message: "field " + field + ": cannot decrypt now", Retryable: true}
+}
+
+// EncryptString is EncryptBytes for a string field, whose ciphertext is
+// base64 encoded.
+func EncryptString(ctx context.Context, field, v string) (string, error) {
+	c, err := EncryptBytes(ctx, field, []byte(v))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(c), nil
+}
+
+// DecryptString is DecryptBytes for a string field.
+func DecryptString(ctx context.Context, field, v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if FieldCrypter == nil {
+		return "", ErrNoCrypter
+	}
+	c, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", Errorf(400, "INVALID_ARGUMENT", "field %s: cannot decrypt: %v", field, err)
+	}
+	p, err := DecryptBytes(ctx, field, c)
+	return string(p), err
+}
+
+// An EnvelopeCrypter encrypts every value with a new AES-256-GCM data key,
+// which is stored with the value wrapped by a key encryption key, e.g. of
+// a KMS.
+type EnvelopeCrypter struct {
+	// WrapKey encrypts the data key dek, UnwrapKey decrypts it.
+	WrapKey   func(ctx context.Context, dek []byte) ([]byte, error)
+	UnwrapKey func(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Encrypt implements Crypter. The ciphertext is the length of the wrapped
+// data key as uvarint, the wrapped key, the nonce and the sealed value.
+func (c EnvelopeCrypter) Encrypt(ctx context.Context, field string, plaintext []byte) ([]byte, error) {
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := c.WrapKey(ctx, dek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(wrapped)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	out = append(out[:binary.PutUvarint(out, uint64(len(wrapped)))], wrapped...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, []byte(field)), nil
+}
+
+// Decrypt implements Crypter.
+func (c EnvelopeCrypter) Decrypt(ctx context.Context, field string, ciphertext []byte) ([]byte, error) {
+	n, k := binary.Uvarint(ciphertext)
+	if k <= 0 || n == 0 || uint64(len(ciphertext)-k) < n {
+		return nil, ErrBadCiphertext
+	}
+	wrapped, rest := ciphertext[k:k+int(n)], ciphertext[k+int(n):]
+	dek, err := c.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dek)
+	if err != nil {
+		// the wrapped key of the ciphertext is not one of ours
+		return nil, fmt.Errorf("%w: %v", ErrBadCiphertext, err)
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, ErrBadCiphertext
+	}
+	p, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], []byte(field))
+	if err != nil {
+		return nil, ErrBadCiphertext
+	}
+	return p, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
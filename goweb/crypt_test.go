@@ -0,0 +1,119 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// xorKeys wraps data keys by xoring them with a fixed key encryption key.
+func xorKeys(ctx context.Context, k []byte) ([]byte, error) {
+	out := make([]byte, len(k))
+	for i := range k {
+		out[i] = k[i] ^ 0x5a
+	}
+	return out, nil
+}
+
+func TestEnvelopeCrypter(t *testing.T) {
+	FieldCrypter = EnvelopeCrypter{WrapKey: xorKeys, UnwrapKey: xorKeys}
+	defer func() { FieldCrypter = nil }()
+	ctx := context.Background()
+	c, err := EncryptString(ctx, "pkg.User.ssn", "123-45-6789")
+	if err != nil || c == "" || c == "123-45-6789" {
+		t.Fatalf("EncryptString = %q, %v", c, err)
+	}
+	if p, err := DecryptString(ctx, "pkg.User.ssn", c); err != nil || p != "123-45-6789" {
+		t.Errorf("DecryptString = %q, %v", p, err)
+	}
+	if _, err := DecryptString(ctx, "pkg.User.other", c); err == nil {
+		t.Error("decrypted with another field name")
+	}
+	if _, err := DecryptString(ctx, "pkg.User.ssn", "AAAA"); err == nil || err.(*Error).Status != 400 {
+		t.Errorf("DecryptString(garbage) = %v", err)
+	}
+	b, _ := EncryptBytes(ctx, "pkg.User.key", []byte{1, 2, 3})
+	if p, err := DecryptBytes(ctx, "pkg.User.key", b); err != nil || !bytes.Equal(p, []byte{1, 2, 3}) {
+		t.Errorf("DecryptBytes = %v, %v", p, err)
+	}
+	if c, err := EncryptString(ctx, "pkg.User.ssn", ""); c != "" || err != nil {
+		t.Errorf("EncryptString(\"\") = %q, %v", c, err)
+	}
+
+	FieldCrypter = nil
+	if _, err := EncryptString(ctx, "pkg.User.ssn", "x"); err != ErrNoCrypter {
+		t.Errorf("EncryptString without crypter = %v", err)
+	}
+}
+
+// Only values the client sent wrong are its fault; an outage of the key
+// service is not.
+func TestDecryptErrors(t *testing.T) {
+	defer func() { FieldCrypter = nil }()
+	ctx := context.Background()
+	FieldCrypter = EnvelopeCrypter{WrapKey: xorKeys, UnwrapKey: xorKeys}
+	sealed, err := EncryptBytes(ctx, "pkg.User.key", []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	for _, c := range []struct {
+		name   string
+		unwrap func(ctx context.Context, k []byte) ([]byte, error)
+		value  []byte
+		status int
+	}{
+		{"tampered", xorKeys, tampered, 400},
+		{"truncated", xorKeys, sealed[:3], 400},
+		{"rejected key", func(ctx context.Context, k []byte) ([]byte, error) {
+			return nil, fmt.Errorf("kms: %w", ErrBadCiphertext)
+		}, sealed, 400},
+		{"outage", func(ctx context.Context, k []byte) ([]byte, error) {
+			return nil, errors.New("kms: connection refused")
+		}, sealed, 503},
+		{"goweb error", func(ctx context.Context, k []byte) ([]byte, error) {
+			return nil, &Error{Status: 403, Code: "PERMISSION_DENIED"}
+		}, sealed, 403},
+	} {
+		FieldCrypter = EnvelopeCrypter{WrapKey: xorKeys, UnwrapKey: c.unwrap}
+		_, err := DecryptBytes(ctx, "pkg.User.key", c.value)
+		if e, ok := err.(*Error); !ok || e.Status != c.status || c.status == 503 && !e.Retryable {
+			t.Errorf("%s: DecryptBytes = %v, want status %d", c.name, err, c.status)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A DeadLetterSink keeps the calls that failed with a server error (5xx),
+// in the capture format, so they can be inspected and replayed with
+// Replay or the generated Replay<Service> functions once the cause is
+// fixed. DeadLetter is called synchronously after the failed call.
+type DeadLetterSink interface {
+	DeadLetter(ctx context.Context, c *CapturedCall)
+}
+
+// DeadLetterSinkFunc adapts a function to a DeadLetterSink.
+type DeadLetterSinkFunc func(ctx context.Context, c *CapturedCall)
+
+// DeadLetter calls f(ctx, c).
+func (f DeadLetterSinkFunc) DeadLetter(ctx context.Context, c *CapturedCall) { f(ctx, c) }
+
+// DeadLetter writes the call c; write errors are dropped.
+func (cw *CaptureWriter) DeadLetter(ctx context.Context, c *CapturedCall) {
+	cw.Write(c)
+}
+
+// ErrorStatusOf returns the http status generated handlers answer err
+// with.
+func ErrorStatusOf(err error) int {
+	e, ok := err.(*Error)
+	switch {
+	case !ok:
+		return 500
+	case e.Status != 0:
+		return e.Status
+	}
+	return ErrorStatus(e.Domain, e.Code)
+}
+
+// DeadLetters returns an Interceptor for the generated Wrap<Service>Server
+// functions passing the calls that fail with a server error (5xx) to sink,
+// with their method, request and error.
+func DeadLetters(sink DeadLetterSink) Interceptor {
+	return func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		out, err := next(ctx, in)
+		if err != nil && ErrorStatusOf(err) >= 500 {
+			req, _ := json.Marshal(in)
+			sink.DeadLetter(ctx, &CapturedCall{
+				Method:        route.FullMethod(),
+				Path:          route.Path,
+				Request:       req,
+				Error:         err.Error(),
+				TimeUnixNano:  start.UnixNano(),
+				DurationNanos: int64(time.Since(start)),
+			})
+		}
+		return out, err
+	}
+}
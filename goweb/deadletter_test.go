@@ -0,0 +1,66 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestDeadLetters(t *testing.T) {
+	var buf bytes.Buffer
+	ic := DeadLetters(NewCaptureWriter(&buf))
+	route := Route{Service: "pkg.Users", Method: "GetUser", Path: "users/get"}
+	for _, err := range []error{
+		nil,
+		ErrorFactory{}.NotFound("user", "7"),
+		ErrorFactory{}.Unavailable("down"),
+		errors.New("boom"),
+	} {
+		ic(context.Background(), route, map[string]string{"id": "7"}, func(ctx context.Context, in interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+	calls, err := ReadCapture(&buf)
+	if err != nil || len(calls) != 2 {
+		t.Fatalf("ReadCapture = %v, %v", calls, err)
+	}
+	if c := calls[0]; c.Method != "/pkg.Users/GetUser" || c.Path != "users/get" || string(c.Request) != `{"id":"7"}` || c.Error != "goweb: UNAVAILABLE: down" {
+		t.Errorf("dead letter %v", c)
+	}
+	if calls[1].Error != "boom" {
+		t.Errorf("dead letter %v", calls[1])
+	}
+}
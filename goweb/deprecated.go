@@ -0,0 +1,95 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DeprecatedFieldsHeader lists the deprecated fields set in the request
+// of a call, by path (e.g. "address.zip", without indexes or keys), so
+// that clients notice them before they are removed.
+const DeprecatedFieldsHeader = "X-Goweb-Deprecated-Fields"
+
+// A DeprecatedField is a field marked deprecated = true in the request of
+// a method.
+type DeprecatedField struct {
+	Method string // e.g. "/pkg.Users/Create"
+	Field  string // its path, e.g. "address.zip"
+}
+
+var deprecatedUses struct {
+	sync.Mutex
+	n map[DeprecatedField]int64
+}
+
+// DeprecatedFields records that the request of the call of route answered
+// on w sets the deprecated fields: it lists them in the
+// DeprecatedFieldsHeader and counts them, see DeprecatedFieldUses.
+// Generated handlers call it for every request setting any.
+func DeprecatedFields(w http.ResponseWriter, route Route, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.Strings(fields)
+	uniq := fields[:1]
+	for _, f := range fields[1:] {
+		if f != uniq[len(uniq)-1] {
+			uniq = append(uniq, f)
+		}
+	}
+	w.Header().Set(DeprecatedFieldsHeader, strings.Join(uniq, ", "))
+	method := route.FullMethod()
+	deprecatedUses.Lock()
+	defer deprecatedUses.Unlock()
+	if deprecatedUses.n == nil {
+		deprecatedUses.n = map[DeprecatedField]int64{}
+	}
+	for _, f := range uniq {
+		deprecatedUses.n[DeprecatedField{method, f}]++
+	}
+}
+
+// DeprecatedFieldUses returns how many calls set each deprecated field so
+// far, for metrics: the fields no call sets any more can be removed.
+func DeprecatedFieldUses() map[DeprecatedField]int64 {
+	deprecatedUses.Lock()
+	defer deprecatedUses.Unlock()
+	uses := make(map[DeprecatedField]int64, len(deprecatedUses.n))
+	for f, n := range deprecatedUses.n {
+		uses[f] = n
+	}
+	return uses
+}
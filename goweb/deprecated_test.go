@@ -0,0 +1,64 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeprecatedFields(t *testing.T) {
+	deprecatedUses.Lock()
+	n := deprecatedUses.n
+	deprecatedUses.n = nil
+	deprecatedUses.Unlock()
+	t.Cleanup(func() {
+		deprecatedUses.Lock()
+		deprecatedUses.n = n
+		deprecatedUses.Unlock()
+	})
+	route := Route{Service: "goweb.Deprecations", Method: "Use"}
+	w := httptest.NewRecorder()
+	DeprecatedFields(w, route, nil)
+	if _, ok := w.Header()[DeprecatedFieldsHeader]; ok {
+		t.Error("header without deprecated fields")
+	}
+	DeprecatedFields(w, route, []string{"b.c", "a", "b.c"})
+	DeprecatedFields(httptest.NewRecorder(), route, []string{"a"})
+	if h := w.Header().Get(DeprecatedFieldsHeader); h != "a, b.c" {
+		t.Errorf("header = %q", h)
+	}
+	uses := DeprecatedFieldUses()
+	if a, bc := uses[DeprecatedField{"/goweb.Deprecations/Use", "a"}], uses[DeprecatedField{"/goweb.Deprecations/Use", "b.c"}]; a != 2 || bc != 1 {
+		t.Errorf("uses = %v", uses)
+	}
+}
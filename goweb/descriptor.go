@@ -0,0 +1,196 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// The process-wide descriptor registry, filled by the init functions of
+// code generated with the descriptors parameter. Unlike the registry of
+// golang/protobuf, its descriptors keep their comments.
+var descriptors struct {
+	sync.RWMutex
+	files map[string]*pb.FileDescriptorProto
+	order []string
+}
+
+// RegisterDescriptor adds the gzipped FileDescriptorProtos files to the
+// registry. It is called by generated code and panics if one is invalid.
+func RegisterDescriptor(files ...[]byte) {
+	descriptors.Lock()
+	defer descriptors.Unlock()
+	if descriptors.files == nil {
+		descriptors.files = make(map[string]*pb.FileDescriptorProto)
+	}
+	for _, gz := range files {
+		fd, err := unzipDescriptor(gz)
+		if err != nil {
+			panic("goweb: invalid descriptor: " + err.Error())
+		}
+		if _, ok := descriptors.files[fd.GetName()]; !ok {
+			descriptors.order = append(descriptors.order, fd.GetName())
+		}
+		descriptors.files[fd.GetName()] = fd
+	}
+}
+
+func unzipDescriptor(gz []byte) (*pb.FileDescriptorProto, error) {
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fd := new(pb.FileDescriptorProto)
+	if err := proto.Unmarshal(b, fd); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+// lookupDescriptor returns the descriptor of the proto file name, from
+// the registry or else from the one of golang/protobuf, which holds the
+// files of all linked .pb.go packages, such as the well-known types.
+func lookupDescriptor(name string) (*pb.FileDescriptorProto, error) {
+	descriptors.RLock()
+	fd := descriptors.files[name]
+	descriptors.RUnlock()
+	if fd != nil {
+		return fd, nil
+	}
+	gz := proto.FileDescriptor(name)
+	if gz == nil {
+		return nil, fmt.Errorf("goweb: no descriptor of %s is linked", name)
+	}
+	return unzipDescriptor(gz)
+}
+
+// DescriptorSet returns the registered files that define services, or
+// all registered files if services is empty, together with their
+// dependencies, each after the files it imports. The services are full
+// names like "pkg.Users". The set lets tools such as protoc, grpcurl or
+// NewDynamicMux work against a running server without its protos.
+func DescriptorSet(services ...string) (*pb.FileDescriptorSet, error) {
+	want := make(map[string]bool, len(services))
+	for _, s := range services {
+		want[s] = true
+	}
+	descriptors.RLock()
+	var roots []string
+	for _, name := range descriptors.order {
+		if len(want) == 0 || definesService(descriptors.files[name], want) {
+			roots = append(roots, name)
+		}
+	}
+	descriptors.RUnlock()
+	set := new(pb.FileDescriptorSet)
+	seen := map[string]bool{}
+	var add func(name string) error
+	add = func(name string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+		fd, err := lookupDescriptor(name)
+		if err != nil {
+			return err
+		}
+		for _, dep := range fd.Dependency {
+			if err := add(dep); err != nil {
+				return err
+			}
+		}
+		set.File = append(set.File, fd)
+		return nil
+	}
+	for _, name := range roots {
+		if err := add(name); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func definesService(fd *pb.FileDescriptorProto, services map[string]bool) bool {
+	prefix := ""
+	if fd.GetPackage() != "" {
+		prefix = fd.GetPackage() + "."
+	}
+	for _, s := range fd.Service {
+		if services[prefix+s.GetName()] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDescriptorSet serves the descriptors of the services of routes,
+// see RoutesHandler: as binary FileDescriptorSet, the format of protoc
+// --descriptor_set_out, to clients that accept application/x-protobuf,
+// else as JSON.
+func writeDescriptorSet(w http.ResponseWriter, r *http.Request, routes []Route) {
+	var services []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		if !seen[route.Service] {
+			seen[route.Service] = true
+			services = append(services, route.Service)
+		}
+	}
+	set, err := DescriptorSet(services...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(set.File) == 0 {
+		http.Error(w, "goweb: no descriptors are registered, see the descriptors parameter", http.StatusNotFound)
+		return
+	}
+	if AcceptsProtobuf(r) {
+		WriteProtobuf(w, set)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	(&jsonpb.Marshaler{OrigName: true}).Marshal(w, set)
+}
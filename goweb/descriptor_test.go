@@ -0,0 +1,122 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func gzipDescriptor(t *testing.T, fd *pb.FileDescriptorProto) []byte {
+	b, err := proto.Marshal(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDescriptorSet(t *testing.T) {
+	RegisterDescriptor(gzipDescriptor(t, &pb.FileDescriptorProto{
+		Name:       proto.String("descriptortest/a.proto"),
+		Package:    proto.String("descriptortest"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Service:    []*pb.ServiceDescriptorProto{{Name: proto.String("A")}},
+		SourceCodeInfo: &pb.SourceCodeInfo{Location: []*pb.SourceCodeInfo_Location{
+			{Path: []int32{6, 0}, LeadingComments: proto.String(" A serves a.\n")},
+		}},
+	}))
+	RegisterDescriptor(gzipDescriptor(t, &pb.FileDescriptorProto{
+		Name:    proto.String("descriptortest/b.proto"),
+		Package: proto.String("descriptortest"),
+		Service: []*pb.ServiceDescriptorProto{{Name: proto.String("B")}},
+	}))
+
+	set, err := DescriptorSet("descriptortest.A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fd := range set.File {
+		names = append(names, fd.GetName())
+	}
+	if got := strings.Join(names, " "); got != "google/protobuf/descriptor.proto descriptortest/a.proto" {
+		t.Fatalf("files = %s", got)
+	}
+	if c := set.File[1].GetSourceCodeInfo().GetLocation()[0].GetLeadingComments(); c != " A serves a.\n" {
+		t.Errorf("comment = %q", c)
+	}
+
+	h := RoutesHandler([]Route{{Service: "descriptortest.B", Method: "Get"}})
+	r := httptest.NewRequest("GET", "/_routes?descriptors", nil)
+	r.Header.Set("Accept", ProtobufContentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	set = new(pb.FileDescriptorSet)
+	if err := proto.Unmarshal(w.Body.Bytes(), set); err != nil || len(set.File) != 1 || set.File[0].GetName() != "descriptortest/b.proto" {
+		t.Fatalf("binary set = %v, %v", set, err)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/_routes?descriptors", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" || !strings.Contains(w.Body.String(), `"name":"descriptortest/b.proto"`) {
+		t.Errorf("JSON set = %s %s", ct, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	RoutesHandler([]Route{{Service: "descriptortest.D"}}).ServeHTTP(w, httptest.NewRequest("GET", "/_routes?descriptors", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status without descriptors = %d", w.Code)
+	}
+}
+
+func TestDescriptorSetMissingDependency(t *testing.T) {
+	RegisterDescriptor(gzipDescriptor(t, &pb.FileDescriptorProto{
+		Name:       proto.String("descriptortest/c.proto"),
+		Package:    proto.String("descriptortest"),
+		Dependency: []string{"descriptortest/missing.proto"},
+		Service:    []*pb.ServiceDescriptorProto{{Name: proto.String("C")}},
+	}))
+	if _, err := DescriptorSet("descriptortest.C"); err == nil || !strings.Contains(err.Error(), "missing.proto") {
+		t.Errorf("err = %v", err)
+	}
+}
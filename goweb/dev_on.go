@@ -0,0 +1,193 @@
+//go:build !goweb_nodev
+// +build !goweb_nodev
+
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var lastErrors struct {
+	sync.Mutex
+	ring [lastErrorsSize]HandlerError
+	n    int
+}
+
+// recordError records an error answered with status in developer mode.
+func recordError(r *http.Request, status int, err error) {
+	if !DevMode {
+		return
+	}
+	recordStack(r, status, err.Error(), debug.Stack())
+}
+
+func recordStack(r *http.Request, status int, msg string, stack []byte) {
+	e := HandlerError{Time: time.Now(), Status: status, Error: msg, Stack: string(stack)}
+	if r != nil {
+		e.Method, e.Path = r.Method, r.URL.Path
+	}
+	lastErrors.Lock()
+	defer lastErrors.Unlock()
+	lastErrors.ring[lastErrors.n%lastErrorsSize] = e
+	lastErrors.n++
+}
+
+// devMode reports whether developer mode is on.
+func devMode() bool { return DevMode }
+
+// LastErrors returns the last errors recorded in developer mode, the
+// latest first.
+func LastErrors() []HandlerError {
+	lastErrors.Lock()
+	defer lastErrors.Unlock()
+	var errs []HandlerError
+	for i := lastErrors.n - 1; i >= 0 && i >= lastErrors.n-lastErrorsSize; i-- {
+		errs = append(errs, lastErrors.ring[i%lastErrorsSize])
+	}
+	return errs
+}
+
+// LastErrorsHandler serves LastErrors as JSON in developer mode, and 404
+// otherwise.
+func LastErrorsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !DevMode {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		b, _ := json.MarshalIndent(LastErrors(), "", "  ")
+		w.Write(append(b, '\n'))
+	})
+}
+
+// DevMiddleware pretty-prints the JSON responses of next in developer
+// mode, and records its panics, answering them with 500. Responses that
+// are flushed, such as server streams, are passed through unchanged.
+func DevMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !DevMode {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pw := &prettyWriter{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				msg := fmt.Sprint("panic: ", p)
+				recordStack(r, 500, msg, debug.Stack())
+				pw.buf.Reset()
+				pw.status = 500
+				pw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				pw.Write([]byte(msg))
+			}
+			pw.finish()
+		}()
+		next.ServeHTTP(pw, r)
+	})
+}
+
+// prettyWriter buffers a response to indent it if it is JSON.
+type prettyWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+	direct bool // flushed or hijacked: passed through
+}
+
+func (w *prettyWriter) WriteHeader(status int) {
+	if w.direct {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *prettyWriter) Write(b []byte) (int, error) {
+	if w.direct {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *prettyWriter) Flush() {
+	if !w.direct {
+		w.send(w.buf.Bytes())
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *prettyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("goweb: %T is not an http.Hijacker", w.ResponseWriter)
+	}
+	w.direct = true
+	return h.Hijack()
+}
+
+func (w *prettyWriter) finish() {
+	if w.direct {
+		return
+	}
+	body := w.buf.Bytes()
+	if json.Valid(body) {
+		body = IndentJSON(body)
+		w.Header().Del("Content-Length")
+	}
+	w.send(body)
+}
+
+// send writes the status and body and passes the rest through.
+func (w *prettyWriter) send(body []byte) {
+	w.direct = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
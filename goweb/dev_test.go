@@ -0,0 +1,127 @@
+//go:build !goweb_nodev
+// +build !goweb_nodev
+
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// resetDevMode turns DevMode off and empties the last errors until the
+// end of the test.
+func resetDevMode(t *testing.T) {
+	dev := DevMode
+	lastErrors.Lock()
+	ring, n := lastErrors.ring, lastErrors.n
+	lastErrors.ring, lastErrors.n = [lastErrorsSize]HandlerError{}, 0
+	lastErrors.Unlock()
+	DevMode = false
+	t.Cleanup(func() {
+		DevMode = dev
+		lastErrors.Lock()
+		lastErrors.ring, lastErrors.n = ring, n
+		lastErrors.Unlock()
+	})
+}
+
+func TestDevMode(t *testing.T) {
+	resetDevMode(t)
+	h := DevMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Write([]byte(`{"a":[1,2]}` + "\n"))
+		case "/fail":
+			WriteRequestError(w, r, Errorf(404, "NOT_FOUND", "no such thing"))
+		case "/panic":
+			panic("boom")
+		}
+	}))
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := serve(h, "/json"); w.Body.String() != `{"a":[1,2]}`+"\n" {
+		t.Errorf("response without DevMode = %q", w.Body)
+	}
+	serve(h, "/fail")
+	if w := serve(LastErrorsHandler(), "/_debug/last-errors"); w.Code != 404 || len(LastErrors()) != 0 {
+		t.Errorf("last errors without DevMode = %d, %v", w.Code, LastErrors())
+	}
+
+	DevMode = true
+	if w := serve(h, "/json"); w.Body.String() != "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n" {
+		t.Errorf("response in DevMode = %q", w.Body)
+	}
+	if w := serve(h, "/fail"); w.Code != 404 || !strings.Contains(w.Body.String(), "\n  \"code\": \"NOT_FOUND\"") {
+		t.Errorf("error in DevMode = %d %q", w.Code, w.Body)
+	}
+	if w := serve(h, "/panic"); w.Code != 500 || w.Body.String() != "panic: boom" {
+		t.Errorf("panic in DevMode = %d %q", w.Code, w.Body)
+	}
+	WriteProblem(httptest.NewRecorder(), nil, errors.New("plain"))
+	errs := LastErrors()
+	if len(errs) != 3 || errs[0].Error != "plain" || errs[1].Status != 500 || !strings.Contains(errs[1].Stack, "TestDevMode") ||
+		errs[2].Path != "/fail" || errs[2].Status != 404 {
+		t.Fatalf("LastErrors = %+v", errs)
+	}
+	if w := serve(LastErrorsHandler(), "/_debug/last-errors"); w.Code != 200 || !strings.Contains(w.Body.String(), `"error": "panic: boom"`) {
+		t.Errorf("last errors = %d %s", w.Code, w.Body)
+	}
+	for i := 0; i < lastErrorsSize; i++ {
+		recordError(nil, 500, errors.New("more"))
+	}
+	if errs := LastErrors(); len(errs) != lastErrorsSize || errs[0].Error != "more" {
+		t.Errorf("LastErrors keeps %d errors", len(errs))
+	}
+}
+
+func TestProtoTextDevMode(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Content-Type", ProtoTextContentType)
+	r.Header.Set("Accept", ProtoTextContentType)
+	if SendsProtoText(r) || AcceptsProtoText(r) {
+		t.Error("text format without DevMode")
+	}
+	DevMode = true
+	defer func() { DevMode = false }()
+	if !SendsProtoText(r) || !AcceptsProtoText(r) {
+		t.Error("no text format in DevMode")
+	}
+}
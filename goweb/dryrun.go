@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// ValidateOnlyHeader asks for a dry run of a call, like the validate_only
+// query parameter, see WithValidateOnly.
+const ValidateOnlyHeader = "X-Validate-Only"
+
+// WithValidateOnly lets clients ask for dry runs of unary calls with the
+// query parameter validate_only ("?validate_only" or
+// "?validate_only=true") or the ValidateOnlyHeader: the handlers decode,
+// validate and authorize the request and run the interceptors as usual,
+// and answer with an empty response instead of calling the
+// implementation, so that forms can be checked before they are submitted.
+// Methods with the dry_run option are called, with DryRun(ctx) true.
+func WithValidateOnly() ServerOption {
+	return func(o *ServerOptions) { o.ValidateOnly = true }
+}
+
+// ValidateOnly reports whether r asks for a dry run, see WithValidateOnly.
+// Malformed values fail with INVALID_ARGUMENT.
+func ValidateOnly(r *http.Request) (bool, error) {
+	v, ok, err := QueryFlag(r.URL.Query(), "validate_only")
+	if err != nil {
+		return false, &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: err.Error()}
+	}
+	if ok {
+		return v, nil
+	}
+	if h := r.Header.Get(ValidateOnlyHeader); h != "" {
+		if v, err = strconv.ParseBool(h); err != nil {
+			return false, &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: "goweb: malformed " + ValidateOnlyHeader + " header " + strconv.Quote(h)}
+		}
+	}
+	return v, nil
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns ctx marking its calls as dry runs: the calls of the
+// generated http clients made with it ask for validation only.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// DryRun reports whether the call in ctx is a dry run, which must not
+// change anything, see WithValidateOnly.
+func DryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
@@ -0,0 +1,120 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestValidateOnly(t *testing.T) {
+	for _, c := range []struct {
+		query, header string
+		want, fails   bool
+	}{
+		{"", "", false, false},
+		{"?validate_only", "", true, false},
+		{"?validate_only=false", "true", false, false},
+		{"", "true", true, false},
+		{"?validate_only=x", "", false, true},
+		{"", "x", false, true},
+	} {
+		r := httptest.NewRequest("GET", "/"+c.query, nil)
+		if c.header != "" {
+			r.Header.Set(ValidateOnlyHeader, c.header)
+		}
+		v, err := ValidateOnly(r)
+		if v != c.want || (err != nil) != c.fails {
+			t.Errorf("ValidateOnly(%q, %q) = %v, %v", c.query, c.header, v, err)
+		}
+		ctx, _, err := NewServerOptions(WithValidateOnly()).NewContext(httptest.NewRecorder(), r)
+		if (err != nil) != c.fails || err == nil && DryRun(ctx) != c.want {
+			t.Errorf("NewContext(%q, %q) = %v", c.query, c.header, err)
+		}
+	}
+	ctx, _, _ := NewServerOptions().NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/?validate_only", nil))
+	if DryRun(ctx) {
+		t.Error("dry run without WithValidateOnly")
+	}
+}
+
+func TestMethodHandlerDryRun(t *testing.T) {
+	called := 0
+	m := &Method{
+		Route: &Route{Service: "goweb.Captures", Method: "Get"},
+		New:   func() interface{} { return new(CapturedCall) },
+		Call: func(srv interface{}, ctx context.Context, in interface{}) (interface{}, error) {
+			called++
+			return in, nil
+		},
+		NewResponse: func() interface{} { return new(CapturedCall) },
+	}
+	h := MethodHandler(m, nil, NewServerOptions(WithValidateOnly()))
+	for _, c := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/?validate_only", 200, "{}"},
+		{"/", 200, `{"method":"x"}`},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", c.url, strings.NewReader(`{"method":"x"}`)))
+		if w.Code != c.code || strings.TrimSpace(w.Body.String()) != c.want {
+			t.Errorf("POST %s = %d %s", c.url, w.Code, w.Body)
+		}
+	}
+	if called != 1 {
+		t.Errorf("called %d times", called)
+	}
+}
+
+func TestUpstreamDryRun(t *testing.T) {
+	var got string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(ValidateOnlyHeader)
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL}
+	route := Route{Service: "goweb.Captures", Method: "Get"}
+	if err := u.Call(WithDryRun(context.Background()), route, &CapturedCall{}, &CapturedCall{}); err != nil || got != "true" {
+		t.Errorf("dry run call = %v, header %q", err, got)
+	}
+	if err := u.Call(context.Background(), route, &CapturedCall{}, &CapturedCall{}); err != nil || got != "" {
+		t.Errorf("call = %v, header %q", err, got)
+	}
+}
@@ -0,0 +1,110 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/zenazn/goji/web"
+	"golang.org/x/net/context"
+)
+
+// A DynamicHandler serves RPCs of services for which no code was generated.
+// The request body is passed as received (JSON); the returned bytes are
+// written as the response body.
+type DynamicHandler interface {
+	ServeRPC(ctx context.Context, route Route, body []byte) ([]byte, error)
+}
+
+// DynamicHandlerFunc adapts a function to a DynamicHandler.
+type DynamicHandlerFunc func(ctx context.Context, route Route, body []byte) ([]byte, error)
+
+// ServeRPC calls f(ctx, route, body).
+func (f DynamicHandlerFunc) ServeRPC(ctx context.Context, route Route, body []byte) ([]byte, error) {
+	return f(ctx, route, body)
+}
+
+// NewDynamicMux mounts the routes of every service in set under prefix,
+// exactly as the generated New<Service>Mux functions would, and dispatches
+// them to h. It lets a gateway proxy services known only by their
+// descriptors.
+func NewDynamicMux(set *pb.FileDescriptorSet, prefix string, h DynamicHandler) *web.Mux {
+	msgs := IndexMessages(set.GetFile())
+	router := web.New()
+	for _, f := range set.GetFile() {
+		for _, route := range RoutesOf(f) {
+			route.Hash = HashRoute(route, msgs)
+			if t, err := route.Template(); err == nil && t != nil {
+				router.Handle(TemplatePattern(prefix, route.Verb, t), dynamicRoute(route, h))
+				continue
+			}
+			router.Handle(JoinPath(prefix, route.Path), dynamicRoute(route, h))
+		}
+	}
+	return router
+}
+
+func dynamicRoute(route Route, h DynamicHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RouteHashHeader, route.Hash)
+		if route.ClientStreaming || route.ServerStreaming {
+			Unsupported(r, route, UnsupportedStreaming)
+			w.WriteHeader(501)
+			w.Write([]byte(`Streaming functions over http are not supported`))
+			return
+		}
+		content, err := ReadBody(r)
+		defer r.Body.Close()
+		if err != nil {
+			w.WriteHeader(BodyStatus(err))
+			w.Write([]byte(err.Error()))
+			log.Println(err.Error())
+			return
+		}
+		if !json.Valid(content) {
+			w.WriteHeader(400)
+			w.Write([]byte("request body is not valid JSON"))
+			return
+		}
+		res, err := h.ServeRPC(context.Background(), route, content)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			log.Println(err.Error())
+			return
+		}
+		w.Write(res)
+	}
+}
@@ -0,0 +1,78 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"golang.org/x/net/context"
+)
+
+func TestDynamicMux(t *testing.T) {
+	set := &pb.FileDescriptorSet{File: []*pb.FileDescriptorProto{{
+		Name:        proto.String("echo.proto"),
+		Package:     proto.String("pkg"),
+		MessageType: []*pb.DescriptorProto{{Name: proto.String("Msg")}},
+		Service: []*pb.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*pb.MethodDescriptorProto{{
+				Name:       proto.String("Say"),
+				InputType:  proto.String(".pkg.Msg"),
+				OutputType: proto.String(".pkg.Msg"),
+			}},
+		}},
+	}}}
+	var got Route
+	mux := NewDynamicMux(set, "/api/", DynamicHandlerFunc(func(ctx context.Context, route Route, body []byte) ([]byte, error) {
+		got = route
+		return body, nil
+	}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/echo/say", strings.NewReader(`{"a":1}`)))
+	if rec.Code != 200 || rec.Body.String() != `{"a":1}` {
+		t.Errorf("POST /api/echo/say = %d %q", rec.Code, rec.Body.String())
+	}
+	if got.FullMethod() != "/pkg.Echo/Say" || rec.Header().Get(RouteHashHeader) != got.Hash {
+		t.Errorf("handler got route %+v, hash header %q", got, rec.Header().Get(RouteHashHeader))
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/echo/say", strings.NewReader(`{`)))
+	if rec.Code != 400 {
+		t.Errorf("invalid JSON: got status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,124 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// JoinPath joins a mux prefix and a path with exactly one slash.
+func JoinPath(prefix, path string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// RoutesHandler returns a handler that serves routes as JSON,
+//
+//	{"routes": [{"service": "pkg.Users", "method": "Get", ...}, ...]}
+//
+// for dynamic clients and gateways. If routes is nil, it serves
+// all routes of the registry at the time of the request. With the query
+// parameter descriptors, as in /_routes?descriptors, it serves the
+// DescriptorSet of their services instead, and with errors the
+// ServiceErrorCodes of their services,
+//
+//	{"services": [{"service": "pkg.Users", "codes": [{"code": "NOT_FOUND", "status": 404, ...}, ...]}]}
+func RoutesHandler(routes []Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list := routes
+		if list == nil {
+			list = Routes()
+		}
+		if _, ok := r.URL.Query()["descriptors"]; ok {
+			writeDescriptorSet(w, r, list)
+			return
+		}
+		if _, ok := r.URL.Query()["errors"]; ok {
+			writeErrorCodes(w, list)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Routes []Route `json:"routes"`
+		}{list})
+	})
+}
+
+// VersionInfo is the contract a generated mux serves, see VersionHandler.
+type VersionInfo struct {
+	File        string `json:"file"`        // the proto file of the service
+	Generator   string `json:"generator"`   // goweb version of the generator
+	Fingerprint string `json:"fingerprint"` // sha256 of its descriptors
+}
+
+// buildModule is a module of the build info, as served by VersionHandler.
+type buildModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Sum     string `json:"sum,omitempty"`
+}
+
+// VersionHandler returns a handler that serves info and the build info of
+// the running program as JSON,
+//
+//	{"file": "users.proto", "generator": "1.0.0", "fingerprint": "sha256:…",
+//	 "goweb": "1.0.0", "go": "go1.21.0", "main": {"path": …, "version": …},
+//	 "settings": {"vcs.revision": …, …}}
+//
+// so operators can check which contract a running instance serves. The
+// fingerprint covers the descriptors of the file and of its imports
+// without comments; it changes with the contract, not its documentation.
+func VersionHandler(info VersionInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := struct {
+			VersionInfo
+			Goweb    string            `json:"goweb"`
+			Go       string            `json:"go"`
+			Main     *buildModule      `json:"main,omitempty"`
+			Settings map[string]string `json:"settings,omitempty"`
+		}{VersionInfo: info, Goweb: Version, Go: runtime.Version()}
+		if build, ok := debug.ReadBuildInfo(); ok {
+			v.Main = &buildModule{build.Main.Path, build.Main.Version, build.Main.Sum}
+			for _, s := range build.Settings {
+				if v.Settings == nil {
+					v.Settings = map[string]string{}
+				}
+				v.Settings[s.Key] = s.Value
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	})
+}
@@ -0,0 +1,70 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	VersionHandler(VersionInfo{File: "a.proto", Generator: "0.9.0", Fingerprint: "sha256:00"}).ServeHTTP(w, httptest.NewRequest("GET", "/_version", nil))
+	var v map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{"file": "a.proto", "generator": "0.9.0", "fingerprint": "sha256:00", "goweb": Version} {
+		if v[k] != want {
+			t.Errorf("%s = %v, want %q", k, v[k], want)
+		}
+	}
+	if v["go"] == "" || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("version = %s", w.Body)
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	for _, c := range []struct{ prefix, path, want string }{
+		{"/", "users/get", "/users/get"},
+		{"", "users/get", "/users/get"},
+		{"/api", "users/get", "/api/users/get"},
+		{"/api/", "users/get", "/api/users/get"},
+		{"/api/", "/v1/users", "/api/v1/users"},
+		{"/", "/v1/users", "/v1/users"},
+	} {
+		if got := JoinPath(c.prefix, c.path); got != c.want {
+			t.Errorf("JoinPath(%q, %q) = %q, want %q", c.prefix, c.path, got, c.want)
+		}
+	}
+}
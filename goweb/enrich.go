@@ -0,0 +1,71 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// An Enricher completes the request in of a method before it is
+// dispatched, e.g. sets its user or tenant fields from the principal in
+// ctx. An error fails the call.
+type Enricher func(ctx context.Context, in proto.Message) error
+
+var enrichers = struct {
+	sync.RWMutex
+	m map[string]Enricher
+}{m: map[string]Enricher{}}
+
+// RegisterEnricher makes e available to the goweb.enrich method option
+// under name, replacing any enricher of that name.
+func RegisterEnricher(name string, e Enricher) {
+	enrichers.Lock()
+	defer enrichers.Unlock()
+	enrichers.m[name] = e
+}
+
+// Enrich applies the named enricher to the request in of a method.
+// Generated handlers call it for methods with a goweb.enrich option; an
+// unknown enricher is an internal error, since the implementation relies
+// on the fields it sets.
+func Enrich(ctx context.Context, name string, in proto.Message) error {
+	enrichers.RLock()
+	e, ok := enrichers.m[name]
+	enrichers.RUnlock()
+	if !ok {
+		return Errorf(500, "INTERNAL", "unknown enricher %q", name)
+	}
+	return e(ctx, in)
+}
@@ -0,0 +1,166 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// An EnumPolicy says what generated handlers do with enum values their
+// enum does not define, which clients and servers built from other
+// versions of the proto send.
+type EnumPolicy int
+
+const (
+	// EnumPreserve keeps unknown numbers as they are, as proto3 does. Unknown
+	// names cannot be kept and are rejected.
+	EnumPreserve EnumPolicy = iota
+	// EnumReject fails on unknown numbers and names.
+	EnumReject
+	// EnumZero replaces unknown numbers and names by 0, the default value
+	// of every proto3 enum.
+	EnumZero
+)
+
+// DecodeJSON decodes data into v like UnmarshalJSON and applies the policy
+// to the unknown values of its enums. Generated handlers decode requests
+// with enums with it for the unknown_enums parameter.
+func DecodeJSON(data []byte, v interface{}, enums EnumPolicy) error {
+	if err := unmarshalJSON(data, v, enums); err != nil {
+		return err
+	}
+	if enums == EnumPreserve {
+		return nil
+	}
+	return CheckEnums(v, enums)
+}
+
+// CheckEnums returns an error naming the first field of m, as encoded to
+// JSON, whose enum does not define its value, with EnumReject, and sets
+// all such fields to 0 with EnumZero. With EnumPreserve it does nothing.
+func CheckEnums(m interface{}, enums EnumPolicy) error {
+	if enums == EnumPreserve {
+		return nil
+	}
+	return checkEnums(reflect.ValueOf(m), "", "", enums)
+}
+
+func checkEnums(v reflect.Value, path, enum string, enums EnumPolicy) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkEnums(v.Elem(), path, enum, enums)
+		}
+	case reflect.Int32:
+		if enum == "" || knownEnum(enum, int32(v.Int())) {
+			return nil
+		}
+		if enums == EnumReject || !v.CanSet() {
+			return fmt.Errorf("goweb: field %s has the value %d, which enum %s does not define", path, v.Int(), enum)
+		}
+		v.SetInt(0)
+	case reflect.Struct:
+		for name, i := range jsonFields(v.Type()) {
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := checkEnums(v.Field(i), name, enumOf(v.Type().Field(i).Tag, "protobuf"), enums); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnums(v.Index(i), fmt.Sprintf("%s[%d]", path, i), enum, enums); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := v.MapIndex(k)
+			if e.Kind() != reflect.Int32 {
+				if err := checkEnums(e, fmt.Sprintf("%s[%v]", path, k), enum, enums); err != nil {
+					return err
+				}
+			} else if enum != "" && !knownEnum(enum, int32(e.Int())) {
+				if enums == EnumReject {
+					return fmt.Errorf("goweb: field %s[%v] has the value %d, which enum %s does not define", path, k, e.Int(), enum)
+				}
+				v.SetMapIndex(k, reflect.Zero(e.Type()))
+			}
+		}
+	}
+	return nil
+}
+
+// enumOf returns the full name of the enum in the protobuf struct tag key
+// of a field, or "" if it is not an enum. The enum of map values is in the
+// tag of the map field under "protobuf_val".
+func enumOf(tag reflect.StructTag, key string) string {
+	for _, opt := range strings.Split(tag.Get(key), ",") {
+		if strings.HasPrefix(opt, "enum=") {
+			return opt[len("enum="):]
+		}
+	}
+	if key == "protobuf" {
+		return enumOf(tag, "protobuf_val")
+	}
+	return ""
+}
+
+func knownEnum(enum string, n int32) bool {
+	for _, v := range proto.EnumValueMap(enum) {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func isEnum(t reflect.Type) bool {
+	return t.Kind() == reflect.Int32
+}
+
+// enumNumber returns the number of the enum value name as a JSON number,
+// or 0 for unknown names with EnumZero, or an error.
+func enumNumber(enum, name string, enums EnumPolicy) (interface{}, error) {
+	if n, ok := proto.EnumValueMap(enum)[name]; ok {
+		return n, nil
+	}
+	if enums == EnumZero {
+		return 0, nil
+	}
+	return nil, fmt.Errorf("goweb: enum %s has no value %s", enum, name)
+}
@@ -0,0 +1,88 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+type color int32
+
+func init() {
+	proto.RegisterEnum("goweb.test.Color", map[int32]string{0: "COLOR_UNSPECIFIED", 1: "RED", 2: "GREEN"},
+		map[string]int32{"COLOR_UNSPECIFIED": 0, "RED": 1, "GREEN": 2})
+}
+
+type palette struct {
+	Main   color            `protobuf:"varint,1,opt,name=main,proto3,enum=goweb.test.Color" json:"main,omitempty"`
+	Others []color          `protobuf:"varint,2,rep,packed,name=others,proto3,enum=goweb.test.Color" json:"others,omitempty"`
+	ByName map[string]color `protobuf:"bytes,3,rep,name=by_name,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=goweb.test.Color" json:"by_name,omitempty"`
+}
+
+func (m *palette) Reset()         { *m = palette{} }
+func (m *palette) String() string { return proto.CompactTextString(m) }
+func (*palette) ProtoMessage()    {}
+
+func TestEnumPolicy(t *testing.T) {
+	var p palette
+	if err := UnmarshalJSON([]byte(`{"main":"GREEN","others":["RED",2],"by_name":{"a":"RED"}}`), &p); err != nil ||
+		p.Main != 2 || len(p.Others) != 2 || p.Others[0] != 1 || p.ByName["a"] != 1 {
+		t.Fatalf("UnmarshalJSON of enum names = %+v, %v", p, err)
+	}
+	if err := UnmarshalJSON([]byte(`{"main":"BLUE"}`), &p); err == nil || !strings.Contains(err.Error(), "no value BLUE") {
+		t.Errorf("UnmarshalJSON of an unknown name = %v", err)
+	}
+
+	p = palette{}
+	if err := DecodeJSON([]byte(`{"main":7}`), &p, EnumPreserve); err != nil || p.Main != 7 {
+		t.Errorf("DecodeJSON with EnumPreserve = %v, %v", p.Main, err)
+	}
+	p = palette{}
+	if err := DecodeJSON([]byte(`{"others":[1,7]}`), &p, EnumReject); err == nil || !strings.Contains(err.Error(), "field others[1] has the value 7") {
+		t.Errorf("DecodeJSON with EnumReject = %v", err)
+	}
+	p = palette{}
+	if err := DecodeJSON([]byte(`{"main":"BLUE","others":[9,1],"by_name":{"a":5}}`), &p, EnumZero); err != nil ||
+		p.Main != 0 || p.Others[0] != 0 || p.Others[1] != 1 || p.ByName["a"] != 0 {
+		t.Errorf("DecodeJSON with EnumZero = %+v, %v", p, err)
+	}
+
+	if err := CheckEnums(&palette{ByName: map[string]color{"x": 3}}, EnumReject); err == nil || !strings.Contains(err.Error(), "by_name[x]") {
+		t.Errorf("CheckEnums = %v", err)
+	}
+	if err := CheckEnums(&palette{Main: 3}, EnumPreserve); err != nil {
+		t.Errorf("CheckEnums with EnumPreserve = %v", err)
+	}
+}
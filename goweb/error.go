@@ -0,0 +1,275 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// An Error is a structured error of a method. Generated handlers answer
+// an *Error returned by an implementation with its status and the JSON
+//
+//	{"code": "NOT_FOUND", "message": "...", "domain": "pkg.Service", "retryable": true,
+//	 "violations": [{"field": "...", "description": "..."}],
+//	 "details": [{"@type": "pkg.Msg", "value": {...}}]}
+//
+// and Upstream.Call, and so the generated http clients, decode such a
+// response back into an *Error, so callers can switch on the code.
+type Error struct {
+	Status  int             // http status; by ErrorStatus(Domain, Code) if 0
+	Code    string          // machine readable code, e.g. "NOT_FOUND"
+	Message string          // human readable message
+	Domain  string          // the service the error comes from, if set
+	Details []proto.Message // messages of registered proto types
+
+	// Violations list the invalid fields of an INVALID_ARGUMENT error.
+	Violations []FieldViolation
+
+	// Retryable tells clients that the call may succeed if retried;
+	// Upstream retries such calls. RetryAfter, if positive, is sent as
+	// the Retry-After header, the time to wait before the retry.
+	Retryable  bool
+	RetryAfter time.Duration
+}
+
+// A FieldViolation describes an invalid field of a request.
+type FieldViolation struct {
+	Field       string `json:"field"` // path of the field, e.g. "address.street"
+	Description string `json:"description"`
+	Expected    string `json:"expected,omitempty"` // the JSON the field takes, see DebugError
+}
+
+// Errorf returns an *Error with status, code and a formatted message.
+func Errorf(status int, code, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return "goweb: " + e.Message
+	}
+	return "goweb: " + e.Code + ": " + e.Message
+}
+
+type errorDetail struct {
+	Type  string          `json:"@type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type errorBody struct {
+	Code       string           `json:"code,omitempty"`
+	Message    string           `json:"message"`
+	Domain     string           `json:"domain,omitempty"`
+	Retryable  bool             `json:"retryable,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	Details    []errorDetail    `json:"details,omitempty"`
+}
+
+// MarshalJSON encodes e as the body of an error response.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	b, err := e.body()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+func (e *Error) body() (errorBody, error) {
+	b := errorBody{Code: e.Code, Message: e.Message, Domain: e.Domain, Retryable: e.Retryable, Violations: e.Violations}
+	for _, d := range e.Details {
+		v, err := json.Marshal(d)
+		if err != nil {
+			return b, err
+		}
+		b.Details = append(b.Details, errorDetail{proto.MessageName(d), v})
+	}
+	return b, nil
+}
+
+// UnmarshalJSON decodes the body of an error response. Details of types
+// that are not registered in this process are dropped.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var b errorBody
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	e.set(b)
+	return nil
+}
+
+func (e *Error) set(b errorBody) {
+	e.Code, e.Message, e.Domain, e.Violations, e.Details = b.Code, b.Message, b.Domain, b.Violations, nil
+	e.Retryable = b.Retryable
+	for _, d := range b.Details {
+		t := proto.MessageType(d.Type)
+		if t == nil || t.Kind() != reflect.Ptr {
+			continue
+		}
+		m, ok := reflect.New(t.Elem()).Interface().(proto.Message)
+		if !ok || json.Unmarshal(d.Value, m) != nil {
+			continue
+		}
+		e.Details = append(e.Details, m)
+	}
+}
+
+// AsError returns err as an *Error: err itself, or the *Error it wraps,
+// or else, for errors with an HTTPStatus() int method, an *Error with
+// that status and the canonical code of the status (e.g. NOT_FOUND for
+// 404), and for gRPC status errors (with a GRPCStatus() method, see
+// google.golang.org/grpc/status) one with the code, message and details
+// of the status, so implementations may return the errors of their
+// libraries. It reports false for other errors.
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var hs interface{ HTTPStatus() int }
+	if errors.As(err, &hs) {
+		e := &Error{Status: hs.HTTPStatus(), Message: err.Error()}
+		e.Code = GRPCCodeName(GRPCCode(e))
+		return e, true
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := grpcStatusError(err); ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// grpcStatusError converts the *status.Status of a gRPC status error,
+// which this package does not import, with reflection.
+func grpcStatusError(err error) (*Error, bool) {
+	m := reflect.ValueOf(err).MethodByName("GRPCStatus")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil, false
+	}
+	s := m.Call(nil)[0]
+	if s.Kind() == reflect.Ptr && s.IsNil() {
+		return nil, false
+	}
+	code, msg := s.MethodByName("Code"), s.MethodByName("Message")
+	if !code.IsValid() || !msg.IsValid() || code.Type().NumIn() != 0 || msg.Type().NumIn() != 0 {
+		return nil, false
+	}
+	n := code.Call(nil)[0]
+	if n.Kind() != reflect.Uint32 || n.Uint() == 0 || n.Uint() >= uint64(len(grpcCodes)) {
+		return nil, false
+	}
+	e := &Error{Code: GRPCCodeName(int(n.Uint()))}
+	e.Message, _ = msg.Call(nil)[0].Interface().(string)
+	if details := s.MethodByName("Details"); details.IsValid() && details.Type().NumIn() == 0 {
+		list, _ := details.Call(nil)[0].Interface().([]interface{})
+		for _, d := range list {
+			if m, ok := d.(proto.Message); ok {
+				e.Details = append(e.Details, m)
+			}
+		}
+	}
+	return e, true
+}
+
+// WriteError answers a failed call with err: an *Error (see AsError) with
+// its status and JSON body, other errors with 500 and the body of an
+// INTERNAL error, whose message does not reveal err, which is logged.
+func WriteError(w http.ResponseWriter, err error) {
+	WriteRequestError(w, nil, err)
+}
+
+// WriteRequestError is WriteError for a call of the request r: the
+// message of an *Error is localized for the Accept-Language of r with
+// ErrorCatalog, if set. Generated handlers use it.
+func WriteRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := AsError(err)
+	if ok && r != nil && ErrorCatalog != nil && e.Code != "" {
+		e = localize(w, r, e)
+	}
+	writeError(w, r, e, err)
+}
+
+// internalError is the error answered for errors that are no *Error.
+var internalError = &Error{Status: 500, Code: "INTERNAL", Message: "internal error"}
+
+// writeError writes e, or an INTERNAL error if e is nil, as the error err
+// of the request r.
+func writeError(w http.ResponseWriter, r *http.Request, e *Error, err error) {
+	if e == nil {
+		log.Println(err.Error())
+		e = internalError
+	}
+	body, merr := json.Marshal(e)
+	if merr != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(merr.Error()))
+		log.Println(merr.Error())
+		return
+	}
+	status := e.Status
+	if status == 0 {
+		status = ErrorStatus(e.Domain, e.Code)
+	}
+	recordError(r, status, err)
+	setRetryAfter(w, e)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// DecodeError returns the error of a non-2xx response with status and
+// body: an *Error if the body is a JSON error object or an RFC 7807
+// problem, an *UpstreamError otherwise.
+func DecodeError(status int, body []byte) error {
+	e := &Error{}
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(body, &probe) != nil {
+		return &UpstreamError{StatusCode: status, Body: body}
+	}
+	if probe["message"] != nil && json.Unmarshal(body, e) == nil {
+		e.Status = status
+		return e
+	}
+	if probe["title"] != nil && decodeProblem(body, e) == nil {
+		e.Status = status
+		return e
+	}
+	return &UpstreamError{StatusCode: status, Body: body}
+}
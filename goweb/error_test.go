@@ -0,0 +1,115 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func init() {
+	proto.RegisterType((*CapturedCall)(nil), "goweb.CapturedCall")
+}
+
+func TestErrorRoundTrip(t *testing.T) {
+	detail := &CapturedCall{Method: "/pkg.Users/Get", Path: "/users/get"}
+	w := httptest.NewRecorder()
+	WriteError(w, &Error{Status: 404, Code: "NOT_FOUND", Message: "no user 7", Details: []proto.Message{detail}})
+	if w.Code != 404 || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("WriteError: %d %s", w.Code, w.Header())
+	}
+	err := DecodeError(w.Code, w.Body.Bytes())
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("DecodeError = %#v", err)
+	}
+	if e.Status != 404 || e.Code != "NOT_FOUND" || e.Message != "no user 7" || len(e.Details) != 1 || !reflect.DeepEqual(e.Details[0], detail) {
+		t.Errorf("DecodeError = %+v", e)
+	}
+	if _, ok := DecodeError(502, []byte("bad gateway")).(*UpstreamError); !ok {
+		t.Error("plain text body not an UpstreamError")
+	}
+
+	w = httptest.NewRecorder()
+	WriteError(w, ErrClientGone)
+	if w.Code != 500 || w.Body.String() != `{"code":"INTERNAL","message":"internal error"}` {
+		t.Errorf("WriteError(plain error): %d %q", w.Code, w.Body)
+	}
+}
+
+type httpStatusError int
+
+func (e httpStatusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e httpStatusError) HTTPStatus() int { return int(e) }
+
+type fakeCode uint32
+
+type fakeStatus struct {
+	code    fakeCode
+	message string
+	details []interface{}
+}
+
+func (s *fakeStatus) Code() fakeCode          { return s.code }
+func (s *fakeStatus) Message() string         { return s.message }
+func (s *fakeStatus) Details() []interface{}  { return s.details }
+func (s *fakeStatus) Error() string           { return s.message }
+func (s *fakeStatus) GRPCStatus() *fakeStatus { return s }
+
+func TestAsError(t *testing.T) {
+	e, ok := AsError(httpStatusError(404))
+	if !ok || e.Status != 404 || e.Code != "NOT_FOUND" || e.Message != "status 404" {
+		t.Errorf("HTTPStatus: %+v %v", e, ok)
+	}
+	detail := &CapturedCall{Method: "m"}
+	e, ok = AsError(fmt.Errorf("get: %w", &fakeStatus{code: 7, message: "denied", details: []interface{}{detail}}))
+	if !ok || e.Code != "PERMISSION_DENIED" || e.Message != "denied" || len(e.Details) != 1 || e.Details[0] != detail {
+		t.Errorf("GRPCStatus: %+v %v", e, ok)
+	}
+	w := httptest.NewRecorder()
+	WriteRequestError(w, nil, &fakeStatus{code: 5, message: "no user"})
+	if e, ok := DecodeError(w.Code, w.Body.Bytes()).(*Error); w.Code != 404 || !ok || e.Code != "NOT_FOUND" || e.Message != "no user" {
+		t.Errorf("WriteRequestError(GRPCStatus): %d %s", w.Code, w.Body)
+	}
+	want := &Error{Code: "ABORTED"}
+	if e, ok = AsError(fmt.Errorf("tx: %w", want)); !ok || e != want {
+		t.Errorf("wrapped *Error: %+v %v", e, ok)
+	}
+	if e, ok = AsError(errors.New("boom")); ok {
+		t.Errorf("plain error: %+v", e)
+	}
+}
@@ -0,0 +1,170 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// An ErrorCode describes an error code a service answers with, for
+// client generators, so that every SDK surfaces the same errors.
+type ErrorCode struct {
+	Code     string `json:"code"`      // e.g. "NOT_FOUND"
+	Status   int    `json:"status"`    // its http status, see ErrorStatus
+	GRPCCode int    `json:"grpc_code"` // its gRPC code number, see GRPCCode
+
+	// Message is the template of the messages of the errors, with
+	// {placeholders} for their variable parts, e.g. `{kind} "{id}" not
+	// found`, if they follow one.
+	Message string `json:"message,omitempty"`
+
+	// ProblemType is the last segment of the type of its RFC 7807
+	// problems, see ProblemTypeBase, e.g. "not-found".
+	ProblemType string `json:"problem_type"`
+}
+
+// ErrorCodes lists the error codes of a service, see ServiceErrorCodes.
+type ErrorCodes struct {
+	Service string      `json:"service"` // the service, also the domain of its errors
+	Codes   []ErrorCode `json:"codes"`
+}
+
+// errorMessages are the templates of the messages of the ErrorFactory
+// constructors that format them.
+var errorMessages = map[string]string{
+	"NOT_FOUND":        `{kind} "{id}" not found`,
+	"ALREADY_EXISTS":   `{kind} "{id}" already exists`,
+	"INVALID_ARGUMENT": "invalid request: {violations}",
+	"UNIMPLEMENTED":    "{method} is not implemented",
+}
+
+var errorCodePattern = regexp.MustCompile(`^([A-Z][A-Z0-9_]*)=([0-9]{3})(?:\s+(.*))?$`)
+
+// ParseErrorCode parses the declaration of an error code of the
+// error_code option, "CODE=STATUS" optionally followed by the template
+// of its messages, e.g. "QUOTA_EXCEEDED=429 quota {quota} exceeded".
+func ParseErrorCode(s string) (ErrorCode, error) {
+	m := errorCodePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return ErrorCode{}, fmt.Errorf("goweb: error code %q is not CODE=STATUS [message]", s)
+	}
+	status, _ := strconv.Atoi(m[2])
+	if status < 400 || status > 599 {
+		return ErrorCode{}, fmt.Errorf("goweb: status of error code %q is not an error status", s)
+	}
+	return ErrorCode{Code: m[1], Status: status, Message: m[3]}, nil
+}
+
+var declaredCodes struct {
+	sync.Mutex
+	m map[string][]ErrorCode
+}
+
+// DeclareErrorCodes adds the error codes to those of the service, with
+// their statuses in its ErrorStatuses. Generated code declares the codes
+// of the error_code options of services.
+func DeclareErrorCodes(service string, codes ...ErrorCode) {
+	statuses := ErrorStatuses(service)
+	statusesMu.Lock()
+	for _, c := range codes {
+		statuses[c.Code] = c.Status
+	}
+	statusesMu.Unlock()
+	declaredCodes.Lock()
+	defer declaredCodes.Unlock()
+	if declaredCodes.m == nil {
+		declaredCodes.m = map[string][]ErrorCode{}
+	}
+	declaredCodes.m[service] = append(declaredCodes.m[service], codes...)
+}
+
+// ServiceErrorCodes returns the error codes of the service: the canonical
+// codes, in the order of their gRPC code numbers, followed by the declared
+// ones, in the order of their declarations, each with the status
+// ErrorStatus maps it to at the time of the call.
+func ServiceErrorCodes(service string) ErrorCodes {
+	declaredCodes.Lock()
+	declared := declaredCodes.m[service]
+	declaredCodes.Unlock()
+	codes := ErrorCodes{Service: service}
+	seen := map[string]bool{}
+	add := func(code, msg string) {
+		if seen[code] {
+			return
+		}
+		seen[code] = true
+		status := ErrorStatus(service, code)
+		codes.Codes = append(codes.Codes, ErrorCode{
+			Code:        code,
+			Status:      status,
+			GRPCCode:    GRPCCode(&Error{Status: status, Code: code}),
+			Message:     msg,
+			ProblemType: strings.ToLower(strings.Replace(code, "_", "-", -1)),
+		})
+	}
+	for _, code := range grpcCodes[1:] {
+		add(code, errorMessages[code])
+	}
+	for _, c := range declared {
+		add(c.Code, c.Message)
+	}
+	return codes
+}
+
+// writeErrorCodes serves the error codes of the services of routes, as
+// in /_routes?errors.
+func writeErrorCodes(w http.ResponseWriter, routes []Route) {
+	var services []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		if !seen[route.Service] {
+			seen[route.Service] = true
+			services = append(services, route.Service)
+		}
+	}
+	sort.Strings(services)
+	list := make([]ErrorCodes, len(services))
+	for i, s := range services {
+		list[i] = ServiceErrorCodes(s)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Services []ErrorCodes `json:"services"`
+	}{list})
+}
@@ -0,0 +1,103 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorCode(t *testing.T) {
+	c, err := ParseErrorCode("QUOTA_EXCEEDED=429 quota {quota} exceeded")
+	if err != nil || c != (ErrorCode{Code: "QUOTA_EXCEEDED", Status: 429, Message: "quota {quota} exceeded"}) {
+		t.Errorf("ParseErrorCode = %+v, %v", c, err)
+	}
+	if c, err := ParseErrorCode("GONE=410"); err != nil || c.Message != "" {
+		t.Errorf("ParseErrorCode = %+v, %v", c, err)
+	}
+	for _, s := range []string{"quota=429", "QUOTA", "QUOTA=200", "QUOTA=4290"} {
+		if _, err := ParseErrorCode(s); err == nil {
+			t.Errorf("ParseErrorCode(%q) succeeded", s)
+		}
+	}
+}
+
+func TestServiceErrorCodes(t *testing.T) {
+	DeclareErrorCodes("goweb.Quotas", ErrorCode{Code: "QUOTA_EXCEEDED", Status: 429, Message: "quota {quota} exceeded"})
+	if s := ErrorStatus("goweb.Quotas", "QUOTA_EXCEEDED"); s != 429 {
+		t.Errorf("status = %d", s)
+	}
+	codes := ServiceErrorCodes("goweb.Quotas")
+	byCode := map[string]ErrorCode{}
+	for _, c := range codes.Codes {
+		byCode[c.Code] = c
+	}
+	if len(codes.Codes) != 17 || codes.Codes[0].Code != "CANCELLED" || codes.Codes[16].Code != "QUOTA_EXCEEDED" {
+		t.Errorf("codes = %+v", codes.Codes)
+	}
+	if c := byCode["QUOTA_EXCEEDED"]; c.GRPCCode != 8 || c.ProblemType != "quota-exceeded" {
+		t.Errorf("QUOTA_EXCEEDED = %+v", c)
+	}
+	if c := byCode["NOT_FOUND"]; c.Status != 404 || c.GRPCCode != 5 {
+		t.Errorf("NOT_FOUND = %+v", c)
+	}
+
+	// the templates match the messages of the ErrorFactory
+	f := ErrorFactory{}
+	for code, msg := range map[string]string{
+		"NOT_FOUND":      f.NotFound("user", "7").Message,
+		"ALREADY_EXISTS": f.AlreadyExists("user", "7").Message,
+		"UNIMPLEMENTED":  f.Unimplemented("/pkg.Users/Get").Message,
+	} {
+		r := strings.NewReplacer("{kind}", "user", "{id}", "7", "{method}", "/pkg.Users/Get")
+		if got := r.Replace(byCode[code].Message); got != msg {
+			t.Errorf("%s: template gives %q, not %q", code, got, msg)
+		}
+	}
+}
+
+func TestRoutesHandlerErrors(t *testing.T) {
+	DeclareErrorCodes("goweb.Quotas", ErrorCode{Code: "QUOTA_EXCEEDED", Status: 429})
+	w := httptest.NewRecorder()
+	RoutesHandler([]Route{{Service: "goweb.Quotas", Method: "Use"}}).ServeHTTP(w, httptest.NewRequest("GET", "/_routes?errors", nil))
+	var out struct {
+		Services []ErrorCodes `json:"services"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || len(out.Services) != 1 || out.Services[0].Service != "goweb.Quotas" {
+		t.Fatalf("errors = %s, %v", w.Body, err)
+	}
+	if codes := out.Services[0].Codes; codes[len(codes)-1].Code != "QUOTA_EXCEEDED" {
+		t.Errorf("codes = %+v", codes)
+	}
+}
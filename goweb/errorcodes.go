@@ -0,0 +1,118 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorFactory constructs the *Error values of the common error codes,
+// with consistent codes and messages, and the statuses ErrorStatus maps
+// the codes to. The generated
+// <Service>Errors variables are ErrorFactories for the errors of a
+// service; the zero ErrorFactory leaves Domain empty.
+type ErrorFactory struct {
+	Domain string
+}
+
+// new returns the error of code, with the status ErrorStatus maps it to.
+func (f ErrorFactory) new(code, msg string) *Error {
+	return &Error{Status: ErrorStatus(f.Domain, code), Code: code, Message: msg, Domain: f.Domain}
+}
+
+// NotFound reports that the kind of thing with the id does not exist.
+func (f ErrorFactory) NotFound(kind, id string) *Error {
+	return f.new("NOT_FOUND", fmt.Sprintf("%s %q not found", kind, id))
+}
+
+// AlreadyExists reports that the kind of thing with the id exists already.
+func (f ErrorFactory) AlreadyExists(kind, id string) *Error {
+	return f.new("ALREADY_EXISTS", fmt.Sprintf("%s %q already exists", kind, id))
+}
+
+// InvalidArgument reports invalid fields of a request.
+func (f ErrorFactory) InvalidArgument(violations ...FieldViolation) *Error {
+	fields := make([]string, len(violations))
+	for i, v := range violations {
+		fields[i] = v.Field + ": " + v.Description
+	}
+	e := f.new("INVALID_ARGUMENT", "invalid request: "+strings.Join(fields, "; "))
+	e.Violations = violations
+	return e
+}
+
+// Violation returns a FieldViolation with a formatted description.
+func Violation(field, format string, args ...interface{}) FieldViolation {
+	return FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)}
+}
+
+// FailedPrecondition reports that the system is not in a state required
+// for the call.
+func (f ErrorFactory) FailedPrecondition(format string, args ...interface{}) *Error {
+	return f.new("FAILED_PRECONDITION", fmt.Sprintf(format, args...))
+}
+
+// Unauthenticated reports a call without valid credentials.
+func (f ErrorFactory) Unauthenticated(format string, args ...interface{}) *Error {
+	return f.new("UNAUTHENTICATED", fmt.Sprintf(format, args...))
+}
+
+// PermissionDenied reports a caller without the permission for the call.
+func (f ErrorFactory) PermissionDenied(format string, args ...interface{}) *Error {
+	return f.new("PERMISSION_DENIED", fmt.Sprintf(format, args...))
+}
+
+// Aborted reports a concurrency conflict, e.g. a failed transaction.
+func (f ErrorFactory) Aborted(format string, args ...interface{}) *Error {
+	return f.new("ABORTED", fmt.Sprintf(format, args...))
+}
+
+// ResourceExhausted reports an exhausted quota or rate limit.
+func (f ErrorFactory) ResourceExhausted(format string, args ...interface{}) *Error {
+	return f.new("RESOURCE_EXHAUSTED", fmt.Sprintf(format, args...))
+}
+
+// Unimplemented reports a method that is not implemented.
+func (f ErrorFactory) Unimplemented(method string) *Error {
+	return f.new("UNIMPLEMENTED", method+" is not implemented")
+}
+
+// Unavailable reports that the service is temporarily unavailable.
+func (f ErrorFactory) Unavailable(format string, args ...interface{}) *Error {
+	return f.new("UNAVAILABLE", fmt.Sprintf(format, args...))
+}
+
+// Internal reports an internal error; its message is shown to clients.
+func (f ErrorFactory) Internal(format string, args ...interface{}) *Error {
+	return f.new("INTERNAL", fmt.Sprintf(format, args...))
+}
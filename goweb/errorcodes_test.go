@@ -0,0 +1,55 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestErrorFactory(t *testing.T) {
+	f := ErrorFactory{Domain: "pkg.Users"}
+	if e := f.NotFound("user", "7"); e.Status != 404 || e.Code != "NOT_FOUND" || e.Message != `user "7" not found` || e.Domain != "pkg.Users" {
+		t.Errorf("NotFound = %+v", e)
+	}
+	e := f.InvalidArgument(Violation("name", "must not be empty"), Violation("age", "must be below %d", 200))
+	if e.Status != 400 || e.Message != "invalid request: name: must not be empty; age: must be below 200" {
+		t.Errorf("InvalidArgument = %+v", e)
+	}
+	w := httptest.NewRecorder()
+	WriteError(w, e)
+	got, ok := DecodeError(w.Code, w.Body.Bytes()).(*Error)
+	if !ok || got.Domain != "pkg.Users" || !reflect.DeepEqual(got.Violations, e.Violations) {
+		t.Errorf("round trip = %+v", got)
+	}
+}
@@ -0,0 +1,55 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "errors"
+
+// ErrStreamingNotSupported is returned by generated adapters for streaming
+// methods, which cannot be served over plain HTTP.
+var ErrStreamingNotSupported = errors.New("goweb: streaming functions over http are not supported")
+
+// ErrInjectedFault is the default error of a FaultInjector.
+var ErrInjectedFault = errors.New("goweb: injected fault")
+
+// ErrClientGone is returned by ServerStream.Send once the client of the
+// stream disconnected.
+var ErrClientGone = errors.New("goweb: client gone")
+
+// ErrWriteTimeout is returned by ServerStream.Send if the client did not
+// accept a message within the write timeout of the stream. The stream is
+// unusable afterwards.
+var ErrWriteTimeout = errors.New("goweb: stream write timeout")
+
+// ErrNoUpstream is returned by the generated Provide<Service>HTTPClient
+// functions of the providers parameter for a configuration without an
+// Upstream.
+var ErrNoUpstream = errors.New("goweb: no Upstream for the http client")
@@ -0,0 +1,189 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// A Faker fills messages with fake data, for the fake servers generated
+// with the fake parameter. The data only depends on the seed, the method
+// and the request, so the same call always gets the same answer.
+type Faker struct {
+	Seed int64
+
+	// MaxDepth limits the nesting of the generated messages; 3 if 0.
+	MaxDepth int
+}
+
+// Fill sets the fields of out to fake values for the call of method with
+// the request in. Oneof fields are left unset.
+func (f Faker) Fill(out proto.Message, method string, in interface{}) {
+	h := fnv.New64a()
+	h.Write([]byte(method))
+	b, _ := json.Marshal(in)
+	h.Write(b)
+	r := rand.New(rand.NewSource(f.Seed ^ int64(h.Sum64())))
+	depth := f.MaxDepth
+	if depth <= 0 {
+		depth = 3
+	}
+	fillStruct(r, reflect.ValueOf(out).Elem(), depth)
+}
+
+var fakeWords = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel"}
+var fakeNames = []string{"Jane Doe", "John Roe", "Ada Park", "Max Kim", "Eva Lund"}
+
+func fillStruct(r *rand.Rand, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("protobuf")
+		if tag == "" || strings.HasPrefix(sf.Name, "XXX_") {
+			continue
+		}
+		var name, enum string
+		for _, p := range strings.Split(tag, ",") {
+			switch {
+			case strings.HasPrefix(p, "name="):
+				name = p[len("name="):]
+			case strings.HasPrefix(p, "enum="):
+				enum = p[len("enum="):]
+			}
+		}
+		fillValue(r, v.Field(i), name, enum, depth)
+	}
+}
+
+func fillValue(r *rand.Rand, v reflect.Value, name, enum string, depth int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			if depth <= 1 {
+				return
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+			fillStruct(r, v.Elem(), depth-1)
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		fillValue(r, v.Elem(), name, enum, depth)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, 8)
+			r.Read(b)
+			v.SetBytes(b)
+			return
+		}
+		n := 1 + r.Intn(3)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			fillValue(r, s.Index(i), name, enum, depth)
+		}
+		if s.Index(0).Kind() == reflect.Ptr && s.Index(0).IsNil() {
+			return
+		}
+		v.Set(s)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < 1+r.Intn(2); i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			fillValue(r, k, "key", "", depth)
+			e := reflect.New(v.Type().Elem()).Elem()
+			fillValue(r, e, name, enum, depth)
+			if e.Kind() == reflect.Ptr && e.IsNil() {
+				return
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	case reflect.String:
+		v.SetString(fakeString(r, name))
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int32:
+		if enum != "" {
+			v.SetInt(int64(fakeEnum(r, enum)))
+			return
+		}
+		v.SetInt(int64(1 + r.Intn(1000)))
+	case reflect.Int64, reflect.Int:
+		v.SetInt(int64(1 + r.Intn(1000)))
+	case reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(1 + r.Intn(1000)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(r.Intn(100000)) / 100)
+	}
+}
+
+// fakeEnum returns a random value of the registered enum, preferring the
+// non-zero values.
+func fakeEnum(r *rand.Rand, enum string) int32 {
+	var values []int32
+	for _, n := range proto.EnumValueMap(enum) {
+		if n != 0 {
+			values = append(values, n)
+		}
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values[r.Intn(len(values))]
+}
+
+// fakeString returns a random string fitting the field name.
+func fakeString(r *rand.Rand, field string) string {
+	name := strings.ToLower(field)
+	word := fakeWords[r.Intn(len(fakeWords))]
+	switch {
+	case strings.Contains(name, "email"):
+		return word + "@example.com"
+	case strings.Contains(name, "phone"):
+		return fmt.Sprintf("+1555555%04d", r.Intn(10000))
+	case strings.Contains(name, "url"), strings.Contains(name, "uri"), strings.Contains(name, "link"):
+		return "https://example.com/" + word
+	case name == "id", strings.HasSuffix(name, "_id"):
+		return fmt.Sprintf("%08x", r.Uint32())
+	case name == "name", strings.HasSuffix(name, "_name"):
+		return fakeNames[r.Intn(len(fakeNames))]
+	}
+	return word
+}
@@ -0,0 +1,142 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The request headers that select faults when FaultInjector.Headers is set.
+const (
+	FaultDelayHeader = "X-Goweb-Fault-Delay" // a duration, e.g. "250ms"
+	FaultErrorHeader = "X-Goweb-Fault-Error" // a percentage of calls that fail
+	FaultAbortHeader = "X-Goweb-Fault-Abort" // a percentage of calls that are aborted
+)
+
+// A Fault describes the faults injected into the calls of a method.
+type Fault struct {
+	// Delay is added before DelayPercent percent of the calls.
+	Delay        time.Duration
+	DelayPercent float64
+
+	// ErrorPercent percent of the calls fail with Error, or ErrInjectedFault
+	// if it is nil, without calling the implementation.
+	ErrorPercent float64
+	Error        error
+
+	// AbortPercent percent of the http calls are aborted: the connection is
+	// closed without a response.
+	AbortPercent float64
+}
+
+// A FaultInjector injects latency, errors and aborted connections into
+// calls, for testing the resilience of callers in staging environments.
+// Its Intercept method is an Interceptor for the generated
+// Wrap<Service>Server functions.
+type FaultInjector struct {
+	// Headers lets callers select faults per request with the
+	// X-Goweb-Fault-* headers, on top of the configured ones.
+	Headers bool
+
+	mu     sync.RWMutex
+	faults map[string]Fault
+}
+
+// SetFault sets the faults of method, given by name ("GetUser"), by full
+// name ("/pkg.Users/GetUser") or as "*" for all methods without faults of
+// their own. The zero Fault disables fault injection.
+func (fi *FaultInjector) SetFault(method string, f Fault) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.faults == nil {
+		fi.faults = make(map[string]Fault)
+	}
+	fi.faults[method] = f
+}
+
+func (fi *FaultInjector) fault(ctx context.Context, route Route) Fault {
+	fi.mu.RLock()
+	var f Fault
+	for _, key := range []string{route.FullMethod(), route.Method, "*"} {
+		if v, ok := fi.faults[key]; ok {
+			f = v
+			break
+		}
+	}
+	fi.mu.RUnlock()
+	r := RequestFrom(ctx)
+	if !fi.Headers || r == nil {
+		return f
+	}
+	if d, err := time.ParseDuration(r.Header.Get(FaultDelayHeader)); err == nil {
+		f.Delay, f.DelayPercent = d, 100
+	}
+	if p, err := strconv.ParseFloat(r.Header.Get(FaultErrorHeader), 64); err == nil {
+		f.ErrorPercent = p
+	}
+	if p, err := strconv.ParseFloat(r.Header.Get(FaultAbortHeader), 64); err == nil {
+		f.AbortPercent = p
+	}
+	return f
+}
+
+func chance(percent float64) bool {
+	return percent > 0 && rand.Float64()*100 < percent
+}
+
+// Intercept injects the faults of the method of route into the call.
+func (fi *FaultInjector) Intercept(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+	f := fi.fault(ctx, route)
+	if f.Delay > 0 && chance(f.DelayPercent) {
+		select {
+		case <-time.After(f.Delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if chance(f.AbortPercent) && RequestFrom(ctx) != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if chance(f.ErrorPercent) {
+		if f.Error != nil {
+			return nil, f.Error
+		}
+		return nil, ErrInjectedFault
+	}
+	return next(ctx, in)
+}
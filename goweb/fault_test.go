@@ -0,0 +1,73 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestFaultInjector(t *testing.T) {
+	route := Route{Service: "pkg.Users", Method: "Get"}
+	ok := func(ctx context.Context, in interface{}) (interface{}, error) { return in, nil }
+	fi := &FaultInjector{Headers: true}
+
+	if _, err := fi.Intercept(context.Background(), route, 1, ok); err != nil {
+		t.Fatalf("unconfigured injector failed: %v", err)
+	}
+	boom := errors.New("boom")
+	fi.SetFault("Get", Fault{ErrorPercent: 100, Error: boom})
+	if _, err := fi.Intercept(context.Background(), route, 1, ok); err != boom {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+	fi.SetFault("Get", Fault{})
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set(FaultDelayHeader, "20ms")
+	start := time.Now()
+	if _, err := fi.Intercept(NewContext(r), route, 1, ok); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("delay header: %v after %v", err, time.Since(start))
+	}
+
+	r.Header.Set(FaultAbortHeader, "100")
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("abort header: recovered %v", p)
+		}
+	}()
+	fi.Intercept(NewContext(r), route, 1, ok)
+}
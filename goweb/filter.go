@@ -0,0 +1,198 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Filter is an expression in the filter syntax of AIP-160, as taken by
+// the filter field of list requests, e.g. `size > 3 AND color = RED`. The
+// generated <Service><Method>Filter variables of the client parameter hold
+// the typed fields of the listed resource that build them, so that
+//
+//	in.Filter = ShelvesListFilter.Size.Gt(3).And(ShelvesListFilter.Color.Eq(Shade_RED)).String()
+//
+// is checked by the compiler. The zero Filter matches everything and is
+// left out by And and Or.
+type Filter struct {
+	expr string
+	op   string // the operator joining expr, if it is a conjunction or disjunction
+}
+
+// String returns the expression of f.
+func (f Filter) String() string { return f.expr }
+
+// And returns the filter matching what f and all of fs match.
+func (f Filter) And(fs ...Filter) Filter { return f.join("AND", fs) }
+
+// Or returns the filter matching what f or any of fs match.
+func (f Filter) Or(fs ...Filter) Filter { return f.join("OR", fs) }
+
+// Not returns the filter matching what f does not match.
+func Not(f Filter) Filter {
+	if f.expr == "" {
+		return f
+	}
+	return Filter{expr: "NOT " + f.operand("NOT")}
+}
+
+func (f Filter) join(op string, fs []Filter) Filter {
+	var terms []string
+	for _, f := range append([]Filter{f}, fs...) {
+		if f.expr != "" {
+			terms = append(terms, f.operand(op))
+		}
+	}
+	if len(terms) < 2 {
+		return Filter{expr: strings.Join(terms, "")}
+	}
+	return Filter{expr: strings.Join(terms, " "+op+" "), op: op}
+}
+
+// operand returns the expression of f as operand of op, parenthesized
+// unless it is joined by op itself: in AIP-160, OR binds tighter than AND.
+func (f Filter) operand(op string) string {
+	if f.op == "" || f.op == op {
+		return f.expr
+	}
+	return "(" + f.expr + ")"
+}
+
+func compare(field, op, value string) Filter {
+	return Filter{expr: field + " " + op + " " + value}
+}
+
+// A StringField is a string field of a resource, by its path.
+type StringField string
+
+// Eq matches the resources whose field is v, which may start or end with
+// the wildcard *.
+func (f StringField) Eq(v string) Filter { return compare(string(f), "=", strconv.Quote(v)) }
+
+// Ne matches the resources whose field is not v.
+func (f StringField) Ne(v string) Filter { return compare(string(f), "!=", strconv.Quote(v)) }
+
+// Has matches the resources whose field contains v.
+func (f StringField) Has(v string) Filter { return Filter{expr: string(f) + ":" + strconv.Quote(v)} }
+
+// An IntField is an integer field of a resource, by its path.
+type IntField string
+
+func (f IntField) cmp(op string, v int64) Filter {
+	return compare(string(f), op, strconv.FormatInt(v, 10))
+}
+
+// Eq matches the resources whose field is v.
+func (f IntField) Eq(v int64) Filter { return f.cmp("=", v) }
+
+// Ne matches the resources whose field is not v.
+func (f IntField) Ne(v int64) Filter { return f.cmp("!=", v) }
+
+// Lt matches the resources whose field is less than v.
+func (f IntField) Lt(v int64) Filter { return f.cmp("<", v) }
+
+// Le matches the resources whose field is at most v.
+func (f IntField) Le(v int64) Filter { return f.cmp("<=", v) }
+
+// Gt matches the resources whose field is greater than v.
+func (f IntField) Gt(v int64) Filter { return f.cmp(">", v) }
+
+// Ge matches the resources whose field is at least v.
+func (f IntField) Ge(v int64) Filter { return f.cmp(">=", v) }
+
+// A FloatField is a floating point field of a resource, by its path.
+type FloatField string
+
+func (f FloatField) cmp(op string, v float64) Filter {
+	return compare(string(f), op, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// Eq matches the resources whose field is v.
+func (f FloatField) Eq(v float64) Filter { return f.cmp("=", v) }
+
+// Ne matches the resources whose field is not v.
+func (f FloatField) Ne(v float64) Filter { return f.cmp("!=", v) }
+
+// Lt matches the resources whose field is less than v.
+func (f FloatField) Lt(v float64) Filter { return f.cmp("<", v) }
+
+// Le matches the resources whose field is at most v.
+func (f FloatField) Le(v float64) Filter { return f.cmp("<=", v) }
+
+// Gt matches the resources whose field is greater than v.
+func (f FloatField) Gt(v float64) Filter { return f.cmp(">", v) }
+
+// Ge matches the resources whose field is at least v.
+func (f FloatField) Ge(v float64) Filter { return f.cmp(">=", v) }
+
+// A BoolField is a bool field of a resource, by its path.
+type BoolField string
+
+// Eq matches the resources whose field is v.
+func (f BoolField) Eq(v bool) Filter { return compare(string(f), "=", strconv.FormatBool(v)) }
+
+// An EnumField is an enum field of a resource, by its path. Its values are
+// those of the generated enum type, which are Stringers.
+type EnumField string
+
+// Eq matches the resources whose field is v.
+func (f EnumField) Eq(v fmt.Stringer) Filter { return compare(string(f), "=", v.String()) }
+
+// Ne matches the resources whose field is not v.
+func (f EnumField) Ne(v fmt.Stringer) Filter { return compare(string(f), "!=", v.String()) }
+
+// A TimeField is a google.protobuf.Timestamp field of a resource, by its
+// path. Times are compared as RFC 3339 strings in UTC.
+type TimeField string
+
+func (f TimeField) cmp(op string, t time.Time) Filter {
+	return compare(string(f), op, strconv.Quote(t.UTC().Format(time.RFC3339Nano)))
+}
+
+// Eq matches the resources whose field is t.
+func (f TimeField) Eq(t time.Time) Filter { return f.cmp("=", t) }
+
+// Lt matches the resources whose field is before t.
+func (f TimeField) Lt(t time.Time) Filter { return f.cmp("<", t) }
+
+// Le matches the resources whose field is not after t.
+func (f TimeField) Le(t time.Time) Filter { return f.cmp("<=", t) }
+
+// Gt matches the resources whose field is after t.
+func (f TimeField) Gt(t time.Time) Filter { return f.cmp(">", t) }
+
+// Ge matches the resources whose field is not before t.
+func (f TimeField) Ge(t time.Time) Filter { return f.cmp(">=", t) }
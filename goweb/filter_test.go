@@ -0,0 +1,72 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"testing"
+	"time"
+)
+
+type shade int
+
+func (s shade) String() string { return [...]string{"NONE", "RED"}[s] }
+
+func TestFilter(t *testing.T) {
+	var (
+		name  StringField = "name"
+		size  IntField    = "size"
+		score FloatField  = "score"
+		done  BoolField   = "done"
+		color EnumField   = "color"
+		at    TimeField   = "at"
+	)
+	for _, c := range []struct {
+		f    Filter
+		want string
+	}{
+		{Filter{}, ""},
+		{name.Eq(`a"b`), `name = "a\"b"`},
+		{name.Has("x").And(size.Ge(3), score.Lt(0.5)), `name:"x" AND size >= 3 AND score < 0.5`},
+		{done.Eq(true).Or(color.Ne(shade(1))), `done = true OR color != RED`},
+		{size.Gt(1).Or(size.Lt(-1)).And(color.Eq(shade(0))), `(size > 1 OR size < -1) AND color = NONE`},
+		{size.Gt(1).And(size.Lt(9)).Or(done.Eq(false)), `(size > 1 AND size < 9) OR done = false`},
+		{Not(name.Eq("a").Or(name.Eq("b"))), `NOT (name = "a" OR name = "b")`},
+		{Not(name.Ne("a")), `NOT name != "a"`},
+		{Filter{}.And(size.Eq(1), Filter{}), `size = 1`},
+		{Not(Filter{}).Or(), ``},
+		{at.Ge(time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))), `at >= "2020-01-02T02:04:05Z"`},
+	} {
+		if got := c.f.String(); got != c.want {
+			t.Errorf("filter = %s, want %s", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// MarshalJSON encodes v like json.Marshal, but writes the NaN and
+// infinite floats of a message as the strings "NaN", "Infinity" and
+// "-Infinity" of the proto3 JSON mapping, where json.Marshal fails.
+// Documents with such numbers have their keys sorted.
+func MarshalJSON(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	e, ok := err.(*json.UnsupportedValueError)
+	if !ok || e.Str != "NaN" && e.Str != "+Inf" && e.Str != "-Inf" {
+		return b, err
+	}
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, err
+	}
+	finite := proto.Clone(m)
+	zeroNonFinite(reflect.ValueOf(finite))
+	if b, err = json.Marshal(finite); err != nil {
+		return nil, err
+	}
+	tree, err := decodeTree(b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(quoteNonFinite(reflect.ValueOf(v), tree))
+}
+
+// CheckFinite returns an error naming the first field of m, as encoded to
+// JSON, that is NaN or infinite. Generated handlers check requests and
+// responses with it for the finite_floats parameter.
+func CheckFinite(m interface{}) error {
+	return checkFinite(reflect.ValueOf(m), "")
+}
+
+func checkFinite(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkFinite(v.Elem(), path)
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("goweb: field %s is %s, but only finite numbers are allowed", path, nonFiniteString(f))
+		}
+	case reflect.Struct:
+		for name, i := range jsonFields(v.Type()) {
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := checkFinite(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkFinite(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if err := checkFinite(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nonFiniteString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case f > 0:
+		return "Infinity"
+	}
+	return "-Infinity"
+}
+
+// parseNonFinite returns the float of the string s of the proto3 JSON
+// mapping, if it is "NaN", "Infinity" or "-Infinity".
+func parseNonFinite(s string) (float64, bool) {
+	switch s {
+	case "NaN":
+		return math.NaN(), true
+	case "Infinity":
+		return math.Inf(1), true
+	case "-Infinity":
+		return math.Inf(-1), true
+	}
+	return 0, false
+}
+
+func isFloat(t reflect.Type) bool {
+	return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+func isNonFinite(v reflect.Value) bool {
+	return isFloat(v.Type()) && (math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0))
+}
+
+// zeroNonFinite sets the NaN and infinite floats of v to 0.
+func zeroNonFinite(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			zeroNonFinite(v.Elem())
+		}
+	case reflect.Float32, reflect.Float64:
+		if isNonFinite(v) && v.CanSet() {
+			v.SetFloat(0)
+		}
+	case reflect.Struct:
+		for _, i := range jsonFields(v.Type()) {
+			zeroNonFinite(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			zeroNonFinite(v.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if e := v.MapIndex(k); isFloat(e.Type()) && isNonFinite(e) {
+				v.SetMapIndex(k, reflect.Zero(e.Type()))
+			} else {
+				zeroNonFinite(e)
+			}
+		}
+	}
+}
+
+// quoteNonFinite writes the NaN and infinite floats of v into its JSON
+// tree, which has them as 0 or leaves them out.
+func quoteNonFinite(v reflect.Value, tree interface{}) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return tree
+		}
+		v = v.Elem()
+	}
+	if isFloat(v.Type()) && isNonFinite(v) {
+		return nonFiniteString(v.Float())
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := tree.(map[string]interface{})
+		if !ok {
+			return tree
+		}
+		for name, i := range jsonFields(v.Type()) {
+			if f := v.Field(i); isFloat(f.Type()) && isNonFinite(f) {
+				obj[name] = nonFiniteString(f.Float())
+			} else if t, ok := obj[name]; ok {
+				obj[name] = quoteNonFinite(f, t)
+			}
+		}
+	case reflect.Slice:
+		if arr, ok := tree.([]interface{}); ok && len(arr) == v.Len() {
+			for i := range arr {
+				arr[i] = quoteNonFinite(v.Index(i), arr[i])
+			}
+		}
+	case reflect.Map:
+		if obj, ok := tree.(map[string]interface{}); ok {
+			for _, k := range v.MapKeys() {
+				name := fmt.Sprint(k.Interface())
+				if t, ok := obj[name]; ok {
+					obj[name] = quoteNonFinite(v.MapIndex(k), t)
+				}
+			}
+		}
+	}
+	return tree
+}
+
+// setNonFinite sets the floats of v that are "NaN", "Infinity" or
+// "-Infinity" in its JSON tree.
+func setNonFinite(v reflect.Value, tree interface{}) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if s, ok := tree.(string); ok {
+			if f, ok := parseNonFinite(s); ok && v.CanSet() {
+				v.SetFloat(f)
+			}
+		}
+	case reflect.Struct:
+		if obj, ok := tree.(map[string]interface{}); ok {
+			for name, i := range jsonFields(v.Type()) {
+				if t, ok := obj[name]; ok {
+					setNonFinite(v.Field(i), t)
+				}
+			}
+		}
+	case reflect.Slice:
+		if arr, ok := tree.([]interface{}); ok && len(arr) == v.Len() {
+			for i := range arr {
+				setNonFinite(v.Index(i), arr[i])
+			}
+		}
+	case reflect.Map:
+		if obj, ok := tree.(map[string]interface{}); ok {
+			for _, k := range v.MapKeys() {
+				t, ok := obj[fmt.Sprint(k.Interface())]
+				if !ok {
+					continue
+				}
+				if e := v.MapIndex(k); isFloat(e.Type()) {
+					if s, ok := t.(string); ok {
+						if f, ok := parseNonFinite(s); ok {
+							v.SetMapIndex(k, reflect.ValueOf(f).Convert(e.Type()))
+						}
+					}
+				} else {
+					setNonFinite(e, t)
+				}
+			}
+		}
+	}
+}
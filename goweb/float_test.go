@@ -0,0 +1,86 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+type sample struct {
+	Value  float64            `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Ratio  float32            `protobuf:"fixed32,2,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Series []float64          `protobuf:"fixed64,3,rep,packed,name=series,proto3" json:"series,omitempty"`
+	ByKey  map[string]float64 `protobuf:"bytes,4,rep,name=by_key,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3" json:"by_key,omitempty"`
+	Next   *sample            `protobuf:"bytes,5,opt,name=next,proto3" json:"next,omitempty"`
+}
+
+func (m *sample) Reset()         { *m = sample{} }
+func (m *sample) String() string { return proto.CompactTextString(m) }
+func (*sample) ProtoMessage()    {}
+
+func TestNonFiniteFloats(t *testing.T) {
+	s := &sample{Value: math.NaN(), Ratio: float32(math.Inf(1)), Series: []float64{1, math.Inf(-1)},
+		ByKey: map[string]float64{"a": math.NaN(), "b": 2}, Next: &sample{Value: 1.5}}
+	b, err := MarshalJSON(s)
+	want := `{"by_key":{"a":"NaN","b":2},"next":{"value":1.5},"ratio":"Infinity","series":[1,"-Infinity"],"value":"NaN"}`
+	if err != nil || string(b) != want {
+		t.Fatalf("MarshalJSON = %s, %v", b, err)
+	}
+	if !math.IsNaN(s.Value) {
+		t.Errorf("MarshalJSON changed its argument")
+	}
+	var got sample
+	if err := UnmarshalJSON(b, &got); err != nil || !math.IsNaN(got.Value) || !math.IsInf(float64(got.Ratio), 1) ||
+		!math.IsInf(got.Series[1], -1) || !math.IsNaN(got.ByKey["a"]) || got.ByKey["b"] != 2 || got.Next.Value != 1.5 {
+		t.Errorf("UnmarshalJSON(%s) = %+v, %v", b, got, err)
+	}
+	if err := UnmarshalJSON([]byte(`{"value":"2.5"}`), &got); err != nil || got.Value != 2.5 {
+		t.Errorf("UnmarshalJSON of a quoted float = %v, %v", got.Value, err)
+	}
+	if err := UnmarshalJSON([]byte(`{"value":"nan"}`), &got); err == nil {
+		t.Errorf("UnmarshalJSON accepted nan")
+	}
+	err = CheckFinite(s)
+	if err == nil || !strings.Contains(err.Error(), "field ") {
+		t.Errorf("CheckFinite = %v", err)
+	}
+	if err := CheckFinite(&sample{Next: &sample{Series: []float64{math.Inf(1)}}}); err == nil || !strings.Contains(err.Error(), "next.series[0] is Infinity") {
+		t.Errorf("CheckFinite = %v", err)
+	}
+	if err := CheckFinite(&got); err != nil {
+		t.Errorf("CheckFinite(finite) = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DeadlineHeader is the header in which an Upstream forwarding deadlines
+// sends the time left of the context of a call, in the gRPC format;
+// servers honor it with WithTimeoutHeader(DeadlineHeader).
+const DeadlineHeader = "Grpc-Timeout"
+
+// Forwarding selects what an Upstream passes on from the call in whose
+// context it makes an outbound call, so that generated servers calling
+// generated clients, e.g. in a gateway, behave like one system. Trace
+// headers are passed on anyway, see WithPropagatedHeaders.
+type Forwarding uint8
+
+const (
+	// ForwardDeadline sends the time left of the deadline of the context
+	// in DeadlineHeader, and fails calls whose deadline passed with
+	// DEADLINE_EXCEEDED without sending them.
+	ForwardDeadline Forwarding = 1 << iota
+
+	// ForwardAuth sends the Authorization header of the incoming request.
+	ForwardAuth
+
+	// ForwardRequestID sends the RequestID of the context in
+	// RequestIDHeader.
+	ForwardRequestID
+
+	// ForwardAll forwards everything.
+	ForwardAll = ForwardDeadline | ForwardAuth | ForwardRequestID
+)
+
+// forward adds the headers of u.Forward from ctx to req, unless set.
+func (u *Upstream) forward(ctx context.Context, req *http.Request) error {
+	set := func(k, v string) {
+		if v != "" && req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	if u.Forward&ForwardDeadline != 0 {
+		if deadline, ok := ctx.Deadline(); ok {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return Errorf(504, "DEADLINE_EXCEEDED", "deadline exceeded before calling %s", req.URL.Path)
+			}
+			set(DeadlineHeader, FormatTimeout(left))
+		}
+	}
+	if u.Forward&ForwardAuth != 0 {
+		if r := RequestFrom(ctx); r != nil {
+			set("Authorization", r.Header.Get("Authorization"))
+		}
+	}
+	if u.Forward&ForwardRequestID != 0 {
+		set(RequestIDHeader, RequestID(ctx))
+	}
+	return nil
+}
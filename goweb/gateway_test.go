@@ -0,0 +1,102 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestFormatTimeout(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                 "0n",
+		1500 * time.Microsecond:           "1500000n",
+		1500 * time.Millisecond:           "1500000u",
+		2 * time.Hour:                     "7200000m",
+		400 * 24 * time.Hour:              "34560000S",
+		time.Duration(1<<63 - 1):          "2562047H",
+		3*time.Second + time.Nanosecond/2: "3000000u",
+	} {
+		got := FormatTimeout(d)
+		if got != want {
+			t.Errorf("FormatTimeout(%v) = %q, want %q", d, got, want)
+		}
+		if back, err := ParseTimeout(got); err != nil || back > d || d-back >= d/1e6+1 {
+			t.Errorf("ParseTimeout(%q) = %v, %v", got, back, err)
+		}
+	}
+}
+
+func TestUpstreamForward(t *testing.T) {
+	var got http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	in := httptest.NewRequest("POST", "/", nil)
+	in.Header.Set("Authorization", "Bearer t")
+	in.Header.Set(RequestIDHeader, "r1")
+	route := Route{Method: "Get", Path: "/get"}
+	var out struct{}
+	for _, c := range []struct {
+		forward        Forwarding
+		auth, id, left string
+	}{
+		{ForwardAll, "Bearer t", "r1", "9"},
+		{ForwardRequestID, "", "r1", ""},
+		{0, "", "", ""},
+	} {
+		ctx, cancel := context.WithTimeout(NewContext(in), 10*time.Second)
+		err := (&Upstream{BaseURL: s.URL, Forward: c.forward}).Call(ctx, route, struct{}{}, &out)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		left, _ := ParseTimeout(got.Get(DeadlineHeader))
+		if got.Get("Authorization") != c.auth || got.Get(RequestIDHeader) != c.id || c.left != "" && left.Round(time.Second) != 10*time.Second || c.left == "" && left != 0 {
+			t.Errorf("Forward %b: sent %v", c.forward, got)
+		}
+	}
+
+	got = nil
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	err := (&Upstream{BaseURL: s.URL, Forward: ForwardDeadline}).Call(ctx, route, struct{}{}, &out)
+	if e, ok := AsError(err); !ok || e.Code != "DEADLINE_EXCEEDED" || got != nil {
+		t.Errorf("expired deadline: %v, sent %v", err, got)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+type captures struct{}
+
+func (captures) Get(ctx context.Context, in *CapturedCall) (*CapturedCall, error) {
+	in.Path = "/" + in.Method
+	return in, nil
+}
+
+func TestHandle(t *testing.T) {
+	route := &Route{Service: "goweb.Captures", Method: "Get", Verb: "GET", Path: "captures/{method}", Hash: "h"}
+	var seen string
+	opts := NewServerOptions(UnaryInterceptor(func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+		seen = route.FullMethod()
+		return next(ctx, in)
+	}))
+	impl := captures{}
+	h := Handle(Method{Route: route, Skip: []string{"method"}}, impl, impl.Get, opts)
+	r := WithPathParams(httptest.NewRequest("GET", "/captures/get?error=x", nil), map[string]string{"method": "get"})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"method":"get","path":"/get","error":"x"}` {
+		t.Errorf("GET = %d %s", w.Code, w.Body)
+	}
+	if seen != "/goweb.Captures/Get" {
+		t.Errorf("interceptor saw %q", seen)
+	}
+}
@@ -0,0 +1,72 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package gowebchi is the chi backend of the muxes generated with
+// router=chi.
+package gowebchi
+
+import (
+	"net/http"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/go-chi/chi/v5"
+)
+
+// Router is the goweb.Router of a chi router. Routes with templates are
+// mounted below their literal prefixes, see goweb.TemplateRoutes; chi
+// prefers the routes of the other patterns.
+type Router struct {
+	Mux chi.Router
+
+	templates *goweb.TemplateRoutes
+}
+
+var _ goweb.Router = (*Router)(nil)
+
+// New returns the Router adding routes to m.
+func New(m chi.Router) *Router {
+	return &Router{Mux: m, templates: &goweb.TemplateRoutes{}}
+}
+
+func (r *Router) Handle(pattern string, h http.Handler) { r.Mux.Handle(pattern, h) }
+
+func (r *Router) Get(pattern string, h http.Handler) {
+	r.Mux.Method("GET", pattern, h)
+	r.Mux.Method("HEAD", pattern, h)
+}
+
+func (r *Router) HandleTemplate(prefix, verb string, t *goweb.PathTemplate, h http.Handler) {
+	if pattern, mount := r.templates.Add(prefix, verb, t, h); mount {
+		r.Mux.Handle(pattern, r.templates)
+	}
+}
+
+func (r *Router) Use(mw func(http.Handler) http.Handler) { r.Mux.Use(mw) }
@@ -0,0 +1,56 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package gowebgrpc adapts the interceptors of gRPC servers to the
+// handlers of the generated muxes, so that interceptors for auth, logging,
+// tracing or panic recovery are shared by both transports.
+package gowebgrpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor returns the goweb.Interceptor running ic, e.g.
+//
+//	NewUsersMux(impl, "/", goweb.ChainUnaryInterceptor(gowebgrpc.UnaryServerInterceptor(recovery)))
+//
+// ic is given the FullMethod of the route; the Server of its info is nil,
+// as goweb interceptors do not see the implementation.
+func UnaryServerInterceptor(ic grpc.UnaryServerInterceptor) goweb.Interceptor {
+	return func(ctx context.Context, route goweb.Route, in interface{}, next goweb.UnaryHandler) (interface{}, error) {
+		info := &grpc.UnaryServerInfo{FullMethod: route.FullMethod()}
+		return ic(ctx, in, info, func(ctx context.Context, in interface{}) (interface{}, error) {
+			return next(ctx, in)
+		})
+	}
+}
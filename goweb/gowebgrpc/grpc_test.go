@@ -0,0 +1,63 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package gowebgrpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var seen string
+	var ic grpc.UnaryServerInterceptor = func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		seen = info.FullMethod
+		if req == "deny" {
+			return nil, errors.New("denied")
+		}
+		return handler(ctx, req.(string)+" intercepted")
+	}
+	o := goweb.NewServerOptions(goweb.UnaryInterceptor(UnaryServerInterceptor(ic)))
+	route := goweb.Route{Service: "pkg.Users", Method: "Get"}
+	out, err := o.Interceptor(context.Background(), route, "call", func(ctx context.Context, in interface{}) (interface{}, error) {
+		return in, nil
+	})
+	if out != "call intercepted" || err != nil || seen != "/pkg.Users/Get" {
+		t.Errorf("call = %v, %v, FullMethod %q", out, err, seen)
+	}
+	if _, err := o.Interceptor(context.Background(), route, "deny", nil); err == nil || err.Error() != "denied" {
+		t.Errorf("denied call = %v", err)
+	}
+}
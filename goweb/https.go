@@ -0,0 +1,137 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPS configures the redirects to https of WithHTTPS and the
+// Strict-Transport-Security header of its https responses.
+type HTTPS struct {
+	// TrustedProxies are the addresses or networks (e.g. "10.0.0.0/8")
+	// of the proxies terminating TLS in front of the server, whose
+	// X-Forwarded-Proto headers tell how the client connected. Requests
+	// from other addresses are https if they came over TLS.
+	TrustedProxies []string
+
+	// MaxAge is the max-age of the Strict-Transport-Security header: a
+	// year if 0, no header if negative.
+	MaxAge time.Duration
+
+	// IncludeSubdomains and Preload add these directives to the header.
+	IncludeSubdomains bool
+	Preload           bool
+}
+
+// WithHTTPS redirects the plaintext requests of the mux to https and adds
+// the Strict-Transport-Security header to the others, see HTTPSRedirect.
+func WithHTTPS(h HTTPS) ServerOption {
+	mw := HTTPSRedirect(h)
+	return func(o *ServerOptions) { o.Middleware = append(o.Middleware, mw) }
+}
+
+// HTTPSRedirect returns middleware redirecting the plaintext requests to
+// the same URL with https, permanently (301 for GET and HEAD, 308 for the
+// other methods, which keeps their body), and adding the
+// Strict-Transport-Security header to the responses of the others, as
+// browsers ignore it over plaintext. It panics if a trusted proxy is
+// neither an IP address nor a CIDR network.
+func HTTPSRedirect(h HTTPS) func(http.Handler) http.Handler {
+	var proxies []*net.IPNet
+	for _, p := range h.TrustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			panic("goweb: invalid trusted proxy " + strconv.Quote(p))
+		}
+		proxies = append(proxies, n)
+	}
+	hsts := ""
+	if h.MaxAge >= 0 {
+		age := h.MaxAge
+		if age == 0 {
+			age = 365 * 24 * time.Hour
+		}
+		hsts = "max-age=" + strconv.FormatInt(int64(age/time.Second), 10)
+		if h.IncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if h.Preload {
+			hsts += "; preload"
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isHTTPS(r, proxies) {
+				status := 308
+				if r.Method == "GET" || r.Method == "HEAD" {
+					status = 301
+				}
+				http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), status)
+				return
+			}
+			if hsts != "" {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isHTTPS reports whether the client sent r over TLS, to the server or to
+// one of the proxies.
+func isHTTPS(r *http.Request, proxies []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, n := range proxies {
+			if n.Contains(ip) {
+				proto := strings.SplitN(r.Header.Get("X-Forwarded-Proto"), ",", 2)[0]
+				return strings.EqualFold(strings.TrimSpace(proto), "https")
+			}
+		}
+	}
+	return r.TLS != nil
+}
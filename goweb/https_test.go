@@ -0,0 +1,94 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(204) })
+	h := HTTPSRedirect(HTTPS{TrustedProxies: []string{"10.0.0.0/8", "::1"}, MaxAge: time.Hour, IncludeSubdomains: true})(ok)
+	for _, c := range []struct {
+		method, remote, proto string
+		tls                   bool
+		code                  int
+	}{
+		{"GET", "1.2.3.4:5", "", false, 301},
+		{"POST", "1.2.3.4:5", "", false, 308},
+		{"GET", "1.2.3.4:5", "https", false, 301}, // untrusted proxy
+		{"GET", "1.2.3.4:5", "", true, 204},
+		{"GET", "10.1.2.3:5", "https", false, 204},
+		{"GET", "10.1.2.3:5", "http", true, 301},
+		{"GET", "[::1]:5", "https, http", false, 204},
+		{"GET", "10.1.2.3:5", "", false, 301},
+	} {
+		r := httptest.NewRequest(c.method, "http://api.example.com/v1/users?id=1", nil)
+		r.RemoteAddr = c.remote
+		if c.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+		if c.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%+v = %d", c, w.Code)
+		}
+		switch {
+		case c.code != 204 && w.Header().Get("Location") != "https://api.example.com/v1/users?id=1":
+			t.Errorf("%+v: Location = %q", c, w.Header().Get("Location"))
+		case c.code == 204 && w.Header().Get("Strict-Transport-Security") != "max-age=3600; includeSubDomains":
+			t.Errorf("%+v: Strict-Transport-Security = %q", c, w.Header().Get("Strict-Transport-Security"))
+		case c.code != 204 && w.Header().Get("Strict-Transport-Security") != "":
+			t.Errorf("%+v: Strict-Transport-Security over plaintext", c)
+		}
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	HTTPSRedirect(HTTPS{})(ok).ServeHTTP(w, r)
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=31536000" {
+		t.Errorf("default Strict-Transport-Security = %q", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid proxy accepted")
+		}
+	}()
+	HTTPSRedirect(HTTPS{TrustedProxies: []string{"proxy"}})
+}
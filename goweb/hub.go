@@ -0,0 +1,197 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// A Hub fans out the messages of a watch-style method, published once by
+// the implementation, to WebSocket subscribers, each with its own filter.
+// Subscribers that fall behind by more than Buffer messages are evicted,
+// so a slow consumer never blocks Publish or the others. The generated
+// <Service><Method>Hub of a server-streaming method is a Hub.
+type Hub struct {
+	// Buffer is the number of messages queued for a subscriber; 64 if 0.
+	Buffer int
+
+	// WriteTimeout limits the write of a message to a subscriber, which
+	// is evicted if it times out; 10 seconds if 0.
+	WriteTimeout time.Duration
+
+	// CheckOrigin decides whether to accept the WebSocket of the request
+	// r. If nil, requests with an Origin header of another host are
+	// refused, as browsers send the cookies of the site with them.
+	CheckOrigin func(r *http.Request) bool
+
+	mu      sync.Mutex
+	subs    map[*hubSubscriber]bool
+	evicted int
+}
+
+type hubSubscriber struct {
+	filter func(m interface{}) bool
+	queue  chan []byte
+	done   chan struct{} // closed when the subscriber is evicted
+}
+
+// Publish sends the JSON of m to the subscribers whose filter accepts it,
+// evicting those whose queue is full. Filters run with the hub locked,
+// so they must be fast.
+func (h *Hub) Publish(m interface{}) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.filter != nil && !s.filter(m) {
+			continue
+		}
+		select {
+		case s.queue <- b:
+		default:
+			h.evict(s)
+		}
+	}
+	return nil
+}
+
+// evict removes s; the hub must be locked.
+func (h *Hub) evict(s *hubSubscriber) {
+	if h.subs[s] {
+		delete(h.subs, s)
+		close(s.done)
+		h.evicted++
+	}
+}
+
+// Subscribers returns the number of subscribers.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Evicted returns the number of subscribers evicted because their queue
+// was full.
+func (h *Hub) Evicted() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.evicted
+}
+
+func (h *Hub) subscribe(filter func(m interface{}) bool) *hubSubscriber {
+	n := h.Buffer
+	if n <= 0 {
+		n = 64
+	}
+	s := &hubSubscriber{filter: filter, queue: make(chan []byte, n), done: make(chan struct{})}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = map[*hubSubscriber]bool{}
+	}
+	h.subs[s] = true
+	return s
+}
+
+func (h *Hub) unsubscribe(s *hubSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, s)
+}
+
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	if h.CheckOrigin != nil {
+		return h.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// Serve upgrades the request r to a WebSocket and sends it the published
+// messages that filter, if not nil, accepts, as JSON text messages,
+// until the client closes it or is evicted.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, filter func(m interface{}) bool) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if !h.checkOrigin(r) {
+				return fmt.Errorf("goweb: websocket origin %q not allowed", r.Header.Get("Origin"))
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			s := h.subscribe(filter)
+			defer h.unsubscribe(s)
+			closed := make(chan struct{})
+			go func() {
+				// Discard what the client sends, to notice when it closes.
+				var msg []byte
+				for websocket.Message.Receive(conn, &msg) == nil {
+				}
+				close(closed)
+			}()
+			timeout := h.WriteTimeout
+			if timeout <= 0 {
+				timeout = 10 * time.Second
+			}
+			for {
+				select {
+				case b := <-s.queue:
+					conn.SetWriteDeadline(time.Now().Add(timeout))
+					if err := websocket.Message.Send(conn, string(b)); err != nil {
+						return
+					}
+				case <-s.done:
+					return
+				case <-closed:
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
@@ -0,0 +1,112 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestHub(t *testing.T) {
+	hub := &Hub{Buffer: 2}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topic := r.URL.Query().Get("topic")
+		hub.Serve(w, r, func(m interface{}) bool { return m.(map[string]string)["topic"] == topic })
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	dial := func(topic, origin string) (*websocket.Conn, error) {
+		return websocket.Dial(url+"/?topic="+topic, "", origin)
+	}
+	wait := func(n int) {
+		for i := 0; i < 100 && hub.Subscribers() != n; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if hub.Subscribers() != n {
+			t.Fatalf("%d subscribers, want %d", hub.Subscribers(), n)
+		}
+	}
+
+	if _, err := dial("a", "http://evil.example.com"); err == nil {
+		t.Errorf("websocket of another origin accepted")
+	}
+	a, err := dial("a", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dial("b", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := dial("slow", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(3)
+
+	hub.Publish(map[string]string{"topic": "a", "n": "1"})
+	hub.Publish(map[string]string{"topic": "b", "n": "2"})
+	var msg string
+	if err := websocket.Message.Receive(a, &msg); err != nil || msg != `{"n":"1","topic":"a"}` {
+		t.Errorf("a received %q, %v", msg, err)
+	}
+	if err := websocket.Message.Receive(b, &msg); err != nil || msg != `{"n":"2","topic":"b"}` {
+		t.Errorf("b received %q, %v", msg, err)
+	}
+
+	// slow does not read: once the socket buffers and its queue are full,
+	// it is evicted while b keeps receiving.
+	big := strings.Repeat("x", 1<<20)
+	for i := 0; i < 200 && hub.Evicted() == 0; i++ {
+		hub.Publish(map[string]string{"topic": "slow", "data": big})
+		time.Sleep(time.Millisecond)
+	}
+	if hub.Evicted() != 1 {
+		t.Errorf("evicted %d, want 1", hub.Evicted())
+	}
+	hub.Publish(map[string]string{"topic": "b", "n": "3"})
+	if err := websocket.Message.Receive(b, &msg); err != nil || msg != `{"n":"3","topic":"b"}` {
+		t.Errorf("b received %q, %v after the eviction", msg, err)
+	}
+	wait(2)
+
+	a.Close()
+	wait(1)
+	b.Close()
+	slow.Close()
+	wait(0)
+}
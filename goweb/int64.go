@@ -0,0 +1,228 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// Int64Strings re-encodes the JSON b of v like DeterministicJSON, with
+// the 64-bit integers of v (the int64, uint64, sint64, fixed64 and
+// sfixed64 fields) as JSON strings, as the proto3 JSON mapping has them:
+// JavaScript numbers lose precision beyond 2^53. Generated handlers use it
+// with the int64_strings parameter.
+func Int64Strings(v interface{}, b []byte) ([]byte, error) {
+	tree, err := decodeTree(b)
+	if err != nil {
+		return nil, err
+	}
+	return DeterministicJSON(quoteInt64s(reflect.ValueOf(v), tree))
+}
+
+// UnmarshalJSON decodes data into v like json.Unmarshal, but accepts the
+// 64-bit integers and the floats of v both as JSON numbers and as
+// strings, including "NaN", "Infinity" and "-Infinity" for floats, and
+// its enums both as numbers and by the names of their values.
+func UnmarshalJSON(data []byte, v interface{}) error {
+	return unmarshalJSON(data, v, EnumPreserve)
+}
+
+func unmarshalJSON(data []byte, v interface{}, enums EnumPolicy) error {
+	err := json.Unmarshal(data, v)
+	e, ok := err.(*json.UnmarshalTypeError)
+	if !ok || e.Value != "string" || !isInt64(e.Type) && !isFloat(e.Type) && !isEnum(e.Type) {
+		return err
+	}
+	tree, err := decodeTree(data)
+	if err != nil {
+		return err
+	}
+	st := unquoteState{enums: enums}
+	tree = unquoteNumbers(reflect.TypeOf(v), "", tree, &st)
+	if st.err != nil {
+		return st.err
+	}
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	if err := json.Unmarshal(b, v); err != nil || !st.nonFinite {
+		return err
+	}
+	// the tree has them as 0 now
+	tree, err = decodeTree(data)
+	if err != nil {
+		return err
+	}
+	setNonFinite(reflect.ValueOf(v), tree)
+	return nil
+}
+
+func isInt64(t reflect.Type) bool {
+	return t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64
+}
+
+var anyGoType = reflect.TypeOf(any.Any{})
+
+// jsonFields returns the fields of the struct type t by their JSON name.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// quoteInt64s turns the numbers of the 64-bit integers of v in its JSON
+// tree into strings.
+func quoteInt64s(v reflect.Value, tree interface{}) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return tree
+		}
+		v = v.Elem()
+	}
+	if n, ok := tree.(json.Number); ok && isInt64(v.Type()) {
+		return string(n)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := tree.(map[string]interface{})
+		if !ok {
+			return tree
+		}
+		if v.Type() == anyGoType && obj["@type"] != nil {
+			// an Any in the JSON mapping, see MarshalAnyJSON
+			a := v.Addr().Interface().(*any.Any)
+			if m, err := UnmarshalAny(a); err == nil {
+				return quoteInt64s(reflect.ValueOf(m), obj)
+			}
+			return tree
+		}
+		for name, i := range jsonFields(v.Type()) {
+			if f, ok := obj[name]; ok {
+				obj[name] = quoteInt64s(v.Field(i), f)
+			}
+		}
+	case reflect.Slice:
+		arr, ok := tree.([]interface{})
+		if !ok || len(arr) != v.Len() {
+			return tree
+		}
+		for i := range arr {
+			arr[i] = quoteInt64s(v.Index(i), arr[i])
+		}
+	case reflect.Map:
+		obj, ok := tree.(map[string]interface{})
+		if !ok {
+			return tree
+		}
+		for _, k := range v.MapKeys() {
+			name := fmt.Sprint(k.Interface())
+			if f, ok := obj[name]; ok {
+				obj[name] = quoteInt64s(v.MapIndex(k), f)
+			}
+		}
+	}
+	return tree
+}
+
+// unquoteState collects what unquoteNumbers found.
+type unquoteState struct {
+	enums     EnumPolicy
+	nonFinite bool  // there were "NaN", "Infinity" or "-Infinity" floats
+	err       error // the first unknown enum name
+}
+
+// unquoteNumbers turns the strings of the 64-bit integers and floats of
+// the type t in a JSON tree into numbers, "NaN", "Infinity" and
+// "-Infinity" into 0, reporting them in st, and the names of the values of
+// enum, if t is an enum, into their numbers.
+func unquoteNumbers(t reflect.Type, enum string, tree interface{}, st *unquoteState) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := tree.(string); ok && (isInt64(t) || isFloat(t)) {
+		if _, ok := parseNonFinite(s); ok && isFloat(t) {
+			st.nonFinite = true
+			return json.Number("0")
+		}
+		return json.Number(s)
+	}
+	if s, ok := tree.(string); ok && isEnum(t) && enum != "" {
+		n, err := enumNumber(enum, s, st.enums)
+		if err != nil && st.err == nil {
+			st.err = err
+		}
+		return n
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := tree.(map[string]interface{})
+		if !ok {
+			return tree
+		}
+		for name, i := range jsonFields(t) {
+			if f, ok := obj[name]; ok {
+				obj[name] = unquoteNumbers(t.Field(i).Type, enumOf(t.Field(i).Tag, "protobuf"), f, st)
+			}
+		}
+	case reflect.Slice:
+		if arr, ok := tree.([]interface{}); ok {
+			for i := range arr {
+				arr[i] = unquoteNumbers(t.Elem(), enum, arr[i], st)
+			}
+		}
+	case reflect.Map:
+		if obj, ok := tree.(map[string]interface{}); ok {
+			for k, f := range obj {
+				obj[k] = unquoteNumbers(t.Elem(), enum, f, st)
+			}
+		}
+	}
+	return tree
+}
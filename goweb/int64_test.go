@@ -0,0 +1,74 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"reflect"
+	"testing"
+)
+
+type counters struct {
+	Name   string              `json:"name,omitempty"`
+	Total  int64               `json:"total,omitempty"`
+	Max    uint64              `json:"max,omitempty"`
+	Small  int32               `json:"small,omitempty"`
+	Series []int64             `json:"series,omitempty"`
+	ByKey  map[string]int64    `json:"by_key,omitempty"`
+	Nested map[int64]*counters `json:"nested,omitempty"`
+}
+
+func TestInt64Strings(t *testing.T) {
+	c := &counters{Name: "1", Total: -9007199254740993, Max: 18446744073709551615, Small: 7,
+		Series: []int64{1, 2}, ByKey: map[string]int64{"a": 3}, Nested: map[int64]*counters{5: {Total: 6}}}
+	b, err := MarshalAnyJSON(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = Int64Strings(c, b)
+	want := `{"by_key":{"a":"3"},"max":"18446744073709551615","name":"1","nested":{"5":{"total":"6"}},"series":["1","2"],"small":7,"total":"-9007199254740993"}` + "\n"
+	if err != nil || string(b) != want {
+		t.Fatalf("Int64Strings = %s, %v", b, err)
+	}
+	for _, doc := range []string{string(b), `{"by_key":{"a":3},"max":18446744073709551615,"name":"1","nested":{"5":{"total":6}},"series":[1,"2"],"small":7,"total":-9007199254740993}`} {
+		var got counters
+		if err := UnmarshalJSON([]byte(doc), &got); err != nil || !reflect.DeepEqual(&got, c) {
+			t.Errorf("UnmarshalJSON(%s) = %+v, %v", doc, got, err)
+		}
+	}
+	var got counters
+	if err := UnmarshalJSON([]byte(`{"total":"x"}`), &got); err == nil {
+		t.Errorf("UnmarshalJSON accepted a string that is no number")
+	}
+	if err := UnmarshalJSON([]byte(`{"name":1,"total":"2"}`), &got); err == nil {
+		t.Errorf("UnmarshalJSON accepted a number for a string")
+	}
+}
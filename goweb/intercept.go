@@ -0,0 +1,161 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// A UnaryHandler calls a unary method of a service with the request in.
+type UnaryHandler func(ctx context.Context, in interface{}) (interface{}, error)
+
+// An Interceptor runs around the unary calls of a service: those of the
+// handlers of a generated mux (see ChainUnaryInterceptor) or of a service
+// wrapped by a generated Wrap<Service>Server function. It is given the
+// route of the method and must call next to continue the call; it may
+// return an error without calling it instead. Package gowebgrpc adapts the
+// interceptors of gRPC servers.
+type Interceptor func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error)
+
+// A ServerOption configures the handlers of a generated mux, e.g.
+// NewUsersMux(impl, "/", goweb.ChainUnaryInterceptor(logging, recovery)).
+type ServerOption func(*ServerOptions)
+
+// ServerOptions are the settings of the handlers of a generated mux.
+type ServerOptions struct {
+	// Interceptor runs around every unary call of the implementation, or
+	// is nil.
+	Interceptor Interceptor
+
+	// ErrorHandler answers the failed calls, if set, see WithErrorHandler.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	// TimeoutHeader is the header of the timeouts of the calls, see
+	// WithTimeoutHeader, or "".
+	TimeoutHeader string
+
+	// Annotators return the metadata of the calls, see
+	// WithHeaderAnnotator.
+	Annotators []HeaderAnnotator
+
+	// MaxBodyBytes limits the request bodies: DefaultMaxBodyBytes if 0,
+	// none if negative, see WithMaxBodyBytes.
+	MaxBodyBytes int64
+
+	// GzipMinBytes is the size from which responses are compressed, or 0
+	// for none, see WithGzip.
+	GzipMinBytes int
+
+	// PropagatedHeaders are the headers of the requests that the calls
+	// pass on to their outbound calls: TraceHeaders if nil, none if
+	// empty, see WithPropagatedHeaders.
+	PropagatedHeaders []string
+
+	// ValidateOnly lets clients ask for dry runs, see WithValidateOnly.
+	ValidateOnly bool
+
+	// Middleware wraps the routes of the mux, the first outermost, e.g.
+	// the one of WithHTTPS.
+	Middleware []func(http.Handler) http.Handler
+
+	// NamedMiddleware are the middlewares the methods name in their
+	// middleware options, see WithNamedMiddleware.
+	NamedMiddleware map[string]func(http.Handler) http.Handler
+}
+
+// WithErrorHandler lets h answer the failed calls of the handlers, with
+// the errors of the implementation and those of decoding and checking the
+// requests, instead of WriteRequestError (or WriteProblem with the
+// error_format=problem parameter), e.g. to map the errors of a library to
+// statuses or to write another error body; AsError helps with the first.
+func WithErrorHandler(h func(w http.ResponseWriter, r *http.Request, err error)) ServerOption {
+	return func(o *ServerOptions) { o.ErrorHandler = h }
+}
+
+// WriteError answers the failed call of r with err: with the ErrorHandler
+// of o if set, else with write. Generated handlers use it.
+func (o ServerOptions) WriteError(w http.ResponseWriter, r *http.Request, err error, write func(w http.ResponseWriter, r *http.Request, err error)) {
+	if o.ErrorHandler != nil {
+		o.ErrorHandler(w, r, err)
+		return
+	}
+	write(w, r, err)
+}
+
+// NewServerOptions returns the settings of opts.
+func NewServerOptions(opts ...ServerOption) ServerOptions {
+	var o ServerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ChainUnaryInterceptor runs the interceptors around the unary calls, the
+// first outermost, after the ones of previous options.
+func ChainUnaryInterceptor(ics ...Interceptor) ServerOption {
+	return func(o *ServerOptions) {
+		// the option may set up several muxes: leave ics as it is
+		all := ics
+		if o.Interceptor != nil {
+			all = append([]Interceptor{o.Interceptor}, ics...)
+		}
+		o.Interceptor = chain(all)
+	}
+}
+
+// UnaryInterceptor runs ic around the unary calls, after the interceptors
+// of previous options.
+func UnaryInterceptor(ic Interceptor) ServerOption {
+	return ChainUnaryInterceptor(ic)
+}
+
+func chain(ics []Interceptor) Interceptor {
+	switch len(ics) {
+	case 0:
+		return nil
+	case 1:
+		return ics[0]
+	}
+	return func(ctx context.Context, route Route, in interface{}, handler UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(ics) - 1; i > 0; i-- {
+			ic, h := ics[i], next
+			next = func(ctx context.Context, in interface{}) (interface{}, error) {
+				return ic(ctx, route, in, h)
+			}
+		}
+		return ics[0](ctx, route, in, next)
+	}
+}
@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestChainUnaryInterceptor(t *testing.T) {
+	var calls []string
+	ic := func(name string) Interceptor {
+		return func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+			calls = append(calls, name+" "+route.FullMethod())
+			out, err := next(ctx, in.(int)+1)
+			calls = append(calls, name+" done")
+			return out, err
+		}
+	}
+	if o := NewServerOptions(); o.Interceptor != nil {
+		t.Errorf("interceptor without options")
+	}
+	o := NewServerOptions(ChainUnaryInterceptor(ic("a"), ic("b")), UnaryInterceptor(ic("c")))
+	route := Route{Service: "pkg.Users", Method: "Get"}
+	out, err := o.Interceptor(context.Background(), route, 0, func(ctx context.Context, in interface{}) (interface{}, error) {
+		calls = append(calls, "handler")
+		return in, nil
+	})
+	if out != 3 || err != nil {
+		t.Errorf("call = %v, %v, want 3, nil", out, err)
+	}
+	want := []string{"a /pkg.Users/Get", "b /pkg.Users/Get", "c /pkg.Users/Get", "handler", "c done", "b done", "a done"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// an option shared by several muxes keeps its own interceptors
+	shared := ChainUnaryInterceptor(ic("a"))
+	NewServerOptions(UnaryInterceptor(ic("x")), shared)
+	o = NewServerOptions(UnaryInterceptor(ic("y")), shared)
+	calls = nil
+	o.Interceptor(context.Background(), route, 0, func(ctx context.Context, in interface{}) (interface{}, error) { return in, nil })
+	want = []string{"y /pkg.Users/Get", "a /pkg.Users/Get", "a done", "y done"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls of the second mux = %q, want %q", calls, want)
+	}
+
+	denied := errors.New("denied")
+	o = NewServerOptions(UnaryInterceptor(func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+		return nil, denied
+	}))
+	if _, err := o.Interceptor(context.Background(), route, 0, nil); err != denied {
+		t.Errorf("short-circuited call = %v, want %v", err, denied)
+	}
+}
@@ -0,0 +1,68 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "testing"
+
+func TestDeterministicJSON(t *testing.T) {
+	type msg struct {
+		Zeta  string            `json:"zeta"`
+		Alpha float64           `json:"alpha"`
+		Tags  map[string]string `json:"tags"`
+	}
+	b, err := DeterministicJSON(msg{"<z>", 1e21, map[string]string{"b": "2", "a": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"alpha":1e+21,"tags":{"a":"1","b":"2"},"zeta":"<z>"}` + "\n"
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestCanonicalHash(t *testing.T) {
+	type req struct {
+		ID   string            `json:"id,omitempty"`
+		Page int32             `json:"page,omitempty"`
+		Meta map[string]string `json:"meta,omitempty"`
+	}
+	a, err := CanonicalHash(&req{ID: "x", Meta: map[string]string{"k": "v", "j": "w"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := CanonicalHash(&req{ID: "x", Meta: map[string]string{"j": "w", "k": "v"}}); a != b {
+		t.Errorf("equal requests hash to %s and %s", a, b)
+	}
+	if b, _ := CanonicalHash(&req{ID: "x", Page: 1, Meta: map[string]string{"j": "w", "k": "v"}}); a == b {
+		t.Errorf("different requests hash to %s", a)
+	}
+}
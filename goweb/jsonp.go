@@ -0,0 +1,78 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// maxCallbackLength limits the names of JSONP callbacks.
+const maxCallbackLength = 128
+
+// callbackName matches the JSONP callback names accepted: JavaScript
+// identifiers, optionally dotted (jQuery.cb_123, window.app.onData).
+var callbackName = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// CheckCallback checks the name of a JSONP callback, the callback query
+// parameter of a request to a GET endpoint generated with the jsonp
+// parameter. Only (dotted) identifiers are accepted, so the name cannot
+// inject script into the response. An empty name is valid: the response
+// is plain JSON then.
+func CheckCallback(name string) error {
+	if name == "" {
+		return nil
+	}
+	if len(name) > maxCallbackLength || !callbackName.MatchString(name) {
+		return fmt.Errorf("invalid JSONP callback %.32q: must be a JavaScript identifier of at most %d characters", name, maxCallbackLength)
+	}
+	return nil
+}
+
+// WriteJSONP writes the JSON document body as a JSONP response, a call of
+// the callback with body as its argument, typed as JavaScript. callback
+// must have passed CheckCallback. The response starts with an empty
+// comment, which keeps it from being taken for other content types by
+// plugins such as Flash.
+func WriteJSONP(w http.ResponseWriter, callback string, body []byte) {
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	var out bytes.Buffer
+	out.WriteString("/**/")
+	out.WriteString(callback)
+	out.WriteByte('(')
+	out.Write(bytes.TrimSpace(body))
+	out.WriteString(");\n")
+	w.Write(out.Bytes())
+}
@@ -0,0 +1,155 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A LimitError reports a request that exceeds a size or complexity limit.
+// Generated handlers answer it with 400.
+type LimitError struct {
+	Field string // the full name of the field, or "" for the nesting depth
+	What  string // "items", "bytes" or "depth"
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("goweb: request nested deeper than %d levels", e.Limit)
+	}
+	return fmt.Sprintf("goweb: field %s has more than %d %s", e.Field, e.Limit, e.What)
+}
+
+// CheckDepth returns a *LimitError if the JSON document data nests objects
+// and arrays deeper than max levels. Malformed JSON is left to the decoder.
+func CheckDepth(data []byte, max int) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			// io.EOF, or a syntax error for the decoder to report.
+			return nil
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			if depth++; depth > max {
+				return &LimitError{What: "depth", Limit: max}
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+// CheckMessageDepth returns a 500 INTERNAL *Error if the message m, as
+// encoded to JSON, nests objects and arrays deeper than max levels, or if
+// it refers to itself (a cycle of a self-referential message, which
+// would never end). Generated handlers check the responses of
+// self-referential types with it before encoding them.
+func CheckMessageDepth(m interface{}, max int) error {
+	return checkMessageDepth(reflect.ValueOf(m), max, 0, "", map[uintptr]bool{})
+}
+
+// checkMessageDepth checks v, at depth levels below the root at path;
+// onPath holds the messages on the path.
+func checkMessageDepth(v reflect.Value, max, depth int, path string, onPath map[uintptr]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			p := v.Pointer()
+			if onPath[p] {
+				return Errorf(500, "INTERNAL", "message refers to itself at %s", path)
+			}
+			onPath[p] = true
+			defer delete(onPath, p)
+		}
+		return checkMessageDepth(v.Elem(), max, depth, path, onPath)
+	case reflect.Struct:
+		if depth++; depth > max {
+			return messageTooDeep(max, path)
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX_") || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := checkMessageDepth(v.Field(i), max, depth, name, onPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 || v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		if depth++; depth > max {
+			return messageTooDeep(max, path)
+		}
+		if v.Kind() == reflect.Map {
+			for _, k := range v.MapKeys() {
+				if err := checkMessageDepth(v.MapIndex(k), max, depth, fmt.Sprintf("%s[%v]", path, k), onPath); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkMessageDepth(v.Index(i), max, depth, fmt.Sprintf("%s[%d]", path, i), onPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func messageTooDeep(max int, path string) error {
+	if path == "" {
+		path = "the root"
+	}
+	return Errorf(500, "INTERNAL", "message nests deeper than %d levels at %s", max, path)
+}
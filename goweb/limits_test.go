@@ -0,0 +1,77 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDepth(t *testing.T) {
+	for doc, ok := range map[string]bool{
+		`{"a":[1,2,{"b":3}]}`: true,
+		`{"a":[[{"b":3}]]}`:   false,
+		`[[[`:                 true, // left to the decoder
+		`"x"`:                 true,
+	} {
+		if err := CheckDepth([]byte(doc), 3); (err == nil) != ok {
+			t.Errorf("CheckDepth(%s, 3) = %v", doc, err)
+		}
+	}
+}
+
+type treeNode struct {
+	Name     string               `json:"name,omitempty"`
+	Children []*treeNode          `json:"children,omitempty"`
+	Links    map[string]*treeNode `json:"links,omitempty"`
+}
+
+func TestCheckMessageDepth(t *testing.T) {
+	leaf := &treeNode{Name: "leaf"}
+	tree := &treeNode{Children: []*treeNode{{Links: map[string]*treeNode{"a": leaf}}}}
+	// {"children":[{"links":{"a":{...}}}]} nests 5 levels.
+	if err := CheckMessageDepth(tree, 5); err != nil {
+		t.Errorf("depth 5: %v", err)
+	}
+	err := CheckMessageDepth(tree, 4)
+	if e, ok := err.(*Error); !ok || e.Status != 500 || !strings.HasSuffix(e.Message, "deeper than 4 levels at children[0].links[a]") {
+		t.Errorf("depth 4: %v", err)
+	}
+	leaf.Children = []*treeNode{tree}
+	err = CheckMessageDepth(tree, 100)
+	if e, ok := err.(*Error); !ok || e.Code != "INTERNAL" || !strings.HasSuffix(e.Message, "refers to itself at children[0].links[a].children[0]") {
+		t.Errorf("cycle: %v", err)
+	}
+	if err := CheckMessageDepth((*treeNode)(nil), 0); err != nil {
+		t.Errorf("nil: %v", err)
+	}
+}
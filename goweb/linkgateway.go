@@ -0,0 +1,202 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zenazn/goji/web"
+)
+
+// A LinkedService is a generated service that LinkGateway can mount on a
+// gateway mux. The init functions of code generated with the linkable
+// parameter register them, so a gateway binary links the services of
+// every generated package it imports.
+type LinkedService struct {
+	Name   string  // fully-qualified, e.g. "pkg.Users"
+	Routes []Route // as registered with RegisterRoutes
+
+	// Mux returns the New<Service>Mux of the implementation impl of the
+	// service under prefix, or nil if impl does not implement its
+	// <Service>Server interface.
+	Mux func(impl interface{}, prefix string, opts ...ServerOption) http.Handler
+}
+
+var linkable struct {
+	sync.Mutex
+	services map[string]LinkedService
+	dups     map[string]int // services registered more than once
+}
+
+// RegisterService registers the service s for LinkGateway. It is called
+// by generated code; a service registered twice, e.g. by two packages
+// generated from copies of its proto file, cannot be linkable.
+func RegisterService(s LinkedService) {
+	linkable.Lock()
+	defer linkable.Unlock()
+	if linkable.services == nil {
+		linkable.services = map[string]LinkedService{}
+		linkable.dups = map[string]int{}
+	}
+	if _, ok := linkable.services[s.Name]; ok {
+		linkable.dups[s.Name]++
+	}
+	linkable.services[s.Name] = s
+}
+
+// LinkedServices returns the names of the services registered for LinkGateway,
+// sorted.
+func LinkedServices() []string {
+	linkable.Lock()
+	defer linkable.Unlock()
+	names := make([]string, 0, len(linkable.services))
+	for name := range linkable.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A RouteConflict is a pair of routes of linked services that match the
+// same requests.
+type RouteConflict struct {
+	Route, Other Route
+}
+
+func (c RouteConflict) String() string {
+	return fmt.Sprintf("%s and %s both serve %s", c.Route.FullMethod(), c.Other.FullMethod(), routeKey(c.Route))
+}
+
+// A LinkError reports why LinkGateway could not assemble a gateway: all the
+// unknown, ambiguous or unimplemented services and conflicting routes.
+type LinkError struct {
+	Problems  []string
+	Conflicts []RouteConflict
+}
+
+func (e *LinkError) Error() string {
+	msgs := append([]string(nil), e.Problems...)
+	for _, c := range e.Conflicts {
+		msgs = append(msgs, c.String())
+	}
+	return "goweb: cannot link gateway: " + strings.Join(msgs, "; ")
+}
+
+// routeKey returns the requests route matches: its verb and the shape of
+// its path template, or "*" and its path for routes without template.
+func routeKey(route Route) string {
+	if t, err := route.Template(); err == nil && t != nil {
+		return route.Verb + " " + t.shape()
+	}
+	return "* /" + route.Path
+}
+
+// conflicting reports whether the routes a and b match the same requests.
+func conflicting(a, b Route) bool {
+	ka, kb := routeKey(a), routeKey(b)
+	if ka == kb {
+		return true
+	}
+	// a route without template takes any verb
+	pa, pb := ka[strings.IndexByte(ka, ' '):], kb[strings.IndexByte(kb, ' '):]
+	return pa == pb && (a.Verb == "" || b.Verb == "")
+}
+
+// LinkGateway assembles a gateway serving registered services under
+// prefix: the services named by impls, by fully-qualified name, each
+// served by the New<Service>Mux of its implementation with opts. It fails
+// with a *LinkError if a service is not registered or registered more
+// than once, if an implementation does not implement its service, or if
+// routes of different services conflict. The gateway also serves the
+// routes of all the services at <prefix>/_routes; the other endpoints of
+// the generated muxes are not linkable.
+func LinkGateway(prefix string, impls map[string]interface{}, opts ...ServerOption) (*web.Mux, error) {
+	names := make([]string, 0, len(impls))
+	for name := range impls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	linkable.Lock()
+	services := make([]LinkedService, 0, len(names))
+	e := &LinkError{}
+	for _, name := range names {
+		s, ok := linkable.services[name]
+		switch {
+		case !ok:
+			e.Problems = append(e.Problems, fmt.Sprintf("service %s is not registered", name))
+		case linkable.dups[name] > 0:
+			e.Problems = append(e.Problems, fmt.Sprintf("service %s is registered by several packages", name))
+		default:
+			services = append(services, s)
+		}
+	}
+	linkable.Unlock()
+
+	var all []Route
+	owner := map[string]string{} // routes of all by FullMethod to their service
+	muxes := make([]http.Handler, len(services))
+	for i, s := range services {
+		if muxes[i] = s.Mux(impls[s.Name], prefix, opts...); muxes[i] == nil {
+			e.Problems = append(e.Problems, fmt.Sprintf("%T does not implement service %s", impls[s.Name], s.Name))
+		}
+		for _, route := range s.Routes {
+			for _, other := range all {
+				if owner[other.FullMethod()] != s.Name && conflicting(route, other) {
+					e.Conflicts = append(e.Conflicts, RouteConflict{route, other})
+				}
+			}
+			owner[route.FullMethod()] = s.Name
+			all = append(all, route)
+		}
+	}
+	if len(e.Problems) > 0 || len(e.Conflicts) > 0 {
+		return nil, e
+	}
+
+	router := web.New()
+	for i, s := range services {
+		for _, route := range s.Routes {
+			if t, err := route.Template(); err == nil && t != nil {
+				router.Handle(TemplatePattern(prefix, route.Verb, t), muxes[i])
+				continue
+			}
+			router.Handle(JoinPath(prefix, route.Path), muxes[i])
+		}
+	}
+	router.Get(JoinPath(prefix, "_routes"), RoutesHandler(all))
+	return router, nil
+}
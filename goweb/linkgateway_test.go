@@ -0,0 +1,119 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type linkImpl string
+
+// linkService registers a service whose mux answers with impl and the
+// path, serving implementations of type linkImpl.
+func linkService(name string, routes ...Route) {
+	for i := range routes {
+		routes[i].Service = name
+	}
+	RegisterService(LinkedService{Name: name, Routes: routes, Mux: func(impl interface{}, prefix string, opts ...ServerOption) http.Handler {
+		s, ok := impl.(linkImpl)
+		if !ok {
+			return nil
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(string(s) + " " + r.URL.Path))
+		})
+	}})
+}
+
+// resetLinkable empties the registry of RegisterService until the end of
+// the test.
+func resetLinkable(t *testing.T) {
+	linkable.Lock()
+	services, dups := linkable.services, linkable.dups
+	linkable.services, linkable.dups = nil, nil
+	linkable.Unlock()
+	t.Cleanup(func() {
+		linkable.Lock()
+		linkable.services, linkable.dups = services, dups
+		linkable.Unlock()
+	})
+}
+
+func TestLinkGateway(t *testing.T) {
+	resetLinkable(t)
+	linkService("link.Users", Route{Method: "Get", Verb: "GET", Path: "v1/{name=users/*}"}, Route{Method: "Legacy", Path: "users/legacy"})
+	linkService("link.Books", Route{Method: "Get", Verb: "GET", Path: "v1/{name=books/*}"})
+	mux, err := LinkGateway("/api", map[string]interface{}{"link.Users": linkImpl("users"), "link.Books": linkImpl("books")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/api/v1/users/7":   "users /api/v1/users/7",
+		"/api/v1/books/9":   "books /api/v1/books/9",
+		"/api/users/legacy": "users /api/users/legacy",
+		"/api/v1/shelves/1": "404 page not found\n",
+		"/api/_routes":      `"service":"link.Books"`,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s: %q", path, w.Body)
+		}
+	}
+}
+
+func TestLinkGatewayErrors(t *testing.T) {
+	resetLinkable(t)
+	linkService("link.Shelves", Route{Method: "Get", Verb: "GET", Path: "v1/{name=shelves/*}"}, Route{Method: "Old", Path: "v1/shelves/old"})
+	linkService("link.Racks", Route{Method: "Get", Verb: "GET", Path: "v1/{rack=shelves/*}"}, Route{Method: "Old", Verb: "POST", Path: "v1/shelves/old"})
+	linkService("link.Twice")
+	linkService("link.Twice")
+	_, err := LinkGateway("/", map[string]interface{}{
+		"link.Shelves": linkImpl("shelves"),
+		"link.Racks":   linkImpl("racks"),
+		"link.Twice":   linkImpl("twice"),
+		"link.Missing": linkImpl("missing"),
+	})
+	e, ok := err.(*LinkError)
+	if !ok || len(e.Problems) != 2 || len(e.Conflicts) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if c := e.Conflicts[0].String(); c != "/link.Shelves/Get and /link.Racks/Get both serve GET /v1/shelves/*" {
+		t.Errorf("conflict %q", c)
+	}
+	if _, err := LinkGateway("/", map[string]interface{}{"link.Shelves": 1}); err == nil || !strings.Contains(err.Error(), "int does not implement service link.Shelves") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// A Link is a related link of a response, as declared with the link
+// method option.
+type Link struct {
+	Rel  string
+	Href string
+}
+
+// ResolveLinks returns the links of the response m declared by templates,
+// each "rel=template" as in the link option, with every {field} of the
+// template replaced by the value of that dotted field path of m (proto or
+// JSON names), escaped for the path or the query. Links referring to a
+// field that is unset (zero) are left out, such as the next page of the
+// last page.
+func ResolveLinks(m interface{}, templates ...string) []Link {
+	var links []Link
+	v := reflect.ValueOf(m)
+	for _, t := range templates {
+		i := strings.Index(t, "=")
+		if i < 0 {
+			continue
+		}
+		if href, ok := expandLink(v, t[i+1:]); ok {
+			links = append(links, Link{Rel: t[:i], Href: href})
+		}
+	}
+	return links
+}
+
+// expandLink replaces the {field} references of template by their values
+// in the message v, or reports false if one of them is unset.
+func expandLink(v reflect.Value, template string) (string, bool) {
+	var b strings.Builder
+	query := false
+	for {
+		i := strings.IndexAny(template, "{?")
+		if i < 0 {
+			b.WriteString(template)
+			return b.String(), true
+		}
+		b.WriteString(template[:i])
+		if template[i] == '?' {
+			query = true
+			b.WriteByte('?')
+			template = template[i+1:]
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			b.WriteString(template[i:])
+			return b.String(), true
+		}
+		s, ok := linkValue(v, template[i+1:i+end])
+		if !ok {
+			return "", false
+		}
+		if query {
+			b.WriteString(url.QueryEscape(s))
+		} else {
+			b.WriteString(url.PathEscape(s))
+		}
+		template = template[i+end+1:]
+	}
+}
+
+// linkValue returns the value of the scalar field at path in the message
+// v as a string, or false if it is unset.
+func linkValue(v reflect.Value, path string) (string, bool) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return "", false
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return "", false
+		}
+		i, ok := fieldByName(v.Type(), name)
+		if !ok {
+			return "", false
+		}
+		v = v.Field(i)
+	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		// wrappers, such as google.protobuf.StringValue
+		if i, ok := jsonFields(v.Elem().Type())["value"]; ok {
+			v = v.Elem().Field(i)
+		}
+	}
+	if !v.IsValid() || v.IsZero() {
+		return "", false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), true
+	}
+	return "", false
+}
+
+// WriteLinkHeader adds the links as Link headers (RFC 8288) to the
+// response.
+func WriteLinkHeader(w http.ResponseWriter, links []Link) {
+	for _, l := range links {
+		w.Header().Add("Link", "<"+l.Href+`>; rel="`+l.Rel+`"`)
+	}
+}
+
+// InjectLinks returns the JSON object out with the links in a _links
+// member, as HAL has them: {"_links": {"self": {"href": "/v1/users/1"}},
+// ...}. out is returned unchanged if it is not an object or there are no
+// links.
+func InjectLinks(out []byte, links []Link) []byte {
+	if len(links) == 0 {
+		return out
+	}
+	hal := map[string]map[string]string{}
+	for _, l := range links {
+		hal[l.Rel] = map[string]string{"href": l.Href}
+	}
+	return injectMember(out, "_links", hal)
+}
+
+// injectMember returns the JSON object out with the member name set to v
+// as its first member, or out unchanged if it is not an object.
+func injectMember(out []byte, name string, v interface{}) []byte {
+	trimmed := bytes.TrimSpace(out)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return out
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(map[string]interface{}{name: v}); err != nil {
+		return out
+	}
+	members := bytes.TrimSpace(b.Bytes())
+	members = members[:len(members)-1] // without the closing brace
+	rest := bytes.TrimSpace(trimmed[1:])
+	if rest[0] != '}' {
+		members = append(members, ',')
+	}
+	res := append(members, rest...)
+	if out[len(out)-1] == '\n' {
+		res = append(res, '\n')
+	}
+	return res
+}
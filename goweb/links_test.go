@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type linked struct {
+	Id            string  `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	NextPageToken string  `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken" json:"next_page_token,omitempty"`
+	Owner         *linked `protobuf:"bytes,3,opt,name=owner" json:"owner,omitempty"`
+	Size          int64   `protobuf:"varint,4,opt,name=size" json:"size,omitempty"`
+}
+
+func TestResolveLinks(t *testing.T) {
+	m := &linked{Id: "a/b", Owner: &linked{Id: "jane doe"}, Size: 3}
+	links := ResolveLinks(m,
+		"self=/v1/items/{id}",
+		"owner=/v1/users/{owner.id}?size={size}&q={owner.id}",
+		"next=/v1/items?page_token={nextPageToken}",
+		"about=https://example.com/docs")
+	want := []Link{
+		{"self", "/v1/items/a%2Fb"},
+		{"owner", "/v1/users/jane%20doe?size=3&q=jane+doe"},
+		{"about", "https://example.com/docs"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("ResolveLinks = %v, want %v", links, want)
+	}
+	if links := ResolveLinks(&linked{}, "owner=/v1/users/{owner.id}"); len(links) != 0 {
+		t.Errorf("ResolveLinks of an unset message = %v", links)
+	}
+
+	w := httptest.NewRecorder()
+	WriteLinkHeader(w, want[:2])
+	if got := w.Header()["Link"]; !reflect.DeepEqual(got, []string{`</v1/items/a%2Fb>; rel="self"`, `</v1/users/jane%20doe?size=3&q=jane+doe>; rel="owner"`}) {
+		t.Errorf("Link = %q", got)
+	}
+}
+
+func TestInjectLinks(t *testing.T) {
+	links := []Link{{"self", "/v1/items/1?a=b&c=d"}}
+	for _, tc := range []struct{ in, want string }{
+		{`{"id":"1"}` + "\n", `{"_links":{"self":{"href":"/v1/items/1?a=b&c=d"}},"id":"1"}` + "\n"},
+		{`{}`, `{"_links":{"self":{"href":"/v1/items/1?a=b&c=d"}}}`},
+		{`[1]`, `[1]`},
+	} {
+		if got := string(InjectLinks([]byte(tc.in), links)); got != tc.want {
+			t.Errorf("InjectLinks(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+	if got := string(InjectLinks([]byte(`{"id":"1"}`), nil)); got != `{"id":"1"}` {
+		t.Errorf("InjectLinks without links = %s", got)
+	}
+}
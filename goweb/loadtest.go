@@ -0,0 +1,204 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A LoadTarget is a route under load test, with the generator of its
+// requests; Next is called with the number of the request, from 0. The
+// generated <Service>LoadTargets functions return the targets of a
+// service.
+type LoadTarget struct {
+	Route Route
+	Next  func(i int) interface{}
+}
+
+// A LoadTest calls routes many times, concurrently, and measures their
+// latencies, to benchmark implementations behind the generated layer. It
+// calls Handler in-process if set, and BaseURL over http otherwise.
+type LoadTest struct {
+	Handler http.Handler
+	BaseURL string
+	Client  *http.Client // http.DefaultClient if nil
+	Prefix  string       // the mux prefix of the routes
+
+	Requests    int // per target; 100 if 0
+	Concurrency int // parallel requests; 1 if 0
+	Rate        int // requests per second per target; unlimited if 0
+}
+
+// LoadResult reports the calls of one target of a LoadTest.
+type LoadResult struct {
+	Route    Route
+	Requests int
+	Errors   int         // transport errors and non-2xx responses
+	Statuses map[int]int // number of responses per status
+	Duration time.Duration
+
+	P50, P90, P99, Max time.Duration
+}
+
+func (r LoadResult) String() string {
+	return fmt.Sprintf("%s: %d requests, %d errors, %.1f/s, p50 %v, p90 %v, p99 %v, max %v",
+		r.Route.Method, r.Requests, r.Errors, float64(r.Requests)/r.Duration.Seconds(), r.P50, r.P90, r.P99, r.Max)
+}
+
+// Run tests the targets one after the other, until done or ctx is
+// canceled.
+func (l *LoadTest) Run(ctx context.Context, targets []LoadTarget) []LoadResult {
+	results := make([]LoadResult, 0, len(targets))
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, l.run(ctx, t))
+	}
+	return results
+}
+
+func (l *LoadTest) run(ctx context.Context, t LoadTarget) LoadResult {
+	n, workers := l.Requests, l.Concurrency
+	if n <= 0 {
+		n = 100
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	var tick <-chan time.Time
+	if l.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(l.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	res := LoadResult{Route: t.Route, Statuses: map[int]int{}}
+	latencies := make([]time.Duration, 0, n)
+	var mu sync.Mutex
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				status, err := l.call(ctx, t.Route, t.Next(i))
+				d := time.Since(start)
+				mu.Lock()
+				res.Requests++
+				latencies = append(latencies, d)
+				if status != 0 {
+					res.Statuses[status]++
+				}
+				if err != nil || status < 200 || status > 299 {
+					res.Errors++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	start := time.Now()
+send:
+	for i := 0; i < n; i++ {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				break send
+			}
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	res.Duration = time.Since(start)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	res.P50, res.P90, res.P99 = percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99)
+	if len(latencies) > 0 {
+		res.Max = latencies[len(latencies)-1]
+	}
+	return res
+}
+
+// percentile returns the p-th percentile of the sorted durations d.
+func percentile(d []time.Duration, p int) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	return d[(len(d)*p+99)/100-1]
+}
+
+// call posts in to route and returns the status of the response.
+func (l *LoadTest) call(ctx context.Context, route Route, in interface{}) (int, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", JoinPath(JoinPath(l.BaseURL, l.Prefix), route.Path), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if l.Handler != nil {
+		w := httptest.NewRecorder()
+		l.Handler.ServeHTTP(w, req)
+		return w.Code, nil
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	return res.StatusCode, nil
+}
@@ -0,0 +1,111 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextPageTokenHeader carries the next_page_token of paginated responses
+// with the response_meta=header parameter.
+const NextPageTokenHeader = "X-Next-Page-Token"
+
+// FieldMaskHeader carries the paths of the field mask of the request with
+// the response_meta=header parameter.
+const FieldMaskHeader = "X-Field-Mask"
+
+// ResponseMeta is the metadata of a response that handlers generated with
+// the response_meta parameter send, as headers or as the _meta member of
+// the response.
+type ResponseMeta struct {
+	// ServerTiming is the time the implementation took.
+	ServerTiming time.Duration `json:"-"`
+
+	// NextPageToken is the next_page_token of the response, for
+	// paginated list methods (AIP-158).
+	NextPageToken string `json:"next_page_token,omitempty"`
+
+	// FieldMask lists the paths, separated by commas, of the
+	// google.protobuf.FieldMask of the request, such as a read_mask.
+	FieldMask string `json:"field_mask,omitempty"`
+
+	start time.Time
+}
+
+// NewResponseMeta returns the metadata of a call whose implementation is
+// called now.
+func NewResponseMeta() *ResponseMeta {
+	return &ResponseMeta{start: time.Now()}
+}
+
+// Done records the time the implementation took, when it returned.
+func (m *ResponseMeta) Done() {
+	m.ServerTiming = time.Since(m.start)
+}
+
+// WriteHeaders adds the metadata to the headers of the response: the
+// timing as Server-Timing ("app;dur=1.25", in milliseconds), the next page
+// token as X-Next-Page-Token and the field mask as X-Field-Mask.
+func (m *ResponseMeta) WriteHeaders(w http.ResponseWriter) {
+	w.Header().Add("Server-Timing", "app;dur="+m.millis())
+	if m.NextPageToken != "" {
+		w.Header().Set(NextPageTokenHeader, m.NextPageToken)
+	}
+	if m.FieldMask != "" {
+		w.Header().Set(FieldMaskHeader, m.FieldMask)
+	}
+}
+
+// InjectMeta returns the JSON object out with the metadata in a _meta
+// member: {"_meta": {"server_timing_ms": 1.25, "next_page_token": "..."},
+// ...}. Clients decoding the response into its message ignore it. out is
+// returned unchanged if it is not an object.
+func InjectMeta(out []byte, m *ResponseMeta) []byte {
+	return injectMember(out, "_meta", struct {
+		ServerTimingMillis json.Number `json:"server_timing_ms"`
+		*ResponseMeta
+	}{json.Number(m.millis()), m})
+}
+
+// millis returns the timing in milliseconds.
+func (m *ResponseMeta) millis() string {
+	return strconv.FormatFloat(float64(m.ServerTiming.Microseconds())/1000, 'f', -1, 64)
+}
+
+// MaskPaths returns the paths of the field mask m, separated by commas.
+func MaskPaths(m interface{ GetPaths() []string }) string {
+	return strings.Join(m.GetPaths(), ",")
+}
@@ -0,0 +1,73 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mask has the getter of google.protobuf.FieldMask.
+type mask struct{ paths []string }
+
+func (m *mask) GetPaths() []string {
+	if m == nil {
+		return nil
+	}
+	return m.paths
+}
+
+func TestResponseMeta(t *testing.T) {
+	m := &ResponseMeta{ServerTiming: 1250 * time.Microsecond, NextPageToken: "p2"}
+	w := httptest.NewRecorder()
+	m.WriteHeaders(w)
+	if got := w.Header().Get("Server-Timing"); got != "app;dur=1.25" {
+		t.Errorf("Server-Timing = %q", got)
+	}
+	if got := w.Header().Get(NextPageTokenHeader); got != "p2" {
+		t.Errorf("%s = %q", NextPageTokenHeader, got)
+	}
+	if _, ok := w.Header()[FieldMaskHeader]; ok {
+		t.Errorf("%s set without a mask", FieldMaskHeader)
+	}
+	if got := string(InjectMeta([]byte(`{"a":1}`+"\n"), m)); got != `{"_meta":{"server_timing_ms":1.25,"next_page_token":"p2"},"a":1}`+"\n" {
+		t.Errorf("InjectMeta = %s", got)
+	}
+	if got := MaskPaths(&mask{[]string{"a", "b.c"}}); got != "a,b.c" {
+		t.Errorf("MaskPaths = %q", got)
+	}
+	var none *mask
+	if got := MaskPaths(none); got != "" {
+		t.Errorf("MaskPaths(nil) = %q", got)
+	}
+}
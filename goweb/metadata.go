@@ -0,0 +1,226 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// MD is the metadata of a call: headers by lowercase name, like the
+// metadata.MD of gRPC, to which it converts.
+type MD map[string][]string
+
+// Get returns the values of the key, in any case.
+func (md MD) Get(key string) []string {
+	return md[strings.ToLower(key)]
+}
+
+// A HeaderAnnotator returns the metadata of the call of the incoming
+// request r, e.g. from its headers or its TLS state, see
+// WithHeaderAnnotator.
+type HeaderAnnotator func(ctx context.Context, r *http.Request) MD
+
+// WithHeaderAnnotator adds the metadata a returns for every call to its
+// context, where the implementation reads it with MetadataFrom.
+func WithHeaderAnnotator(a HeaderAnnotator) ServerOption {
+	return func(o *ServerOptions) { o.Annotators = append(o.Annotators, a) }
+}
+
+// WithIncomingHeaders copies the headers of the requests with the names,
+// e.g. "Authorization" and "X-Request-Id", to the metadata of the calls.
+func WithIncomingHeaders(names ...string) ServerOption {
+	return WithHeaderAnnotator(func(ctx context.Context, r *http.Request) MD {
+		md := MD{}
+		for _, name := range names {
+			if v := r.Header.Values(name); len(v) > 0 {
+				md[strings.ToLower(name)] = v
+			}
+		}
+		return md
+	})
+}
+
+// WithTimeoutHeader sets the deadline of the calls from the header name of
+// their requests, e.g. "Grpc-Timeout", in the format of gRPC ("100m", a
+// number and one of the units H, M, S, m, u and n) or of time.Duration
+// ("1.5s"). Calls with malformed timeouts fail with INVALID_ARGUMENT.
+func WithTimeoutHeader(name string) ServerOption {
+	return func(o *ServerOptions) { o.TimeoutHeader = name }
+}
+
+// grpcTimeoutUnits are the units of the gRPC timeout format.
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+	'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+}
+
+// ParseTimeout parses a timeout header value, see WithTimeoutHeader.
+// gRPC timeouts beyond the longest time.Duration, some 292 years, are
+// clamped to it.
+func ParseTimeout(v string) (time.Duration, error) {
+	if n := len(v); n > 1 && n <= 9 {
+		if unit, ok := grpcTimeoutUnits[v[n-1]]; ok {
+			if t, err := strconv.ParseUint(v[:n-1], 10, 64); err == nil {
+				if t > uint64(math.MaxInt64/unit) {
+					return math.MaxInt64, nil
+				}
+				return time.Duration(t) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, errors.New("goweb: malformed timeout " + strconv.Quote(v))
+	}
+	return d, nil
+}
+
+// timeoutUnits are the units of FormatTimeout, the finest first.
+var timeoutUnits = []byte{'n', 'u', 'm', 'S', 'M', 'H'}
+
+// FormatTimeout formats d in the gRPC timeout format, with the finest
+// unit that keeps it to the 8 digits the format allows, rounded down.
+func FormatTimeout(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	for _, unit := range timeoutUnits {
+		if n := d / grpcTimeoutUnits[unit]; n < 1e8 {
+			return strconv.FormatInt(int64(n), 10) + string(unit)
+		}
+	}
+	return "99999999H"
+}
+
+type callKey struct{}
+
+// callMeta is the metadata of a call in its context.
+type callMeta struct {
+	md MD
+	w  http.ResponseWriter
+}
+
+// NewContext returns the context in which the handlers call the
+// implementation for r, answered on w: the one of NewContext, canceled
+// when the client goes away, with the deadline of the timeout header of
+// o, marked as a dry run if it asks for one (see WithValidateOnly), the
+// metadata of its annotators, its propagated headers (see
+// WithPropagatedHeaders) and w for SetHeader and SetTrailer.
+// cancel releases the resources of the deadline.
+func (o ServerOptions) NewContext(w http.ResponseWriter, r *http.Request) (ctx context.Context, cancel context.CancelFunc, err error) {
+	ctx = NewContext(r)
+	cancel = func() {}
+	if o.TimeoutHeader != "" {
+		if v := r.Header.Get(o.TimeoutHeader); v != "" {
+			d, err := ParseTimeout(v)
+			if err != nil {
+				return nil, nil, &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: err.Error()}
+			}
+			ctx, cancel = context.WithTimeout(ctx, d)
+		}
+	}
+	if o.ValidateOnly {
+		v, err := ValidateOnly(r)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		if v {
+			ctx = WithDryRun(ctx)
+		}
+	}
+	names := o.PropagatedHeaders
+	if names == nil {
+		names = TraceHeaders
+	}
+	if h := propagatedHeaders(r, names); h != nil {
+		ctx = WithOutgoingHeaders(ctx, h)
+	}
+	meta := &callMeta{md: MD{}, w: w}
+	for _, a := range o.Annotators {
+		for k, v := range a(ctx, r) {
+			k = strings.ToLower(k)
+			meta.md[k] = append(meta.md[k], v...)
+		}
+	}
+	return context.WithValue(ctx, callKey{}, meta), cancel, nil
+}
+
+// MetadataFrom returns the metadata of the call in ctx, from the
+// annotators of the mux, or nil.
+func MetadataFrom(ctx context.Context) MD {
+	if meta, ok := ctx.Value(callKey{}).(*callMeta); ok {
+		return meta.md
+	}
+	return nil
+}
+
+// errNoCall is the error of SetHeader and SetTrailer outside of calls.
+var errNoCall = errors.New("goweb: not the context of a call")
+
+// SetHeader adds md to the headers of the response of the call in ctx,
+// like grpc.SetHeader. The implementation of a unary method may call it
+// until it returns, also when it fails.
+func SetHeader(ctx context.Context, md MD) error {
+	meta, ok := ctx.Value(callKey{}).(*callMeta)
+	if !ok {
+		return errNoCall
+	}
+	for k, v := range md {
+		for _, v := range v {
+			meta.w.Header().Add(k, v)
+		}
+	}
+	return nil
+}
+
+// SetTrailer adds md to the trailers of the response of the call in ctx,
+// like grpc.SetTrailer, which are sent after the body.
+func SetTrailer(ctx context.Context, md MD) error {
+	meta, ok := ctx.Value(callKey{}).(*callMeta)
+	if !ok {
+		return errNoCall
+	}
+	for k, v := range md {
+		for _, v := range v {
+			meta.w.Header().Add(http.TrailerPrefix+k, v)
+		}
+	}
+	return nil
+}
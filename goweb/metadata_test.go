@@ -0,0 +1,128 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestParseTimeout(t *testing.T) {
+	for v, want := range map[string]time.Duration{
+		"100m": 100 * time.Millisecond,
+		"2S":   2 * time.Second,
+		"1H":   time.Hour,
+		"1.5s": 1500 * time.Millisecond,
+		"7n":   7,
+		// beyond the longest duration
+		"3000000H":  math.MaxInt64,
+		"99999999H": math.MaxInt64,
+		"2562047H":  2562047 * time.Hour,
+	} {
+		if d, err := ParseTimeout(v); err != nil || d != want {
+			t.Errorf("ParseTimeout(%q) = %v, %v, want %v", v, d, err, want)
+		}
+	}
+	for _, v := range []string{"", "m", "1x", "-1s", "123456789S"} {
+		if _, err := ParseTimeout(v); err == nil {
+			t.Errorf("ParseTimeout(%q) succeeds", v)
+		}
+	}
+}
+
+func TestServerOptionsNewContext(t *testing.T) {
+	opts := NewServerOptions(
+		WithTimeoutHeader("Grpc-Timeout"),
+		WithIncomingHeaders("Authorization", "X-Request-Id"),
+		WithHeaderAnnotator(func(ctx context.Context, r *http.Request) MD {
+			return MD{"Path": {r.URL.Path}}
+		}),
+	)
+	r := httptest.NewRequest("GET", "/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer t")
+	r.Header.Set("Grpc-Timeout", "1S")
+	parent, stop := context.WithCancel(r.Context())
+	r = r.WithContext(parent)
+	w := httptest.NewRecorder()
+	ctx, cancel, err := opts.NewContext(w, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if RequestFrom(ctx) != r {
+		t.Error("no request in the context")
+	}
+	if d, ok := ctx.Deadline(); !ok || time.Until(d) > time.Second {
+		t.Errorf("deadline = %v, %v", d, ok)
+	}
+	md := MetadataFrom(ctx)
+	if got := md.Get("Authorization"); len(got) != 1 || got[0] != "Bearer t" {
+		t.Errorf("authorization = %q", got)
+	}
+	if got := md.Get("path"); len(got) != 1 || got[0] != "/v1/x" {
+		t.Errorf("path = %q", got)
+	}
+	if _, ok := md["x-request-id"]; ok {
+		t.Error("metadata of a missing header")
+	}
+	if err := SetHeader(ctx, MD{"X-Served-By": {"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTrailer(ctx, MD{"X-Checksum": {"c"}}); err != nil {
+		t.Fatal(err)
+	}
+	w.WriteHeader(200)
+	w.WriteString("{}")
+	res := w.Result()
+	if res.Header.Get("X-Served-By") != "a" || res.Trailer.Get("X-Checksum") != "c" {
+		t.Errorf("header = %v, trailer = %v", res.Header, res.Trailer)
+	}
+	stop()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("err after the client went away = %v", ctx.Err())
+	}
+
+	r.Header.Set("Grpc-Timeout", "soon")
+	if _, _, err := opts.NewContext(w, r); err == nil {
+		t.Error("malformed timeout accepted")
+	} else if e, _ := AsError(err); e.Status != 400 || e.Code != "INVALID_ARGUMENT" {
+		t.Errorf("err = %v", err)
+	}
+	if SetHeader(context.Background(), MD{"a": {"b"}}) == nil {
+		t.Error("SetHeader outside of a call succeeds")
+	}
+}
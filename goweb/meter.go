@@ -0,0 +1,157 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A Usage is the metering record of a call, e.g. for billing.
+type Usage struct {
+	Tenant        string        `json:"tenant,omitempty"` // see TenantFrom
+	Route         Route         `json:"route"`
+	RequestBytes  int64         `json:"request_bytes"`  // of the request body, as received
+	ResponseBytes int64         `json:"response_bytes"` // of the response body, as sent
+	Status        int           `json:"status"`
+	Time          time.Time     `json:"time"`
+	Duration      time.Duration `json:"duration"`
+	TraceID       string        `json:"trace_id,omitempty"` // of the sampled trace of the call, see SampledTraceID
+}
+
+// A Meter receives the Usage of every call of the http handlers generated
+// with the metering parameter. Record is called synchronously at the end
+// of the call, so it should not block.
+type Meter interface {
+	Record(ctx context.Context, u *Usage)
+}
+
+// MeterFunc adapts a function to a Meter.
+type MeterFunc func(ctx context.Context, u *Usage)
+
+// Record calls f(ctx, u).
+func (f MeterFunc) Record(ctx context.Context, u *Usage) { f(ctx, u) }
+
+// Metering receives the Usage of the calls; calls are not metered while
+// it is nil.
+var Metering Meter
+
+// Meters returns a Meter recording the Usage to each of ms in turn, e.g.
+// to a billing pipeline and to CallMetrics.
+func Meters(ms ...Meter) Meter {
+	return MeterFunc(func(ctx context.Context, u *Usage) {
+		for _, m := range ms {
+			m.Record(ctx, u)
+		}
+	})
+}
+
+type usageKey struct{}
+
+// MeterCall starts metering a call of route answering r on w. It returns
+// the writer and request the handler must use instead, and the function
+// recording the Usage to Metering, which the handler defers.
+func MeterCall(w http.ResponseWriter, r *http.Request, route Route) (http.ResponseWriter, *http.Request, func()) {
+	m := Metering
+	if m == nil {
+		return w, r, func() {}
+	}
+	u := &Usage{Route: route, Time: time.Now(), TraceID: SampledTraceID(r)}
+	mw := &meteredWriter{ResponseWriter: w, u: u}
+	r = r.WithContext(context.WithValue(r.Context(), usageKey{}, u))
+	if r.Body != nil {
+		r.Body = &meteredBody{r.Body, u}
+	}
+	return mw, r, func() {
+		u.Duration = time.Since(u.Time)
+		if u.Status == 0 {
+			u.Status = 200
+		}
+		if u.Tenant == "" {
+			u.Tenant = TenantFrom(r.Context())
+		}
+		if u.Tenant == "" {
+			u.Tenant = r.Header.Get(TenantHeader)
+		}
+		m.Record(r.Context(), u)
+	}
+}
+
+// SetUsageTenant sets the tenant of the Usage of the call of ctx, once
+// the generated handler resolved it.
+func SetUsageTenant(ctx context.Context, tenant string) {
+	if r := RequestFrom(ctx); r != nil {
+		if u, ok := r.Context().Value(usageKey{}).(*Usage); ok {
+			u.Tenant = tenant
+		}
+	}
+}
+
+type meteredWriter struct {
+	http.ResponseWriter
+	u *Usage
+}
+
+func (w *meteredWriter) WriteHeader(status int) {
+	if w.u.Status == 0 {
+		w.u.Status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *meteredWriter) Write(b []byte) (int, error) {
+	if w.u.Status == 0 {
+		w.u.Status = 200
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.u.ResponseBytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer.
+func (w *meteredWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	u *Usage
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.u.RequestBytes += int64(n)
+	return n, err
+}
@@ -0,0 +1,233 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the duration
+// buckets of CallMetrics, those of the Prometheus clients.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// OpenMetricsContentType is the media type of the OpenMetrics text format,
+// which Prometheus asks for to scrape exemplars.
+const OpenMetricsContentType = "application/openmetrics-text"
+
+// CallMetrics is a Meter keeping the metrics of the calls that
+// PrometheusRules refers to: per method the histogram of the durations,
+// with Buckets and the latency objective of the method as bounds, and per
+// method and status the counter of the calls. As an http.Handler, e.g. at
+// /metrics, it serves them in the OpenMetrics format to scrapers asking
+// for it, with the trace id of the latest sampled call of each bucket as
+// its exemplar (see Usage.TraceID), so that a slow bucket leads to one of
+// its traces; other clients get the Prometheus text format, which has no
+// exemplars. Set it as Metering, or with Meters next to another Meter:
+//
+//	metrics := &goweb.CallMetrics{}
+//	goweb.Metering = metrics
+//	http.Handle("/metrics", metrics)
+type CallMetrics struct {
+	// Metrics names the histogram, the counter and their labels;
+	// "http_request_duration_seconds", "http_requests_total", "method"
+	// and "code" if empty. Window is not used.
+	Metrics PrometheusMetrics
+
+	// Buckets are the upper bounds of the duration buckets in seconds,
+	// DefaultLatencyBuckets if nil.
+	Buckets []float64
+
+	mu      sync.Mutex
+	methods map[string]*methodMetrics
+}
+
+type methodMetrics struct {
+	bounds    []float64 // sorted, the last is +Inf
+	counts    []uint64  // per bucket, not cumulative
+	exemplars []*exemplar
+	sum       float64
+	statuses  map[int]uint64
+}
+
+type exemplar struct {
+	traceID string
+	value   float64
+	time    time.Time
+}
+
+// Record adds the call of u to the metrics.
+func (m *CallMetrics) Record(ctx context.Context, u *Usage) {
+	method := u.Route.FullMethod()
+	d := u.Duration.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.methods == nil {
+		m.methods = make(map[string]*methodMetrics)
+	}
+	mm := m.methods[method]
+	if mm == nil {
+		mm = m.newMethod(u.Route)
+		m.methods[method] = mm
+	}
+	i := sort.SearchFloat64s(mm.bounds, d)
+	mm.counts[i]++
+	if u.TraceID != "" {
+		mm.exemplars[i] = &exemplar{u.TraceID, d, u.Time.Add(u.Duration)}
+	}
+	mm.sum += d
+	mm.statuses[u.Status]++
+}
+
+func (m *CallMetrics) newMethod(route Route) *methodMetrics {
+	buckets := m.Buckets
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	if route.SLO != nil && route.SLO.LatencyMillis > 0 {
+		bounds = append(bounds, float64(route.SLO.LatencyMillis)/1000)
+	}
+	sort.Float64s(bounds)
+	n := 0
+	for i, b := range bounds {
+		if i == 0 || b != bounds[n-1] {
+			bounds[n] = b
+			n++
+		}
+	}
+	bounds = append(bounds[:n], math.Inf(1))
+	return &methodMetrics{
+		bounds:    bounds,
+		counts:    make([]uint64, len(bounds)),
+		exemplars: make([]*exemplar, len(bounds)),
+		statuses:  make(map[int]uint64),
+	}
+}
+
+// ServeHTTP writes the metrics, in the OpenMetrics format if r accepts it.
+func (m *CallMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	open := strings.Contains(r.Header.Get("Accept"), OpenMetricsContentType)
+	if open {
+		w.Header().Set("Content-Type", OpenMetricsContentType+"; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	b := bufio.NewWriter(w)
+	m.write(b, open)
+	b.Flush()
+}
+
+func (m *CallMetrics) write(b *bufio.Writer, open bool) {
+	names := m.Metrics
+	if names.Duration == "" {
+		names.Duration = "http_request_duration_seconds"
+	}
+	if names.Requests == "" {
+		names.Requests = "http_requests_total"
+	}
+	if names.MethodLabel == "" {
+		names.MethodLabel = "method"
+	}
+	if names.StatusLabel == "" {
+		names.StatusLabel = "code"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	methods := make([]string, 0, len(m.methods))
+	for method := range m.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	fmt.Fprintf(b, "# TYPE %s histogram\n", names.Duration)
+	for _, method := range methods {
+		mm := m.methods[method]
+		label := names.MethodLabel + "=" + quoteLabel(method)
+		var count uint64
+		for i, bound := range mm.bounds {
+			count += mm.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s,le=%q} %d", names.Duration, label, formatBound(bound), count)
+			if e := mm.exemplars[i]; open && e != nil {
+				fmt.Fprintf(b, " # {trace_id=%q} %s %s", e.traceID, strconv.FormatFloat(e.value, 'g', -1, 64),
+					strconv.FormatFloat(float64(e.time.UnixNano())/1e9, 'f', 3, 64))
+			}
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", names.Duration, label, strconv.FormatFloat(mm.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", names.Duration, label, count)
+	}
+
+	family := names.Requests
+	if open {
+		// OpenMetrics names the family of a counter without _total
+		family = strings.TrimSuffix(family, "_total")
+	}
+	fmt.Fprintf(b, "# TYPE %s counter\n", family)
+	for _, method := range methods {
+		mm := m.methods[method]
+		statuses := make([]int, 0, len(mm.statuses))
+		for status := range mm.statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(b, "%s{%s=%s,%s=\"%d\"} %d\n", names.Requests, names.MethodLabel, quoteLabel(method), names.StatusLabel, status, mm.statuses[status])
+		}
+	}
+	if open {
+		b.WriteString("# EOF\n")
+	}
+}
+
+// formatBound formats the upper bound of a bucket as Prometheus clients do.
+func formatBound(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and
+// newlines only.
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
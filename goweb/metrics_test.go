@@ -0,0 +1,122 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSampledTraceID(t *testing.T) {
+	for v, want := range map[string]string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00": "",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": "",
+		"": "",
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Traceparent", v)
+		if got := SampledTraceID(r); got != want {
+			t.Errorf("SampledTraceID(%q) = %q", v, got)
+		}
+	}
+}
+
+func TestCallMetrics(t *testing.T) {
+	m := &CallMetrics{Buckets: []float64{0.1, 1}}
+	get := Route{Service: "pkg.Books", Method: "Get", SLO: &SLO{LatencyMillis: 200}}
+	list := Route{Service: "pkg.Books", Method: "List"}
+	at := time.Unix(1700000000, 0)
+	for _, u := range []*Usage{
+		{Route: get, Status: 200, Duration: 50 * time.Millisecond, Time: at},
+		{Route: get, Status: 200, Duration: 150 * time.Millisecond, Time: at, TraceID: "aa"},
+		{Route: get, Status: 500, Duration: 3 * time.Second, Time: at, TraceID: "bb"},
+		{Route: list, Status: 200, Duration: 500 * time.Millisecond, Time: at, TraceID: "cc"},
+	} {
+		m.Record(nil, u)
+	}
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	want := `# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{method="/pkg.Books/Get",le="0.1"} 1
+http_request_duration_seconds_bucket{method="/pkg.Books/Get",le="0.2"} 2 # {trace_id="aa"} 0.15 1700000000.150
+http_request_duration_seconds_bucket{method="/pkg.Books/Get",le="1"} 2
+http_request_duration_seconds_bucket{method="/pkg.Books/Get",le="+Inf"} 3 # {trace_id="bb"} 3 1700000003.000
+http_request_duration_seconds_sum{method="/pkg.Books/Get"} 3.2
+http_request_duration_seconds_count{method="/pkg.Books/Get"} 3
+http_request_duration_seconds_bucket{method="/pkg.Books/List",le="0.1"} 0
+http_request_duration_seconds_bucket{method="/pkg.Books/List",le="1"} 1 # {trace_id="cc"} 0.5 1700000000.500
+http_request_duration_seconds_bucket{method="/pkg.Books/List",le="+Inf"} 1
+http_request_duration_seconds_sum{method="/pkg.Books/List"} 0.5
+http_request_duration_seconds_count{method="/pkg.Books/List"} 1
+# TYPE http_requests counter
+http_requests_total{method="/pkg.Books/Get",code="200"} 2
+http_requests_total{method="/pkg.Books/Get",code="500"} 1
+http_requests_total{method="/pkg.Books/List",code="200"} 1
+# EOF
+`
+	if got := w.Body.String(); got != want {
+		t.Errorf("OpenMetrics =\n%s\nwant\n%s", got, want)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, OpenMetricsContentType) {
+		t.Errorf("Content-Type = %s", ct)
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if got := w.Body.String(); strings.Contains(got, "trace_id") || strings.Contains(got, "# EOF") || !strings.Contains(got, "# TYPE http_requests_total counter\n") {
+		t.Errorf("text format =\n%s", got)
+	}
+}
+
+func TestMeteredTraceID(t *testing.T) {
+	defer func(m Meter) { Metering = m }(Metering)
+	var got []*Usage
+	m := &CallMetrics{}
+	Metering = Meters(MeterFunc(func(ctx context.Context, u *Usage) { got = append(got, u) }), m)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w, r, done := MeterCall(httptest.NewRecorder(), r, Route{Service: "pkg.S", Method: "M"})
+	w.WriteHeader(http.StatusTeapot)
+	done()
+	if len(got) != 1 || got[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || got[0].Status != http.StatusTeapot || len(m.methods) != 1 {
+		t.Errorf("usage = %+v", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "net/http"
+
+// WithNamedMiddleware registers mw as the middleware name of the mux,
+// wrapping the routes of the methods whose middleware options list it,
+// e.g. (goweb.middleware) = "cache". Muxes fail to build, panicking, with
+// methods naming middlewares they were not given, so that no method is
+// served without the ones it declares.
+func WithNamedMiddleware(name string, mw func(http.Handler) http.Handler) ServerOption {
+	return func(o *ServerOptions) {
+		if o.NamedMiddleware == nil {
+			o.NamedMiddleware = map[string]func(http.Handler) http.Handler{}
+		}
+		o.NamedMiddleware[name] = mw
+	}
+}
+
+// MethodMiddleware returns the handler of the method of route wrapped in
+// its named middlewares, the first outermost, inside the Middleware of the
+// mux. It panics if one of names was not registered with
+// WithNamedMiddleware. Generated muxes call it for the methods with
+// middleware options.
+func (o ServerOptions) MethodMiddleware(route Route, names []string, h http.Handler) http.Handler {
+	for i := len(names) - 1; i >= 0; i-- {
+		mw, ok := o.NamedMiddleware[names[i]]
+		if !ok {
+			panic("goweb: no middleware " + names[i] + " for " + route.FullMethod() + ", see WithNamedMiddleware")
+		}
+		h = mw(h)
+	}
+	return h
+}
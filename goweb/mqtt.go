@@ -0,0 +1,82 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// An MQTTHandler handles a message published to a subscribed topic.
+// A non-nil reply should be published to the response topic of the
+// message, if it has one.
+type MQTTHandler func(ctx context.Context, topic string, payload []byte) (reply []byte, err error)
+
+// An MQTTClient subscribes to MQTT topic filters, for example by wrapping
+// a github.com/eclipse/paho.mqtt.golang client.
+type MQTTClient interface {
+	Subscribe(filter string, qos byte, h MQTTHandler) error
+}
+
+// ParseTopic parses an MQTT topic template such as
+// "devices/{device_id}/telemetry". It returns the topic filter to subscribe
+// to, "devices/+/telemetry", and the level index of each variable.
+func ParseTopic(template string) (filter string, vars map[string]int, err error) {
+	levels := strings.Split(template, "/")
+	vars = make(map[string]int)
+	for i, l := range levels {
+		if !strings.HasPrefix(l, "{") {
+			if strings.ContainsAny(l, "{}+#") {
+				return "", nil, fmt.Errorf("goweb: bad level %q in topic template %q", l, template)
+			}
+			continue
+		}
+		if !strings.HasSuffix(l, "}") || len(l) < 3 {
+			return "", nil, fmt.Errorf("goweb: bad variable %q in topic template %q", l, template)
+		}
+		vars[l[1:len(l)-1]] = i
+		levels[i] = "+"
+	}
+	return strings.Join(levels, "/"), vars, nil
+}
+
+// TopicLevel returns level i of topic below prefix, or "" if it has fewer
+// levels.
+func TopicLevel(topic, prefix string, i int) string {
+	levels := strings.Split(strings.TrimPrefix(topic, prefix), "/")
+	if i < len(levels) {
+		return levels[i]
+	}
+	return ""
+}
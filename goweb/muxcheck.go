@@ -0,0 +1,166 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MuxRequirements are what the methods of a generated mux need besides
+// the implementation, which they would otherwise only miss when they are
+// called, failing with 500 errors. The generated Validate<Service>Mux
+// functions check those of their services with ServerOptions.Check.
+type MuxRequirements struct {
+	Middleware   []string // the middleware options, see WithNamedMiddleware
+	Enrichers    []string // the enrich options, see RegisterEnricher
+	Transformers []string // the transform options, see RegisterTransformer
+	Policies     bool     // policy options need Policies
+	Sessions     bool     // session options and the oidc parameter need Sessions
+	Audit        bool     // audit options need Audit
+	FieldCrypter bool     // encrypted fields need FieldCrypter
+	OIDC         bool     // the oidc parameter needs OIDC
+	AdminAuth    bool     // the admin parameter needs AdminAuth
+}
+
+// MuxErrors are the problems found by ServerOptions.Check and CheckMounts,
+// all of them.
+type MuxErrors []error
+
+func (e MuxErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "goweb: " + strings.Join(msgs, "; ")
+}
+
+// Check returns the MuxErrors of serving a mux with the requirements req
+// below prefix with the options o, or nil: a prefix other than "" not
+// starting with a slash or with a query, middlewares not given with
+// WithNamedMiddleware, enrichers and transformers not registered, and
+// Policies, Sessions, Audit, FieldCrypter, OIDC and AdminAuth left unset.
+// Call it at startup, after setting them, rather than finding out at
+// request time.
+func (o ServerOptions) Check(prefix string, req MuxRequirements) error {
+	var errs MuxErrors
+	if prefix != "" && !strings.HasPrefix(prefix, "/") || strings.ContainsAny(prefix, "?#{} ") {
+		errs = append(errs, fmt.Errorf("prefix %q is not a path", prefix))
+	}
+	for _, name := range dedup(req.Middleware) {
+		if _, ok := o.NamedMiddleware[name]; !ok {
+			errs = append(errs, fmt.Errorf("no middleware %s, see WithNamedMiddleware", name))
+		}
+	}
+	for _, name := range dedup(req.Enrichers) {
+		enrichers.RLock()
+		_, ok := enrichers.m[name]
+		enrichers.RUnlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("no enricher %s, see RegisterEnricher", name))
+		}
+	}
+	for _, name := range dedup(req.Transformers) {
+		transformers.RLock()
+		_, ok := transformers.m[name]
+		transformers.RUnlock()
+		if !ok {
+			errs = append(errs, fmt.Errorf("no transformer %s, see RegisterTransformer", name))
+		}
+	}
+	for _, global := range []struct {
+		name          string
+		needed, unset bool
+	}{
+		{"Policies", req.Policies, Policies == nil},
+		{"Sessions", req.Sessions, Sessions == nil},
+		{"Audit", req.Audit, Audit == nil},
+		{"FieldCrypter", req.FieldCrypter, FieldCrypter == nil},
+		{"OIDC", req.OIDC, OIDC == nil},
+		{"AdminAuth", req.AdminAuth, AdminAuth == nil},
+	} {
+		if global.needed && global.unset {
+			errs = append(errs, fmt.Errorf("goweb.%s is not set", global.name))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CheckMounts returns the MuxErrors of serving the muxes with the routes
+// of each prefix side by side, e.g. under one http.ServeMux, or nil: the
+// routes of different muxes with the same verb and path, of which only
+// one would be served, e.g.
+//
+//	goweb.CheckMounts(map[string][]goweb.Route{"/": pb.UsersRoutes(), "/v2": pb.UsersV2Routes()})
+func CheckMounts(mounts map[string][]Route) error {
+	var prefixes []string
+	for prefix := range mounts {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	var errs MuxErrors
+	seen := map[string]string{}
+	for _, prefix := range prefixes {
+		for _, r := range mounts[prefix] {
+			verb := r.Verb
+			if verb == "" {
+				verb = "POST"
+			}
+			key := verb + " " + JoinPath(prefix, r.Path)
+			if other, ok := seen[key]; ok && other != r.FullMethod() {
+				errs = append(errs, fmt.Errorf("%s is both %s and %s", key, other, r.FullMethod()))
+				continue
+			}
+			seen[key] = r.FullMethod()
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func dedup(names []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+func TestCheck(t *testing.T) {
+	RegisterEnricher("check-caller", func(ctx context.Context, in proto.Message) error { return nil })
+	defer func(a *AuditLog) { Audit = a }(Audit)
+	Audit = nil
+	o := NewServerOptions(WithNamedMiddleware("auth", func(h http.Handler) http.Handler { return h }))
+	req := MuxRequirements{
+		Middleware: []string{"auth", "cache", "cache"},
+		Enrichers:  []string{"check-caller", "check-missing"},
+		Audit:      true,
+	}
+	err := o.Check("api", req)
+	errs, ok := err.(MuxErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("Check = %v", err)
+	}
+	want := `goweb: prefix "api" is not a path; no middleware cache, see WithNamedMiddleware; ` +
+		`no enricher check-missing, see RegisterEnricher; goweb.Audit is not set`
+	if err.Error() != want {
+		t.Errorf("Check = %v", err)
+	}
+	if err := o.Check("/api/", MuxRequirements{Middleware: []string{"auth"}, Enrichers: []string{"check-caller"}}); err != nil {
+		t.Errorf("Check of a complete mux = %v", err)
+	}
+	if err := o.Check("", MuxRequirements{}); err != nil {
+		t.Errorf("Check without requirements = %v", err)
+	}
+}
+
+func TestCheckMounts(t *testing.T) {
+	users := []Route{{Service: "pkg.Users", Method: "Get", Verb: "GET", Path: "v1/users/{id}"}, {Service: "pkg.Users", Method: "Put", Path: "pkg.Users/Put"}}
+	admin := []Route{{Service: "pkg.Admin", Method: "Get", Verb: "GET", Path: "users/{id}"}, {Service: "pkg.Admin", Method: "Put", Path: "pkg.Users/Put"}}
+	if err := CheckMounts(map[string][]Route{"/": users, "/v1": admin}); err == nil || err.Error() != "goweb: GET /v1/users/{id} is both /pkg.Users/Get and /pkg.Admin/Get" {
+		t.Errorf("CheckMounts = %v", err)
+	}
+	if err := CheckMounts(map[string][]Route{"/": users, "/admin": admin}); err != nil {
+		t.Errorf("CheckMounts of separate muxes = %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var normalizers = struct {
+	sync.RWMutex
+	m map[string]func(string) string
+}{m: map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"email": func(s string) string { return strings.ToLower(strings.TrimSpace(s)) },
+	"phone": canonicalPhone,
+}}
+
+// RegisterNormalizer makes the normalizer f available to the goweb.normalize
+// field option under name, replacing any normalizer of that name. The
+// built-in normalizers are "trim", "lower", "upper", "email" (trim and
+// lower) and "phone", which keeps a leading + and the digits only; register
+// a "phone" normalizer backed by a phone number library for real E.164
+// canonicalization.
+func RegisterNormalizer(name string, f func(string) string) {
+	normalizers.Lock()
+	defer normalizers.Unlock()
+	normalizers.m[name] = f
+}
+
+// Normalize applies the named normalizers to s, in order. Unknown
+// normalizers are logged and skipped.
+func Normalize(s string, names ...string) string {
+	normalizers.RLock()
+	defer normalizers.RUnlock()
+	for _, name := range names {
+		f, ok := normalizers.m[name]
+		if !ok {
+			log.Printf("goweb: unknown normalizer %q", name)
+			continue
+		}
+		s = f(s)
+	}
+	return s
+}
+
+func canonicalPhone(s string) string {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsDigit(r) || r == '+' && i == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
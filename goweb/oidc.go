@@ -0,0 +1,478 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// An OIDCProvider runs the OpenID Connect authorization code flow (with
+// PKCE) against an identity provider, for the login, callback and
+// logout endpoints that the oidc parameter adds to the generated muxes.
+// A login ends in a session of goweb.Sessions whose principal is the
+// map of the claims of the ID token.
+type OIDCProvider struct {
+	Issuer       string // e.g. "https://accounts.example.com"
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // the URL of the callback endpoint
+	Scopes       []string // "openid", "profile" and "email" if empty
+
+	// AfterLogin and AfterLogout are where the browser goes after a
+	// login without a return_to parameter and after a logout; "/" if
+	// empty. Logouts go to the end session endpoint of the provider
+	// first, if it has one.
+	AfterLogin  string
+	AfterLogout string
+
+	Client *http.Client // http.DefaultClient if nil
+
+	mu     sync.Mutex
+	config *oidcConfig
+	keys   map[string]crypto.PublicKey
+}
+
+// OIDC is the provider of the endpoints added by the oidc parameter;
+// while it is nil, they fail.
+var OIDC *OIDCProvider
+
+type oidcConfig struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// oidcFlow is the state of a login between its redirect and callback,
+// kept in a cookie.
+type oidcFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to,omitempty"`
+}
+
+func (p *OIDCProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := p.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("goweb: oidc: GET %s: %s", url, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// discover returns the configuration of the provider, fetched once.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.config != nil {
+		return p.config, nil
+	}
+	c := &oidcConfig{}
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", c); err != nil {
+		return nil, err
+	}
+	if c.Issuer != p.Issuer {
+		return nil, fmt.Errorf("goweb: oidc: issuer %q does not match %q", c.Issuer, p.Issuer)
+	}
+	p.config = c
+	return c, nil
+}
+
+// key returns the signing key kid of the provider, refetching its keys
+// once if it is unknown, e.g. after a key rotation.
+func (p *OIDCProvider) key(ctx context.Context, c *oidcConfig, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, c.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		switch {
+		case jwk.Kty == "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err1 == nil && err2 == nil {
+				keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			}
+		case jwk.Kty == "EC" && jwk.Crv == "P-256":
+			x, err1 := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if err1 == nil && err2 == nil {
+				keys[jwk.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			}
+		}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("goweb: oidc: unknown key %q", kid)
+}
+
+// verify checks the signature (RS256 or ES256) and the claims of the ID
+// token raw and returns its claims.
+func (p *OIDCProvider) verify(ctx context.Context, c *oidcConfig, raw, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("goweb: oidc: malformed id token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.key(ctx, c, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	valid := false
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		valid = header.Alg == "RS256" && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		valid = header.Alg == "ES256" && len(sig) == 64 &&
+			ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	}
+	if !valid {
+		return nil, errors.New("goweb: oidc: invalid id token signature")
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	exp, _ := claims["exp"].(float64)
+	switch {
+	case claims["iss"] != c.Issuer:
+		return nil, fmt.Errorf("goweb: oidc: id token of issuer %v", claims["iss"])
+	case !audienceHas(claims["aud"], p.ClientID):
+		return nil, fmt.Errorf("goweb: oidc: id token for audience %v", claims["aud"])
+	case time.Now().After(time.Unix(int64(exp), 0)):
+		return nil, errors.New("goweb: oidc: id token expired")
+	case claims["nonce"] != nonce:
+		return nil, errors.New("goweb: oidc: id token nonce mismatch")
+	}
+	return claims, nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func audienceHas(aud interface{}, id string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == id
+	case []interface{}:
+		for _, a := range aud {
+			if a == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *OIDCProvider) flowCookie(value string, maxAge int) *http.Cookie {
+	secure := Sessions == nil || !Sessions.Insecure
+	name := "__Host-oidc"
+	if !secure {
+		name = "oidc"
+	}
+	return &http.Cookie{Name: name, Value: value, Path: "/", MaxAge: maxAge, Secure: secure, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+}
+
+// localPath returns path if it is a path on this site, or else "".
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+	return path
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// Login redirects the browser to the provider to log in; the path in the
+// return_to parameter, if any, is where the callback sends it after.
+func (p *OIDCProvider) Login(w http.ResponseWriter, r *http.Request) {
+	c, err := p.discover(r.Context())
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	var flow oidcFlow
+	for _, s := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *s, err = newSessionID(); err != nil {
+			WriteRequestError(w, r, err)
+			return
+		}
+	}
+	flow.ReturnTo = localPath(r.URL.Query().Get("return_to"))
+	b, _ := json.Marshal(flow)
+	http.SetCookie(w, p.flowCookie(base64.RawURLEncoding.EncodeToString(b), 600))
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(c.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, c.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+}
+
+// Callback completes a login: it exchanges the code for the ID token,
+// verifies it, issues a session with its claims and redirects the
+// browser back.
+func (p *OIDCProvider) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fail := func(err error) {
+		WriteRequestError(w, r, &Error{Status: 401, Code: "UNAUTHENTICATED", Message: err.Error()})
+	}
+	cookie, err := r.Cookie(p.flowCookie("", 0).Name)
+	if err != nil {
+		fail(errors.New("no login in progress"))
+		return
+	}
+	http.SetCookie(w, p.flowCookie("", -1))
+	var flow oidcFlow
+	if err := decodeSegment(cookie.Value, &flow); err != nil || flow.State == "" || r.URL.Query().Get("state") != flow.State {
+		fail(errors.New("state mismatch"))
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		fail(fmt.Errorf("login failed: %s", e))
+		return
+	}
+	c, err := p.discover(ctx)
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {r.URL.Query().Get("code")},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {flow.Verifier},
+	}
+	req, err := http.NewRequest("POST", c.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+	res, err := p.client().Do(req.WithContext(ctx))
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	defer res.Body.Close()
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if res.StatusCode != 200 || json.NewDecoder(res.Body).Decode(&token) != nil || token.IDToken == "" {
+		fail(fmt.Errorf("token exchange failed: %s", res.Status))
+		return
+	}
+	claims, err := p.verify(ctx, c, token.IDToken, flow.Nonce)
+	if err != nil {
+		fail(err)
+		return
+	}
+	m, err := sessions()
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	if _, err := m.Issue(ctx, w, claims); err != nil {
+		WriteRequestError(w, r, err)
+		return
+	}
+	to := flow.ReturnTo
+	if to == "" {
+		to = orRoot(p.AfterLogin)
+	}
+	http.Redirect(w, r, to, http.StatusFound)
+}
+
+// Logout ends the session of the browser and redirects it to the end
+// session endpoint of the provider, if any, or else to AfterLogout. It
+// only takes POST requests from pages of the same origin, so that other
+// sites cannot log users out (see sameOrigin).
+func (p *OIDCProvider) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		WriteRequestError(w, r, Errorf(405, "INVALID_ARGUMENT", "logout takes a POST request"))
+		return
+	}
+	if !sameOrigin(r) {
+		WriteRequestError(w, r, Errorf(403, "PERMISSION_DENIED", "logout from another site"))
+		return
+	}
+	if m, err := sessions(); err == nil {
+		if err := m.Revoke(r.Context(), w, r); err != nil {
+			WriteRequestError(w, r, err)
+			return
+		}
+	}
+	to := orRoot(p.AfterLogout)
+	if c, err := p.discover(r.Context()); err == nil && c.EndSessionEndpoint != "" {
+		q := url.Values{"client_id": {p.ClientID}}
+		if strings.Contains(to, "://") {
+			q.Set("post_logout_redirect_uri", to)
+		}
+		to = c.EndSessionEndpoint + "?" + q.Encode()
+	}
+	http.Redirect(w, r, to, http.StatusSeeOther)
+}
+
+// sameOrigin reports whether the request r comes from a page of its own
+// origin, after its Origin header or else its Sec-Fetch-Site or Referer
+// header. Requests with none of them are accepted: the browsers that send
+// none do not send SameSite=Lax session cookies with cross-site POSTs.
+func sameOrigin(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == r.Host
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	if referer := r.Header.Get("Referer"); referer != "" {
+		u, err := url.Parse(referer)
+		return err == nil && u.Host == r.Host
+	}
+	return true
+}
+
+// OIDCHandler returns the handler of the endpoint of OIDC for action,
+// "login", "callback" or "logout"; the generated muxes serve them under
+// _auth/ with the oidc parameter.
+func OIDCHandler(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := OIDC
+		if p == nil {
+			WriteRequestError(w, r, Errorf(500, "INTERNAL", "goweb.OIDC is not set"))
+			return
+		}
+		switch action {
+		case "login":
+			p.Login(w, r)
+		case "callback":
+			p.Callback(w, r)
+		case "logout":
+			p.Logout(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
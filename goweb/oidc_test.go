@@ -0,0 +1,177 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIdP is an identity provider issuing RS256 ID tokens for the code
+// "good".
+func fakeIdP(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	var srv *httptest.Server
+	var nonce, challenge string
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer": srv.URL, "authorization_endpoint": srv.URL + "/authorize", "token_endpoint": srv.URL + "/token",
+				"jwks_uri": srv.URL + "/jwks", "end_session_endpoint": srv.URL + "/logout",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": "k1", "kty": "RSA",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		case "/authorize":
+			nonce, challenge = r.URL.Query().Get("nonce"), r.URL.Query().Get("code_challenge")
+		case "/token":
+			r.ParseForm()
+			verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if id, secret, _ := r.BasicAuth(); r.Form.Get("code") != "good" || id != "app" || secret != "s3cret" ||
+				base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+				w.WriteHeader(400)
+				return
+			}
+			seg := func(v interface{}) string { b, _ := json.Marshal(v); return base64.RawURLEncoding.EncodeToString(b) }
+			signed := seg(map[string]string{"alg": "RS256", "kid": "k1"}) + "." + seg(map[string]interface{}{
+				"iss": srv.URL, "aud": "app", "sub": "jane", "nonce": nonce, "exp": time.Now().Add(time.Hour).Unix(),
+			})
+			digest := sha256.Sum256([]byte(signed))
+			sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			json.NewEncoder(w).Encode(map[string]string{"id_token": signed + "." + base64.RawURLEncoding.EncodeToString(sig)})
+		}
+	}))
+	return srv
+}
+
+func TestOIDC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := fakeIdP(t, key)
+	defer idp.Close()
+	defer func(o *OIDCProvider, s *SessionManager) { OIDC, Sessions = o, s }(OIDC, Sessions)
+	Sessions = &SessionManager{Store: NewMemorySessionStore()}
+	OIDC = &OIDCProvider{Issuer: idp.URL, ClientID: "app", ClientSecret: "s3cret", RedirectURL: "https://app.example.com/_auth/callback"}
+
+	// login redirects to the provider
+	w := httptest.NewRecorder()
+	OIDCHandler("login")(w, httptest.NewRequest("GET", "/_auth/login?return_to=/home", nil))
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	if w.Code != 302 || !strings.HasPrefix(loc.String(), idp.URL+"/authorize?") || loc.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("login: %d %s", w.Code, loc)
+	}
+	http.Get(loc.String()) // the browser visits the provider
+	flow := w.Result().Cookies()
+
+	callback := func(query string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/_auth/callback?"+query, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		OIDCHandler("callback")(w, r)
+		return w
+	}
+	state := loc.Query().Get("state")
+	if w := callback("code=good&state=forged", flow); w.Code != 401 {
+		t.Errorf("forged state: %d", w.Code)
+	}
+	if w := callback("code=good&state="+state, nil); w.Code != 401 {
+		t.Errorf("without flow cookie: %d", w.Code)
+	}
+	if w := callback("code=bad&state="+state, flow); w.Code != 401 {
+		t.Errorf("bad code: %d", w.Code)
+	}
+	w = callback("code=good&state="+state, flow)
+	if w.Code != 302 || w.Header().Get("Location") != "/home" {
+		t.Fatalf("callback: %d %s %s", w.Code, w.Header().Get("Location"), w.Body)
+	}
+	var session []*http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "__Host-session" {
+			session = append(session, c)
+		}
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(session[0])
+	ctx, err := RequireSession(r.Context(), httptest.NewRecorder(), r)
+	if err != nil || PrincipalFrom(ctx).(map[string]interface{})["sub"] != "jane" {
+		t.Fatalf("session after login: %v", err)
+	}
+
+	// logout takes a POST of the same site
+	for _, logout := range []*http.Request{httptest.NewRequest("GET", "/_auth/logout", nil), httptest.NewRequest("POST", "/_auth/logout", nil)} {
+		logout.AddCookie(session[0])
+		logout.Header.Set("Origin", "https://evil.example.com")
+		w = httptest.NewRecorder()
+		OIDCHandler("logout")(w, logout)
+		if w.Code != 405 && w.Code != 403 {
+			t.Errorf("%s logout from another site: %d", logout.Method, w.Code)
+		}
+	}
+	if _, err := RequireSession(r.Context(), httptest.NewRecorder(), r); err != nil {
+		t.Fatalf("session ended by another site: %v", err)
+	}
+
+	// logout ends the session at the provider too
+	w = httptest.NewRecorder()
+	logout := httptest.NewRequest("POST", "/_auth/logout", nil)
+	logout.AddCookie(session[0])
+	logout.Header.Set("Origin", "http://"+logout.Host)
+	OIDCHandler("logout")(w, logout)
+	if w.Code != 303 || !strings.HasPrefix(w.Header().Get("Location"), idp.URL+"/logout?") {
+		t.Errorf("logout: %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if _, err := RequireSession(r.Context(), httptest.NewRecorder(), r); err == nil {
+		t.Errorf("session valid after logout")
+	}
+
+	if localPath("//evil.example.com") != "" || localPath("https://evil.example.com") != "" || localPath("/ok") != "/ok" {
+		t.Errorf("localPath accepts other sites")
+	}
+}
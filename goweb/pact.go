@@ -0,0 +1,229 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PactSpecification is the version of the Pact specification of the
+// contracts of PactContract.
+const PactSpecification = "2.0.0"
+
+// A PactInteraction is an expected call of a method in a consumer-driven
+// contract, see PactContract.
+type PactInteraction struct {
+	// Description names the interaction, "<Method> succeeds" or "<Method>
+	// fails with <code>" if empty.
+	Description string
+
+	// ProviderState is the state the provider must be in, if any.
+	ProviderState string
+
+	Route    Route
+	Request  interface{} // the request message
+	Response interface{} // the response message, unless Error is set
+	Error    *Error      // the error of the call, if it fails
+}
+
+// pact is the JSON of a contract in the version PactSpecification.
+type pact struct {
+	Consumer     pactParty         `json:"consumer"`
+	Provider     pactParty         `json:"provider"`
+	Interactions []pactInteraction `json:"interactions"`
+	Metadata     struct {
+		PactSpecification struct {
+			Version string `json:"version"`
+		} `json:"pactSpecification"`
+	} `json:"metadata"`
+}
+
+type pactParty struct {
+	Name string `json:"name"`
+}
+
+type pactInteraction struct {
+	Description   string       `json:"description"`
+	ProviderState string       `json:"providerState,omitempty"`
+	Request       pactRequest  `json:"request"`
+	Response      pactResponse `json:"response"`
+}
+
+type pactRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+type pactResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// PactContract returns the Pact contract (https://pact.io) between the
+// consumer and the provider with the interactions, as JSON, so that
+// consumers verify their clients against a mock of the provider in CI,
+// e.g. with the interactions generated with the pact parameter. The
+// requests are sent like the ones of an Upstream, with the path variables
+// in the path and the other fields outside of the body in the query, and
+// the responses like the ones of the generated handlers.
+func PactContract(consumer, provider string, interactions ...PactInteraction) ([]byte, error) {
+	p := pact{Consumer: pactParty{consumer}, Provider: pactParty{provider}, Interactions: []pactInteraction{}}
+	p.Metadata.PactSpecification.Version = PactSpecification
+	for _, in := range interactions {
+		i, err := in.pact()
+		if err != nil {
+			return nil, err
+		}
+		p.Interactions = append(p.Interactions, i)
+	}
+	return json.MarshalIndent(p, "", "  ")
+}
+
+func (in PactInteraction) pact() (pactInteraction, error) {
+	route := in.Route
+	verb, path, query, body, err := exampleRequest(route, in.Request)
+	if err != nil {
+		return pactInteraction{}, err
+	}
+	i := pactInteraction{
+		Description:   in.Description,
+		ProviderState: in.ProviderState,
+		Request:       pactRequest{Method: verb, Path: path, Query: query},
+		Response:      pactResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}},
+	}
+	if body != nil {
+		i.Request.Headers = map[string]string{"Content-Type": "application/json"}
+		i.Request.Body = body
+	}
+	if in.Error != nil {
+		i.Response.Status = ErrorStatusOf(in.Error)
+		if i.Response.Body, err = json.Marshal(in.Error); err != nil {
+			return pactInteraction{}, err
+		}
+		if i.Description == "" {
+			i.Description = route.Method + " fails with " + in.Error.Code
+		}
+		return i, nil
+	}
+	if i.Response.Body, err = MarshalJSON(in.Response); err != nil {
+		return pactInteraction{}, fmt.Errorf("goweb: response of %s: %v", route.FullMethod(), err)
+	}
+	if i.Description == "" {
+		i.Description = route.Method + " succeeds"
+	}
+	return i, nil
+}
+
+// exampleRequest returns the request of route with the message in, as
+// an Upstream sends it, for contracts and collections: the verb, the path
+// (with samplePath), the query and the JSON body, nil if there is none.
+func exampleRequest(route Route, in interface{}) (verb, path, query string, body []byte, err error) {
+	if body, err = MarshalJSON(in); err != nil {
+		return "", "", "", nil, fmt.Errorf("goweb: request of %s: %v", route.FullMethod(), err)
+	}
+	if route.Verb == "" {
+		return "POST", "/" + route.Path, "", body, nil
+	}
+	if body, err = sampleVars(route, body); err != nil {
+		return "", "", "", nil, err
+	}
+	target, body, err := templateRequest(route, "/"+route.Path, body)
+	if err != nil {
+		return "", "", "", nil, err
+	}
+	path = target
+	if n := strings.IndexByte(target, '?'); n >= 0 {
+		path, query = target[:n], target[n+1:]
+	}
+	path, err = samplePath(route, path)
+	return route.Verb, path, query, body, err
+}
+
+// sampleVars returns the JSON request body with the sample values of
+// samplePath for the path variables of route it has no value for, such as
+// those of empty requests.
+func sampleVars(route Route, body []byte) ([]byte, error) {
+	t, err := route.Template()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := decodeTree(body)
+	obj, ok := tree.(map[string]interface{})
+	if err != nil || !ok {
+		return body, nil
+	}
+	missing := false
+	for field, value := range t.samples() {
+		if lookupTree(obj, field) != nil {
+			continue
+		}
+		names := strings.Split(field, ".")
+		m := obj
+		for _, name := range names[:len(names)-1] {
+			next, ok := m[name].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[name] = next
+			}
+			m = next
+		}
+		m[names[len(names)-1]] = value
+		missing = true
+	}
+	if !missing {
+		return body, nil
+	}
+	return json.Marshal(obj)
+}
+
+// samplePath returns path if it matches the template of route, and else
+// the path with sample values matching the template for all its
+// variables, e.g. "/v1/shelves/1/books/1" for
+// "/v1/{name=shelves/*/books/*}", since example requests rarely have valid
+// resource names.
+func samplePath(route Route, path string) (string, error) {
+	t, err := route.Template()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := t.Match(strings.TrimPrefix(path, "/")); ok {
+		return path, nil
+	}
+	path, err = t.Expand(t.samples())
+	return "/" + path, err
+}
@@ -0,0 +1,94 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestPactContract(t *testing.T) {
+	b, err := PactContract("web", "captures",
+		PactInteraction{
+			Route:    Route{Service: "goweb.Captures", Method: "Put", Path: "goweb.Captures/Put"},
+			Request:  &CapturedCall{Method: "a"},
+			Response: &CapturedCall{Path: "p"},
+		},
+		PactInteraction{
+			ProviderState: "call x exists",
+			Route:         Route{Service: "goweb.Captures", Method: "Get", Verb: "GET", Path: "v1/{method=calls/*}"},
+			Request:       &CapturedCall{Method: "x", Path: "p"},
+			Response:      &CapturedCall{},
+		},
+		PactInteraction{
+			Route:   Route{Service: "goweb.Captures", Method: "Get", Verb: "GET", Path: "v1/{method=calls/*}"},
+			Request: &CapturedCall{Method: "calls/y"},
+			Error:   &Error{Code: "NOT_FOUND", Message: "no call y"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p struct {
+		pact
+		Interactions []json.RawMessage `json:"interactions"`
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Consumer.Name != "web" || p.Provider.Name != "captures" || p.Metadata.PactSpecification.Version != "2.0.0" || len(p.Interactions) != 3 {
+		t.Fatalf("contract = %s", b)
+	}
+	compact := func(b []byte) string {
+		var buf bytes.Buffer
+		json.Compact(&buf, b)
+		return buf.String()
+	}
+	for i, want := range []struct {
+		desc, state, method, path, query, body string
+		status                                 int
+		response                               string
+	}{
+		{"Put succeeds", "", "POST", "/goweb.Captures/Put", "", `{"method":"a"}`, 200, `{"path":"p"}`},
+		{"Get succeeds", "call x exists", "GET", "/v1/calls/1", "path=p", "", 200, `{}`},
+		{"Get fails with NOT_FOUND", "", "GET", "/v1/calls/y", "", "", 404, `{"code":"NOT_FOUND","message":"no call y"}`},
+	} {
+		var got pactInteraction
+		json.Unmarshal(p.Interactions[i], &got)
+		if got.Description != want.desc || got.ProviderState != want.state || got.Request.Method != want.method ||
+			got.Request.Path != want.path || got.Request.Query != want.query || compact(got.Request.Body) != want.body ||
+			got.Response.Status != want.status || compact(got.Response.Body) != want.response {
+			t.Errorf("interaction %d = %+v", i, got)
+		}
+	}
+}
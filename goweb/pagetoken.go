@@ -0,0 +1,157 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"reflect"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// pageScopeFields are the fields of list requests that change from page
+// to page, and so are not part of the scope of their page tokens.
+var pageScopeFields = []string{"page_token", "page_size", "skip"}
+
+// PageTokens encodes and decodes the opaque page tokens of list methods
+// (AIP-158): a token holds a cursor, a message of the implementation with
+// what it needs to continue the list, such as the sort keys of the last
+// item of a page, in the binary wire format, and an HMAC-SHA256 over it,
+// the method and the other fields of the request, such as its filter and
+// order, so that clients can neither forge nor alter cursors, nor continue
+// a list with other filters. The generated Encode<Service><Method>PageToken
+// and Decode<Service><Method>PageToken functions of the list methods of a
+// service call them.
+type PageTokens struct {
+	// Key is the HMAC key; it must be the same for encoding and decoding.
+	Key []byte
+
+	// TTL, if positive, is the time tokens are valid for.
+	TTL time.Duration
+
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+}
+
+func (p *PageTokens) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// Encode returns the token of the page after cursor of the list of route
+// requested with in.
+func (p *PageTokens) Encode(route Route, in, cursor proto.Message) (string, error) {
+	scope, err := pageScope(route, in)
+	if err != nil {
+		return "", err
+	}
+	b, err := proto.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	var expires int64
+	if p.TTL > 0 {
+		expires = p.now().Add(p.TTL).Unix()
+	}
+	token := make([]byte, 8, 8+len(b)+sha256.Size)
+	binary.BigEndian.PutUint64(token, uint64(expires))
+	token = append(token, b...)
+	token = append(token, p.mac(scope, token)...)
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Decode decodes the page token of the list of route requested with in
+// into cursor, which it resets for the first page, with an empty token.
+// Tokens that were not encoded by Encode with the key of p for the same
+// method and fields of the request, or have expired, fail with a 400
+// INVALID_ARGUMENT *Error, which implementations can return as it is.
+func (p *PageTokens) Decode(route Route, in proto.Message, token string, cursor proto.Message) error {
+	cursor.Reset()
+	if token == "" {
+		return nil
+	}
+	scope, err := pageScope(route, in)
+	if err != nil {
+		return err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < 8+sha256.Size {
+		return badPageToken()
+	}
+	data, mac := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	if !hmac.Equal(mac, p.mac(scope, data)) {
+		return badPageToken()
+	}
+	if expires := int64(binary.BigEndian.Uint64(data)); expires != 0 && p.now().Unix() > expires {
+		return Errorf(400, "INVALID_ARGUMENT", "expired page token")
+	}
+	if err := proto.Unmarshal(data[8:], cursor); err != nil {
+		return badPageToken()
+	}
+	return nil
+}
+
+func (p *PageTokens) mac(scope string, data []byte) []byte {
+	h := hmac.New(sha256.New, p.Key)
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func badPageToken() error {
+	return Errorf(400, "INVALID_ARGUMENT", "invalid page token")
+}
+
+// pageScope returns the scope of the page tokens of the list of route
+// requested with in: the method and the CanonicalHash of the request
+// without its pageScopeFields.
+func pageScope(route Route, in proto.Message) (string, error) {
+	in = proto.Clone(in)
+	v := reflect.ValueOf(in).Elem()
+	for _, name := range pageScopeFields {
+		if i, ok := fieldByName(v.Type(), name); ok {
+			v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+		}
+	}
+	hash, err := CanonicalHash(in)
+	if err != nil {
+		return "", err
+	}
+	return route.FullMethod() + "\n" + hash, nil
+}
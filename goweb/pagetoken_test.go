@@ -0,0 +1,80 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestPageTokens(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := &PageTokens{Key: []byte("k"), TTL: time.Minute, Now: func() time.Time { return now }}
+	route := Route{Service: "pkg.Books", Method: "List"}
+	in := &pb.FieldDescriptorProto{Name: proto.String("filter")}
+	token, err := p.Encode(route, in, &pb.FieldDescriptorProto{Number: proto.Int32(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor := &pb.FieldDescriptorProto{Name: proto.String("stale")}
+	if err := p.Decode(route, in, token, cursor); err != nil || cursor.GetNumber() != 5 || cursor.Name != nil {
+		t.Errorf("Decode = %v, %v", cursor, err)
+	}
+	if err := p.Decode(route, in, "", cursor); err != nil || cursor.Number != nil {
+		t.Errorf("Decode of the first page = %v, %v", cursor, err)
+	}
+
+	tampered := []byte(token)
+	tampered[10] ^= 1
+	other := *p
+	other.Key = []byte("other")
+	for name, decode := range map[string]func() error{
+		"tampered":     func() error { return p.Decode(route, in, string(tampered), cursor) },
+		"garbage":      func() error { return p.Decode(route, in, "!!", cursor) },
+		"short":        func() error { return p.Decode(route, in, token[:20], cursor) },
+		"other key":    func() error { return other.Decode(route, in, token, cursor) },
+		"other method": func() error { return p.Decode(Route{Service: "pkg.Books", Method: "Search"}, in, token, cursor) },
+		"other filter": func() error { return p.Decode(route, &pb.FieldDescriptorProto{Name: proto.String("x")}, token, cursor) },
+		"expired": func() error {
+			defer func(t time.Time) { now = t }(now)
+			now = now.Add(2 * time.Minute)
+			return p.Decode(route, in, token, cursor)
+		},
+	} {
+		if e, ok := AsError(decode()); !ok || e.Status != 400 || e.Code != "INVALID_ARGUMENT" {
+			t.Errorf("%s token: %v", name, e)
+		}
+	}
+}
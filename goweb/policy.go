@@ -0,0 +1,150 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// An Authorizer decides whether the caller in ctx may call method (e.g.
+// "/pkg.Users/Get") with the request in. Generated handlers authorize
+// the calls of methods with an authorize option with their *Rule, and
+// of methods with a policy option with Policy(path).
+type Authorizer interface {
+	Authorize(ctx context.Context, method string, in proto.Message) (bool, error)
+}
+
+// Authorize returns nil if a allows the call of method with the request
+// in, or else a PERMISSION_DENIED *Error. Calls that a fails to decide,
+// e.g. because the caller has no claims, are denied; the errors are
+// logged.
+func Authorize(ctx context.Context, a Authorizer, method string, in proto.Message) error {
+	ok, err := a.Authorize(ctx, method, in)
+	if err != nil {
+		log.Printf("goweb: authorizing %s with %v: %v", method, a, err)
+	}
+	if !ok {
+		return Errorf(403, "PERMISSION_DENIED", "permission denied")
+	}
+	return nil
+}
+
+// A PolicyEngine decides calls by the policy at path, e.g.
+// "httpapi/authz/allow", for the input document
+//
+//	{"method": "/pkg.Users/Get", "request": {...}, "principal": {...}}
+//
+// with the request by the proto names of its fields and the principal
+// of the call (see WithPrincipal) as JSON. OPAServer queries an Open
+// Policy Agent; embedding OPA takes a PolicyEngine evaluating the query
+// "data." + path with its rego package.
+type PolicyEngine interface {
+	Decide(ctx context.Context, path string, input map[string]interface{}) (bool, error)
+}
+
+// Policies is the engine of the policy options of the generated muxes;
+// while it is nil, their calls are denied.
+var Policies PolicyEngine
+
+// Policy returns the Authorizer asking Policies for the decision of the
+// policy at path.
+func Policy(path string) Authorizer { return policy(path) }
+
+type policy string
+
+func (p policy) String() string { return "policy " + string(p) }
+
+func (p policy) Authorize(ctx context.Context, method string, in proto.Message) (bool, error) {
+	if Policies == nil {
+		return false, fmt.Errorf("goweb.Policies is not set")
+	}
+	input := map[string]interface{}{"method": method, "request": ruleValue(reflect.ValueOf(in))}
+	if principal := PrincipalFrom(ctx); principal != nil {
+		input["principal"] = claimsValue(principal)
+	}
+	return Policies.Decide(ctx, string(p), input)
+}
+
+// An OPAServer is the PolicyEngine of an Open Policy Agent, which it
+// queries with its data API: the decision is the result of
+// POST <URL>/v1/data/<path>, which must be a boolean; an undefined
+// result denies the call.
+type OPAServer struct {
+	URL    string       // e.g. "http://localhost:8181"
+	Client *http.Client // http.DefaultClient if nil
+}
+
+func (s *OPAServer) Decide(ctx context.Context, path string, input map[string]interface{}) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("POST", strings.TrimSuffix(s.URL, "/")+"/v1/data/"+strings.Trim(path, "/"), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return false, fmt.Errorf("opa: %s", res.Status)
+	}
+	var decision struct {
+		Result interface{} `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&decision); err != nil {
+		return false, err
+	}
+	switch result := decision.Result.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return result, nil
+	}
+	return false, fmt.Errorf("opa: result of %s is not a boolean: %v", path, decision.Result)
+}
@@ -0,0 +1,83 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestPolicy(t *testing.T) {
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Input map[string]interface{} }
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/data/authz/allow":
+			principal, _ := body.Input["principal"].(map[string]interface{})
+			request := body.Input["request"].(map[string]interface{})
+			allow := body.Input["method"] == "/pkg.Users/Get" && principal != nil && principal["sub"] == request["request"]
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": allow})
+		case "/v1/data/authz/undefined":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer opa.Close()
+	defer func(p PolicyEngine) { Policies = p }(Policies)
+
+	in := &CapturedCall{Request: []byte("alice")}
+	alice := WithPrincipal(context.Background(), map[string]string{"sub": "alice"})
+	if err := Authorize(alice, Policy("authz/allow"), "/pkg.Users/Get", in); err == nil {
+		t.Errorf("Authorize without Policies succeeded")
+	}
+	Policies = &OPAServer{URL: opa.URL}
+	for _, c := range []struct {
+		ctx    context.Context
+		path   string
+		denied bool
+	}{
+		{alice, "authz/allow", false},
+		{context.Background(), "authz/allow", true},
+		{WithPrincipal(context.Background(), map[string]string{"sub": "bob"}), "authz/allow", true},
+		{alice, "authz/undefined", true},
+		{alice, "authz/missing", true},
+	} {
+		if err := Authorize(c.ctx, Policy(c.path), "/pkg.Users/Get", in); (err != nil) != c.denied {
+			t.Errorf("%s: Authorize = %v", c.path, err)
+		}
+	}
+}
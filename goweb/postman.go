@@ -0,0 +1,158 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// PostmanSchema is the schema of the collections of PostmanCollection,
+// the version 2.1 of the Postman collection format, which Insomnia
+// imports too.
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// A PostmanRequest is a request of a Postman collection, see
+// PostmanCollection.
+type PostmanRequest struct {
+	Name    string      // the method of Route if empty
+	Route   Route       //
+	Request interface{} // the request message
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+// postmanItem is a folder, with items, or a request.
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+type postmanURL struct {
+	Raw   string            `json:"raw"`
+	Host  []string          `json:"host"`
+	Path  []string          `json:"path"`
+	Query []postmanKeyValue `json:"query,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string `json:"mode"`
+	Raw     string `json:"raw"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PostmanCollection returns the Postman collection named name with the
+// requests, in a folder per service, as JSON, so that QA teams import
+// ready-made requests of an API, e.g. the ones generated with the postman
+// parameter. The URLs start with the variable {{baseUrl}}, set to
+// baseURL. The requests are sent like the ones of an Upstream, with the
+// path variables in the path and the other fields outside of the body in
+// the query.
+func PostmanCollection(name, baseURL string, requests ...PostmanRequest) ([]byte, error) {
+	var c postmanCollection
+	c.Info.Name, c.Info.Schema = name, PostmanSchema
+	c.Item = []postmanItem{}
+	c.Variable = []postmanKeyValue{{"baseUrl", strings.TrimSuffix(baseURL, "/")}}
+	folders := map[string]int{}
+	for _, r := range requests {
+		req, err := r.postman()
+		if err != nil {
+			return nil, err
+		}
+		i, ok := folders[r.Route.Service]
+		if !ok {
+			i = len(c.Item)
+			folders[r.Route.Service] = i
+			c.Item = append(c.Item, postmanItem{Name: r.Route.Service})
+		}
+		name := r.Name
+		if name == "" {
+			name = r.Route.Method
+		}
+		c.Item[i].Item = append(c.Item[i].Item, postmanItem{Name: name, Request: req})
+	}
+	return json.MarshalIndent(c, "", "  ")
+}
+
+func (r PostmanRequest) postman() (*postmanRequest, error) {
+	verb, path, query, body, err := exampleRequest(r.Route, r.Request)
+	if err != nil {
+		return nil, err
+	}
+	req := &postmanRequest{Method: verb, Header: []postmanKeyValue{{"Accept", "application/json"}}}
+	req.URL.Raw = "{{baseUrl}}" + path
+	req.URL.Host = []string{"{{baseUrl}}"}
+	req.URL.Path = strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if query != "" {
+		req.URL.Raw += "?" + query
+		for _, kv := range strings.Split(query, "&") {
+			k, v := kv, ""
+			if n := strings.IndexByte(kv, '='); n >= 0 {
+				k, v = kv[:n], kv[n+1:]
+			}
+			k, _ = url.QueryUnescape(k)
+			v, _ = url.QueryUnescape(v)
+			req.URL.Query = append(req.URL.Query, postmanKeyValue{k, v})
+		}
+	}
+	if body != nil {
+		req.Header = append(req.Header, postmanKeyValue{"Content-Type", "application/json"})
+		req.Body = &postmanBody{Mode: "raw", Raw: string(body)}
+		req.Body.Options.Raw.Language = "json"
+	}
+	return req, nil
+}
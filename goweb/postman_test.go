@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPostmanCollection(t *testing.T) {
+	b, err := PostmanCollection("captures", "http://localhost:8080/",
+		PostmanRequest{Route: Route{Service: "goweb.Captures", Method: "Put", Path: "goweb.Captures/Put"}, Request: &CapturedCall{Method: "a"}},
+		PostmanRequest{Name: "Get a call", Route: Route{Service: "goweb.Captures", Method: "Get", Verb: "GET", Path: "v1/{method=calls/*}"},
+			Request: &CapturedCall{Method: "calls/x", Path: "p q"}},
+		PostmanRequest{Route: Route{Service: "goweb.Calls", Method: "List", Verb: "GET", Path: "v1/calls"}, Request: &CapturedCall{}},
+		PostmanRequest{Route: Route{Service: "goweb.Calls", Method: "Get", Verb: "GET", Path: "v1/{method=calls/*}"}, Request: &CapturedCall{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c postmanCollection
+	if err := json.Unmarshal(b, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Info.Name != "captures" || c.Info.Schema != PostmanSchema || len(c.Variable) != 1 || c.Variable[0] != (postmanKeyValue{"baseUrl", "http://localhost:8080"}) {
+		t.Fatalf("collection = %s", b)
+	}
+	if len(c.Item) != 2 || c.Item[0].Name != "goweb.Captures" || len(c.Item[0].Item) != 2 || c.Item[1].Name != "goweb.Calls" || len(c.Item[1].Item) != 2 {
+		t.Fatalf("folders = %s", b)
+	}
+	compact := func(s string) string {
+		var buf bytes.Buffer
+		json.Compact(&buf, []byte(s))
+		return buf.String()
+	}
+	for i, want := range []struct {
+		item            postmanItem
+		name, method    string
+		raw, path, body string
+		query           []postmanKeyValue
+	}{
+		{c.Item[0].Item[0], "Put", "POST", "{{baseUrl}}/goweb.Captures/Put", "goweb.Captures/Put", `{"method":"a"}`, nil},
+		{c.Item[0].Item[1], "Get a call", "GET", "{{baseUrl}}/v1/calls/x?path=p+q", "v1/calls/x", "", []postmanKeyValue{{"path", "p q"}}},
+		{c.Item[1].Item[0], "List", "GET", "{{baseUrl}}/v1/calls", "v1/calls", "", nil},
+		{c.Item[1].Item[1], "Get", "GET", "{{baseUrl}}/v1/calls/1", "v1/calls/1", "", nil},
+	} {
+		r := want.item.Request
+		body := ""
+		if r != nil && r.Body != nil {
+			body = compact(r.Body.Raw)
+		}
+		if want.item.Name != want.name || r == nil || r.Method != want.method || r.URL.Raw != want.raw ||
+			len(r.URL.Host) != 1 || r.URL.Host[0] != "{{baseUrl}}" || strings.Join(r.URL.Path, "/") != want.path || body != want.body ||
+			len(r.URL.Query) != len(want.query) || len(want.query) > 0 && r.URL.Query[0] != want.query[0] {
+			t.Errorf("request %d = %+v", i, want.item)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WantsPretty reports whether the request asks for an indented JSON
+// response, with the query parameter pretty (?pretty, ?pretty=true) or
+// a pretty parameter of a JSON media type it accepts
+// (Accept: application/json; pretty=true). Handlers generated with the
+// pretty_json parameter honor it.
+func WantsPretty(r *http.Request) bool {
+	if v, ok := r.URL.Query()["pretty"]; ok {
+		if len(v) == 0 || v[0] == "" {
+			return true
+		}
+		pretty, _ := strconv.ParseBool(v[0])
+		return pretty
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		typ, params, err := mime.ParseMediaType(accept)
+		if err != nil || !strings.HasSuffix(typ, "json") && typ != "*/*" {
+			continue
+		}
+		if pretty, err := strconv.ParseBool(params["pretty"]); err == nil {
+			return pretty
+		}
+	}
+	return false
+}
+
+// IndentJSON returns the JSON document b indented by two spaces and
+// followed by a newline, or b unchanged if it is not valid JSON.
+func IndentJSON(b []byte) []byte {
+	var out bytes.Buffer
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || json.Indent(&out, b, "", "  ") != nil {
+		return b
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
@@ -0,0 +1,72 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsPretty(t *testing.T) {
+	for _, tc := range []struct {
+		target, accept string
+		want           bool
+	}{
+		{"/x", "", false},
+		{"/x?pretty", "", true},
+		{"/x?pretty=true", "", true},
+		{"/x?pretty=1", "", true},
+		{"/x?pretty=false", "application/json; pretty=true", false},
+		{"/x", "application/json; pretty=true", true},
+		{"/x", "text/html, application/json;pretty=true;q=0.9", true},
+		{"/x", "text/plain; pretty=true", false},
+		{"/x", "*/*; pretty=true", true},
+		{"/x", "application/json", false},
+	} {
+		r := httptest.NewRequest("GET", tc.target, nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		if got := WantsPretty(r); got != tc.want {
+			t.Errorf("WantsPretty(%s, Accept: %s) = %v", tc.target, tc.accept, got)
+		}
+	}
+}
+
+func TestIndentJSON(t *testing.T) {
+	if got := string(IndentJSON([]byte(`{"a":[1,2]}` + "\n"))); got != "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n" {
+		t.Errorf("IndentJSON = %q", got)
+	}
+	if got := string(IndentJSON([]byte("not json"))); got != "not json" {
+		t.Errorf("IndentJSON of invalid JSON = %q", got)
+	}
+}
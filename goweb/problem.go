@@ -0,0 +1,128 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ProblemTypeBase, if set, is the base URI of the types of RFC 7807
+// problems: the type of an error with a code is ProblemTypeBase followed
+// by the code in lower case and with dashes, e.g.
+// "https://errors.example.com/not-found". Problems are of the type
+// "about:blank" otherwise.
+var ProblemTypeBase string
+
+// problemBody is an RFC 7807 problem, with the fields of errorBody as
+// extension members.
+type problemBody struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code       string           `json:"code,omitempty"`
+	Domain     string           `json:"domain,omitempty"`
+	Retryable  bool             `json:"retryable,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	Details    []errorDetail    `json:"details,omitempty"`
+}
+
+// WriteProblem is WriteRequestError, but answers with an RFC 7807
+// application/problem+json body: the status text as title, the message
+// as detail, the path of r as instance and code, domain, retryable,
+// violations and details as extension members. Errors that are no *Error
+// (see AsError) are answered like an INTERNAL error and logged. Generated
+// handlers use it with the error_format=problem parameter.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := AsError(err)
+	if !ok {
+		log.Println(err.Error())
+		e = internalError
+	} else if r != nil && ErrorCatalog != nil && e.Code != "" {
+		e = localize(w, r, e)
+	}
+	status := e.Status
+	if status == 0 {
+		status = ErrorStatus(e.Domain, e.Code)
+	}
+	b, merr := e.body()
+	if merr != nil {
+		writeError(w, r, nil, merr)
+		return
+	}
+	p := problemBody{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     b.Message,
+		Code:       b.Code,
+		Domain:     b.Domain,
+		Retryable:  b.Retryable,
+		Violations: b.Violations,
+		Details:    b.Details,
+	}
+	if ProblemTypeBase != "" && e.Code != "" {
+		p.Type = ProblemTypeBase + strings.ToLower(strings.Replace(e.Code, "_", "-", -1))
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	body, merr := json.Marshal(p)
+	if merr != nil {
+		writeError(w, r, nil, merr)
+		return
+	}
+	recordError(r, status, err)
+	setRetryAfter(w, e)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// decodeProblem decodes the RFC 7807 problem body into e.
+func decodeProblem(body []byte, e *Error) error {
+	var p problemBody
+	if err := json.Unmarshal(body, &p); err != nil {
+		return err
+	}
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	e.set(errorBody{Code: p.Code, Message: msg, Domain: p.Domain, Retryable: p.Retryable, Violations: p.Violations, Details: p.Details})
+	return nil
+}
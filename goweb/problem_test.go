@@ -0,0 +1,61 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteProblem(t *testing.T) {
+	ProblemTypeBase = "https://errors.example.com/"
+	defer func() { ProblemTypeBase = "" }()
+	r := httptest.NewRequest("POST", "/users/get", strings.NewReader(""))
+	w := httptest.NewRecorder()
+	WriteProblem(w, r, ErrorFactory{Domain: "pkg.Users"}.NotFound("user", "7"))
+	want := `{"type":"https://errors.example.com/not-found","title":"Not Found","status":404,"detail":"user \"7\" not found","instance":"/users/get","code":"NOT_FOUND","domain":"pkg.Users"}`
+	if w.Code != 404 || w.Header().Get("Content-Type") != "application/problem+json" || w.Body.String() != want {
+		t.Errorf("%d %q %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	e, ok := DecodeError(w.Code, w.Body.Bytes()).(*Error)
+	if !ok || e.Status != 404 || e.Code != "NOT_FOUND" || e.Message != `user "7" not found` || e.Domain != "pkg.Users" {
+		t.Errorf("DecodeError = %+v", e)
+	}
+
+	w = httptest.NewRecorder()
+	WriteProblem(w, r, errors.New("boom"))
+	if w.Code != 500 || !strings.Contains(w.Body.String(), `"type":"https://errors.example.com/internal","title":"Internal Server Error","status":500,"detail":"internal error"`) {
+		t.Errorf("%d %s", w.Code, w.Body)
+	}
+}
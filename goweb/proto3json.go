@@ -0,0 +1,98 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+// Proto3JSON encodes and decodes messages in the canonical JSON mapping of
+// proto3 (jsonpb): fields by their JSON names (json_name), enums by name,
+// 64-bit integers as strings, oneofs as their set field, well-known types
+// such as Timestamp and Duration as strings and Any with "@type". The
+// generated handlers and Upstream use it unless they are generated with
+// the legacy_json parameter, which keeps encoding/json.
+// Values that are not messages are encoded with encoding/json.
+type Proto3JSON struct {
+	// EmitDefaults writes the fields that have their zero value, which
+	// the mapping leaves out.
+	EmitDefaults bool
+
+	// EnumsAsInts writes enum values as numbers instead of names.
+	EnumsAsInts bool
+}
+
+// Marshal encodes v.
+func (j Proto3JSON) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return marshalJSON(v)
+	}
+	var b bytes.Buffer
+	err := (&jsonpb.Marshaler{EmitDefaults: j.EmitDefaults, EnumsAsInts: j.EnumsAsInts, AnyResolver: anyResolver{}}).Marshal(&b, m)
+	return b.Bytes(), err
+}
+
+// Unmarshal decodes data into v, accepting enums by name or number and the
+// fields by their JSON or proto names. Unknown fields are ignored, as by
+// the encoding/json based decoding of the handlers, but data after the
+// JSON value is not.
+func (j Proto3JSON) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return UnmarshalJSON(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := (&jsonpb.Unmarshaler{AllowUnknownFields: true, AnyResolver: anyResolver{}}).UnmarshalNext(dec, m); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errTrailingJSON
+	}
+	return nil
+}
+
+// errTrailingJSON reports data after the JSON value decoded by Proto3JSON.
+var errTrailingJSON = errors.New("goweb: data after the JSON value")
+
+// anyResolver resolves the types of Any values with ResolveAny.
+type anyResolver struct{}
+
+func (anyResolver) Resolve(url string) (proto.Message, error) {
+	return ResolveAny(url)
+}
@@ -0,0 +1,83 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+func TestProto3JSON(t *testing.T) {
+	f := &pb.FieldDescriptorProto{
+		Name:     proto.String("user_id"),
+		JsonName: proto.String("userId"),
+		Number:   proto.Int32(1),
+		Type:     pb.FieldDescriptorProto_TYPE_INT64.Enum(),
+	}
+	for _, tc := range []struct {
+		j    Proto3JSON
+		want string
+	}{
+		{Proto3JSON{}, `{"name":"user_id","number":1,"type":"TYPE_INT64","jsonName":"userId"}`},
+		{Proto3JSON{EnumsAsInts: true}, `{"name":"user_id","number":1,"type":3,"jsonName":"userId"}`},
+	} {
+		b, err := tc.j.Marshal(f)
+		if err != nil || string(b) != tc.want {
+			t.Errorf("%+v.Marshal = %s, %v, want %s", tc.j, b, err, tc.want)
+		}
+	}
+
+	var got pb.FieldDescriptorProto
+	if err := (Proto3JSON{}).Unmarshal([]byte(`{"name":"user_id","type":"TYPE_INT64","json_name":"userId","unknown":1}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GetType() != pb.FieldDescriptorProto_TYPE_INT64 || got.GetJsonName() != "userId" {
+		t.Errorf("Unmarshal = %v", &got)
+	}
+	if err := (Proto3JSON{}).Unmarshal([]byte(`{"type":"TYPE_NONE"}`), &got); err == nil {
+		t.Errorf("Unmarshal of an unknown enum name did not fail")
+	}
+	if err := (Proto3JSON{}).Unmarshal([]byte(`{"name":"a"} {}`), &got); err != errTrailingJSON {
+		t.Errorf("Unmarshal of trailing data = %v", err)
+	}
+
+	b, err := Proto3JSON{}.Marshal(&timestamp.Timestamp{Seconds: 1600000000})
+	if err != nil || string(b) != `"2020-09-13T12:26:40Z"` {
+		t.Errorf("Marshal of a Timestamp = %s, %v", b, err)
+	}
+	if b, err := (Proto3JSON{}).Marshal(map[string]int{"a": 1}); err != nil || string(b) != `{"a":1}` {
+		t.Errorf("Marshal of a map = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// A QueueHandler handles one request received from a message queue
+// and returns the reply.
+type QueueHandler func(ctx context.Context, data []byte) ([]byte, error)
+
+// A QueueConn is the subscription side of a request/reply message queue
+// such as NATS. An adapter for a *nats.Conn looks like
+//
+//	func (c natsConn) Subscribe(subject string, h goweb.QueueHandler) error {
+//		_, err := c.nc.Subscribe(subject, func(m *nats.Msg) {
+//			reply, err := h(context.Background(), m.Data)
+//			if err != nil {
+//				reply = []byte(err.Error())
+//			}
+//			m.Respond(reply)
+//		})
+//		return err
+//	}
+type QueueConn interface {
+	Subscribe(subject string, h QueueHandler) error
+}
+
+// A Codec encodes and decodes the messages exchanged over a transport.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes messages as JSON.
+var JSONCodec Codec = jsonCodec{}
+
+// ProtoCodec encodes messages in the protocol buffer wire format.
+var ProtoCodec Codec = protoCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("goweb: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("goweb: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// An EventHandler handles one event consumed from a topic.
+type EventHandler func(ctx context.Context, key, value []byte) error
+
+// An EventConsumer delivers the events of a topic, for example through a
+// Kafka consumer group. If the handler returns an error, the event should
+// not be committed.
+type EventConsumer interface {
+	Consume(topic string, h EventHandler) error
+}
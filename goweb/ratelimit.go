@@ -0,0 +1,269 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The response headers reporting the state of a RateLimiter.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"     // the burst of the key
+	RateLimitRemainingHeader = "X-RateLimit-Remaining" // calls left in the burst
+	RateLimitResetHeader     = "X-RateLimit-Reset"     // seconds until the burst is full again
+	QuotaLimitHeader         = "X-Quota-Limit"         // calls per quota period
+	QuotaRemainingHeader     = "X-Quota-Remaining"     // calls left in the period
+	QuotaResetHeader         = "X-Quota-Reset"         // seconds until the period ends
+)
+
+// A RateLimiter limits the calls per key, by default the tenant the
+// authentication middleware set on the request (see WithTenant) or else
+// the ClientIP, with a token bucket and optionally a Quota. Its Handler method wraps a mux;
+// calls over the limit are answered with 429 and a RESOURCE_EXHAUSTED
+// *Error, and every response carries the X-RateLimit-* (and X-Quota-*)
+// headers.
+type RateLimiter struct {
+	// Rate is the number of calls per second and key, Burst the number
+	// of calls a key may make at once (at least 1).
+	Rate  float64
+	Burst int
+
+	// Key, if set, returns the key of the request r; requests with the
+	// key "" are not limited.
+	Key func(r *http.Request) string
+
+	// Quota, if set, also limits the calls per key and day or month.
+	Quota Quota
+
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element // of *bucket, in used
+	used    list.List                // the buckets, the last used first
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// maxBuckets bounds the buckets a RateLimiter keeps: beyond it, the one
+// used the longest time ago is dropped, which by then is full, or nearly
+// so, like a new bucket.
+const maxBuckets = 10000
+
+// Handler returns h limited by rl.
+func (rl *RateLimiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.admit(w, r) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// admit reports whether the request r is within the limits, and answers
+// it with 429 otherwise.
+func (rl *RateLimiter) admit(w http.ResponseWriter, r *http.Request) bool {
+	key := rl.key(r)
+	if key == "" {
+		return true
+	}
+	now := rl.now()
+	if wait, ok := rl.take(w, key, now); !ok {
+		WriteError(w, &Error{Status: 429, Code: "RESOURCE_EXHAUSTED", Message: "rate limit exceeded", Retryable: true, RetryAfter: wait})
+		return false
+	}
+	if rl.Quota != nil {
+		s, err := rl.Quota.Use(r.Context(), key, now)
+		if err == nil || err == ErrQuotaExceeded {
+			w.Header().Set(QuotaLimitHeader, strconv.FormatInt(s.Limit, 10))
+			w.Header().Set(QuotaRemainingHeader, strconv.FormatInt(s.Remaining, 10))
+			w.Header().Set(QuotaResetHeader, seconds(s.Reset.Sub(now)))
+		}
+		if err != nil {
+			WriteError(w, err)
+			return false
+		}
+	}
+	return true
+}
+
+// key returns the key of r. The TenantHeader is not used: clients could
+// send any tenant, to get around their limit or use up that of another.
+func (rl *RateLimiter) key(r *http.Request) string {
+	if rl.Key != nil {
+		return rl.Key(r)
+	}
+	ctx := NewContext(r)
+	if t := TenantFrom(ctx); t != "" {
+		return t
+	}
+	return ClientIP(ctx)
+}
+
+func (rl *RateLimiter) now() time.Time {
+	if rl.Now != nil {
+		return rl.Now()
+	}
+	return time.Now()
+}
+
+// take takes a token of key from its bucket and sets the X-RateLimit-*
+// headers. If there is none, it returns the time until there is one.
+func (rl *RateLimiter) take(w http.ResponseWriter, key string, now time.Time) (time.Duration, bool) {
+	burst := float64(rl.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.buckets == nil {
+		rl.buckets = make(map[string]*list.Element)
+	}
+	e := rl.buckets[key]
+	if e == nil {
+		if len(rl.buckets) >= maxBuckets {
+			oldest := rl.used.Back()
+			rl.used.Remove(oldest)
+			delete(rl.buckets, oldest.Value.(*bucket).key)
+		}
+		e = rl.used.PushFront(&bucket{key: key, tokens: burst, last: now})
+		rl.buckets[key] = e
+	} else {
+		rl.used.MoveToFront(e)
+	}
+	b := e.Value.(*bucket)
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rl.Rate)
+	b.last = now
+	ok := b.tokens >= 1
+	if ok {
+		b.tokens--
+	}
+	w.Header().Set(RateLimitLimitHeader, strconv.Itoa(int(burst)))
+	w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(int(b.tokens)))
+	w.Header().Set(RateLimitResetHeader, seconds(rl.after(burst-b.tokens)))
+	return rl.after(1 - b.tokens), ok
+}
+
+// after returns the time until n tokens are added to a bucket.
+func (rl *RateLimiter) after(n float64) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	if rl.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(n / rl.Rate * float64(time.Second))
+}
+
+// seconds formats d in whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// ErrQuotaExceeded reports a call over the Quota of its key.
+var ErrQuotaExceeded = &Error{Status: 429, Code: "RESOURCE_EXHAUSTED", Message: "quota exceeded"}
+
+// QuotaStatus is the state of the quota of a key.
+type QuotaStatus struct {
+	Limit     int64     // calls per period
+	Remaining int64     // calls left in the period
+	Reset     time.Time // the end of the period
+}
+
+// A Quota limits the calls per key and period, e.g. in a shared store
+// for servers behind a load balancer.
+type Quota interface {
+	// Use counts a call of key at now. It returns ErrQuotaExceeded,
+	// together with the status, if the quota of key is used up.
+	Use(ctx context.Context, key string, now time.Time) (QuotaStatus, error)
+}
+
+// A QuotaPeriod is the period in which a Quota allows its calls.
+type QuotaPeriod int
+
+// The periods of a MemoryQuota, which start at midnight UTC.
+const (
+	Daily QuotaPeriod = iota
+	Monthly
+)
+
+// start returns the start of the period of t and of the next one.
+func (p QuotaPeriod) start(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	if p == Monthly {
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// A MemoryQuota is a Quota of Limit calls per key and Period, counted in
+// memory, so for a single server.
+type MemoryQuota struct {
+	Limit  int64
+	Period QuotaPeriod
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int64
+}
+
+// Use implements Quota.
+func (q *MemoryQuota) Use(ctx context.Context, key string, now time.Time) (QuotaStatus, error) {
+	start, next := q.Period.start(now)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !start.Equal(q.start) {
+		q.start, q.counts = start, make(map[string]int64)
+	}
+	s := QuotaStatus{Limit: q.Limit, Reset: next}
+	if q.counts[key] >= q.Limit {
+		return s, ErrQuotaExceeded
+	}
+	q.counts[key]++
+	s.Remaining = q.Limit - q.counts[key]
+	return s, nil
+}
@@ -0,0 +1,149 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 31, 23, 59, 0, 0, time.UTC)
+	rl := &RateLimiter{Rate: 1, Burst: 2, Quota: &MemoryQuota{Limit: 3, Period: Daily}, Now: func() time.Time { return now }}
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(tenant string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(""))
+		r = r.WithContext(WithTenant(r.Context(), tenant))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for i, want := range []int{200, 200, 429} {
+		if w := call("acme"); w.Code != want {
+			t.Errorf("call %d: %d, want %d", i, w.Code, want)
+		}
+	}
+	if w := call("other"); w.Code != 200 || w.Header().Get(RateLimitRemainingHeader) != "1" || w.Header().Get(QuotaRemainingHeader) != "2" {
+		t.Errorf("other tenant: %d %v", w.Code, w.Header())
+	}
+	w := call("acme")
+	if w.Code != 429 || w.Header().Get("Retry-After") != "1" || w.Header().Get(RateLimitRemainingHeader) != "0" {
+		t.Errorf("limited: %d %v", w.Code, w.Header())
+	}
+
+	now = now.Add(time.Second)
+	if w := call("acme"); w.Code != 200 || w.Header().Get(QuotaRemainingHeader) != "0" || w.Header().Get(QuotaResetHeader) != "59" {
+		t.Errorf("after a second: %d %v", w.Code, w.Header())
+	}
+	now = now.Add(time.Second)
+	if w := call("acme"); w.Code != 429 || !strings.Contains(w.Body.String(), "quota exceeded") {
+		t.Errorf("over quota: %d %s", w.Code, w.Body)
+	}
+	now = now.Add(time.Minute) // the next day
+	if w := call("acme"); w.Code != 200 || w.Header().Get(QuotaRemainingHeader) != "2" {
+		t.Errorf("next day: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimiterTenantHeader(t *testing.T) {
+	rl := &RateLimiter{Rate: 1, Burst: 1}
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i, want := range []int{200, 429} {
+		r := httptest.NewRequest("POST", "/", nil)
+		// rotating the unauthenticated header does not get around the
+		// limit of the client IP
+		r.Header.Set(TenantHeader, "tenant"+string(rune('a'+i)))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("call %d: %d, want %d", i, w.Code, want)
+		}
+	}
+}
+
+func TestRateLimiterForwardedFor(t *testing.T) {
+	defer func(trust bool, proxies []string) { TrustForwardedFor, TrustedProxies = trust, proxies }(TrustForwardedFor, TrustedProxies)
+	TrustForwardedFor, TrustedProxies = true, []string{"10.0.0.1"}
+	rl := &RateLimiter{Rate: 1, Burst: 1}
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i, want := range []int{200, 429} {
+		r := httptest.NewRequest("POST", "/", nil)
+		// rotating the entries the client sends does not get around the
+		// limit of the address the proxy saw
+		r.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i)+", 203.0.113.9, 10.0.0.1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("call %d: %d, want %d", i, w.Code, want)
+		}
+	}
+}
+
+// Beyond maxBuckets, the bucket used the longest time ago is dropped.
+func TestRateLimiterEviction(t *testing.T) {
+	rl := &RateLimiter{Burst: 1, Key: func(r *http.Request) string { return r.Header.Get("Key") }}
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(key string) int {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set("Key", key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	for i := 0; i < maxBuckets; i++ {
+		call(strconv.Itoa(i))
+	}
+	if code := call("0"); code != 429 {
+		t.Errorf("bucket 0 dropped before maxBuckets: %d", code)
+	}
+	call("new")
+	if len(rl.buckets) != maxBuckets || rl.used.Len() != maxBuckets {
+		t.Errorf("%d buckets, %d in use order, want %d", len(rl.buckets), rl.used.Len(), maxBuckets)
+	}
+	if code := call("0"); code != 429 {
+		t.Errorf("recently used bucket 0 dropped: %d", code)
+	}
+	if code := call("1"); code != 200 {
+		t.Errorf("oldest bucket 1 kept: %d", code)
+	}
+}
+
+func TestQuotaPeriod(t *testing.T) {
+	start, next := Monthly.start(time.Date(2020, 2, 10, 12, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)) || !next.Equal(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Monthly = %v, %v", start, next)
+	}
+}
@@ -0,0 +1,121 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// RegionForwardedHeader marks a call handed to another region, which
+// serves it whatever its RegionRouter says, so calls never loop.
+const RegionForwardedHeader = "X-Goweb-Region-Forwarded"
+
+// A RegionEndpoint is where a call is served.
+type RegionEndpoint struct {
+	// URL is the base URL of the mux of the region, e.g.
+	// "https://eu.api.example.com"; "" serves the call here.
+	URL string
+
+	// Proxy forwards the call to URL instead of redirecting the client
+	// there with 307.
+	Proxy bool
+}
+
+// A RegionRouter decides the region serving calls of methods with a
+// goweb.region_field, from the value key of that field.
+type RegionRouter interface {
+	Endpoint(ctx context.Context, route Route, key string) (RegionEndpoint, error)
+}
+
+// RegionRouterFunc adapts a function to a RegionRouter.
+type RegionRouterFunc func(ctx context.Context, route Route, key string) (RegionEndpoint, error)
+
+// Endpoint calls f(ctx, route, key).
+func (f RegionRouterFunc) Endpoint(ctx context.Context, route Route, key string) (RegionEndpoint, error) {
+	return f(ctx, route, key)
+}
+
+// Regions routes the calls of methods with a goweb.region_field; they are
+// all served here while it is nil.
+var Regions RegionRouter
+
+// RouteRegion hands the call of route answering r, whose region field is
+// key and whose body was body, to the endpoint Regions returns for it, and
+// reports whether it did so (or failed), in which case the handler is
+// done. The path and query of r are kept below the URL of the endpoint.
+func RouteRegion(w http.ResponseWriter, r *http.Request, route Route, key string, body []byte) bool {
+	if Regions == nil || r.Header.Get(RegionForwardedHeader) != "" {
+		return false
+	}
+	e, err := Regions.Endpoint(NewContext(r), route, key)
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return true
+	}
+	if e.URL == "" {
+		return false
+	}
+	if !e.Proxy {
+		w.Header().Set("Location", strings.TrimSuffix(e.URL, "/")+r.URL.RequestURI())
+		w.WriteHeader(307)
+		return true
+	}
+	target, err := url.Parse(e.URL)
+	if err != nil {
+		WriteRequestError(w, r, err)
+		return true
+	}
+	out := r.WithContext(r.Context())
+	out.Header = r.Header.Clone()
+	out.Header.Set(RegionForwardedHeader, "1")
+	out.Header.Del("Content-Encoding") // body is decoded
+	out.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	p := httputil.NewSingleHostReverseProxy(target)
+	p.FlushInterval = -1 // for streams
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Println("goweb: region proxy:", err)
+		w.WriteHeader(502)
+	}
+	p.ServeHTTP(w, out)
+	return true
+}
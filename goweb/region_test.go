@@ -0,0 +1,80 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRouteRegion(t *testing.T) {
+	eu := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte("eu " + r.URL.Path + " " + string(body) + " " + r.Header.Get(RegionForwardedHeader)))
+	}))
+	defer eu.Close()
+	Regions = RegionRouterFunc(func(ctx context.Context, route Route, key string) (RegionEndpoint, error) {
+		switch key {
+		case "de":
+			return RegionEndpoint{URL: eu.URL, Proxy: true}, nil
+		case "fr":
+			return RegionEndpoint{URL: "https://eu.example.com/"}, nil
+		}
+		return RegionEndpoint{}, nil
+	})
+	defer func() { Regions = nil }()
+	call := func(key string, hdr ...string) (*httptest.ResponseRecorder, bool) {
+		r := httptest.NewRequest("POST", "/users/get?x=1", strings.NewReader("ignored"))
+		for i := 0; i+1 < len(hdr); i += 2 {
+			r.Header.Set(hdr[i], hdr[i+1])
+		}
+		w := httptest.NewRecorder()
+		return w, RouteRegion(w, r, Route{}, key, []byte(`{"country":"`+key+`"}`))
+	}
+	if _, done := call("us"); done {
+		t.Error("local call routed")
+	}
+	if w, done := call("fr"); !done || w.Code != 307 || w.Header().Get("Location") != "https://eu.example.com/users/get?x=1" {
+		t.Errorf("redirect: %v %d %v", done, w.Code, w.Header())
+	}
+	if w, done := call("de"); !done || w.Body.String() != `eu /users/get {"country":"de"} 1` {
+		t.Errorf("proxy: %v %d %q", done, w.Code, w.Body)
+	}
+	if _, done := call("de", RegionForwardedHeader, "1"); done {
+		t.Error("forwarded call routed again")
+	}
+}
@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "sync"
+
+// RouteHashHeader is the response header in which generated handlers
+// report the hash of the route they serve, so that a client can detect
+// that it was generated from a different contract than the server.
+const RouteHashHeader = "X-Goweb-Route-Hash"
+
+// The process-wide route registry, filled by the init functions of
+// generated code.
+var registry struct {
+	sync.RWMutex
+	routes []Route
+	index  map[string]int // FullMethod to position in routes
+}
+
+// RegisterRoutes adds routes to the registry. It is called by generated
+// code; registering a method again replaces the earlier entry.
+func RegisterRoutes(routes ...Route) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.index == nil {
+		registry.index = make(map[string]int)
+	}
+	for _, r := range routes {
+		if i, ok := registry.index[r.FullMethod()]; ok {
+			registry.routes[i] = r
+			continue
+		}
+		registry.index[r.FullMethod()] = len(registry.routes)
+		registry.routes = append(registry.routes, r)
+	}
+}
+
+// Routes returns all registered routes in registration order.
+func Routes() []Route {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]Route(nil), registry.routes...)
+}
+
+// LookupRoute returns the registered route for fullMethod,
+// which has the form "/pkg.Service/Method".
+func LookupRoute(fullMethod string) (Route, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	i, ok := registry.index[fullMethod]
+	if !ok {
+		return Route{}, false
+	}
+	return registry.routes[i], true
+}
@@ -0,0 +1,96 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMarkRetryable(t *testing.T) {
+	f := ErrorFactory{}
+	if e := MarkRetryable(f.Unavailable("down"), true).(*Error); !e.Retryable {
+		t.Error("UNAVAILABLE of a safe method not retryable")
+	}
+	if e := MarkRetryable(f.NotFound("user", "7"), true).(*Error); e.Retryable {
+		t.Error("NOT_FOUND retryable")
+	}
+	if e := MarkRetryable(&Error{Code: "UNAVAILABLE", Retryable: true}, false).(*Error); e.Retryable {
+		t.Error("error of an unsafe method retryable")
+	}
+	if err := errors.New("x"); MarkRetryable(err, true) != err {
+		t.Error("other error changed")
+	}
+}
+
+func TestUpstreamRetries(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls < 3 {
+			WriteError(w, &Error{Status: 503, Code: "UNAVAILABLE", Message: "down", Retryable: true})
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, Retries: 1, RetryBackoff: time.Millisecond}
+	var out struct{}
+	err := u.Call(context.Background(), Route{Path: "/"}, struct{}{}, &out)
+	if e, ok := err.(*Error); !ok || !e.Retryable || calls != 2 {
+		t.Errorf("Call with 1 retry = %v after %d calls", err, calls)
+	}
+	calls = 0
+	u.Retries = 2
+	if err := u.Call(context.Background(), Route{Path: "/"}, struct{}{}, &out); err != nil || calls != 3 {
+		t.Errorf("Call with 2 retries = %v after %d calls", err, calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for v, want := range map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"Wed, 01 Jan 2020 00:00:10 GMT": 10 * time.Second,
+		"Tue, 31 Dec 2019 00:00:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(v, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
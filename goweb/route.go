@@ -0,0 +1,233 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package goweb contains the runtime support shared by the code that
+// protoc-gen-goweb generates, together with the route derivation used by
+// the generator itself, so both always agree on how an RPC is exposed.
+package goweb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Route describes how a single RPC is exposed over HTTP.
+type Route struct {
+	Service         string                 `json:"service"`        // Fully-qualified service name, e.g. "pkg.Users".
+	Method          string                 `json:"method"`         // Method name as declared in the .proto.
+	Verb            string                 `json:"verb,omitempty"` // HTTP verb; empty means any verb is accepted.
+	Path            string                 `json:"path"`           // Path relative to the mux prefix; a path template if Verb is set.
+	Body            string                 `json:"body,omitempty"` // The body selector if Verb is set: "*", a field or "" for none.
+	Input           string                 `json:"input"`          // Fully-qualified input type, e.g. ".pkg.Req".
+	Output          string                 `json:"output"`         // Fully-qualified output type.
+	ClientStreaming bool                   `json:"client_streaming,omitempty"`
+	ServerStreaming bool                   `json:"server_streaming,omitempty"`
+	Options         map[string]interface{} `json:"options,omitempty"`     // Method options by full name, see MethodOptions.
+	Hash            string                 `json:"hash,omitempty"`        // See HashRoute.
+	SLO             *SLO                   `json:"slo,omitempty"`         // The objectives of the method, if declared.
+	LegacyJSON      bool                   `json:"legacy_json,omitempty"` // Whether the JSON follows encoding/json rather than the proto3 mapping.
+}
+
+// FullMethod returns the gRPC style method name, "/pkg.Service/Method".
+func (r Route) FullMethod() string {
+	return "/" + r.Service + "/" + r.Method
+}
+
+// RouteOf returns the route for a method of a service defined in file.
+func RouteOf(file *pb.FileDescriptorProto, service *pb.ServiceDescriptorProto, method *pb.MethodDescriptorProto) Route {
+	servName := generator.CamelCase(service.GetName())
+	// the versions of a service share their paths, see VersionedMux
+	if _, version, ok := ParseMediaVersion(options.String(service.GetOptions(), options.E_ApiVersion)); ok {
+		if name, ok := VersionedName(servName, version); ok {
+			servName = name
+		}
+	}
+	path := strings.ToLower(servName) + "/" + method.GetName()
+	if p := options.String(method.GetOptions(), options.E_Path); p != "" {
+		path = p
+	}
+	fullServName := service.GetName()
+	if pkg := file.GetPackage(); pkg != "" {
+		fullServName = pkg + "." + fullServName
+	}
+	route := Route{
+		Service:         fullServName,
+		Method:          method.GetName(),
+		Path:            strings.ToLower(path),
+		Input:           method.GetInputType(),
+		Output:          method.GetOutputType(),
+		ClientStreaming: method.GetClientStreaming(),
+		ServerStreaming: method.GetServerStreaming(),
+		Options:         MethodOptions(method.GetOptions()),
+		SLO:             sloOf(method.GetOptions()),
+	}
+	// a google.api.http annotation takes precedence over the legacy path
+	if rule := options.Http(method.GetOptions()); rule != nil {
+		if verb, template := rule.Pattern(); template != "" {
+			route.Verb, route.Path, route.Body = verb, strings.TrimPrefix(template, "/"), rule.Body
+		}
+	}
+	return route
+}
+
+// MethodOptions returns the options set on a method by full name, e.g.
+// {"deprecated": true, "goweb.timeout_seconds": uint32(5)}: the
+// deprecated and idempotency_level fields, the latter by enum value name,
+// and the custom options of the options registry with scalar or repeated
+// string values, dereferenced. Message options such as google.api.http,
+// which is the Verb, Path and Body of the route, are left out. It returns
+// nil if no option is set.
+func MethodOptions(opts *pb.MethodOptions) map[string]interface{} {
+	if opts == nil {
+		return nil
+	}
+	m := make(map[string]interface{})
+	if opts.Deprecated != nil {
+		m["deprecated"] = opts.GetDeprecated()
+	}
+	if opts.IdempotencyLevel != nil {
+		m["idempotency_level"] = opts.GetIdempotencyLevel().String()
+	}
+	for _, ext := range options.Extensions() {
+		switch v := options.Value(opts, ext.Name).(type) {
+		case nil, proto.Message:
+		case []string:
+			m[ext.Name] = v
+		default:
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+				m[ext.Name] = rv.Elem().Interface()
+			}
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Template returns the parsed path template of r if its path is one,
+// i.e. if it comes from a google.api.http annotation.
+func (r Route) Template() (*PathTemplate, error) {
+	if r.Verb == "" {
+		return nil, nil
+	}
+	return ParseTemplate("/" + r.Path)
+}
+
+// RoutesOf returns the routes of all services defined in file,
+// in declaration order.
+func RoutesOf(file *pb.FileDescriptorProto) []Route {
+	var routes []Route
+	for _, service := range file.Service {
+		for _, method := range service.Method {
+			routes = append(routes, RouteOf(file, service, method))
+		}
+	}
+	return routes
+}
+
+// IndexMessages maps the fully-qualified name of every message defined in
+// files, nested ones included, to its descriptor. The keys use the input
+// syntax with a leading period, as found in MethodDescriptorProto.InputType.
+func IndexMessages(files []*pb.FileDescriptorProto) map[string]*pb.DescriptorProto {
+	index := make(map[string]*pb.DescriptorProto)
+	var walk func(prefix string, msgs []*pb.DescriptorProto)
+	walk = func(prefix string, msgs []*pb.DescriptorProto) {
+		for _, msg := range msgs {
+			name := prefix + "." + msg.GetName()
+			index[name] = msg
+			walk(name, msg.NestedType)
+		}
+	}
+	for _, f := range files {
+		prefix := ""
+		if pkg := f.GetPackage(); pkg != "" {
+			prefix = "." + pkg
+		}
+		walk(prefix, f.MessageType)
+	}
+	return index
+}
+
+// HashRoute returns a stable hash of the HTTP contract of r: its verb, path
+// and the schema of its request message, including the messages it
+// references. msgs must contain the request message, see IndexMessages.
+// The hash does not depend on the declaration order of fields, so only
+// changes visible on the wire alter it.
+func HashRoute(r Route, msgs map[string]*pb.DescriptorProto) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Verb, r.Path)
+	if r.Body != "" {
+		fmt.Fprintf(h, "body %s\n", r.Body)
+	}
+	seen := make(map[string]bool)
+	var schema func(name string)
+	schema = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		msg := msgs[name]
+		if msg == nil {
+			fmt.Fprintf(h, "message %s unknown\n", name)
+			return
+		}
+		fields := append([]*pb.FieldDescriptorProto(nil), msg.Field...)
+		sort.Sort(byNumber(fields))
+		fmt.Fprintf(h, "message %s {\n", name)
+		for _, f := range fields {
+			fmt.Fprintf(h, "%d %s %s %s %s\n", f.GetNumber(), f.GetLabel(), f.GetType(), f.GetTypeName(), f.GetName())
+		}
+		fmt.Fprintf(h, "}\n")
+		for _, f := range fields {
+			if f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE || f.GetType() == pb.FieldDescriptorProto_TYPE_GROUP {
+				schema(f.GetTypeName())
+			}
+		}
+	}
+	schema(r.Input)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+type byNumber []*pb.FieldDescriptorProto
+
+func (s byNumber) Len() int           { return len(s) }
+func (s byNumber) Less(i, j int) bool { return s[i].GetNumber() < s[j].GetNumber() }
+func (s byNumber) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
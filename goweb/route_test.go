@@ -0,0 +1,154 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestHashRoute(t *testing.T) {
+	msgs := func(fields ...*pb.FieldDescriptorProto) map[string]*pb.DescriptorProto {
+		return map[string]*pb.DescriptorProto{
+			".pkg.Req": {Name: proto.String("Req"), Field: fields},
+		}
+	}
+	id := &pb.FieldDescriptorProto{Name: proto.String("id"), Number: proto.Int32(1), Type: pb.FieldDescriptorProto_TYPE_STRING.Enum()}
+	n := &pb.FieldDescriptorProto{Name: proto.String("n"), Number: proto.Int32(2), Type: pb.FieldDescriptorProto_TYPE_INT32.Enum()}
+	n64 := &pb.FieldDescriptorProto{Name: proto.String("n"), Number: proto.Int32(2), Type: pb.FieldDescriptorProto_TYPE_INT64.Enum()}
+	r := Route{Path: "svc/get", Input: ".pkg.Req"}
+
+	base := HashRoute(r, msgs(id, n))
+	if got := HashRoute(r, msgs(n, id)); got != base {
+		t.Errorf("field order changed the hash: %s != %s", got, base)
+	}
+	if got := HashRoute(r, msgs(id, n64)); got == base {
+		t.Errorf("field type change kept the hash %s", got)
+	}
+	moved := r
+	moved.Path = "svc/fetch"
+	if got := HashRoute(moved, msgs(id, n)); got == base {
+		t.Errorf("path change kept the hash %s", got)
+	}
+}
+
+func TestRouteOfPath(t *testing.T) {
+	file := &pb.FileDescriptorProto{Package: proto.String("pkg")}
+	service := &pb.ServiceDescriptorProto{Name: proto.String("S")}
+	method := &pb.MethodDescriptorProto{Name: proto.String("B"), Options: &pb.MethodOptions{}}
+	// the path option next to other string options
+	if err := proto.SetExtension(method.Options, options.E_Path, proto.String("/custom/b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.SetExtension(method.Options, options.E_Transform, proto.String("view")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method = &pb.MethodDescriptorProto{}
+	if err := proto.Unmarshal(b, method); err != nil {
+		t.Fatal(err)
+	}
+	if r := RouteOf(file, service, method); r.Path != "/custom/b" {
+		t.Errorf("path = %q", r.Path)
+	}
+}
+
+func TestRouteOfHttpRule(t *testing.T) {
+	file := &pb.FileDescriptorProto{Package: proto.String("pkg")}
+	service := &pb.ServiceDescriptorProto{Name: proto.String("Users")}
+	method := &pb.MethodDescriptorProto{Name: proto.String("Get"), InputType: proto.String(".pkg.Req"), Options: &pb.MethodOptions{}}
+	if r := RouteOf(file, service, method); r.Verb != "" || r.Path != "users/get" {
+		t.Errorf("route without annotation = %+v", r)
+	}
+	if err := proto.SetExtension(method.Options, options.E_Http, &options.HttpRule{Patch: "/v1/Users/{user.id}", Body: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	// as the generator gets them from protoc
+	b, err := proto.Marshal(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method = &pb.MethodDescriptorProto{}
+	if err := proto.Unmarshal(b, method); err != nil {
+		t.Fatal(err)
+	}
+	r := RouteOf(file, service, method)
+	if r.Verb != "PATCH" || r.Path != "v1/Users/{user.id}" || r.Body != "user" {
+		t.Errorf("route with annotation = %+v", r)
+	}
+	if tmpl, err := r.Template(); err != nil || len(tmpl.Fields()) != 1 || tmpl.Fields()[0] != "user.id" {
+		t.Errorf("Template() = %v, %v", tmpl, err)
+	}
+}
+
+func TestMethodOptions(t *testing.T) {
+	if opts := MethodOptions(&pb.MethodOptions{}); opts != nil {
+		t.Errorf("options of a method without options = %v", opts)
+	}
+	opts := &pb.MethodOptions{Deprecated: proto.Bool(true), IdempotencyLevel: pb.MethodOptions_NO_SIDE_EFFECTS.Enum()}
+	for ext, v := range map[*proto.ExtensionDesc]interface{}{
+		options.E_TimeoutSeconds: proto.Uint32(5),
+		options.E_Transform:      proto.String("view"),
+		options.E_Middleware:     []string{"auth", "log"},
+		options.E_Http:           &options.HttpRule{Get: "/v1/users"},
+	} {
+		if err := proto.SetExtension(opts, ext, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// as the generator gets them from protoc
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = &pb.MethodOptions{}
+	if err := proto.Unmarshal(b, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"deprecated":            true,
+		"idempotency_level":     "NO_SIDE_EFFECTS",
+		"goweb.timeout_seconds": uint32(5),
+		"goweb.transform":       "view",
+		"goweb.middleware":      []string{"auth", "log"},
+	}
+	if got := MethodOptions(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("MethodOptions() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zenazn/goji/web"
+	"golang.org/x/net/context"
+)
+
+// A Router is the routing backend of the generated muxes, selected with
+// the router parameter: GojiRouter (goji, the default), StdRouter
+// (net/http) and the routers of the gowebchi (chi) and gowebmux
+// (gorilla/mux) packages. The generated code only routes through this
+// interface, so a new backend is an implementation of it plus an entry in
+// the routers table of the generator; its Mux field holds the mux that
+// New<Service>Mux returns.
+//
+// Patterns are paths, or paths ending in "/*" for every path below them.
+// Use must be called before the routes are added.
+type Router interface {
+	// Handle routes the requests for pattern to h, whatever their method.
+	Handle(pattern string, h http.Handler)
+
+	// Get routes the GET and HEAD requests for pattern to h.
+	Get(pattern string, h http.Handler)
+
+	// HandleTemplate routes the requests of verb ("GET" also matches
+	// HEAD) whose path below prefix matches t to h, with the variables of
+	// t as the PathParams of the request.
+	HandleTemplate(prefix, verb string, t *PathTemplate, h http.Handler)
+
+	// Use wraps the routes added afterwards in mw.
+	Use(mw func(http.Handler) http.Handler)
+}
+
+type pathParamsKey struct{}
+
+// WithPathParams returns a shallow copy of r carrying the variables of the
+// path template its route matched, by field path. Routers call it before
+// the handler of a HandleTemplate route.
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// PathParams returns the variables of the path template the route of r
+// matched, by field path, see BindPath; nil for routes without template.
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// TemplateRoutes dispatches requests to the first of its routes whose
+// verb and template match, or answers 404. Routers without patterns for
+// path templates mount it at the Mount paths of the routes, letting it
+// match them itself.
+type TemplateRoutes struct {
+	routes []templateRoute
+}
+
+type templateRoute struct {
+	templatePattern
+	h http.Handler
+}
+
+// Add adds the route of HandleTemplate to rs. It returns the pattern
+// under which requests for it can arrive: the path up to the first
+// variable of t, e.g. "/v1/users/*", or the whole path if t has none, and
+// whether rs has to be mounted there, as no previous route of rs has the
+// same pattern.
+func (rs *TemplateRoutes) Add(prefix, verb string, t *PathTemplate, h http.Handler) (pattern string, mount bool) {
+	p := templatePattern{strings.TrimSuffix(prefix, "/"), verb, t}
+	rs.routes = append(rs.routes, templateRoute{p, h})
+	pattern = p.Prefix()
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "*"
+	}
+	for _, r := range rs.routes[:len(rs.routes)-1] {
+		if r.Prefix() == p.Prefix() {
+			return pattern, false
+		}
+	}
+	return pattern, true
+}
+
+// Match reports whether a route of rs matches r.
+func (rs *TemplateRoutes) Match(r *http.Request) bool {
+	for _, route := range rs.routes {
+		if _, ok := route.match(r); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves r with the first matching route.
+func (rs *TemplateRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, route := range rs.routes {
+		if vars, ok := route.match(r); ok {
+			route.h.ServeHTTP(w, WithPathParams(r, vars))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// GojiRouter is the Router of goji, the default backend.
+type GojiRouter struct {
+	Mux *web.Mux
+}
+
+// NewGojiRouter returns the Router adding routes to m.
+func NewGojiRouter(m *web.Mux) *GojiRouter { return &GojiRouter{m} }
+
+func (r *GojiRouter) Handle(pattern string, h http.Handler)  { r.Mux.Handle(pattern, h) }
+func (r *GojiRouter) Get(pattern string, h http.Handler)     { r.Mux.Get(pattern, h) }
+func (r *GojiRouter) Use(mw func(http.Handler) http.Handler) { r.Mux.Use(mw) }
+
+func (r *GojiRouter) HandleTemplate(prefix, verb string, t *PathTemplate, h http.Handler) {
+	r.Mux.Handle(TemplatePattern(prefix, verb, t), func(c web.C, w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, WithPathParams(req, c.URLParams))
+	})
+}
+
+// StdRouter is the Router of an http.ServeMux, for servers without a
+// router package. Routes with templates are mounted like by the other
+// routers without patterns for them, see TemplateRoutes; the other routes
+// must not have the same pattern.
+type StdRouter struct {
+	Mux *http.ServeMux
+
+	mw        []func(http.Handler) http.Handler
+	templates *TemplateRoutes
+}
+
+// NewStdRouter returns the Router adding routes to m.
+func NewStdRouter(m *http.ServeMux) *StdRouter {
+	return &StdRouter{Mux: m, templates: &TemplateRoutes{}}
+}
+
+func (r *StdRouter) Handle(pattern string, h http.Handler) {
+	r.Mux.Handle(strings.TrimSuffix(pattern, "*"), r.wrap(h))
+}
+
+func (r *StdRouter) Get(pattern string, h http.Handler) {
+	r.Handle(pattern, OnlyGet(h))
+}
+
+func (r *StdRouter) HandleTemplate(prefix, verb string, t *PathTemplate, h http.Handler) {
+	if pattern, mount := r.templates.Add(prefix, verb, t, r.wrap(h)); mount {
+		r.Mux.Handle(strings.TrimSuffix(pattern, "*"), r.templates)
+	}
+}
+
+func (r *StdRouter) Use(mw func(http.Handler) http.Handler) { r.mw = append(r.mw, mw) }
+
+func (r *StdRouter) wrap(h http.Handler) http.Handler {
+	for i := len(r.mw) - 1; i >= 0; i-- {
+		h = r.mw[i](h)
+	}
+	return h
+}
+
+// OnlyGet answers the requests with other methods than GET and HEAD with
+// 405 and passes the others to h.
+func OnlyGet(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			w.WriteHeader(405)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,114 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestRouters(t *testing.T) {
+	gojiRouter := NewGojiRouter(web.New())
+	stdRouter := NewStdRouter(http.NewServeMux())
+	for name, tc := range map[string]struct {
+		r Router
+		h http.Handler
+	}{"goji": {gojiRouter, gojiRouter.Mux}, "stdlib": {stdRouter, stdRouter.Mux}} {
+		tc.r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Mw", "1")
+				next.ServeHTTP(w, r)
+			})
+		})
+		echo := func(s string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(s + PathParams(r)["user_id"] + PathParams(r)["name"]))
+			})
+		}
+		tc.r.HandleTemplate("/api/", "GET", MustParseTemplate("/v1/users/{user_id}"), echo("get "))
+		tc.r.HandleTemplate("/api/", "DELETE", MustParseTemplate("/v1/users/{user_id}"), echo("delete "))
+		tc.r.HandleTemplate("/api/", "POST", MustParseTemplate("/v1/{name=shelves/*}:publish"), echo("publish "))
+		tc.r.Handle("/api/pkg.Users/List", echo("list"))
+		tc.r.Get("/api/_routes", echo("routes"))
+		tc.r.Handle("/static/*", echo("static"))
+		for _, c := range []struct{ method, path, want string }{
+			{"GET", "/api/v1/users/42", "get 42"},
+			{"HEAD", "/api/v1/users/42", "get 42"},
+			{"DELETE", "/api/v1/users/42", "delete 42"},
+			{"PUT", "/api/v1/users/42", "404"},
+			{"POST", "/api/v1/shelves/1:publish", "publish shelves/1"},
+			{"POST", "/api/pkg.Users/List", "list"},
+			{"GET", "/api/_routes", "routes"},
+			{"POST", "/api/_routes", "405"}, // 404 with goji
+			{"GET", "/static/a/b.css", "static"},
+			{"GET", "/api/v2/x", "404"},
+		} {
+			w := httptest.NewRecorder()
+			tc.h.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+			got := w.Body.String()
+			if w.Code >= 400 {
+				got = strconv.Itoa(w.Code)
+			}
+			if got != c.want && !(name == "goji" && got == "404" && c.want == "405") {
+				t.Errorf("%s: %s %s = %d %q, want %q", name, c.method, c.path, w.Code, got, c.want)
+			}
+			if w.Code < 400 && w.Header().Get("X-Mw") != "1" {
+				t.Errorf("%s: %s %s passed the middleware by", name, c.method, c.path)
+			}
+		}
+	}
+}
+
+func TestTemplateRoutes(t *testing.T) {
+	rs := &TemplateRoutes{}
+	for _, tc := range []struct {
+		template, pattern string
+		mount             bool
+	}{
+		{"/v1/users/{user_id}", "/api/v1/users/*", true},
+		{"/v1/users/{user_id}:undelete", "/api/v1/users/*", false},
+		{"/v1/users", "/api/v1/users", true},
+		{"/{name=**}", "/api/*", true},
+	} {
+		pattern, mount := rs.Add("/api/", "GET", MustParseTemplate(tc.template), http.NotFoundHandler())
+		if pattern != tc.pattern || mount != tc.mount {
+			t.Errorf("Add(%s) = %s, %v, want %s, %v", tc.template, pattern, mount, tc.pattern, tc.mount)
+		}
+	}
+	if !rs.Match(httptest.NewRequest("GET", "/api/x/y", nil)) || rs.Match(httptest.NewRequest("POST", "/api/x", nil)) {
+		t.Errorf("Match does not follow the routes")
+	}
+}
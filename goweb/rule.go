@@ -0,0 +1,930 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// A Rule is a compiled authorization rule of a method, an expression in
+// the subset of CEL over the request and the claims of the caller, e.g.
+//
+//	request.owner_id == claims.sub || "admin" in claims.roles
+//
+// request holds the fields of the request by their proto names and
+// claims those of the principal (see WithPrincipal): a proto message, a
+// map[string]interface{}, a map[string]string or a value that encodes as
+// a JSON object. The subset has the literals null, true, false, numbers
+// and strings in single or double quotes, lists ([1, 2]), field and
+// index selection (a.b, a["b"], a[0]), the operators !, -, *, /, %, +,
+// ==, !=, <, <=, >, >=, in, && and ||, and the functions has(a.b) and
+// size(a). Integers stay exact int64 or uint64 values, whether they
+// come from fields or literals, and are compared exactly, with each
+// other and with doubles; the quotient of integers that do not divide is
+// a double.
+type Rule struct {
+	expr string
+	root ruleNode
+}
+
+// CompileRule compiles the rule expr.
+func CompileRule(expr string) (*Rule, error) {
+	p := &ruleParser{expr: expr}
+	p.next()
+	root, err := p.parseExpr()
+	if err == nil && p.tok != "" {
+		err = p.errorf("unexpected %q", p.tok)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("goweb: rule %q: %v", expr, err)
+	}
+	return &Rule{expr, root}, nil
+}
+
+// MustCompileRule is CompileRule for rules known to be valid, such as
+// those of the generated muxes, which the generator has compiled; it
+// panics on errors.
+func MustCompileRule(expr string) *Rule {
+	r, err := CompileRule(expr)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Rule) String() string { return r.expr }
+
+// Eval evaluates the rule for the request in and the principal of ctx.
+func (r *Rule) Eval(ctx context.Context, in proto.Message) (bool, error) {
+	env := map[string]interface{}{"request": ruleValue(reflect.ValueOf(in))}
+	if p := PrincipalFrom(ctx); p != nil {
+		env["claims"] = claimsValue(p)
+	}
+	v, err := r.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("result %v is not a bool", v)
+	}
+	return b, nil
+}
+
+// Authorize evaluates the rule for the request in; it implements
+// Authorizer, for the methods with an authorize option.
+func (r *Rule) Authorize(ctx context.Context, method string, in proto.Message) (bool, error) {
+	return r.Eval(ctx, in)
+}
+
+func claimsValue(p interface{}) interface{} {
+	switch p := p.(type) {
+	case proto.Message:
+		return ruleValue(reflect.ValueOf(p))
+	case map[string]interface{}:
+		return ruleValue(reflect.ValueOf(p))
+	case map[string]string:
+		m := make(map[string]interface{}, len(p))
+		for k, v := range p {
+			m[k] = v
+		}
+		return m
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if d.Decode(&v) != nil {
+		return nil
+	}
+	return ruleValue(reflect.ValueOf(v))
+}
+
+// ruleValue converts v into the values rules operate on: nil, bool,
+// int64, uint64, float64, string, []interface{} and map[string]interface{}, with
+// messages keyed by the proto names of their fields.
+func ruleValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return ruleValue(v.Elem())
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		if v.Type() == reflect.TypeOf(json.Number("")) {
+			return jsonNumber(json.Number(v.String()))
+		}
+		return v.String()
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i] = ruleValue(v.Index(i))
+		}
+		return l
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[fmt.Sprint(k.Interface())] = ruleValue(v.MapIndex(k))
+		}
+		return m
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.Tag.Get("protobuf_oneof") != "" {
+				if f := v.Field(i); !f.IsNil() {
+					w := f.Elem().Elem()
+					m[protoName(w.Type().Field(0).Tag.Get("protobuf"))] = ruleValue(w.Field(0))
+				}
+				continue
+			}
+			if tag := sf.Tag.Get("protobuf"); tag != "" && !strings.HasPrefix(sf.Name, "XXX_") {
+				m[protoName(tag)] = ruleValue(v.Field(i))
+			}
+		}
+		return m
+	}
+	return nil
+}
+
+// jsonNumber returns the number n of JSON claims as an int64, a uint64
+// or else a float64, so that integers stay exact.
+func jsonNumber(n json.Number) interface{} {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// protoName returns the name in the protobuf struct tag tag.
+func protoName(tag string) string {
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "name=") {
+			return part[5:]
+		}
+	}
+	return ""
+}
+
+// A ruleNode is a node of the syntax tree of a rule.
+type ruleNode interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+type (
+	ruleLiteral  struct{ v interface{} }
+	ruleIdent    struct{ name string }
+	ruleList     struct{ items []ruleNode }
+	ruleSelector struct {
+		x     ruleNode
+		field string
+	}
+	ruleIndex struct{ x, i ruleNode }
+	ruleUnary struct {
+		op string
+		x  ruleNode
+	}
+	ruleBinary struct {
+		op   string
+		x, y ruleNode
+	}
+	ruleCall struct {
+		fn  string
+		arg ruleNode
+	}
+)
+
+func (n ruleLiteral) eval(map[string]interface{}) (interface{}, error) { return n.v, nil }
+
+func (n ruleIdent) eval(env map[string]interface{}) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("no %s", n.name)
+	}
+	return v, nil
+}
+
+func (n ruleList) eval(env map[string]interface{}) (interface{}, error) {
+	l := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = v
+	}
+	return l, nil
+}
+
+func (n ruleSelector) eval(env map[string]interface{}) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no field %s in %v", n.field, x)
+	}
+	v, ok := m[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such field %s", n.field)
+	}
+	return v, nil
+}
+
+func (n ruleIndex) eval(env map[string]interface{}) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	i, err := n.i.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case map[string]interface{}:
+		if k, ok := i.(string); ok {
+			if v, ok := x[k]; ok {
+				return v, nil
+			}
+			return nil, fmt.Errorf("no such key %q", k)
+		}
+	case []interface{}:
+		if j, ok := ruleIndexOf(i); ok && j < len(x) {
+			return x[j], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid index %v of %v", i, x)
+}
+
+func (n ruleUnary) eval(env map[string]interface{}) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case bool:
+		if n.op == "!" {
+			return !x, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -x, nil
+		}
+	case int64:
+		if n.op == "-" && x != math.MinInt64 {
+			return -x, nil
+		}
+	case uint64:
+		if n.op == "-" && x <= 1<<63 {
+			return int64(-x), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operand of %s: %v", n.op, x)
+}
+
+func (n ruleBinary) eval(env map[string]interface{}) (interface{}, error) {
+	if n.op == "&&" || n.op == "||" {
+		return n.logical(env)
+	}
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return ruleEqual(x, y), nil
+	case "!=":
+		return !ruleEqual(x, y), nil
+	case "in":
+		switch y := y.(type) {
+		case []interface{}:
+			for _, v := range y {
+				if ruleEqual(x, v) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			if k, ok := x.(string); ok {
+				_, ok = y[k]
+				return ok, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid operand of in: %v", y)
+	}
+	if a, ok := x.(string); ok {
+		if b, ok := y.(string); ok {
+			switch n.op {
+			case "+":
+				return a + b, nil
+			case "<":
+				return a < b, nil
+			case "<=":
+				return a <= b, nil
+			case ">":
+				return a > b, nil
+			case ">=":
+				return a >= b, nil
+			}
+		}
+	}
+	if a, b, ok := intOperands(x, y); ok {
+		return intArith(n.op, a, b)
+	}
+	if a, b, ok := uintOperands(x, y); ok {
+		return uintArith(n.op, a, b)
+	}
+	if c, ok := compareNumbers(x, y); ok && isComparison(n.op) {
+		return compared(n.op, c), nil
+	}
+	a, ok1 := toFloat(x)
+	b, ok2 := toFloat(y)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("invalid operands of %s: %v, %v", n.op, x, y)
+	}
+	if isComparison(n.op) { // with NaN
+		return false, nil
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	default: // "%"
+		if int64(b) == 0 {
+			return nil, fmt.Errorf("modulus by zero")
+		}
+		return float64(int64(a) % int64(b)), nil
+	}
+}
+
+func isComparison(op string) bool {
+	return op == "<" || op == "<=" || op == ">" || op == ">="
+}
+
+// compared returns the result of the comparison op of two values whose
+// order is c, as returned by compareNumbers.
+func compared(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default: // ">="
+		return c >= 0
+	}
+}
+
+// ruleNumber returns the number x of a rule as a big.Float, exactly.
+func ruleNumber(x interface{}) (*big.Float, bool) {
+	switch x := x.(type) {
+	case int64:
+		return new(big.Float).SetInt64(x), true
+	case uint64:
+		return new(big.Float).SetUint64(x), true
+	case float64:
+		if math.IsNaN(x) {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(x), true
+	}
+	return nil, false
+}
+
+// compareNumbers returns -1, 0 or 1 as the number x is less than, equal
+// to or greater than y, without losing precision, or false if either is
+// not a number or is NaN.
+func compareNumbers(x, y interface{}) (int, bool) {
+	a, ok1 := ruleNumber(x)
+	b, ok2 := ruleNumber(y)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return a.Cmp(b), true
+}
+
+// ruleEqual reports whether the values x and y of a rule are equal, with
+// numbers equal by value whatever their types.
+func ruleEqual(x, y interface{}) bool {
+	if c, ok := compareNumbers(x, y); ok {
+		return c == 0
+	}
+	switch x := x.(type) {
+	case []interface{}:
+		y, ok := y.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !ruleEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := y.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !ruleEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func toFloat(x interface{}) (float64, bool) {
+	switch x := x.(type) {
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// intOperands returns x and y as int64 values if both are integers that
+// fit.
+func intOperands(x, y interface{}) (int64, int64, bool) {
+	a, ok1 := toInt(x)
+	b, ok2 := toInt(y)
+	return a, b, ok1 && ok2
+}
+
+func toInt(x interface{}) (int64, bool) {
+	switch x := x.(type) {
+	case int64:
+		return x, true
+	case uint64:
+		return int64(x), x <= math.MaxInt64
+	}
+	return 0, false
+}
+
+// uintOperands returns x and y as uint64 values if both are integers
+// that fit.
+func uintOperands(x, y interface{}) (uint64, uint64, bool) {
+	a, ok1 := toUint(x)
+	b, ok2 := toUint(y)
+	return a, b, ok1 && ok2
+}
+
+func toUint(x interface{}) (uint64, bool) {
+	switch x := x.(type) {
+	case int64:
+		return uint64(x), x >= 0
+	case uint64:
+		return x, true
+	}
+	return 0, false
+}
+
+func intArith(op string, a, b int64) (interface{}, error) {
+	switch op {
+	case "+":
+		if c := a + b; (c > a) == (b > 0) {
+			return c, nil
+		}
+	case "-":
+		if c := a - b; (c < a) == (b > 0) {
+			return c, nil
+		}
+	case "*":
+		if a == 0 || b == 0 {
+			return int64(0), nil
+		}
+		if c := a * b; c/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+			return c, nil
+		}
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if a%b == 0 && !(a == math.MinInt64 && b == -1) {
+			return a / b, nil
+		}
+		return float64(a) / float64(b), nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("modulus by zero")
+		}
+		if b == -1 {
+			return int64(0), nil
+		}
+		return a % b, nil
+	default:
+		return compared(op, cmpInt(a, b)), nil
+	}
+	return nil, fmt.Errorf("integer overflow in %d %s %d", a, op, b)
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func uintArith(op string, a, b uint64) (interface{}, error) {
+	switch op {
+	case "+":
+		if c := a + b; c >= a {
+			return c, nil
+		}
+	case "-":
+		if a >= b {
+			return a - b, nil
+		}
+		if b-a <= 1<<63 {
+			return -int64(b - a), nil
+		}
+	case "*":
+		if a == 0 || b == 0 {
+			return uint64(0), nil
+		}
+		if c := a * b; c/b == a {
+			return c, nil
+		}
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if a%b == 0 {
+			return a / b, nil
+		}
+		return float64(a) / float64(b), nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("modulus by zero")
+		}
+		return a % b, nil
+	default:
+		c := 0
+		if a < b {
+			c = -1
+		} else if a > b {
+			c = 1
+		}
+		return compared(op, c), nil
+	}
+	return nil, fmt.Errorf("integer overflow in %d %s %d", a, op, b)
+}
+
+// ruleIndexOf returns the list index i, an integer or an integral double.
+func ruleIndexOf(i interface{}) (int, bool) {
+	switch i := i.(type) {
+	case int64:
+		return int(i), i >= 0 && i <= math.MaxInt32
+	case uint64:
+		return int(i), i <= math.MaxInt32
+	case float64:
+		return int(i), i >= 0 && i <= math.MaxInt32 && i == math.Trunc(i)
+	}
+	return 0, false
+}
+
+// logical evaluates && and || like CEL: an error of one operand is
+// ignored if the other one decides the result.
+func (n ruleBinary) logical(env map[string]interface{}) (interface{}, error) {
+	decides := n.op == "||"
+	x, errx := n.x.eval(env)
+	if b, ok := x.(bool); errx == nil && ok && b == decides {
+		return decides, nil
+	}
+	y, erry := n.y.eval(env)
+	if b, ok := y.(bool); erry == nil && ok && b == decides {
+		return decides, nil
+	}
+	if errx != nil {
+		return nil, errx
+	}
+	if erry != nil {
+		return nil, erry
+	}
+	if _, ok := x.(bool); !ok {
+		return nil, fmt.Errorf("invalid operand of %s: %v", n.op, x)
+	}
+	if _, ok := y.(bool); !ok {
+		return nil, fmt.Errorf("invalid operand of %s: %v", n.op, y)
+	}
+	return !decides, nil
+}
+
+func (n ruleCall) eval(env map[string]interface{}) (interface{}, error) {
+	if n.fn == "has" {
+		s := n.arg.(ruleSelector)
+		x, err := s.x.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("has(%s) of %v", s.field, x)
+		}
+		v, ok := m[s.field]
+		return ok && v != nil && !reflect.ValueOf(v).IsZero(), nil
+	}
+	x, err := n.arg.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case string:
+		return int64(len([]rune(x))), nil
+	case []interface{}:
+		return int64(len(x)), nil
+	case map[string]interface{}:
+		return int64(len(x)), nil
+	}
+	return nil, fmt.Errorf("size(%v)", x)
+}
+
+// ruleParser is a recursive descent parser of rules; tok is the current
+// token and "" at the end.
+type ruleParser struct {
+	expr string
+	pos  int
+	tok  string
+}
+
+func (p *ruleParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// next scans the next token.
+func (p *ruleParser) next() {
+	s := p.expr
+	for p.pos < len(s) && strings.IndexByte(" \t\r\n", s[p.pos]) >= 0 {
+		p.pos++
+	}
+	start := p.pos
+	switch {
+	case p.pos == len(s):
+	case isIdentByte(s[p.pos], true):
+		for p.pos < len(s) && isIdentByte(s[p.pos], false) {
+			p.pos++
+		}
+	case s[p.pos] >= '0' && s[p.pos] <= '9':
+		for p.pos < len(s) && (s[p.pos] >= '0' && s[p.pos] <= '9' || s[p.pos] == '.') {
+			p.pos++
+		}
+	case s[p.pos] == '"' || s[p.pos] == '\'':
+		q := s[p.pos]
+		for p.pos++; p.pos < len(s) && s[p.pos] != q; p.pos++ {
+			if s[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		p.pos++
+		if p.pos > len(s) {
+			p.pos = len(s)
+		}
+	default:
+		for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||"} {
+			if strings.HasPrefix(s[p.pos:], op) {
+				p.pos += 2
+				p.tok = op
+				return
+			}
+		}
+		p.pos++
+	}
+	p.tok = s[start:p.pos]
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+func (p *ruleParser) expect(tok string) error {
+	if p.tok != tok {
+		return p.errorf("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+var rulePrecedence = map[string]int{
+	"||": 1, "&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3, "in": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+func (p *ruleParser) parseExpr() (ruleNode, error) { return p.parseBinary(1) }
+
+func (p *ruleParser) parseBinary(prec int) (ruleNode, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.tok
+		opPrec, ok := rulePrecedence[op]
+		if !ok || opPrec < prec {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseBinary(opPrec + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = ruleBinary{op, x, y}
+	}
+}
+
+func (p *ruleParser) parseUnary() (ruleNode, error) {
+	if op := p.tok; op == "!" || op == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return ruleUnary{op, x}, nil
+	}
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.tok {
+		case ".":
+			p.next()
+			if p.tok == "" || !isIdentByte(p.tok[0], true) {
+				return nil, p.errorf("expected field name, found %q", p.tok)
+			}
+			x = ruleSelector{x, p.tok}
+			p.next()
+		case "[":
+			p.next()
+			i, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = ruleIndex{x, i}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, p.errorf("unexpected end")
+	case tok == "(":
+		p.next()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case tok == "[":
+		var l ruleList
+		for p.next(); p.tok != "]"; {
+			item, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, item)
+			if p.tok != "," {
+				break
+			}
+			p.next()
+		}
+		return l, p.expect("]")
+	case tok[0] == '"' || tok[0] == '\'':
+		if len(tok) < 2 || tok[len(tok)-1] != tok[0] {
+			return nil, p.errorf("unterminated string")
+		}
+		s := tok[1 : len(tok)-1]
+		if tok[0] == '\'' {
+			s = strings.Replace(strings.Replace(s, `\'`, `'`, -1), `"`, `\"`, -1)
+		}
+		v, err := strconv.Unquote(`"` + s + `"`)
+		if err != nil {
+			return nil, p.errorf("invalid string %s", tok)
+		}
+		p.next()
+		return ruleLiteral{v}, nil
+	case tok[0] >= '0' && tok[0] <= '9':
+		var v interface{}
+		if i, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			v = i
+		} else if u, err := strconv.ParseUint(tok, 10, 64); err == nil {
+			v = u
+		} else if f, err := strconv.ParseFloat(tok, 64); err == nil {
+			v = f
+		} else {
+			return nil, p.errorf("invalid number %s", tok)
+		}
+		p.next()
+		return ruleLiteral{v}, nil
+	case isIdentByte(tok[0], true):
+		p.next()
+		switch tok {
+		case "true", "false":
+			return ruleLiteral{tok == "true"}, nil
+		case "null":
+			return ruleLiteral{nil}, nil
+		case "has", "size":
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := arg.(ruleSelector); tok == "has" && !ok {
+				return nil, p.errorf("argument of has is not a field selection")
+			}
+			return ruleCall{tok, arg}, p.expect(")")
+		}
+		if tok != "request" && tok != "claims" {
+			return nil, p.errorf("undefined %s", tok)
+		}
+		return ruleIdent{tok}, nil
+	}
+	return nil, p.errorf("unexpected %q", tok)
+}
@@ -0,0 +1,108 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRule(t *testing.T) {
+	in := &CapturedCall{Method: "/pkg.Users/Get", Path: "users/get", Request: []byte("alice"), TimeUnixNano: 42}
+	claims := map[string]interface{}{"sub": "alice", "roles": []interface{}{"admin", "dev"}, "level": 3.0}
+	ctx := WithPrincipal(context.Background(), claims)
+	for expr, want := range map[string]bool{
+		`request.request == claims.sub`:                            true,
+		`request.method == '/pkg.Users/Get' && request.path != ""`: true,
+		`"admin" in claims.roles`:                                  true,
+		`'ops' in claims.roles || claims.level >= 3`:               true,
+		`!has(request.error) && has(request.path)`:                 true,
+		`size(claims.roles) == 2 && claims.roles[1] == "dev"`:      true,
+		`request.time_unix_nano % 10 == 2 && -claims.level < 0`:    true,
+		`claims["sub"] + "!" == "alice!"`:                          true,
+		`request.error in ["", "x"]`:                               true,
+		`claims.missing == 1 || true`:                              true,
+		`claims.level * 2 > 6`:                                     false,
+		`claims.missing == 1`:                                      false,
+	} {
+		r, err := CompileRule(expr)
+		if err != nil {
+			t.Errorf("CompileRule(%q): %v", expr, err)
+			continue
+		}
+		if got, _ := r.Eval(ctx, in); got != want {
+			t.Errorf("%s = %v, want %v", expr, got, want)
+		}
+	}
+	r := MustCompileRule(`request.request == claims.sub`)
+	// integers beyond 2^53 stay exact
+	big := map[string]interface{}{"id": int64(1<<53 + 1), "other": int64(1 << 53), "max": uint64(1<<64 - 1)}
+	for expr, want := range map[string]bool{
+		`claims.id == claims.other`:            false,
+		`claims.id > claims.other`:             true,
+		`claims.id == 9007199254740993`:        true,
+		`claims.id == 9007199254740992.0`:      false,
+		`claims.max > claims.id`:               true,
+		`claims.max == 18446744073709551615`:   true,
+		`claims.id - claims.other == 1`:        true,
+		`claims.other / 2 == 4503599627370496`: true,
+		`3 / 2 == 1.5 && 4 / 2 == 2`:           true,
+		`claims.id in [9007199254740993]`:      true,
+		`1 == 1.0`:                             true,
+	} {
+		ok, err := MustCompileRule(expr).Eval(WithPrincipal(context.Background(), big), in)
+		if err != nil || ok != want {
+			t.Errorf("%s = %v, %v, want %v", expr, ok, err, want)
+		}
+	}
+	ok, err := MustCompileRule(`claims.id == 9007199254740993`).Eval(WithPrincipal(context.Background(), json.RawMessage(`{"id": 9007199254740993}`)), in)
+	if !ok || err != nil {
+		t.Errorf("JSON claims: %v, %v", ok, err)
+	}
+	if _, err := MustCompileRule(`claims.max + 1 > 0`).Eval(WithPrincipal(context.Background(), big), in); err == nil {
+		t.Error("no overflow error")
+	}
+
+	if err := Authorize(context.Background(), r, "/pkg.Users/Get", in); err == nil || err.(*Error).Status != 403 {
+		t.Errorf("Authorize without claims = %v", err)
+	}
+	if err := Authorize(ctx, r, "/pkg.Users/Get", in); err != nil {
+		t.Errorf("Authorize = %v", err)
+	}
+	for _, expr := range []string{``, `request.`, `user.id == 1`, `(request.a`, `has(1)`, `"abc`, `request.a ==`, `1 2`} {
+		if _, err := CompileRule(expr); err == nil {
+			t.Errorf("CompileRule(%q) succeeded", expr)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/context"
+)
+
+// DefaultShutdownTimeout bounds the time a Runner waits for the calls in
+// progress when it stops.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// A Runner serves a handler, e.g. a generated mux, until its context is
+// done, and then shuts the server down gracefully. The runner parameter
+// generates Run<Service>, which serves a service with one.
+//
+// With ACMEHosts it serves TLS with certificates it obtains from an ACME
+// certificate authority, Let's Encrypt by default, for these hosts only,
+// so small services need no other tooling for https.
+type Runner struct {
+	// Addr is the address to listen on; ":8080" by default, ":443" with
+	// ACMEHosts.
+	Addr string
+
+	// Listener, if set, is served instead of listening on Addr.
+	Listener net.Listener
+
+	// ShutdownTimeout bounds the graceful shutdown;
+	// DefaultShutdownTimeout if 0.
+	ShutdownTimeout time.Duration
+
+	// ACMEHosts are the host names to obtain certificates for. Requests
+	// for other hosts fail the TLS handshake.
+	ACMEHosts []string
+
+	// ACMECacheDir is the directory keeping the certificates and the
+	// account key across restarts, which the rate limits of Let's Encrypt
+	// need; it must be set with ACMEHosts.
+	ACMECacheDir string
+
+	// ACMEEmail is the contact address of the account, for notices about
+	// expiring certificates; optional.
+	ACMEEmail string
+
+	// ACMEDirectoryURL is the directory of the certificate authority;
+	// Let's Encrypt production if empty.
+	ACMEDirectoryURL string
+
+	// ACMEHTTPAddr is the address answering the http-01 challenges and
+	// redirecting other http requests to https; ":80" if empty, "-" to
+	// not listen (tls-alpn-01 challenges are answered on Addr anyway).
+	ACMEHTTPAddr string
+}
+
+// ErrNoACMECache is returned by Runner.Run for ACMEHosts without
+// ACMECacheDir.
+var ErrNoACMECache = errors.New("goweb: Runner.ACMEHosts need an ACMECacheDir")
+
+// certManager returns the autocert manager of r, or nil without ACMEHosts.
+func (r *Runner) certManager() (*autocert.Manager, error) {
+	if len(r.ACMEHosts) == 0 {
+		return nil, nil
+	}
+	if r.ACMECacheDir == "" {
+		return nil, ErrNoACMECache
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(r.ACMEHosts...),
+		Cache:      autocert.DirCache(r.ACMECacheDir),
+		Email:      r.ACMEEmail,
+		Client:     &acme.Client{DirectoryURL: r.ACMEDirectoryURL},
+	}, nil
+}
+
+// Run serves h with the timeouts, e.g. the <Service>ServerTimeouts of a
+// generated service, until ctx is done or the server fails. It returns
+// nil after a graceful shutdown.
+func (r *Runner) Run(ctx context.Context, h http.Handler, timeouts ServerTimeouts) error {
+	m, err := r.certManager()
+	if err != nil {
+		return err
+	}
+	addr := r.Addr
+	if addr == "" {
+		addr = ":8080"
+		if m != nil {
+			addr = ":443"
+		}
+	}
+	ln := r.Listener
+	if ln == nil {
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+	srv := &http.Server{Handler: h}
+	timeouts.Apply(srv)
+	servers := []*http.Server{srv}
+	errs := make(chan error, 2)
+	if m == nil {
+		go func() { errs <- srv.Serve(ln) }()
+	} else {
+		srv.TLSConfig = m.TLSConfig()
+		go func() { errs <- srv.ServeTLS(ln, "", "") }()
+		if r.ACMEHTTPAddr != "-" {
+			httpAddr := r.ACMEHTTPAddr
+			if httpAddr == "" {
+				httpAddr = ":80"
+			}
+			challenges := &http.Server{Addr: httpAddr, Handler: m.HTTPHandler(nil), ReadHeaderTimeout: DefaultReadHeaderTimeout}
+			servers = append(servers, challenges)
+			go func() { errs <- challenges.ListenAndServe() }()
+		}
+	}
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+	wait := r.ShutdownTimeout
+	if wait == 0 {
+		wait = DefaultShutdownTimeout
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	for _, s := range servers {
+		if e := s.Shutdown(shutdown); err == nil || err == http.ErrServerClosed {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A Sample is a recorded call.
+type Sample struct {
+	Route    Route           `json:"route"`
+	Time     time.Time       `json:"time"`
+	Duration time.Duration   `json:"duration"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// A SampleSink stores the samples taken by a Sampler. Store is called
+// synchronously after the sampled call, so it should not block.
+type SampleSink interface {
+	Store(ctx context.Context, s *Sample)
+}
+
+// SampleSinkFunc adapts a function to a SampleSink.
+type SampleSinkFunc func(ctx context.Context, s *Sample)
+
+// Store calls f(ctx, s).
+func (f SampleSinkFunc) Store(ctx context.Context, s *Sample) { f(ctx, s) }
+
+// A Sampler records a percentage of the calls of each method, with their
+// request and response, for debugging in production. Its Intercept method
+// is an Interceptor for the generated Wrap<Service>Server functions.
+// Rates can be changed at any time; no method is sampled by default.
+// Samples hold the responses of the implementation before fields marked
+// with goweb.redact are cleared, so the sink must keep them safe.
+type Sampler struct {
+	Sink SampleSink
+
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+// SetRate sets the percentage (0 to 100) of the calls of method that are
+// sampled. The method is given by name ("GetUser"), by full name
+// ("/pkg.Users/GetUser") or as "*" for all methods without a rate of their
+// own.
+func (s *Sampler) SetRate(method string, percent float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rates == nil {
+		s.rates = make(map[string]float64)
+	}
+	s.rates[method] = percent
+}
+
+func (s *Sampler) rate(route Route) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range []string{route.FullMethod(), route.Method, "*"} {
+		if r, ok := s.rates[key]; ok {
+			return r
+		}
+	}
+	return 0
+}
+
+// Intercept samples the call according to the rate of its method.
+func (s *Sampler) Intercept(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+	if r := s.rate(route); r <= 0 || rand.Float64()*100 >= r {
+		return next(ctx, in)
+	}
+	sample := &Sample{Route: route, Time: time.Now()}
+	sample.Request, _ = json.Marshal(in)
+	out, err := next(ctx, in)
+	sample.Duration = time.Since(sample.Time)
+	if err != nil {
+		sample.Error = err.Error()
+	} else {
+		sample.Response, _ = json.Marshal(out)
+	}
+	s.Sink.Store(ctx, sample)
+	return out, err
+}
@@ -0,0 +1,67 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestSampler(t *testing.T) {
+	var samples []*Sample
+	s := &Sampler{Sink: SampleSinkFunc(func(ctx context.Context, s *Sample) { samples = append(samples, s) })}
+	get := Route{Service: "pkg.Users", Method: "Get"}
+	list := Route{Service: "pkg.Users", Method: "List"}
+	echo := func(ctx context.Context, in interface{}) (interface{}, error) { return in, nil }
+	call := func(r Route) {
+		if out, err := s.Intercept(context.Background(), r, map[string]int{"n": 1}, echo); err != nil || out == nil {
+			t.Fatalf("Intercept = %v, %v", out, err)
+		}
+	}
+
+	call(get)
+	if len(samples) != 0 {
+		t.Fatalf("sampled without a rate: %v", samples)
+	}
+	s.SetRate("/pkg.Users/Get", 100)
+	call(get)
+	call(list)
+	if len(samples) != 1 || samples[0].Route.Method != "Get" || string(samples[0].Response) != `{"n":1}` {
+		t.Fatalf("samples = %+v", samples)
+	}
+	s.SetRate("*", 100)
+	call(list)
+	if len(samples) != 2 {
+		t.Fatalf("%d samples, want 2", len(samples))
+	}
+}
@@ -0,0 +1,371 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A Session is a login of a browser, identified by the id in its
+// session cookie.
+type Session struct {
+	ID        string
+	Principal interface{} // the caller, see WithPrincipal
+	Created   time.Time   // when the id was issued; rotation renews it
+	Expires   time.Time
+}
+
+// A SessionStore keeps sessions, e.g. in memory or a shared database.
+type SessionStore interface {
+	// Load returns the session with id, or nil if there is none.
+	Load(ctx context.Context, id string) (*Session, error)
+	Save(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, id string) error
+}
+
+// A MemorySessionStore is a SessionStore in the memory of the process,
+// for a single server. Save drops the expired sessions at most once per
+// minute, so abandoned sessions do not pile up.
+type MemorySessionStore struct {
+	mu    sync.Mutex
+	m     map[string]Session
+	sweep time.Time // of the next sweep
+}
+
+// NewMemorySessionStore returns an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{m: map[string]Session{}}
+}
+
+func (st *MemorySessionStore) Load(ctx context.Context, id string) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.m[id]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(s.Expires) {
+		delete(st.m, id)
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (st *MemorySessionStore) Save(ctx context.Context, s *Session) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if now := time.Now(); now.After(st.sweep) {
+		for id, s := range st.m {
+			if now.After(s.Expires) {
+				delete(st.m, id)
+			}
+		}
+		st.sweep = now.Add(time.Minute)
+	}
+	st.m[s.ID] = *s
+	return nil
+}
+
+func (st *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.m, id)
+	return nil
+}
+
+// A SessionManager issues, validates, rotates and revokes the session
+// cookies of browser-facing services. Its cookies are HttpOnly, Secure
+// unless Insecure is set, and SameSite=Lax unless SameSite is set.
+type SessionManager struct {
+	Store SessionStore
+
+	// CookieName is the name of the cookie, if empty "__Host-session",
+	// or "__Secure-session" with a Domain or a Path other than "/",
+	// which the __Host- prefix does not allow, or "session" if Insecure.
+	CookieName string
+	Path       string // "/" if empty
+	Domain     string
+	SameSite   http.SameSite
+	Insecure   bool // allow plain http, for development only
+
+	// MaxAge is the lifetime of a session, 24 hours if 0. RotateAfter,
+	// if positive, is the age of a session id after which it is
+	// replaced with a new one on the next request, which limits the use
+	// of stolen cookies.
+	MaxAge      time.Duration
+	RotateAfter time.Duration
+
+	// RotationGrace is how long the replaced id stays valid after a
+	// rotation, for concurrent requests that still carry it; 30 seconds
+	// if 0, none if negative.
+	RotationGrace time.Duration
+}
+
+// Sessions is the SessionManager of the session options of the
+// generated muxes; while it is nil, their calls fail.
+var Sessions *SessionManager
+
+func (m *SessionManager) cookieName() string {
+	switch {
+	case m.CookieName != "":
+		return m.CookieName
+	case m.Insecure:
+		return "session"
+	case m.Domain != "" || m.Path != "" && m.Path != "/":
+		return "__Secure-session"
+	}
+	return "__Host-session"
+}
+
+func (m *SessionManager) setCookie(w http.ResponseWriter, s *Session) {
+	c := &http.Cookie{
+		Name:     m.cookieName(),
+		Value:    s.ID,
+		Path:     m.Path,
+		Domain:   m.Domain,
+		Secure:   !m.Insecure,
+		HttpOnly: true,
+		SameSite: m.SameSite,
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if s.ID == "" {
+		c.MaxAge = -1
+	} else {
+		c.Expires = s.Expires
+		c.MaxAge = int(time.Until(s.Expires) / time.Second)
+	}
+	http.SetCookie(w, c)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Issue starts a session of principal and sets its cookie on w.
+func (m *SessionManager) Issue(ctx context.Context, w http.ResponseWriter, principal interface{}) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	maxAge := m.MaxAge
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	now := time.Now()
+	s := &Session{ID: id, Principal: principal, Created: now, Expires: now.Add(maxAge)}
+	if err := m.Store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	m.setCookie(w, s)
+	return s, nil
+}
+
+// Load returns the session of the cookie of r, or nil if r has no valid
+// session, rotating its id if it is due.
+func (m *SessionManager) Load(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Session, error) {
+	c, err := r.Cookie(m.cookieName())
+	if err != nil || c.Value == "" {
+		return nil, nil
+	}
+	s, err := m.Store.Load(ctx, c.Value)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	if time.Now().After(s.Expires) {
+		m.Store.Delete(ctx, s.ID)
+		return nil, nil
+	}
+	if m.RotateAfter > 0 && time.Since(s.Created) > m.RotateAfter {
+		id, err := newSessionID()
+		if err != nil {
+			return nil, err
+		}
+		rotated := *s
+		rotated.ID, rotated.Created = id, time.Now()
+		if err := m.Store.Save(ctx, &rotated); err != nil {
+			return nil, err
+		}
+		if err := m.retire(ctx, s); err != nil {
+			return nil, err
+		}
+		m.setCookie(w, &rotated)
+		s = &rotated
+	}
+	return s, nil
+}
+
+// retire keeps the rotated session s for RotationGrace, renewed so that
+// it is not rotated again, or deletes it.
+func (m *SessionManager) retire(ctx context.Context, s *Session) error {
+	grace := m.RotationGrace
+	if grace == 0 {
+		grace = 30 * time.Second
+	}
+	if grace < 0 {
+		return m.Store.Delete(ctx, s.ID)
+	}
+	old := *s
+	old.Created = time.Now()
+	if expires := old.Created.Add(grace); expires.Before(old.Expires) {
+		old.Expires = expires
+	}
+	return m.Store.Save(ctx, &old)
+}
+
+// Revoke ends the session of the cookie of r, if any, and clears the
+// cookie.
+func (m *SessionManager) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	if c, err := r.Cookie(m.cookieName()); err == nil && c.Value != "" {
+		if err := m.Store.Delete(ctx, c.Value); err != nil {
+			return err
+		}
+	}
+	m.setCookie(w, &Session{})
+	return nil
+}
+
+// Middleware returns h with the principal of the session of each
+// request, if it has a valid one, set with WithPrincipal on the context
+// of the request, for methods without a session option.
+func (m *SessionManager) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := m.Load(r.Context(), w, r)
+		if err != nil {
+			WriteRequestError(w, r, err)
+			return
+		}
+		if s != nil {
+			r = r.WithContext(withSession(WithPrincipal(r.Context(), s.Principal), s))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+type sessionKey struct{}
+type pendingSessionKey struct{}
+
+func withSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFrom returns the session of the call, or nil.
+func SessionFrom(ctx context.Context) *Session {
+	s, _ := value(ctx, sessionKey{}).(*Session)
+	return s
+}
+
+func sessions() (*SessionManager, error) {
+	if Sessions == nil {
+		return nil, Errorf(500, "INTERNAL", "goweb.Sessions is not set")
+	}
+	return Sessions, nil
+}
+
+// RequireSession returns ctx with the session of r and its principal,
+// or an UNAUTHENTICATED *Error if r has no valid session. Generated
+// handlers of methods with the session option REQUIRED call it.
+func RequireSession(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	m, err := sessions()
+	if err != nil {
+		return ctx, err
+	}
+	s, err := m.Load(ctx, w, r)
+	if err != nil {
+		return ctx, err
+	}
+	if s == nil {
+		return ctx, Errorf(401, "UNAUTHENTICATED", "no valid session")
+	}
+	return withSession(WithPrincipal(ctx, s.Principal), s), nil
+}
+
+// WithSessionIssue returns ctx for a call of a method with the session
+// option ISSUE, in which the implementation calls IssueSession.
+func WithSessionIssue(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pendingSessionKey{}, new(interface{}))
+}
+
+// IssueSession makes a successful call of a method with the session
+// option ISSUE, such as a login, start a session of principal.
+func IssueSession(ctx context.Context, principal interface{}) error {
+	p, _ := ctx.Value(pendingSessionKey{}).(*interface{})
+	if p == nil {
+		return Errorf(500, "INTERNAL", "IssueSession outside a method with the session option ISSUE")
+	}
+	*p = principal
+	return nil
+}
+
+// CommitSession issues the session of the principal passed to
+// IssueSession during the call, if any; generated handlers call it
+// after the call succeeded.
+func CommitSession(ctx context.Context, w http.ResponseWriter) error {
+	p, _ := ctx.Value(pendingSessionKey{}).(*interface{})
+	if p == nil || *p == nil {
+		return nil
+	}
+	m, err := sessions()
+	if err != nil {
+		return err
+	}
+	if old := SessionFrom(ctx); old != nil {
+		m.Store.Delete(ctx, old.ID)
+	}
+	_, err = m.Issue(ctx, w, *p)
+	return err
+}
+
+// RevokeSession ends the session of r; generated handlers of methods
+// with the session option REVOKE, such as a logout, call it after the
+// call succeeded.
+func RevokeSession(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	m, err := sessions()
+	if err != nil {
+		return err
+	}
+	return m.Revoke(ctx, w, r)
+}
@@ -0,0 +1,178 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSessions(t *testing.T) {
+	m := &SessionManager{Store: NewMemorySessionStore(), RotateAfter: time.Hour}
+	defer func(s *SessionManager) { Sessions = s }(Sessions)
+	Sessions = m
+	request := func(cookies []*http.Cookie) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		return r
+	}
+
+	// Issue a session in a login.
+	ctx := WithSessionIssue(context.Background())
+	if err := IssueSession(ctx, map[string]string{"sub": "jane"}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	if err := CommitSession(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "__Host-session" || !cookies[0].Secure || !cookies[0].HttpOnly ||
+		cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Path != "/" || cookies[0].MaxAge <= 0 {
+		t.Fatalf("cookies = %v", cookies)
+	}
+	if err := IssueSession(context.Background(), "x"); err == nil {
+		t.Errorf("IssueSession outside a login succeeded")
+	}
+
+	// Require it.
+	w = httptest.NewRecorder()
+	ctx, err := RequireSession(context.Background(), w, request(cookies))
+	if err != nil || PrincipalFrom(ctx).(map[string]string)["sub"] != "jane" || SessionFrom(ctx) == nil {
+		t.Fatalf("RequireSession = %v", err)
+	}
+	if _, err := RequireSession(context.Background(), w, request(nil)); err == nil || err.(*Error).Status != 401 {
+		t.Errorf("RequireSession without cookie = %v", err)
+	}
+
+	// Rotate it.
+	s, _ := m.Store.Load(context.Background(), cookies[0].Value)
+	s.Created = time.Now().Add(-2 * time.Hour)
+	m.Store.Save(context.Background(), s)
+	w = httptest.NewRecorder()
+	if _, err := RequireSession(context.Background(), w, request(cookies)); err != nil {
+		t.Fatal(err)
+	}
+	rotated := w.Result().Cookies()
+	if len(rotated) != 1 || rotated[0].Value == cookies[0].Value {
+		t.Fatalf("rotated cookies = %v", rotated)
+	}
+	// The old id stays valid for a while, without another rotation.
+	w = httptest.NewRecorder()
+	if _, err := RequireSession(context.Background(), w, request(cookies)); err != nil {
+		t.Errorf("the old session id is invalid during the grace period: %v", err)
+	}
+	if c := w.Result().Cookies(); len(c) != 0 {
+		t.Errorf("the old session id was rotated again: %v", c)
+	}
+	s, _ = m.Store.Load(context.Background(), cookies[0].Value)
+	if s == nil || time.Until(s.Expires) > 30*time.Second {
+		t.Fatalf("old session = %+v", s)
+	}
+	s.Expires = time.Now().Add(-time.Second)
+	m.Store.Save(context.Background(), s)
+	if _, err := RequireSession(context.Background(), httptest.NewRecorder(), request(cookies)); err == nil {
+		t.Errorf("the old session id is still valid after the grace period")
+	}
+
+	// Use it through the middleware.
+	var principal interface{}
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { principal = PrincipalFrom(r.Context()) }))
+	h.ServeHTTP(httptest.NewRecorder(), request(rotated))
+	if principal == nil {
+		t.Errorf("middleware did not set the principal")
+	}
+
+	// Revoke it.
+	w = httptest.NewRecorder()
+	if err := RevokeSession(context.Background(), w, request(rotated)); err != nil {
+		t.Fatal(err)
+	}
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("revoke cookies = %v", c)
+	}
+	if _, err := RequireSession(context.Background(), httptest.NewRecorder(), request(rotated)); err == nil {
+		t.Errorf("the revoked session is still valid")
+	}
+}
+
+func TestSessionRotationWithoutGrace(t *testing.T) {
+	m := &SessionManager{Store: NewMemorySessionStore(), RotateAfter: time.Hour, RotationGrace: -1}
+	ctx := context.Background()
+	s := &Session{ID: "old", Created: time.Now().Add(-2 * time.Hour), Expires: time.Now().Add(time.Hour)}
+	m.Store.Save(ctx, s)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "__Host-session", Value: "old"})
+	if s, err := m.Load(ctx, httptest.NewRecorder(), r); err != nil || s == nil || s.ID == "old" {
+		t.Fatalf("Load = %+v, %v", s, err)
+	}
+	if s, _ := m.Store.Load(ctx, "old"); s != nil {
+		t.Errorf("the old session id is still stored")
+	}
+}
+
+func TestMemorySessionStoreSweep(t *testing.T) {
+	st := NewMemorySessionStore()
+	ctx := context.Background()
+	st.Save(ctx, &Session{ID: "abandoned", Expires: time.Now().Add(time.Millisecond)})
+	time.Sleep(2 * time.Millisecond)
+	st.Save(ctx, &Session{ID: "live", Expires: time.Now().Add(time.Hour)})
+	if _, ok := st.m["abandoned"]; !ok {
+		t.Fatalf("swept again within a minute")
+	}
+	st.sweep = time.Time{}
+	st.Save(ctx, &Session{ID: "new", Expires: time.Now().Add(time.Hour)})
+	if _, ok := st.m["abandoned"]; ok || len(st.m) != 2 {
+		t.Errorf("sessions after the sweep = %v", st.m)
+	}
+}
+
+func TestSessionCookieName(t *testing.T) {
+	for m, want := range map[*SessionManager]string{
+		{}:                                "__Host-session",
+		{Path: "/"}:                       "__Host-session",
+		{Domain: "example.com"}:           "__Secure-session",
+		{Path: "/app"}:                    "__Secure-session",
+		{Insecure: true, Domain: "x.com"}: "session",
+		{CookieName: "sid"}:               "sid",
+	} {
+		if name := m.cookieName(); name != want {
+			t.Errorf("cookie of %+v = %q, want %q", m, name, want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// DefaultShadowTimeout bounds the shadow calls of a Shadow without
+// Timeout.
+const DefaultShadowTimeout = 10 * time.Second
+
+// A ShadowCall calls the method of route of the shadow implementation.
+// The generated With<Service>Shadow functions make them.
+type ShadowCall func(ctx context.Context, route Route, in interface{}) (interface{}, error)
+
+// A Shadow mirrors unary calls to a shadow implementation, e.g. the
+// rewrite of a backend, and compares its responses with those of the
+// primary one, field by field, to validate it with production traffic.
+// Clients only ever get the responses of the primary; the shadow is
+// called after it, in the background, with the values of the context of
+// the call but without its deadline. Its Interceptor method is used by
+// the generated With<Service>Shadow functions.
+type Shadow struct {
+	// Percent is the percentage of the calls that are mirrored; 0
+	// mirrors all.
+	Percent float64
+
+	// IgnoreOrder compares lists regardless of the order of their
+	// elements.
+	IgnoreOrder bool
+
+	// Timeout bounds the shadow calls, DefaultShadowTimeout if 0.
+	Timeout time.Duration
+
+	// LogEvery logs every LogEvery-th call of a method whose responses
+	// differ; 0 and 1 log all. Stats counts them all.
+	LogEvery int
+
+	// Log logs a call whose responses differ, with log.Printf if nil.
+	Log func(d *ShadowDiff)
+
+	mu    sync.Mutex
+	stats map[string]*ShadowStats
+}
+
+// ShadowStats count the mirrored calls of a method.
+type ShadowStats struct {
+	Calls int64 `json:"calls"`
+
+	// Diffs counts the calls whose responses or error statuses differ.
+	Diffs int64 `json:"diffs"`
+
+	// Errors counts the failed shadow calls.
+	Errors int64 `json:"errors"`
+}
+
+// A ShadowDiff is a call whose primary and shadow responses differ.
+type ShadowDiff struct {
+	Route   Route           `json:"route"`
+	Request json.RawMessage `json:"request"`
+
+	// Fields are the differences of the responses if both succeeded.
+	Fields []FieldDiff `json:"fields,omitempty"`
+
+	// PrimaryError and ShadowError are the errors of the calls, if any.
+	PrimaryError string `json:"primary_error,omitempty"`
+	ShadowError  string `json:"shadow_error,omitempty"`
+}
+
+// A FieldDiff is a field whose values differ, as JSON; nil if unset.
+type FieldDiff struct {
+	Path    string          `json:"path"` // e.g. "items[2].name", "" for the whole value
+	Primary json.RawMessage `json:"primary"`
+	Shadow  json.RawMessage `json:"shadow"`
+}
+
+func (d *ShadowDiff) String() string {
+	if len(d.Fields) == 0 {
+		return fmt.Sprintf("error %q, shadow error %q", d.PrimaryError, d.ShadowError)
+	}
+	var b strings.Builder
+	for i, f := range d.Fields {
+		if i == 10 {
+			fmt.Fprintf(&b, "; and %d more", len(d.Fields)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s: %s != %s", f.Path, orNull(f.Primary), orNull(f.Shadow))
+	}
+	return b.String()
+}
+
+func orNull(v json.RawMessage) string {
+	if v == nil {
+		return "unset"
+	}
+	return string(v)
+}
+
+// Stats returns the counts of the mirrored calls by full method name.
+func (s *Shadow) Stats() map[string]ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[string]ShadowStats, len(s.stats))
+	for k, v := range s.stats {
+		stats[k] = *v
+	}
+	return stats
+}
+
+// Interceptor returns the Interceptor mirroring the calls to shadow.
+func (s *Shadow) Interceptor(shadow ShadowCall) Interceptor {
+	return func(ctx context.Context, route Route, in interface{}, next UnaryHandler) (interface{}, error) {
+		if s.Percent > 0 && rand.Float64()*100 >= s.Percent {
+			return next(ctx, in)
+		}
+		req := in
+		if m, ok := in.(proto.Message); ok {
+			req = Clone(m)
+		}
+		out, err := next(ctx, in)
+		var primary []byte
+		if err == nil {
+			primary, _ = json.Marshal(out)
+		}
+		go s.compare(detached{ctx}, route, req, primary, err, shadow)
+		return out, err
+	}
+}
+
+// compare calls shadow and compares its response with primary.
+func (s *Shadow) compare(ctx context.Context, route Route, in interface{}, primary []byte, primaryErr error, shadow ShadowCall) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := shadow(ctx, route, in)
+	d := &ShadowDiff{Route: route}
+	differs := false
+	switch {
+	case primaryErr != nil || err != nil:
+		if primaryErr != nil {
+			d.PrimaryError = primaryErr.Error()
+		}
+		if err != nil {
+			d.ShadowError = err.Error()
+		}
+		differs = primaryErr == nil || err == nil || ErrorStatusOf(primaryErr) != ErrorStatusOf(err)
+	default:
+		res, _ := json.Marshal(out)
+		d.Fields = DiffJSON(primary, res, s.IgnoreOrder)
+		differs = len(d.Fields) > 0
+	}
+
+	s.mu.Lock()
+	if s.stats == nil {
+		s.stats = make(map[string]*ShadowStats)
+	}
+	st := s.stats[route.FullMethod()]
+	if st == nil {
+		st = &ShadowStats{}
+		s.stats[route.FullMethod()] = st
+	}
+	st.Calls++
+	if err != nil {
+		st.Errors++
+	}
+	logged := false
+	if differs {
+		st.Diffs++
+		logged = s.LogEvery <= 1 || st.Diffs%int64(s.LogEvery) == 1
+	}
+	s.mu.Unlock()
+
+	if !logged {
+		return
+	}
+	d.Request, _ = json.Marshal(in)
+	if s.Log != nil {
+		s.Log(d)
+	} else {
+		log.Printf("goweb: shadow of %s differs: %s", route.FullMethod(), d)
+	}
+}
+
+// detached is a context with the values of its parent, but without its
+// deadline and cancellation.
+type detached struct{ context.Context }
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+// DiffJSON returns the differences between the JSON values a and b, by
+// path, see FieldDiff. Objects are compared by member, and lists by
+// index, or as sorted lists if ignoreOrder is set. Invalid JSON differs
+// as a whole.
+func DiffJSON(a, b []byte, ignoreOrder bool) []FieldDiff {
+	var av, bv interface{}
+	if decodeJSON(a, &av) != nil || decodeJSON(b, &bv) != nil {
+		if string(a) == string(b) {
+			return nil
+		}
+		return []FieldDiff{{Primary: a, Shadow: b}}
+	}
+	return diffValues("", av, bv, ignoreOrder, nil)
+}
+
+func decodeJSON(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+func diffValues(path string, a, b interface{}, ignoreOrder bool, diffs []FieldDiff) []FieldDiff {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(av)+len(bv))
+			for k := range av {
+				keys = append(keys, k)
+			}
+			for k := range bv {
+				if _, ok := av[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				diffs = diffValues(FieldPath(path, k), av[k], bv[k], ignoreOrder, diffs)
+			}
+			return diffs
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			if ignoreOrder {
+				av, bv = sortedJSON(av), sortedJSON(bv)
+			}
+			for i := 0; i < len(av) || i < len(bv); i++ {
+				var x, y interface{}
+				if i < len(av) {
+					x = av[i]
+				}
+				if i < len(bv) {
+					y = bv[i]
+				}
+				diffs = diffValues(IndexPath(path, i), x, y, ignoreOrder, diffs)
+			}
+			return diffs
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		diffs = append(diffs, FieldDiff{Path: path, Primary: rawJSON(a), Shadow: rawJSON(b)})
+	}
+	return diffs
+}
+
+// sortedJSON returns a copy of vs sorted by their JSON encoding.
+func sortedJSON(vs []interface{}) []interface{} {
+	keys := make([]string, len(vs))
+	for i, v := range vs {
+		b, _ := json.Marshal(v)
+		keys[i] = string(b)
+	}
+	sorted := append([]interface{}(nil), vs...)
+	sort.Sort(byKey{keys, sorted})
+	return sorted
+}
+
+type byKey struct {
+	keys []string
+	vs   []interface{}
+}
+
+func (s byKey) Len() int           { return len(s.keys) }
+func (s byKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s byKey) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.vs[i], s.vs[j] = s.vs[j], s.vs[i]
+}
+
+func rawJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
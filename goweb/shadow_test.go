@@ -0,0 +1,119 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestDiffJSON(t *testing.T) {
+	for _, c := range []struct {
+		a, b        string
+		ignoreOrder bool
+		want        []string
+	}{
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, false, nil},
+		{`{"a":1,"b":{"c":"x"}}`, `{"a":2,"b":{"d":"x"}}`, false, []string{"a", "b.c", "b.d"}},
+		{`{"l":[1,2,3]}`, `{"l":[3,2]}`, false, []string{"l[0]", "l[2]"}},
+		{`{"l":[{"n":1},{"n":2}]}`, `{"l":[{"n":2},{"n":1}]}`, true, nil},
+		{`{"l":[1,2,3]}`, `{"l":[3,1]}`, true, []string{"l[1]", "l[2]"}},
+		{`{"n":10000000000000001}`, `{"n":10000000000000000}`, false, []string{"n"}},
+		{`[1]`, `{}`, false, []string{""}},
+		{`{`, `{`, false, nil},
+	} {
+		var got []string
+		for _, d := range DiffJSON([]byte(c.a), []byte(c.b), c.ignoreOrder) {
+			got = append(got, d.Path)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("DiffJSON(%s, %s) = %q, want %q", c.a, c.b, got, c.want)
+		}
+	}
+	d := DiffJSON([]byte(`{"a":1}`), []byte(`{"b":"x"}`), false)
+	if s := (&ShadowDiff{Fields: d}).String(); s != `a: 1 != unset; b: unset != "x"` {
+		t.Errorf("String = %s", s)
+	}
+}
+
+type shadowKey struct{}
+
+func TestShadow(t *testing.T) {
+	logged := make(chan *ShadowDiff, 10)
+	s := &Shadow{LogEvery: 2, IgnoreOrder: true, Log: func(d *ShadowDiff) { logged <- d }}
+	ic := s.Interceptor(func(ctx context.Context, route Route, in interface{}) (interface{}, error) {
+		if ctx.Value(shadowKey{}) != "v" {
+			t.Error("shadow call without the values of the context")
+		}
+		switch in.(string) {
+		case "same":
+			return map[string]interface{}{"l": []int{2, 1}}, nil
+		case "fail":
+			return nil, errors.New("shadow failed")
+		}
+		return map[string]interface{}{"l": []int{3}}, nil
+	})
+	route := Route{Service: "pkg.S", Method: "Get"}
+	primary := func(ctx context.Context, in interface{}) (interface{}, error) {
+		return map[string]interface{}{"l": []int{1, 2}}, nil
+	}
+	for i, in := range []string{"same", "other", "fail", "other"} {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), shadowKey{}, "v"))
+		out, err := ic(ctx, route, in, primary)
+		cancel()
+		if err != nil || out == nil {
+			t.Fatalf("%s: %v, %v", in, out, err)
+		}
+		for deadline := time.Now().Add(time.Second); s.Stats()["/pkg.S/Get"].Calls <= int64(i) && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	var diffs []*ShadowDiff
+	for len(diffs) < 2 {
+		select {
+		case d := <-logged:
+			diffs = append(diffs, d)
+		case <-time.After(time.Second):
+			t.Fatalf("logged %d diffs", len(diffs))
+		}
+	}
+	if got := s.Stats()["/pkg.S/Get"]; got != (ShadowStats{Calls: 4, Diffs: 3, Errors: 1}) {
+		t.Errorf("stats %+v", got)
+	}
+	if len(diffs) != 2 || string(diffs[0].Request) != `"other"` || len(diffs[0].Fields) != 2 || diffs[1].ShadowError != "" {
+		t.Errorf("logged %+v", diffs)
+	}
+}
@@ -0,0 +1,100 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// DebugError returns err, an error of decoding or checking the request v,
+// as a 400 INVALID_ARGUMENT *Error. If err names a field, as the errors of
+// encoding/json, *LimitError and *RangeError do, the *Error has a
+// violation of the field whose Expected describes the JSON the field
+// takes, after shapes, the descriptions of the fields of v and of the
+// messages nested in it by their full names, e.g. "pkg.User.age":
+// "an integer". Generated handlers answer with it for the debug_errors
+// parameter.
+func DebugError(err error, v interface{}, shapes map[string]string) error {
+	e := Errorf(400, "INVALID_ARGUMENT", "%s", strings.TrimPrefix(err.Error(), "goweb: "))
+	var path, name string
+	switch err := err.(type) {
+	case *json.UnmarshalTypeError:
+		path, name = err.Field, protoFieldName(reflect.TypeOf(v), err.Field)
+	case *LimitError:
+		path, name = err.Field, err.Field
+	case *RangeError:
+		path, name = err.Field, err.Field
+	}
+	if path != "" {
+		e.Violations = []FieldViolation{{Field: path, Description: e.Message, Expected: shapes[name]}}
+	}
+	return e
+}
+
+// protoFieldName returns the full name of the field of the message type t
+// at the JSON path, as encoding/json reports it, e.g. "pkg.Item.name" for
+// "items.0.name", or "" if there is none.
+func protoFieldName(t reflect.Type, path string) string {
+	name := ""
+	segs := strings.Split(path, ".")
+	for i := 0; i < len(segs); i++ {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if k := t.Kind(); k == reflect.Map || k == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+			// the segment is the key or the index, which older Go versions
+			// leave out for arrays
+			t = t.Elem()
+			if k == reflect.Map || strings.Trim(segs[i], "0123456789") == "" {
+				continue
+			}
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+		}
+		if t.Kind() != reflect.Struct {
+			return ""
+		}
+		f, ok := jsonFields(t)[segs[i]]
+		m, isMsg := reflect.New(t).Interface().(proto.Message)
+		if !ok || !isMsg {
+			return ""
+		}
+		name = proto.MessageName(m) + "." + protoName(t.Field(f).Tag.Get("protobuf"))
+		t = t.Field(f).Type
+	}
+	return name
+}
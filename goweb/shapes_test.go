@@ -0,0 +1,81 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func init() {
+	proto.RegisterType((*palette)(nil), "goweb.test.Palette")
+	proto.RegisterType((*sample)(nil), "goweb.test.Sample")
+}
+
+func TestDebugError(t *testing.T) {
+	shapes := map[string]string{"goweb.test.Sample.value": "a number", "goweb.test.Sample.series": "an array of numbers"}
+	var s sample
+	err := DebugError(json.Unmarshal([]byte(`{"next":{"series":[1,"x"]}}`), &s), &s, shapes)
+	e, ok := err.(*Error)
+	if !ok || e.Status != 400 || e.Code != "INVALID_ARGUMENT" || len(e.Violations) != 1 {
+		t.Fatalf("DebugError = %#v", err)
+	}
+	if v := e.Violations[0]; v.Expected != "an array of numbers" || v.Field == "" {
+		t.Errorf("violation = %+v", v)
+	}
+	e = DebugError(&LimitError{Field: "goweb.test.Sample.value", What: "bytes", Limit: 3}, &s, shapes).(*Error)
+	if len(e.Violations) != 1 || e.Violations[0].Expected != "a number" {
+		t.Errorf("DebugError of a LimitError = %+v", e.Violations)
+	}
+	e = DebugError(&LimitError{Limit: 3}, &s, shapes).(*Error)
+	if len(e.Violations) != 0 || e.Message != "request nested deeper than 3 levels" {
+		t.Errorf("DebugError of a depth LimitError = %+v", e)
+	}
+
+	for path, want := range map[string]string{
+		"value":            "goweb.test.Sample.value",
+		"next.next.series": "goweb.test.Sample.series",
+		"by_key.a":         "goweb.test.Sample.by_key",
+		"next.series.1":    "goweb.test.Sample.series",
+		"nope":             "",
+	} {
+		if got := protoFieldName(reflect.TypeOf(&s), path); got != want {
+			t.Errorf("protoFieldName(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := protoFieldName(reflect.TypeOf(&palette{}), "by_name.x"); got != "goweb.test.Palette.by_name" {
+		t.Errorf("protoFieldName of a map value = %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A RequestSigner signs the requests of an Upstream, e.g. with AWS
+// Signature Version 4 or HMACSigner. body is the request body, which has
+// already been set on r.
+type RequestSigner interface {
+	SignRequest(r *http.Request, route Route, body []byte) error
+}
+
+// RequestSignerFunc adapts a function to a RequestSigner.
+type RequestSignerFunc func(r *http.Request, route Route, body []byte) error
+
+// SignRequest calls f.
+func (f RequestSignerFunc) SignRequest(r *http.Request, route Route, body []byte) error {
+	return f(r, route, body)
+}
+
+// CanonicalRequest returns the canonical form of r that request signers
+// sign: the verb, the path, the sorted query, the listed headers
+// (lower-cased and sorted, one "name:value" per line), the sorted header
+// names and the hex SHA-256 of body, separated by newlines, in the style
+// of AWS Signature Version 4.
+func CanonicalRequest(r *http.Request, headers []string, body []byte) string {
+	names := make([]string, len(headers))
+	for i, h := range headers {
+		names[i] = strings.ToLower(h)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path + "\n")
+	b.WriteString(strings.Replace(r.URL.Query().Encode(), "+", "%20", -1) + "\n")
+	for _, h := range names {
+		v := r.Header.Get(h)
+		if h == "host" && v == "" {
+			v = r.Host
+			if v == "" {
+				v = r.URL.Host
+			}
+		}
+		b.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	b.WriteString(strings.Join(names, ";") + "\n")
+	sum := sha256.Sum256(body)
+	b.WriteString(hex.EncodeToString(sum[:]))
+	return b.String()
+}
+
+// HMACSigner signs requests with an HMAC-SHA256 over the time of the
+// request and their CanonicalRequest, sent as
+//
+//	Authorization: GOWEB-HMAC-SHA256 KeyId=<id>, SignedHeaders=<names>, Signature=<hex>
+//
+// together with the time in the X-Goweb-Date header (RFC 3339, UTC).
+type HMACSigner struct {
+	KeyID string
+	Key   []byte
+
+	// Headers lists the headers that are signed besides host,
+	// content-type and x-goweb-date.
+	Headers []string
+
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+}
+
+// DateHeader carries the time of a request signed by an HMACSigner.
+const DateHeader = "X-Goweb-Date"
+
+// SignRequest implements RequestSigner.
+func (s *HMACSigner) SignRequest(r *http.Request, route Route, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	date := now().UTC().Format(time.RFC3339)
+	r.Header.Set(DateHeader, date)
+	headers := []string{"host", "content-type", strings.ToLower(DateHeader)}
+	for _, h := range s.Headers {
+		headers = append(headers, strings.ToLower(h))
+	}
+	h := hmac.New(sha256.New, s.Key)
+	h.Write([]byte(date + "\n" + CanonicalRequest(r, headers, body)))
+	sort.Strings(headers)
+	r.Header.Set("Authorization", "GOWEB-HMAC-SHA256 KeyId="+s.KeyID+", SignedHeaders="+strings.Join(headers, ";")+", Signature="+hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
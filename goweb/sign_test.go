@@ -0,0 +1,73 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestCanonicalRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "http://api.example.com/users/get?b=2&a=1", nil)
+	r.Header.Set("Content-Type", "application/json")
+	want := "POST\n/users/get\na=1&b=2\ncontent-type:application/json\nhost:api.example.com\ncontent-type;host\n" +
+		"44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"
+	if got := CanonicalRequest(r, []string{"Host", "Content-Type"}, []byte("{}")); got != want {
+		t.Errorf("CanonicalRequest =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUpstreamSigner(t *testing.T) {
+	var auth, date string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, date = r.Header.Get("Authorization"), r.Header.Get(DateHeader)
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, Signer: &HMACSigner{
+		KeyID: "k1",
+		Key:   []byte("secret"),
+		Now:   func() time.Time { return time.Unix(0, 0) },
+	}}
+	var out struct{}
+	if err := u.Call(context.Background(), Route{Path: "/users/get"}, struct{}{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if date != "1970-01-01T00:00:00Z" || !strings.HasPrefix(auth, "GOWEB-HMAC-SHA256 KeyId=k1, SignedHeaders=content-type;host;x-goweb-date, Signature=") {
+		t.Errorf("Authorization = %q, %s = %q", auth, DateHeader, date)
+	}
+}
@@ -0,0 +1,155 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// The query parameters of a signed URL.
+const (
+	ExpiresParam   = "goweb_expires"
+	SignatureParam = "goweb_signature"
+)
+
+// ErrBadSignature is returned by URLSigner.Verify for requests without a
+// valid, unexpired signature.
+var ErrBadSignature = errors.New("goweb: missing, invalid or expired url signature")
+
+// A URLSigner signs URLs of routes for a limited time, so that a client
+// can call them without its own credentials, e.g. to upload a file
+// directly to a download or upload method. The signature is an
+// HMAC-SHA256 over verb, path and query, including the expiry time;
+// the generated
+// Sign<Service><Method>URL functions sign the routes of a service.
+type URLSigner struct {
+	// Key is the HMAC key; it must be the same for signing and verifying.
+	Key []byte
+
+	// Now returns the current time; time.Now if nil.
+	Now func() time.Time
+}
+
+func (s *URLSigner) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *URLSigner) mac(verb, path string, query url.Values) string {
+	h := hmac.New(sha256.New, s.Key)
+	h.Write([]byte(verb + "\n" + path + "\n" + query.Encode()))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sign returns target, a path with an optional query, with the query
+// parameters that allow verb requests to it until expires. An empty verb
+// allows all verbs. The signature covers the path and all query
+// parameters, in canonical order, so neither can be changed.
+func (s *URLSigner) Sign(verb, target string, expires time.Time) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		u = &url.URL{Path: target}
+	}
+	q := u.Query()
+	q.Del(SignatureParam)
+	q.Set(ExpiresParam, strconv.FormatInt(expires.Unix(), 10))
+	return u.EscapedPath() + "?" + q.Encode() + "&" + SignatureParam + "=" + s.mac(verb, u.EscapedPath(), q)
+}
+
+// SignRoute signs the URL of route below the mux prefix for the request
+// in, for the verb of the route. The variables of a path template are
+// expanded from in and its other fields, but those of the body selector,
+// become query parameters, the way an Upstream sends them, so that the
+// signature covers the concrete path and query the client requests.
+func (s *URLSigner) SignRoute(prefix string, route Route, in interface{}, expires time.Time) (string, error) {
+	target := JoinPath(prefix, route.Path)
+	if route.Verb != "" {
+		body, err := MarshalJSON(in)
+		if err != nil {
+			return "", fmt.Errorf("goweb: request of %s: %v", route.FullMethod(), err)
+		}
+		if target, _, err = templateRequest(route, target, body); err != nil {
+			return "", err
+		}
+	}
+	return s.Sign(route.Verb, target, expires), nil
+}
+
+// Verify checks the signature of r. HEAD requests are allowed by
+// signatures for GET.
+func (s *URLSigner) Verify(r *http.Request) error {
+	q := r.URL.Query()
+	e, err := strconv.ParseInt(q.Get(ExpiresParam), 10, 64)
+	if err != nil || s.now().Unix() > e {
+		return ErrBadSignature
+	}
+	got := []byte(q.Get(SignatureParam))
+	q.Del(SignatureParam)
+	verb := r.Method
+	if verb == "HEAD" {
+		verb = "GET"
+	}
+	for _, v := range []string{verb, ""} {
+		if hmac.Equal(got, []byte(s.mac(v, r.URL.EscapedPath(), q))) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// Require is a middleware answering requests without a valid signature
+// with 403, e.g. mux.Use(signer.Require). The signature parameters are
+// removed from the requests it passes on.
+func (s *URLSigner) Require(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Verify(r); err != nil {
+			http.Error(w, err.Error(), 403)
+			return
+		}
+		r = r.Clone(r.Context())
+		q := r.URL.Query()
+		q.Del(ExpiresParam)
+		q.Del(SignatureParam)
+		r.URL.RawQuery = q.Encode()
+		h.ServeHTTP(w, r)
+	})
+}
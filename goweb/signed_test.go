@@ -0,0 +1,88 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := &URLSigner{Key: []byte("secret"), Now: func() time.Time { return now }}
+	var query string
+	h := s.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+	}))
+	get, err := s.SignRoute("/api", Route{Service: "pkg.Files", Method: "Get", Verb: "GET", Path: "files/{name}/get"}, map[string]interface{}{"name": "a b", "version": 2}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(get, "/api/files/a%20b/get?goweb_expires=1060&version=2&goweb_signature=") {
+		t.Errorf("SignRoute = %s", get)
+	}
+	if _, err := s.SignRoute("/api", Route{Verb: "GET", Path: "files/{name}/get"}, map[string]interface{}{}, now); err == nil {
+		t.Errorf("SignRoute without the path variable did not fail")
+	}
+	post := s.Sign("POST", "/api/files/put?name=a", now.Add(time.Minute))
+	for _, c := range []struct {
+		verb, url string
+		code      int
+	}{
+		{"GET", get, 200},
+		{"HEAD", get, 200},
+		{"POST", get, 403},
+		{"GET", strings.Replace(get, "/get", "/put", 1), 403},
+		{"GET", strings.Replace(get, "a%20b", "c", 1), 403},
+		{"GET", strings.Replace(get, "version=2", "version=3", 1), 403},
+		{"GET", get + "&admin=1", 403},
+		{"GET", "/api/files/a%20b/get", 403},
+		{"POST", strings.Replace(post, "name=a", "name=b", 1), 403},
+		{"POST", post, 200},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(c.verb, c.url, nil))
+		if w.Code != c.code {
+			t.Errorf("%s %s: %d, want %d", c.verb, c.url, w.Code, c.code)
+		}
+	}
+	if query != "name=a" {
+		t.Errorf("query = %q", query)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := s.Verify(httptest.NewRequest("GET", get, nil)); err != ErrBadSignature {
+		t.Errorf("expired: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// DefaultLatencyPercentile is the percentile of latency objectives that
+// do not give one.
+const DefaultLatencyPercentile = 99
+
+// An SLO is the service level objective of a method, declared with the
+// slo_latency_ms, slo_latency_percentile and slo_availability method
+// options, and exported with its route (Route.SLO) for monitoring.
+type SLO struct {
+	// LatencyMillis is the time within which LatencyPercentile percent of
+	// the calls are to be answered, or 0 for no latency objective.
+	LatencyMillis     uint32  `json:"latency_ms,omitempty"`
+	LatencyPercentile float64 `json:"latency_percentile,omitempty"`
+
+	// Availability is the percentage of calls that are not to fail with
+	// a server error, or 0 for no availability objective.
+	Availability float64 `json:"availability,omitempty"`
+}
+
+// sloOf returns the SLO declared by the method options opts, or nil.
+func sloOf(opts *pb.MethodOptions) *SLO {
+	slo := &SLO{
+		LatencyMillis:     options.Uint32(opts, options.E_SloLatencyMs),
+		LatencyPercentile: options.Float64(opts, options.E_SloLatencyPercentile),
+		Availability:      options.Float64(opts, options.E_SloAvailability),
+	}
+	if slo.LatencyMillis > 0 && slo.LatencyPercentile == 0 {
+		slo.LatencyPercentile = DefaultLatencyPercentile
+	}
+	if *slo == (SLO{}) {
+		return nil
+	}
+	return slo
+}
+
+// Check returns an error if the objectives are out of range.
+func (s *SLO) Check() error {
+	if s.LatencyPercentile != 0 && (s.LatencyMillis == 0 || s.LatencyPercentile <= 0 || s.LatencyPercentile >= 100) {
+		return fmt.Errorf("slo_latency_percentile must be in (0, 100) and needs slo_latency_ms, not %v", s.LatencyPercentile)
+	}
+	if s.Availability < 0 || s.Availability >= 100 {
+		return fmt.Errorf("slo_availability must be in (0, 100), not %v", s.Availability)
+	}
+	return nil
+}
+
+// SLOLabels returns the objectives of the route as metric labels, e.g.
+// {"slo_latency_ms": "200", "slo_latency_percentile": "99",
+// "slo_availability": "99.9"}, for monitoring systems that derive alerts
+// from the labels of the metrics. Routes without SLO have none.
+func (r Route) SLOLabels() map[string]string {
+	labels := map[string]string{}
+	if r.SLO == nil {
+		return labels
+	}
+	if r.SLO.LatencyMillis > 0 {
+		labels["slo_latency_ms"] = strconv.FormatUint(uint64(r.SLO.LatencyMillis), 10)
+		labels["slo_latency_percentile"] = formatFloat(r.SLO.LatencyPercentile)
+	}
+	if r.SLO.Availability > 0 {
+		labels["slo_availability"] = formatFloat(r.SLO.Availability)
+	}
+	return labels
+}
+
+// PrometheusMetrics names the metrics and labels of the http calls of a
+// server that PrometheusRules refers to.
+type PrometheusMetrics struct {
+	// Duration is the histogram of the call durations in seconds, e.g.
+	// "http_request_duration_seconds"; its buckets must include the
+	// latency objectives.
+	Duration string
+
+	// Requests is the counter of the calls, e.g. "http_requests_total".
+	Requests string
+
+	// MethodLabel is the label holding Route.FullMethod(), e.g. "method",
+	// and StatusLabel the one holding the http status code, e.g. "code".
+	MethodLabel string
+	StatusLabel string
+
+	// Window is the range of the rates, "5m" if empty.
+	Window string
+}
+
+// PrometheusRules returns a Prometheus rule file with a recording rule per
+// objective of the routes with SLO, e.g. of Routes(): the ratio of calls
+// answered within the latency objective (goweb:slo_latency:ratio_rate5m)
+// and the ratio of calls not failing with a 5xx status
+// (goweb:slo_availability:ratio_rate5m), labeled with the method and the
+// objective as a ratio, so alerts can compare the two.
+func PrometheusRules(routes []Route, m PrometheusMetrics) string {
+	window := m.Window
+	if window == "" {
+		window = "5m"
+	}
+	routes = append([]Route(nil), routes...)
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].FullMethod() < routes[j].FullMethod() })
+	var b strings.Builder
+	b.WriteString("groups:\n- name: goweb_slo\n  rules:\n")
+	rule := func(record, expr, method string, objective float64) {
+		fmt.Fprintf(&b, "  - record: %s\n    expr: %s\n    labels:\n      %s: %q\n      objective: %q\n",
+			record, strconv.Quote(expr), m.MethodLabel, method, formatFloat(objective/100))
+	}
+	for _, r := range routes {
+		if r.SLO == nil {
+			continue
+		}
+		sel := m.MethodLabel + "=" + strconv.Quote(r.FullMethod())
+		if r.SLO.LatencyMillis > 0 {
+			le := formatFloat(float64(r.SLO.LatencyMillis) / 1000)
+			rule("goweb:slo_latency:ratio_rate"+window,
+				fmt.Sprintf("sum(rate(%s_bucket{%s,le=%q}[%s])) / sum(rate(%s_count{%s}[%s]))", m.Duration, sel, le, window, m.Duration, sel, window),
+				r.FullMethod(), r.SLO.LatencyPercentile)
+		}
+		if r.SLO.Availability > 0 {
+			rule("goweb:slo_availability:ratio_rate"+window,
+				fmt.Sprintf("1 - (sum(rate(%s{%s,%s=~\"5..\"}[%s])) or vector(0)) / sum(rate(%s{%s}[%s]))", m.Requests, sel, m.StatusLabel, window, m.Requests, sel, window),
+				r.FullMethod(), r.SLO.Availability)
+		}
+	}
+	return b.String()
+}
+
+// formatFloat formats f without the rounding errors of the conversions,
+// e.g. 99.9 / 100 as 0.999.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 12, 64)
+}
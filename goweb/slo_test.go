@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestSLO(t *testing.T) {
+	opts := &pb.MethodOptions{}
+	if slo := sloOf(opts); slo != nil {
+		t.Errorf("sloOf without options = %+v", slo)
+	}
+	proto.SetExtension(opts, options.E_SloLatencyMs, proto.Uint32(250))
+	proto.SetExtension(opts, options.E_SloAvailability, proto.Float64(99.95))
+	slo := sloOf(opts)
+	if want := (&SLO{LatencyMillis: 250, LatencyPercentile: 99, Availability: 99.95}); !reflect.DeepEqual(slo, want) {
+		t.Errorf("sloOf = %+v, want %+v", slo, want)
+	}
+	if err := slo.Check(); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []SLO{{LatencyPercentile: 90}, {LatencyMillis: 1, LatencyPercentile: 100}, {Availability: 100}} {
+		if err := bad.Check(); err == nil {
+			t.Errorf("Check(%+v) did not fail", bad)
+		}
+	}
+
+	r := Route{Service: "pkg.Users", Method: "Get", SLO: slo}
+	if got := r.SLOLabels(); !reflect.DeepEqual(got, map[string]string{"slo_latency_ms": "250", "slo_latency_percentile": "99", "slo_availability": "99.95"}) {
+		t.Errorf("SLOLabels = %v", got)
+	}
+	rules := PrometheusRules([]Route{{Service: "pkg.Users", Method: "List"}, r}, PrometheusMetrics{
+		Duration: "http_request_duration_seconds", Requests: "http_requests_total", MethodLabel: "method", StatusLabel: "code", Window: "30m"})
+	for _, want := range []string{
+		"  - record: goweb:slo_latency:ratio_rate30m\n" +
+			`    expr: "sum(rate(http_request_duration_seconds_bucket{method=\"/pkg.Users/Get\",le=\"0.25\"}[30m])) / sum(rate(http_request_duration_seconds_count{method=\"/pkg.Users/Get\"}[30m]))"` + "\n" +
+			"    labels:\n      method: \"/pkg.Users/Get\"\n      objective: \"0.99\"\n",
+		`code=~\"5..\"}[30m])) or vector(0))`,
+		`objective: "0.9995"`,
+	} {
+		if !strings.Contains(rules, want) {
+			t.Errorf("rules without %s:\n%s", want, rules)
+		}
+	}
+	if strings.Contains(rules, "List") {
+		t.Errorf("rules of a route without SLO:\n%s", rules)
+	}
+}
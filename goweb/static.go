@@ -0,0 +1,131 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions configure a Static handler.
+type StaticOptions struct {
+	// SPA serves index.html for GET requests of paths without a file
+	// extension that have no file, so a single page app can route them.
+	SPA bool
+
+	// MaxAge is how long browsers may cache files whose names carry no
+	// content hash; 1 hour if 0. Files with a content hash in their name
+	// (app.3f9a1c2e.js) are cached for a year, index.html not at all.
+	MaxAge time.Duration
+}
+
+// fingerprinted matches names with a content hash, e.g. app.3f9a1c2e.js
+// or chunk-5HQZ2NLE.js.
+var fingerprinted = regexp.MustCompile(`[.-]([0-9a-f]{8,}|[0-9A-Z]{8})\.[0-9A-Za-z]+$`)
+
+// Static returns a handler serving the files of the directory dir of
+// fsys (e.g. an embed.FS) for the requests below prefix, with ETags and
+// cache headers suited to built web apps. The generated muxes mount it
+// behind their routes with the static parameter.
+func Static(fsys fs.FS, dir, prefix string, opts StaticOptions) http.Handler {
+	if dir != "" && dir != "." {
+		sub, err := fs.Sub(fsys, dir)
+		if err != nil {
+			panic(err)
+		}
+		fsys = sub
+	}
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = time.Hour
+	}
+	return &static{fsys: fsys, prefix: strings.TrimSuffix(prefix, "/"), spa: opts.SPA, maxAge: maxAge}
+}
+
+type static struct {
+	fsys   fs.FS
+	prefix string
+	spa    bool
+	maxAge time.Duration
+	etags  sync.Map // name -> ETag
+}
+
+func (s *static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, s.prefix)
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	content, err := fs.ReadFile(s.fsys, name)
+	if err != nil && s.spa && path.Ext(name) == "" {
+		name = "index.html"
+		content, err = fs.ReadFile(s.fsys, name)
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case path.Base(name) == "index.html":
+		w.Header().Set("Cache-Control", "no-cache")
+	case fingerprinted.MatchString(path.Base(name)):
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	default:
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(s.maxAge/time.Second)))
+	}
+	w.Header().Set("ETag", s.etag(name, content))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+func (s *static) etag(name string, content []byte) string {
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string)
+	}
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	s.etags.Store(name, etag)
+	return etag
+}
@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist/index.html":               {Data: []byte("<html>app</html>")},
+		"dist/assets/app.3f9a1c2e.js":   {Data: []byte("js")},
+		"dist/assets/chunk-5HQZ2NLE.js": {Data: []byte("chunk")},
+		"dist/favicon.ico":              {Data: []byte("ico")},
+		"secret.txt":                    {Data: []byte("secret")},
+	}
+	h := Static(fsys, "dist", "/app/", StaticOptions{SPA: true})
+	for _, c := range []struct {
+		method, path string
+		status       int
+		body, cache  string
+	}{
+		{"GET", "/app/", 200, "<html>app</html>", "no-cache"},
+		{"GET", "/app/assets/app.3f9a1c2e.js", 200, "js", "public, max-age=31536000, immutable"},
+		{"GET", "/app/assets/chunk-5HQZ2NLE.js", 200, "chunk", "public, max-age=31536000, immutable"},
+		{"GET", "/app/favicon.ico", 200, "ico", "public, max-age=3600"},
+		{"GET", "/app/users/42", 200, "<html>app</html>", "no-cache"},
+		{"GET", "/app/missing.js", 404, "", ""},
+		{"GET", "/app/../secret.txt", 404, "", ""},
+		{"POST", "/app/", 405, "", ""},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.status || c.status == 200 && (w.Body.String() != c.body || w.Header().Get("Cache-Control") != c.cache) {
+			t.Errorf("%s %s: %d %q %q", c.method, c.path, w.Code, w.Body, w.Header().Get("Cache-Control"))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/app/favicon.ico", nil))
+	r := httptest.NewRequest("GET", "/app/favicon.ico", nil)
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	if h.ServeHTTP(w, r); w.Code != 304 {
+		t.Errorf("If-None-Match: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	Static(fsys, "dist", "/", StaticOptions{}).ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Code != 404 {
+		t.Errorf("without SPA: %d", w.Code)
+	}
+}
@@ -0,0 +1,172 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// A StatusMap maps error codes to http statuses.
+type StatusMap map[string]int
+
+// DefaultErrorStatuses maps the canonical error codes (the gRPC codes) to
+// the http statuses of their errors. Codes are looked up in upper snake
+// case, so the gRPC code names ("NotFound") match as well.
+var DefaultErrorStatuses = StatusMap{
+	"CANCELLED":           499,
+	"UNKNOWN":             500,
+	"INVALID_ARGUMENT":    400,
+	"DEADLINE_EXCEEDED":   504,
+	"NOT_FOUND":           404,
+	"ALREADY_EXISTS":      409,
+	"PERMISSION_DENIED":   403,
+	"RESOURCE_EXHAUSTED":  429,
+	"FAILED_PRECONDITION": 400,
+	"ABORTED":             409,
+	"OUT_OF_RANGE":        400,
+	"UNIMPLEMENTED":       501,
+	"INTERNAL":            500,
+	"UNAVAILABLE":         503,
+	"DATA_LOSS":           500,
+	"UNAUTHENTICATED":     401,
+}
+
+var (
+	statusesMu sync.Mutex
+	statuses   = map[string]StatusMap{}
+)
+
+// ErrorStatuses returns the StatusMap of the service (e.g. "pkg.Users"),
+// whose entries override DefaultErrorStatuses for its errors. Set them
+// before serving; the generated <Service>ErrorStatuses variables are
+// these maps.
+func ErrorStatuses(service string) StatusMap {
+	statusesMu.Lock()
+	defer statusesMu.Unlock()
+	m := statuses[service]
+	if m == nil {
+		m = StatusMap{}
+		statuses[service] = m
+	}
+	return m
+}
+
+// ErrorStatus returns the http status of the error code of the service:
+// from its ErrorStatuses, else from DefaultErrorStatuses, else 500.
+func ErrorStatus(service, code string) int {
+	if s, ok := errorStatus(service, code); ok {
+		return s
+	}
+	return 500
+}
+
+func errorStatus(service, code string) (int, bool) {
+	code = codeKey(code)
+	statusesMu.Lock()
+	s, ok := statuses[service][code]
+	statusesMu.Unlock()
+	if ok {
+		return s, true
+	}
+	s, ok = DefaultErrorStatuses[code]
+	return s, ok
+}
+
+// codeKey returns code in upper snake case, e.g. NOT_FOUND for NotFound.
+func codeKey(code string) string {
+	if strings.ToUpper(code) == code {
+		return code
+	}
+	var b strings.Builder
+	for i, c := range code {
+		if unicode.IsUpper(c) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
+
+// grpcCodes lists the canonical error codes by their gRPC code number.
+var grpcCodes = [...]string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// grpcStatusCodes maps http statuses to the gRPC codes of errors with
+// other than canonical codes.
+var grpcStatusCodes = map[int]int{
+	400: 3, 401: 16, 403: 7, 404: 5, 409: 10, 429: 8,
+	499: 1, 500: 13, 501: 12, 503: 14, 504: 4,
+}
+
+// GRPCCode returns the gRPC code number of e: that of its code if it is
+// a canonical one, else the one of its http status, or 2 (UNKNOWN).
+func GRPCCode(e *Error) int {
+	code := codeKey(e.Code)
+	for i, c := range grpcCodes {
+		if i > 0 && c == code {
+			return i
+		}
+	}
+	status := e.Status
+	if status == 0 {
+		status = ErrorStatus(e.Domain, e.Code)
+	}
+	if c, ok := grpcStatusCodes[status]; ok {
+		return c
+	}
+	return 2
+}
+
+// GRPCCodeName returns the canonical error code of the gRPC code number
+// c, e.g. "NOT_FOUND" for 5, or "UNKNOWN".
+func GRPCCodeName(c int) string {
+	if c < 0 || c >= len(grpcCodes) {
+		return "UNKNOWN"
+	}
+	return grpcCodes[c]
+}
+
+// GRPCErrorStatus returns the http status of the error code of the
+// service received in a gRPC status with the code number c: the one
+// ErrorStatus maps the code to, or else the one of c.
+func GRPCErrorStatus(service, code string, c int) int {
+	if s, ok := errorStatus(service, code); ok {
+		return s
+	}
+	return ErrorStatus("", GRPCCodeName(c))
+}
@@ -0,0 +1,107 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// setErrorStatus maps code of service to status until the end of the test.
+func setErrorStatus(t *testing.T, service, code string, status int) {
+	m := ErrorStatuses(service)
+	old, ok := m[code]
+	m[code] = status
+	t.Cleanup(func() {
+		if ok {
+			m[code] = old
+		} else {
+			delete(m, code)
+		}
+	})
+}
+
+func TestErrorStatus(t *testing.T) {
+	setErrorStatus(t, "pkg.Quotas", "NOT_FOUND", 410)
+	setErrorStatus(t, "pkg.Quotas", "QUOTA_EXCEEDED", 429)
+	for _, test := range []struct {
+		service, code string
+		want          int
+	}{
+		{"pkg.Quotas", "NOT_FOUND", 410},
+		{"pkg.Quotas", "QuotaExceeded", 429},
+		{"pkg.Users", "NOT_FOUND", 404},
+		{"pkg.Users", "NotFound", 404},
+		{"pkg.Users", "QUOTA_EXCEEDED", 500},
+		{"", "", 500},
+	} {
+		if got := ErrorStatus(test.service, test.code); got != test.want {
+			t.Errorf("ErrorStatus(%q, %q) = %d, want %d", test.service, test.code, got, test.want)
+		}
+	}
+	if e := (ErrorFactory{Domain: "pkg.Quotas"}).NotFound("quota", "q"); e.Status != 410 {
+		t.Errorf("NotFound status = %d", e.Status)
+	}
+	w := httptest.NewRecorder()
+	WriteError(w, &Error{Code: "QUOTA_EXCEEDED", Message: "over", Domain: "pkg.Quotas"})
+	if w.Code != 429 {
+		t.Errorf("WriteError status = %d", w.Code)
+	}
+}
+
+func TestGRPCCode(t *testing.T) {
+	setErrorStatus(t, "pkg.Quotas", "QUOTA_EXCEEDED", 429)
+	for _, test := range []struct {
+		e    *Error
+		want int
+	}{
+		{&Error{Code: "NOT_FOUND"}, 5},
+		{&Error{Code: "PermissionDenied", Status: 404}, 7},
+		{&Error{Code: "QUOTA_EXCEEDED", Domain: "pkg.Quotas"}, 8},
+		{&Error{Code: "OUT_OF_STOCK", Status: 409}, 10},
+		{&Error{Code: "TEAPOT", Status: 418}, 2},
+		{&Error{Code: "OK"}, 13},
+	} {
+		if got := GRPCCode(test.e); got != test.want {
+			t.Errorf("GRPCCode(%v) = %d, want %d", test.e, got, test.want)
+		}
+	}
+	if GRPCCodeName(5) != "NOT_FOUND" || GRPCCodeName(16) != "UNAUTHENTICATED" || GRPCCodeName(17) != "UNKNOWN" {
+		t.Errorf("GRPCCodeName(5, 16, 17) = %s, %s, %s", GRPCCodeName(5), GRPCCodeName(16), GRPCCodeName(17))
+	}
+	if s := GRPCErrorStatus("pkg.Quotas", "QUOTA_EXCEEDED", 10); s != 429 {
+		t.Errorf("GRPCErrorStatus(mapped) = %d", s)
+	}
+	if s := GRPCErrorStatus("pkg.Users", "OUT_OF_STOCK", 10); s != 409 {
+		t.Errorf("GRPCErrorStatus(unmapped) = %d", s)
+	}
+}
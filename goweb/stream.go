@@ -0,0 +1,393 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// StreamOptions configure how a ServerStream writes messages.
+type StreamOptions struct {
+	// FlushEvery flushes the response after every FlushEvery messages;
+	// 0 and 1 flush after every message.
+	FlushEvery int
+
+	// Gzip compresses the stream if the client accepts gzip. The
+	// compressor is flushed together with the response, so every flushed
+	// message reaches the client.
+	Gzip bool
+
+	// KeepAlive, if positive, writes a comment (SSE) or an empty line
+	// (NDJSON) whenever the stream was idle for that long, so that proxies
+	// do not close it.
+	KeepAlive time.Duration
+
+	// ProxySafe keeps reverse proxies from buffering or closing the
+	// stream: it sends X-Accel-Buffering: no and Cache-Control: no-cache,
+	// no-transform, drops Content-Length, flushes after every message and
+	// keeps the stream alive every DefaultProxyKeepAlive unless KeepAlive
+	// is set.
+	ProxySafe bool
+
+	// WriteTimeout, if positive, bounds the time Send waits for a slow
+	// client to accept a message before failing with ErrWriteTimeout.
+	WriteTimeout time.Duration
+
+	// Int64Strings writes the 64-bit integers of the messages as JSON
+	// strings, see Int64Strings.
+	Int64Strings bool
+
+	// JSON, if set, writes the messages in the proto3 JSON mapping, see
+	// Proto3JSON.
+	JSON *Proto3JSON
+}
+
+// A ServerStream writes the messages of a server-streaming method to an
+// http response, as Server-Sent Events if the client accepts
+// text/event-stream and as newline-delimited JSON otherwise. The generated
+// handlers wrap it in the <Service>_<Method>Server interface of the method.
+type ServerStream struct {
+	ctx     context.Context
+	rw      http.ResponseWriter
+	w       io.Writer
+	gz      *gzip.Writer
+	sse     bool
+	opts    StreamOptions
+	mu      sync.Mutex
+	started bool
+	pending int
+	last    time.Time
+	done    chan struct{}
+	rc      *http.ResponseController
+	broken  bool // after a write timeout
+}
+
+// DefaultProxyKeepAlive is the KeepAlive of ProxySafe streams, below the
+// idle timeouts of common proxies (60s for nginx and most load
+// balancers).
+const DefaultProxyKeepAlive = 15 * time.Second
+
+// NewServerStream starts a stream answering r on w. Its context is the
+// one of r, so it is canceled when the client goes away.
+func NewServerStream(w http.ResponseWriter, r *http.Request, opts StreamOptions) *ServerStream {
+	s := &ServerStream{
+		ctx:  context.WithValue(r.Context(), requestKey{}, r),
+		rw:   w,
+		w:    w,
+		sse:  strings.Contains(r.Header.Get("Accept"), "text/event-stream"),
+		opts: opts,
+		last: time.Now(),
+		done: make(chan struct{}),
+		rc:   http.NewResponseController(w),
+	}
+	if s.sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	if opts.ProxySafe {
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.Header().Set("Cache-Control", "no-cache, no-transform")
+		w.Header().Del("Content-Length")
+		s.opts.FlushEvery = 1
+		if s.opts.KeepAlive <= 0 {
+			s.opts.KeepAlive = DefaultProxyKeepAlive
+		}
+	}
+	if opts.Gzip && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		s.gz = gzip.NewWriter(w)
+		s.w = s.gz
+	}
+	if s.opts.KeepAlive > 0 {
+		go s.keepAlive()
+	}
+	return s
+}
+
+func (s *ServerStream) keepAlive() {
+	t := time.NewTicker(s.opts.KeepAlive / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ctx.Done():
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			if !s.broken && now.Sub(s.last) >= s.opts.KeepAlive {
+				if s.sse {
+					io.WriteString(s.w, ": keep-alive\n\n")
+				} else {
+					io.WriteString(s.w, "\n")
+				}
+				s.flush()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// flush sends everything written so far to the client; s.mu is held.
+func (s *ServerStream) flush() error {
+	s.started = true
+	s.pending = 0
+	s.last = time.Now()
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// Context returns the context of the request.
+func (s *ServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SetHeader adds md to the response headers; it fails once the first
+// message was sent.
+func (s *ServerStream) SetHeader(md map[string][]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.New("goweb: SetHeader after the stream started")
+	}
+	for k, v := range md {
+		for _, v := range v {
+			s.rw.Header().Add(k, v)
+		}
+	}
+	return nil
+}
+
+// SendHeader adds md to the response headers and sends them.
+func (s *ServerStream) SendHeader(md map[string][]string) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+	return nil
+}
+
+// SetTrailer sends md as http trailers at the end of the stream.
+func (s *ServerStream) SetTrailer(md map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range md {
+		for _, v := range v {
+			s.rw.Header().Add(http.TrailerPrefix+k, v)
+		}
+	}
+}
+
+// Send writes the message m as one event or line. It blocks while the
+// client does not keep up, up to the write timeout of the stream, and
+// fails with ErrClientGone once the client disconnected.
+func (s *ServerStream) Send(m interface{}) error {
+	return s.SendContext(s.ctx, m)
+}
+
+// SendContext is Send, but also gives up when ctx is done.
+func (s *ServerStream) SendContext(ctx context.Context, m interface{}) error {
+	return s.SendEvent(ctx, "", m)
+}
+
+// SendEvent is SendContext for a message with the event ID id, which an
+// SSE client sends back in the Last-Event-ID header when it reconnects,
+// see LastEventID. NDJSON streams leave the ID out.
+func (s *ServerStream) SendEvent(ctx context.Context, id string, m interface{}) error {
+	var b []byte
+	var err error
+	if s.opts.JSON != nil {
+		b, err = s.opts.JSON.Marshal(m)
+	} else {
+		b, err = marshalJSON(m)
+	}
+	if err == nil && s.opts.Int64Strings {
+		b, err = Int64Strings(m, b)
+		b = bytes.TrimSuffix(b, []byte("\n"))
+	}
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		if s.ctx.Err() != nil {
+			return ErrClientGone
+		}
+		return err
+	}
+	var deadline time.Time
+	if s.opts.WriteTimeout > 0 {
+		deadline = time.Now().Add(s.opts.WriteTimeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	return s.write("", id, b, deadline)
+}
+
+// SendMsg is Send, for the grpc.ServerStream interface.
+func (s *ServerStream) SendMsg(m interface{}) error {
+	return s.Send(m)
+}
+
+// RecvMsg fails: the request of a server-streaming method is decoded by
+// the handler.
+func (s *ServerStream) RecvMsg(m interface{}) error {
+	return errors.New("goweb: RecvMsg on a server stream")
+}
+
+// write writes one event or line and flushes if due. A non-zero deadline
+// bounds the time the client may take to accept it, where the
+// ResponseWriter supports write deadlines.
+func (s *ServerStream) write(event, id string, data []byte, deadline time.Time) error {
+	if s.ctx.Err() != nil {
+		return ErrClientGone
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken {
+		return ErrWriteTimeout
+	}
+	if !deadline.IsZero() {
+		if err := s.rc.SetWriteDeadline(deadline); err == nil {
+			defer s.rc.SetWriteDeadline(time.Time{})
+		}
+	}
+	var buf bytes.Buffer
+	if s.sse {
+		if event != "" {
+			buf.WriteString("event: " + event + "\n")
+		}
+		if id != "" {
+			buf.WriteString("id: " + strings.NewReplacer("\n", "", "\r", "").Replace(id) + "\n")
+		}
+		buf.WriteString("data: ")
+		buf.Write(data)
+		buf.WriteString("\n\n")
+	} else {
+		buf.Write(data)
+		buf.WriteString("\n")
+	}
+	_, err := s.w.Write(buf.Bytes())
+	if err == nil {
+		s.pending++
+		if s.pending >= s.opts.FlushEvery {
+			err = s.flush()
+		}
+	}
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		s.broken = true
+		return ErrWriteTimeout
+	}
+	return ErrClientGone
+}
+
+// Fail reports the error err of the method: with 500 if nothing was
+// sent yet, or else as a final "error" event (SSE) or {"error": ...} line
+// (NDJSON).
+func (s *ServerStream) Fail(err error) {
+	s.mu.Lock()
+	started := s.started || s.pending > 0
+	s.mu.Unlock()
+	if !started && s.gz == nil {
+		s.rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		s.rw.WriteHeader(500)
+		io.WriteString(s.rw, err.Error())
+		return
+	}
+	if s.sse {
+		b, _ := json.Marshal(err.Error())
+		s.write("error", "", b, time.Time{})
+		return
+	}
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	s.write("", "", b, time.Time{})
+}
+
+// Close flushes the stream and stops its keep-alives.
+func (s *ServerStream) Close() {
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken {
+		return
+	}
+	if s.gz != nil {
+		s.gz.Close()
+	}
+	s.rc.Flush()
+}
+
+// LastEventID returns the ID of the last event an SSE client received
+// before it reconnected, from the Last-Event-ID header or the lastEventId
+// query parameter, or "" for a new stream. ctx is the context of a
+// ServerStream, so streaming methods can resume from that event.
+func LastEventID(ctx context.Context) string {
+	r := RequestFrom(ctx)
+	if r == nil {
+		return ""
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// EventID formats the value of an event ID field.
+func EventID(v interface{}) string {
+	return fmt.Sprint(v)
+}
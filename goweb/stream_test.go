@@ -0,0 +1,174 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestServerStream(t *testing.T) {
+	for _, c := range []struct {
+		accept, want string
+	}{
+		{"text/event-stream", "data: {\"n\":1}\n\ndata: {\"n\":2}\n\nevent: error\ndata: \"boom\"\n\n"},
+		{"", "{\"n\":1}\n{\"n\":2}\n{\"error\":\"boom\"}\n"},
+	} {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		s := NewServerStream(w, r, StreamOptions{})
+		s.Send(map[string]int{"n": 1})
+		s.Send(map[string]int{"n": 2})
+		s.Fail(errors.New("boom"))
+		s.Close()
+		if got := w.Body.String(); got != c.want {
+			t.Errorf("Accept %q: got %q, want %q", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestServerStreamProxySafe(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Length", "10")
+	s := NewServerStream(w, r, StreamOptions{ProxySafe: true, FlushEvery: 3})
+	defer s.Close()
+	s.Send(map[string]int{"n": 1})
+	h := w.Header()
+	if h.Get("X-Accel-Buffering") != "no" || h.Get("Cache-Control") != "no-cache, no-transform" || h.Get("Content-Length") != "" {
+		t.Errorf("headers %v", h)
+	}
+	if !w.Flushed || s.opts.KeepAlive != DefaultProxyKeepAlive {
+		t.Errorf("flushed %v, keep-alive %v", w.Flushed, s.opts.KeepAlive)
+	}
+}
+
+func TestServerStreamGzip(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	s := NewServerStream(w, r, StreamOptions{Gzip: true, FlushEvery: 2})
+	s.Send(1)
+	if w.Flushed {
+		t.Errorf("flushed before FlushEvery messages")
+	}
+	s.Send(2)
+	s.Close()
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(zr)
+	if string(b) != "1\n2\n" {
+		t.Errorf("got %q", b)
+	}
+}
+
+func TestServerStreamFailEarly(t *testing.T) {
+	w := httptest.NewRecorder()
+	s := NewServerStream(w, httptest.NewRequest("POST", "/", nil), StreamOptions{})
+	s.Fail(errors.New("boom"))
+	s.Close()
+	if w.Code != 500 || w.Body.String() != "boom" {
+		t.Errorf("got %d %q", w.Code, w.Body)
+	}
+}
+
+func TestServerStreamClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("POST", "/", nil).WithContext(ctx)
+	s := NewServerStream(httptest.NewRecorder(), r, StreamOptions{})
+	defer s.Close()
+	if err := s.Send(1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := s.Send(2); err != ErrClientGone {
+		t.Errorf("Send after disconnect = %v, want ErrClientGone", err)
+	}
+}
+
+func TestServerStreamWriteTimeout(t *testing.T) {
+	result := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := NewServerStream(w, r, StreamOptions{WriteTimeout: 50 * time.Millisecond})
+		defer s.Close()
+		big := strings.Repeat("x", 1<<20)
+		for i := 0; i < 1000; i++ {
+			if err := s.Send(big); err != nil {
+				result <- err
+				return
+			}
+		}
+		result <- nil
+	}))
+	defer srv.Close()
+	res, err := http.Get(srv.URL) // never read
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	select {
+	case err := <-result:
+		if err != ErrWriteTimeout {
+			t.Errorf("Send to a stalled client = %v, want ErrWriteTimeout", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Send blocked on a stalled client")
+	}
+}
+
+func TestServerStreamEventID(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set("Last-Event-ID", "41")
+	w := httptest.NewRecorder()
+	s := NewServerStream(w, r, StreamOptions{})
+	if id := LastEventID(s.Context()); id != "41" {
+		t.Errorf("LastEventID = %q", id)
+	}
+	s.SendEvent(s.Context(), EventID(42), 1)
+	s.Close()
+	if got, want := w.Body.String(), "id: 42\ndata: 1\n\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
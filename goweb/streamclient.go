@@ -0,0 +1,240 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A ClientStream reads the messages of a server-streaming method served
+// by a goweb.ServerStream. It asks for Server-Sent Events, but also reads
+// newline-delimited JSON from servers that only send that. The generated http
+// clients wrap it in a <Service>_<Method>HTTPClientStream with a typed Recv.
+type ClientStream struct {
+	u      *Upstream
+	route  Route
+	body   []byte
+	ctx    context.Context
+	cancel context.CancelFunc
+	res    *http.Response
+	r      *bufio.Reader
+	sse    bool
+	lastID string
+	tries  int
+}
+
+// Stream posts in to the server-streaming method of route and returns the
+// stream of its responses. Canceling ctx or calling Close ends it.
+func (u *Upstream) Stream(ctx context.Context, route Route, in interface{}) (*ClientStream, error) {
+	body, err := u.encode(ctx, route, in)
+	if err != nil {
+		return nil, err
+	}
+	s := &ClientStream{u: u, route: route, body: body}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	if err := s.open(); err != nil {
+		s.cancel()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open (re)opens the stream, resuming after the last event received.
+func (s *ClientStream) open() error {
+	start := time.Now()
+	err := s.connect(start)
+	if err != nil && s.u.Hooks.OnError != nil {
+		s.u.Hooks.OnError(s.ctx, s.route, err, time.Since(start))
+	}
+	return err
+}
+
+func (s *ClientStream) connect(start time.Time) error {
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if s.lastID != "" {
+		header.Set("Last-Event-ID", s.lastID)
+	}
+	req, err := s.u.request(s.ctx, s.route, s.body, header)
+	if err != nil {
+		return err
+	}
+	if s.u.Hooks.OnRequest != nil {
+		s.u.Hooks.OnRequest(s.ctx, s.route, req)
+	}
+	res, err := s.u.client().Do(req.WithContext(s.ctx))
+	if err != nil {
+		return err
+	}
+	if s.u.Hooks.OnResponse != nil {
+		s.u.Hooks.OnResponse(s.ctx, s.route, res, time.Since(start))
+	}
+	if err := gzipResponse(res); err != nil {
+		res.Body.Close()
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		var content bytes.Buffer
+		content.ReadFrom(res.Body)
+		return DecodeError(res.StatusCode, content.Bytes())
+	}
+	s.res = res
+	s.r = bufio.NewReader(res.Body)
+	s.sse = strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream")
+	return nil
+}
+
+// Context returns the context of the stream.
+func (s *ClientStream) Context() context.Context {
+	return s.ctx
+}
+
+// LastEventID returns the ID of the last event received.
+func (s *ClientStream) LastEventID() string {
+	return s.lastID
+}
+
+// RecvMsg decodes the next message into m. It returns io.EOF at the end
+// of the stream, the error of the method if it failed, and ctx.Err() once
+// the context is done. A broken connection is reopened up to
+// Upstream.Reconnect times.
+func (s *ClientStream) RecvMsg(m interface{}) error {
+	for {
+		event, data, err := s.next()
+		if err == nil {
+			if event == "error" {
+				var msg string
+				json.Unmarshal(data, &msg)
+				return &Error{Message: msg}
+			}
+			return s.u.unmarshal(s.route, data, m)
+		}
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		if err == io.EOF || s.tries >= s.u.Reconnect {
+			return err
+		}
+		s.tries++
+		s.res.Body.Close()
+		delay := s.u.ReconnectDelay
+		if delay == 0 {
+			delay = time.Second
+		}
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+}
+
+// next reads the next event with data.
+func (s *ClientStream) next() (event string, data []byte, err error) {
+	if !s.sse {
+		return s.nextLine()
+	}
+	var buf bytes.Buffer
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if buf.Len() > 0 {
+				return event, buf.Bytes(), nil
+			}
+			event = ""
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "id":
+			s.lastID = value
+		case "data":
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(value)
+		}
+	}
+}
+
+// nextLine reads the next non-empty line of an NDJSON stream, which is an
+// "error" event if it is the {"error": ...} object a failed method ends
+// its stream with.
+func (s *ClientStream) nextLine() (event string, data []byte, err error) {
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF && len(bytes.TrimSpace(line)) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", nil, err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var e map[string]json.RawMessage
+		if json.Unmarshal(line, &e) == nil && len(e) == 1 && e["error"] != nil {
+			return "error", e["error"], nil
+		}
+		return "", line, nil
+	}
+}
+
+// Close ends the stream.
+func (s *ClientStream) Close() error {
+	s.cancel()
+	return s.res.Body.Close()
+}
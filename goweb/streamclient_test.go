@@ -0,0 +1,103 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestClientStream(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := NewServerStream(w, r, StreamOptions{})
+		defer stream.Close()
+		switch LastEventID(stream.Context()) {
+		case "":
+			stream.SendEvent(stream.Context(), "1", map[string]int{"n": 1})
+			panic(http.ErrAbortHandler) // connection breaks
+		case "1":
+			stream.SendEvent(stream.Context(), "2", map[string]int{"n": 2})
+			stream.Fail(errors.New("boom"))
+		}
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, Reconnect: 1, ReconnectDelay: time.Millisecond}
+	stream, err := u.Stream(context.Background(), Route{Path: "/watch"}, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var m struct{ N int }
+	for _, n := range []int{1, 2} {
+		if err := stream.RecvMsg(&m); err != nil || m.N != n {
+			t.Fatalf("message %d: %v %v", n, m, err)
+		}
+	}
+	if err := stream.RecvMsg(&m); err == nil || err.Error() != "goweb: boom" {
+		t.Errorf("error event: %v", err)
+	}
+	if stream.LastEventID() != "2" {
+		t.Errorf("LastEventID = %q", stream.LastEventID())
+	}
+}
+
+func TestClientStreamNDJSON(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, "{\"n\":1}\n\n{\"n\":2}\n")
+	}))
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := (&Upstream{BaseURL: s.URL}).Stream(ctx, Route{Path: "/watch"}, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m struct{ N int }
+	for _, n := range []int{1, 2} {
+		if err := stream.RecvMsg(&m); err != nil || m.N != n {
+			t.Fatalf("message %d: %v %v", n, m, err)
+		}
+	}
+	if err := stream.RecvMsg(&m); err != io.EOF {
+		t.Errorf("end of stream: %v", err)
+	}
+	cancel()
+	if err := stream.RecvMsg(&m); err != context.Canceled {
+		t.Errorf("after cancel: %v", err)
+	}
+}
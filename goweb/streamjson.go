@@ -0,0 +1,176 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// streamBufferSize is the size of the buffer of StreamJSON, the most of a
+// streamed response held at a time, but for its largest element.
+const streamBufferSize = 32 << 10
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// StreamJSON writes v as JSON and a newline, like WriteJSON, but without
+// encoding it whole first: the elements of its repeated fields, at any
+// depth, are encoded and written one after the other, so that a huge list
+// takes the memory of its largest element rather than of the whole
+// document. Handlers of methods with the stream_response option write
+// their responses with it. The response is compressed with gzip if o
+// compresses responses (WithGzip) and r accepts it, whatever its size,
+// which is not known in advance; an error stops the response where it
+// occurs, its status and headers being sent.
+func (o ServerOptions) StreamJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	var out io.Writer = w
+	if o.GzipMinBytes > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) && w.Header().Get("Content-Encoding") == "" {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Del("Content-Length")
+			zw := gzipWriters.Get().(*gzip.Writer)
+			zw.Reset(w)
+			defer func() {
+				zw.Close()
+				gzipWriters.Put(zw)
+			}()
+			out = zw
+		}
+	}
+	bw := bufio.NewWriterSize(out, streamBufferSize)
+	if err := streamValue(bw, reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// streamValue writes the JSON of v to w like json.Marshal, the elements
+// of its slices and the fields of its structs one by one.
+func streamValue(w *bufio.Writer, v reflect.Value) error {
+	if !v.IsValid() {
+		_, err := w.WriteString("null")
+		return err
+	}
+	if v.Kind() == reflect.Struct && v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+		return streamLeaf(w, v.Addr())
+	}
+	if v.Type().Implements(marshalerType) || v.Kind() != reflect.Ptr && v.Kind() != reflect.Struct && v.Kind() != reflect.Slice ||
+		v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return streamLeaf(w, v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return streamLeaf(w, v)
+		}
+		return streamValue(w, v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			_, err := w.WriteString("null")
+			return err
+		}
+		w.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := streamValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+	}
+	w.WriteByte('{')
+	first := true
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if f.PkgPath != "" || f.Anonymous || tag[0] == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "omitempty" && isEmptyValue(v.Field(i)) {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = f.Name
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(name)
+		w.Write(key)
+		w.WriteByte(':')
+		if err := streamValue(w, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte('}')
+}
+
+// streamLeaf writes the JSON of v to w, encoded whole with MarshalJSON.
+func streamLeaf(w *bufio.Writer, v reflect.Value) error {
+	b, err := MarshalJSON(v.Interface())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// isEmptyValue reports whether v is left out of the JSON of its struct
+// with the omitempty option, as encoding/json has it.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
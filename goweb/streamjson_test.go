@@ -0,0 +1,92 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestStreamJSON(t *testing.T) {
+	file := &pb.FileDescriptorProto{Name: proto.String("a<b>.proto"), Dependency: []string{"x", "y"}}
+	for i := 0; i < 1000; i++ {
+		file.MessageType = append(file.MessageType, &pb.DescriptorProto{
+			Name:  proto.String("M" + strings.Repeat("x", i%7)),
+			Field: []*pb.FieldDescriptorProto{{Name: proto.String("f"), Number: proto.Int32(int32(i))}},
+		})
+	}
+	for _, v := range []interface{}{file, &pb.FileDescriptorProto{}, []string(nil), struct {
+		A []int `json:"a"`
+		B []byte
+		C map[string]int `json:"c,omitempty"`
+	}{A: []int{1, 2}, B: []byte("b")}} {
+		var want bytes.Buffer
+		json.NewEncoder(&want).Encode(v)
+		w := httptest.NewRecorder()
+		if err := (ServerOptions{}).StreamJSON(w, httptest.NewRequest("GET", "/", nil), v); err != nil {
+			t.Fatal(err)
+		}
+		if w.Body.String() != want.String() {
+			t.Errorf("StreamJSON(%T) = %.200s, want %.200s", v, w.Body, &want)
+		}
+	}
+
+	opts := NewServerOptions(WithGzip(1 << 20))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	if err := opts.StreamJSON(w, r, file); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip: %v %v", w.Header(), err)
+	}
+	b, _ := ioutil.ReadAll(zr)
+	if want, _ := json.Marshal(file); string(b) != string(want)+"\n" {
+		t.Errorf("gzipped = %.200s", b)
+	}
+
+	w = httptest.NewRecorder()
+	if err := (ServerOptions{}).StreamJSON(w, r, []float64{1, math.Inf(1)}); err == nil {
+		t.Errorf("StreamJSON of an infinite float = %s", w.Body)
+	}
+}
@@ -0,0 +1,312 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zenazn/goji/web"
+)
+
+// A PathTemplate is a parsed path template of a google.api.http annotation,
+// e.g. "/v1/users/{user_id}" or "/v1/{name=shelves/*/books/*}:publish":
+// literal segments, "*" for any one segment, "**" for the rest of the path,
+// variables binding a field of the request to the segments they match
+// ("*" if not given) and a custom verb after ":".
+type PathTemplate struct {
+	segments []string      // literals, "*" and "**"
+	vars     []templateVar // in order
+	verb     string        // without ":"
+}
+
+// A templateVar binds the field path to the segments [start, end).
+type templateVar struct {
+	field      string
+	start, end int
+}
+
+// ParseTemplate parses the path template s.
+func ParseTemplate(s string) (*PathTemplate, error) {
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("goweb: path template %q does not start with /", s)
+	}
+	t := &PathTemplate{}
+	rest := s[1:]
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.ContainsAny(rest[i:], "/}") {
+		rest, t.verb = rest[:i], rest[i+1:]
+	}
+	for rest != "" {
+		var seg string
+		if strings.HasPrefix(rest, "{") {
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return nil, fmt.Errorf("goweb: path template %q has an unclosed variable", s)
+			}
+			seg, rest = rest[1:end], rest[end+1:]
+			field, pattern := seg, "*"
+			if i := strings.Index(seg, "="); i >= 0 {
+				field, pattern = seg[:i], seg[i+1:]
+			}
+			if field == "" || strings.ContainsAny(field, "{*/") {
+				return nil, fmt.Errorf("goweb: path template %q has a bad variable {%s}", s, seg)
+			}
+			v := templateVar{field: field, start: len(t.segments)}
+			for _, p := range strings.Split(pattern, "/") {
+				t.segments = append(t.segments, p)
+			}
+			v.end = len(t.segments)
+			t.vars = append(t.vars, v)
+		} else {
+			i := strings.Index(rest, "/")
+			if i < 0 {
+				i = len(rest)
+			}
+			seg, rest = rest[:i], rest[i:]
+			t.segments = append(t.segments, seg)
+		}
+		if rest != "" && !strings.HasPrefix(rest, "/") {
+			return nil, fmt.Errorf("goweb: path template %q has a variable inside a segment", s)
+		}
+		rest = strings.TrimPrefix(rest, "/")
+	}
+	for i, seg := range t.segments {
+		if seg == "" || strings.ContainsAny(seg, "{}=") || seg == "**" && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("goweb: path template %q has a bad segment %q", s, seg)
+		}
+	}
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics if s does not parse,
+// for the templates of generated code.
+func MustParseTemplate(s string) *PathTemplate {
+	t, err := ParseTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Fields returns the field paths of the variables of t, e.g. "user_id".
+func (t *PathTemplate) Fields() []string {
+	fields := make([]string, len(t.vars))
+	for i, v := range t.vars {
+		fields[i] = v.field
+	}
+	return fields
+}
+
+// Match matches the escaped path, without the mux prefix, and returns the
+// unescaped values of its variables by field path.
+func (t *PathTemplate) Match(path string) (map[string]string, bool) {
+	if t.verb != "" {
+		if !strings.HasSuffix(path, ":"+t.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+t.verb)
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		parts = nil
+	}
+	// ends[i] is the end of the parts that segment i matched
+	ends := make([]int, len(t.segments))
+	n := 0
+	for i, seg := range t.segments {
+		switch seg {
+		case "**":
+			n = len(parts)
+		case "*":
+			if n >= len(parts) || parts[n] == "" {
+				return nil, false
+			}
+			n++
+		default:
+			if n >= len(parts) || parts[n] != seg {
+				return nil, false
+			}
+			n++
+		}
+		ends[i] = n
+	}
+	if n != len(parts) {
+		return nil, false
+	}
+	vars := make(map[string]string, len(t.vars))
+	for _, v := range t.vars {
+		from := 0
+		if v.start > 0 {
+			from = ends[v.start-1]
+		}
+		value, err := url.PathUnescape(strings.Join(parts[from:ends[v.end-1]], "/"))
+		if err != nil {
+			return nil, false
+		}
+		vars[v.field] = value
+	}
+	return vars, true
+}
+
+// Expand returns the path of t, relative to the mux prefix, with the
+// values of its variables from vars, escaped; values of variables matching
+// several segments keep their slashes.
+func (t *PathTemplate) Expand(vars map[string]string) (string, error) {
+	parts := make([]string, 0, len(t.segments))
+	for i := 0; i < len(t.segments); {
+		v := t.varAt(i)
+		if v == nil {
+			parts = append(parts, t.segments[i])
+			i++
+			continue
+		}
+		value, ok := vars[v.field]
+		if !ok || value == "" {
+			return "", fmt.Errorf("goweb: no value for the path variable %s", v.field)
+		}
+		if v.end-v.start == 1 && t.segments[v.start] == "*" {
+			value = url.PathEscape(value)
+		} else {
+			segs := strings.Split(value, "/")
+			for j := range segs {
+				segs[j] = url.PathEscape(segs[j])
+			}
+			value = strings.Join(segs, "/")
+		}
+		parts = append(parts, value)
+		i = v.end
+	}
+	path := strings.Join(parts, "/")
+	if t.verb != "" {
+		path += ":" + t.verb
+	}
+	return path, nil
+}
+
+// samples returns sample values matching the template for its variables,
+// by field path.
+func (t *PathTemplate) samples() map[string]string {
+	vars := map[string]string{}
+	for _, v := range t.vars {
+		segs := make([]string, v.end-v.start)
+		for j, seg := range t.segments[v.start:v.end] {
+			if seg == "*" || seg == "**" {
+				seg = "1"
+			}
+			segs[j] = seg
+		}
+		vars[v.field] = strings.Join(segs, "/")
+	}
+	return vars
+}
+
+func (t *PathTemplate) varAt(segment int) *templateVar {
+	for i := range t.vars {
+		if t.vars[i].start == segment {
+			return &t.vars[i]
+		}
+	}
+	return nil
+}
+
+// literalPrefix returns the path up to the first segment that is not a
+// literal.
+func (t *PathTemplate) literalPrefix() string {
+	var prefix string
+	for i, seg := range t.segments {
+		if seg == "*" || seg == "**" || t.varAt(i) != nil {
+			return prefix
+		}
+		prefix += seg
+		if i < len(t.segments)-1 {
+			prefix += "/"
+		}
+	}
+	return prefix
+}
+
+// TemplatePattern returns the goji pattern of the path template of a
+// route under prefix, for the verb ("GET" also matches HEAD). Generated
+// muxes route the methods with google.api.http annotations with it; Run
+// sets the variables as the URLParams of the context, by field path, see
+// BindPath.
+func TemplatePattern(prefix, verb string, t *PathTemplate) web.Pattern {
+	return templatePattern{strings.TrimSuffix(prefix, "/"), verb, t}
+}
+
+type templatePattern struct {
+	prefix string
+	verb   string
+	t      *PathTemplate
+}
+
+func (p templatePattern) Prefix() string {
+	return p.prefix + "/" + p.t.literalPrefix()
+}
+
+func (p templatePattern) match(r *http.Request) (map[string]string, bool) {
+	if r.Method != p.verb && !(p.verb == "GET" && r.Method == "HEAD") {
+		return nil, false
+	}
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, p.prefix+"/") {
+		return nil, false
+	}
+	return p.t.Match(path[len(p.prefix):])
+}
+
+func (p templatePattern) Match(r *http.Request, c *web.C) bool {
+	_, ok := p.match(r)
+	return ok
+}
+
+func (p templatePattern) Run(r *http.Request, c *web.C) {
+	vars, _ := p.match(r)
+	if c.URLParams == nil {
+		c.URLParams = make(map[string]string, len(vars))
+	}
+	for k, v := range vars {
+		c.URLParams[k] = v
+	}
+}
+
+// shape returns the requests t matches regardless of its variables: its
+// segments, with "*" and "**" for those of variables, and custom verb.
+func (t *PathTemplate) shape() string {
+	s := "/" + strings.Join(t.segments, "/")
+	if t.verb != "" {
+		s += ":" + t.verb
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestPathTemplate(t *testing.T) {
+	for _, bad := range []string{"v1/users", "/v1/{id", "/v1/{}", "/v1/**/x", "/v1/a{id}", "/v1//x"} {
+		if _, err := ParseTemplate(bad); err == nil {
+			t.Errorf("ParseTemplate(%q) did not fail", bad)
+		}
+	}
+	for _, tc := range []struct {
+		template, path string
+		vars           map[string]string
+	}{
+		{"/v1/users/{user_id}", "/v1/users/42", map[string]string{"user_id": "42"}},
+		{"/v1/users/{user_id}", "/v1/users/a%2Fb", map[string]string{"user_id": "a/b"}},
+		{"/v1/users/{user_id}", "/v1/users/42/x", nil},
+		{"/v1/users/{user_id}", "/v1/users/", nil},
+		{"/v1/{name=shelves/*/books/*}:publish", "/v1/shelves/1/books/2:publish", map[string]string{"name": "shelves/1/books/2"}},
+		{"/v1/{name=shelves/*/books/*}:publish", "/v1/shelves/1/books/2", nil},
+		{"/files/{path=**}", "/files/a/b/c.txt", map[string]string{"path": "a/b/c.txt"}},
+		{"/v1/*/items/{item.id}", "/v1/x/items/7", map[string]string{"item.id": "7"}},
+		{"/health", "/health", map[string]string{}},
+	} {
+		tmpl, err := ParseTemplate(tc.template)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q): %v", tc.template, err)
+		}
+		vars, ok := tmpl.Match(tc.path)
+		if ok != (tc.vars != nil) || ok && !reflect.DeepEqual(vars, tc.vars) {
+			t.Errorf("%s Match(%s) = %v, %v, want %v", tc.template, tc.path, vars, ok, tc.vars)
+		}
+		if !ok || strings.Contains(tc.template, "/*/") {
+			// anonymous wildcards cannot be expanded back
+			continue
+		}
+		if path, err := tmpl.Expand(vars); err != nil || "/"+path != tc.path {
+			t.Errorf("%s Expand(%v) = %s, %v, want %s", tc.template, vars, path, err, tc.path)
+		}
+	}
+	if _, err := MustParseTemplate("/v1/users/{user_id}").Expand(nil); err == nil {
+		t.Errorf("Expand without the value of a variable did not fail")
+	}
+}
+
+func TestTemplatePattern(t *testing.T) {
+	mux := web.New()
+	mux.Handle(TemplatePattern("/api/", "GET", MustParseTemplate("/v1/users/{user_id}")), func(c web.C, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(c.URLParams["user_id"]))
+	})
+	mux.Handle(TemplatePattern("/api/", "GET", MustParseTemplate("/v1/users")), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("list"))
+	})
+	for _, tc := range []struct{ method, path, want string }{
+		{"GET", "/api/v1/users/42", "42"},
+		{"HEAD", "/api/v1/users/42", "42"},
+		{"POST", "/api/v1/users/42", "404"},
+		{"GET", "/v1/users/42", "404"},
+		{"GET", "/api/v1/users", "list"},
+		{"GET", "/api/v1/usersx", "404"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		got := w.Body.String()
+		if w.Code == 404 {
+			got = "404"
+		}
+		if got != tc.want {
+			t.Errorf("%s %s = %d %q, want %q", tc.method, tc.path, w.Code, got, tc.want)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	var s sample
+	if err := BindPath(&s, map[string]string{"value": "1.5", "next.ratio": "2"}); err != nil || s.Value != 1.5 || s.Next.Ratio != 2 {
+		t.Errorf("BindPath = %+v, %v", s, err)
+	}
+	q := url.Values{"series": {"1", "2"}, "next.value": {"3"}, "unknown": {"x"}, "value": {"9"}}
+	if err := BindQuery(&s, q, "value"); err != nil || len(s.Series) != 2 || s.Series[1] != 2 || s.Next.Value != 3 || s.Value != 1.5 {
+		t.Errorf("BindQuery = %+v, %v", s, err)
+	}
+	if err := BindQuery(&s, url.Values{"value": {"x"}}); err == nil || !strings.Contains(err.Error(), "query parameter value") {
+		t.Errorf("BindQuery of a bad number = %v", err)
+	}
+	if err := BindQuery(&s, url.Values{"ratio": {"1", "2"}}); err == nil {
+		t.Errorf("BindQuery of a repeated singular field did not fail")
+	}
+	var p palette
+	if err := BindQuery(&p, url.Values{"main": {"GREEN"}, "others": {"RED", "2"}}); err != nil || p.Main != 2 || p.Others[0] != 1 || p.Others[1] != 2 {
+		t.Errorf("BindQuery of enums = %+v, %v", p, err)
+	}
+	if got := string(WrapBody("next", []byte(" {\"value\":1}\n"))); got != `{"next":{"value":1}}` {
+		t.Errorf("WrapBody = %s", got)
+	}
+	if got := string(WrapBody("next", nil)); got != `{}` {
+		t.Errorf("WrapBody of no body = %s", got)
+	}
+}
+
+func TestUpstreamTemplate(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	u := &Upstream{BaseURL: srv.URL + "/api"}
+	in := &sample{Value: 1, Series: []float64{2, 3}, Next: &sample{Ratio: 4}}
+	for _, r := range []Route{
+		{Verb: "GET", Path: "v1/samples/{value}"},
+		{Verb: "POST", Path: "v1/samples/{next.ratio}:run", Body: "*"},
+		{Verb: "PUT", Path: "v1/samples/{value}", Body: "next"},
+	} {
+		if err := u.Call(context.Background(), r, in, &sample{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"GET /api/v1/samples/1?next.ratio=4&series=2&series=3 ",
+		`POST /api/v1/samples/4:run {"value":1,"series":[2,3],"next":{"ratio":4}}`,
+		`PUT /api/v1/samples/1?series=2&series=3 {"ratio":4}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+	if err := u.Call(context.Background(), Route{Verb: "GET", Path: "v1/{missing}"}, in, &sample{}); err == nil {
+		t.Errorf("Call without a path variable did not fail")
+	}
+}
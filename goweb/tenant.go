@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "golang.org/x/net/context"
+
+// TenantHeader carries the tenant of a request to methods whose request
+// has a field with the goweb.tenant option.
+var TenantHeader = "X-Tenant-Id"
+
+var (
+	// ErrNoTenant reports a call of a multi-tenant method without a tenant.
+	ErrNoTenant = &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: "tenant required"}
+
+	// ErrTenantMismatch reports a call naming different tenants in its
+	// context, header and request.
+	ErrTenantMismatch = &Error{Status: 403, Code: "PERMISSION_DENIED", Message: "tenant mismatch"}
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant t, e.g. set by
+// authentication middleware on the context of the request, or by callers
+// of an Upstream, which sends it in the TenantHeader.
+func WithTenant(ctx context.Context, t string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// TenantFrom returns the tenant set with WithTenant, or "". Generated
+// handlers of multi-tenant methods set it to the tenant of the call.
+func TenantFrom(ctx context.Context) string {
+	t, _ := value(ctx, tenantKey{}).(string)
+	return t
+}
+
+// ResolveTenant returns the tenant of a call with the context ctx of its
+// request and the value field of the tenant field of its request: the
+// tenant of the context (see WithTenant), the TenantHeader and field must
+// agree where they are set (or else ErrTenantMismatch) and at least one
+// must be set (or else ErrNoTenant).
+func ResolveTenant(ctx context.Context, field string) (string, error) {
+	tenant := ""
+	sources := []string{TenantFrom(ctx), field}
+	if r := RequestFrom(ctx); r != nil {
+		sources = append(sources, r.Header.Get(TenantHeader))
+	}
+	for _, t := range sources {
+		switch {
+		case t == "":
+		case tenant == "":
+			tenant = t
+		case t != tenant:
+			return "", ErrTenantMismatch
+		}
+	}
+	if tenant == "" {
+		return "", ErrNoTenant
+	}
+	return tenant, nil
+}
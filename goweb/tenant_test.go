@@ -0,0 +1,69 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestResolveTenant(t *testing.T) {
+	for _, test := range []struct {
+		auth, header, field string
+		want                string
+		err                 error
+	}{
+		{"", "", "acme", "acme", nil},
+		{"", "acme", "", "acme", nil},
+		{"acme", "acme", "acme", "acme", nil},
+		{"acme", "", "", "acme", nil},
+		{"acme", "", "evil", "", ErrTenantMismatch},
+		{"", "acme", "evil", "", ErrTenantMismatch},
+		{"", "", "", "", ErrNoTenant},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(""))
+		if test.auth != "" {
+			r = r.WithContext(WithTenant(r.Context(), test.auth))
+		}
+		r.Header.Set(TenantHeader, test.header)
+		got, err := ResolveTenant(NewContext(r), test.field)
+		if got != test.want || err != test.err {
+			t.Errorf("%+v: ResolveTenant = %q, %v", test, got, err)
+		}
+	}
+	if got, _ := ResolveTenant(context.Background(), "acme"); got != "acme" {
+		t.Errorf("ResolveTenant without request = %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ProtoTextContentType is the media type of messages in the text format
+// of protobuf, which handlers generated with the text_format parameter
+// accept and answer besides JSON in developer mode (see DevMode), for
+// debugging with curl.
+const ProtoTextContentType = "text/x-protobuf"
+
+// IsProtoText reports whether the media type contentType, e.g. the
+// Content-Type of a request, is the text format of protobuf:
+// text/x-protobuf or text/protobuf.
+func IsProtoText(contentType string) bool {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return typ == ProtoTextContentType || typ == "text/protobuf"
+}
+
+// SendsProtoText reports whether the body of the request is in the text
+// format of protobuf and may be decoded as such: only in developer mode.
+func SendsProtoText(r *http.Request) bool {
+	return devMode() && IsProtoText(r.Header.Get("Content-Type"))
+}
+
+// AcceptsProtoText reports whether the request prefers a response in the
+// text format of protobuf, like AcceptsProtobuf, and may get one: only in
+// developer mode.
+func AcceptsProtoText(r *http.Request) bool {
+	return devMode() && IsProtoText(preferredType(r))
+}
+
+// UnmarshalProtoText decodes the message m from the text format of
+// protobuf.
+func UnmarshalProtoText(data []byte, m proto.Message) error {
+	return proto.UnmarshalText(string(data), m)
+}
+
+// WriteProtoText writes the message m as the response, in the text format
+// of protobuf.
+func WriteProtoText(w http.ResponseWriter, m proto.Message) error {
+	var buf bytes.Buffer
+	if err := proto.MarshalText(&buf, m); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ProtoTextContentType+"; charset=utf-8")
+	w.Write(buf.Bytes())
+	return nil
+}
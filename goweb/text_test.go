@@ -0,0 +1,64 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestProtoText(t *testing.T) {
+	for typ, want := range map[string]bool{
+		"text/x-protobuf": true, "text/protobuf; charset=utf-8": true, "text/plain": false, ProtobufContentType: false, "": false,
+	} {
+		if got := IsProtoText(typ); got != want {
+			t.Errorf("IsProtoText(%q) = %v", typ, got)
+		}
+	}
+	w := httptest.NewRecorder()
+	if err := WriteProtoText(w, &pb.FieldDescriptorProto{Name: proto.String("a"), Number: proto.Int32(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != "text/x-protobuf; charset=utf-8" || w.Body.String() != "name: \"a\"\nnumber: 2\n" {
+		t.Errorf("WriteProtoText = %q %q", w.Header().Get("Content-Type"), w.Body)
+	}
+	var m pb.FieldDescriptorProto
+	if err := UnmarshalProtoText(w.Body.Bytes(), &m); err != nil || m.GetName() != "a" || m.GetNumber() != 2 {
+		t.Errorf("UnmarshalProtoText = %v, %v", &m, err)
+	}
+	if err := UnmarshalProtoText([]byte("nmae: 1"), &m); err == nil {
+		t.Error("UnmarshalProtoText of an unknown field succeeded")
+	}
+}
@@ -0,0 +1,110 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"time"
+)
+
+// The timeouts of CallTimeouts besides the calls themselves.
+const (
+	// DefaultReadHeaderTimeout bounds the time clients take to send the
+	// request headers, protecting servers from slow clients holding
+	// connections open.
+	DefaultReadHeaderTimeout = 10 * time.Second
+
+	// DefaultReadTimeout bounds the time clients take to send a request,
+	// body included, for services without uploads.
+	DefaultReadTimeout = 30 * time.Second
+
+	// DefaultIdleTimeout bounds the time keep-alive connections wait for
+	// the next request.
+	DefaultIdleTimeout = 2 * time.Minute
+
+	// TimeoutMargin is added to the write timeout for writing responses
+	// after the calls returned.
+	TimeoutMargin = 5 * time.Second
+)
+
+// ServerTimeouts are the timeouts of an http.Server; zero ones are
+// unlimited. The server_timeouts parameter generates them for every
+// service as <Service>ServerTimeouts, see CallTimeouts.
+type ServerTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// CallTimeouts returns the ServerTimeouts that do not cut off calls of a
+// service whose unary calls take at most longest (0 if some are not
+// bounded). The write timeout of an http.Server runs from the end of the
+// request headers to the end of the response, so it covers reading the
+// body, the call and the response; it is unlimited if longest is 0 or
+// the service has long responses (streams, downloads, web sockets),
+// which a write timeout would silently truncate. The read timeout is
+// unlimited if the service has long requests (uploads).
+func CallTimeouts(longest time.Duration, longRequests, longResponses bool) ServerTimeouts {
+	t := ServerTimeouts{ReadHeader: DefaultReadHeaderTimeout, Read: DefaultReadTimeout, Idle: DefaultIdleTimeout}
+	if longRequests {
+		t.Read = 0
+	}
+	if longest > 0 && t.Read > 0 && !longResponses {
+		t.Write = t.Read + longest + TimeoutMargin
+	}
+	return t
+}
+
+// Merge returns the timeouts serving the calls of both t and o, for
+// servers of several services: the longer of each, or unlimited if
+// either is.
+func (t ServerTimeouts) Merge(o ServerTimeouts) ServerTimeouts {
+	longer := func(a, b time.Duration) time.Duration {
+		if a == 0 || b == 0 {
+			return 0
+		}
+		if a > b {
+			return a
+		}
+		return b
+	}
+	return ServerTimeouts{longer(t.ReadHeader, o.ReadHeader), longer(t.Read, o.Read), longer(t.Write, o.Write), longer(t.Idle, o.Idle)}
+}
+
+// Apply sets the timeouts of s to t.
+func (t ServerTimeouts) Apply(s *http.Server) {
+	s.ReadHeaderTimeout = t.ReadHeader
+	s.ReadTimeout = t.Read
+	s.WriteTimeout = t.Write
+	s.IdleTimeout = t.Idle
+}
@@ -0,0 +1,70 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCallTimeouts(t *testing.T) {
+	for _, c := range []struct {
+		longest                     time.Duration
+		longRequests, longResponses bool
+		read, write                 time.Duration
+	}{
+		{20 * time.Second, false, false, DefaultReadTimeout, DefaultReadTimeout + 20*time.Second + TimeoutMargin},
+		{0, false, false, DefaultReadTimeout, 0},
+		{20 * time.Second, true, false, 0, 0},
+		{20 * time.Second, false, true, DefaultReadTimeout, 0},
+	} {
+		got := CallTimeouts(c.longest, c.longRequests, c.longResponses)
+		if got.Read != c.read || got.Write != c.write || got.ReadHeader != DefaultReadHeaderTimeout || got.Idle != DefaultIdleTimeout {
+			t.Errorf("CallTimeouts(%v, %v, %v) = %+v", c.longest, c.longRequests, c.longResponses, got)
+		}
+	}
+}
+
+func TestServerTimeoutsMerge(t *testing.T) {
+	a := ServerTimeouts{ReadHeader: time.Second, Read: 2 * time.Second, Write: 3 * time.Second}
+	b := ServerTimeouts{ReadHeader: 2 * time.Second, Read: time.Second, Write: 5 * time.Second, Idle: time.Minute}
+	want := ServerTimeouts{ReadHeader: 2 * time.Second, Read: 2 * time.Second, Write: 5 * time.Second}
+	if got := a.Merge(b); got != want {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+	var s http.Server
+	want.Apply(&s)
+	if s.ReadHeaderTimeout != want.ReadHeader || s.ReadTimeout != want.Read || s.WriteTimeout != want.Write || s.IdleTimeout != 0 {
+		t.Errorf("Apply: %v %v %v %v", s.ReadHeaderTimeout, s.ReadTimeout, s.WriteTimeout, s.IdleTimeout)
+	}
+}
@@ -0,0 +1,96 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+// The ranges of google.protobuf.Timestamp and google.protobuf.Duration in
+// the protobuf spec: timestamps from 0001-01-01T00:00:00Z to
+// 9999-12-31T23:59:59.999999999Z, durations up to about 10000 years.
+const (
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+	maxDurationSeconds  = 315576000000
+	maxNanos            = 999999999
+)
+
+// A RangeError reports a google.protobuf.Timestamp or Duration in a request
+// that is outside the range of the protobuf spec. Generated handlers answer
+// it with 400.
+type RangeError struct {
+	Field  string // the full name of the field
+	Reason string // e.g. "before 0001-01-01T00:00:00Z"
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("goweb: field %s is %s", e.Field, e.Reason)
+}
+
+// CheckTimestamp returns a *RangeError naming field if t is not a valid
+// timestamp. Generated handlers check every Timestamp of a request with
+// it while decoding it.
+func CheckTimestamp(field string, t *timestamp.Timestamp) error {
+	switch {
+	case t == nil:
+		return nil
+	case t.Seconds < minTimestampSeconds:
+		return &RangeError{field, "before 0001-01-01T00:00:00Z"}
+	case t.Seconds > maxTimestampSeconds:
+		return &RangeError{field, "after 9999-12-31T23:59:59.999999999Z"}
+	case t.Nanos < 0 || t.Nanos > maxNanos:
+		return &RangeError{field, fmt.Sprintf("a timestamp with nanos %d, outside [0, %d]", t.Nanos, maxNanos)}
+	}
+	return nil
+}
+
+// CheckDuration returns a *RangeError naming field if d is not a valid
+// duration: longer than 10000 years, or with nanos out of range or of
+// another sign than the seconds. Generated handlers check every Duration
+// of a request with it while decoding it.
+func CheckDuration(field string, d *duration.Duration) error {
+	switch {
+	case d == nil:
+		return nil
+	case d.Seconds < -maxDurationSeconds || d.Seconds > maxDurationSeconds:
+		return &RangeError{field, fmt.Sprintf("a duration of %d seconds, longer than 10000 years", d.Seconds)}
+	case d.Nanos < -maxNanos || d.Nanos > maxNanos:
+		return &RangeError{field, fmt.Sprintf("a duration with nanos %d, outside [-%d, %d]", d.Nanos, maxNanos, maxNanos)}
+	case d.Seconds > 0 && d.Nanos < 0 || d.Seconds < 0 && d.Nanos > 0:
+		return &RangeError{field, "a duration whose seconds and nanos have different signs"}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+func TestTimeRanges(t *testing.T) {
+	for _, tc := range []struct {
+		ts   *timestamp.Timestamp
+		want string
+	}{
+		{nil, ""},
+		{&timestamp.Timestamp{Seconds: minTimestampSeconds}, ""},
+		{&timestamp.Timestamp{Seconds: maxTimestampSeconds, Nanos: maxNanos}, ""},
+		{&timestamp.Timestamp{Seconds: minTimestampSeconds - 1}, "before 0001"},
+		{&timestamp.Timestamp{Seconds: maxTimestampSeconds + 1}, "after 9999"},
+		{&timestamp.Timestamp{Nanos: -1}, "nanos -1"},
+	} {
+		err := CheckTimestamp("pkg.M.at", tc.ts)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("CheckTimestamp(%v) = %v, want %q", tc.ts, err, tc.want)
+		}
+	}
+	for _, tc := range []struct {
+		d    *duration.Duration
+		want string
+	}{
+		{&duration.Duration{Seconds: -maxDurationSeconds, Nanos: -maxNanos}, ""},
+		{&duration.Duration{Seconds: maxDurationSeconds + 1}, "longer than 10000 years"},
+		{&duration.Duration{Nanos: 1e9}, "nanos 1000000000"},
+		{&duration.Duration{Seconds: 1, Nanos: -1}, "different signs"},
+		{&duration.Duration{Nanos: -1}, ""},
+	} {
+		err := CheckDuration("pkg.M.wait", tc.d)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("CheckDuration(%v) = %v, want %q", tc.d, err, tc.want)
+		}
+	}
+	if _, ok := CheckTimestamp("f", &timestamp.Timestamp{Nanos: -1}).(*RangeError); !ok {
+		t.Errorf("CheckTimestamp does not return a *RangeError")
+	}
+}
@@ -0,0 +1,128 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// TraceHeaders are the headers of W3C Trace Context and Baggage, which
+// generated handlers pass on to the outbound calls of their calls by
+// default, keeping distributed traces intact across gateways.
+var TraceHeaders = []string{"Traceparent", "Tracestate", "Baggage"}
+
+// WithPropagatedHeaders sets the headers of the incoming requests, e.g.
+// TraceHeaders and "X-Request-Id", that the calls pass on to the
+// outbound calls they make through an Upstream, such as those of the
+// generated http clients and proxies. Without names, none are passed on.
+func WithPropagatedHeaders(names ...string) ServerOption {
+	return func(o *ServerOptions) { o.PropagatedHeaders = append([]string{}, names...) }
+}
+
+type outgoingKey struct{}
+
+// WithOutgoingHeaders returns ctx with h added to the headers that an
+// Upstream sends with the calls made in ctx, for calls made outside of
+// generated handlers.
+func WithOutgoingHeaders(ctx context.Context, h http.Header) context.Context {
+	out := OutgoingHeaders(ctx).Clone()
+	if out == nil {
+		out = http.Header{}
+	}
+	for k, v := range h {
+		out[http.CanonicalHeaderKey(k)] = v
+	}
+	return context.WithValue(ctx, outgoingKey{}, out)
+}
+
+// OutgoingHeaders returns the headers passed on to the outbound calls
+// made in ctx, or nil.
+func OutgoingHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(outgoingKey{}).(http.Header)
+	return h
+}
+
+// traceparent is the syntax of a traceparent header: version, trace id,
+// parent id and flags, and more fields in later versions.
+var traceparent = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}(-|$)`)
+
+// validTraceparent reports whether v is a traceparent header that tracers
+// accept: not of the invalid version ff, with nonzero ids and, in version
+// 00, nothing else.
+func validTraceparent(v string) bool {
+	if !traceparent.MatchString(v) || strings.HasPrefix(v, "ff") || strings.HasPrefix(v, "00") && len(v) != 55 {
+		return false
+	}
+	return strings.Trim(v[3:35], "0") != "" && strings.Trim(v[36:52], "0") != ""
+}
+
+// SampledTraceID returns the trace id of the traceparent header of r if
+// it is valid and its sampled flag is set, so that the trace is recorded,
+// else "".
+func SampledTraceID(r *http.Request) string {
+	v := r.Header.Get("Traceparent")
+	if !validTraceparent(v) {
+		return ""
+	}
+	flags, err := strconv.ParseUint(v[53:55], 16, 8)
+	if err != nil || flags&1 == 0 {
+		return ""
+	}
+	return v[3:35]
+}
+
+// propagatedHeaders returns the headers of r among names; an invalid
+// traceparent is left out together with the tracestate belonging to it.
+func propagatedHeaders(r *http.Request, names []string) http.Header {
+	var h http.Header
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		v := r.Header.Values(name)
+		if len(v) == 0 {
+			continue
+		}
+		if h == nil {
+			h = http.Header{}
+		}
+		h[name] = v
+	}
+	if tp, ok := h["Traceparent"]; ok && (len(tp) != 1 || !validTraceparent(tp[0])) {
+		delete(h, "Traceparent")
+		delete(h, "Tracestate")
+	}
+	return h
+}
@@ -0,0 +1,110 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestValidTraceparent(t *testing.T) {
+	for v, want := range map[string]bool{
+		testTraceparent: true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":       false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":       false,
+		"": false,
+	} {
+		if validTraceparent(v) != want {
+			t.Errorf("validTraceparent(%q) = %v", v, !want)
+		}
+	}
+}
+
+func TestPropagatedHeaders(t *testing.T) {
+	for _, c := range []struct {
+		opts []ServerOption
+		tp   string
+		want http.Header
+	}{
+		{nil, testTraceparent, http.Header{"Traceparent": {testTraceparent}, "Tracestate": {"k=v"}, "Baggage": {"user=1"}}},
+		{nil, "bogus", http.Header{"Baggage": {"user=1"}}},
+		{[]ServerOption{WithPropagatedHeaders("X-Request-Id", "traceparent")}, testTraceparent, http.Header{"X-Request-Id": {"r1"}, "Traceparent": {testTraceparent}}},
+		{[]ServerOption{WithPropagatedHeaders()}, testTraceparent, nil},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Traceparent", c.tp)
+		r.Header.Set("Tracestate", "k=v")
+		r.Header.Set("Baggage", "user=1")
+		r.Header.Set("X-Request-Id", "r1")
+		r.Header.Set("Authorization", "secret")
+		ctx, cancel, err := NewServerOptions(c.opts...).NewContext(httptest.NewRecorder(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if got := OutgoingHeaders(ctx); len(got) != len(c.want) {
+			t.Errorf("traceparent %q: propagated %v, want %v", c.tp, got, c.want)
+		} else {
+			for k, v := range c.want {
+				if got.Get(k) != v[0] {
+					t.Errorf("traceparent %q: %s = %q, want %q", c.tp, k, got.Get(k), v[0])
+				}
+			}
+		}
+	}
+}
+
+func TestUpstreamPropagates(t *testing.T) {
+	var got http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	ctx := WithOutgoingHeaders(context.Background(), http.Header{"traceparent": {testTraceparent}, "Content-Type": {"text/plain"}})
+	var out struct{}
+	if err := (&Upstream{BaseURL: s.URL}).Call(ctx, Route{Method: "Get", Path: "/get"}, struct{}{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Traceparent") != testTraceparent || got.Get("Content-Type") != "application/json" {
+		t.Errorf("headers %v", got)
+	}
+}
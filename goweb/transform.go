@@ -0,0 +1,71 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// A Transformer shapes the response res of a method into the value that
+// is serialized instead, e.g. a struct or map with legacy field aliases
+// or flattened messages, so such view models stay out of the proto.
+type Transformer func(ctx context.Context, res proto.Message) (interface{}, error)
+
+var transformers = struct {
+	sync.RWMutex
+	m map[string]Transformer
+}{m: map[string]Transformer{}}
+
+// RegisterTransformer makes t available to the goweb.transform method
+// option under name, replacing any transformer of that name.
+func RegisterTransformer(name string, t Transformer) {
+	transformers.Lock()
+	defer transformers.Unlock()
+	transformers.m[name] = t
+}
+
+// Transform applies the named transformer to the response res of a
+// method. Generated handlers call it for methods with a goweb.transform
+// option; an unknown transformer is an internal error, since serializing
+// res unchanged would silently change the shape of the response.
+func Transform(ctx context.Context, name string, res proto.Message) (interface{}, error) {
+	transformers.RLock()
+	t, ok := transformers.m[name]
+	transformers.RUnlock()
+	if !ok {
+		return nil, Errorf(500, "INTERNAL", "unknown transformer %q", name)
+	}
+	return t(ctx, res)
+}
@@ -0,0 +1,123 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// EgressOptions configure how a client reaches the network, for
+// generated clients running in restricted networks; see NewHTTPClient.
+type EgressOptions struct {
+	// Proxy is the URL of the HTTP(S) proxy to use, e.g.
+	// "http://proxy.corp:3128". If empty, the proxy is taken from the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string
+
+	// NoProxy disables proxies, including those of the environment.
+	NoProxy bool
+
+	// DialContext, if set, opens the connections instead of a net.Dialer.
+	// Network, FallbackDelay and DialTimeout are ignored then.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Network restricts connections to "tcp4" or "tcp6".
+	Network string
+
+	// FallbackDelay is the time a dual-stack dial waits for IPv6 before
+	// trying IPv4 (Happy Eyeballs, RFC 6555); 0 means 300ms and a
+	// negative value disables the fallback.
+	FallbackDelay time.Duration
+
+	// DialTimeout bounds the time to open a connection; 30s if 0.
+	DialTimeout time.Duration
+
+	// Timeout bounds whole requests; none if 0.
+	Timeout time.Duration
+}
+
+// NewHTTPClient returns an http.Client configured by opts, to be used as
+// the Client of an Upstream.
+func NewHTTPClient(opts EgressOptions) (*http.Client, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	switch {
+	case opts.NoProxy:
+		t.Proxy = nil
+	case opts.Proxy != "":
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if opts.DialContext != nil {
+		t.DialContext = opts.DialContext
+	} else {
+		d := &net.Dialer{
+			Timeout:       opts.DialTimeout,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: opts.FallbackDelay,
+		}
+		if d.Timeout == 0 {
+			d.Timeout = 30 * time.Second
+		}
+		network := opts.Network
+		t.DialContext = func(ctx context.Context, n, addr string) (net.Conn, error) {
+			if network != "" {
+				n = network
+			}
+			return d.DialContext(ctx, n, addr)
+		}
+	}
+	return &http.Client{Transport: t, Timeout: opts.Timeout}, nil
+}
+
+type hostKey struct{}
+
+// WithHost returns a copy of ctx that makes an Upstream send the calls
+// made with it to host: the Host header is set to host, while the
+// connection still goes to the BaseURL, e.g. to reach a virtual host
+// through a fixed address.
+func WithHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, hostKey{}, host)
+}
+
+// HostFrom returns the host set by WithHost, or "".
+func HostFrom(ctx context.Context) string {
+	h, _ := ctx.Value(hostKey{}).(string)
+	return h
+}
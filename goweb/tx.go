@@ -0,0 +1,108 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+
+	"golang.org/x/net/context"
+)
+
+// A Tx is a transaction opened by a TxManager.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// A TxManager opens the transactions of the methods with the
+// goweb.transactional option, e.g. on a database.
+type TxManager interface {
+	Begin(ctx context.Context, route Route) (Tx, error)
+}
+
+// TxManagerFunc adapts a function to a TxManager.
+type TxManagerFunc func(ctx context.Context, route Route) (Tx, error)
+
+// Begin calls f(ctx, route).
+func (f TxManagerFunc) Begin(ctx context.Context, route Route) (Tx, error) { return f(ctx, route) }
+
+// Transactions opens the transactions of transactional methods. Calls of
+// these methods fail with ErrNoTxManager while it is nil.
+var Transactions TxManager
+
+// ErrNoTxManager reports a call of a transactional method without
+// Transactions.
+var ErrNoTxManager = errors.New("goweb: no TxManager for a transactional method")
+
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying the transaction tx.
+func WithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFrom returns the transaction of a call of a transactional method, or
+// nil. Implementations assert it to the type of their TxManager, e.g. a
+// wrapper of *sql.Tx.
+func TxFrom(ctx context.Context) Tx {
+	tx, _ := ctx.Value(txKey{}).(Tx)
+	return tx
+}
+
+// BeginTx opens the transaction of a call of route with Transactions and
+// returns a copy of ctx carrying it. Generated handlers call it before
+// calling a transactional method and EndTx after it.
+func BeginTx(ctx context.Context, route Route) (context.Context, Tx, error) {
+	if Transactions == nil {
+		return ctx, nil, ErrNoTxManager
+	}
+	tx, err := Transactions.Begin(ctx, route)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return WithTx(ctx, tx), tx, nil
+}
+
+// EndTx, deferred, ends the transaction tx of a call whose result is
+// *err: it commits tx if the call succeeded, setting *err to the error of
+// the commit, and rolls it back if the call failed or panicked.
+func EndTx(tx Tx, err *error) {
+	if p := recover(); p != nil {
+		tx.Rollback()
+		panic(p)
+	}
+	if *err != nil {
+		tx.Rollback()
+		return
+	}
+	*err = tx.Commit()
+}
@@ -0,0 +1,85 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+type testTx struct{ ended string }
+
+func (tx *testTx) Commit() error   { tx.ended = "commit"; return nil }
+func (tx *testTx) Rollback() error { tx.ended = "rollback"; return nil }
+
+func TestTx(t *testing.T) {
+	var txs []*testTx
+	Transactions = TxManagerFunc(func(ctx context.Context, route Route) (Tx, error) {
+		tx := &testTx{}
+		txs = append(txs, tx)
+		return tx, nil
+	})
+	defer func() { Transactions = nil }()
+	call := func(fail, panics bool) (err error) {
+		ctx, tx, err := BeginTx(context.Background(), Route{})
+		if err != nil {
+			return err
+		}
+		defer func() { recover() }()
+		return func() (err error) {
+			defer EndTx(tx, &err)
+			if TxFrom(ctx) != tx {
+				t.Error("TxFrom != tx")
+			}
+			if panics {
+				panic("boom")
+			}
+			if fail {
+				return errors.New("fail")
+			}
+			return nil
+		}()
+	}
+	call(false, false)
+	call(true, false)
+	call(false, true)
+	if len(txs) != 3 || txs[0].ended != "commit" || txs[1].ended != "rollback" || txs[2].ended != "rollback" {
+		t.Errorf("transactions %+v %+v %+v", txs[0], txs[1], txs[2])
+	}
+
+	Transactions = nil
+	if _, _, err := BeginTx(context.Background(), Route{}); err != ErrNoTxManager {
+		t.Errorf("BeginTx without manager = %v", err)
+	}
+}
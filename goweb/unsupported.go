@@ -0,0 +1,107 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"log"
+	"net/http"
+	"sync"
+)
+
+// The kinds of UnsupportedUse.
+const (
+	// UnsupportedStreaming is a call of a streaming method the http
+	// handlers answer with 501 Not Implemented.
+	UnsupportedStreaming = "streaming"
+
+	// UnsupportedDeprecated is a call of a method marked deprecated = true.
+	UnsupportedDeprecated = "deprecated"
+)
+
+// An UnsupportedUse is a call of a method the http handlers do not serve
+// or that is deprecated, by client: the methods their API owners should
+// implement streaming support for, or cannot remove yet.
+type UnsupportedUse struct {
+	Method string // e.g. "/pkg.Users/Watch"
+	Kind   string // UnsupportedStreaming or UnsupportedDeprecated
+	Client string // the application of the User-Agent, see ParseUserAgent
+}
+
+// UnsupportedLog is called for every unsupported use with its request and
+// the number of calls so far of the same method, kind and client. By
+// default it logs the first call and then every power of ten, with the
+// User-Agent; set it to log to a structured logger, or to nil to only
+// count the calls, see UnsupportedUses.
+var UnsupportedLog = func(r *http.Request, u UnsupportedUse, n int64) {
+	for n%10 == 0 {
+		n /= 10
+	}
+	if n != 1 {
+		return
+	}
+	log.Printf("goweb: %s call of %s by %q", u.Kind, u.Method, r.UserAgent())
+}
+
+var unsupportedUses struct {
+	sync.Mutex
+	n map[UnsupportedUse]int64
+}
+
+// Unsupported records the call r of route as an unsupported use of the
+// kind: it counts it and calls UnsupportedLog. Generated handlers call it
+// for every call of a deprecated method and before answering 501 to a
+// streaming one.
+func Unsupported(r *http.Request, route Route, kind string) {
+	u := UnsupportedUse{Method: route.FullMethod(), Kind: kind, Client: ParseUserAgent(r.UserAgent()).App}
+	unsupportedUses.Lock()
+	if unsupportedUses.n == nil {
+		unsupportedUses.n = map[UnsupportedUse]int64{}
+	}
+	unsupportedUses.n[u]++
+	n := unsupportedUses.n[u]
+	unsupportedUses.Unlock()
+	if log := UnsupportedLog; log != nil {
+		log(r, u, n)
+	}
+}
+
+// UnsupportedUses returns how many calls of each method, kind and client
+// were unsupported so far, for metrics.
+func UnsupportedUses() map[UnsupportedUse]int64 {
+	unsupportedUses.Lock()
+	defer unsupportedUses.Unlock()
+	uses := make(map[UnsupportedUse]int64, len(unsupportedUses.n))
+	for u, n := range unsupportedUses.n {
+		uses[u] = n
+	}
+	return uses
+}
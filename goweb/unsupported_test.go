@@ -0,0 +1,91 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// resetUnsupportedUses empties the counts of UnsupportedUses until the
+// end of the test.
+func resetUnsupportedUses(t *testing.T) {
+	unsupportedUses.Lock()
+	n := unsupportedUses.n
+	unsupportedUses.n = nil
+	unsupportedUses.Unlock()
+	t.Cleanup(func() {
+		unsupportedUses.Lock()
+		unsupportedUses.n = n
+		unsupportedUses.Unlock()
+	})
+}
+
+func TestUnsupported(t *testing.T) {
+	resetUnsupportedUses(t)
+	defer func(log func(*http.Request, UnsupportedUse, int64)) { UnsupportedLog = log }(UnsupportedLog)
+	var logged []int64
+	UnsupportedLog = func(r *http.Request, u UnsupportedUse, n int64) { logged = append(logged, n) }
+	route := Route{Service: "goweb.Unsupported", Method: "Watch", ServerStreaming: true}
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("User-Agent", "tool/1.2 goweb/1.0 (pkg.Users)")
+	for i := 0; i < 3; i++ {
+		Unsupported(r, route, UnsupportedStreaming)
+	}
+	Unsupported(httptest.NewRequest("POST", "/", nil), route, UnsupportedDeprecated)
+	uses := UnsupportedUses()
+	if n := uses[UnsupportedUse{"/goweb.Unsupported/Watch", UnsupportedStreaming, "tool"}]; n != 3 {
+		t.Errorf("uses = %v", uses)
+	}
+	if n := uses[UnsupportedUse{"/goweb.Unsupported/Watch", UnsupportedDeprecated, ""}]; n != 1 {
+		t.Errorf("uses = %v", uses)
+	}
+	if len(logged) != 4 || logged[2] != 3 || logged[3] != 1 {
+		t.Errorf("logged %v", logged)
+	}
+}
+
+func TestDynamicUnsupported(t *testing.T) {
+	resetUnsupportedUses(t)
+	defer func(log func(*http.Request, UnsupportedUse, int64)) { UnsupportedLog = log }(UnsupportedLog)
+	UnsupportedLog = nil
+	route := Route{Service: "goweb.Unsupported", Method: "Upload", ClientStreaming: true}
+	w := httptest.NewRecorder()
+	dynamicRoute(route, nil)(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != 501 {
+		t.Errorf("code = %d", w.Code)
+	}
+	if n := UnsupportedUses()[UnsupportedUse{"/goweb.Unsupported/Upload", UnsupportedStreaming, ""}]; n != 1 {
+		t.Errorf("uses = %d", n)
+	}
+}
@@ -0,0 +1,139 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+// ErrUploadTooLarge is returned by Upload.Read once the upload exceeds the
+// size limit of its method; the generated handler then answers with 413.
+var ErrUploadTooLarge = errors.New("goweb: upload too large")
+
+// An Upload is the file part of a multipart/form-data request of an upload
+// method, streamed to the implementation instead of being buffered into
+// the request message.
+type Upload struct {
+	Filename    string
+	ContentType string
+
+	part   *multipart.Part
+	max    int64
+	n      int64
+	tooBig int32
+}
+
+// Read reads from the file content.
+func (u *Upload) Read(p []byte) (int, error) {
+	if u.max > 0 {
+		if left := u.max - atomic.LoadInt64(&u.n); int64(len(p)) > left+1 {
+			p = p[:left+1]
+		}
+	}
+	n, err := u.part.Read(p)
+	if total := atomic.AddInt64(&u.n, int64(n)); u.max > 0 && total > u.max {
+		atomic.StoreInt32(&u.tooBig, 1)
+		return n - int(total-u.max), ErrUploadTooLarge
+	}
+	return n, err
+}
+
+// BytesRead returns the number of bytes read so far, for progress reports.
+func (u *Upload) BytesRead() int64 {
+	return atomic.LoadInt64(&u.n)
+}
+
+// TooLarge reports whether the upload exceeded its size limit.
+func (u *Upload) TooLarge() bool {
+	return u != nil && atomic.LoadInt32(&u.tooBig) == 1
+}
+
+type uploadKey struct{}
+
+// WithUpload returns a copy of ctx carrying u.
+func WithUpload(ctx context.Context, u *Upload) context.Context {
+	if u == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, uploadKey{}, u)
+}
+
+// UploadFrom returns the upload of the request of ctx, or nil if it was
+// not a multipart/form-data request with a file.
+func UploadFrom(ctx context.Context) *Upload {
+	u, _ := ctx.Value(uploadKey{}).(*Upload)
+	return u
+}
+
+// ReadUploadRequest reads the request of an upload method. For
+// multipart/form-data requests, the form fields before the first file part
+// are returned as a JSON object, to be decoded into the request message,
+// and the file part as an Upload limited to max bytes (if positive). Other
+// requests are read whole, as JSON.
+func ReadUploadRequest(r *http.Request, max int64) ([]byte, *Upload, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		content, err := ioutil.ReadAll(r.Body)
+		return content, nil, err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := url.Values{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return QueryJSON(fields), nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if part.FileName() != "" {
+			u := &Upload{Filename: part.FileName(), ContentType: part.Header.Get("Content-Type"), part: part, max: max}
+			return QueryJSON(fields), u, nil
+		}
+		v, err := ioutil.ReadAll(io.LimitReader(part, 1<<20))
+		if err != nil {
+			return nil, nil, err
+		}
+		fields.Add(part.FormName(), string(v))
+	}
+}
@@ -0,0 +1,350 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// An Upstream forwards calls to a JSON over HTTP service. The generated
+// New<Service>HTTPProxyMux functions use it to put a proto-validated
+// gateway in front of legacy JSON APIs, and the generated
+// New<Service>HTTPClient functions to call goweb services.
+type Upstream struct {
+	// BaseURL is prepended to the path of each route, e.g. "http://legacy:8080/".
+	BaseURL string
+
+	// Client sends the requests; http.DefaultClient if nil. NewHTTPClient
+	// configures proxies and dialing.
+	Client *http.Client
+
+	// Rewrite maps a method, by name ("GetUser") or by full name
+	// ("/pkg.Users/GetUser"), to the URL it is forwarded to. Relative
+	// URLs are resolved against BaseURL; unlisted methods keep their path.
+	Rewrite map[string]string
+
+	// Transform, if set, is called with the decoded request before it is
+	// forwarded. It may change the request; an error rejects the call.
+	Transform func(ctx context.Context, route Route, req interface{}) error
+
+	// Signer, if set, signs every request before it is sent, for
+	// gateways that require signed requests.
+	Signer RequestSigner
+
+	// Hooks are called around every call, e.g. for metrics and tracing.
+	Hooks ClientHooks
+
+	// Reconnect is the number of times a stream opened with Stream is
+	// reopened after its connection broke, resuming after the last event
+	// received; ReconnectDelay (1s if 0) is the time between attempts.
+	Reconnect      int
+	ReconnectDelay time.Duration
+
+	// App and AppVersion name the calling application in the User-Agent
+	// and X-Client-Version headers of the requests; by default, they
+	// are taken from the build info of the program.
+	App        string
+	AppVersion string
+
+	// GzipThreshold, if positive, compresses request bodies of at least
+	// that many bytes with gzip; generated handlers decompress them.
+	GzipThreshold int
+
+	// AcceptGzip asks for gzip compressed responses, e.g. of streams with
+	// the goweb.stream_gzip option, and decompresses them, also where the
+	// Client does not do so by itself.
+	AcceptGzip bool
+
+	// Retries is the number of times a call that failed with a retryable
+	// *Error is retried, after the Retry-After of the error or else an
+	// exponential backoff starting at RetryBackoff (100ms if 0).
+	Retries      int
+	RetryBackoff time.Duration
+
+	// Protobuf sends the requests in the binary wire format of protobuf
+	// and asks for responses in it, for services generated with the
+	// protobuf parameter; responses are decoded after their Content-Type
+	// either way. Routes with google.api.http annotations, whose requests
+	// are built from their JSON, keep JSON.
+	Protobuf bool
+
+	// Forward passes on the deadline, credentials and request id of the
+	// call in whose context a call is made, see Forwarding.
+	Forward Forwarding
+
+	// JSON, if set, encodes the requests and decodes the responses and
+	// stream messages in the proto3 JSON mapping with its options. By
+	// default, routes use the mapping without options, and routes with
+	// LegacyJSON set the encoding/json based one.
+	JSON *Proto3JSON
+
+	// LegacyJSON encodes and decodes all routes with encoding/json, e.g.
+	// for JSON APIs that are not goweb services.
+	LegacyJSON bool
+}
+
+// ClientHooks observe the calls of an Upstream. Each hook is optional;
+// route.Method names the method, latency is the time since the call
+// started.
+type ClientHooks struct {
+	// OnRequest is called with the request before it is sent.
+	OnRequest func(ctx context.Context, route Route, req *http.Request)
+
+	// OnResponse is called once the response was read, whatever its status.
+	OnResponse func(ctx context.Context, route Route, res *http.Response, latency time.Duration)
+
+	// OnError is called if the call fails, also for non-2xx responses
+	// (with an *Error or *UpstreamError).
+	OnError func(ctx context.Context, route Route, err error, latency time.Duration)
+}
+
+// UpstreamError reports a non-2xx response of the upstream service that
+// is not an Error.
+type UpstreamError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("goweb: upstream returned %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+// URL returns the upstream URL for route.
+func (u *Upstream) URL(route Route) string {
+	target, ok := u.Rewrite[route.FullMethod()]
+	if !ok {
+		target, ok = u.Rewrite[route.Method]
+	}
+	if !ok {
+		target = route.Path
+	}
+	if strings.Contains(target, "://") {
+		return target
+	}
+	return JoinPath(u.BaseURL, target)
+}
+
+// Call validates and transforms in, posts it as JSON to the upstream URL
+// of route and decodes the response into out.
+func (u *Upstream) Call(ctx context.Context, route Route, in, out interface{}) error {
+	start := time.Now()
+	err := u.call(ctx, start, route, in, out)
+	if err != nil && u.Hooks.OnError != nil {
+		u.Hooks.OnError(ctx, route, err, time.Since(start))
+	}
+	return err
+}
+
+func (u *Upstream) call(ctx context.Context, start time.Time, route Route, in, out interface{}) error {
+	body, err := u.encode(ctx, route, in)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		err := u.send(ctx, start, route, body, out)
+		e, ok := err.(*Error)
+		if !ok || !e.Retryable || attempt >= u.Retries {
+			return err
+		}
+		if err := sleep(ctx, u.retryDelay(e, attempt)); err != nil {
+			return e
+		}
+	}
+}
+
+// send posts the encoded request body to route once.
+func (u *Upstream) send(ctx context.Context, start time.Time, route Route, body []byte, out interface{}) error {
+	req, err := u.request(ctx, route, body, nil)
+	if err != nil {
+		return err
+	}
+	if u.Hooks.OnRequest != nil {
+		u.Hooks.OnRequest(ctx, route, req)
+	}
+	res, err := u.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := gzipResponse(res); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if u.Hooks.OnResponse != nil {
+		u.Hooks.OnResponse(ctx, route, res, time.Since(start))
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err := DecodeError(res.StatusCode, content)
+		if e, ok := err.(*Error); ok {
+			e.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		}
+		return err
+	}
+	if m, ok := out.(proto.Message); ok && IsProtobuf(res.Header.Get("Content-Type")) {
+		return proto.Unmarshal(content, m)
+	}
+	return u.unmarshal(route, content, out)
+}
+
+// encode validates, transforms and encodes the request in of route.
+func (u *Upstream) encode(ctx context.Context, route Route, in interface{}) ([]byte, error) {
+	if v, ok := in.(interface {
+		Validate() error
+	}); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if u.Transform != nil {
+		if err := u.Transform(ctx, route, in); err != nil {
+			return nil, err
+		}
+	}
+	if u.binary(route) {
+		m, ok := in.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("goweb: cannot send %T in the protobuf wire format", in)
+		}
+		return proto.Marshal(m)
+	}
+	if j := u.json(route); j != nil {
+		return j.Marshal(in)
+	}
+	return marshalJSON(in)
+}
+
+// json returns the proto3 JSON mapping of the requests and responses of
+// route, or nil if they follow encoding/json.
+func (u *Upstream) json(route Route) *Proto3JSON {
+	switch {
+	case u.JSON != nil:
+		return u.JSON
+	case u.LegacyJSON || route.LegacyJSON:
+		return nil
+	}
+	return &Proto3JSON{}
+}
+
+// binary reports whether the requests of route are sent in the binary wire
+// format of protobuf.
+func (u *Upstream) binary(route Route) bool {
+	return u.Protobuf && route.Verb == ""
+}
+
+// unmarshal decodes a response or stream message of route into out.
+func (u *Upstream) unmarshal(route Route, data []byte, out interface{}) error {
+	if j := u.json(route); j != nil {
+		return j.Unmarshal(data, out)
+	}
+	return UnmarshalAnyJSON(data, out)
+}
+
+// request returns the signed request posting body to route, with the
+// headers header, or sending it as the path template of route has it.
+func (u *Upstream) request(ctx context.Context, route Route, body []byte, header http.Header) (*http.Request, error) {
+	verb, target := "POST", u.URL(route)
+	if route.Verb != "" {
+		var err error
+		if target, body, err = templateRequest(route, target, body); err != nil {
+			return nil, err
+		}
+		verb = route.Verb
+	}
+	gzipped := u.GzipThreshold > 0 && len(body) >= u.GzipThreshold
+	if gzipped {
+		body = gzipBytes(body)
+	}
+	req, err := http.NewRequest(verb, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if u.binary(route) {
+		req.Header.Set("Content-Type", ProtobufContentType)
+		req.Header.Set("Accept", ProtobufContentType+", application/json;q=0.9")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if u.AcceptGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	req.Header.Set("User-Agent", u.UserAgent(route))
+	if _, version := u.app(); version != "" {
+		req.Header.Set(ClientVersionHeader, version)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	for k, v := range OutgoingHeaders(ctx) {
+		if _, ok := req.Header[k]; !ok {
+			req.Header[k] = v
+		}
+	}
+	if err := u.forward(ctx, req); err != nil {
+		return nil, err
+	}
+	if t := TenantFrom(ctx); t != "" {
+		req.Header.Set(TenantHeader, t)
+	}
+	if DryRun(ctx) {
+		req.Header.Set(ValidateOnlyHeader, "true")
+	}
+	if host := HostFrom(ctx); host != "" {
+		req.Host = host
+	}
+	if u.Signer != nil {
+		if err := u.Signer.SignRequest(req, route, body); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func (u *Upstream) client() *http.Client {
+	if u.Client == nil {
+		return http.DefaultClient
+	}
+	return u.Client
+}
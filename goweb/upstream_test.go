@@ -0,0 +1,103 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"golang.org/x/net/context"
+)
+
+func TestClientHooks(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", 503)
+	}))
+	defer s.Close()
+	var calls []string
+	u := &Upstream{BaseURL: s.URL, Hooks: ClientHooks{
+		OnRequest: func(ctx context.Context, route Route, req *http.Request) {
+			calls = append(calls, "request "+route.Method)
+		},
+		OnResponse: func(ctx context.Context, route Route, res *http.Response, latency time.Duration) {
+			calls = append(calls, "response "+res.Status)
+		},
+		OnError: func(ctx context.Context, route Route, err error, latency time.Duration) {
+			if _, ok := err.(*UpstreamError); ok && latency > 0 {
+				calls = append(calls, "error")
+			}
+		},
+	}}
+	var out struct{}
+	if err := u.Call(context.Background(), Route{Method: "Get", Path: "/get"}, struct{}{}, &out); err == nil {
+		t.Fatal("no error")
+	}
+	if got := strings.Join(calls, ", "); got != "request Get, response 503 Service Unavailable, error" {
+		t.Errorf("hooks: %s", got)
+	}
+}
+
+// Upstreams use the proto3 JSON mapping unless the route or the upstream
+// asks for encoding/json.
+func TestUpstreamJSON(t *testing.T) {
+	var sent string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		sent = string(b)
+		w.Write(b)
+	}))
+	defer s.Close()
+	in := &pb.FieldDescriptorProto{Type: pb.FieldDescriptorProto_TYPE_INT64.Enum()}
+	for _, c := range []struct {
+		u      *Upstream
+		legacy bool
+		want   string
+	}{
+		{&Upstream{}, false, `{"type":"TYPE_INT64"}`},
+		{&Upstream{JSON: &Proto3JSON{EnumsAsInts: true}}, false, `{"type":3}`},
+		{&Upstream{}, true, `{"type":3}`},
+		{&Upstream{LegacyJSON: true}, false, `{"type":3}`},
+	} {
+		c.u.BaseURL = s.URL
+		out := new(pb.FieldDescriptorProto)
+		err := c.u.Call(context.Background(), Route{Method: "Get", Path: "/get", LegacyJSON: c.legacy}, in, out)
+		if err != nil || sent != c.want || !proto.Equal(out, in) {
+			t.Errorf("%+v, legacy route %v: sent %s, got %v, %v", c.u, c.legacy, sent, out, err)
+		}
+	}
+}
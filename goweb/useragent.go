@@ -0,0 +1,141 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Version is the version of goweb, sent by clients in their User-Agent.
+const Version = "1.0.0"
+
+// ClientVersionHeader carries the version of the client application.
+const ClientVersionHeader = "X-Client-Version"
+
+// buildApp returns the name and version of the main module of the
+// running program, from its build info.
+func buildApp() (name, version string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" {
+		return "goweb-client", ""
+	}
+	version = info.Main.Version
+	if version == "(devel)" {
+		version = ""
+	}
+	return path.Base(info.Main.Path), version
+}
+
+// UserAgent returns the User-Agent an Upstream sends for calls of route,
+//
+//	<app>/<version> goweb/<goweb version> (<service>; <go version>)
+//
+// where app and version are those of the main module of the program,
+// unless set in the App and AppVersion fields of u.
+func (u *Upstream) UserAgent(route Route) string {
+	app, version := u.app()
+	ua := app
+	if version != "" {
+		ua += "/" + version
+	}
+	return ua + " goweb/" + Version + " (" + route.Service + "; " + runtime.Version() + ")"
+}
+
+func (u *Upstream) app() (name, version string) {
+	name, version = buildApp()
+	if u.App != "" {
+		name = u.App
+	}
+	if u.AppVersion != "" {
+		version = u.AppVersion
+	}
+	return name, version
+}
+
+// ClientInfo describes the client of a request, as sent by the clients
+// of goweb.
+type ClientInfo struct {
+	App     string // name of the client application
+	Version string // its version, from the X-Client-Version header
+	Goweb   string // goweb version of the client; empty for other clients
+	Service string // the service the client was generated for
+}
+
+// ParseUserAgent parses a User-Agent in the format of Upstream.UserAgent.
+// Other user agents give the name and version of their first product.
+func ParseUserAgent(ua string) ClientInfo {
+	var c ClientInfo
+	comment := ""
+	if i := strings.IndexByte(ua, '('); i >= 0 {
+		comment = strings.TrimSuffix(ua[i+1:], ")")
+		if j := strings.IndexByte(comment, ')'); j >= 0 {
+			comment = comment[:j]
+		}
+		ua = ua[:i]
+	}
+	for i, product := range strings.Fields(ua) {
+		name, version := product, ""
+		if j := strings.IndexByte(product, '/'); j >= 0 {
+			name, version = product[:j], product[j+1:]
+		}
+		switch {
+		case i == 0:
+			c.App, c.Version = name, version
+		case name == "goweb":
+			c.Goweb = version
+		}
+	}
+	if c.Goweb != "" {
+		c.Service = strings.TrimSpace(strings.SplitN(comment, ";", 2)[0])
+	}
+	return c
+}
+
+// ClientInfoFrom returns the client of the request of ctx, from its
+// User-Agent and X-Client-Version headers, e.g. for metrics over the
+// client versions in use.
+func ClientInfoFrom(ctx context.Context) ClientInfo {
+	r := RequestFrom(ctx)
+	if r == nil {
+		return ClientInfo{}
+	}
+	c := ParseUserAgent(r.UserAgent())
+	if v := r.Header.Get(ClientVersionHeader); v != "" {
+		c.Version = v
+	}
+	return c
+}
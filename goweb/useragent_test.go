@@ -0,0 +1,65 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestUserAgent(t *testing.T) {
+	var info ClientInfo
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = ClientInfoFrom(NewContext(r))
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	u := &Upstream{BaseURL: s.URL, App: "billing", AppVersion: "v1.2.3"}
+	route := Route{Service: "pkg.Users", Path: "/users/get"}
+	if got, want := u.UserAgent(route), "billing/v1.2.3 goweb/"+Version+" (pkg.Users; "+runtime.Version()+")"; got != want {
+		t.Errorf("UserAgent = %q, want %q", got, want)
+	}
+	var out struct{}
+	if err := u.Call(context.Background(), route, struct{}{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := (ClientInfo{App: "billing", Version: "v1.2.3", Goweb: Version, Service: "pkg.Users"}); info != want {
+		t.Errorf("ClientInfoFrom = %+v, want %+v", info, want)
+	}
+	if got := ParseUserAgent("curl/8.0.1"); got != (ClientInfo{App: "curl", Version: "8.0.1"}) {
+		t.Errorf("ParseUserAgent(curl) = %+v", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ValidationError returns the 400 INVALID_ARGUMENT *Error of the
+// violations of the validation rules of a request, listing them in its
+// details, or nil if there are none.
+func ValidationError(violations []FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return ErrorFactory{}.InvalidArgument(violations...)
+}
+
+// FieldPath returns the path of the field name of the message at path,
+// e.g. "address.zip_code".
+func FieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// IndexPath returns the path of the element i of the repeated field at
+// path, e.g. "items[2]".
+func IndexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// KeyPath returns the path of the value of key in the map field at path,
+// e.g. "labels[env]".
+func KeyPath(path string, key interface{}) string {
+	return path + "[" + fmt.Sprint(key) + "]"
+}
+
+// patterns caches the compiled patterns of MatchPattern.
+var patterns sync.Map
+
+// MatchPattern reports whether s matches the regular expression expr,
+// compiled once. The generator checks the patterns; MatchPattern panics
+// for invalid ones.
+func MatchPattern(expr, s string) bool {
+	re, ok := patterns.Load(expr)
+	if !ok {
+		re, _ = patterns.LoadOrStore(expr, regexp.MustCompile(expr))
+	}
+	return re.(*regexp.Regexp).MatchString(s)
+}
+
+// Validate calls the Validate method of the request m if it has one, like
+// the messages of protoc-gen-validate: its *Error values are returned as
+// they are, and other errors as 400 INVALID_ARGUMENT errors with their
+// text.
+func Validate(m interface{}) error {
+	v, ok := m.(interface{ Validate() error })
+	if !ok {
+		return nil
+	}
+	err := v.Validate()
+	if err == nil {
+		return nil
+	}
+	if e, ok := AsError(err); ok {
+		return e
+	}
+	return &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: err.Error()}
+}
@@ -0,0 +1,92 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	if ValidationError(nil) != nil {
+		t.Error("error without violations")
+	}
+	err := ValidationError([]FieldViolation{{Field: FieldPath("address", "zip"), Description: "is required"}})
+	e, ok := AsError(err)
+	if !ok || e.Status != 400 || e.Code != "INVALID_ARGUMENT" || len(e.Violations) != 1 || e.Violations[0].Field != "address.zip" {
+		t.Errorf("ValidationError = %#v", err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	for _, c := range []struct{ got, want string }{
+		{FieldPath("", "name"), "name"},
+		{FieldPath("a.b", "c"), "a.b.c"},
+		{IndexPath("items", 2), "items[2]"},
+		{KeyPath("labels", "env"), "labels[env]"},
+		{KeyPath(FieldPath("m", "ids"), 7), "m.ids[7]"},
+	} {
+		if c.got != c.want {
+			t.Errorf("path = %q, want %q", c.got, c.want)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	if !MatchPattern("^[a-z]+$", "abc") || MatchPattern("^[a-z]+$", "ab1") {
+		t.Error("MatchPattern")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for an invalid pattern")
+		}
+	}()
+	MatchPattern("(", "")
+}
+
+type validatingRequest struct{ err error }
+
+func (v validatingRequest) Validate() error { return v.err }
+
+func TestValidate(t *testing.T) {
+	if Validate(struct{}{}) != nil || Validate(validatingRequest{}) != nil {
+		t.Error("error for a valid message")
+	}
+	conflict := Errorf(409, "ALREADY_EXISTS", "taken")
+	if err := Validate(validatingRequest{conflict}); err != conflict {
+		t.Errorf("Validate = %v, want the *Error", err)
+	}
+	e, ok := AsError(Validate(validatingRequest{errors.New("bad name")}))
+	if !ok || e.Status != 400 || e.Code != "INVALID_ARGUMENT" || e.Message != "bad name" {
+		t.Errorf("Validate = %#v", e)
+	}
+}
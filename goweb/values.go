@@ -0,0 +1,176 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// RequestIDHeader carries the id of a request, set by clients or proxies.
+const RequestIDHeader = "X-Request-Id"
+
+// TrustForwardedFor makes ClientIP use the X-Forwarded-For header, which
+// only proxies in front of the server should be trusted to set.
+var TrustForwardedFor = false
+
+// TrustedProxies are the addresses ("10.0.0.1") and networks
+// ("10.0.0.0/8") of the proxies in front of the server, whose entries of
+// the X-Forwarded-For header ClientIP skips.
+var TrustedProxies []string
+
+type principalKey struct{}
+type requestIDKey struct{}
+
+// value returns the value of key in ctx or else in the context of its http
+// request, where middleware in front of the generated mux puts it.
+func value(ctx context.Context, key interface{}) interface{} {
+	if v := ctx.Value(key); v != nil {
+		return v
+	}
+	if r := RequestFrom(ctx); r != nil {
+		return r.Context().Value(key)
+	}
+	return nil
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated caller p,
+// e.g. set by authentication middleware on the context of the request.
+func WithPrincipal(ctx context.Context, p interface{}) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the caller set with WithPrincipal, or nil.
+func PrincipalFrom(ctx context.Context) interface{} {
+	return value(ctx, principalKey{})
+}
+
+// WithRequestID returns a copy of ctx carrying the request id id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the id set with WithRequestID, or else the
+// X-Request-Id header of the request, or "".
+func RequestID(ctx context.Context) string {
+	if id, _ := value(ctx, requestIDKey{}).(string); id != "" {
+		return id
+	}
+	if r := RequestFrom(ctx); r != nil {
+		return r.Header.Get(RequestIDHeader)
+	}
+	return ""
+}
+
+// ClientIP returns the IP address of the client of the request, or "".
+// If TrustForwardedFor is set, it is the right-most X-Forwarded-For entry
+// that is not one of the TrustedProxies: the entries left of it were sent
+// by the client, which can put anything there. Otherwise it is the remote
+// address of the connection.
+func ClientIP(ctx context.Context) string {
+	r := RequestFrom(ctx)
+	if r == nil {
+		return ""
+	}
+	if TrustForwardedFor {
+		var hops []string
+		for _, fwd := range r.Header["X-Forwarded-For"] {
+			for _, hop := range strings.Split(fwd, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !trustedProxy(hops[i]) || i == 0 {
+				return hops[i]
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// trustedProxy reports whether the address addr is one of TrustedProxies.
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, p := range TrustedProxies {
+		if _, network, err := net.ParseCIDR(p); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if ip.Equal(net.ParseIP(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Locale returns the preferred language of the client, the tag with the
+// highest quality in the Accept-Language header of the request (e.g.
+// "de-CH"), or "".
+func Locale(ctx context.Context) string {
+	r := RequestFrom(ctx)
+	if r == nil {
+		return ""
+	}
+	return requestLocale(r)
+}
+
+func requestLocale(r *http.Request) string {
+	best, bestQ := "", -1.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(part, ";")
+		tag, q := strings.TrimSpace(fields[0]), 1.0
+		for _, f := range fields[1:] {
+			if f = strings.TrimSpace(f); strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if tag != "" && tag != "*" && q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,82 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestValues(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "10.0.0.7:4711"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	r.Header.Set("X-Request-Id", "req-1")
+	r.Header.Set("Accept-Language", "en;q=0.5, de-CH, fr;q=0.9")
+	r = r.WithContext(WithPrincipal(r.Context(), "alice"))
+	ctx := NewContext(r)
+	if p := PrincipalFrom(ctx); p != "alice" {
+		t.Errorf("PrincipalFrom = %v", p)
+	}
+	if id := RequestID(ctx); id != "req-1" {
+		t.Errorf("RequestID = %q", id)
+	}
+	if id := RequestID(WithRequestID(ctx, "req-2")); id != "req-2" {
+		t.Errorf("RequestID after WithRequestID = %q", id)
+	}
+	if ip := ClientIP(ctx); ip != "10.0.0.7" {
+		t.Errorf("ClientIP = %q", ip)
+	}
+	TrustForwardedFor = true
+	defer func() { TrustForwardedFor = false }()
+	if ip := ClientIP(ctx); ip != "10.0.0.1" {
+		t.Errorf("ClientIP behind an untrusted proxy = %q", ip)
+	}
+	defer func(proxies []string) { TrustedProxies = proxies }(TrustedProxies)
+	TrustedProxies = []string{"10.0.0.0/8"}
+	if ip := ClientIP(ctx); ip != "203.0.113.9" {
+		t.Errorf("ClientIP behind a proxy = %q", ip)
+	}
+	// the client sent the left-most entries
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.9, 10.0.0.1")
+	r.Header.Add("X-Forwarded-For", "10.0.0.2")
+	if ip := ClientIP(ctx); ip != "203.0.113.9" {
+		t.Errorf("ClientIP with a spoofed entry = %q", ip)
+	}
+	TrustedProxies = []string{"10.0.0.1", "10.0.0.2", "203.0.113.9", "198.51.100.1"}
+	if ip := ClientIP(ctx); ip != "198.51.100.1" {
+		t.Errorf("ClientIP behind trusted proxies only = %q", ip)
+	}
+	if l := Locale(ctx); l != "de-CH" {
+		t.Errorf("Locale = %q", l)
+	}
+}
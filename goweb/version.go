@@ -0,0 +1,125 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseMediaVersion splits a vendor media type with a version, e.g.
+// "application/vnd.myapi.v2+json", into its base "application/vnd.myapi"
+// and version "v2".
+func ParseMediaVersion(mediaType string) (base, version string, ok bool) {
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	if !strings.HasSuffix(mediaType, "+json") {
+		return "", "", false
+	}
+	mediaType = strings.TrimSuffix(mediaType, "+json")
+	i := strings.LastIndex(mediaType, ".")
+	if i < 0 || !strings.Contains(mediaType[:i], "/vnd.") || i == len(mediaType)-1 {
+		return "", "", false
+	}
+	return mediaType[:i], mediaType[i+1:], true
+}
+
+// VersionedName returns the service name without the suffix version,
+// e.g. "Users" for "UsersV2" and version "v2", or false if name does not
+// end in version.
+func VersionedName(name, version string) (string, bool) {
+	if len(name) <= len(version) || !strings.EqualFold(name[len(name)-len(version):], version) {
+		return "", false
+	}
+	name = strings.TrimRight(name[:len(name)-len(version)], "_")
+	return name, name != ""
+}
+
+// A VersionedMux serves the versions of an API, selected by the vendor
+// media type in the Accept header of a request
+// (Accept: application/vnd.myapi.v2+json), instead of a path prefix.
+// Requests that accept no version of the media type get the Default
+// version; requests that only accept unknown versions are answered with
+// 406. Responses carry the media type of the version as Content-Type,
+// unless the handler sets another, and Vary: Accept.
+type VersionedMux struct {
+	MediaType string                  // e.g. "application/vnd.myapi"
+	Versions  map[string]http.Handler // by version, e.g. "v2"
+	Default   string
+}
+
+// Version returns the version of the request r, or false if r only
+// accepts versions the mux does not serve.
+func (m *VersionedMux) Version(r *http.Request) (string, bool) {
+	best, bestQ, vendor := "", 0.0, false
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		fields := strings.Split(part, ";")
+		base, version, ok := ParseMediaVersion(fields[0])
+		if !ok || base != strings.ToLower(m.MediaType) {
+			continue
+		}
+		vendor = true
+		q := 1.0
+		for _, f := range fields[1:] {
+			if f = strings.TrimSpace(f); strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if _, ok := m.Versions[version]; ok && q > bestQ {
+			best, bestQ = version, q
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+	return m.Default, !vendor
+}
+
+func (m *VersionedMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	version, ok := m.Version(r)
+	h := m.Versions[version]
+	if !ok || h == nil {
+		var types []string
+		for v := range m.Versions {
+			types = append(types, m.MediaType+"."+v+"+json")
+		}
+		sort.Strings(types)
+		WriteRequestError(w, r, Errorf(http.StatusNotAcceptable, "NOT_ACCEPTABLE", "supported media types: %s", strings.Join(types, ", ")))
+		return
+	}
+	w.Header().Set("Content-Type", m.MediaType+"."+version+"+json")
+	h.ServeHTTP(w, r)
+}
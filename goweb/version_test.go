@@ -0,0 +1,101 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseMediaVersion(t *testing.T) {
+	for in, want := range map[string][2]string{
+		"application/vnd.myapi.v2+json":   {"application/vnd.myapi", "v2"},
+		"Application/VND.My.Api.v10+json": {"application/vnd.my.api", "v10"},
+		"application/json":                {},
+		"application/vnd.myapi+json":      {},
+	} {
+		base, version, ok := ParseMediaVersion(in)
+		if base != want[0] || version != want[1] || ok != (want[0] != "") {
+			t.Errorf("ParseMediaVersion(%q) = %q, %q, %v", in, base, version, ok)
+		}
+	}
+}
+
+func TestVersionedName(t *testing.T) {
+	for _, c := range []struct{ name, version, want string }{
+		{"UsersV2", "v2", "Users"},
+		{"Users_v1", "v1", "Users"},
+		{"UsersBeta", "beta", "Users"},
+		{"Users", "v2", ""},
+		{"V2", "v2", ""},
+		{"_V2", "v2", ""},
+	} {
+		if got, ok := VersionedName(c.name, c.version); got != c.want || ok != (c.want != "") {
+			t.Errorf("VersionedName(%q, %q) = %q, %v", c.name, c.version, got, ok)
+		}
+	}
+}
+
+func TestVersionedMux(t *testing.T) {
+	version := func(v string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(v)) })
+	}
+	m := &VersionedMux{
+		MediaType: "application/vnd.myapi",
+		Versions:  map[string]http.Handler{"v1": version("v1"), "v2": version("v2")},
+		Default:   "v2",
+	}
+	for _, c := range []struct {
+		accept, body string
+		status       int
+	}{
+		{"", "v2", 200},
+		{"application/json", "v2", 200},
+		{"application/vnd.myapi.v1+json", "v1", 200},
+		{"application/vnd.myapi.v1+json;q=0.5, application/vnd.myapi.v2+json", "v2", 200},
+		{"application/vnd.myapi.v3+json, application/vnd.myapi.v1+json;q=0.1", "v1", 200},
+		{"application/vnd.myapi.v3+json", "", 406},
+		{"application/vnd.other.v3+json", "v2", 200},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", c.accept)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		if w.Code != c.status || c.status == 200 && (w.Body.String() != c.body || w.Header().Get("Content-Type") != "application/vnd.myapi."+c.body+"+json") {
+			t.Errorf("Accept %q: %d %q %q", c.accept, w.Code, w.Body, w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary %q", c.accept, w.Header().Get("Vary"))
+		}
+	}
+}
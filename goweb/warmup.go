@@ -0,0 +1,92 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Warmup runs the codecs of the generated handlers on each message, so
+// that the type caches of encoding/json, proto and jsonpb are built
+// before the first requests instead of during them: the messages are
+// filled with fake data (see Faker), which reaches their nested types,
+// and encoded and decoded as JSON, with and without the extensions of
+// goweb (MarshalJSON, UnmarshalJSON), and in the binary format; jsonpb
+// (Proto3JSON) gets the empty message. The generated <Service>Warmup
+// functions call it with the request and response types of a service.
+// An error names a message the handlers cannot encode or decode.
+func Warmup(msgs ...proto.Message) error {
+	for _, m := range msgs {
+		name := reflect.TypeOf(m).Elem().Name()
+		filled := proto.Clone(m)
+		filled.Reset()
+		Faker{}.Fill(filled, "warmup", nil)
+		if err := warmJSON(filled); err != nil {
+			return fmt.Errorf("goweb: warming up %s: %v", name, err)
+		}
+		b, err := proto.Marshal(filled)
+		if err == nil {
+			err = proto.Unmarshal(b, proto.Clone(m))
+		}
+		if err != nil {
+			return fmt.Errorf("goweb: warming up %s: %v", name, err)
+		}
+		empty := proto.Clone(m)
+		empty.Reset()
+		b, err = Proto3JSON{}.Marshal(empty)
+		if err == nil {
+			err = Proto3JSON{}.Unmarshal(b, proto.Clone(empty))
+		}
+		if err != nil {
+			return fmt.Errorf("goweb: warming up %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func warmJSON(m proto.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, proto.Clone(m)); err != nil {
+		return err
+	}
+	if b, err = MarshalJSON(m); err != nil {
+		return err
+	}
+	return UnmarshalJSON(b, proto.Clone(m))
+}
@@ -0,0 +1,70 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "bytes"
+
+// A RequestValidator checks the requests of a unary method like its
+// handler does, so that clients built from the same generated code, such
+// as the WebAssembly modules of ExportValidators, reject the requests the
+// server would reject before sending them.
+type RequestValidator struct {
+	// Route is the route of the method.
+	Route Route
+
+	// New returns a new, empty request.
+	New func() interface{}
+
+	// Rules appends the violations of the validation rules of the fields
+	// of the request in to a list, or is nil if it has none.
+	Rules func(in interface{}) []FieldViolation
+}
+
+// Validate decodes the JSON request body and returns the error the
+// handler of the method answers it with before calling the method: a 400
+// error if it is not a request of the method, the violations of the
+// validation rules of its fields (see ValidationError), or the error of
+// its Validate method (see Validate).
+func (v RequestValidator) Validate(body []byte) error {
+	in := v.New()
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := UnmarshalJSON(body, in); err != nil {
+			return &Error{Status: 400, Code: "INVALID_ARGUMENT", Message: err.Error()}
+		}
+	}
+	if v.Rules != nil {
+		if err := ValidationError(v.Rules(in)); err != nil {
+			return err
+		}
+	}
+	return Validate(in)
+}
@@ -0,0 +1,83 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"encoding/json"
+	"syscall/js"
+)
+
+// ExportValidators sets the global JavaScript function name of a module
+// compiled to WebAssembly (GOOS=js GOARCH=wasm) to one that checks the
+// requests of the methods of validators with exactly the rules of their
+// server, e.g. for edge workers and browsers:
+//
+//	func main() {
+//		goweb.ExportValidators("validateRequest", pb.UsersRequestValidators()...)
+//		select {}
+//	}
+//
+// name(fullMethod, json) returns null if the JSON request of the method,
+// e.g. "/pkg.Users/GetUser", is valid, and else the JSON body of the
+// error response the server would answer it with, see Error.
+func ExportValidators(name string, validators ...RequestValidator) {
+	byMethod := map[string]RequestValidator{}
+	for _, v := range validators {
+		byMethod[v.Route.FullMethod()] = v
+	}
+	js.Global().Set(name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) != 2 {
+			return errorJSON(&Error{Status: 400, Code: "INVALID_ARGUMENT", Message: name + " takes a method and a JSON request"})
+		}
+		v, ok := byMethod[args[0].String()]
+		if !ok {
+			return errorJSON(&Error{Status: 404, Code: "NOT_FOUND", Message: "no method " + args[0].String()})
+		}
+		if err := v.Validate([]byte(args[1].String())); err != nil {
+			return errorJSON(err)
+		}
+		return nil
+	}))
+}
+
+// errorJSON returns the body of the error response of err.
+func errorJSON(err error) interface{} {
+	e, ok := AsError(err)
+	if !ok {
+		e = &Error{Status: 500, Code: "INTERNAL", Message: err.Error()}
+	}
+	b, _ := json.Marshal(e)
+	return string(b)
+}
@@ -0,0 +1,56 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import "testing"
+
+func TestRequestValidator(t *testing.T) {
+	v := RequestValidator{
+		Route: Route{Service: "goweb.Captures", Method: "Put", Path: "goweb.Captures/Put"},
+		New:   func() interface{} { return new(CapturedCall) },
+		Rules: func(in interface{}) []FieldViolation {
+			if in.(*CapturedCall).Method == "" {
+				return []FieldViolation{{Field: "method", Description: "is required"}}
+			}
+			return nil
+		},
+	}
+	if err := v.Validate([]byte(`{"method":"a"}`)); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+	for body, want := range map[string]string{"": "method", `{"path":"p"}`: "method", `{"method":`: "", `{"method":1}`: ""} {
+		e, ok := AsError(v.Validate([]byte(body)))
+		if !ok || e.Status != 400 || e.Code != "INVALID_ARGUMENT" || want != "" && (len(e.Violations) != 1 || e.Violations[0].Field != want) {
+			t.Errorf("%q: %+v", body, e)
+		}
+	}
+}
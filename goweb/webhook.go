@@ -0,0 +1,85 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package goweb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrBadWebhookSignature is returned by VerifyWebhook for requests without
+// a valid signature.
+var ErrBadWebhookSignature = errors.New("goweb: missing or invalid webhook signature")
+
+var webhookSecrets = struct {
+	sync.RWMutex
+	m map[string][][]byte
+}{m: map[string][][]byte{}}
+
+// RegisterWebhookSecret sets the secrets of the webhook methods with the
+// secret name name (see the goweb.webhook_secret option). A signature made
+// with any of them is accepted, so secrets can be rotated.
+func RegisterWebhookSecret(name string, secrets ...[]byte) {
+	webhookSecrets.Lock()
+	defer webhookSecrets.Unlock()
+	webhookSecrets.m[name] = secrets
+}
+
+// VerifyWebhook checks that signature is the HMAC-SHA256 of body with one
+// of the secrets registered as name. The signature is hex or base64
+// encoded and may be prefixed with "sha256=", as sent by most webhook
+// providers.
+func VerifyWebhook(name, signature string, body []byte) error {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		if got, err = base64.StdEncoding.DecodeString(signature); err != nil {
+			return ErrBadWebhookSignature
+		}
+	}
+	webhookSecrets.RLock()
+	secrets := webhookSecrets.m[name]
+	webhookSecrets.RUnlock()
+	for _, secret := range secrets {
+		h := hmac.New(sha256.New, secret)
+		h.Write(body)
+		if hmac.Equal(got, h.Sum(nil)) {
+			return nil
+		}
+	}
+	return ErrBadWebhookSignature
+}
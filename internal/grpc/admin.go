@@ -0,0 +1,182 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// adminResource is a resource of the admin UI of a service, see
+// goweb.AdminResource; the methods are indexes in the routes of the
+// service, -1 if the service has none.
+type adminResource struct {
+	msg                               string // full name of the message
+	key                               *pb.FieldDescriptorProto
+	field                             *pb.FieldDescriptorProto // of the Create and Update requests, if they are not resources
+	items, pageToken, nextPageToken   *pb.FieldDescriptorProto
+	list, get, create, update, remove int
+}
+
+// field returns the singular field name of the message msg, or nil.
+func (g *grpc) field(msg, name string) *pb.FieldDescriptorProto {
+	for _, f := range g.msgs[msg].GetField() {
+		if f.GetName() == name && f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED {
+			return f
+		}
+	}
+	return nil
+}
+
+// adminResources returns the resources of the standard methods of the
+// service (AIP-131 to AIP-135): the messages returned by a Get<Resource>
+// method, named by their name or id field, that a method lists in a
+// repeated field of its response. Create<Resource>, Update<Resource> and
+// Delete<Resource> methods are optional.
+func (g *grpc) adminResources(service *pb.ServiceDescriptorProto) []adminResource {
+	unary := func(m *pb.MethodDescriptorProto) bool { return !m.GetClientStreaming() && !m.GetServerStreaming() }
+	// keyed reports whether the requests in address a resource of r by
+	// its key, and takes the resources themselves if holds is set.
+	keyed := func(r *adminResource, in string) bool {
+		f := g.field(in, r.key.GetName())
+		return in == r.msg || f != nil && f.GetType() == r.key.GetType()
+	}
+	holds := func(r *adminResource, in string) (*pb.FieldDescriptorProto, bool) {
+		if in == r.msg {
+			return nil, true
+		}
+		for _, f := range g.msgs[in].GetField() {
+			if f.GetTypeName() == r.msg && f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED {
+				return f, true
+			}
+		}
+		return nil, false
+	}
+	var resources []adminResource
+	for i, get := range service.Method {
+		suffix := strings.TrimPrefix(get.GetName(), "Get")
+		msg := get.GetOutputType()
+		if suffix == get.GetName() || !unary(get) || g.msgs[msg] == nil || strings.HasPrefix(msg, ".google.protobuf.") {
+			continue
+		}
+		r := adminResource{msg: msg, list: -1, get: i, create: -1, update: -1, remove: -1}
+		for _, name := range []string{"name", "id"} {
+			if f := g.field(msg, name); f != nil && filterFieldType(f) != "" && f.GetType() != pb.FieldDescriptorProto_TYPE_BOOL {
+				r.key = f
+				break
+			}
+		}
+		if r.key == nil || !keyed(&r, get.GetInputType()) {
+			continue
+		}
+		for j, m := range service.Method {
+			if !unary(m) {
+				continue
+			}
+			switch {
+			case r.list < 0 && strings.HasPrefix(m.GetName(), "List") && g.listResource(m.GetOutputType()) == msg:
+				r.list = j
+				out := g.msgs[m.GetOutputType()]
+				for _, f := range out.GetField() {
+					if f.GetTypeName() == msg && f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+						r.items = f
+						break
+					}
+				}
+				if t, n := g.stringField(m.GetInputType(), "page_token"), g.stringField(m.GetOutputType(), "next_page_token"); t != nil && n != nil {
+					r.pageToken, r.nextPageToken = t, n
+				}
+			case m.GetName() == "Create"+suffix && m.GetOutputType() == msg:
+				if f, ok := holds(&r, m.GetInputType()); ok {
+					r.create, r.field = j, f
+				}
+			case m.GetName() == "Update"+suffix && m.GetOutputType() == msg:
+				if f, ok := holds(&r, m.GetInputType()); ok && (r.create < 0 || f.GetName() == r.field.GetName()) {
+					r.update, r.field = j, f
+				}
+			case m.GetName() == "Delete"+suffix && keyed(&r, m.GetInputType()):
+				r.remove = j
+			}
+		}
+		if r.list >= 0 {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// generateAdmin generates _<Service>_admin, the resources of the admin UI
+// of the service, see goweb.AdminHandler.
+func (g *grpc) generateAdmin(servName string, resources []adminResource) {
+	route := func(i int) string {
+		if i < 0 {
+			return "nil"
+		}
+		return "&_" + servName + "_routes[" + strconv.Itoa(i) + "]"
+	}
+	g.P("// _", servName, "_admin are the resources of the admin UI of ", servName, ".")
+	g.P("var _", servName, "_admin = []goweb.AdminResource{")
+	for _, r := range resources {
+		d := g.newOpenAPIDoc()
+		ref := d.ref(r.msg)
+		d.define()
+		ref["components"] = jsonObject{"schemas": d.schemas}
+		schema, err := json.Marshal(ref)
+		if err != nil {
+			g.gen.Error(err, "encoding the schema of", r.msg)
+		}
+		name := func(f *pb.FieldDescriptorProto) string {
+			if f == nil {
+				return ""
+			}
+			return d.name(f)
+		}
+		g.P("{")
+		g.P("Name: ", strconv.Quote(r.msg[1:]), ",")
+		g.P("Key: ", strconv.Quote(name(r.key)), ",")
+		if r.field != nil {
+			g.P("Field: ", strconv.Quote(name(r.field)), ",")
+		}
+		g.P("Items: ", strconv.Quote(name(r.items)), ",")
+		if r.pageToken != nil {
+			g.P("PageToken: ", strconv.Quote(name(r.pageToken)), ", NextPageToken: ", strconv.Quote(name(r.nextPageToken)), ",")
+		}
+		g.P("Schema: []byte(", strconv.Quote(string(schema)), "),")
+		g.P("List: ", route(r.list), ", Get: ", route(r.get), ", Create: ", route(r.create), ", Update: ", route(r.update), ", Delete: ", route(r.remove), ",")
+		g.P("},")
+	}
+	g.P("}")
+	g.P()
+}
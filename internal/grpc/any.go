@@ -0,0 +1,98 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// anyPass matches the google.protobuf.Any fields, whose JSON the handlers
+// read and write with the "@type" of the protobuf JSON mapping. It has no
+// functions of its own.
+var anyPass = &fieldPass{
+	match: func(f *pb.FieldDescriptorProto) bool { return f.GetTypeName() == ".google.protobuf.Any" },
+}
+
+// usesAny reports whether a message of the request has an Any field.
+func (g *grpc) usesAny() bool {
+	for _, m := range g.msgs {
+		for _, f := range m.GetField() {
+			if anyPass.match(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// generateAnyTypes generates the registration of the messages of file
+// for resolving the type URLs of Any values, with the prefix of the
+// type_url_prefix parameter. It is left out if neither the parameter is
+// set nor any message of the request has an Any field.
+func (g *grpc) generateAnyTypes(file *generator.FileDescriptor) {
+	prefix, ok := g.param("type_url_prefix")
+	if !ok && !g.usesAny() {
+		return
+	}
+	var names []string
+	var walk func(scope string, msgs []*pb.DescriptorProto)
+	walk = func(scope string, msgs []*pb.DescriptorProto) {
+		for _, m := range msgs {
+			if m.GetOptions().GetMapEntry() {
+				continue
+			}
+			name := scope + "." + m.GetName()
+			names = append(names, name)
+			walk(name, m.NestedType)
+		}
+	}
+	scope := ""
+	if pkg := file.GetPackage(); pkg != "" {
+		scope = "." + pkg
+	}
+	walk(scope, file.MessageType)
+	if len(names) == 0 {
+		return
+	}
+	goweb := g.gen.Import(gowebPkgPath)
+	g.gen.Block("func init()", func() {
+		g.P(goweb.Ident("RegisterAnyTypes"), "(", strconv.Quote(prefix), ",")
+		for _, name := range names {
+			g.P("(*", g.typeName(name), ")(nil),")
+		}
+		g.P(")")
+	})
+	g.P()
+}
@@ -0,0 +1,71 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateCanonical generates CanonicalJSON and CanonicalHash methods for
+// every message of file, map entries excepted.
+func (g *grpc) generateCanonical(file *generator.FileDescriptor) {
+	prefix := "."
+	if pkg := file.GetPackage(); pkg != "" {
+		prefix += pkg + "."
+	}
+	for _, msg := range file.MessageType {
+		g.generateCanonicalMessage(prefix, msg)
+	}
+}
+
+func (g *grpc) generateCanonicalMessage(prefix string, msg *pb.DescriptorProto) {
+	if msg.GetOptions().GetMapEntry() {
+		return
+	}
+	name := prefix + msg.GetName()
+	typeName := g.typeName(name)
+	g.P("// CanonicalJSON returns the canonical serialization of m, see goweb.CanonicalJSON.")
+	g.P("func (m *", typeName, ") CanonicalJSON() ([]byte, error) {")
+	g.P("	return goweb.CanonicalJSON(m)")
+	g.P("}")
+	g.P()
+	g.P("// CanonicalHash returns the hash of the canonical serialization of m,")
+	g.P("// see goweb.CanonicalHash.")
+	g.P("func (m *", typeName, ") CanonicalHash() (string, error) {")
+	g.P("	return goweb.CanonicalHash(m)")
+	g.P("}")
+	g.P()
+	for _, nested := range msg.NestedType {
+		g.generateCanonicalMessage(name+".", nested)
+	}
+}
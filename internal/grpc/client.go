@@ -0,0 +1,216 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateClient generates the <Service>HTTPClient interface, with one
+// method per unary method of the service, New<Service>HTTPClient, which
+// implements it over http, and New<Service>LocalClient, which implements
+// it on top of a local <Service>Server.
+func (g *grpc) generateClient(servName string, service *pb.ServiceDescriptorProto) {
+	clientType := servName + "HTTPClient"
+	remoteType := "_" + servName + "HTTPClient"
+	localType := "_" + servName + "LocalClient"
+
+	g.P("// ", clientType, " is the client API of the unary methods of the ", servName, " service.")
+	g.P("// Its methods have the signatures of those of ", servName, "Server.")
+	g.P("type ", clientType, " interface {")
+	for _, method := range service.Method {
+		if g.clientStreams(method) {
+			g.P(g.clientStreamSignature(servName, method))
+		}
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P(g.clientSignature(method))
+	}
+	g.P("}")
+	g.P()
+	if !hasStreams(service) {
+		g.P("// ", clientType, " can stand in for the implementation of the service.")
+		g.P("var _ ", servName, "Server = ", clientType, "(nil)")
+		g.P()
+	}
+	for _, method := range service.Method {
+		if g.clientStreams(method) {
+			g.generateClientStreamType(servName, method)
+		}
+	}
+
+	g.P("// New", servName, "HTTPClient returns a ", clientType, " posting each call")
+	g.P("// to the ", servName, " service at u.BaseURL. Calls made with the context")
+	g.P("// of a call of a generated handler pass on its trace headers, see")
+	g.P("// goweb.WithPropagatedHeaders.")
+	g.P("func New", servName, "HTTPClient(u *goweb.Upstream) ", clientType, " {")
+	g.P("	return ", remoteType, "{u}")
+	g.P("}")
+	g.P()
+	g.P("// New", servName, "GatewayClient returns a ", clientType, " calling the")
+	g.P("// ", servName, " service at baseURL with the deadline, credentials, request id")
+	g.P("// and trace headers of the call in whose context it is called, see")
+	g.P("// goweb.ForwardAll. The called server honors the deadline with")
+	g.P("// goweb.WithTimeoutHeader(goweb.DeadlineHeader).")
+	g.P("func New", servName, "GatewayClient(baseURL string) ", clientType, " {")
+	g.P("	return New", servName, "HTTPClient(&goweb.Upstream{BaseURL: baseURL, Forward: goweb.ForwardAll})")
+	g.P("}")
+	g.P()
+	g.P("type ", remoteType, " struct {")
+	g.P("	upstream *goweb.Upstream")
+	g.P("}")
+	g.P()
+	for i, method := range service.Method {
+		if g.clientStreams(method) {
+			g.P("func (c ", remoteType, ") ", g.clientStreamSignature(servName, method), " {")
+			g.P("	s, err := c.upstream.Stream(ctx, _", servName, "_routes[", i, "], in)")
+			g.P("	if err != nil {")
+			g.P("		return nil, err")
+			g.P("	}")
+			g.P("	return _", servName, "_", generator.CamelCase(method.GetName()), "HTTPClientStream{s}, nil")
+			g.P("}")
+			g.P()
+		}
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P("func (c ", remoteType, ") ", g.clientSignature(method), " {")
+		g.P("	out := new(", g.typeName(method.GetOutputType()), ")")
+		g.P("	if err := c.upstream.Call(ctx, _", servName, "_routes[", i, "], in, out); err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		g.P("	return out, nil")
+		g.P("}")
+		g.P()
+	}
+
+	g.P("// New", servName, "LocalClient returns a ", clientType, " calling h directly,")
+	g.P("// without going through http.")
+	g.P("func New", servName, "LocalClient(h ", servName, "Server) ", clientType, " {")
+	g.P("	return ", localType, "{h}")
+	g.P("}")
+	g.P()
+	g.P("type ", localType, " struct {")
+	g.P("	h ", servName, "Server")
+	g.P("}")
+	g.P()
+	for _, method := range service.Method {
+		if g.clientStreams(method) {
+			g.P("func (c ", localType, ") ", g.clientStreamSignature(servName, method), " {")
+			g.P("	return nil, goweb.ErrStreamingNotSupported")
+			g.P("}")
+			g.P()
+		}
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P("func (c ", localType, ") ", g.clientSignature(method), " {")
+		g.P("	return c.h.", generator.CamelCase(method.GetName()), "(ctx, in)")
+		g.P("}")
+		g.P()
+	}
+}
+
+// clientSignature returns the client-side signature of a unary method.
+func (g *grpc) clientSignature(method *pb.MethodDescriptorProto) string {
+	return generator.CamelCase(method.GetName()) + "(ctx " + contextPkg + ".Context, in *" +
+		g.typeName(method.GetInputType()) + ") (*" + g.typeName(method.GetOutputType()) + ", error)"
+}
+
+// hasStreams reports whether service has streaming methods, which its
+// http client does not have with the signatures of the server.
+func hasStreams(service *pb.ServiceDescriptorProto) bool {
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			return true
+		}
+	}
+	return false
+}
+
+// clientStreams reports whether the http client has a method for the
+// streaming method: server streams served with the streams parameter.
+func (g *grpc) clientStreams(method *pb.MethodDescriptorProto) bool {
+	return method.GetServerStreaming() && !method.GetClientStreaming() && g.flag("streams")
+}
+
+// clientStreamSignature returns the client-side signature of a
+// server-streaming method.
+func (g *grpc) clientStreamSignature(servName string, method *pb.MethodDescriptorProto) string {
+	methName := generator.CamelCase(method.GetName())
+	return methName + "(ctx " + contextPkg + ".Context, in *" + g.typeName(method.GetInputType()) + ") (" +
+		servName + "_" + methName + "HTTPClientStream, error)"
+}
+
+// generateClientStreamType generates the <Service>_<Method>HTTPClientStream
+// interface returned by the http client for a server-streaming method, and
+// its implementation on top of a goweb.ClientStream.
+func (g *grpc) generateClientStreamType(servName string, method *pb.MethodDescriptorProto) {
+	typ := servName + "_" + generator.CamelCase(method.GetName()) + "HTTPClientStream"
+	outType := g.typeName(method.GetOutputType())
+	g.P("// ", typ, " is the client side of the ", generator.CamelCase(method.GetName()), " stream. Recv returns")
+	g.P("// io.EOF at its end; Close stops it early.")
+	g.P("type ", typ, " interface {")
+	g.P("	Recv() (*", outType, ", error)")
+	g.P("	Context() ", contextPkg, ".Context")
+	g.P("	LastEventID() string")
+	g.P("	Close() error")
+	g.P("}")
+	g.P()
+	g.P("type _", typ, " struct {")
+	g.P("	*goweb.ClientStream")
+	g.P("}")
+	g.P()
+	g.P("func (s _", typ, ") Recv() (*", outType, ", error) {")
+	g.P("	m := new(", outType, ")")
+	g.P("	if err := s.RecvMsg(m); err != nil {")
+	g.P("		return nil, err")
+	g.P("	}")
+	g.P("	return m, nil")
+	g.P("}")
+	g.P()
+}
+
+// generateTestServer generates NewTest<Service>Server, which starts an
+// httptest.Server with the mux of the service and a client for it.
+func (g *grpc) generateTestServer(servName string) {
+	g.P("// NewTest", servName, "Server starts an httptest.Server serving h with the")
+	g.P("// ", servName, " mux and returns it together with a ", servName, "HTTPClient")
+	g.P("// calling it. The caller should Close the server when done.")
+	g.P("func NewTest", servName, "Server(h ", servName, "Server) (*httptest.Server, ", servName, "HTTPClient) {")
+	g.P("	s := httptest.NewServer(New", servName, "Mux(h, \"/\"))")
+	g.P("	return s, New", servName, "HTTPClient(&goweb.Upstream{BaseURL: s.URL, Client: s.Client()})")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,96 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestClientIsServer(t *testing.T) {
+	file := testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User"), method("Delete", "User", "User")),
+	)
+	src := generateMux(t, "client", file)
+	checkDecl(t, src, "UsersHTTPClient", `
+type UsersHTTPClient interface {
+	Get(ctx context.Context, in *User) (*User, error)
+	Delete(ctx context.Context, in *User) (*User, error)
+}`)
+	if !strings.Contains(src, "var _ UsersServer = UsersHTTPClient(nil)") {
+		t.Error("no assertion that UsersHTTPClient is a UsersServer")
+	}
+
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	file.Service[0].Method = append(file.Service[0].Method, watch)
+	if src := generateMux(t, "client", file); strings.Contains(src, "var _ UsersServer") {
+		t.Error("the client of a service with streams asserted to be a UsersServer")
+	}
+}
+
+func TestTestServerRoutes(t *testing.T) {
+	src := generateMux(t, "test_server", testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User")),
+	))
+	if !strings.Contains(src, `router.Handle(goweb.JoinPath(prefix, "users/get"), http.HandlerFunc(t.Get))`) {
+		t.Error("route not joined to the prefix with goweb.JoinPath")
+	}
+	if !strings.Contains(src, `httptest.NewServer(NewUsersMux(h, "/"))`) {
+		t.Error("test server does not mount the mux at /")
+	}
+}
+
+func TestLocalClient(t *testing.T) {
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	src := generateMux(t, "client", testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User"), watch),
+	))
+	checkDecl(t, src, "NewUsersLocalClient", `
+func NewUsersLocalClient(h UsersServer) UsersHTTPClient {
+	return _UsersLocalClient{h}
+}`)
+	checkDecl(t, src, "(_UsersLocalClient).Get", `
+func (c _UsersLocalClient) Get(ctx context.Context, in *User) (*User, error) {
+	return c.h.Get(ctx, in)
+}`)
+	if strings.Contains(src, "(c _UsersLocalClient) Watch") {
+		t.Error("local client method for a streaming method")
+	}
+}
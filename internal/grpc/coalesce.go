@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// coalesces reports whether the calls of method are coalesced, see the
+// coalesce_ms option.
+func coalesces(method *pb.MethodDescriptorProto) bool {
+	return options.Uint32(method.GetOptions(), options.E_CoalesceMs) > 0 &&
+		!method.GetServerStreaming() && !method.GetClientStreaming()
+}
+
+// generateCoalescers generates the part of New<Service>Mux that makes the
+// goweb.Coalescer of each method with the coalesce_ms option.
+func (g *grpc) generateCoalescers(service *pb.ServiceDescriptorProto) {
+	for _, method := range service.Method {
+		if coalesces(method) {
+			n := options.Uint32(method.GetOptions(), options.E_CoalesceMs)
+			g.P("	t.coalesce", generator.CamelCase(method.GetName()), " = goweb.NewCoalescer(", strconv.Itoa(int(n)), "e6)")
+		}
+	}
+}
+
+// implCall returns the call of the implementation of the unary method
+// with the request in, through its coalescer if it has one.
+func (g *grpc) implCall(method *pb.MethodDescriptorProto, in string) string {
+	methName := generator.CamelCase(method.GetName())
+	if coalesces(method) {
+		return "impl.call" + methName + "Coalesced(ctx, " + in + ")"
+	}
+	return "impl.handler." + methName + "(ctx, " + in + ")"
+}
+
+// generateCoalescedCall generates the method of _<Service>Server calling
+// a unary method with the coalesce_ms option through its coalescer.
+func (g *grpc) generateCoalescedCall(servName string, method *pb.MethodDescriptorProto, index int) {
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	g.P("func (impl *_", servName, "Server) call", methName, "Coalesced(ctx ", contextPkg, ".Context, in *", inType, ") (*", outType, ", error) {")
+	g.P("	out, err := impl.coalesce", methName, ".Do(ctx, _", servName, "_routes[", index, "], in, func(ctx ", contextPkg, ".Context) (interface{}, error) {")
+	g.P("		return impl.handler.", methName, "(ctx, in)")
+	g.P("	})")
+	g.P("	res, _ := out.(*", outType, ")")
+	g.P("	return res, err")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,170 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// handlerOptions are the method options whose handlers need code of their
+// own, so that their methods are not plain, see plain.
+var handlerOptions = []*proto.ExtensionDesc{
+	options.E_Download, options.E_Upload, options.E_WebhookSignatureHeader, options.E_RawBody,
+	options.E_Retryable, options.E_Transactional, options.E_RegionField, options.E_Transform,
+	options.E_Enrich, options.E_Authorize, options.E_Policy, options.E_Audit, options.E_Session,
+	options.E_Link, options.E_TimeoutSeconds, options.E_StreamResponse,
+}
+
+// handlerParams are the parameters whose handlers need code of their own.
+var handlerParams = []string{"metering", "hot_config", "arena", "protobuf", "text_format", "deterministic_json", "pretty_json", "debug_errors"}
+
+// plain reports whether method is served by a handler of goweb rather than
+// by one generated for it, with the compact or the generics parameter: if
+// it is unary and its handler would only decode the JSON request, call the
+// method and encode the JSON response.
+func (g *grpc) plain(method *pb.MethodDescriptorProto, route goweb.Route) bool {
+	g.method = "/" + route.Service + "/" + route.Method
+	defer func() { g.method = "" }()
+	if !g.flag("compact") && !g.flag("generics") || method.GetServerStreaming() || method.GetClientStreaming() {
+		return false
+	}
+	for _, name := range handlerParams {
+		if g.flag(name) {
+			return false
+		}
+	}
+	for _, ext := range handlerOptions {
+		if options.Has(method.GetOptions(), ext) {
+			return false
+		}
+	}
+	in, out := method.GetInputType(), method.GetOutputType()
+	if g.jsonp(method, route) || g.responseMeta() != "" || g.int64Strings(method) || g.finiteFloats(in) || g.finiteFloats(out) ||
+		g.enumPolicy("unknown_enums", in) != "" || g.enumPolicy("response_enums", out) != "" ||
+		g.maxDepth(in) > 0 || g.recursive(out) || options.String(g.service.GetOptions(), options.E_ServiceRegionField) != "" ||
+		g.softDelete() || coalesces(method) || g.needs(deprecatedPass, in) {
+		return false
+	}
+	for _, p := range []*fieldPass{anyPass, outputOnlyPass, g.limitPass(), g.timePass(), decryptPass, normalizePass, defaultPass, validatePass} {
+		if g.needs(p, in) {
+			return false
+		}
+	}
+	for _, f := range g.msgs[in].GetField() {
+		if options.Bool(f.GetOptions(), options.E_Tenant) {
+			return false
+		}
+	}
+	for _, p := range []*fieldPass{anyPass, inputOnlyPass, redactPass, encryptPass} {
+		if g.needs(p, out) {
+			return false
+		}
+	}
+	return true
+}
+
+// generatePlainMethods generates the method table of the plain methods of
+// service served by goweb.MethodHandler, with the compact parameter, and
+// returns the handlers of all plain methods by index: those of their rows,
+// or, with the generics parameter, instantiations of goweb.Handle.
+func (g *grpc) generatePlainMethods(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) map[int]string {
+	handlers := map[int]string{}
+	var rows []int
+	for i, method := range service.Method {
+		if !g.plain(method, routes[i]) {
+			continue
+		}
+		g.method = "/" + routes[i].Service + "/" + routes[i].Method
+		if g.flag("generics") {
+			handlers[i] = "goweb.Handle(goweb.Method{" + g.methodFields(servName, method, i, routes[i]) + "}, h, h." + generator.CamelCase(method.GetName()) + ", t.opts)"
+		} else {
+			handlers[i] = "goweb.MethodHandler(&_" + servName + "_methods[" + strconv.Itoa(len(rows)) + "], h, t.opts)"
+			rows = append(rows, i)
+		}
+		g.method = ""
+	}
+	if len(rows) == 0 {
+		return handlers
+	}
+	g.P("var _", servName, "_methods = [...]goweb.Method{")
+	for _, i := range rows {
+		method := service.Method[i]
+		g.method = "/" + routes[i].Service + "/" + routes[i].Method
+		inType := g.typeName(method.GetInputType())
+		g.P("{")
+		g.P(g.methodFields(servName, method, i, routes[i]), ",")
+		g.P("New: func() interface{} { return new(", inType, ") },")
+		g.P("Call: func(srv interface{}, ctx ", contextPkg, ".Context, in interface{}) (interface{}, error) {")
+		g.P("	return srv.(", servName, "Server).", generator.CamelCase(method.GetName()), "(ctx, in.(*", inType, "))")
+		g.P("},")
+		g.P("},")
+		g.method = ""
+	}
+	g.P("}")
+	g.P()
+	return handlers
+}
+
+// methodFields returns the fields of the goweb.Method of the plain method
+// with the route of index i, but for New and Call.
+func (g *grpc) methodFields(servName string, method *pb.MethodDescriptorProto, i int, route goweb.Route) string {
+	fields := []string{"Route: &_" + servName + "_routes[" + strconv.Itoa(i) + "]"}
+	if route.Verb != "" && route.Body != "*" {
+		t, _ := route.Template()
+		var skip []string
+		for _, field := range append([]string{route.Body}, t.Fields()...) {
+			if field != "" {
+				skip = append(skip, strconv.Quote(field))
+			}
+		}
+		if len(skip) > 0 {
+			fields = append(fields, "Skip: []string{"+strings.Join(skip, ", ")+"}")
+		}
+	}
+	if !g.dryRuns(method) {
+		fields = append(fields, "NewResponse: func() interface{} { return new("+g.typeName(method.GetOutputType())+") }")
+	}
+	if w := g.errorWriter(); w != "WriteRequestError" {
+		fields = append(fields, "WriteError: goweb."+w)
+	}
+	if p := g.proto3JSON(); p != "" && p != "goweb.Proto3JSON{}" {
+		fields = append(fields, "JSON: "+p)
+	}
+	return strings.Join(fields, ", ")
+}
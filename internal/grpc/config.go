@@ -0,0 +1,53 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import "github.com/ekle/protoc-gen-goweb/generator"
+
+// generateConfig generates <Service>Config, the runtime configuration of
+// the muxes of the service, and Apply<Service>Config.
+func (g *grpc) generateConfig(servName string) {
+	goweb := g.gen.Import(gowebPkgPath)
+	g.P("// ", servName, "Config is the runtime configuration of the ", servName, " muxes:")
+	g.P("// maintenance mode, disabled methods, timeouts, rate limits and feature")
+	g.P("// flags (", servName, "Config.Flag(name)).")
+	g.P("var ", servName, "Config = &", goweb.Ident("MuxSettings"), "{}")
+	g.P()
+	g.gen.EmitFunc(generator.Func{
+		Doc: "Apply" + servName + "Config swaps in the configuration c for the next calls of the\n" +
+			servName + " muxes, e.g. after the config file of the server changed.",
+		Name:    "Apply" + servName + "Config",
+		Params:  "c " + goweb.Ident("MuxConfig"),
+		Results: "error",
+		Body:    func() { g.P("return ", servName, "Config.Apply(c)") },
+	})
+}
@@ -0,0 +1,81 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateConformance generates Check<Service>Conformance, which runs the
+// contract checks of goweb against an implementation of the service.
+func (g *grpc) generateConformance(file *generator.FileDescriptor, servName string, service *pb.ServiceDescriptorProto) {
+	g.P("// Check", servName, "Conformance checks the contract of the ", servName, " service")
+	g.P("// against h, reporting failures to t, e.g. a *testing.T: every unary method")
+	g.P("// must handle an empty request and reject one whose Validate method")
+	g.P("// fails, and methods with the idempotency_level NO_SIDE_EFFECTS or")
+	g.P("// IDEMPOTENT must answer two identical calls alike.")
+	g.P("func Check", servName, "Conformance(t goweb.TestReporter, h ", servName, "Server) {")
+	g.P("	t.Helper()")
+	for _, method := range service.Method {
+		if !method.GetServerStreaming() && !method.GetClientStreaming() {
+			g.P("	ctx := ", contextPkg, ".Background()")
+			break
+		}
+	}
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		g.P("	{")
+		g.P("		in := &", inType, "{}")
+		g.P("		call := func() (proto.Message, error) { return h.", methName, "(ctx, in) }")
+		g.P("		goweb.CheckEmpty(t, ", strconv.Quote(methName), ", call)")
+		g.P("		goweb.CheckValidate(t, ", strconv.Quote(methName), ", in, call)")
+		switch method.GetOptions().GetIdempotencyLevel() {
+		case pb.MethodOptions_NO_SIDE_EFFECTS, pb.MethodOptions_IDEMPOTENT:
+			if example := g.exampleMessage(file, method.GetInputType(), ""); g.flag("examples") && example != "" {
+				g.P("		example := ", example)
+				g.P("		goweb.CheckIdempotent(t, ", strconv.Quote(methName), ", func() (proto.Message, error) { return h.", methName, "(ctx, example) })")
+			} else {
+				g.P("		goweb.CheckIdempotent(t, ", strconv.Quote(methName), ", call)")
+			}
+		}
+		g.P("	}")
+	}
+	g.P("}")
+	g.P()
+}
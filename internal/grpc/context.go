@@ -0,0 +1,61 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+// generateContextAccessors generates <Service>Context, with typed
+// accessors for the request values goweb puts into the contexts of calls,
+// so implementations do not depend on the context keys of the runtime.
+func (g *grpc) generateContextAccessors(servName string) {
+	typ := servName + "Context"
+	g.P("// ", typ, " gives access to the request values of a call of the ", servName)
+	g.P("// service, e.g. ", typ, "{ctx}.RequestID().")
+	g.P("type ", typ, " struct {")
+	g.P("	", contextPkg, ".Context")
+	g.P("}")
+	g.P()
+	g.P("// Principal returns the authenticated caller, see goweb.WithPrincipal.")
+	g.P("func (c ", typ, ") Principal() interface{} { return goweb.PrincipalFrom(c.Context) }")
+	g.P()
+	g.P("// RequestID returns the id of the request, see goweb.RequestID.")
+	g.P("func (c ", typ, ") RequestID() string { return goweb.RequestID(c.Context) }")
+	g.P()
+	g.P("// ClientIP returns the IP address of the client, see goweb.ClientIP.")
+	g.P("func (c ", typ, ") ClientIP() string { return goweb.ClientIP(c.Context) }")
+	g.P()
+	g.P("// Locale returns the preferred language of the client, see goweb.Locale.")
+	g.P("func (c ", typ, ") Locale() string { return goweb.Locale(c.Context) }")
+	g.P()
+	g.P("// RawBody returns the request body of methods with the goweb.raw_body")
+	g.P("// option, see goweb.RawBody.")
+	g.P("func (c ", typ, ") RawBody() []byte { return goweb.RawBody(c.Context) }")
+	g.P()
+}
@@ -0,0 +1,118 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// deprecatedPass matches the deprecated fields outside of oneofs; the
+// functions of generateDeprecatedFunc list those set in a request.
+var deprecatedPass = &fieldPass{
+	name: "deprecated",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return f.GetOptions().GetDeprecated() && f.OneofIndex == nil
+	},
+}
+
+// deprecatedFunc returns the name of the function appending the paths of
+// the deprecated fields set in the message name and in the messages
+// nested in it to a list, and queues its generation.
+func (g *grpc) deprecatedFunc(name string) string {
+	fn := "_deprecated" + mangle(name)
+	if !g.passFuncs[fn] {
+		g.passFuncs[fn] = true
+		g.passQueue = append(g.passQueue, func() { g.generateDeprecatedFunc(name, fn) })
+	}
+	return fn
+}
+
+// generateDeprecated generates the part of a handler that reports the
+// deprecated fields set in the request, see goweb.DeprecatedFields.
+func (g *grpc) generateDeprecated(servName string, method *pb.MethodDescriptorProto, index int) {
+	if in := method.GetInputType(); g.needs(deprecatedPass, in) {
+		g.P("	goweb.DeprecatedFields(w, _", servName, "_routes[", index, "], ", g.deprecatedFunc(in), "(", g.in(), ", \"\", nil))")
+	}
+}
+
+func (g *grpc) generateDeprecatedFunc(name, fn string) {
+	g.P("func ", fn, "(m *", g.typeName(name), ", path string, fields []string) []string {")
+	g.P("	if m == nil {")
+	g.P("		return fields")
+	g.P("	}")
+	for _, f := range g.msgs[name].GetField() {
+		if f.OneofIndex != nil {
+			continue
+		}
+		field := "m." + g.goField(name, f).field
+		path := "goweb.FieldPath(path, " + strconv.Quote(f.GetName()) + ")"
+		if deprecatedPass.match(f) {
+			g.P("	if ", g.isSet(name, f, field), " {")
+			g.P("		fields = append(fields, ", path, ")")
+			g.P("	}")
+		}
+		m := g.fieldMessage(f)
+		if m == "" || !g.needs(deprecatedPass, m) {
+			continue
+		}
+		// The elements of lists and maps share the path of their field.
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+			g.P("	for _, v := range ", field, " {")
+			g.P("		fields = ", g.deprecatedFunc(m), "(v, ", path, ", fields)")
+			g.P("	}")
+		} else {
+			g.P("	fields = ", g.deprecatedFunc(m), "(", field, ", ", path, ", fields)")
+		}
+	}
+	g.P("	return fields")
+	g.P("}")
+	g.P()
+}
+
+// isSet returns the condition that the field f of the message msg, held
+// in field, is set: not nil, empty or zero.
+func (g *grpc) isSet(msg string, f *pb.FieldDescriptorProto, field string) string {
+	t := f.GetType()
+	repeated := f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED
+	switch {
+	case repeated || t == pb.FieldDescriptorProto_TYPE_BYTES || t == pb.FieldDescriptorProto_TYPE_STRING && g.gen.ObjectNamed(msg).File().GetSyntax() == "proto3":
+		return "len(" + field + ") > 0"
+	case t == pb.FieldDescriptorProto_TYPE_MESSAGE || t == pb.FieldDescriptorProto_TYPE_GROUP || g.gen.ObjectNamed(msg).File().GetSyntax() != "proto3":
+		return field + " != nil"
+	case t == pb.FieldDescriptorProto_TYPE_BOOL:
+		return field
+	default:
+		return field + " != 0"
+	}
+}
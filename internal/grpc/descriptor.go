@@ -0,0 +1,88 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/golang/protobuf/proto"
+)
+
+// generateDescriptor generates the gzipped FileDescriptorProtos, with
+// their comments, of file and of its transitive imports but the
+// well-known types, whose packages register them with golang/protobuf,
+// and their registration with goweb.RegisterDescriptor.
+func (g *grpc) generateDescriptor(file *generator.FileDescriptor) {
+	v := fileVar(file, "descriptors")
+	g.P("// ", v, " are the gzipped FileDescriptorProtos of ", file.GetName(), " and")
+	g.P("// its imports, served with the well-known types by goweb.DescriptorSet.")
+	g.P("var ", v, " = [][]byte{")
+	for _, f := range g.closure(file) {
+		if strings.HasPrefix(f.GetName(), "google/protobuf/") {
+			continue
+		}
+		b, err := proto.Marshal(f)
+		if err != nil {
+			g.gen.Error(err, "marshaling the descriptor of", f.GetName())
+		}
+		var buf bytes.Buffer
+		w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		w.Write(b)
+		w.Close()
+		b = buf.Bytes()
+		g.P("// ", f.GetName(), ", ", len(b), " bytes")
+		g.P("{")
+		for len(b) > 0 {
+			n := 16
+			if n > len(b) {
+				n = len(b)
+			}
+			s := ""
+			for _, c := range b[:n] {
+				s += fmt.Sprintf("0x%02x,", c)
+			}
+			g.P(s)
+			b = b[n:]
+		}
+		g.P("},")
+	}
+	g.P("}")
+	g.P()
+	g.P("func init() {")
+	g.P("	goweb.RegisterDescriptor(", v, "...)")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,59 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateDownload generates the end of the http handler of a method
+// marked with goweb.download, which serves the response res as a file.
+func (g *grpc) generateDownload(method *pb.MethodDescriptorProto) {
+	content, contentType, filename := "", `""`, `""`
+	for _, f := range g.msgs[method.GetOutputType()].GetField() {
+		getter := "res." + g.goField(method.GetOutputType(), f).getter + "()"
+		switch {
+		case f.GetType() == pb.FieldDescriptorProto_TYPE_BYTES && content == "":
+			content = getter
+		case f.GetType() != pb.FieldDescriptorProto_TYPE_STRING:
+		case options.Bool(f.GetOptions(), options.E_Filename):
+			filename = getter
+		case f.GetName() == "content_type":
+			contentType = getter
+		}
+	}
+	if content == "" {
+		g.gen.Fail("download method", method.GetName(), "has no bytes field in its response", method.GetOutputType()[1:])
+	}
+	g.P("	goweb.ServeDownload(w, r, ", filename, ", ", contentType, ", ", content, ")")
+}
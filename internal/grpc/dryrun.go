@@ -0,0 +1,64 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// dryRuns reports whether the implementation of method handles its dry
+// runs, see goweb.WithValidateOnly: with the dry_run option, or with a
+// validate_only request field in soft_delete services.
+func (g *grpc) dryRuns(method *pb.MethodDescriptorProto) bool {
+	if options.Bool(method.GetOptions(), options.E_DryRun) {
+		return true
+	}
+	for _, f := range g.softDeleteFlags(method) {
+		if f.GetName() == "validate_only" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateDryRun generates the part of the call of a unary method that
+// answers dry runs with an empty response, unless the implementation
+// handles them.
+func (g *grpc) generateDryRun(method *pb.MethodDescriptorProto, indent string) {
+	if g.dryRuns(method) {
+		return
+	}
+	g.P(indent, "if goweb.DryRun(ctx) {")
+	g.P(indent, "	return &", g.typeName(method.GetOutputType()), "{}, nil")
+	g.P(indent, "}")
+}
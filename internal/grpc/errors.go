@@ -0,0 +1,125 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateErrors generates <Service>Errors, the goweb.ErrorFactory for the
+// errors of the service.
+func (g *grpc) generateErrors(servName string, routes []goweb.Route) {
+	service := serviceName(servName, routes)
+	g.P("// ", servName, "Errors constructs the errors of the ", servName, " service, e.g.")
+	g.P("// ", servName, "Errors.NotFound(\"user\", id).")
+	g.P("var ", servName, "Errors = goweb.ErrorFactory{Domain: ", strconv.Quote(service), "}")
+	g.P()
+}
+
+// generateErrorStatuses generates <Service>ErrorStatuses, the
+// goweb.StatusMap overriding the http statuses of the error codes of the
+// service.
+func (g *grpc) generateErrorStatuses(servName string, routes []goweb.Route) {
+	service := serviceName(servName, routes)
+	g.P("// ", servName, "ErrorStatuses maps error codes of the ", servName, " service to http")
+	g.P("// statuses, overriding goweb.DefaultErrorStatuses; set its entries before serving.")
+	g.P("var ", servName, "ErrorStatuses = goweb.ErrorStatuses(", strconv.Quote(service), ")")
+	g.P()
+}
+
+// errorCodes returns the error codes declared by the error_code options
+// of service.
+func (g *grpc) errorCodes(service *pb.ServiceDescriptorProto) []goweb.ErrorCode {
+	var codes []goweb.ErrorCode
+	for _, s := range options.Strings(service.GetOptions(), options.E_ErrorCode) {
+		c, err := goweb.ParseErrorCode(s)
+		if err != nil {
+			g.gen.Fail("error_code option of", service.GetName()+":", err.Error())
+		}
+		codes = append(codes, c)
+	}
+	return codes
+}
+
+// generateErrorCodes generates the declaration of the error codes of the
+// service, see goweb.DeclareErrorCodes.
+func (g *grpc) generateErrorCodes(servName string, routes []goweb.Route, codes []goweb.ErrorCode) {
+	g.P("func init() {")
+	g.P("	goweb.DeclareErrorCodes(", strconv.Quote(serviceName(servName, routes)), ",")
+	for _, c := range codes {
+		msg := ""
+		if c.Message != "" {
+			msg = ", Message: " + strconv.Quote(c.Message)
+		}
+		g.P("		goweb.ErrorCode{Code: ", strconv.Quote(c.Code), ", Status: ", c.Status, msg, "},")
+	}
+	g.P("	)")
+	g.P("}")
+	g.P()
+}
+
+// generateErrorCatalog adds the error codes of the services of file to
+// the output, as <file>.errors.json, in the format of /_routes?errors.
+func (g *grpc) generateErrorCatalog(file *generator.FileDescriptor) {
+	var catalog struct {
+		Services []goweb.ErrorCodes `json:"services"`
+	}
+	for _, service := range file.Service {
+		name := service.GetName()
+		if pkg := file.GetPackage(); pkg != "" {
+			name = pkg + "." + name
+		}
+		// only the declared codes override the default statuses here
+		goweb.DeclareErrorCodes(name, g.errorCodes(service)...)
+		catalog.Services = append(catalog.Services, goweb.ServiceErrorCodes(name))
+	}
+	out, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		g.gen.Error(err, "encoding the error catalog of", file.GetName())
+	}
+	g.gen.AddFile(strings.TrimSuffix(file.GetName(), ".proto")+".errors.json", string(out)+"\n")
+}
+
+// serviceName returns the fully-qualified name of the service servName.
+func serviceName(servName string, routes []goweb.Route) string {
+	if len(routes) > 0 {
+		return routes[0].Service
+	}
+	return servName
+}
@@ -0,0 +1,206 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateExamples generates Example<Message> constructors for every
+// message of file, map entries excepted, returning sample messages with
+// plausible values for docs, tests and load tests.
+func (g *grpc) generateExamples(file *generator.FileDescriptor) {
+	prefix := "."
+	if pkg := file.GetPackage(); pkg != "" {
+		prefix += pkg + "."
+	}
+	for _, msg := range file.MessageType {
+		g.generateExampleMessage(file, prefix, msg)
+	}
+}
+
+func (g *grpc) generateExampleMessage(file *generator.FileDescriptor, prefix string, msg *pb.DescriptorProto) {
+	if msg.GetOptions().GetMapEntry() {
+		return
+	}
+	name := prefix + msg.GetName()
+	typeName := g.typeName(name)
+	g.P("// Example", typeName, " returns a sample ", typeName, " message.")
+	g.P("func Example", typeName, "() *", typeName, " {")
+	g.P("	return &", typeName, "{")
+	if file.GetSyntax() == "proto3" {
+		for _, f := range msg.Field {
+			if v := g.exampleField(file, name, f); v != "" {
+				g.P(g.goField(name, f).field, ": ", v, ",")
+			}
+		}
+	}
+	g.P("	}")
+	g.P("}")
+	g.P()
+	for _, nested := range msg.NestedType {
+		g.generateExampleMessage(file, name+".", nested)
+	}
+}
+
+// exampleField returns the example value of the field f of the message
+// msg, or "" to leave it unset: oneofs, and messages that have no example
+// constructor or would recurse into msg.
+func (g *grpc) exampleField(file *generator.FileDescriptor, msg string, f *pb.FieldDescriptorProto) string {
+	if f.OneofIndex != nil {
+		return ""
+	}
+	desc := g.objectNamed(msg).(*generator.Descriptor)
+	typ, _ := g.gen.GoType(desc, f)
+	if f.GetType() != pb.FieldDescriptorProto_TYPE_MESSAGE {
+		v := g.exampleScalar(msg, f)
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+			return typ + "{" + v + "}"
+		}
+		return v
+	}
+	if entry := g.msgs[f.GetTypeName()]; entry.GetOptions().GetMapEntry() {
+		entryDesc := g.objectNamed(f.GetTypeName()).(*generator.Descriptor)
+		key, val := entry.Field[0], entry.Field[1]
+		keyType, _ := g.gen.GoType(entryDesc, key)
+		valType, _ := g.gen.GoType(entryDesc, val)
+		v := ""
+		if val.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE {
+			v = g.exampleMessage(file, val.GetTypeName(), msg)
+		} else {
+			v = g.exampleScalar(f.GetTypeName(), val)
+		}
+		if v == "" {
+			return ""
+		}
+		return "map[" + keyType + "]" + valType + "{" + g.exampleScalar(f.GetTypeName(), key) + ": " + v + "}"
+	}
+	v := g.exampleMessage(file, f.GetTypeName(), msg)
+	if v != "" && f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		return typ + "{" + v + "}"
+	}
+	return v
+}
+
+// exampleMessage returns the call of the example constructor of the
+// message name, if it is generated for file and does not lead back to
+// the message from.
+func (g *grpc) exampleMessage(file *generator.FileDescriptor, name, from string) string {
+	if g.objectNamed(name).File().GetName() != file.GetName() || g.contains(name, from, map[string]bool{}) {
+		return ""
+	}
+	return "Example" + g.typeName(name) + "()"
+}
+
+// contains reports whether a message of type to can be nested in one of
+// type name, or is one.
+func (g *grpc) contains(name, to string, seen map[string]bool) bool {
+	if name == to {
+		return true
+	}
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+	for _, f := range g.msgs[name].GetField() {
+		if m := g.fieldMessage(f); m != "" && g.contains(m, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleScalar returns the example value of a scalar or enum field: its
+// goweb.default if set, a value derived from the field name for strings,
+// cut to the length limit of the field, and the first non-zero value of
+// enums.
+func (g *grpc) exampleScalar(msg string, f *pb.FieldDescriptorProto) string {
+	if options.String(f.GetOptions(), options.E_Default) != "" && f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED {
+		return g.defaultValue(msg, f)
+	}
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		s := exampleString(f.GetName())
+		max := g.intParam("max_string")
+		if n := options.Uint32(f.GetOptions(), options.E_MaxLength); n > 0 {
+			max = int(n)
+		}
+		if max > 0 && len(s) > max {
+			s = s[:max]
+		}
+		return strconv.Quote(s)
+	case pb.FieldDescriptorProto_TYPE_BYTES:
+		return `[]byte("example")`
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return "true"
+	case pb.FieldDescriptorProto_TYPE_DOUBLE, pb.FieldDescriptorProto_TYPE_FLOAT:
+		return "1.5"
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		enum := g.objectNamed(f.GetTypeName()).(*generator.EnumDescriptor)
+		n := int32(0)
+		for _, e := range enum.Value {
+			if e.GetNumber() != 0 {
+				n = e.GetNumber()
+				break
+			}
+		}
+		return g.typeName(f.GetTypeName()) + "(" + strconv.Itoa(int(n)) + ")"
+	}
+	return "1"
+}
+
+// exampleString returns a plausible value for a string field by its name.
+func exampleString(field string) string {
+	name := strings.ToLower(field)
+	switch {
+	case strings.Contains(name, "email"):
+		return "jane.doe@example.com"
+	case strings.Contains(name, "phone"):
+		return "+15555550100"
+	case strings.Contains(name, "url"), strings.Contains(name, "uri"), strings.Contains(name, "link"):
+		return "https://example.com/"
+	case name == "id", strings.HasSuffix(name, "_id"):
+		return "123"
+	case name == "name", strings.HasSuffix(name, "_name"):
+		return "Jane Doe"
+	case strings.Contains(name, "locale"), strings.Contains(name, "lang"):
+		return "en-US"
+	case strings.Contains(name, "country"):
+		return "US"
+	}
+	return "example " + strings.Replace(name, "_", " ", -1)
+}
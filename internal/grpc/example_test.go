@@ -0,0 +1,74 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestExamples(t *testing.T) {
+	str := pb.FieldDescriptorProto_TYPE_STRING
+	role := &pb.EnumDescriptorProto{
+		Name: proto.String("Role"),
+		Value: []*pb.EnumValueDescriptorProto{
+			{Name: proto.String("ROLE_UNSPECIFIED"), Number: proto.Int32(0)},
+			{Name: proto.String("ADMIN"), Number: proto.Int32(1)},
+		},
+	}
+	src := generateMux(t, "examples", testFile("users.proto",
+		role,
+		message("User",
+			field("id", 1, str),
+			withOption(field("email", 2, str), options.E_MaxLength, proto.Uint32(8)),
+			withOption(field("lang", 3, str), options.E_Default, proto.String("de")),
+			field("role", 4, pb.FieldDescriptorProto_TYPE_ENUM, ".pkg.Role"),
+			repeated(field("tags", 5, str)),
+			field("manager", 6, pb.FieldDescriptorProto_TYPE_MESSAGE, ".pkg.User"),
+		),
+		service("Users", method("Get", "User", "User")),
+	))
+	// the email is cut to its max_length, the recursive manager left unset
+	checkDecl(t, src, "ExampleUser", `
+func ExampleUser() *User {
+	return &User{
+		Id:    "123",
+		Email: "jane.doe",
+		Lang:  "de",
+		Role:  Role(1),
+		Tags:  []string{"example tags"},
+	}
+}`)
+}
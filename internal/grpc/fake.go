@@ -0,0 +1,91 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateFake generates NewFake<Service>Server, an implementation of the
+// service answering every call with fake data from a goweb.Faker, so that
+// clients can be developed before the real implementation exists.
+func (g *grpc) generateFake(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	serverType := servName + "Server"
+	fakeType := "_" + servName + "Fake"
+
+	g.P("// NewFake", servName, "Server returns a ", serverType, " answering every call with")
+	g.P("// fake data, which only depends on seed and the request. Server streams")
+	g.P("// send three messages.")
+	g.P("func NewFake", servName, "Server(seed int64) ", serverType, " {")
+	g.P("	return ", fakeType, "{goweb.Faker{Seed: seed}}")
+	g.P("}")
+	g.P()
+	g.P("type ", fakeType, " struct {")
+	g.P("	faker goweb.Faker")
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverType, " = ", fakeType, "{}")
+	g.P()
+	for i, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		outType := g.typeName(method.GetOutputType())
+		fullMethod := strconv.Quote(routes[i].FullMethod())
+		switch {
+		case method.GetClientStreaming():
+			g.P("func (f ", fakeType, ") ", g.generateServerSignature(servName, method), " {")
+			g.P("	return goweb.ErrStreamingNotSupported")
+			g.P("}")
+		case method.GetServerStreaming():
+			g.P("func (f ", fakeType, ") ", methName, "(in *", g.typeName(method.GetInputType()), ", stream ", servName, "_", methName, "Server) error {")
+			g.P("	for i := 0; i < 3; i++ {")
+			g.P("		out := new(", outType, ")")
+			g.P("		f.faker.Fill(out, ", fullMethod, "+\"#\"+strconv.Itoa(i), in)")
+			g.P("		if err := stream.Send(out); err != nil {")
+			g.P("			return err")
+			g.P("		}")
+			g.P("	}")
+			g.P("	return nil")
+			g.P("}")
+		default:
+			g.P("func (f ", fakeType, ") ", methName, "(ctx ", contextPkg, ".Context, in *", g.typeName(method.GetInputType()), ") (*", outType, ", error) {")
+			g.P("	out := new(", outType, ")")
+			g.P("	f.faker.Fill(out, ", fullMethod, ", in)")
+			g.P("	return out, nil")
+			g.P("}")
+		}
+		g.P()
+	}
+}
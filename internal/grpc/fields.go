@@ -0,0 +1,501 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// A fieldPass generates, per message type, a function that applies an
+// action to the matching fields of a message and of all messages nested in
+// it, e.g. clearing the fields marked with goweb.redact.
+type fieldPass struct {
+	name  string                                                              // function name prefix
+	match func(f *pb.FieldDescriptorProto) bool                               // the fields the action applies to
+	apply func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) // prints the action on field "m.Name" of msg
+
+	// errors makes the functions return an error, which apply may return
+	// with "return err".
+	errors bool
+
+	// ctx gives the functions the context of the call as ctx.
+	ctx bool
+}
+
+// needs reports whether the message name or a message nested in it has
+// a field matching p.
+func (g *grpc) needs(p *fieldPass, name string) bool {
+	return g.reaches(p, name, map[string]bool{})
+}
+
+func (g *grpc) reaches(p *fieldPass, name string, seen map[string]bool) bool {
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+	for _, f := range g.msgs[name].GetField() {
+		if p.match(f) {
+			return true
+		}
+		if m := g.fieldMessage(f); m != "" && g.reaches(p, m, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// recursive reports whether the message name can contain itself, directly
+// or through other messages, e.g. the nodes of a tree.
+func (g *grpc) recursive(name string) bool {
+	return g.needs(&fieldPass{match: func(f *pb.FieldDescriptorProto) bool { return g.fieldMessage(f) == name }}, name)
+}
+
+// int64Pass matches the 64-bit integer fields and the maps with 64-bit
+// integer values, whose JSON the int64_strings parameter changes.
+func (g *grpc) int64Pass() *fieldPass {
+	return g.typePass(pb.FieldDescriptorProto_TYPE_INT64, pb.FieldDescriptorProto_TYPE_UINT64, pb.FieldDescriptorProto_TYPE_SINT64,
+		pb.FieldDescriptorProto_TYPE_FIXED64, pb.FieldDescriptorProto_TYPE_SFIXED64)
+}
+
+// floatPass matches the float and double fields and the maps with such
+// values, which may be NaN or infinite.
+func (g *grpc) floatPass() *fieldPass {
+	return g.typePass(pb.FieldDescriptorProto_TYPE_FLOAT, pb.FieldDescriptorProto_TYPE_DOUBLE)
+}
+
+// enumPass matches the enum fields and the maps with enum values.
+func (g *grpc) enumPass() *fieldPass {
+	return g.typePass(pb.FieldDescriptorProto_TYPE_ENUM)
+}
+
+// typePass returns a pass matching the fields of the scalar types and the
+// maps with values of the types. It has no functions of its own.
+func (g *grpc) typePass(types ...pb.FieldDescriptorProto_Type) *fieldPass {
+	var match func(f *pb.FieldDescriptorProto) bool
+	match = func(f *pb.FieldDescriptorProto) bool {
+		for _, t := range types {
+			if f.GetType() == t {
+				return true
+			}
+		}
+		if entry := g.msgs[f.GetTypeName()]; f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE && entry.GetOptions().GetMapEntry() {
+			for _, v := range entry.GetField() {
+				if v.GetNumber() == 2 && match(v) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return &fieldPass{match: match}
+}
+
+// fieldMessage returns the message type of the field f, or of its values
+// if f is a map, or "" if they are not messages.
+func (g *grpc) fieldMessage(f *pb.FieldDescriptorProto) string {
+	if f.GetType() != pb.FieldDescriptorProto_TYPE_MESSAGE {
+		return ""
+	}
+	entry := g.msgs[f.GetTypeName()]
+	if !entry.GetOptions().GetMapEntry() {
+		return f.GetTypeName()
+	}
+	for _, v := range entry.GetField() {
+		if v.GetNumber() == 2 && v.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE {
+			return v.GetTypeName()
+		}
+	}
+	return ""
+}
+
+// passFunc returns the name of the function of p for the message name and
+// queues its generation at the end of the file.
+func (g *grpc) passFunc(p *fieldPass, name string) string {
+	fn := "_" + p.name + mangle(name)
+	if !g.passFuncs[fn] {
+		g.passFuncs[fn] = true
+		g.passQueue = append(g.passQueue, func() { g.generatePassFunc(p, name, fn) })
+	}
+	return fn
+}
+
+// generatePassFuncs generates the queued functions of all field passes.
+func (g *grpc) generatePassFuncs() {
+	for len(g.passQueue) > 0 {
+		gen := g.passQueue[0]
+		g.passQueue = g.passQueue[1:]
+		gen()
+	}
+}
+
+func (g *grpc) generatePassFunc(p *fieldPass, name, fn string) {
+	result, ret := "", "return"
+	if p.errors {
+		result, ret = "error ", "return nil"
+	}
+	params := "m *" + g.typeName(name)
+	if p.ctx {
+		params = "ctx " + contextPkg + ".Context, " + params
+	}
+	g.P("func ", fn, "(", params, ") ", result, "{")
+	g.P("	if m == nil {")
+	g.P("		", ret)
+	g.P("	}")
+	for _, f := range g.msgs[name].GetField() {
+		m := g.fieldMessage(f)
+		nested := m != "" && g.needs(p, m)
+		if !p.match(f) && !nested {
+			continue
+		}
+		field := "m." + g.goField(name, f).field
+		if f.OneofIndex == nil {
+			if p.match(f) {
+				p.apply(g, name, f, field)
+			}
+			if nested {
+				g.generatePassCall(p, f, m, field)
+			}
+			continue
+		}
+		// A field of a oneof is reached through its wrapper while it is
+		// set, and the messages in it are handled first, as the action may
+		// unset the oneof.
+		field = g.oneofCase(name, f) + "." + g.goField(name, f).field
+		g.P("	if _, ok := ", g.oneofCase(name, f), "; ok {")
+		if nested {
+			g.generatePassCall(p, f, m, field)
+		}
+		if p.match(f) {
+			p.apply(g, name, f, field)
+		}
+		g.P("	}")
+	}
+	if p.errors {
+		g.P("	return nil")
+	}
+	g.P("}")
+	g.P()
+}
+
+// generatePassCall generates the call of the function of p for the
+// message m in field, or for each of its values if f is repeated.
+func (g *grpc) generatePassCall(p *fieldPass, f *pb.FieldDescriptorProto, m, field string) {
+	arg := field
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		g.P("	for _, v := range ", field, " {")
+		arg = "v"
+	}
+	if p.ctx {
+		arg = "ctx, " + arg
+	}
+	call := g.passFunc(p, m) + "(" + arg + ")"
+	if p.errors {
+		g.P("	if err := ", call, "; err != nil {")
+		g.P("		return err")
+		g.P("	}")
+	} else {
+		g.P("	", call)
+	}
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		g.P("	}")
+	}
+}
+
+// zeroValue returns the Go zero value of the field f of the message msg.
+func (g *grpc) zeroValue(msg string, f *pb.FieldDescriptorProto) string {
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED || f.OneofIndex == nil && g.gen.ObjectNamed(msg).File().GetSyntax() != "proto3" {
+		return "nil"
+	}
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		return `""`
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return "false"
+	case pb.FieldDescriptorProto_TYPE_MESSAGE, pb.FieldDescriptorProto_TYPE_GROUP, pb.FieldDescriptorProto_TYPE_BYTES:
+		return "nil"
+	}
+	return "0"
+}
+
+// clearField prints the action of passes that clear fields. A field of a
+// oneof is cleared by unsetting the oneof.
+func clearField(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+	if f.OneofIndex != nil {
+		g.P("	m.", g.goField(msg, f).oneof, " = nil")
+		return
+	}
+	g.P("	", field, " = ", g.zeroValue(msg, f))
+}
+
+// redactPass clears the fields marked with goweb.redact.
+var redactPass = &fieldPass{
+	name:  "redact",
+	match: func(f *pb.FieldDescriptorProto) bool { return options.Bool(f.GetOptions(), options.E_Redact) },
+	apply: clearField,
+}
+
+// outputOnlyPass clears the output-only fields of requests.
+var outputOnlyPass = &fieldPass{
+	name: "clearOutputOnly",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return options.Int32(f.GetOptions(), options.E_Visibility) == options.OutputOnly
+	},
+	apply: clearField,
+}
+
+// inputOnlyPass clears the input-only fields of responses.
+var inputOnlyPass = &fieldPass{
+	name: "clearInputOnly",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return options.Int32(f.GetOptions(), options.E_Visibility) == options.InputOnly
+	},
+	apply: clearField,
+}
+
+// defaultPass fills the unset fields of requests that have a goweb.default.
+var defaultPass = &fieldPass{
+	name: "setDefaults",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return options.String(f.GetOptions(), options.E_Default) != ""
+	},
+	apply: func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+		g.P("	if ", field, " == ", g.zeroValue(msg, f), " {")
+		g.P("		", field, " = ", g.defaultValue(msg, f))
+		g.P("	}")
+	},
+}
+
+// defaultValue returns the Go literal of the goweb.default of the field f
+// of the message msg.
+func (g *grpc) defaultValue(msg string, f *pb.FieldDescriptorProto) string {
+	v := options.String(f.GetOptions(), options.E_Default)
+	fail := func(reason string) string {
+		g.gen.Fail("bad default", strconv.Quote(v), "for field", msg[1:]+"."+f.GetName()+":", reason)
+		return ""
+	}
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED || g.zeroValue(msg, f) == "nil" {
+		return fail("defaults are only supported for singular scalar and enum fields of proto3 messages")
+	}
+	if f.OneofIndex != nil {
+		return fail("defaults are not supported for fields of oneofs, which are unset rather than zero")
+	}
+	var err error
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		return strconv.Quote(v)
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		_, err = strconv.ParseBool(v)
+	case pb.FieldDescriptorProto_TYPE_DOUBLE, pb.FieldDescriptorProto_TYPE_FLOAT:
+		_, err = strconv.ParseFloat(v, 64)
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		enum := g.objectNamed(f.GetTypeName()).(*generator.EnumDescriptor)
+		for _, e := range enum.Value {
+			if e.GetName() == v {
+				return g.typeName(f.GetTypeName()) + "(" + strconv.Itoa(int(e.GetNumber())) + ")"
+			}
+		}
+		return fail("no such enum value")
+	case pb.FieldDescriptorProto_TYPE_UINT32, pb.FieldDescriptorProto_TYPE_UINT64,
+		pb.FieldDescriptorProto_TYPE_FIXED32, pb.FieldDescriptorProto_TYPE_FIXED64:
+		_, err = strconv.ParseUint(v, 10, 64)
+	default:
+		_, err = strconv.ParseInt(v, 10, 64)
+	}
+	if err != nil {
+		return fail(err.Error())
+	}
+	return v
+}
+
+// normalizePass applies the goweb.normalize normalizers to requests.
+var normalizePass = &fieldPass{
+	name: "normalize",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return options.String(f.GetOptions(), options.E_Normalize) != ""
+	},
+	apply: func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+		if f.GetType() != pb.FieldDescriptorProto_TYPE_STRING || g.gen.ObjectNamed(msg).File().GetSyntax() != "proto3" {
+			g.gen.Fail("goweb.normalize on field", msg[1:]+"."+f.GetName(), "which is not a string field of a proto3 message")
+		}
+		var names []string
+		for _, name := range strings.Split(options.String(f.GetOptions(), options.E_Normalize), ",") {
+			names = append(names, strconv.Quote(strings.TrimSpace(name)))
+		}
+		args := strings.Join(names, ", ")
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+			g.P("	for i, v := range ", field, " {")
+			g.P("		", field, "[i] = goweb.Normalize(v, ", args, ")")
+			g.P("	}")
+		} else {
+			g.P("	", field, " = goweb.Normalize(", field, ", ", args, ")")
+		}
+	},
+}
+
+// cryptPass returns the pass encrypting (or decrypting, if decrypt) the
+// fields marked with goweb.encrypt.
+func cryptPass(decrypt bool) *fieldPass {
+	name, verb := "encrypt", "Encrypt"
+	if decrypt {
+		name, verb = "decrypt", "Decrypt"
+	}
+	return &fieldPass{
+		name:   name,
+		errors: true,
+		ctx:    true,
+		match:  func(f *pb.FieldDescriptorProto) bool { return options.Bool(f.GetOptions(), options.E_Encrypt) },
+		apply: func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+			fn := "goweb." + verb + "String"
+			switch f.GetType() {
+			case pb.FieldDescriptorProto_TYPE_STRING:
+			case pb.FieldDescriptorProto_TYPE_BYTES:
+				fn = "goweb." + verb + "Bytes"
+			default:
+				g.gen.Fail("goweb.encrypt on field", msg[1:]+"."+f.GetName(), "which is not a string or bytes field")
+			}
+			name := strconv.Quote(msg[1:] + "." + f.GetName())
+			v := field
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	for i := range ", field, " {")
+				v = field + "[i]"
+			}
+			g.P("	if c, err := ", fn, "(ctx, ", name, ", ", v, "); err != nil {")
+			g.P("		return err")
+			g.P("	} else {")
+			g.P("		", v, " = c")
+			g.P("	}")
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	}")
+			}
+		},
+	}
+}
+
+var (
+	encryptPass = cryptPass(false)
+	decryptPass = cryptPass(true)
+)
+
+// limitPass returns the pass enforcing the max_items and max_length field
+// options and the max_repeated, max_map and max_string parameters.
+func (g *grpc) limitPass() *fieldPass {
+	if g.limits != nil {
+		return g.limits
+	}
+	limits := func(f *pb.FieldDescriptorProto) (items, length int) {
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+			items = g.intParam("max_repeated")
+			if m := g.msgs[f.GetTypeName()]; m.GetOptions().GetMapEntry() {
+				items = g.intParam("max_map")
+			}
+			if n := options.Uint32(f.GetOptions(), options.E_MaxItems); n > 0 {
+				items = int(n)
+			}
+		}
+		if t := f.GetType(); t == pb.FieldDescriptorProto_TYPE_STRING || t == pb.FieldDescriptorProto_TYPE_BYTES {
+			length = g.intParam("max_string")
+			if n := options.Uint32(f.GetOptions(), options.E_MaxLength); n > 0 {
+				length = int(n)
+			}
+		}
+		return items, length
+	}
+	g.limits = &fieldPass{
+		name: "checkLimits",
+		match: func(f *pb.FieldDescriptorProto) bool {
+			items, length := limits(f)
+			return items > 0 || length > 0
+		},
+		apply: func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+			name := strconv.Quote(msg[1:] + "." + f.GetName())
+			items, length := limits(f)
+			if items > 0 {
+				g.P("	if len(", field, ") > ", items, " {")
+				g.P("		return &goweb.LimitError{Field: ", name, ", What: \"items\", Limit: ", items, "}")
+				g.P("	}")
+			}
+			if length == 0 {
+				return
+			}
+			v := "m." + g.goField(msg, f).getter + "()"
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	for _, v := range ", field, " {")
+				v = "v"
+			}
+			g.P("	if len(", v, ") > ", length, " {")
+			g.P("		return &goweb.LimitError{Field: ", name, ", What: \"bytes\", Limit: ", length, "}")
+			g.P("	}")
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	}")
+			}
+		},
+		errors: true,
+	}
+	return g.limits
+}
+
+// timeChecks are the goweb functions checking the range of the well-known
+// time types.
+var timeChecks = map[string]string{
+	".google.protobuf.Timestamp": "goweb.CheckTimestamp",
+	".google.protobuf.Duration":  "goweb.CheckDuration",
+}
+
+// timePass returns the pass checking that the Timestamp and Duration
+// fields of requests, and the values of maps of them, are in the ranges of
+// the protobuf spec.
+func (g *grpc) timePass() *fieldPass {
+	return &fieldPass{
+		name:   "checkTimes",
+		match:  func(f *pb.FieldDescriptorProto) bool { return timeChecks[g.fieldMessage(f)] != "" },
+		errors: true,
+		apply: func(g *grpc, msg string, f *pb.FieldDescriptorProto, field string) {
+			name := strconv.Quote(msg[1:] + "." + f.GetName())
+			v := field
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	for _, v := range ", field, " {")
+				v = "v"
+			}
+			g.P("	if err := ", timeChecks[g.fieldMessage(f)], "(", name, ", ", v, "); err != nil {")
+			g.P("		return err")
+			g.P("	}")
+			if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+				g.P("	}")
+			}
+		},
+	}
+}
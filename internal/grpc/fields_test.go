@@ -0,0 +1,221 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// loginFile is a service whose messages have fields marked with option
+// ext in a oneof, directly and in a message nested in it.
+func loginFile(ext *proto.ExtensionDesc, v interface{}) *pb.FileDescriptorProto {
+	str := pb.FieldDescriptorProto_TYPE_STRING
+	return testFile("login.proto",
+		message("Secret", withOption(field("token", 1, str), ext, v)),
+		withOneofs(message("User",
+			field("name", 1, str),
+			inOneof(0, withOption(field("password", 2, str), ext, v)),
+			inOneof(0, field("secret", 3, pb.FieldDescriptorProto_TYPE_MESSAGE, ".pkg.Secret")),
+		), "login"),
+		service("Users", method("Get", "User", "User")),
+	)
+}
+
+func TestRedactOneof(t *testing.T) {
+	src := generateMux(t, "", loginFile(options.E_Redact, proto.Bool(true)))
+	checkDecl(t, src, "_redact_pkg_User", `
+func _redact_pkg_User(m *User) {
+	if m == nil {
+		return
+	}
+	if _, ok := m.Login.(*User_Password); ok {
+		m.Login = nil
+	}
+	if _, ok := m.Login.(*User_Secret); ok {
+		_redact_pkg_Secret(m.Login.(*User_Secret).Secret)
+	}
+}`)
+	checkDecl(t, src, "_redact_pkg_Secret", `
+func _redact_pkg_Secret(m *Secret) {
+	if m == nil {
+		return
+	}
+	m.Token = ""
+}`)
+}
+
+func TestEncryptOneof(t *testing.T) {
+	src := generateMux(t, "", loginFile(options.E_Encrypt, proto.Bool(true)))
+	checkDecl(t, src, "_encrypt_pkg_User", `
+func _encrypt_pkg_User(ctx context.Context, m *User) error {
+	if m == nil {
+		return nil
+	}
+	if _, ok := m.Login.(*User_Password); ok {
+		if c, err := goweb.EncryptString(ctx, "pkg.User.password", m.Login.(*User_Password).Password); err != nil {
+			return err
+		} else {
+			m.Login.(*User_Password).Password = c
+		}
+	}
+	if _, ok := m.Login.(*User_Secret); ok {
+		if err := _encrypt_pkg_Secret(ctx, m.Login.(*User_Secret).Secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}`)
+	if !strings.Contains(decl(t, src, "_decrypt_pkg_User"), "goweb.DecryptString(ctx, \"pkg.User.password\", m.Login.(*User_Password).Password)") {
+		t.Error("requests are not decrypted in oneofs")
+	}
+}
+
+func TestLimitsOneof(t *testing.T) {
+	src := generateMux(t, "", loginFile(options.E_MaxLength, proto.Uint32(4)))
+	checkDecl(t, src, "_checkLimits_pkg_User", `
+func _checkLimits_pkg_User(m *User) error {
+	if m == nil {
+		return nil
+	}
+	if _, ok := m.Login.(*User_Password); ok {
+		if len(m.GetPassword()) > 4 {
+			return &goweb.LimitError{Field: "pkg.User.password", What: "bytes", Limit: 4}
+		}
+	}
+	if _, ok := m.Login.(*User_Secret); ok {
+		if err := _checkLimits_pkg_Secret(m.Login.(*User_Secret).Secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}`)
+}
+
+func TestRedactTransports(t *testing.T) {
+	src := generateMux(t, "mqtt,queue", loginFile(options.E_Redact, proto.Bool(true)))
+	for _, fn := range []string{"SubscribeUsersMQTT", "SubscribeUsers"} {
+		if !strings.Contains(decl(t, src, fn), "_redact_pkg_User(redacted)") {
+			t.Errorf("%s does not redact responses", fn)
+		}
+	}
+}
+
+func TestDefaultOneof(t *testing.T) {
+	err := generateError(t, "", loginFile(options.E_Default, proto.String("x")))
+	if !strings.Contains(err, "bad default") || !strings.Contains(err, "pkg.User.password") {
+		t.Errorf("error = %s", err)
+	}
+}
+
+// Output-only fields are cleared in requests, input-only fields in
+// responses.
+func TestVisibility(t *testing.T) {
+	str := pb.FieldDescriptorProto_TYPE_STRING
+	src := generateMux(t, "", testFile("users.proto",
+		message("User",
+			field("name", 1, str),
+			withOption(field("id", 2, str), options.E_Visibility, proto.Int32(options.OutputOnly)),
+			withOption(field("password", 3, str), options.E_Visibility, proto.Int32(options.InputOnly)),
+		),
+		service("Users", method("Get", "User", "User")),
+	))
+	checkDecl(t, src, "_clearOutputOnly_pkg_User", `
+func _clearOutputOnly_pkg_User(m *User) {
+	if m == nil {
+		return
+	}
+	m.Id = ""
+}`)
+	checkDecl(t, src, "_clearInputOnly_pkg_User", `
+func _clearInputOnly_pkg_User(m *User) {
+	if m == nil {
+		return
+	}
+	m.Password = ""
+}`)
+	handler := decl(t, src, "(*_UsersServer).Get")
+	for _, want := range []string{
+		"_clearOutputOnly_pkg_User(&in)\n\tif err := goweb.Validate(&in); err != nil {",
+		"res = goweb.Clone(res).(*User)\n\t_clearInputOnly_pkg_User(res)\n",
+	} {
+		if !strings.Contains(handler, want) {
+			t.Errorf("handler\n%s\nlacks %q", handler, want)
+		}
+	}
+}
+
+// Defaults are set on unset fields of requests.
+func TestDefault(t *testing.T) {
+	str := pb.FieldDescriptorProto_TYPE_STRING
+	src := generateMux(t, "", testFile("users.proto",
+		message("User",
+			field("name", 1, str),
+			withOption(field("lang", 2, str), options.E_Default, proto.String("en")),
+			withOption(field("limit", 3, pb.FieldDescriptorProto_TYPE_INT32), options.E_Default, proto.String("10")),
+		),
+		service("Users", method("Get", "User", "User")),
+	))
+	checkDecl(t, src, "_setDefaults_pkg_User", `
+func _setDefaults_pkg_User(m *User) {
+	if m == nil {
+		return
+	}
+	if m.Lang == "" {
+		m.Lang = "en"
+	}
+	if m.Limit == 0 {
+		m.Limit = 10
+	}
+}`)
+	if !strings.Contains(decl(t, src, "(*_UsersServer).Get"), "_setDefaults_pkg_User(&in)\n\tif err := goweb.Validate(&in); err != nil {") {
+		t.Error("defaults not set before validation")
+	}
+}
+
+// Responses are redacted unless the caller may see the secrets.
+func TestRedact(t *testing.T) {
+	src := generateMux(t, "", loginFile(options.E_Redact, proto.Bool(true)))
+	want := `
+	if redacted, ok := goweb.Redacted(ctx, res).(*User); ok {
+		_redact_pkg_User(redacted)
+		res = redacted
+	}
+	out, err := goweb.Proto3JSON{}.Marshal(res)`
+	if handler := decl(t, src, "(*_UsersServer).Get"); !strings.Contains(handler, want) {
+		t.Errorf("handler does not redact the response:\n%s", handler)
+	}
+}
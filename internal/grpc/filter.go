@@ -0,0 +1,123 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// filterFieldType returns the goweb type of the field f of a resource in
+// filters, or "" if filters cannot compare it. Redacted fields are left
+// out, since filters on them reveal their values.
+func filterFieldType(f *pb.FieldDescriptorProto) string {
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED || options.Bool(f.GetOptions(), options.E_Redact) {
+		return ""
+	}
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		return "goweb.StringField"
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return "goweb.BoolField"
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		return "goweb.EnumField"
+	case pb.FieldDescriptorProto_TYPE_DOUBLE, pb.FieldDescriptorProto_TYPE_FLOAT:
+		return "goweb.FloatField"
+	case pb.FieldDescriptorProto_TYPE_INT32, pb.FieldDescriptorProto_TYPE_INT64, pb.FieldDescriptorProto_TYPE_UINT32,
+		pb.FieldDescriptorProto_TYPE_UINT64, pb.FieldDescriptorProto_TYPE_SINT32, pb.FieldDescriptorProto_TYPE_SINT64,
+		pb.FieldDescriptorProto_TYPE_FIXED32, pb.FieldDescriptorProto_TYPE_FIXED64, pb.FieldDescriptorProto_TYPE_SFIXED32,
+		pb.FieldDescriptorProto_TYPE_SFIXED64:
+		return "goweb.IntField"
+	case pb.FieldDescriptorProto_TYPE_MESSAGE:
+		if f.GetTypeName() == ".google.protobuf.Timestamp" {
+			return "goweb.TimeField"
+		}
+	}
+	return ""
+}
+
+// listResource returns the type of the resources listed by the response
+// msg, that of its first repeated message field, or "".
+func (g *grpc) listResource(msg string) string {
+	for _, f := range g.msgs[msg].GetField() {
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED && f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE &&
+			!g.msgs[f.GetTypeName()].GetOptions().GetMapEntry() {
+			return f.GetTypeName()
+		}
+	}
+	return ""
+}
+
+// generateListFilters generates <Service><Method>Filter for the unary
+// methods of the service with a filter request field listing resources,
+// holding the fields of the resources by which filters compare, see
+// goweb.Filter.
+func (g *grpc) generateListFilters(servName string, service *pb.ServiceDescriptorProto) {
+	for _, method := range service.Method {
+		in := method.GetInputType()
+		filter := g.stringField(in, "filter")
+		resource := g.listResource(method.GetOutputType())
+		if method.GetServerStreaming() || method.GetClientStreaming() || filter == nil || resource == "" {
+			continue
+		}
+		var fields []*pb.FieldDescriptorProto
+		for _, f := range g.msgs[resource].GetField() {
+			if filterFieldType(f) != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		name := servName + generator.CamelCase(method.GetName()) + "Filter"
+		example := name + "." + generator.CamelCase(fields[0].GetName())
+		g.P("// ", name, " holds the fields of ", g.typeName(resource), " that build the")
+		g.P("// filter of the requests of the ", method.GetName(), " method of ", servName, ", e.g.")
+		g.P("//")
+		g.P("//	in.", g.goField(in, filter).field, " = ", example, ".Eq(v).And(...).String()")
+		g.P("//")
+		g.P("// see goweb.Filter.")
+		g.P("var ", name, " = struct {")
+		for _, f := range fields {
+			g.P(generator.CamelCase(f.GetName()), " ", filterFieldType(f))
+		}
+		g.P("}{")
+		for _, f := range fields {
+			g.P(generator.CamelCase(f.GetName()), ": ", strconv.Quote(f.GetName()), ",")
+		}
+		g.P("}")
+		g.P()
+	}
+}
@@ -0,0 +1,96 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// fingerprint returns the sha256 of the descriptor set of file and its
+// transitive imports, without source code info, in the order of the
+// request.
+func (g *grpc) fingerprint(file *generator.FileDescriptor) string {
+	set := &pb.FileDescriptorSet{}
+	for _, f := range g.closure(file) {
+		f = proto.Clone(f).(*pb.FileDescriptorProto)
+		f.SourceCodeInfo = nil
+		set.File = append(set.File, f)
+	}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		g.gen.Error(err, "fingerprinting", file.GetName())
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// closure returns file and its transitive imports, in the order of the
+// request, which lists every file after its imports.
+func (g *grpc) closure(file *generator.FileDescriptor) []*pb.FileDescriptorProto {
+	need := map[string]bool{}
+	byName := map[string]*pb.FileDescriptorProto{}
+	for _, f := range g.gen.Request.ProtoFile {
+		byName[f.GetName()] = f
+	}
+	var mark func(name string)
+	mark = func(name string) {
+		if need[name] {
+			return
+		}
+		need[name] = true
+		for _, dep := range byName[name].GetDependency() {
+			mark(dep)
+		}
+	}
+	mark(file.GetName())
+	var files []*pb.FileDescriptorProto
+	for _, f := range g.gen.Request.ProtoFile {
+		if need[f.GetName()] {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// generateVersionEndpoint generates the route of <prefix>/_version in
+// New<Service>Mux, serving the contract of file, see goweb.VersionHandler.
+func (g *grpc) generateVersionEndpoint(file *generator.FileDescriptor) {
+	g.P("router.Get(goweb.JoinPath(prefix, \"_version\"), goweb.VersionHandler(goweb.VersionInfo{File: ", strconv.Quote(file.GetName()),
+		", Generator: ", strconv.Quote(goweb.Version), ", Fingerprint: ", strconv.Quote(g.fingerprint(file)), "}))")
+}
@@ -0,0 +1,67 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestFingerprint(t *testing.T) {
+	files := func(comment, dep, other string) []*pb.FileDescriptorProto {
+		return []*pb.FileDescriptorProto{
+			{Name: proto.String("dep.proto"), Package: proto.String(dep)},
+			{Name: proto.String("other.proto"), Package: proto.String(other)},
+			{Name: proto.String("a.proto"), Dependency: []string{"dep.proto"}, SourceCodeInfo: &pb.SourceCodeInfo{
+				Location: []*pb.SourceCodeInfo_Location{{LeadingComments: proto.String(comment)}},
+			}},
+		}
+	}
+	fingerprint := func(protos []*pb.FileDescriptorProto) string {
+		g := &grpc{gen: generator.New()}
+		g.gen.Request.ProtoFile = protos
+		return g.fingerprint(&generator.FileDescriptor{FileDescriptorProto: protos[2]})
+	}
+	base := fingerprint(files("a", "dep", "other"))
+	if len(base) != len("sha256:")+64 {
+		t.Fatalf("fingerprint = %q", base)
+	}
+	if got := fingerprint(files("b", "dep", "x")); got != base {
+		t.Errorf("comments or unrelated files change the fingerprint: %q", got)
+	}
+	if got := fingerprint(files("a", "dep2", "other")); got == base {
+		t.Error("an import does not change the fingerprint")
+	}
+}
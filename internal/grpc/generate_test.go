@@ -0,0 +1,293 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// The tests of the generated code run the plugin in a child process, as
+// the generator keeps global state and exits on errors.
+func TestMain(m *testing.M) {
+	if os.Getenv("GOWEB_TEST_PLUGIN") != "" {
+		runPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runPlugin is the main function of protoc-gen-goweb.
+func runPlugin() {
+	g := generator.New()
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		g.Error(err, "reading input")
+	}
+	if err := proto.Unmarshal(data, g.Request); err != nil {
+		g.Error(err, "parsing input proto")
+	}
+	g.CommandLineParameters(g.Request.GetParameter())
+	g.WrapTypes()
+	g.SetPackageNames()
+	g.BuildTypeNameMap()
+	g.GenerateAllFiles()
+	g.ApplyManifest()
+	if data, err = proto.Marshal(g.Response); err != nil {
+		g.Error(err, "failed to marshal output proto")
+	}
+	os.Stdout.Write(data)
+}
+
+// generate runs the plugin with the parameter on the last of files and
+// returns the generated files by name, or the error the plugin reported.
+func generate(t *testing.T, param string, files ...*pb.FileDescriptorProto) (map[string]string, error) {
+	t.Helper()
+	res, err := request(t, &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{files[len(files)-1].GetName()},
+		Parameter:      proto.String("plugins=grpc," + param),
+		ProtoFile:      files,
+	})
+	if err != nil {
+		return nil, err
+	}
+	generated := map[string]string{}
+	for _, f := range res {
+		generated[f.GetName()] = f.GetContent()
+	}
+	return generated, nil
+}
+
+// request runs the plugin on req and returns the files it generated, in
+// order, or the error it reported.
+func request(t *testing.T, req *plugin.CodeGeneratorRequest) ([]*plugin.CodeGeneratorResponse_File, error) {
+	t.Helper()
+	data, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out, errs bytes.Buffer
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "GOWEB_TEST_PLUGIN=1")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(data), &out, &errs
+	if err := cmd.Run(); err != nil {
+		return nil, &pluginError{strings.TrimSpace(errs.String())}
+	}
+	res := new(plugin.CodeGeneratorResponse)
+	if err := proto.Unmarshal(out.Bytes(), res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != nil {
+		return nil, &pluginError{res.GetError()}
+	}
+	return res.File, nil
+}
+
+type pluginError struct{ msg string }
+
+func (e *pluginError) Error() string { return e.msg }
+
+// generateMux is like generate, but returns the Go file of the last of
+// files, failing the test if the plugin fails.
+func generateMux(t *testing.T, param string, files ...*pb.FileDescriptorProto) string {
+	t.Helper()
+	generated, err := generate(t, param, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := strings.TrimSuffix(files[len(files)-1].GetName(), ".proto") + ".mux.go"
+	src, ok := generated[name]
+	if !ok {
+		t.Fatalf("%s not generated", name)
+	}
+	return src
+}
+
+// generateError is like generate, but returns the error of the plugin,
+// failing the test if it succeeds.
+func generateError(t *testing.T, param string, files ...*pb.FileDescriptorProto) string {
+	t.Helper()
+	_, err := generate(t, param, files...)
+	if err == nil {
+		t.Fatal("generation did not fail")
+	}
+	return err.Error()
+}
+
+// decl returns the top-level declaration called name of the Go source
+// src: a function, a method such as "(*T).Name", a type or a constant.
+func decl(t *testing.T, src, name string) string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v", err)
+	}
+	for _, d := range file.Decls {
+		var found bool
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			fn := d.Name.Name
+			if d.Recv != nil {
+				var recv bytes.Buffer
+				printer.Fprint(&recv, fset, d.Recv.List[0].Type)
+				fn = "(" + recv.String() + ")." + fn
+			}
+			found = fn == name
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					found = found || s.Name.Name == name
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						found = found || n.Name == name
+					}
+				}
+			}
+		}
+		if found {
+			start, end := fset.Position(d.Pos()).Offset, fset.Position(d.End()).Offset
+			return src[start:end]
+		}
+	}
+	t.Fatalf("generated code has no %s:\n%s", name, src)
+	return ""
+}
+
+// checkDecl checks that the declaration called name of the Go source src
+// is want, apart from leading and trailing white space.
+func checkDecl(t *testing.T, src, name, want string) {
+	t.Helper()
+	if got := decl(t, src, name); got != strings.TrimSpace(want) {
+		t.Errorf("generated %s:\n%s\nwant:\n%s", name, got, strings.TrimSpace(want))
+	}
+}
+
+// A test proto file is built from these helpers, e.g.
+//
+//	testFile("svc.proto", message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)), service("Users", method("Get", "User", "User")))
+func testFile(name string, decls ...proto.Message) *pb.FileDescriptorProto {
+	f := &pb.FileDescriptorProto{
+		Name:    proto.String(name),
+		Package: proto.String("pkg"),
+		Syntax:  proto.String("proto3"),
+		Options: &pb.FileOptions{GoPackage: proto.String("example.com/pkg")},
+	}
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *pb.DescriptorProto:
+			f.MessageType = append(f.MessageType, d)
+		case *pb.EnumDescriptorProto:
+			f.EnumType = append(f.EnumType, d)
+		case *pb.ServiceDescriptorProto:
+			f.Service = append(f.Service, d)
+		}
+	}
+	return f
+}
+
+func message(name string, fields ...*pb.FieldDescriptorProto) *pb.DescriptorProto {
+	return &pb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// withOneofs declares the oneofs of m, which its fields refer to by index.
+func withOneofs(m *pb.DescriptorProto, names ...string) *pb.DescriptorProto {
+	for _, n := range names {
+		m.OneofDecl = append(m.OneofDecl, &pb.OneofDescriptorProto{Name: proto.String(n)})
+	}
+	return m
+}
+
+// field returns a singular field; a message or enum type is given as
+// the full name of typeName.
+func field(name string, number int32, typ pb.FieldDescriptorProto_Type, typeName ...string) *pb.FieldDescriptorProto {
+	f := &pb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  pb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+	if len(typeName) > 0 {
+		f.TypeName = proto.String(typeName[0])
+	}
+	return f
+}
+
+// repeated makes f repeated.
+func repeated(f *pb.FieldDescriptorProto) *pb.FieldDescriptorProto {
+	f.Label = pb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// inOneof puts f into the oneof with the index of its message.
+func inOneof(index int32, f *pb.FieldDescriptorProto) *pb.FieldDescriptorProto {
+	f.OneofIndex = proto.Int32(index)
+	return f
+}
+
+// withOption sets the extension ext of the options of f to v.
+func withOption(f *pb.FieldDescriptorProto, ext *proto.ExtensionDesc, v interface{}) *pb.FieldDescriptorProto {
+	if f.Options == nil {
+		f.Options = &pb.FieldOptions{}
+	}
+	if err := proto.SetExtension(f.Options, ext, v); err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func service(name string, methods ...*pb.MethodDescriptorProto) *pb.ServiceDescriptorProto {
+	return &pb.ServiceDescriptorProto{Name: proto.String(name), Method: methods}
+}
+
+// method returns a unary method of the messages in and out of package pkg.
+func method(name, in, out string) *pb.MethodDescriptorProto {
+	return &pb.MethodDescriptorProto{
+		Name:       proto.String(name),
+		InputType:  proto.String(".pkg." + in),
+		OutputType: proto.String(".pkg." + out),
+	}
+}
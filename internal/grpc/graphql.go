@@ -0,0 +1,321 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// queryPrefixes are the method name prefixes that make a method a GraphQL
+// query; all other unary methods become mutations.
+var queryPrefixes = []string{"Get", "List", "Search", "Find", "Lookup", "Query"}
+
+func isQuery(method *pb.MethodDescriptorProto) bool {
+	for _, p := range queryPrefixes {
+		if strings.HasPrefix(method.GetName(), p) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerFirst lowercases the first letter of s.
+func lowerFirst(s string) string { return strings.ToLower(s[:1]) + s[1:] }
+
+// jsonName returns the JSON (lowerCamelCase) name of a field.
+func jsonName(f *pb.FieldDescriptorProto) string {
+	if n := f.GetJsonName(); n != "" {
+		return n
+	}
+	return lowerFirst(generator.CamelCase(f.GetName()))
+}
+
+// graphqlName returns the GraphQL type name for the message or enum
+// called name, which is its Go name without package qualifier dots.
+func (g *grpc) graphqlName(name string) string {
+	return strings.Replace(g.typeName(name), ".", "_", -1)
+}
+
+// graphqlScalar maps the scalar proto types onto GraphQL scalars. Types
+// whose Go representation the resolvers cannot hand out as a GraphQL
+// scalar (64-bit and unsigned integers, float, bytes, enums) are missing;
+// fields of those types are left out of the schema.
+var graphqlScalar = map[pb.FieldDescriptorProto_Type]string{
+	pb.FieldDescriptorProto_TYPE_DOUBLE:   "Float",
+	pb.FieldDescriptorProto_TYPE_INT32:    "Int",
+	pb.FieldDescriptorProto_TYPE_SFIXED32: "Int",
+	pb.FieldDescriptorProto_TYPE_SINT32:   "Int",
+	pb.FieldDescriptorProto_TYPE_BOOL:     "Boolean",
+	pb.FieldDescriptorProto_TYPE_STRING:   "String",
+}
+
+// graphqlSchema collects the GraphQL type definitions of a service.
+type graphqlSchema struct {
+	g        *grpc
+	buf      bytes.Buffer
+	done     map[string]bool // type names already defined
+	queue    []string        // messages still to define, as "input:name" or "type:name"
+	checking map[string]bool // messages hasFields is checking, as keys of queue
+}
+
+// ref returns the GraphQL type expression for the message name,
+// queuing its definition; input selects the input object variant.
+func (s *graphqlSchema) ref(name string, input bool) string {
+	typ := s.g.graphqlName(name)
+	key := "type:" + name
+	if input {
+		typ += "Input"
+		key = "input:" + name
+	}
+	if !s.done[key] {
+		s.done[key] = true
+		s.queue = append(s.queue, key)
+	}
+	return typ
+}
+
+// hasFields reports whether the message name has fields GraphQL can
+// express, in its input object variant if input.
+func (s *graphqlSchema) hasFields(name string, input bool) bool {
+	key := "type:" + name
+	if input {
+		key = "input:" + name
+	}
+	msg := s.g.msgs[name]
+	if msg == nil || s.checking[key] {
+		// A message containing itself has no fields through itself.
+		return false
+	}
+	s.checking[key] = true
+	defer delete(s.checking, key)
+	for _, f := range msg.Field {
+		if s.fieldType(f, input, false) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType returns the GraphQL type of a field, or "" if it has none
+// (map fields, groups, the scalars missing from graphqlScalar, the
+// redacted fields of output types and messages without any other fields,
+// which would be empty types). define queues referenced messages.
+func (s *graphqlSchema) fieldType(f *pb.FieldDescriptorProto, input, define bool) string {
+	if !input && options.Bool(f.GetOptions(), options.E_Redact) {
+		return ""
+	}
+	var typ string
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_MESSAGE:
+		msg := s.g.msgs[f.GetTypeName()]
+		if msg == nil || msg.GetOptions().GetMapEntry() || !s.hasFields(f.GetTypeName(), input) {
+			return ""
+		}
+		if !define {
+			return "message"
+		}
+		typ = s.ref(f.GetTypeName(), input)
+	case pb.FieldDescriptorProto_TYPE_GROUP:
+		return ""
+	default:
+		typ = graphqlScalar[f.GetType()]
+		if typ == "" {
+			return ""
+		}
+		if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+			break
+		}
+		// Scalars are never null in proto3. In input objects the zero
+		// value default keeps them optional for the client.
+		typ += "!"
+		if input {
+			typ += " = " + graphqlZero[typ]
+		}
+	}
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		// Lists, like scalars, are never null.
+		typ = "[" + typ + "!]!"
+		if input {
+			typ += " = []"
+		}
+	}
+	return typ
+}
+
+var graphqlZero = map[string]string{
+	"Float!":   "0",
+	"Int!":     "0",
+	"Boolean!": "false",
+	"String!":  `""`,
+}
+
+// define writes the definition of a queued type.
+func (s *graphqlSchema) define(key string) {
+	i := strings.Index(key, ":")
+	kind, name := key[:i], key[i+1:]
+	msg := s.g.msgs[name]
+	typ := s.g.graphqlName(name)
+	if kind == "input" {
+		typ += "Input"
+	}
+	fmt.Fprintf(&s.buf, "%s %s {\n", kind, typ)
+	for _, f := range msg.Field {
+		if t := s.fieldType(f, kind == "input", true); t != "" {
+			fmt.Fprintf(&s.buf, "  %s: %s\n", jsonName(f), t)
+		}
+	}
+	fmt.Fprintf(&s.buf, "}\n")
+}
+
+// generateGraphQL generates <Service>GraphQLSchema, the GraphQL schema of
+// the service, and <Service>GraphQLResolver, a root resolver calling the
+// <Service>Server. The resolver follows the conventions of
+// github.com/graph-gophers/graphql-go:
+//
+//	schema := graphql.MustParseSchema(pkg.UsersGraphQLSchema,
+//		pkg.NewUsersGraphQLResolver(impl), graphql.UseFieldResolvers())
+//
+// Methods whose name starts with Get, List, Search, Find, Lookup or Query
+// are queries, other unary methods are mutations; streaming methods are
+// left out. Responses are filtered like those of the HTTP handlers, and
+// redacted fields are not part of the output types.
+func (g *grpc) generateGraphQL(servName string, service *pb.ServiceDescriptorProto) {
+	s := &graphqlSchema{g: g, done: make(map[string]bool), checking: make(map[string]bool)}
+	var queries, mutations bytes.Buffer
+	type resolver struct {
+		method         *pb.MethodDescriptorProto
+		input, boolean bool // has an input argument; returns a bool for an empty output
+	}
+	var resolvers []resolver
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		r := resolver{method: method, input: s.hasFields(method.GetInputType(), true), boolean: !s.hasFields(method.GetOutputType(), false)}
+		resolvers = append(resolvers, r)
+		w := &mutations
+		if isQuery(method) {
+			w = &queries
+		}
+		fmt.Fprintf(w, "  %s", lowerFirst(generator.CamelCase(method.GetName())))
+		if r.input {
+			fmt.Fprintf(w, "(input: %s!)", s.ref(method.GetInputType(), true))
+		}
+		if r.boolean {
+			fmt.Fprintf(w, ": Boolean\n")
+		} else {
+			fmt.Fprintf(w, ": %s\n", s.ref(method.GetOutputType(), false))
+		}
+	}
+	if queries.Len() == 0 {
+		// A GraphQL schema must have a query type.
+		fmt.Fprintf(&queries, "  serviceName: String!\n")
+	}
+	fmt.Fprintf(&s.buf, "schema {\n  query: Query\n")
+	if mutations.Len() > 0 {
+		fmt.Fprintf(&s.buf, "  mutation: Mutation\n")
+	}
+	fmt.Fprintf(&s.buf, "}\ntype Query {\n%s}\n", queries.String())
+	if mutations.Len() > 0 {
+		fmt.Fprintf(&s.buf, "type Mutation {\n%s}\n", mutations.String())
+	}
+	for len(s.queue) > 0 {
+		key := s.queue[0]
+		s.queue = s.queue[1:]
+		s.define(key)
+	}
+
+	g.P("// ", servName, "GraphQLSchema is the GraphQL schema of the ", servName, " service.")
+	g.P("const ", servName, "GraphQLSchema = `", s.buf.String(), "`")
+	g.P()
+	resType := servName + "GraphQLResolver"
+	g.P("// ", resType, " resolves the queries and mutations of ", servName, "GraphQLSchema")
+	g.P("// by calling a ", servName, "Server.")
+	g.P("type ", resType, " struct {")
+	g.P("	handler ", servName, "Server")
+	g.P("}")
+	g.P()
+	g.P("// New", resType, " returns the root resolver for ", servName, "GraphQLSchema.")
+	g.P("func New", resType, "(h ", servName, "Server) *", resType, " {")
+	g.P("	return &", resType, "{h}")
+	g.P("}")
+	g.P()
+	if queries.String() == "  serviceName: String!\n" {
+		g.P("func (*", resType, ") ServiceName() string {")
+		g.P("	return ", strconv.Quote(servName))
+		g.P("}")
+		g.P()
+	}
+	for _, r := range resolvers {
+		methName := generator.CamelCase(r.method.GetName())
+		inType := g.typeName(r.method.GetInputType())
+		args := ""
+		if r.input {
+			args = "args struct{ Input *" + inType + " }"
+		}
+		ret := "*" + g.typeName(r.method.GetOutputType())
+		if r.boolean {
+			ret = "*bool"
+		}
+		g.P("func (r *", resType, ") ", methName, "(ctx ", contextPkg, ".Context, ", args, ") (", ret, ", error) {")
+		if r.input {
+			g.P("	in := args.Input")
+		} else {
+			g.P("	in := new(", inType, ")")
+		}
+		if r.boolean {
+			g.P("	if _, err := r.handler.", methName, "(ctx, in); err != nil {")
+			g.P("		return nil, err")
+			g.P("	}")
+			g.P("	ok := true")
+			g.P("	return &ok, nil")
+		} else if out := r.method.GetOutputType(); g.needs(inputOnlyPass, out) || g.needs(redactPass, out) {
+			g.P("	out, err := r.handler.", methName, "(ctx, in)")
+			g.P("	if err != nil {")
+			g.P("		return nil, err")
+			g.P("	}")
+			g.generateResponseFilter(r.method, "out")
+			g.P("	return out, nil")
+		} else {
+			g.P("	return r.handler.", methName, "(ctx, in)")
+		}
+		g.P("}")
+		g.P()
+	}
+}
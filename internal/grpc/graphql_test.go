@@ -0,0 +1,135 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Redacted fields are left out of the output types, and messages with
+// only redacted fields too; the resolvers redact responses anyway.
+func TestGraphQLRedact(t *testing.T) {
+	src := generateMux(t, "graphql", loginFile(options.E_Redact, proto.Bool(true)))
+	checkDecl(t, src, "UsersGraphQLSchema", "const UsersGraphQLSchema = `schema {\n"+`  query: Query
+}
+type Query {
+  get(input: UserInput!): User
+}
+input UserInput {
+  name: String! = ""
+  password: String! = ""
+  secret: SecretInput
+}
+type User {
+  name: String!
+}
+input SecretInput {
+  token: String! = ""
+}
+`+"`")
+	checkDecl(t, src, "(*UsersGraphQLResolver).Get", `
+func (r *UsersGraphQLResolver) Get(ctx context.Context, args struct{ Input *User }) (*User, error) {
+	in := args.Input
+	out, err := r.handler.Get(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if redacted, ok := goweb.Redacted(ctx, out).(*User); ok {
+		_redact_pkg_User(redacted)
+		out = redacted
+	}
+	return out, nil
+}`)
+}
+
+// Get… methods are queries and other unary methods mutations; streaming
+// methods and fields without a GraphQL scalar are left out.
+func TestGraphQL(t *testing.T) {
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	src := generateMux(t, "graphql", testFile("users.proto",
+		message("User",
+			field("name", 1, pb.FieldDescriptorProto_TYPE_STRING),
+			field("age", 2, pb.FieldDescriptorProto_TYPE_INT32),
+			repeated(field("tags", 3, pb.FieldDescriptorProto_TYPE_STRING)),
+			field("id", 4, pb.FieldDescriptorProto_TYPE_INT64),
+			field("address", 5, pb.FieldDescriptorProto_TYPE_MESSAGE, ".pkg.Address"),
+		),
+		message("Address", field("city", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("GetUser", "User", "User"), method("DeleteUser", "User", "User"), watch),
+	))
+	checkDecl(t, src, "UsersGraphQLSchema", "const UsersGraphQLSchema = `schema {\n"+`  query: Query
+  mutation: Mutation
+}
+type Query {
+  getUser(input: UserInput!): User
+}
+type Mutation {
+  deleteUser(input: UserInput!): User
+}
+input UserInput {
+  name: String! = ""
+  age: Int! = 0
+  tags: [String!]! = []
+  address: AddressInput
+}
+type User {
+  name: String!
+  age: Int!
+  tags: [String!]!
+  address: Address
+}
+input AddressInput {
+  city: String! = ""
+}
+type Address {
+  city: String!
+}
+`+"`")
+	checkDecl(t, src, "NewUsersGraphQLResolver", `
+func NewUsersGraphQLResolver(h UsersServer) *UsersGraphQLResolver {
+	return &UsersGraphQLResolver{h}
+}`)
+	checkDecl(t, src, "(*UsersGraphQLResolver).DeleteUser", `
+func (r *UsersGraphQLResolver) DeleteUser(ctx context.Context, args struct{ Input *User }) (*User, error) {
+	in := args.Input
+	return r.handler.DeleteUser(ctx, in)
+}`)
+	if strings.Contains(src, "(r *UsersGraphQLResolver) Watch") {
+		t.Error("resolver for a streaming method")
+	}
+}
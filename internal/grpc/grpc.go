@@ -37,10 +37,13 @@ package grpc
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
 	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
 )
 
@@ -49,16 +52,40 @@ import (
 const (
 	contextPkgPath = "golang.org/x/net/context"
 	grpcPkgPath    = "google.golang.org/grpc"
+	gowebPkgPath   = "github.com/ekle/protoc-gen-goweb/goweb"
+	protoPkgPath   = "github.com/golang/protobuf/proto"
 )
 
 func init() {
 	generator.RegisterPlugin(new(grpc))
+	// The packages the generated code refers to by these names; see
+	// GenerateImports.
+	generator.ReservePackageNames("web", "goweb", "http", "httptest", "log", "json", "time", "strconv",
+		"metadata", "health", "healthpb", "reflection")
 }
 
 // grpc is an implementation of the Go protocol buffer compiler's
 // plugin architecture.  It generates bindings for gRPC support.
 type grpc struct {
-	gen *generator.Generator
+	gen  *generator.Generator
+	msgs map[string]*pb.DescriptorProto // All messages of the request, by full name.
+
+	passFuncs  map[string]bool                      // The field pass functions of the file, by name.
+	passQueue  []func()                             // The field pass functions still to generate.
+	fieldNames map[*pb.FieldDescriptorProto]goNames // See goField.
+	limits     *fieldPass                           // See limitPass.
+
+	service *pb.ServiceDescriptorProto // The service being generated.
+
+	// The parameters of the services and methods of the config file, by
+	// full service name and full method name ("/pkg.Service/Method"), and
+	// the names of those being generated.
+	services    map[string]map[string]string
+	methods     map[string]map[string]string
+	serviceName string
+	method      string
+
+	arena bool // Whether the handler being generated uses an arena, see in.
 }
 
 // Name returns the name of this plugin, "grpc".
@@ -77,8 +104,22 @@ var (
 // Init initializes the plugin.
 func (g *grpc) Init(gen *generator.Generator) {
 	g.gen = gen
+	g.msgs = goweb.IndexMessages(gen.Request.ProtoFile)
 	contextPkg = generator.RegisterUniquePackageName("context", nil)
 	grpcPkg = generator.RegisterUniquePackageName("grpc", nil)
+	for _, file := range gen.Request.ProtoFile {
+		if err := options.RegisterFile(file); err != nil {
+			gen.Error(err, "registering the custom options of", file.GetName())
+		}
+	}
+	g.loadConfig()
+	g.applyProfile()
+}
+
+// Fork returns an instance of the plugin for another generator, sharing
+// the messages and the parameters of the config file with g.
+func (g *grpc) Fork(gen *generator.Generator) generator.Plugin {
+	return &grpc{gen: gen, msgs: g.msgs, services: g.services, methods: g.methods}
 }
 
 // Given a type name defined in a .proto, return its object.
@@ -93,14 +134,88 @@ func (g *grpc) typeName(str string) string {
 	return g.gen.TypeName(g.objectNamed(str))
 }
 
+// flag reports whether the boolean plugin parameter name is set,
+// either bare ("name") or with a value other than "false".
+func (g *grpc) flag(name string) bool {
+	v, ok := g.param(name)
+	return ok && v != "false"
+}
+
+// intParam returns the value of the integer plugin parameter name, or 0.
+func (g *grpc) intParam(name string) int {
+	v, ok := g.param(name)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		g.gen.Fail("parameter", name, "must be a non-negative integer, not", strconv.Quote(v))
+	}
+	return n
+}
+
+// unsupportedStream fails the generation for a streaming method that the
+// http handlers cannot serve if the unsupported_streams parameter is
+// "fail"; by default ("501") the handler answers 501 Not Implemented.
+func (g *grpc) unsupportedStream(route goweb.Route) {
+	switch v, _ := g.param("unsupported_streams"); v {
+	case "", "501":
+	case "fail":
+		if route.ClientStreaming {
+			g.gen.Fail("method", route.FullMethod(), "is client or bidirectional streaming, which http handlers cannot serve")
+		}
+		g.gen.Fail("method", route.FullMethod(), "is server streaming, which http handlers only serve with the streams parameter")
+	default:
+		g.gen.Fail("parameter unsupported_streams must be 501 or fail, not", strconv.Quote(v))
+	}
+}
+
+// errorWriter returns the goweb function generated handlers answer
+// errors with, after the error_format parameter.
+func (g *grpc) errorWriter() string {
+	switch v, _ := g.param("error_format"); v {
+	case "", "json":
+		return "WriteRequestError"
+	case "problem":
+		return "WriteProblem"
+	default:
+		g.gen.Fail("parameter error_format must be json or problem, not", strconv.Quote(v))
+	}
+	return ""
+}
+
 // P forwards to g.gen.P.
 func (g *grpc) P(args ...interface{}) { g.gen.P(args...) }
 
 // Generate generates code for the services in the given file.
 func (g *grpc) Generate(file *generator.FileDescriptor) {
+	g.passFuncs = map[string]bool{}
 	for i, service := range file.FileDescriptorProto.Service {
 		g.generateService(file, service, i)
 	}
+	if g.flag("canonical") && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateCanonical(file)
+	}
+	if g.flag("examples") && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateExamples(file)
+	}
+	g.generateVersions(file)
+	if g.staticDir() != "" && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateStaticFS(file)
+	}
+	if len(file.FileDescriptorProto.Service) > 0 {
+		g.generateAnyTypes(file)
+	}
+	if g.flag("openapi") && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateOpenAPI(file)
+	}
+	if g.flag("descriptors") && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateDescriptor(file)
+	}
+	if g.flag("error_catalog") && len(file.FileDescriptorProto.Service) > 0 {
+		g.generateErrorCatalog(file)
+	}
+	g.generatePassFuncs()
 }
 
 // GenerateImports generates the import declaration for this file.
@@ -110,11 +225,33 @@ func (g *grpc) GenerateImports(file *generator.FileDescriptor) {
 	}
 	g.P("import (")
 	g.P(contextPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, contextPkgPath)))
-	//g.P(grpcPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath)))
-	g.P("\"github.com/zenazn/goji/web\"")
+	if g.anyFlag(file, "grpc_proxy") || g.anyFlag(file, "grpc_dual") {
+		g.P(grpcPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath)))
+	}
+	if g.anyFlag(file, "grpc_dual") {
+		g.P(strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath, "health")))
+		g.P("healthpb ", strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath, "health/grpc_health_v1")))
+		g.P(strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath, "reflection")))
+	}
+	if g.anyFlag(file, "streams") {
+		g.P("metadata ", strconv.Quote(path.Join(g.gen.ImportPrefix, grpcPkgPath, "metadata")))
+	}
+	g.generateRouterImports()
+	g.P("goweb ", strconv.Quote(path.Join(g.gen.ImportPrefix, gowebPkgPath)))
 	g.P("\"net/http\"")
-	g.P("\"io/ioutil\"")
+	if g.anyFlag(file, "test_server") {
+		g.P("\"net/http/httptest\"")
+	}
 	g.P("\"log\"")
+	if g.anyFlag(file, "signed_urls") {
+		g.P("\"time\"")
+	}
+	if g.anyFlag(file, "fake") {
+		g.P("\"strconv\"")
+	}
+	if g.anyFlag(file, "conformance") || g.anyFlag(file, "protobuf") || g.anyFlag(file, "page_tokens") {
+		g.P("proto ", strconv.Quote(path.Join(g.gen.ImportPrefix, protoPkgPath)))
+	}
 	//g.P("\"strings\"")
 	g.P("\"encoding/json\"")
 	g.P(")")
@@ -122,13 +259,28 @@ func (g *grpc) GenerateImports(file *generator.FileDescriptor) {
 	g.P("// Reference imports to suppress errors if they are not otherwise used.")
 	g.P("var _ ", contextPkg, ".Context")
 	//g.P("var _ ", grpcPkg, ".ClientConn")
-	g.P("var _ web.C")
+	if ref := g.router().ref; ref != "" {
+		g.P("var _ ", ref)
+	}
+	g.P("var _ goweb.Route")
+	if g.anyFlag(file, "streams") {
+		g.P("var _ metadata.MD")
+	}
+	if g.anyFlag(file, "signed_urls") {
+		g.P("var _ time.Time")
+	}
+	if g.anyFlag(file, "fake") {
+		g.P("var _ = strconv.Itoa")
+	}
+	if g.anyFlag(file, "conformance") {
+		g.P("var _ proto.Message")
+	}
 	g.P()
 }
 
 // reservedClientName records whether a client name is reserved on the client side.
 var reservedClientName = map[string]bool{
-// TODO: do we need any in gRPC?
+	// TODO: do we need any in gRPC?
 }
 
 func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
@@ -137,8 +289,14 @@ func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
 func (g *grpc) generateService(file *generator.FileDescriptor, service *pb.ServiceDescriptorProto, index int) {
 	//path := fmt.Sprintf("6,%d", index) // 6 means service.
 
+	g.service = service
 	origServName := service.GetName()
 	//fullServName := file.GetPackage() + "." + origServName
+	g.serviceName = origServName
+	if pkg := file.GetPackage(); pkg != "" {
+		g.serviceName = pkg + "." + origServName
+	}
+	defer func() { g.serviceName = "" }()
 	servName := generator.CamelCase(origServName)
 	g.P("// Server API for ", servName, " service")
 	g.P()
@@ -147,37 +305,214 @@ func (g *grpc) generateService(file *generator.FileDescriptor, service *pb.Servi
 	serverType := servName + "Server"
 	g.P()
 
-	g.P("func New", servName, "Mux(h ", serverType, ", prefix string) *web.Mux {")
+	routes := make([]goweb.Route, len(service.Method))
+	for i, method := range service.Method {
+		routes[i] = goweb.RouteOf(file.FileDescriptorProto, service, method)
+		routes[i].Hash = goweb.HashRoute(routes[i], g.msgs)
+		g.method = "/" + routes[i].Service + "/" + routes[i].Method
+		routes[i].LegacyJSON = g.proto3JSON() == ""
+		g.method = ""
+	}
+	g.generateMatchers(servName, service, routes)
+	plain := g.generatePlainMethods(servName, service, routes)
+
+	g.P("func New", servName, "Mux(h ", serverType, ", prefix string, opts ...goweb.ServerOption) ", g.router().mux, " {")
 	g.P("	t := _", serverType, "{}")
 	g.P("	t.handler = h")
-	g.P("	router := web.New()")
-	for _, method := range service.Method {
-		path := strings.ToLower(servName) + "/" + method.GetName()
+	g.P("	t.opts = goweb.NewServerOptions(opts...)")
+	g.generateAuthorizers(servName, service)
+	g.generateCoalescers(service)
+	g.P("	router := ", g.router().new)
+	g.P("	for _, mw := range t.opts.Middleware {")
+	g.P("		router.Use(mw)")
+	g.P("	}")
+	if g.flag("dev_mode") {
+		g.P("router.Use(goweb.DevMiddleware)")
+		g.P("router.Get(goweb.JoinPath(prefix, \"_debug/last-errors\"), goweb.LastErrorsHandler())")
+	}
+	for i, method := range service.Method {
 		methName := generator.CamelCase(method.GetName())
-		// there should be a better way to get the options
-		m := method.GetOptions().String()
-		if m != "" {
-			parts := strings.Split(m, "\"")
-			if len(parts) == 3 {
-				if parts[0] == "10000:" {
-					path = parts[1]
-				}
+		handler, ok := plain[i]
+		if !ok {
+			handler = "http.HandlerFunc(t." + methName + ")"
+		}
+		if names := options.Strings(method.GetOptions(), options.E_Middleware); len(names) > 0 {
+			quoted := make([]string, len(names))
+			for j, name := range names {
+				quoted[j] = strconv.Quote(name)
 			}
+			handler = "t.opts.MethodMiddleware(_" + servName + "_routes[" + strconv.Itoa(i) + "], []string{" + strings.Join(quoted, ", ") + "}, " + handler + ")"
+		}
+		if routes[i].Verb != "" {
+			g.P("router.HandleTemplate(prefix, ", strconv.Quote(routes[i].Verb), ", _", servName, "_", methName, "_template, ", handler, ")")
+		} else {
+			g.P("router.Handle(goweb.JoinPath(prefix, \"", routes[i].Path, "\"), ", handler, ")")
+		}
+		if g.flag("hub") && isWatch(method) {
+			g.P("router.Get(goweb.JoinPath(prefix, \"", routes[i].Path, "/ws\"), http.HandlerFunc(_", servName, "_", methName, "_WebSocket))")
 		}
-		g.P("router.Handle(prefix+\"", strings.ToLower(path), "\", t.", methName, ")")
 	}
-	g.P("	return router")
+	if g.flag("routes_endpoint") {
+		g.P("router.Get(goweb.JoinPath(prefix, \"_routes\"), goweb.RoutesHandler(_", servName, "_routes))")
+	}
+	if g.flag("version_endpoint") {
+		g.generateVersionEndpoint(file)
+	}
+	if g.flag("oidc") {
+		g.P("router.Get(goweb.JoinPath(prefix, \"_auth/login\"), goweb.OIDCHandler(\"login\"))")
+		g.P("router.Get(goweb.JoinPath(prefix, \"_auth/callback\"), goweb.OIDCHandler(\"callback\"))")
+		g.P("router.Handle(goweb.JoinPath(prefix, \"_auth/logout\"), goweb.OIDCHandler(\"logout\"))")
+	}
+	if g.flag("admin") && len(g.adminResources(service)) > 0 {
+		g.P("admin := goweb.AdminHandler(prefix, _", servName, "_admin)")
+		g.P("router.Handle(goweb.JoinPath(prefix, \"_admin\"), admin)")
+		g.P("router.Handle(goweb.JoinPath(prefix, \"_admin/*\"), admin)")
+	}
+	if g.staticDir() != "" {
+		g.generateStaticRoute(file)
+	}
+	g.P("	return router.Mux")
 	g.P("}")
 	g.P()
 
+	g.generateRoutes(servName, routes)
+	if g.flag("linkable") {
+		g.generateLink(servName, routes)
+	}
+
 	g.P("type _", serverType, " struct {")
 	g.P("	handler ", serverType)
+	g.P("	opts goweb.ServerOptions")
+	for _, method := range service.Method {
+		if authorized(method) {
+			g.P("	authz", generator.CamelCase(method.GetName()), " goweb.Authorizer")
+		}
+		if coalesces(method) {
+			g.P("	coalesce", generator.CamelCase(method.GetName()), " *goweb.Coalescer")
+		}
+	}
 	g.P("}")
 	g.P()
 
 	// Server handler implementations.
-	for _, method := range service.Method {
-		g.generateServerMethod(servName, method)
+	for i, method := range service.Method {
+		if _, ok := plain[i]; ok {
+			continue
+		}
+		g.generateServerMethod(servName, method, routes[i], i)
+		if !method.GetServerStreaming() && !method.GetClientStreaming() {
+			g.generateCall(servName, method, i)
+		}
+		if method.GetServerStreaming() && !method.GetClientStreaming() && g.flag("streams") {
+			g.generateStreamType(servName, method)
+		}
+	}
+	g.generateValidateMux(servName, service)
+
+	if g.flag("grpc_proxy") || g.flag("grpc_dual") {
+		g.generateStatusConversion(servName)
+	}
+	if g.flag("grpc_proxy") {
+		g.generateGRPCProxy(servName, service)
+	}
+	if g.flag("grpc_dual") {
+		g.generateDual(file, servName, service)
+	}
+	if g.flag("http_proxy") {
+		g.generateHTTPProxy(servName, service)
+	}
+	if g.flag("graphql") {
+		g.generateGraphQL(servName, service)
+	}
+	if g.flag("queue") && len(service.Method) > 0 {
+		g.generateQueue(servName, service, routes)
+	}
+	if g.flag("mqtt") && len(service.Method) > 0 {
+		g.generateMQTT(servName, service, routes)
+	}
+	if g.flag("events") {
+		g.generateEvents(servName, service, routes)
+	}
+	if g.flag("hub") {
+		g.generateHubs(servName, service, routes)
+	}
+	if g.flag("client") || g.flag("test_server") {
+		g.generateClient(servName, service)
+	}
+	if g.flag("test_server") {
+		g.generateTestServer(servName)
+	}
+	if g.flag("client") {
+		g.generateListFilters(servName, service)
+	}
+	if g.flag("providers") {
+		g.generateProviders(servName)
+	}
+	if g.flag("wrap") || g.flag("dead_letters") || g.flag("shadow") {
+		g.generateWrap(servName, service)
+	}
+	if g.flag("capture") || g.flag("dead_letters") {
+		g.generateReplay(servName)
+	}
+	if g.flag("dead_letters") {
+		g.generateDeadLetters(servName)
+	}
+	if g.flag("shadow") {
+		g.generateShadow(servName, service)
+	}
+	if g.flag("signed_urls") {
+		g.generateSignedURLs(servName, service)
+	}
+	if g.flag("loadtest") {
+		g.generateLoadTest(file, servName, service)
+	}
+	if g.flag("pact") {
+		g.generatePact(file, servName, service)
+	}
+	if g.flag("postman") {
+		g.generatePostman(file, servName, service)
+	}
+	if g.flag("wasm") {
+		g.generateRequestValidators(servName, service)
+	}
+	if g.flag("page_tokens") {
+		g.generatePageTokens(servName, service)
+	}
+	if resources := g.adminResources(service); g.flag("admin") && len(resources) > 0 {
+		g.generateAdmin(servName, resources)
+	}
+	if g.flag("fake") {
+		g.generateFake(servName, service, routes)
+	}
+	if g.flag("mock") {
+		g.generateMock(servName, service, routes)
+	}
+	if g.flag("conformance") {
+		g.generateConformance(file, servName, service)
+	}
+	if g.flag("context_accessors") {
+		g.generateContextAccessors(servName)
+	}
+	if g.flag("error_helpers") {
+		g.generateErrors(servName, routes)
+	}
+	if g.flag("server_timeouts") || g.flag("runner") {
+		g.generateServerTimeouts(servName, service, routes)
+	}
+	if g.flag("runner") {
+		g.generateRunner(servName)
+	}
+	if g.flag("hot_config") {
+		g.generateConfig(servName)
+	}
+	if g.flag("error_statuses") {
+		g.generateErrorStatuses(servName, routes)
+	}
+	if codes := g.errorCodes(service); len(codes) > 0 {
+		g.generateErrorCodes(servName, routes, codes)
+	}
+	if _, ok := g.gen.Param["warmup"]; ok {
+		g.generateWarmup(servName, service)
 	}
 
 }
@@ -206,9 +541,122 @@ func (g *grpc) generateServerSignature(servName string, method *pb.MethodDescrip
 	return methName + "(" + strings.Join(reqArgs, ", ") + ") " + ret
 }
 
-func (g *grpc) generateServerMethod(servName string, method *pb.MethodDescriptorProto) string {
+// generateCall generates the method of _<Service>Server calling a unary
+// method of the implementation through the interceptor of its options.
+func (g *grpc) generateCall(servName string, method *pb.MethodDescriptorProto, index int) {
+	serverType := servName + "Server"
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	g.P("func (impl *_", serverType, ") call", methName, "(ctx ", contextPkg, ".Context, in *", inType, ") (*", outType, ", error) {")
+	g.P("	if impl.opts.Interceptor == nil {")
+	g.generateDryRun(method, "		")
+	g.P("		return ", g.implCall(method, "in"))
+	g.P("	}")
+	g.P("	out, err := impl.opts.Interceptor(ctx, _", servName, "_routes[", index, "], in, func(ctx ", contextPkg, ".Context, in interface{}) (interface{}, error) {")
+	g.generateDryRun(method, "		")
+	g.P("		return ", g.implCall(method, "in.(*"+inType+")"))
+	g.P("	})")
+	g.P("	res, _ := out.(*", outType, ")")
+	g.P("	return res, err")
+	g.P("}")
+	g.P()
+	if coalesces(method) {
+		g.generateCoalescedCall(servName, method, index)
+	}
+}
+
+// generateRoutes generates the route table of a service, its accessor and
+// the registration with the goweb route registry.
+func (g *grpc) generateRoutes(servName string, routes []goweb.Route) {
+	g.P("var _", servName, "_routes = []goweb.Route{")
+	for _, r := range routes {
+		g.P("{")
+		g.P("Service: ", strconv.Quote(r.Service), ",")
+		g.P("Method: ", strconv.Quote(r.Method), ",")
+		if r.Verb != "" {
+			g.P("Verb: ", strconv.Quote(r.Verb), ",")
+		}
+		g.P("Path: ", strconv.Quote(r.Path), ",")
+		if r.Body != "" {
+			g.P("Body: ", strconv.Quote(r.Body), ",")
+		}
+		g.P("Input: ", strconv.Quote(r.Input), ",")
+		g.P("Output: ", strconv.Quote(r.Output), ",")
+		if r.ClientStreaming {
+			g.P("ClientStreaming: true,")
+		}
+		if r.ServerStreaming {
+			g.P("ServerStreaming: true,")
+		}
+		if len(r.Options) > 0 {
+			g.P("Options: ", optionsLiteral(r.Options), ",")
+		}
+		g.P("Hash: ", strconv.Quote(r.Hash), ",")
+		if r.LegacyJSON {
+			g.P("LegacyJSON: true,")
+		}
+		if r.SLO != nil {
+			if err := r.SLO.Check(); err != nil {
+				g.gen.Fail("method", r.FullMethod()+":", err.Error())
+			}
+			g.P("SLO: &goweb.SLO{LatencyMillis: ", strconv.FormatUint(uint64(r.SLO.LatencyMillis), 10), ", LatencyPercentile: ", strconv.FormatFloat(r.SLO.LatencyPercentile, 'g', -1, 64),
+				", Availability: ", strconv.FormatFloat(r.SLO.Availability, 'g', -1, 64), "},")
+		}
+		g.P("},")
+	}
+	g.P("}")
+	g.P()
+	g.P("// ", servName, "Routes returns the HTTP routes of the ", servName, " service.")
+	g.P("// The hashes identify the contract the code was generated from.")
+	g.P("func ", servName, "Routes() []goweb.Route {")
+	g.P("	return append([]goweb.Route(nil), _", servName, "_routes...)")
+	g.P("}")
+	g.P()
+	g.P("func init() {")
+	g.P("	goweb.RegisterRoutes(_", servName, "_routes...)")
+	g.P("}")
+	g.P()
+}
+
+// optionsLiteral returns the Go expression of the options of a route, see
+// goweb.MethodOptions, with the keys sorted and the values of their types.
+func optionsLiteral(opts map[string]interface{}) string {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	elems := make([]string, len(names))
+	for i, name := range names {
+		var v string
+		switch o := opts[name].(type) {
+		case string:
+			v = strconv.Quote(o)
+		case bool:
+			v = strconv.FormatBool(o)
+		case float64:
+			v = "float64(" + strconv.FormatFloat(o, 'g', -1, 64) + ")"
+		case []string:
+			quoted := make([]string, len(o))
+			for j, s := range o {
+				quoted[j] = strconv.Quote(s)
+			}
+			v = "[]string{" + strings.Join(quoted, ", ") + "}"
+		default:
+			v = fmt.Sprintf("%T(%v)", o, o)
+		}
+		elems[i] = strconv.Quote(name) + ": " + v
+	}
+	return "map[string]interface{}{" + strings.Join(elems, ", ") + "}"
+}
+
+func (g *grpc) generateServerMethod(servName string, method *pb.MethodDescriptorProto, route goweb.Route, index int) string {
 	methName := generator.CamelCase(method.GetName())
 	hname := fmt.Sprintf("_%s_%s_Handler", servName, methName)
+	g.method = "/" + route.Service + "/" + route.Method
+	g.arena = g.flag("arena") && !method.GetServerStreaming() && !method.GetClientStreaming()
+	defer func() { g.method, g.arena = "", false }()
 	inType := g.typeName(method.GetInputType())
 	outType := g.typeName(method.GetOutputType())
 
@@ -218,40 +666,750 @@ func (g *grpc) generateServerMethod(servName string, method *pb.MethodDescriptor
 	g.P("// _", serverType, ".", methName, "(", inType, ") ", outType)
 	g.P("var _ = ", inType, "{} // to prevent error, if not directly used")
 	g.P("var _ = ", outType, "{} // to prevent error, if not directly used")
-	g.P("func (impl* _", serverType, " )", methName, "(c web.C, w http.ResponseWriter, r *http.Request) {")
+	g.P("func (impl* _", serverType, " )", methName, "(w http.ResponseWriter, r *http.Request) {")
+	g.P("	w.Header().Set(goweb.RouteHashHeader, ", strconv.Quote(route.Hash), ")")
+	if g.flag("metering") {
+		g.P("	w, r, endMeter := goweb.MeterCall(w, r, _", servName, "_routes[", index, "])")
+		g.P("	defer endMeter()")
+	}
+	if g.flag("hot_config") {
+		g.P("	if !", servName, "Config.Admit(w, r, _", servName, "_routes[", index, "]) {")
+		g.P("		return")
+		g.P("	}")
+	}
+	if method.GetOptions().GetDeprecated() {
+		g.P("	goweb.Unsupported(r, _", servName, "_routes[", index, "], goweb.UnsupportedDeprecated)")
+	}
 
 	if method.GetServerStreaming() || method.GetClientStreaming() {
+		if options.Bool(method.GetOptions(), options.E_Audit) {
+			g.gen.Fail("audit option of", route.FullMethod()+":", "streaming methods cannot be audited")
+		}
+		if options.Has(method.GetOptions(), options.E_Session) {
+			g.gen.Fail("session option of", route.FullMethod()+":", "streaming methods cannot use sessions")
+		}
+		if options.Has(method.GetOptions(), options.E_TimeoutSeconds) {
+			g.gen.Fail("timeout_seconds option of", route.FullMethod()+":", "streaming methods have no deadline")
+		}
+		if options.Has(method.GetOptions(), options.E_CoalesceMs) {
+			g.gen.Fail("coalesce_ms option of", route.FullMethod()+":", "streaming methods cannot be coalesced")
+		}
+	}
+	switch {
+	case method.GetServerStreaming() && !method.GetClientStreaming() && g.flag("streams"):
+		g.generateDecode(method, route)
+		g.generateDeprecated(servName, method, index)
+		g.generateServerStream(servName, method)
+	case method.GetServerStreaming() || method.GetClientStreaming():
+		g.unsupportedStream(route)
+		g.P("		goweb.Unsupported(r, _", servName, "_routes[", index, "], goweb.UnsupportedStreaming)")
 		g.P("		w.WriteHeader(501)")
 		g.P("		w.Write([]byte(`Streaming functions over http are not supported`))")
 		g.P("		return")
-	} else {
-		g.P("	in := ", inType, "{}")
-		g.P("	content, err := ioutil.ReadAll(r.Body)")
-		g.P("	defer r.Body.Close()")
+	default:
+		if g.jsonp(method, route) {
+			g.P("	callback := r.URL.Query().Get(\"callback\")")
+			g.P("	if err := goweb.CheckCallback(callback); err != nil {")
+			g.P("		w.WriteHeader(400)")
+			g.P("		w.Write([]byte(err.Error()))")
+			g.P("		return")
+			g.P("	}")
+		}
+		g.generateDecode(method, route)
+		g.generateDeprecated(servName, method, index)
+		g.generateContext()
+		if g.arena {
+			g.P("	ctx = goweb.WithArena(ctx, arena)")
+		}
+		if g.flag("hot_config") {
+			g.P("	ctx, cancelConfig := ", servName, "Config.WithTimeout(ctx)")
+			g.P("	defer cancelConfig()")
+		}
+		g.generateTimeout(method)
+		if options.Bool(method.GetOptions(), options.E_Upload) {
+			g.P("	ctx = goweb.WithUpload(ctx, upload)")
+		}
+		if options.Bool(method.GetOptions(), options.E_RawBody) {
+			g.P("	ctx = goweb.WithRawBody(ctx, rawBody)")
+		}
+		g.generateSession(method)
+		g.generateEnrich(method, "ctx")
+		g.generateTenant(method)
+		g.generateAuthorize(method, "ctx")
+		g.generateMetaStart()
+		if options.Bool(method.GetOptions(), options.E_Transactional) {
+			g.P("	ctx, tx, err := goweb.BeginTx(ctx, _", servName, "_routes[", index, "])")
+			g.P("	if err != nil {")
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+			g.P("	}")
+			g.P("	res, err := func() (res *", outType, ", err error) {")
+			g.P("		defer goweb.EndTx(tx, &err)")
+			g.P("		return impl.call", methName, "(ctx, ", g.in(), ")")
+			g.P("	}()")
+		} else {
+			g.P("	res, err := impl.call", methName, "(ctx, ", g.in(), ")")
+		}
+		g.generateAllowMissing(method)
+		if g.responseMeta() != "" {
+			g.P("	meta.Done()")
+		}
+		if options.Bool(method.GetOptions(), options.E_Upload) {
+			g.P("	if upload.TooLarge() {")
+			g.P("		w.WriteHeader(413)")
+			g.P("		w.Write([]byte(goweb.ErrUploadTooLarge.Error()))")
+			g.P("		return")
+			g.P("	}")
+		}
+		if options.Bool(method.GetOptions(), options.E_Audit) {
+			g.P("	if err := goweb.AuditCall(ctx, _", servName, "_routes[", index, "], ", g.in(), ", err); err != nil {")
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+			g.P("	}")
+		}
 		g.P("	if err != nil {")
-		g.P("		w.WriteHeader(408)")
-		g.P("		w.Write([]byte(err.Error()))")
-		g.P("		log.Println(err.Error())")
+		if options.Has(method.GetOptions(), options.E_Retryable) {
+			g.P("		err = goweb.MarkRetryable(err, ", options.Bool(method.GetOptions(), options.E_Retryable), ")")
+		}
+		g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
 		g.P("		return")
 		g.P("	}")
-		g.P("	err = json.Unmarshal(content, &in)")
-		g.P("	if err != nil {")
-		g.P("		w.WriteHeader(400)")
-		g.P("		w.Write([]byte(err.Error()))")
-		g.P("		log.Println(err.Error())")
+		g.generateSessionEnd(method)
+		if g.recursive(method.GetOutputType()) {
+			g.P("	if err := goweb.CheckMessageDepth(res, ", g.maxDepth(method.GetOutputType()), "); err != nil {")
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+			g.P("	}")
+		}
+		g.generateResponseEnums(method, "res", func() {
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+		})
+		g.generateResponseFilter(method, "res")
+		if g.needs(encryptPass, method.GetOutputType()) {
+			g.P("	res = goweb.Clone(res).(*", outType, ")")
+			g.P("	if err := ", g.passFunc(encryptPass, method.GetOutputType()), "(ctx, res); err != nil {")
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+			g.P("	}")
+		}
+		g.generateMeta(method)
+		g.generateLinks(method, route)
+		body := "res"
+		if name := options.String(method.GetOptions(), options.E_Transform); name != "" {
+			if options.Bool(method.GetOptions(), options.E_Download) {
+				g.gen.Fail("method", route.FullMethod(), "cannot be both a download and transformed")
+			}
+			g.P("	view, err := goweb.Transform(ctx, ", strconv.Quote(name), ", res)")
+			g.P("	if err != nil {")
+			g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+			g.P("		return")
+			g.P("	}")
+			body = "view"
+		}
+		if options.Bool(method.GetOptions(), options.E_Download) {
+			g.generateDownload(method)
+		} else {
+			g.generateEncode(method, route, body)
+		}
+	}
+	g.P("}")
+	g.P()
+
+	return hname
+}
+
+// jsonp reports whether the handler of method answers JSONP requests,
+// for GET endpoints with the jsonp parameter.
+func (g *grpc) jsonp(method *pb.MethodDescriptorProto, route goweb.Route) bool {
+	return g.flag("jsonp") && route.Verb == "GET" && !options.Bool(method.GetOptions(), options.E_Download)
+}
+
+// generateEncode generates the part of a handler that writes the response
+// body as JSON, with the links in it for the links parameter, and wrapped
+// in the callback for JSONP, compressed as the options of the mux say.
+func (g *grpc) generateEncode(method *pb.MethodDescriptorProto, route goweb.Route, body string) {
+	jsonp := g.jsonp(method, route)
+	links := g.linksInBody(method) && len(options.Strings(method.GetOptions(), options.E_Link)) > 0
+	envelope := g.responseMeta() == "envelope"
+	if g.finiteFloats(method.GetOutputType()) {
+		g.P("	if err := goweb.CheckFinite(", body, "); err != nil {")
+		g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
 		g.P("		return")
 		g.P("	}")
-		g.P("	res,err := impl.handler.", methName, "(context.Background(),&in)")
-		g.P("	if err != nil {")
+	}
+	if (g.flag("protobuf") || g.flag("text_format")) && body == "res" {
+		g.P("	w.Header().Add(\"Vary\", \"Accept\")")
+	}
+	for _, format := range []struct{ param, name string }{{"protobuf", "Protobuf"}, {"text_format", "ProtoText"}} {
+		if !g.flag(format.param) || body != "res" {
+			continue
+		}
+		g.P("	if goweb.Accepts", format.name, "(r) {")
+		g.P("		if err := goweb.Write", format.name, "(w, res); err != nil {")
+		g.P("			w.WriteHeader(500)")
+		g.P("			w.Write([]byte(err.Error()))")
+		g.P("			log.Println(err.Error())")
+		g.P("		}")
+		g.P("		return")
+		g.P("	}")
+	}
+	if options.Bool(method.GetOptions(), options.E_StreamResponse) {
+		if g.proto3JSON() != "" || g.needs(anyPass, method.GetOutputType()) || g.flag("deterministic_json") || g.int64Strings(method) ||
+			jsonp || links || envelope || g.flag("pretty_json") {
+			g.gen.Fail("method", route.FullMethod(), "cannot stream its response in the JSON its parameters and options ask for")
+		}
+		g.P("	if err := impl.opts.StreamJSON(w, r, ", body, "); err != nil {")
+		g.P("		log.Println(err.Error())")
+		g.P("	}")
+		return
+	}
+	var encode string
+	switch {
+	case g.proto3JSON() != "":
+		encode = g.proto3JSON() + ".Marshal"
+	case g.needs(anyPass, method.GetOutputType()):
+		encode = "goweb.MarshalAnyJSON"
+	case g.flag("deterministic_json") || g.int64Strings(method):
+		encode = "goweb.DeterministicJSON"
+	case g.needs(g.floatPass(), method.GetOutputType()):
+		encode = "goweb.MarshalJSON"
+	case jsonp || links || envelope:
+		encode = "json.Marshal"
+	default:
+		if g.flag("pretty_json") {
+			g.P("	enc := json.NewEncoder(w)")
+			g.P("	if goweb.WantsPretty(r) {")
+			g.P("		enc.SetIndent(\"\", \"  \")")
+			g.P("	}")
+			g.P("	enc.Encode(", body, ")")
+			return
+		}
+		g.P("	if err := impl.opts.WriteJSON(w, r, ", body, "); err != nil {")
 		g.P("		w.WriteHeader(500)")
 		g.P("		w.Write([]byte(err.Error()))")
 		g.P("		log.Println(err.Error())")
+		g.P("	}")
+		return
+	}
+	g.P("	out, err := ", encode, "(", body, ")")
+	if g.int64Strings(method) {
+		g.P("	if err == nil {")
+		g.P("		out, err = goweb.Int64Strings(", body, ", out)")
+		g.P("	}")
+	}
+	g.P("	if err != nil {")
+	g.P("		w.WriteHeader(500)")
+	g.P("		w.Write([]byte(err.Error()))")
+	g.P("		log.Println(err.Error())")
+	g.P("		return")
+	g.P("	}")
+	if encode == "goweb.MarshalJSON" || strings.HasSuffix(encode, ".Marshal") {
+		g.P("	out = append(out, '\\n')")
+	}
+	if envelope {
+		g.P("	out = goweb.InjectMeta(out, meta)")
+	}
+	if links {
+		g.P("	out = goweb.InjectLinks(out, links)")
+	}
+	if g.flag("pretty_json") {
+		g.P("	if goweb.WantsPretty(r) {")
+		g.P("		out = goweb.IndentJSON(out)")
+		g.P("	}")
+	}
+	if jsonp {
+		g.P("	if callback != \"\" {")
+		g.P("		goweb.WriteJSONP(w, callback, out)")
 		g.P("		return")
 		g.P("	}")
-		g.P("	json.NewEncoder(w).Encode(res)")
 	}
-	g.P("}")
+	g.P("	impl.opts.WriteBody(w, r, out)")
+}
+
+// int64Strings reports whether the handler of method writes the 64-bit
+// integers of its responses as JSON strings, which the proto3 JSON
+// mapping does anyway.
+func (g *grpc) int64Strings(method *pb.MethodDescriptorProto) bool {
+	return g.flag("int64_strings") && g.proto3JSON() == "" && g.needs(g.int64Pass(), method.GetOutputType())
+}
+
+// proto3JSON returns the goweb.Proto3JSON literal the handlers encode and
+// decode with, or "" for methods that keep the encoding/json based
+// encoding, see legacyJSON.
+func (g *grpc) proto3JSON() string {
+	if g.legacyJSON() {
+		for _, name := range []string{"emit_defaults", "enums_as_ints"} {
+			if g.flag(name) {
+				g.gen.Fail("parameter", name, "needs the proto3 JSON mapping, which legacy_json turns off")
+			}
+		}
+		return ""
+	}
+	var fields []string
+	if g.flag("emit_defaults") {
+		fields = append(fields, "EmitDefaults: true")
+	}
+	if g.flag("enums_as_ints") {
+		fields = append(fields, "EnumsAsInts: true")
+	}
+	return "goweb.Proto3JSON{" + strings.Join(fields, ", ") + "}"
+}
+
+// legacyJSON reports whether the current method encodes and decodes its
+// JSON with encoding/json rather than in the proto3 mapping: with the
+// legacy_json parameter or proto3_json=false, and for methods with the
+// stream_response option, which only streams encoding/json, unless
+// proto3_json is set.
+func (g *grpc) legacyJSON() bool {
+	if g.flag("legacy_json") {
+		if g.flag("proto3_json") {
+			g.gen.Fail("parameters legacy_json and proto3_json exclude each other")
+		}
+		return true
+	}
+	if v, ok := g.param("proto3_json"); ok {
+		return v == "false"
+	}
+	method := g.currentMethod()
+	return method != nil && options.Bool(method.GetOptions(), options.E_StreamResponse)
+}
+
+// currentMethod returns the method being generated, or nil.
+func (g *grpc) currentMethod() *pb.MethodDescriptorProto {
+	if g.method == "" || g.service == nil {
+		return nil
+	}
+	name := g.method[strings.LastIndex(g.method, "/")+1:]
+	for _, method := range g.service.Method {
+		if method.GetName() == name {
+			return method
+		}
+	}
+	return nil
+}
+
+// finiteFloats reports whether the handlers reject NaN and infinite
+// floats in the message name, for the finite_floats parameter.
+func (g *grpc) finiteFloats(name string) bool {
+	return g.flag("finite_floats") && g.needs(g.floatPass(), name)
+}
+
+// enumPolicy returns the goweb.EnumPolicy of the enums of the message name
+// after the parameter param, unknown_enums for requests and response_enums
+// for responses, or "" for goweb.EnumPreserve, the default.
+func (g *grpc) enumPolicy(param, name string) string {
+	if !g.needs(g.enumPass(), name) {
+		return ""
+	}
+	switch v, _ := g.param(param); v {
+	case "", "preserve":
+	case "reject":
+		return "goweb.EnumReject"
+	case "zero":
+		return "goweb.EnumZero"
+	default:
+		g.gen.Fail("parameter", param, "must be preserve, reject or zero, not", strconv.Quote(v))
+	}
+	return ""
+}
+
+// generateResponseEnums generates the part of a handler or stream that
+// applies the response_enums parameter to the response v, a variable,
+// with fail generating what to do with the error err.
+func (g *grpc) generateResponseEnums(method *pb.MethodDescriptorProto, v string, fail func()) {
+	switch g.enumPolicy("response_enums", method.GetOutputType()) {
+	case "goweb.EnumReject":
+		g.P("	if err := goweb.CheckEnums(", v, ", goweb.EnumReject); err != nil {")
+		fail()
+		g.P("	}")
+	case "goweb.EnumZero":
+		g.P("	", v, " = goweb.Clone(", v, ").(*", g.typeName(method.GetOutputType()), ")")
+		g.P("	goweb.CheckEnums(", v, ", goweb.EnumZero)")
+	}
+}
+
+// defaultRecursiveDepth is the depth limit of self-referential messages
+// without the max_depth parameter.
+const defaultRecursiveDepth = 100
+
+// maxDepth returns the limit of the JSON nesting depth of the message
+// name: the max_depth parameter, or defaultRecursiveDepth if the message
+// is self-referential, or 0 for none.
+func (g *grpc) maxDepth(name string) int {
+	if max := g.intParam("max_depth"); max > 0 {
+		return max
+	}
+	if g.recursive(name) {
+		return defaultRecursiveDepth
+	}
+	return 0
+}
+
+// in returns the expression of the pointer to the request in the handler
+// being generated: in is a message of its arena with the arena parameter,
+// and a local variable otherwise.
+func (g *grpc) in() string {
+	if g.arena {
+		return "in"
+	}
+	return "&in"
+}
+
+// generateContext generates the part of a handler that declares ctx, the
+// context of the call, with the timeout and metadata of the request.
+func (g *grpc) generateContext() {
+	g.P("	ctx, cancel, err := impl.opts.NewContext(w, r)")
+	g.P("	if err != nil {")
+	g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+	g.P("		return")
+	g.P("	}")
+	g.P("	defer cancel()")
+}
+
+// generateDecode generates the part of a handler that reads and decodes the
+// request into in, checks its limits and applies the field options.
+func (g *grpc) generateDecode(method *pb.MethodDescriptorProto, route goweb.Route) {
+	inType := g.typeName(method.GetInputType())
+	if g.arena {
+		g.P("	arena := goweb.NewArena()")
+		g.P("	defer arena.Release()")
+		g.P("	in := arena.Get((*", inType, ")(nil)).(*", inType, ")")
+	} else {
+		g.P("	in := ", inType, "{}")
+	}
+	if g.streamsBody(method, route) {
+		g.generateDecodeBody(method)
+	} else {
+		g.generateReadBody(method, route)
+	}
+	g.generateBind(method, route)
+	g.generateSoftDelete(method)
+	if g.finiteFloats(method.GetInputType()) {
+		g.P("	if err := goweb.CheckFinite(", g.in(), "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+	if g.needs(outputOnlyPass, method.GetInputType()) {
+		g.P("	", g.passFunc(outputOnlyPass, method.GetInputType()), "(", g.in(), ")")
+	}
+	if g.needs(g.limitPass(), method.GetInputType()) {
+		g.P("	if err := ", g.passFunc(g.limitPass(), method.GetInputType()), "(", g.in(), "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+	if g.needs(g.timePass(), method.GetInputType()) {
+		g.P("	if err := ", g.passFunc(g.timePass(), method.GetInputType()), "(", g.in(), "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+	if g.needs(decryptPass, method.GetInputType()) {
+		g.P("	if err := ", g.passFunc(decryptPass, method.GetInputType()), "(goweb.NewContext(r), ", g.in(), "); err != nil {")
+		g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+		g.P("		return")
+		g.P("	}")
+	}
+	if g.needs(normalizePass, method.GetInputType()) {
+		g.P("	", g.passFunc(normalizePass, method.GetInputType()), "(", g.in(), ")")
+	}
+	if g.needs(defaultPass, method.GetInputType()) {
+		g.P("	", g.passFunc(defaultPass, method.GetInputType()), "(", g.in(), ")")
+	}
+	g.generateValidate(method.GetInputType())
+	g.generateRegion(method, route)
+}
+
+// streamsBody reports whether the handler of method decodes the request
+// body while it is read, because nothing needs its bytes: they are not
+// checked, wrapped, forwarded or kept, and decoded with encoding/json.
+func (g *grpc) streamsBody(method *pb.MethodDescriptorProto, route goweb.Route) bool {
+	opts := method.GetOptions()
+	in := method.GetInputType()
+	switch {
+	case route.Verb != "" && route.Body != "*",
+		options.Bool(opts, options.E_Upload), options.Bool(opts, options.E_Download), options.Bool(opts, options.E_RawBody),
+		options.String(opts, options.E_WebhookSignatureHeader) != "",
+		options.String(opts, options.E_RegionField) != "", options.String(g.service.GetOptions(), options.E_ServiceRegionField) != "",
+		g.flag("protobuf"), g.flag("text_format"), g.maxDepth(in) > 0, g.proto3JSON() != "", g.enumPolicy("unknown_enums", in) != "",
+		g.needs(anyPass, in), g.needs(g.int64Pass(), in), g.needs(g.floatPass(), in), g.needs(g.enumPass(), in):
+		return false
+	}
+	return true
+}
+
+// generateDecodeBody generates the part of a handler that decodes the
+// request body into in while it is read, see goweb.ServerOptions.DecodeBody.
+func (g *grpc) generateDecodeBody(method *pb.MethodDescriptorProto) {
+	g.P("	err := impl.opts.DecodeBody(w, r, ", g.in(), ")")
+	g.P("	defer r.Body.Close()")
+	g.P("	if e, ok := err.(*goweb.Error); ok {")
+	g.P("		w.WriteHeader(e.Status)")
+	g.P("		w.Write([]byte(e.Message))")
+	g.P("		log.Println(e.Message)")
+	g.P("		return")
+	g.P("	}")
+	g.P("	if err != nil {")
+	g.generateBadRequest(method, true)
+	g.P("	}")
+}
+
+// nonJSONBody returns the condition of the requests whose bodies are sent
+// in the formats of the protobuf and text_format parameters.
+func (g *grpc) nonJSONBody() string {
+	var conds []string
+	if g.flag("protobuf") {
+		conds = append(conds, "goweb.IsProtobuf(r.Header.Get(\"Content-Type\"))")
+	}
+	if g.flag("text_format") {
+		conds = append(conds, "goweb.SendsProtoText(r)")
+	}
+	return strings.Join(conds, " || ")
+}
+
+// generateReadBody generates the part of a handler that reads the request
+// body into content, within the limit of the mux, and decodes it into in.
+func (g *grpc) generateReadBody(method *pb.MethodDescriptorProto, route goweb.Route) {
+	if options.Bool(method.GetOptions(), options.E_Upload) {
+		max := options.Uint64(method.GetOptions(), options.E_UploadMaxBytes)
+		g.P("	content, upload, err := goweb.ReadUploadRequest(r, ", strconv.FormatUint(max, 10), ")")
+	} else if options.Bool(method.GetOptions(), options.E_RawBody) {
+		// the implementation may keep the raw body of its context
+		g.P("	content, err := impl.opts.ReadBody(w, r)")
+	} else {
+		g.P("	content, releaseBody, err := impl.opts.ReadPooledBody(w, r)")
+		g.P("	defer releaseBody()")
+	}
+	g.P("	defer r.Body.Close()")
+	g.P("	if err != nil {")
+	g.P("		w.WriteHeader(goweb.BodyStatus(err))")
+	g.P("		w.Write([]byte(err.Error()))")
+	g.P("		log.Println(err.Error())")
+	g.P("		return")
+	g.P("	}")
+	if header := options.String(method.GetOptions(), options.E_WebhookSignatureHeader); header != "" {
+		if options.Bool(method.GetOptions(), options.E_Upload) {
+			g.gen.Fail("method", route.FullMethod(), "cannot be both an upload and a webhook")
+		}
+		secret := options.String(method.GetOptions(), options.E_WebhookSecret)
+		if secret == "" {
+			secret = route.FullMethod()
+		}
+		g.P("	if err := goweb.VerifyWebhook(", strconv.Quote(secret), ", r.Header.Get(", strconv.Quote(header), "), content); err != nil {")
+		g.P("		w.WriteHeader(401)")
+		g.P("		w.Write([]byte(err.Error()))")
+		g.P("		return")
+		g.P("	}")
+	}
+	if options.Bool(method.GetOptions(), options.E_RawBody) && !method.GetServerStreaming() {
+		// before the body is wrapped or replaced below
+		g.P("	rawBody := content")
+	}
+	if route.Verb != "" {
+		switch route.Body {
+		case "*":
+		case "":
+			g.P("	content = []byte(\"{}\")")
+		default:
+			g.P("	content = goweb.WrapBody(", strconv.Quote(route.Body), ", content)")
+		}
+	} else if options.Bool(method.GetOptions(), options.E_Download) {
+		g.P("	if r.Method == \"GET\" || r.Method == \"HEAD\" {")
+		g.P("		content = goweb.QueryJSON(r.URL.Query())")
+		g.P("	}")
+	}
+	enums := g.enumPolicy("unknown_enums", method.GetInputType())
+	wholeBody := route.Verb == "" || route.Body == "*"
+	binary := g.flag("protobuf") && wholeBody
+	text := g.flag("text_format") && wholeBody
+	if (g.flag("protobuf") || g.flag("text_format")) && !wholeBody && route.Body != "" {
+		g.P("	if ", g.nonJSONBody(), " {")
+		g.P("		w.WriteHeader(415)")
+		g.P("		w.Write([]byte(\"the body of this method can only be sent as JSON\"))")
+		g.P("		return")
+		g.P("	}")
+	}
+	checks := func() {
+		if enums != "" {
+			g.P("	if err == nil {")
+			g.P("		err = goweb.CheckEnums(", g.in(), ", ", enums, ")")
+			g.P("	}")
+		}
+		if max := g.maxDepth(method.GetInputType()); max > 0 {
+			g.P("	if err == nil {")
+			g.P("		err = goweb.CheckMessageDepth(", g.in(), ", ", max, ")")
+			g.P("	}")
+		}
+	}
+	cond := "	if "
+	if binary {
+		g.P(cond, "goweb.IsProtobuf(r.Header.Get(\"Content-Type\")) {")
+		g.P("		err = proto.Unmarshal(content, ", g.in(), ")")
+		checks()
+		cond = "	} else if "
+	}
+	if text {
+		g.P(cond, "goweb.SendsProtoText(r) {")
+		g.P("		err = goweb.UnmarshalProtoText(content, ", g.in(), ")")
+		checks()
+	}
+	if binary || text {
+		g.P("	} else {")
+	}
+	if max := g.maxDepth(method.GetInputType()); max > 0 {
+		g.P("	if err := goweb.CheckDepth(content, ", max, "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+	if p := g.proto3JSON(); p != "" || g.needs(anyPass, method.GetInputType()) {
+		if p != "" {
+			g.P("	err = ", p, ".Unmarshal(content, ", g.in(), ")")
+		} else {
+			g.P("	err = goweb.UnmarshalAnyJSON(content, ", g.in(), ")")
+		}
+		if enums != "" {
+			g.P("	if err == nil {")
+			g.P("		err = goweb.CheckEnums(", g.in(), ", ", enums, ")")
+			g.P("	}")
+		}
+	} else if enums != "" {
+		g.P("	err = goweb.DecodeJSON(content, ", g.in(), ", ", enums, ")")
+	} else if g.needs(g.int64Pass(), method.GetInputType()) || g.needs(g.floatPass(), method.GetInputType()) || g.needs(g.enumPass(), method.GetInputType()) {
+		g.P("	err = goweb.UnmarshalJSON(content, ", g.in(), ")")
+	} else {
+		g.P("	err = json.Unmarshal(content, ", g.in(), ")")
+	}
+	if binary || text {
+		g.P("	}")
+	}
+	g.P("	if err != nil {")
+	g.generateBadRequest(method, true)
+	g.P("	}")
+}
+
+// generateSession generates the part of the session option of a method
+// before its dispatch.
+func (g *grpc) generateSession(method *pb.MethodDescriptorProto) {
+	switch options.Int32(method.GetOptions(), options.E_Session) {
+	case options.SessionRequired, options.SessionRevoke:
+		g.P("	if ctx, err = goweb.RequireSession(ctx, w, r); err != nil {")
+		g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+		g.P("		return")
+		g.P("	}")
+	case options.SessionIssue:
+		g.P("	ctx = goweb.WithSessionIssue(ctx)")
+	}
+}
+
+// generateSessionEnd generates the part of the session option of a
+// method after a successful call.
+func (g *grpc) generateSessionEnd(method *pb.MethodDescriptorProto) {
+	var call string
+	switch options.Int32(method.GetOptions(), options.E_Session) {
+	case options.SessionIssue:
+		call = "goweb.CommitSession(ctx, w)"
+	case options.SessionRevoke:
+		call = "goweb.RevokeSession(ctx, w, r)"
+	default:
+		return
+	}
+	g.P("	if err := ", call, "; err != nil {")
+	g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+	g.P("		return")
+	g.P("	}")
+}
+
+// generateEnrich generates the call of the enricher of a method with an
+// enrich option on the request in, with the context ctx.
+func (g *grpc) generateEnrich(method *pb.MethodDescriptorProto, ctx string) {
+	name := options.String(method.GetOptions(), options.E_Enrich)
+	if name == "" {
+		return
+	}
+	g.P("	if err := goweb.Enrich(", ctx, ", ", strconv.Quote(name), ", ", g.in(), "); err != nil {")
+	g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+	g.P("		return")
+	g.P("	}")
+}
+
+// authorized reports whether calls of method are authorized by an
+// authorize rule or a policy.
+func authorized(method *pb.MethodDescriptorProto) bool {
+	return options.String(method.GetOptions(), options.E_Authorize) != "" || options.String(method.GetOptions(), options.E_Policy) != ""
+}
+
+// generateAuthorizers generates the goweb.Authorizers of the methods of
+// service in New<Service>Mux, compiling their authorize rules after
+// checking them.
+func (g *grpc) generateAuthorizers(servName string, service *pb.ServiceDescriptorProto) {
+	for _, method := range service.Method {
+		expr := options.String(method.GetOptions(), options.E_Authorize)
+		path := options.String(method.GetOptions(), options.E_Policy)
+		full := g.serviceName + "." + method.GetName()
+		switch {
+		case expr != "" && path != "":
+			g.gen.Fail("method", full, "has both an authorize rule and a policy")
+		case expr != "":
+			methName := generator.CamelCase(method.GetName())
+			g.P("	t.authz", methName, " = _", servName, "_", methName, "_rule")
+		case path != "":
+			g.P("	t.authz", generator.CamelCase(method.GetName()), " = goweb.Policy(", strconv.Quote(path), ")")
+		}
+	}
+}
+
+// generateMatchers generates the path templates and authorize rules of
+// the methods of a service as package variables, so they are parsed and
+// compiled at init time, once, rather than by every New<Service>Mux.
+func (g *grpc) generateMatchers(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	var vars []string
+	for i, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		if routes[i].Verb != "" {
+			g.checkTemplate(method, routes[i])
+			vars = append(vars, "_"+servName+"_"+methName+"_template = goweb.MustParseTemplate("+strconv.Quote("/"+routes[i].Path)+")")
+		}
+		if expr := options.String(method.GetOptions(), options.E_Authorize); expr != "" {
+			if _, err := goweb.CompileRule(expr); err != nil {
+				g.gen.Fail("authorize option of", g.serviceName+"."+method.GetName()+":", err.Error())
+			}
+			vars = append(vars, "_"+servName+"_"+methName+"_rule = goweb.MustCompileRule("+strconv.Quote(expr)+")")
+		}
+	}
+	if len(vars) == 0 {
+		return
+	}
+	g.P("var (")
+	for _, v := range vars {
+		g.P(v)
+	}
+	g.P(")")
 	g.P()
+}
 
-	return hname
+// generateAuthorize generates the authorization of a call of a method
+// with an authorize rule or a policy, with the context ctx.
+func (g *grpc) generateAuthorize(method *pb.MethodDescriptorProto, ctx string) {
+	if !authorized(method) {
+		return
+	}
+	g.P("	if err := goweb.Authorize(", ctx, ", impl.authz", generator.CamelCase(method.GetName()), ", ", strconv.Quote(g.method), ", ", g.in(), "); err != nil {")
+	g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+	g.P("		return")
+	g.P("	}")
+}
+
+// generateResponseFilter generates the code clearing the input-only and
+// redacted fields of the response v, which needs ctx in scope.
+func (g *grpc) generateResponseFilter(method *pb.MethodDescriptorProto, v string) {
+	outType := g.typeName(method.GetOutputType())
+	if g.needs(inputOnlyPass, method.GetOutputType()) {
+		g.P("	", v, " = goweb.Clone(", v, ").(*", outType, ")")
+		g.P("	", g.passFunc(inputOnlyPass, method.GetOutputType()), "(", v, ")")
+	}
+	if g.needs(redactPass, method.GetOutputType()) {
+		g.P("	if redacted, ok := goweb.Redacted(ctx, ", v, ").(*", outType, "); ok {")
+		g.P("		", g.passFunc(redactPass, method.GetOutputType()), "(redacted)")
+		g.P("		", v, " = redacted")
+		g.P("	}")
+	}
 }
@@ -0,0 +1,166 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateStatusConversion generates <Service>ErrorToStatus and
+// <Service>StatusToError, which convert between *goweb.Error and gRPC
+// status errors. The code, message and details of an *Error map to the
+// google.rpc.Status; its own code and domain travel in an ErrorInfo, its
+// violations in a BadRequest and its retry hint in a RetryInfo detail.
+func (g *grpc) generateStatusConversion(servName string) {
+	goweb := g.gen.Import(gowebPkgPath)
+	status := g.gen.Import(grpcPkgPath + "/status")
+	codes := g.gen.Import(grpcPkgPath + "/codes")
+	errdetails := g.gen.Import("google.golang.org/genproto/googleapis/rpc/errdetails")
+	ptypes := g.gen.Import("github.com/golang/protobuf/ptypes")
+	proto := g.gen.Import(protoPkgPath)
+
+	g.gen.EmitFunc(generator.Func{
+		Doc: servName + "ErrorToStatus returns err as a gRPC status error if it is a *goweb.Error,\n" +
+			"else err. The details of the status are an ErrorInfo with the code and\n" +
+			"domain of the error, a BadRequest with its violations and a RetryInfo if\n" +
+			"it is retryable, followed by the details of the error.",
+		Name:    servName + "ErrorToStatus",
+		Params:  "err error",
+		Results: "error",
+		Body: func() {
+			g.P("e, ok := err.(*", goweb.Ident("Error"), ")")
+			g.gen.Block("if !ok", func() { g.P("return err") })
+			g.P("details := []", proto.Ident("Message"), "{&", errdetails.Ident("ErrorInfo"), "{Reason: e.Code, Domain: e.Domain}}")
+			g.gen.Block("if len(e.Violations) > 0", func() {
+				g.P("br := &", errdetails.Ident("BadRequest"), "{}")
+				g.gen.Block("for _, v := range e.Violations", func() {
+					g.P("br.FieldViolations = append(br.FieldViolations, &", errdetails.Ident("BadRequest_FieldViolation"), "{Field: v.Field, Description: v.Description})")
+				})
+				g.P("details = append(details, br)")
+			})
+			g.gen.Block("if e.Retryable", func() {
+				g.P("ri := &", errdetails.Ident("RetryInfo"), "{}")
+				g.gen.Block("if e.RetryAfter > 0", func() {
+					g.P("ri.RetryDelay = ", ptypes.Ident("DurationProto"), "(e.RetryAfter)")
+				})
+				g.P("details = append(details, ri)")
+			})
+			g.P("s, err := ", status.Ident("New"), "(", codes.Ident("Code"), "(", goweb.Ident("GRPCCode"), "(e)), e.Message).WithDetails(append(details, e.Details...)...)")
+			g.gen.Block("if err != nil", func() { g.P("return err") })
+			g.P("return s.Err()")
+		},
+	})
+
+	g.gen.EmitFunc(generator.Func{
+		Doc: servName + "StatusToError returns err as a *goweb.Error if it is a gRPC status\n" +
+			"error, else err: the inverse of " + servName + "ErrorToStatus. Without an ErrorInfo,\n" +
+			"the code of the error is the name of the gRPC code, e.g. \"NOT_FOUND\". Details\n" +
+			"of types that are not registered in this process are dropped.",
+		Name:    servName + "StatusToError",
+		Params:  "err error",
+		Results: "error",
+		Body: func() {
+			g.P("s, ok := ", status.Ident("FromError"), "(err)")
+			g.gen.Block("if !ok || s.Code() == "+codes.Ident("OK"), func() { g.P("return err") })
+			g.P("e := &", goweb.Ident("Error"), "{Code: ", goweb.Ident("GRPCCodeName"), "(int(s.Code())), Message: s.Message()}")
+			g.gen.Block("for _, a := range s.Proto().Details", func() {
+				g.P("var d ", ptypes.Ident("DynamicAny"))
+				g.gen.Block("if "+ptypes.Ident("UnmarshalAny")+"(a, &d) != nil", func() { g.P("continue") })
+				g.gen.Block("switch d := d.Message.(type)", func() {
+					g.P("case *", errdetails.Ident("ErrorInfo"), ":")
+					g.gen.Block("if d.Reason != \"\"", func() { g.P("e.Code = d.Reason") })
+					g.P("e.Domain = d.Domain")
+					g.P("case *", errdetails.Ident("BadRequest"), ":")
+					g.gen.Block("for _, v := range d.FieldViolations", func() {
+						g.P("e.Violations = append(e.Violations, ", goweb.Ident("FieldViolation"), "{Field: v.Field, Description: v.Description})")
+					})
+					g.P("case *", errdetails.Ident("RetryInfo"), ":")
+					g.P("e.Retryable = true")
+					g.gen.Block("if d.RetryDelay != nil", func() {
+						g.P("e.RetryAfter, _ = ", ptypes.Ident("Duration"), "(d.RetryDelay)")
+					})
+					g.P("default:")
+					g.P("e.Details = append(e.Details, d)")
+				})
+			})
+			g.P("e.Status = ", goweb.Ident("GRPCErrorStatus"), "(e.Domain, e.Code, int(s.Code()))")
+			g.P("return e")
+		},
+	})
+}
+
+// generateErrorMapper generates _<Service>ErrorMapper, a <Service>Server
+// passing the errors of each method of another one through conv.
+func (g *grpc) generateErrorMapper(servName string, service *pb.ServiceDescriptorProto) {
+	mapper := "_" + servName + "ErrorMapper"
+	g.gen.EmitStruct(generator.Struct{
+		Name:   mapper,
+		Fields: []generator.Field{{Name: "h", Type: servName + "Server"}, {Name: "conv", Type: "func(error) error"}},
+	})
+	for _, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		callName := methName
+		if reservedClientName[methName] {
+			callName += "_"
+		}
+		switch {
+		case !method.GetServerStreaming() && !method.GetClientStreaming():
+			g.gen.EmitFunc(generator.Func{
+				Recv:    "m " + mapper,
+				Name:    callName,
+				Params:  "ctx " + contextPkg + ".Context, in *" + g.typeName(method.GetInputType()),
+				Results: "(*" + g.typeName(method.GetOutputType()) + ", error)",
+				Body: func() {
+					g.P("out, err := m.h.", callName, "(ctx, in)")
+					g.P("return out, m.conv(err)")
+				},
+			})
+		case !method.GetClientStreaming():
+			g.gen.EmitFunc(generator.Func{
+				Recv:    "m " + mapper,
+				Name:    callName,
+				Params:  "in *" + g.typeName(method.GetInputType()) + ", stream " + servName + "_" + methName + "Server",
+				Results: "error",
+				Body:    func() { g.P("return m.conv(m.h.", callName, "(in, stream))") },
+			})
+		default:
+			g.gen.EmitFunc(generator.Func{
+				Recv:    "m " + mapper,
+				Name:    callName,
+				Params:  "stream " + servName + "_" + methName + "Server",
+				Results: "error",
+				Body:    func() { g.P("return m.conv(m.h.", callName, "(stream))") },
+			})
+		}
+	}
+}
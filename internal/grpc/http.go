@@ -0,0 +1,115 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// checkTemplate fails the generation if the path template of route, from
+// the google.api.http annotation of method, does not parse, or names
+// fields that the request does not have.
+func (g *grpc) checkTemplate(method *pb.MethodDescriptorProto, route goweb.Route) {
+	t, err := route.Template()
+	if err != nil {
+		g.gen.Fail("google.api.http of", route.FullMethod()+":", err.Error())
+	}
+	for _, field := range t.Fields() {
+		if f := g.fieldAt(method.GetInputType(), field); f == nil || f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE && !isWrapper(f.GetTypeName()) {
+			g.gen.Fail("google.api.http of", route.FullMethod()+":", "the path variable", field, "is not a scalar field of", method.GetInputType()[1:])
+		}
+	}
+	if route.Body != "" && route.Body != "*" && (strings.Contains(route.Body, ".") || g.fieldAt(method.GetInputType(), route.Body) == nil) {
+		g.gen.Fail("google.api.http of", route.FullMethod()+":", "the body", strconv.Quote(route.Body), "is not a field of", method.GetInputType()[1:])
+	}
+}
+
+// fieldAt returns the field at the dotted path of field names in the
+// message name, or nil.
+func (g *grpc) fieldAt(name, path string) *pb.FieldDescriptorProto {
+	var field *pb.FieldDescriptorProto
+	for _, part := range strings.Split(path, ".") {
+		if field != nil {
+			name = field.GetTypeName()
+		}
+		field = nil
+		for _, f := range g.msgs[name].GetField() {
+			if f.GetName() == part {
+				field = f
+			}
+		}
+		if field == nil {
+			return nil
+		}
+	}
+	return field
+}
+
+// isWrapper reports whether the message name is one of the wrappers of
+// google/protobuf/wrappers.proto, which path variables and query
+// parameters set like scalars.
+func isWrapper(name string) bool {
+	return strings.HasPrefix(name, ".google.protobuf.") && strings.HasSuffix(name, "Value") && name != ".google.protobuf.Value"
+}
+
+// generateBind generates the part of a handler of a method with a
+// google.api.http annotation that binds the query parameters and the path
+// variables of the request to the fields of in.
+func (g *grpc) generateBind(method *pb.MethodDescriptorProto, route goweb.Route) {
+	if route.Verb == "" {
+		return
+	}
+	t, _ := route.Template()
+	if route.Body != "*" {
+		var skip string
+		for _, field := range append([]string{route.Body}, t.Fields()...) {
+			if field != "" {
+				skip += ", " + strconv.Quote(field)
+			}
+		}
+		for _, f := range g.softDeleteFlags(method) {
+			skip += ", " + strconv.Quote(f.GetName())
+		}
+		g.P("	if err := goweb.BindQuery(", g.in(), ", r.URL.Query()", skip, "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+	if len(t.Fields()) > 0 {
+		g.P("	if err := goweb.BindPath(", g.in(), ", goweb.PathParams(r)); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	}")
+	}
+}
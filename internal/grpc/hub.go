@@ -0,0 +1,85 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// isWatch reports whether method is a watch-style method, whose messages
+// a goweb.Hub fans out with the hub parameter.
+func isWatch(method *pb.MethodDescriptorProto) bool {
+	return method.GetServerStreaming() && !method.GetClientStreaming()
+}
+
+// generateHubs generates, for every server-streaming method of the
+// service, the goweb.Hub fanning out its messages to WebSocket
+// subscribers at <path>/ws, its filter, its publish function and the
+// handler of the subscribers.
+func (g *grpc) generateHubs(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	for i, method := range service.Method {
+		if !isWatch(method) {
+			continue
+		}
+		name := servName + generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("// ", name, "Hub fans out the messages published with Publish", name, " to the")
+		g.P("// WebSocket subscribers of <prefix>/", routes[i].Path, "/ws.")
+		g.P("var ", name, "Hub = &goweb.Hub{}")
+		g.P()
+		g.P("// ", name, "Filter, if set, decides whether a subscriber of ", name, "Hub,")
+		g.P("// which subscribed with the request in (in the query of its URL), gets m.")
+		g.P("var ", name, "Filter func(ctx ", contextPkg, ".Context, in *", inType, ", m *", outType, ") bool")
+		g.P()
+		g.P("// Publish", name, " sends m to the subscribers of ", name, "Hub.")
+		g.P("func Publish", name, "(m *", outType, ") error {")
+		g.P("	return ", name, "Hub.Publish(m)")
+		g.P("}")
+		g.P()
+		g.P("func _", servName, "_", generator.CamelCase(method.GetName()), "_WebSocket(w http.ResponseWriter, r *http.Request) {")
+		g.P("	in := ", inType, "{}")
+		g.P("	if err := json.Unmarshal(goweb.QueryJSON(r.URL.Query()), &in); err != nil {")
+		g.P("		w.WriteHeader(400)")
+		g.P("		w.Write([]byte(err.Error()))")
+		g.P("		return")
+		g.P("	}")
+		g.P("	ctx := goweb.NewContext(r)")
+		g.P("	", name, "Hub.Serve(w, r, func(m interface{}) bool {")
+		g.P("		return ", name, "Filter == nil || ", name, "Filter(ctx, &in, m.(*", outType, "))")
+		g.P("	})")
+		g.P("}")
+		g.P()
+	}
+}
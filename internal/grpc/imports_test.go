@@ -0,0 +1,71 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Request and response types of other proto packages are qualified by
+// the names their packages are imported as, which must not shadow the
+// packages the generated code imports: the proto package log here.
+func TestCrossPackageTypes(t *testing.T) {
+	entry := &pb.FileDescriptorProto{
+		Name:        proto.String("log/entry.proto"),
+		Package:     proto.String("log"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*pb.DescriptorProto{message("Entry", field("text", 1, pb.FieldDescriptorProto_TYPE_STRING))},
+	}
+	add := method("Append", "User", "User")
+	add.InputType = proto.String(".log.Entry")
+	users := testFile("users.proto",
+		message("User", field("last", 1, pb.FieldDescriptorProto_TYPE_MESSAGE, ".log.Entry")),
+		service("Users", add),
+	)
+	users.Dependency = []string{"log/entry.proto"}
+	src := generateMux(t, "client", entry, users)
+	if !strings.Contains(src, `import log1 "log"`) {
+		t.Error("the proto package log is not imported as log1")
+	}
+	checkDecl(t, src, "UsersHTTPClient", `
+type UsersHTTPClient interface {
+	Append(ctx context.Context, in *log1.Entry) (*User, error)
+}`)
+	handler := decl(t, src, "(*_UsersServer).Append")
+	if !strings.Contains(handler, "in := log1.Entry{}") || !strings.Contains(handler, "log.Println(err.Error())") {
+		t.Errorf("handler mixes up the packages:\n%s", handler)
+	}
+}
@@ -0,0 +1,115 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// countFile has a service with a method whose response has a 64-bit
+// integer, whose JSON the mappings write differently.
+func countFile(opts ...*proto.ExtensionDesc) *pb.FileDescriptorProto {
+	count := method("Count", "Count", "Count")
+	count.Options = &pb.MethodOptions{}
+	for _, ext := range opts {
+		if err := proto.SetExtension(count.Options, ext, proto.Bool(true)); err != nil {
+			panic(err)
+		}
+	}
+	return testFile("count.proto",
+		message("Count", field("n", 1, pb.FieldDescriptorProto_TYPE_INT64)),
+		service("Counts", count),
+	)
+}
+
+// The handlers use the proto3 JSON mapping by default.
+func TestProto3JSON(t *testing.T) {
+	src := generateMux(t, "", countFile())
+	handler := decl(t, src, "(*_CountsServer).Count")
+	for _, want := range []string{"err = goweb.Proto3JSON{}.Unmarshal(content, &in)", "out, err := goweb.Proto3JSON{}.Marshal(res)"} {
+		if !strings.Contains(handler, want) {
+			t.Errorf("handler does not use the proto3 mapping, no %s:\n%s", want, handler)
+		}
+	}
+	if strings.Contains(decl(t, src, "_Counts_routes"), "LegacyJSON") {
+		t.Error("route marked as legacy JSON")
+	}
+
+	src = generateMux(t, "emit_defaults,enums_as_ints", countFile())
+	if !strings.Contains(decl(t, src, "(*_CountsServer).Count"), "goweb.Proto3JSON{EmitDefaults: true, EnumsAsInts: true}.Marshal(res)") {
+		t.Error("handler ignores the options of the mapping")
+	}
+	src = generateMux(t, "compact,enums_as_ints", countFile())
+	if !strings.Contains(decl(t, src, "_Counts_methods"), "JSON: goweb.Proto3JSON{EnumsAsInts: true}") {
+		t.Error("method row ignores the options of the mapping")
+	}
+}
+
+// legacy_json and proto3_json=false keep the encoding/json based encoding.
+func TestLegacyJSON(t *testing.T) {
+	for _, param := range []string{"legacy_json", "proto3_json=false"} {
+		src := generateMux(t, param, countFile())
+		handler := decl(t, src, "(*_CountsServer).Count")
+		if strings.Contains(handler, "Proto3JSON") || !strings.Contains(handler, "err = goweb.UnmarshalJSON(content, &in)") {
+			t.Errorf("%s: handler uses the proto3 mapping:\n%s", param, handler)
+		}
+		if !strings.Contains(decl(t, src, "_Counts_routes"), "LegacyJSON: true,") {
+			t.Errorf("%s: route not marked as legacy JSON", param)
+		}
+	}
+	if err := generateError(t, "legacy_json,emit_defaults", countFile()); !strings.Contains(err, "emit_defaults needs the proto3 JSON mapping") {
+		t.Errorf("error %q", err)
+	}
+	if err := generateError(t, "legacy_json,proto3_json", countFile()); !strings.Contains(err, "legacy_json and proto3_json exclude each other") {
+		t.Errorf("error %q", err)
+	}
+}
+
+// Methods with the stream_response option keep encoding/json, which is the
+// JSON they can stream, unless proto3_json asks for the mapping.
+func TestStreamResponseJSON(t *testing.T) {
+	src := generateMux(t, "", countFile(options.E_StreamResponse))
+	if handler := decl(t, src, "(*_CountsServer).Count"); strings.Contains(handler, "Proto3JSON") || !strings.Contains(handler, "impl.opts.StreamJSON(w, r, res)") {
+		t.Errorf("handler does not stream encoding/json:\n%s", handler)
+	}
+	if !strings.Contains(decl(t, src, "_Counts_routes"), "LegacyJSON: true,") {
+		t.Error("route not marked as legacy JSON")
+	}
+	if err := generateError(t, "proto3_json", countFile(options.E_StreamResponse)); !strings.Contains(err, "cannot stream its response") {
+		t.Errorf("error %q", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// linkRel matches the relation types of links: registered ones such as
+// "next", or extension relation types, which are URLs.
+var linkRel = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9.\-]*$|^[a-z][a-z0-9+.\-]*://[^\s"<>]+$`)
+
+// links returns the templates of the link options of method, failing the
+// generation if one is malformed or refers to a field that is not a
+// singular scalar field of the response.
+func (g *grpc) links(method *pb.MethodDescriptorProto, route goweb.Route) []string {
+	links := options.Strings(method.GetOptions(), options.E_Link)
+	if len(links) > 0 && (method.GetServerStreaming() || method.GetClientStreaming()) {
+		g.gen.Fail("link option of", route.FullMethod()+":", "streaming methods cannot have links")
+	}
+	rels := map[string]bool{}
+	for _, link := range links {
+		i := strings.Index(link, "=")
+		if i < 0 || !linkRel.MatchString(link[:i]) || rels[link[:i]] {
+			g.gen.Fail("link option of", route.FullMethod()+":", strconv.Quote(link), "is not rel=template with a new relation type")
+		}
+		rels[link[:i]] = true
+		template := link[i+1:]
+		for {
+			start := strings.IndexByte(template, '{')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(template[start:], '}')
+			if end < 0 {
+				g.gen.Fail("link option of", route.FullMethod()+":", strconv.Quote(link), "has an unclosed {")
+			}
+			field := template[start+1 : start+end]
+			f := g.fieldAt(method.GetOutputType(), field)
+			if f == nil || f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED ||
+				f.GetType() == pb.FieldDescriptorProto_TYPE_BYTES ||
+				f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE && !isWrapper(f.GetTypeName()) {
+				g.gen.Fail("link option of", route.FullMethod()+":", "{"+field+"} is not a singular scalar field of", method.GetOutputType()[1:])
+			}
+			template = template[start+end+1:]
+		}
+	}
+	return links
+}
+
+// linksInBody reports whether the handler of method writes its links into
+// the response, for the links parameter; Link headers are the default,
+// and the only choice for downloads.
+func (g *grpc) linksInBody(method *pb.MethodDescriptorProto) bool {
+	if options.Bool(method.GetOptions(), options.E_Download) {
+		return false
+	}
+	switch v, _ := g.param("links"); v {
+	case "", "header":
+		return false
+	case "body":
+		return true
+	default:
+		g.gen.Fail("parameter links must be header or body, not", strconv.Quote(v))
+	}
+	return false
+}
+
+// generateLinks generates the part of a handler that resolves the links
+// of the response res, and sends them as Link headers unless they go
+// into the body.
+func (g *grpc) generateLinks(method *pb.MethodDescriptorProto, route goweb.Route) {
+	links := g.links(method, route)
+	if len(links) == 0 {
+		return
+	}
+	var args string
+	for _, link := range links {
+		args += ", " + strconv.Quote(link)
+	}
+	g.P("	links := goweb.ResolveLinks(res", args, ")")
+	if !g.linksInBody(method) {
+		g.P("	goweb.WriteLinkHeader(w, links)")
+	}
+}
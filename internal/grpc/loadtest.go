@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateLoadTest generates <Service>LoadGenerators, with a request
+// generator per unary method, and <Service>LoadTargets, turning them into
+// the targets of a goweb.LoadTest. With examples, it also generates
+// <Service>ExampleLoadGenerators, sending the example requests.
+func (g *grpc) generateLoadTest(file *generator.FileDescriptor, servName string, service *pb.ServiceDescriptorProto) {
+	g.P("// ", servName, "LoadGenerators generate the requests of a load test of the")
+	g.P("// ", servName, " service; methods without a generator are not called.")
+	g.P("type ", servName, "LoadGenerators struct {")
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P(generator.CamelCase(method.GetName()), " func(i int) *", g.typeName(method.GetInputType()))
+	}
+	g.P("}")
+	g.P()
+	g.P("// ", servName, "LoadTargets returns the routes of the ", servName, " service with a")
+	g.P("// generator in gen, as targets of a goweb.LoadTest.")
+	g.P("func ", servName, "LoadTargets(gen ", servName, "LoadGenerators) []goweb.LoadTarget {")
+	g.P("	var targets []goweb.LoadTarget")
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		g.P("	if gen.", methName, " != nil {")
+		g.P("		targets = append(targets, goweb.LoadTarget{Route: _", servName, "_routes[", i, "], Next: func(i int) interface{} { return gen.", methName, "(i) }})")
+		g.P("	}")
+	}
+	g.P("	return targets")
+	g.P("}")
+	g.P()
+	if !g.flag("examples") {
+		return
+	}
+	g.P("// ", servName, "ExampleLoadGenerators returns generators sending the example")
+	g.P("// request of each method that has one.")
+	g.P("func ", servName, "ExampleLoadGenerators() ", servName, "LoadGenerators {")
+	g.P("	return ", servName, "LoadGenerators{")
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		if example := g.exampleMessage(file, method.GetInputType(), ""); example != "" {
+			g.P(generator.CamelCase(method.GetName()), ": func(int) *", g.typeName(method.GetInputType()), " { return ", example, " },")
+		}
+	}
+	g.P("	}")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// responseMeta returns where the handlers send the metadata of their
+// responses, for the response_meta parameter: "header", "envelope", or ""
+// without it.
+func (g *grpc) responseMeta() string {
+	switch v, _ := g.param("response_meta"); v {
+	case "", "false":
+		return ""
+	case "true", "header":
+		return "header"
+	case "envelope":
+		return "envelope"
+	default:
+		g.gen.Fail("parameter response_meta must be header or envelope, not", strconv.Quote(v))
+	}
+	return ""
+}
+
+// generateMetaStart generates the part of a handler that starts the
+// timing of the implementation, right before calling it.
+func (g *grpc) generateMetaStart() {
+	if g.responseMeta() != "" {
+		g.P("	meta := goweb.NewResponseMeta()")
+	}
+}
+
+// generateMeta generates the part of a handler that completes the
+// metadata of the response res to the request in, from the next_page_token
+// field of the response and the google.protobuf.FieldMask field of the
+// request, and sends it as headers unless it goes into the envelope.
+func (g *grpc) generateMeta(method *pb.MethodDescriptorProto) {
+	mode := g.responseMeta()
+	if mode == "" {
+		return
+	}
+	for _, f := range g.msgs[method.GetOutputType()].GetField() {
+		if f.GetName() == "next_page_token" && f.GetType() == pb.FieldDescriptorProto_TYPE_STRING && f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED {
+			g.P("	meta.NextPageToken = res.Get", generator.CamelCase(f.GetName()), "()")
+		}
+	}
+	for _, f := range g.msgs[method.GetInputType()].GetField() {
+		if f.GetTypeName() == ".google.protobuf.FieldMask" && f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED {
+			g.P("	meta.FieldMask = goweb.MaskPaths(in.Get", generator.CamelCase(f.GetName()), "())")
+			break
+		}
+	}
+	if mode == "header" {
+		g.P("	meta.WriteHeaders(w)")
+	}
+}
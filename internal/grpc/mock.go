@@ -0,0 +1,95 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateMock generates Mock<Service>Server, an implementation of the
+// service calling a function field per method and recording the calls,
+// for the tests of code using the service.
+func (g *grpc) generateMock(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	serverType := servName + "Server"
+	mockType := "Mock" + serverType
+
+	g.P("// ", mockType, " is a ", serverType, " for tests: its methods call the")
+	g.P("// <Method>Func fields, or fail with UNIMPLEMENTED if they are nil, and")
+	g.P("// record the calls in its goweb.MockCalls, e.g. m.Calls(\"<Method>\").")
+	g.P("// Serve it with New", servName, "Mux(m, \"/\"), or NewTest", servName, "Server(m) with test_server.")
+	g.P("type ", mockType, " struct {")
+	g.P("	goweb.MockCalls")
+	for _, method := range service.Method {
+		sig := g.generateServerSignature(servName, method)
+		name := sig[:strings.IndexByte(sig, '(')]
+		g.P("	", name, "Func func", sig[len(name):])
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverType, " = &", mockType, "{}")
+	g.P()
+	for i, method := range service.Method {
+		sig := g.generateServerSignature(servName, method)
+		name := sig[:strings.IndexByte(sig, '(')]
+		unimplemented := "goweb.ErrorFactory{}.Unimplemented(" + strconv.Quote(routes[i].FullMethod()) + ")"
+		switch {
+		case method.GetClientStreaming():
+			g.P("func (m *", mockType, ") ", name, "(stream ", servName, "_", generator.CamelCase(method.GetName()), "Server) error {")
+			g.P("	m.Record(", strconv.Quote(name), ", nil)")
+			g.P("	if m.", name, "Func == nil {")
+			g.P("		return ", unimplemented)
+			g.P("	}")
+			g.P("	return m.", name, "Func(stream)")
+		case method.GetServerStreaming():
+			g.P("func (m *", mockType, ") ", name, "(in *", g.typeName(method.GetInputType()), ", stream ", servName, "_", generator.CamelCase(method.GetName()), "Server) error {")
+			g.P("	m.Record(", strconv.Quote(name), ", in)")
+			g.P("	if m.", name, "Func == nil {")
+			g.P("		return ", unimplemented)
+			g.P("	}")
+			g.P("	return m.", name, "Func(in, stream)")
+		default:
+			g.P("func (m *", mockType, ") ", name, "(ctx ", contextPkg, ".Context, in *", g.typeName(method.GetInputType()), ") (*", g.typeName(method.GetOutputType()), ", error) {")
+			g.P("	m.Record(", strconv.Quote(name), ", in)")
+			g.P("	if m.", name, "Func == nil {")
+			g.P("		return nil, ", unimplemented)
+			g.P("	}")
+			g.P("	return m.", name, "Func(ctx, in)")
+		}
+		g.P("}")
+		g.P()
+	}
+}
@@ -0,0 +1,99 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateMQTT generates Subscribe<Service>MQTT, which binds every unary
+// method to the MQTT topic given by its goweb.mqtt_topic option.
+func (g *grpc) generateMQTT(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	g.P("// Subscribe", servName, "MQTT serves the unary methods of the ", servName, " service")
+	g.P("// over MQTT, each on the topic declared with its goweb.mqtt_topic option")
+	g.P("// (by default its HTTP path) below prefix. Payloads are decoded and")
+	g.P("// replies encoded with codec.")
+	g.P("func Subscribe", servName, "MQTT(c goweb.MQTTClient, h ", servName, "Server, prefix string, codec goweb.Codec) error {")
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		template := options.String(method.GetOptions(), options.E_MqttTopic)
+		if template == "" {
+			template = strings.TrimPrefix(routes[i].Path, "/")
+		}
+		filter, vars, err := goweb.ParseTopic(template)
+		if err != nil {
+			g.gen.Error(err, "method", routes[i].FullMethod())
+		}
+		qos := options.Int32(method.GetOptions(), options.E_MqttQos)
+		if qos < 0 || qos > 2 {
+			g.gen.Fail("method", routes[i].FullMethod(), "has invalid mqtt_qos", strconv.Itoa(int(qos)))
+		}
+		g.P("if err := c.Subscribe(prefix+", strconv.Quote(filter), ", ", strconv.Itoa(int(qos)), ", func(ctx ", contextPkg, ".Context, topic string, payload []byte) ([]byte, error) {")
+		g.P("	in := new(", g.typeName(method.GetInputType()), ")")
+		g.P("	if err := codec.Unmarshal(payload, in); err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		for _, f := range g.msgs[method.GetInputType()].GetField() {
+			if level, ok := vars[f.GetName()]; ok {
+				if f.GetType() != pb.FieldDescriptorProto_TYPE_STRING || f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+					g.gen.Fail("topic variable", f.GetName(), "of method", routes[i].FullMethod(), "is not a string field")
+				}
+				g.P("	in.", g.goField(method.GetInputType(), f).field, " = goweb.TopicLevel(topic, prefix, ", strconv.Itoa(level), ")")
+				delete(vars, f.GetName())
+			}
+		}
+		for name := range vars {
+			g.gen.Fail("topic variable", name, "of method", routes[i].FullMethod(), "is not a field of the request")
+		}
+		g.P("	out, err := h.", methName, "(ctx, in)")
+		g.P("	if err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		g.generateResponseFilter(method, "out")
+		g.P("	return codec.Marshal(out)")
+		g.P("}); err != nil {")
+		g.P("	return err")
+		g.P("}")
+	}
+	g.P("	return nil")
+	g.P("}")
+	g.P()
+}
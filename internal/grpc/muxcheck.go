@@ -0,0 +1,89 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateValidateMux generates Validate<Service>Mux, which checks at
+// startup that the options and the process have what the methods of the
+// service need, see goweb.ServerOptions.Check.
+func (g *grpc) generateValidateMux(servName string, service *pb.ServiceDescriptorProto) {
+	var fields []string
+	list := func(field string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = strconv.Quote(name)
+		}
+		fields = append(fields, field+": []string{"+strings.Join(quoted, ", ")+"}")
+	}
+	var middleware, enrichers, transformers []string
+	needs := map[string]bool{"Sessions": g.flag("oidc"), "OIDC": g.flag("oidc"), "AdminAuth": g.flag("admin") && len(g.adminResources(service)) > 0}
+	for _, method := range service.Method {
+		o := method.GetOptions()
+		middleware = append(middleware, options.Strings(o, options.E_Middleware)...)
+		if name := options.String(o, options.E_Enrich); name != "" {
+			enrichers = append(enrichers, name)
+		}
+		if name := options.String(o, options.E_Transform); name != "" {
+			transformers = append(transformers, name)
+		}
+		needs["Policies"] = needs["Policies"] || options.String(o, options.E_Policy) != ""
+		needs["Sessions"] = needs["Sessions"] || options.Int32(o, options.E_Session) != 0
+		needs["Audit"] = needs["Audit"] || options.Bool(o, options.E_Audit)
+		needs["FieldCrypter"] = needs["FieldCrypter"] || g.needs(decryptPass, method.GetInputType()) || g.needs(encryptPass, method.GetOutputType())
+	}
+	list("Middleware", middleware)
+	list("Enrichers", enrichers)
+	list("Transformers", transformers)
+	for _, name := range []string{"Policies", "Sessions", "Audit", "FieldCrypter", "OIDC", "AdminAuth"} {
+		if needs[name] {
+			fields = append(fields, name+": true")
+		}
+	}
+	g.P("// Validate", servName, "Mux checks at startup that the options and the process")
+	g.P("// have what the methods of the ", servName, " service need, such as the named")
+	g.P("// middlewares and the registered enrichers, and returns all that is")
+	g.P("// missing as goweb.MuxErrors, see goweb.ServerOptions.Check.")
+	g.P("func Validate", servName, "Mux(prefix string, opts ...goweb.ServerOption) error {")
+	g.P("	return goweb.NewServerOptions(opts...).Check(prefix, goweb.MuxRequirements{", strings.Join(fields, ", "), "})")
+	g.P("}")
+	g.P()
+}
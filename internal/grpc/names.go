@@ -0,0 +1,151 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Go names of proto declarations. Generated code refers to the types,
+// fields and getters that protoc-gen-go generates, so it must derive
+// their names the same way, and the names of its own helpers must not
+// collide for different messages.
+
+// methodNames are the methods of generated messages; fields with these
+// names get an underscore appended.
+var methodNames = []string{"Reset", "String", "ProtoMessage", "Marshal", "Unmarshal", "ExtensionRangeArray", "ExtensionMap", "Descriptor"}
+
+// goNames are the Go names of a field: the struct field and its getter,
+// and for a field of a oneof the struct field of the oneof and the suffix
+// of the wrapper type after the message type, e.g. "_Email".
+type goNames struct {
+	field, getter  string
+	oneof, wrapper string
+}
+
+// fieldNames returns the Go names of the fields of msg, allocated like
+// protoc-gen-go does: in declaration order, appending underscores to the
+// field and getter names while one of them is taken by a method or an
+// earlier field, getter or oneof.
+func fieldNames(msg *pb.DescriptorProto) map[*pb.FieldDescriptorProto]goNames {
+	used := map[string]bool{}
+	for _, n := range methodNames {
+		used[n] = true
+	}
+	alloc := func(names ...string) []string {
+		for {
+			taken := false
+			for _, n := range names {
+				taken = taken || used[n]
+			}
+			if !taken {
+				break
+			}
+			for i := range names {
+				names[i] += "_"
+			}
+		}
+		for _, n := range names {
+			used[n] = true
+		}
+		return names
+	}
+	names := map[*pb.FieldDescriptorProto]goNames{}
+	oneofs := map[int32]string{}
+	for _, f := range msg.GetField() {
+		base := generator.CamelCase(f.GetName())
+		ns := alloc(base, "Get"+base)
+		n := goNames{field: ns[0], getter: ns[1]}
+		if f.OneofIndex != nil {
+			if _, ok := oneofs[f.GetOneofIndex()]; !ok {
+				oneofs[f.GetOneofIndex()] = alloc(generator.CamelCase(msg.OneofDecl[f.GetOneofIndex()].GetName()))[0]
+			}
+			n.oneof, n.wrapper = oneofs[f.GetOneofIndex()], wrapperName(msg, n.field)
+		}
+		names[f] = n
+	}
+	return names
+}
+
+// wrapperName returns the suffix of the Go type wrapping the oneof field
+// named field of msg, with underscores appended while it collides with a
+// nested message or enum.
+func wrapperName(msg *pb.DescriptorProto, field string) string {
+	nested := map[string]bool{}
+	for _, m := range msg.NestedType {
+		nested[generator.CamelCase(m.GetName())] = true
+	}
+	for _, e := range msg.EnumType {
+		nested[generator.CamelCase(e.GetName())] = true
+	}
+	for nested[field] {
+		field += "_"
+	}
+	return "_" + field
+}
+
+// goField returns the Go names of the field f of the message name.
+func (g *grpc) goField(name string, f *pb.FieldDescriptorProto) goNames {
+	if g.fieldNames == nil {
+		g.fieldNames = map[*pb.FieldDescriptorProto]goNames{}
+	}
+	if n, ok := g.fieldNames[f]; ok {
+		return n
+	}
+	for field, n := range fieldNames(g.msgs[name]) {
+		g.fieldNames[field] = n
+	}
+	if n, ok := g.fieldNames[f]; ok {
+		return n
+	}
+	// Not a field of name; only the usual names can be derived.
+	base := generator.CamelCase(f.GetName())
+	return goNames{field: base, getter: "Get" + base}
+}
+
+// oneofCase returns the type assertion of the oneof of the field f of the
+// message name, m, to the wrapper of f, which holds while f is set, e.g.
+// "m.Login.(*User_Password)".
+func (g *grpc) oneofCase(name string, f *pb.FieldDescriptorProto) string {
+	n := g.goField(name, f)
+	return "m." + n.oneof + ".(*" + g.typeName(name) + n.wrapper + ")"
+}
+
+// mangle returns the full proto name name (e.g. ".pkg.Msg.Nested") as a
+// part of a Go identifier, e.g. "_pkg_Msg_Nested". Underscores in the
+// name are doubled, so that ".a_b.C" and ".a.b_C" do not collide.
+func mangle(name string) string {
+	return strings.Replace(strings.Replace(name, "_", "__", -1), ".", "_", -1)
+}
@@ -0,0 +1,92 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestFieldNames(t *testing.T) {
+	field := func(name string, oneof ...int32) *pb.FieldDescriptorProto {
+		f := &pb.FieldDescriptorProto{Name: proto.String(name)}
+		if len(oneof) > 0 {
+			f.OneofIndex = proto.Int32(oneof[0])
+		}
+		return f
+	}
+	msg := &pb.DescriptorProto{
+		Field: []*pb.FieldDescriptorProto{
+			field("descriptor"), field("x"), field("get_x"), field("user_id"), field("kind", 0), field("string"), field("x_"),
+		},
+		OneofDecl:  []*pb.OneofDescriptorProto{{Name: proto.String("string")}},
+		NestedType: []*pb.DescriptorProto{{Name: proto.String("kind")}},
+	}
+	want := []goNames{
+		{"Descriptor_", "GetDescriptor_", "", ""},
+		{"X", "GetX", "", ""},
+		{"GetX_", "GetGetX_", "", ""},
+		{"UserId", "GetUserId", "", ""},
+		{"Kind", "GetKind", "String_", "_Kind_"}, // Msg_Kind is the nested message
+		{"String__", "GetString__", "", ""},      // String is a method, String_ the oneof
+		{"X__", "GetX__", "", ""},                // GetX_ is the field of get_x
+	}
+	names := fieldNames(msg)
+	for i, f := range msg.Field {
+		if names[f] != want[i] {
+			t.Errorf("%s: got %v, want %v", f.GetName(), names[f], want[i])
+		}
+	}
+}
+
+func TestMangle(t *testing.T) {
+	for in, want := range map[string]string{
+		".pkg.User":    "_pkg_User",
+		".pkg.Foo.Bar": "_pkg_Foo_Bar",
+		".a_b.C":       "_a__b_C",
+		".a.b_C":       "_a_b__C",
+	} {
+		if got := mangle(in); got != want {
+			t.Errorf("mangle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestVersionParam(t *testing.T) {
+	for version, want := range map[string]string{"v2": "v2", "2": "v2", "beta": "vbeta", "v": "vv", "1-0": "v1_0"} {
+		if got := versionParam(version); got != want {
+			t.Errorf("versionParam(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
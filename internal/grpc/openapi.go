@@ -0,0 +1,540 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// openapiVersion is the version of the OpenAPI specification the
+// documents of the openapi parameter follow.
+const openapiVersion = "3.0.3"
+
+// jsonObject is an object of an OpenAPI document.
+type jsonObject map[string]interface{}
+
+// openapiDoc collects the schemas of the OpenAPI document of a file.
+type openapiDoc struct {
+	g        *grpc
+	proto3   bool                               // whether the JSON follows the proto3 mapping, see proto3JSON
+	comments map[string]string                  // leading comments, by full name of the element
+	enums    map[string]*pb.EnumDescriptorProto // all enums of the request, by full name
+	schemas  jsonObject                         // component schemas, by full name without the leading period
+	queue    []string                           // messages still to define
+}
+
+// generateOpenAPI adds the OpenAPI document of the services of file to the
+// output, as <file>.openapi.json: their routes, with the path variables
+// and query parameters, request bodies and responses, the JSON schemas of
+// their messages, and the comments of the proto file as descriptions.
+func (g *grpc) generateOpenAPI(file *generator.FileDescriptor) {
+	d := g.newOpenAPIDoc()
+	paths := jsonObject{}
+	var hashes []string
+	for _, service := range file.Service {
+		mediaType := "application/json"
+		if v := options.String(service.GetOptions(), options.E_ApiVersion); v != "" {
+			mediaType = v
+		}
+		for _, method := range service.Method {
+			route := goweb.RouteOf(file.FileDescriptorProto, service, method)
+			route.Hash = goweb.HashRoute(route, g.msgs)
+			hashes = append(hashes, route.Hash)
+			if method.GetClientStreaming() || method.GetServerStreaming() && !g.flag("streams") {
+				continue
+			}
+			path := goweb.JoinPath("/", templateVar.ReplaceAllString(route.Path, "{$1}"))
+			verb := strings.ToLower(route.Verb)
+			if verb == "" {
+				verb = "post"
+			}
+			item, _ := paths[path].(jsonObject)
+			if item == nil {
+				item = jsonObject{}
+				paths[path] = item
+			}
+			op := d.operation(method, route, mediaType)
+			if prev, ok := item[verb].(jsonObject); ok {
+				// the versions of a service share their paths, see
+				// goweb.VersionedMux
+				mergeContent(prev["requestBody"], op["requestBody"])
+				mergeContent(prev["responses"].(jsonObject)["200"], op["responses"].(jsonObject)["200"])
+				continue
+			}
+			item[verb] = op
+		}
+	}
+	d.schemas["goweb.Error"] = jsonObject{
+		"type":        "object",
+		"description": "The error of a failed call.",
+		"properties": jsonObject{
+			"code":    jsonObject{"type": "string"},
+			"message": jsonObject{"type": "string"},
+			"details": jsonObject{"type": "array", "items": jsonObject{"type": "object"}},
+		},
+	}
+	d.define()
+	title := file.GetPackage()
+	if title == "" {
+		title = file.GetName()
+	}
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "")))
+	doc := jsonObject{
+		"openapi":    openapiVersion,
+		"info":       jsonObject{"title": title, "version": hex.EncodeToString(sum[:8])},
+		"paths":      paths,
+		"components": jsonObject{"schemas": d.schemas},
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		g.gen.Error(err, "encoding the OpenAPI document of", file.GetName())
+	}
+	g.gen.AddFile(strings.TrimSuffix(file.GetName(), ".proto")+".openapi.json", string(out)+"\n")
+}
+
+// newOpenAPIDoc returns an empty document indexing all files of the
+// request.
+func (g *grpc) newOpenAPIDoc() *openapiDoc {
+	d := &openapiDoc{g: g, proto3: g.proto3JSON() != "", comments: map[string]string{}, enums: map[string]*pb.EnumDescriptorProto{}, schemas: jsonObject{}}
+	for _, f := range g.gen.Request.ProtoFile {
+		d.index(f)
+	}
+	return d
+}
+
+// define adds the schemas of the messages referenced so far.
+func (d *openapiDoc) define() {
+	for len(d.queue) > 0 {
+		name := d.queue[0]
+		d.queue = d.queue[1:]
+		d.schemas[name[1:]] = d.message(name)
+	}
+}
+
+// templateVar matches the variables of path templates, whose patterns
+// OpenAPI paths cannot express.
+var templateVar = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// mergeContent adds the media types of the request body or response from
+// to the one of to.
+func mergeContent(to, from interface{}) {
+	t, _ := to.(jsonObject)
+	f, _ := from.(jsonObject)
+	if t == nil || f == nil {
+		return
+	}
+	for k, v := range f["content"].(jsonObject) {
+		t["content"].(jsonObject)[k] = v
+	}
+}
+
+// index records the comments and enums of file.
+func (d *openapiDoc) index(file *pb.FileDescriptorProto) {
+	comments := map[string]string{}
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if c := commentText(loc.GetLeadingComments()); c != "" {
+			var path []string
+			for _, n := range loc.Path {
+				path = append(path, strconv.Itoa(int(n)))
+			}
+			comments[strings.Join(path, ",")] = c
+		}
+	}
+	prefix := "."
+	if pkg := file.GetPackage(); pkg != "" {
+		prefix += pkg + "."
+	}
+	var messages func(msgs []*pb.DescriptorProto, prefix, path string)
+	enums := func(list []*pb.EnumDescriptorProto, prefix, path string) {
+		for i, e := range list {
+			p := path + strconv.Itoa(i)
+			d.enums[prefix+e.GetName()] = e
+			d.comments[prefix+e.GetName()] = comments[p]
+			for j, v := range e.Value {
+				d.comments[prefix+e.GetName()+"."+v.GetName()] = comments[p+",2,"+strconv.Itoa(j)]
+			}
+		}
+	}
+	messages = func(msgs []*pb.DescriptorProto, prefix, path string) {
+		for i, m := range msgs {
+			p := path + strconv.Itoa(i)
+			name := prefix + m.GetName()
+			d.comments[name] = comments[p]
+			for j, f := range m.Field {
+				d.comments[name+"."+f.GetName()] = comments[p+",2,"+strconv.Itoa(j)]
+			}
+			messages(m.NestedType, name+".", p+",3,")
+			enums(m.EnumType, name+".", p+",4,")
+		}
+	}
+	messages(file.MessageType, prefix, "4,")
+	enums(file.EnumType, prefix, "5,")
+	for i, s := range file.Service {
+		for j, m := range s.Method {
+			d.comments[prefix+s.GetName()+"."+m.GetName()] = comments["6,"+strconv.Itoa(i)+",2,"+strconv.Itoa(j)]
+		}
+	}
+}
+
+// commentText returns the text of the comment c, without the space
+// after the comment markers.
+func commentText(c string) string {
+	lines := strings.Split(strings.TrimSpace(c), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// operation returns the OpenAPI operation of the route of method, whose
+// service answers with mediaType.
+func (d *openapiDoc) operation(method *pb.MethodDescriptorProto, route goweb.Route, mediaType string) jsonObject {
+	op := jsonObject{
+		"operationId": strings.Replace(route.Service, ".", "_", -1) + "_" + route.Method,
+		"tags":        []string{route.Service},
+	}
+	if c := d.comments["."+route.Service+"."+route.Method]; c != "" {
+		op["description"] = c
+	}
+	if method.GetOptions().GetDeprecated() {
+		op["deprecated"] = true
+	}
+	in := method.GetInputType()
+	var params []jsonObject
+	skip := map[string]bool{}
+	if t, _ := route.Template(); t != nil {
+		for _, field := range t.Fields() {
+			skip[strings.Split(field, ".")[0]] = true
+			param := jsonObject{"name": field, "in": "path", "required": true}
+			if f := d.g.fieldAt(in, field); f != nil {
+				param["schema"] = d.scalar(f)
+			}
+			params = append(params, param)
+		}
+	}
+	if route.Verb != "" && route.Body != "*" {
+		skip[route.Body] = true
+		for _, f := range d.g.msgs[in].GetField() {
+			if skip[f.GetName()] || f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE && !isWrapper(f.GetTypeName()) || f.GetType() == pb.FieldDescriptorProto_TYPE_GROUP {
+				continue
+			}
+			param := jsonObject{"name": d.name(f), "in": "query", "schema": d.field(f)}
+			if c := d.comments[in+"."+f.GetName()]; c != "" {
+				param["description"] = c
+			}
+			params = append(params, param)
+		}
+	}
+	if params != nil {
+		op["parameters"] = params
+	}
+	var body jsonObject
+	switch {
+	case route.Verb == "" || route.Body == "*":
+		body = d.ref(in)
+	case route.Body != "":
+		if f := d.g.fieldAt(in, route.Body); f != nil {
+			body = d.field(f)
+		}
+	}
+	if body != nil {
+		content := jsonObject{mediaType: jsonObject{"schema": body}}
+		if options.Bool(method.GetOptions(), options.E_Upload) {
+			content["multipart/form-data"] = jsonObject{"schema": jsonObject{"allOf": []jsonObject{body, {
+				"type":       "object",
+				"properties": jsonObject{"file": jsonObject{"type": "string", "format": "binary"}},
+			}}}}
+		}
+		op["requestBody"] = jsonObject{"required": true, "content": content}
+	}
+	out := d.ref(method.GetOutputType())
+	var content jsonObject
+	switch {
+	case method.GetServerStreaming():
+		content = jsonObject{"application/x-ndjson": jsonObject{"schema": out}, "text/event-stream": jsonObject{"schema": out}}
+	case options.Bool(method.GetOptions(), options.E_Download):
+		content = jsonObject{"application/octet-stream": jsonObject{"schema": jsonObject{"type": "string", "format": "binary"}}}
+	default:
+		content = jsonObject{mediaType: jsonObject{"schema": out}}
+	}
+	errorType := "application/json"
+	if d.g.errorWriter() == "WriteProblem" {
+		errorType = "application/problem+json"
+	}
+	op["responses"] = jsonObject{
+		"200":     jsonObject{"description": "The response of " + route.Method + ".", "content": content},
+		"default": jsonObject{"description": "An error.", "content": jsonObject{errorType: jsonObject{"schema": jsonObject{"$ref": "#/components/schemas/goweb.Error"}}}},
+	}
+	return op
+}
+
+// ref returns the reference to the schema of the message name, queuing its
+// definition.
+func (d *openapiDoc) ref(name string) jsonObject {
+	if s := d.wellKnown(name); s != nil {
+		return s
+	}
+	if _, ok := d.schemas[name[1:]]; !ok {
+		d.schemas[name[1:]] = nil
+		d.queue = append(d.queue, name)
+	}
+	return jsonObject{"$ref": "#/components/schemas/" + name[1:]}
+}
+
+// wellKnown returns the schema of the well-known type name in the proto3
+// JSON mapping, or nil; the default encoding writes them like other
+// messages.
+func (d *openapiDoc) wellKnown(name string) jsonObject {
+	if !d.proto3 || !strings.HasPrefix(name, ".google.protobuf.") {
+		return nil
+	}
+	switch name {
+	case ".google.protobuf.Timestamp":
+		return jsonObject{"type": "string", "format": "date-time"}
+	case ".google.protobuf.Duration", ".google.protobuf.FieldMask":
+		return jsonObject{"type": "string"}
+	case ".google.protobuf.Struct", ".google.protobuf.Empty":
+		return jsonObject{"type": "object"}
+	case ".google.protobuf.ListValue":
+		return jsonObject{"type": "array", "items": jsonObject{}}
+	case ".google.protobuf.Value":
+		return jsonObject{}
+	case ".google.protobuf.Any":
+		return jsonObject{"type": "object", "properties": jsonObject{"@type": jsonObject{"type": "string"}}, "required": []string{"@type"}}
+	}
+	if isWrapper(name) {
+		s := d.scalar(d.g.msgs[name].GetField()[0])
+		s["nullable"] = true
+		return s
+	}
+	return nil
+}
+
+// message returns the schema of the message name.
+func (d *openapiDoc) message(name string) jsonObject {
+	msg := d.g.msgs[name]
+	props := jsonObject{}
+	oneofs := map[int32]jsonObject{}
+	for _, f := range msg.GetField() {
+		s := d.field(f)
+		if c := d.comments[name+"."+f.GetName()]; c != "" {
+			s = d.describe(s, c)
+		}
+		if f.OneofIndex != nil && !d.proto3 {
+			// encoding/json writes a oneof as an object with the set
+			// field, under the Go name of the oneof
+			o := oneofs[f.GetOneofIndex()]
+			if o == nil {
+				o = jsonObject{"type": "object", "properties": jsonObject{}, "maxProperties": 1}
+				oneofs[f.GetOneofIndex()] = o
+				props[generator.CamelCase(msg.OneofDecl[f.GetOneofIndex()].GetName())] = o
+			}
+			o["properties"].(jsonObject)[d.name(f)] = s
+			continue
+		}
+		props[d.name(f)] = s
+	}
+	s := jsonObject{"type": "object", "properties": props}
+	var required []string
+	for _, f := range d.g.msgs[name].GetField() {
+		if f.OneofIndex == nil && options.Bool(f.GetOptions(), options.E_Required) {
+			required = append(required, d.name(f))
+		}
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	if c := d.comments[name]; c != "" {
+		s["description"] = c
+	}
+	return s
+}
+
+// describe returns the schema s with the description c; references cannot
+// have siblings, so they are wrapped in allOf.
+func (d *openapiDoc) describe(s jsonObject, c string) jsonObject {
+	if _, ok := s["$ref"]; ok {
+		s = jsonObject{"allOf": []jsonObject{s}}
+	}
+	s["description"] = c
+	return s
+}
+
+// name returns the JSON name of the field f.
+func (d *openapiDoc) name(f *pb.FieldDescriptorProto) string {
+	if d.proto3 {
+		return jsonName(f)
+	}
+	return f.GetName()
+}
+
+// field returns the schema of the field f, with its limits and visibility.
+func (d *openapiDoc) field(f *pb.FieldDescriptorProto) jsonObject {
+	var s jsonObject
+	if entry := d.g.msgs[f.GetTypeName()]; entry.GetOptions().GetMapEntry() {
+		s = jsonObject{"type": "object", "additionalProperties": d.value(entry.GetField()[1])}
+		if n := options.Uint32(f.GetOptions(), options.E_MaxItems); n > 0 {
+			s["maxProperties"] = n
+		}
+		if n := options.Uint32(f.GetOptions(), options.E_MinItems); n > 0 {
+			s["minProperties"] = n
+		}
+	} else if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		s = jsonObject{"type": "array", "items": d.value(f)}
+		if n := options.Uint32(f.GetOptions(), options.E_MaxItems); n > 0 {
+			s["maxItems"] = n
+		}
+		if n := options.Uint32(f.GetOptions(), options.E_MinItems); n > 0 {
+			s["minItems"] = n
+		}
+	} else {
+		s = d.value(f)
+	}
+	switch options.Int32(f.GetOptions(), options.E_Visibility) {
+	case options.OutputOnly:
+		s = d.flag(s, "readOnly")
+	case options.InputOnly:
+		s = d.flag(s, "writeOnly")
+	}
+	if f.GetOptions().GetDeprecated() {
+		s = d.flag(s, "deprecated")
+	}
+	return s
+}
+
+// flag returns the schema s with the boolean property name set.
+func (d *openapiDoc) flag(s jsonObject, name string) jsonObject {
+	if _, ok := s["$ref"]; ok {
+		s = jsonObject{"allOf": []jsonObject{s}}
+	}
+	s[name] = true
+	return s
+}
+
+// value returns the schema of a single value of the field f.
+func (d *openapiDoc) value(f *pb.FieldDescriptorProto) jsonObject {
+	if f.GetType() == pb.FieldDescriptorProto_TYPE_MESSAGE || f.GetType() == pb.FieldDescriptorProto_TYPE_GROUP {
+		return d.ref(f.GetTypeName())
+	}
+	s := d.scalar(f)
+	o := f.GetOptions()
+	if n := options.Uint32(o, options.E_MaxLength); n > 0 {
+		s["maxLength"] = n
+	}
+	if n := options.Uint32(o, options.E_MinLength); n > 0 {
+		s["minLength"] = n
+	}
+	if p := options.String(o, options.E_Pattern); p != "" {
+		s["pattern"] = p
+	}
+	if t := s["type"]; t == "integer" || t == "number" {
+		if options.Has(o, options.E_Min) {
+			s["minimum"] = options.Float64(o, options.E_Min)
+		}
+		if options.Has(o, options.E_Max) {
+			s["maximum"] = options.Float64(o, options.E_Max)
+		}
+	}
+	return s
+}
+
+// scalar returns the schema of a value of the scalar or enum field f, or
+// of the value of a wrapper.
+func (d *openapiDoc) scalar(f *pb.FieldDescriptorProto) jsonObject {
+	if isWrapper(f.GetTypeName()) {
+		return d.scalar(d.g.msgs[f.GetTypeName()].GetField()[0])
+	}
+	int64s := d.proto3 || d.g.flag("int64_strings")
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_INT32, pb.FieldDescriptorProto_TYPE_SINT32, pb.FieldDescriptorProto_TYPE_SFIXED32:
+		return jsonObject{"type": "integer", "format": "int32"}
+	case pb.FieldDescriptorProto_TYPE_UINT32, pb.FieldDescriptorProto_TYPE_FIXED32:
+		return jsonObject{"type": "integer", "format": "int64", "minimum": 0, "maximum": 1<<32 - 1}
+	case pb.FieldDescriptorProto_TYPE_INT64, pb.FieldDescriptorProto_TYPE_SINT64, pb.FieldDescriptorProto_TYPE_SFIXED64:
+		if int64s {
+			return jsonObject{"type": "string", "format": "int64"}
+		}
+		return jsonObject{"type": "integer", "format": "int64"}
+	case pb.FieldDescriptorProto_TYPE_UINT64, pb.FieldDescriptorProto_TYPE_FIXED64:
+		if int64s {
+			return jsonObject{"type": "string", "format": "uint64"}
+		}
+		return jsonObject{"type": "integer", "format": "uint64", "minimum": 0}
+	case pb.FieldDescriptorProto_TYPE_FLOAT:
+		return jsonObject{"type": "number", "format": "float"}
+	case pb.FieldDescriptorProto_TYPE_DOUBLE:
+		return jsonObject{"type": "number", "format": "double"}
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return jsonObject{"type": "boolean"}
+	case pb.FieldDescriptorProto_TYPE_BYTES:
+		return jsonObject{"type": "string", "format": "byte"}
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		return d.enum(f.GetTypeName())
+	}
+	return jsonObject{"type": "string"}
+}
+
+// enum returns the schema of a value of the enum name: its number, or its
+// name in the proto3 JSON mapping unless enums_as_ints is set.
+func (d *openapiDoc) enum(name string) jsonObject {
+	e := d.enums[name]
+	var names []string
+	var numbers []int32
+	var desc []string
+	if c := d.comments[name]; c != "" {
+		desc = append(desc, c)
+	}
+	for _, v := range e.GetValue() {
+		names = append(names, v.GetName())
+		numbers = append(numbers, v.GetNumber())
+		line := "- " + v.GetName() + " = " + strconv.Itoa(int(v.GetNumber()))
+		if c := d.comments[name+"."+v.GetName()]; c != "" {
+			line += ": " + c
+		}
+		desc = append(desc, line)
+	}
+	s := jsonObject{"type": "integer", "format": "int32", "enum": numbers, "description": strings.Join(desc, "\n")}
+	if d.proto3 && !d.g.flag("enums_as_ints") {
+		s = jsonObject{"type": "string", "enum": names, "description": strings.Join(desc, "\n")}
+	}
+	return s
+}
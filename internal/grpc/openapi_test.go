@@ -0,0 +1,90 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestOpenAPIComments(t *testing.T) {
+	loc := func(c string, path ...int32) *pb.SourceCodeInfo_Location {
+		return &pb.SourceCodeInfo_Location{Path: path, LeadingComments: proto.String(c)}
+	}
+	file := &pb.FileDescriptorProto{
+		Package: proto.String("pkg"),
+		MessageType: []*pb.DescriptorProto{{
+			Name:       proto.String("User"),
+			Field:      []*pb.FieldDescriptorProto{{Name: proto.String("id")}},
+			NestedType: []*pb.DescriptorProto{{Name: proto.String("Address")}},
+			EnumType:   []*pb.EnumDescriptorProto{{Name: proto.String("Role"), Value: []*pb.EnumValueDescriptorProto{{Name: proto.String("ADMIN")}}}},
+		}},
+		Service: []*pb.ServiceDescriptorProto{{Name: proto.String("Users"), Method: []*pb.MethodDescriptorProto{{Name: proto.String("Get")}}}},
+		SourceCodeInfo: &pb.SourceCodeInfo{Location: []*pb.SourceCodeInfo_Location{
+			loc(" A user.\n", 4, 0),
+			loc(" The id,\n unique.\n", 4, 0, 2, 0),
+			loc(" Where.\n", 4, 0, 3, 0),
+			loc(" Admins.\n", 4, 0, 4, 0, 2, 0),
+			loc(" Get a user.\n", 6, 0, 2, 0),
+		}},
+	}
+	d := &openapiDoc{comments: map[string]string{}, enums: map[string]*pb.EnumDescriptorProto{}}
+	d.index(file)
+	for name, want := range map[string]string{
+		".pkg.User":            "A user.",
+		".pkg.User.id":         "The id,\nunique.",
+		".pkg.User.Address":    "Where.",
+		".pkg.User.Role":       "",
+		".pkg.User.Role.ADMIN": "Admins.",
+		".pkg.Users.Get":       "Get a user.",
+	} {
+		if got := d.comments[name]; got != want {
+			t.Errorf("comment of %s = %q, want %q", name, got, want)
+		}
+	}
+	if d.enums[".pkg.User.Role"] == nil {
+		t.Error("enum .pkg.User.Role not indexed")
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"v1/{name=shelves/*/nodes/*}:publish": "v1/{name}:publish",
+		"v1/users/{user.id}/{page}":           "v1/users/{user.id}/{page}",
+	} {
+		if got := templateVar.ReplaceAllString(in, "{$1}"); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+}
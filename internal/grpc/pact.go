@@ -0,0 +1,67 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generatePact generates <Service>PactInteractions, an interaction of a
+// Pact contract per unary method of the service: its example request,
+// with examples, answered with its example response.
+func (g *grpc) generatePact(file *generator.FileDescriptor, servName string, service *pb.ServiceDescriptorProto) {
+	g.P("// ", servName, "PactInteractions returns an interaction per unary method of the")
+	g.P("// ", servName, " service, for the Pact contracts of its consumers, see")
+	g.P("// goweb.PactContract: the example request of the method answered with its")
+	g.P("// example response, or empty messages without the examples parameter.")
+	g.P("func ", servName, "PactInteractions() []goweb.PactInteraction {")
+	g.P("	return []goweb.PactInteraction{")
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P("{Route: _", servName, "_routes[", i, "], Request: ", g.pactMessage(file, method.GetInputType()),
+			", Response: ", g.pactMessage(file, method.GetOutputType()), "},")
+	}
+	g.P("	}")
+	g.P("}")
+	g.P()
+}
+
+// pactMessage returns the example of the message name, or a new one.
+func (g *grpc) pactMessage(file *generator.FileDescriptor, name string) string {
+	if example := g.exampleMessage(file, name, ""); g.flag("examples") && example != "" {
+		return example
+	}
+	return "&" + g.typeName(name) + "{}"
+}
@@ -0,0 +1,75 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// stringField returns the singular string field name of the message msg,
+// or nil.
+func (g *grpc) stringField(msg, name string) *pb.FieldDescriptorProto {
+	if f := g.field(msg, name); f.GetType() == pb.FieldDescriptorProto_TYPE_STRING {
+		return f
+	}
+	return nil
+}
+
+// generatePageTokens generates Encode<Service><Method>PageToken and
+// Decode<Service><Method>PageToken for the list methods of the service,
+// the unary methods with a page_token request field and a next_page_token
+// response field, see goweb.PageTokens.
+func (g *grpc) generatePageTokens(servName string, service *pb.ServiceDescriptorProto) {
+	for i, method := range service.Method {
+		in, out := method.GetInputType(), method.GetOutputType()
+		pageToken := g.stringField(in, "page_token")
+		if method.GetServerStreaming() || method.GetClientStreaming() || pageToken == nil || g.stringField(out, "next_page_token") == nil {
+			continue
+		}
+		name := servName + method.GetName()
+		inType := g.typeName(in)
+		g.P("// Encode", name, "PageToken returns the next_page_token of the ", method.GetName(), " method")
+		g.P("// of ", servName, " continuing the list requested with in after cursor, see")
+		g.P("// goweb.PageTokens.")
+		g.P("func Encode", name, "PageToken(tokens *goweb.PageTokens, in *", inType, ", cursor proto.Message) (string, error) {")
+		g.P("	return tokens.Encode(_", servName, "_routes[", i, "], in, cursor)")
+		g.P("}")
+		g.P()
+		g.P("// Decode", name, "PageToken decodes the page_token of in, a request of the")
+		g.P("// ", method.GetName(), " method of ", servName, ", into cursor, failing with a 400 error for tokens")
+		g.P("// of other lists, tampered or expired, see goweb.PageTokens.")
+		g.P("func Decode", name, "PageToken(tokens *goweb.PageTokens, in *", inType, ", cursor proto.Message) error {")
+		g.P("	return tokens.Decode(_", servName, "_routes[", i, "], in, in.", g.goField(in, pageToken).getter, "(), cursor)")
+		g.P("}")
+		g.P()
+	}
+}
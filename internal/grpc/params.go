@@ -0,0 +1,207 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"gopkg.in/yaml.v2"
+)
+
+// A paramConfig is the config file named by the config parameter, e.g.
+//
+//	{
+//	  "parameters": {"streams": true, "max_string": 4096},
+//	  "services": {
+//	    "pkg.Users": {
+//	      "parameters": {"fake": true},
+//	      "methods": {"GetUser": {"metering": true}}
+//	    }
+//	  }
+//	}
+//
+// or the same in YAML if its name ends in .yaml or .yml,
+//
+//	parameters: {streams: true, max_string: 4096}
+//	services:
+//	  pkg.Users:
+//	    parameters: {fake: true}
+//	    methods:
+//	      GetUser: {metering: true}
+//
+// Its parameters apply to every file, but the ones on the command line
+// take precedence; the parameters of a service and of a method override
+// them for that service or method.
+type paramConfig struct {
+	Parameters map[string]interface{}   `json:"parameters" yaml:"parameters"`
+	Services   map[string]serviceConfig `json:"services" yaml:"services"`
+}
+
+type serviceConfig struct {
+	Parameters map[string]interface{}            `json:"parameters" yaml:"parameters"`
+	Methods    map[string]map[string]interface{} `json:"methods" yaml:"methods"`
+}
+
+// fileParams are the parameters that only apply to whole files.
+var fileParams = map[string]bool{"config": true, "canonical": true, "examples": true, "max_repeated": true, "max_map": true, "max_string": true, "type_url_prefix": true, "router": true, "warmup": true, "openapi": true, "error_catalog": true, "static": true}
+
+// methodParams are the parameters that can be set per method.
+var methodParams = map[string]bool{"metering": true, "error_format": true, "deterministic_json": true, "pretty_json": true, "jsonp": true, "links": true, "proto3_json": true, "legacy_json": true, "response_meta": true, "arena": true, "emit_defaults": true, "enums_as_ints": true, "int64_strings": true, "finite_floats": true, "unknown_enums": true, "response_enums": true, "debug_errors": true, "max_depth": true, "unsupported_streams": true, "compact": true, "generics": true}
+
+// profiles are the parameter sets selected with the profile parameter.
+var profiles = map[string][]string{
+	// Handlers only.
+	"minimal": nil,
+	// Handlers, streams and an http client, with the helpers most services use.
+	"standard": {"streams", "client", "routes_endpoint", "version_endpoint", "server_timeouts", "error_helpers", "context_accessors"},
+	// Everything that does not need other transports (gRPC, GraphQL, queues).
+	"full": {"streams", "client", "test_server", "routes_endpoint", "version_endpoint", "server_timeouts", "error_helpers", "error_statuses",
+		"context_accessors", "wrap", "capture", "dead_letters", "shadow", "metering", "hot_config", "canonical",
+		"examples", "fake", "mock", "loadtest", "pact", "postman", "wasm", "page_tokens", "descriptors", "providers", "linkable", "conformance", "signed_urls", "hub", "runner"},
+}
+
+// applyProfile sets the parameters of the profile named by the profile
+// parameter that are not set otherwise, e.g. with profile=full,fake=false.
+func (g *grpc) applyProfile() {
+	name, ok := g.gen.Param["profile"]
+	if !ok {
+		return
+	}
+	params, ok := profiles[name]
+	if !ok {
+		g.gen.Fail("unknown profile", strconv.Quote(name), "(want minimal, standard or full)")
+	}
+	for _, p := range params {
+		if _, ok := g.gen.Param[p]; !ok {
+			g.gen.Param[p] = ""
+		}
+	}
+}
+
+// loadConfig reads the config file named by the config parameter, if any.
+func (g *grpc) loadConfig() {
+	path, ok := g.gen.Param["config"]
+	if !ok {
+		return
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		g.gen.Error(err, "reading the config file")
+	}
+	var c paramConfig
+	unmarshal := json.Unmarshal
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		unmarshal = yaml.Unmarshal
+	}
+	if err := unmarshal(b, &c); err != nil {
+		g.gen.Error(err, "parsing the config file", path)
+	}
+	for name, v := range g.params(c.Parameters) {
+		if _, ok := g.gen.Param[name]; !ok {
+			g.gen.Param[name] = v
+		}
+	}
+	g.services = map[string]map[string]string{}
+	g.methods = map[string]map[string]string{}
+	for service, sc := range c.Services {
+		g.services[service] = g.params(sc.Parameters)
+		for name := range g.services[service] {
+			if fileParams[name] {
+				g.gen.Fail("parameter", name, "of service", service, "in the config file only applies to whole files")
+			}
+		}
+		for method, params := range sc.Methods {
+			g.methods["/"+service+"/"+method] = g.params(params)
+			for name := range params {
+				if !methodParams[name] {
+					g.gen.Fail("parameter", name, "of method", service+"."+method, "in the config file does not apply to single methods")
+				}
+			}
+		}
+	}
+}
+
+// params converts the parameter values of a config file to their
+// command line form.
+func (g *grpc) params(values map[string]interface{}) map[string]string {
+	params := map[string]string{}
+	for name, v := range values {
+		switch v := v.(type) {
+		case bool:
+			params[name] = strconv.FormatBool(v)
+		case float64:
+			params[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case int:
+			params[name] = strconv.Itoa(v)
+		case string:
+			params[name] = v
+		default:
+			g.gen.Fail("parameter", name, "in the config file is not a bool, number or string")
+		}
+	}
+	return params
+}
+
+// param returns the value of the plugin parameter name for the method and
+// service being generated, if set.
+func (g *grpc) param(name string) (string, bool) {
+	if v, ok := g.methods[g.method][name]; ok {
+		return v, true
+	}
+	if v, ok := g.services[g.serviceName][name]; ok {
+		return v, true
+	}
+	v, ok := g.gen.Param[name]
+	return v, ok
+}
+
+// anyFlag reports whether the boolean parameter name is set for file or
+// any of its services, e.g. for imports.
+func (g *grpc) anyFlag(file *generator.FileDescriptor, name string) bool {
+	if g.flag(name) {
+		return true
+	}
+	for _, service := range file.Service {
+		fullName := service.GetName()
+		if pkg := file.GetPackage(); pkg != "" {
+			fullName = pkg + "." + fullName
+		}
+		if v, ok := g.services[fullName][name]; ok && v != "false" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// configFile has two services.
+func configFile() *pb.FileDescriptorProto {
+	return testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User")),
+		service("Groups", method("Get", "User", "User")),
+	)
+}
+
+func TestConfig(t *testing.T) {
+	for name, config := range map[string]string{
+		"goweb.json": `{
+  "parameters": {"client": true, "max_string": 4096},
+  "services": {"pkg.Users": {"parameters": {"test_server": true}}}
+}`,
+		"goweb.yaml": `
+parameters: {client: true, max_string: 4096}
+services:
+  pkg.Users:
+    parameters: {test_server: true}
+`,
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		src := generateMux(t, "config="+path, configFile())
+		for _, fn := range []string{"NewUsersHTTPClient", "NewGroupsHTTPClient", "NewTestUsersServer"} {
+			decl(t, src, fn)
+		}
+		if strings.Contains(src, "NewTestGroupsServer") {
+			t.Errorf("%s: service parameter applied to another service", name)
+		}
+		if !strings.Contains(decl(t, src, "_checkLimits_pkg_User"), "len(m.GetName()) > 4096") {
+			t.Errorf("%s: max_string not applied", name)
+		}
+	}
+}
+
+func TestConfigFileParam(t *testing.T) {
+	for _, param := range []string{"max_string: 10", "static: web"} {
+		path := filepath.Join(t.TempDir(), "goweb.yml")
+		if err := ioutil.WriteFile(path, []byte("services: {pkg.Users: {parameters: {"+param+"}}}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := generateError(t, "config="+path, configFile()); !strings.Contains(err, "only applies to whole files") {
+			t.Errorf("%s: error = %s", param, err)
+		}
+	}
+}
+
+func TestProfile(t *testing.T) {
+	if src := generateMux(t, "profile=minimal", configFile()); strings.Contains(src, "UsersHTTPClient") || strings.Contains(src, "_routes\"), goweb.RoutesHandler") {
+		t.Error("minimal profile generated more than handlers")
+	}
+	src := generateMux(t, "profile=standard,client=false", configFile())
+	if !strings.Contains(src, `router.Get(goweb.JoinPath(prefix, "_routes"), goweb.RoutesHandler(_Users_routes))`) {
+		t.Error("standard profile without routes endpoint")
+	}
+	if strings.Contains(src, "UsersHTTPClient") {
+		t.Error("client=false did not override the profile")
+	}
+	if _, err := generate(t, "profile=full", configFile()); err != nil {
+		t.Errorf("full profile: %v", err)
+	}
+	if err := generateError(t, "profile=huge", configFile()); !strings.Contains(err, `unknown profile "huge"`) {
+		t.Errorf("error = %s", err)
+	}
+}
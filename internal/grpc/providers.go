@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+// generateProviders generates the providers of the mux and of the http
+// client of the service for dependency injection frameworks such as wire
+// and fx: <Service>ProviderConfig, <Service>Handler, Provide<Service>Handler,
+// Provide<Service>HTTPClient and <Service>Providers. The config and the
+// handler have types of their own, since the frameworks tell the
+// dependencies apart by their types.
+func (g *grpc) generateProviders(servName string) {
+	client := g.flag("client") || g.flag("test_server")
+	config := servName + "ProviderConfig"
+	handler := servName + "Handler"
+	g.P("// ", config, " configures the providers of the ", servName, " service for")
+	g.P("// dependency injection, e.g. given with wire.Value or fx.Supply.")
+	g.P("type ", config, " struct {")
+	g.P("	Prefix  string               // of the mux, see New", servName, "Mux")
+	g.P("	Options []goweb.ServerOption // of the mux")
+	if client {
+		g.P("	Upstream *goweb.Upstream // called by the http client, see New", servName, "HTTPClient")
+	}
+	g.P("}")
+	g.P()
+	g.P("// ", handler, " is the mux of the ", servName, " service as Provide", handler)
+	g.P("// provides it, with a type of its own so that the muxes of several")
+	g.P("// services can be injected side by side.")
+	g.P("type ", handler, " struct{ http.Handler }")
+	g.P()
+	g.P("// Provide", handler, " returns the mux of the ", servName, " service serving h,")
+	g.P("// or the errors of Validate", servName, "Mux, so that they fail the start of the")
+	g.P("// application.")
+	g.P("func Provide", handler, "(h ", servName, "Server, c ", config, ") (", handler, ", error) {")
+	g.P("	if err := Validate", servName, "Mux(c.Prefix, c.Options...); err != nil {")
+	g.P("		return ", handler, "{}, err")
+	g.P("	}")
+	g.P("	return ", handler, "{New", servName, "Mux(h, c.Prefix, c.Options...)}, nil")
+	g.P("}")
+	g.P()
+	providers := "Provide" + handler
+	if client {
+		g.P("// Provide", servName, "HTTPClient returns the http client of the ", servName, " service")
+		g.P("// calling c.Upstream, or goweb.ErrNoUpstream.")
+		g.P("func Provide", servName, "HTTPClient(c ", config, ") (", servName, "HTTPClient, error) {")
+		g.P("	if c.Upstream == nil {")
+		g.P("		return nil, goweb.ErrNoUpstream")
+		g.P("	}")
+		g.P("	return New", servName, "HTTPClient(c.Upstream), nil")
+		g.P("}")
+		g.P()
+		providers += ", Provide" + servName + "HTTPClient"
+	}
+	g.P("// ", servName, "Providers are the providers of the ", servName, " service, e.g. for")
+	g.P("// fx.Provide(", servName, "Providers...); wire takes them one by one, as in")
+	g.P("// wire.NewSet(", providers, ").")
+	g.P("var ", servName, "Providers = []interface{}{", providers, "}")
+	g.P()
+}
@@ -0,0 +1,69 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestProviders(t *testing.T) {
+	file := testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User")),
+	)
+	src := generateMux(t, "providers,client", file)
+	checkDecl(t, src, "ProvideUsersHandler", `
+func ProvideUsersHandler(h UsersServer, c UsersProviderConfig) (UsersHandler, error) {
+	if err := ValidateUsersMux(c.Prefix, c.Options...); err != nil {
+		return UsersHandler{}, err
+	}
+	return UsersHandler{NewUsersMux(h, c.Prefix, c.Options...)}, nil
+}`)
+	checkDecl(t, src, "ProvideUsersHTTPClient", `
+func ProvideUsersHTTPClient(c UsersProviderConfig) (UsersHTTPClient, error) {
+	if c.Upstream == nil {
+		return nil, goweb.ErrNoUpstream
+	}
+	return NewUsersHTTPClient(c.Upstream), nil
+}`)
+	checkDecl(t, src, "UsersProviders", `
+var UsersProviders = []interface{}{ProvideUsersHandler, ProvideUsersHTTPClient}`)
+
+	// without the client, there is nothing to provide it with
+	src = generateMux(t, "providers", file)
+	if strings.Contains(src, "ProvideUsersHTTPClient") || strings.Contains(decl(t, src, "UsersProviderConfig"), "Upstream") {
+		t.Error("client providers without the client parameter")
+	}
+}
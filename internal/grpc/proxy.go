@@ -0,0 +1,145 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateGRPCProxy generates New<Service>ProxyMux, which serves the service
+// over HTTP by forwarding every call to an upstream gRPC server through the
+// <Service>Client that protoc-gen-go generates with plugins=grpc.
+func (g *grpc) generateGRPCProxy(servName string, service *pb.ServiceDescriptorProto) {
+	serverType := servName + "Server"
+	proxyType := "_" + servName + "Proxy"
+
+	g.P("// New", servName, "ProxyMux returns a mux serving the ", servName, " service")
+	g.P("// by forwarding each call to the gRPC server at the other end of conn.")
+	g.P("// gRPC status errors are answered like the *goweb.Error of ", servName, "StatusToError.")
+	g.P("func New", servName, "ProxyMux(conn *", grpcPkg, ".ClientConn, prefix string) ", g.router().mux, " {")
+	g.P("	return New", servName, "Mux(&", proxyType, "{New", servName, "Client(conn)}, prefix)")
+	g.P("}")
+	g.P()
+	g.P("type ", proxyType, " struct {")
+	g.P("	client ", servName, "Client")
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverType, " = (*", proxyType, ")(nil)")
+	g.P()
+	for _, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			g.P("func (p *", proxyType, ") ", g.generateServerSignature(servName, method), " {")
+			g.P("	return goweb.ErrStreamingNotSupported")
+			g.P("}")
+			g.P()
+			continue
+		}
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("func (p *", proxyType, ") ", methName, "(ctx ", contextPkg, ".Context, in *", inType, ") (*", outType, ", error) {")
+		g.P("	out, err := p.client.", methName, "(ctx, in)")
+		g.P("	return out, ", servName, "StatusToError(err)")
+		g.P("}")
+		g.P()
+	}
+}
+
+// generateHTTPProxy generates New<Service>HTTPProxyMux, which decodes each
+// call like the regular mux and forwards it to an upstream JSON over HTTP
+// service through a goweb.Upstream.
+func (g *grpc) generateHTTPProxy(servName string, service *pb.ServiceDescriptorProto) {
+	serverType := servName + "Server"
+	proxyType := "_" + servName + "HTTPProxy"
+
+	g.P("// New", servName, "HTTPProxyMux returns a mux serving the ", servName, " service")
+	g.P("// by forwarding each decoded call to the upstream HTTP service u.")
+	g.P("func New", servName, "HTTPProxyMux(u *goweb.Upstream, prefix string) ", g.router().mux, " {")
+	g.P("	return New", servName, "Mux(&", proxyType, "{u}, prefix)")
+	g.P("}")
+	g.P()
+	g.P("type ", proxyType, " struct {")
+	g.P("	upstream *goweb.Upstream")
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverType, " = (*", proxyType, ")(nil)")
+	g.P()
+	for i, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			g.P("func (p *", proxyType, ") ", g.generateServerSignature(servName, method), " {")
+			g.P("	return goweb.ErrStreamingNotSupported")
+			g.P("}")
+			g.P()
+			continue
+		}
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("func (p *", proxyType, ") ", methName, "(ctx ", contextPkg, ".Context, in *", inType, ") (*", outType, ", error) {")
+		g.P("	out := new(", outType, ")")
+		g.P("	if err := p.upstream.Call(ctx, _", servName, "_routes[", i, "], in, out); err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		g.P("	return out, nil")
+		g.P("}")
+		g.P()
+	}
+}
+
+// generateDual generates New<Service>Dual, which serves the service both
+// over gRPC and JSON/http1. The gRPC server also gets the server reflection
+// and health services, so grpcurl and load balancers work out of the box.
+func (g *grpc) generateDual(file *generator.FileDescriptor, servName string, service *pb.ServiceDescriptorProto) {
+	fullServName := service.GetName()
+	if pkg := file.GetPackage(); pkg != "" {
+		fullServName = pkg + "." + fullServName
+	}
+	g.P("// New", servName, "Dual returns a gRPC server and an HTTP mux both serving h.")
+	g.P("// The gRPC server also serves reflection and the standard health service,")
+	g.P("// which reports ", fullServName, " as SERVING. Errors keep their code and details")
+	g.P("// on both: *goweb.Error values of h become gRPC status errors on the gRPC")
+	g.P("// server and status errors become *goweb.Error values on the mux.")
+	g.P("func New", servName, "Dual(h ", servName, "Server, prefix string, opts ...", grpcPkg, ".ServerOption) (*", grpcPkg, ".Server, ", g.router().mux, ") {")
+	g.P("	s := ", grpcPkg, ".NewServer(opts...)")
+	g.P("	Register", servName, "Server(s, _", servName, "ErrorMapper{h, ", servName, "ErrorToStatus})")
+	g.P("	reflection.Register(s)")
+	g.P("	hs := health.NewServer()")
+	g.P("	hs.SetServingStatus(", strconv.Quote(fullServName), ", healthpb.HealthCheckResponse_SERVING)")
+	g.P("	healthpb.RegisterHealthServer(s, hs)")
+	g.P("	return s, New", servName, "Mux(_", servName, "ErrorMapper{h, ", servName, "StatusToError}, prefix)")
+	g.P("}")
+	g.P()
+	g.generateErrorMapper(servName, service)
+}
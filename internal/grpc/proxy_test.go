@@ -0,0 +1,110 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// proxyFile has a service with a unary and a server-streaming method.
+func proxyFile() *pb.FileDescriptorProto {
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	return testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", method("Get", "User", "User"), watch),
+	)
+}
+
+func TestGRPCProxy(t *testing.T) {
+	src := generateMux(t, "grpc_proxy", proxyFile())
+	checkDecl(t, src, "NewUsersProxyMux", `
+func NewUsersProxyMux(conn *grpc.ClientConn, prefix string) *web.Mux {
+	return NewUsersMux(&_UsersProxy{NewUsersClient(conn)}, prefix)
+}`)
+	checkDecl(t, src, "(*_UsersProxy).Get", `
+func (p *_UsersProxy) Get(ctx context.Context, in *User) (*User, error) {
+	out, err := p.client.Get(ctx, in)
+	return out, UsersStatusToError(err)
+}`)
+	checkDecl(t, src, "(*_UsersProxy).Watch", `
+func (p *_UsersProxy) Watch(*User, Users_WatchServer) error {
+	return goweb.ErrStreamingNotSupported
+}`)
+}
+
+func TestHTTPProxy(t *testing.T) {
+	src := generateMux(t, "http_proxy", proxyFile())
+	checkDecl(t, src, "NewUsersHTTPProxyMux", `
+func NewUsersHTTPProxyMux(u *goweb.Upstream, prefix string) *web.Mux {
+	return NewUsersMux(&_UsersHTTPProxy{u}, prefix)
+}`)
+	checkDecl(t, src, "(*_UsersHTTPProxy).Get", `
+func (p *_UsersHTTPProxy) Get(ctx context.Context, in *User) (*User, error) {
+	out := new(User)
+	if err := p.upstream.Call(ctx, _Users_routes[0], in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}`)
+	checkDecl(t, src, "(*_UsersHTTPProxy).Watch", `
+func (p *_UsersHTTPProxy) Watch(*User, Users_WatchServer) error {
+	return goweb.ErrStreamingNotSupported
+}`)
+}
+
+func TestDual(t *testing.T) {
+	src := generateMux(t, "grpc_dual", proxyFile())
+	checkDecl(t, src, "NewUsersDual", `
+func NewUsersDual(h UsersServer, prefix string, opts ...grpc.ServerOption) (*grpc.Server, *web.Mux) {
+	s := grpc.NewServer(opts...)
+	RegisterUsersServer(s, _UsersErrorMapper{h, UsersErrorToStatus})
+	reflection.Register(s)
+	hs := health.NewServer()
+	hs.SetServingStatus("pkg.Users", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	return s, NewUsersMux(_UsersErrorMapper{h, UsersStatusToError}, prefix)
+}`)
+	for _, imp := range []string{
+		`"google.golang.org/grpc/health"`,
+		`healthpb "google.golang.org/grpc/health/grpc_health_v1"`,
+		`"google.golang.org/grpc/reflection"`,
+	} {
+		if !strings.Contains(src, imp) {
+			t.Errorf("no import %s", imp)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateQueue generates Subscribe<Service>, which binds every unary
+// method to a request/reply subject of a message queue such as NATS.
+func (g *grpc) generateQueue(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	g.P("// Subscribe", servName, " serves the unary methods of the ", servName, " service over")
+	g.P("// a request/reply message queue, one subject per method: the subject prefix")
+	g.P("// followed by the full method name, e.g. prefix+", strconv.Quote(routes[0].Service+"."+routes[0].Method), ".")
+	g.P("// Requests and replies are encoded with codec.")
+	g.P("func Subscribe", servName, "(conn goweb.QueueConn, h ", servName, "Server, prefix string, codec goweb.Codec) error {")
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		g.P("if err := conn.Subscribe(prefix+", strconv.Quote(routes[i].Service+"."+routes[i].Method), ", func(ctx ", contextPkg, ".Context, data []byte) ([]byte, error) {")
+		g.P("	in := new(", g.typeName(method.GetInputType()), ")")
+		g.P("	if err := codec.Unmarshal(data, in); err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		g.P("	out, err := h.", methName, "(ctx, in)")
+		g.P("	if err != nil {")
+		g.P("		return nil, err")
+		g.P("	}")
+		g.generateResponseFilter(method, "out")
+		g.P("	return codec.Marshal(out)")
+		g.P("}); err != nil {")
+		g.P("	return err")
+		g.P("}")
+	}
+	g.P("	return nil")
+	g.P("}")
+	g.P()
+}
+
+// isEvent reports whether method is an event handler: a unary method marked
+// with the goweb.event option whose response message has no fields.
+func (g *grpc) isEvent(method *pb.MethodDescriptorProto) bool {
+	if method.GetServerStreaming() || method.GetClientStreaming() || !options.Bool(method.GetOptions(), options.E_Event) {
+		return false
+	}
+	out := g.msgs[method.GetOutputType()]
+	return out != nil && len(out.Field) == 0
+}
+
+// generateEvents generates Consume<Service>Events, which feeds the events
+// of one topic per event method into the handler, and Publish<Service>
+// <Method>Event, which emits one. The event types ("pkg.Service.Method")
+// are registered for goweb.CloudEvent.
+func (g *grpc) generateEvents(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	g.P("// Consume", servName, "Events feeds the events consumed from c into the event")
+	g.P("// methods of the ", servName, " service, one topic per method: the topic prefix")
+	g.P("// followed by the full method name. Events are decoded with codec.")
+	g.P("func Consume", servName, "Events(c goweb.EventConsumer, h ", servName, "Server, prefix string, codec goweb.Codec) error {")
+	for i, method := range service.Method {
+		if !g.isEvent(method) {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		g.P("if err := c.Consume(prefix+", strconv.Quote(routes[i].Service+"."+routes[i].Method), ", func(ctx ", contextPkg, ".Context, key, value []byte) error {")
+		g.P("	in := new(", g.typeName(method.GetInputType()), ")")
+		g.P("	if err := codec.Unmarshal(value, in); err != nil {")
+		g.P("		return err")
+		g.P("	}")
+		g.P("	_, err := h.", methName, "(ctx, in)")
+		g.P("	return err")
+		g.P("}); err != nil {")
+		g.P("	return err")
+		g.P("}")
+	}
+	g.P("	return nil")
+	g.P("}")
+	g.P()
+	var types []string
+	for i, method := range service.Method {
+		if !g.isEvent(method) {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		typ := strconv.Quote(routes[i].Service + "." + routes[i].Method)
+		types = append(types, "goweb.RegisterEventType("+typ+", (*"+g.typeName(method.GetInputType())+")(nil))")
+		g.P("// Publish", servName, methName, "Event publishes in to the topic of the ", methName, " event")
+		g.P("// method of the ", servName, " service, encoded with codec; with")
+		g.P("// goweb.CloudEventsCodec as a CloudEvent of type ", typ, ".")
+		g.P("func Publish", servName, methName, "Event(ctx ", contextPkg, ".Context, p goweb.EventPublisher, prefix string, codec goweb.Codec, in *", g.typeName(method.GetInputType()), ") error {")
+		g.P("	value, err := goweb.MarshalEvent(codec, ", typ, ", in)")
+		g.P("	if err != nil {")
+		g.P("		return err")
+		g.P("	}")
+		g.P("	return p.Publish(ctx, prefix+", typ, ", nil, value)")
+		g.P("}")
+		g.P()
+	}
+	if len(types) > 0 {
+		g.P("func init() {")
+		for _, t := range types {
+			g.P(t)
+		}
+		g.P("}")
+		g.P()
+	}
+}
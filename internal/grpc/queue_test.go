@@ -0,0 +1,123 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// queueFile has a service with a unary, an event and a streaming method.
+func queueFile(t *testing.T) *pb.FileDescriptorProto {
+	created := method("Created", "User", "Ack")
+	created.Options = &pb.MethodOptions{}
+	if err := proto.SetExtension(created.Options, options.E_Event, proto.Bool(true)); err != nil {
+		t.Fatal(err)
+	}
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	return testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		message("Ack"),
+		service("Users", method("Get", "User", "User"), created, watch),
+	)
+}
+
+func TestQueue(t *testing.T) {
+	src := generateMux(t, "queue", queueFile(t))
+	checkDecl(t, src, "SubscribeUsers", `
+func SubscribeUsers(conn goweb.QueueConn, h UsersServer, prefix string, codec goweb.Codec) error {
+	if err := conn.Subscribe(prefix+"pkg.Users.Get", func(ctx context.Context, data []byte) ([]byte, error) {
+		in := new(User)
+		if err := codec.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		out, err := h.Get(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Marshal(out)
+	}); err != nil {
+		return err
+	}
+	if err := conn.Subscribe(prefix+"pkg.Users.Created", func(ctx context.Context, data []byte) ([]byte, error) {
+		in := new(User)
+		if err := codec.Unmarshal(data, in); err != nil {
+			return nil, err
+		}
+		out, err := h.Created(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Marshal(out)
+	}); err != nil {
+		return err
+	}
+	return nil
+}`)
+}
+
+func TestEvents(t *testing.T) {
+	src := generateMux(t, "events", queueFile(t))
+	checkDecl(t, src, "ConsumeUsersEvents", `
+func ConsumeUsersEvents(c goweb.EventConsumer, h UsersServer, prefix string, codec goweb.Codec) error {
+	if err := c.Consume(prefix+"pkg.Users.Created", func(ctx context.Context, key, value []byte) error {
+		in := new(User)
+		if err := codec.Unmarshal(value, in); err != nil {
+			return err
+		}
+		_, err := h.Created(ctx, in)
+		return err
+	}); err != nil {
+		return err
+	}
+	return nil
+}`)
+	checkDecl(t, src, "PublishUsersCreatedEvent", `
+func PublishUsersCreatedEvent(ctx context.Context, p goweb.EventPublisher, prefix string, codec goweb.Codec, in *User) error {
+	value, err := goweb.MarshalEvent(codec, "pkg.Users.Created", in)
+	if err != nil {
+		return err
+	}
+	return p.Publish(ctx, prefix+"pkg.Users.Created", nil, value)
+}`)
+	if !strings.Contains(src, `goweb.RegisterEventType("pkg.Users.Created", (*User)(nil))`) {
+		t.Error("event type not registered")
+	}
+	if strings.Contains(src, "PublishUsersGetEvent") {
+		t.Error("event publisher for a method without the event option")
+	}
+}
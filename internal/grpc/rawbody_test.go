@@ -0,0 +1,87 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// rawBodyFile has a method with the raw_body option, bound to the HTTP
+// rule rule if it is not nil.
+func rawBodyFile(rule *options.HttpRule) *pb.FileDescriptorProto {
+	sign := method("Sign", "Signed", "Signed")
+	sign.Options = &pb.MethodOptions{}
+	if err := proto.SetExtension(sign.Options, options.E_RawBody, proto.Bool(true)); err != nil {
+		panic(err)
+	}
+	if rule != nil {
+		if err := proto.SetExtension(sign.Options, options.E_Http, rule); err != nil {
+			panic(err)
+		}
+	}
+	return testFile("sign.proto",
+		message("Inner", field("text", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		message("Signed", field("inner", 1, pb.FieldDescriptorProto_TYPE_MESSAGE, ".pkg.Inner")),
+		service("Signer", sign),
+	)
+}
+
+// The implementation gets the body as received, not as decoded.
+func TestRawBody(t *testing.T) {
+	src := generateMux(t, "", rawBodyFile(nil))
+	handler := decl(t, src, "(*_SignerServer).Sign")
+	for _, want := range []string{"content, err := impl.opts.ReadBody(w, r)", "rawBody := content", "ctx = goweb.WithRawBody(ctx, rawBody)"} {
+		if !strings.Contains(handler, want) {
+			t.Errorf("handler has no %s:\n%s", want, handler)
+		}
+	}
+}
+
+// The body of a google.api.http rule bound to a field is wrapped into an
+// object for decoding; the implementation still gets it unwrapped.
+func TestRawBodyField(t *testing.T) {
+	src := generateMux(t, "", rawBodyFile(&options.HttpRule{Post: "/v1/sign", Body: "inner"}))
+	handler := decl(t, src, "(*_SignerServer).Sign")
+	raw := strings.Index(handler, "rawBody := content")
+	wrap := strings.Index(handler, `content = goweb.WrapBody("inner", content)`)
+	if raw < 0 || wrap < 0 || raw > wrap {
+		t.Errorf("raw body not kept before the body is wrapped:\n%s", handler)
+	}
+	if !strings.Contains(handler, "ctx = goweb.WithRawBody(ctx, rawBody)") {
+		t.Errorf("handler does not pass on the raw body:\n%s", handler)
+	}
+}
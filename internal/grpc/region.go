@@ -0,0 +1,72 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateRegion generates the pre-dispatch check of a method with a
+// region_field, which hands calls of other regions to goweb.RouteRegion.
+func (g *grpc) generateRegion(method *pb.MethodDescriptorProto, route goweb.Route) {
+	name := options.String(method.GetOptions(), options.E_RegionField)
+	if name == "" {
+		name = options.String(g.service.GetOptions(), options.E_ServiceRegionField)
+	}
+	if name == "" {
+		return
+	}
+	var field *pb.FieldDescriptorProto
+	for _, f := range g.msgs[method.GetInputType()].GetField() {
+		if f.GetName() == name {
+			field = f
+		}
+	}
+	if field == nil || field.GetType() != pb.FieldDescriptorProto_TYPE_STRING || field.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		g.gen.Fail("region_field", strconv.Quote(name), "of", route.FullMethod(), "is not a singular string field of", method.GetInputType())
+	}
+	index := 0
+	for i, m := range g.service.Method {
+		if m == method {
+			index = i
+		}
+	}
+	servName := generator.CamelCase(g.service.GetName())
+	g.P("	if goweb.RouteRegion(w, r, _", servName, "_routes[", index, "], in.", g.goField(method.GetInputType(), field).getter, "(), content) {")
+	g.P("		return")
+	g.P("	}")
+}
@@ -0,0 +1,101 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"path"
+	"sort"
+	"strconv"
+)
+
+// A router is a routing backend of the generated muxes, selected with the
+// router parameter. Its goweb.Router does the routing and the extraction
+// of the path variables; the generated code only differs in the imports,
+// the type New<Service>Mux returns and how the goweb.Router is created.
+type router struct {
+	imports map[string]string // import paths by name, relative to the import prefix
+	mux     string            // the type New<Service>Mux returns, the Mux of the goweb.Router
+	ref     string            // a reference to the imports, to suppress errors if unused
+	new     string            // the expression creating the goweb.Router
+}
+
+// routers are the values of the router parameter.
+var routers = map[string]router{
+	"goji": {
+		imports: map[string]string{"web": "github.com/zenazn/goji/web"},
+		mux:     "*web.Mux",
+		ref:     "web.C",
+		new:     "goweb.NewGojiRouter(web.New())",
+	},
+	"stdlib": {
+		mux: "*http.ServeMux",
+		new: "goweb.NewStdRouter(http.NewServeMux())",
+	},
+	"chi": {
+		imports: map[string]string{"chi": "github.com/go-chi/chi/v5", "gowebchi": gowebPkgPath + "/gowebchi"},
+		mux:     "chi.Router",
+		ref:     "chi.Router",
+		new:     "gowebchi.New(chi.NewRouter())",
+	},
+	"gorilla": {
+		imports: map[string]string{"mux": "github.com/gorilla/mux", "gowebmux": gowebPkgPath + "/gowebmux"},
+		mux:     "*mux.Router",
+		ref:     "mux.Router",
+		new:     "gowebmux.New(mux.NewRouter())",
+	},
+}
+
+// router returns the backend of the router parameter, goji by default.
+func (g *grpc) router() router {
+	name := g.gen.Param["router"]
+	if name == "" {
+		name = "goji"
+	}
+	r, ok := routers[name]
+	if !ok {
+		g.gen.Fail("parameter router must be goji, stdlib, chi or gorilla, not", strconv.Quote(name))
+	}
+	return r
+}
+
+// generateRouterImports generates the imports of the router backend.
+func (g *grpc) generateRouterImports() {
+	imports := g.router().imports
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.P(name, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, imports[name])))
+	}
+}
@@ -0,0 +1,76 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestRoutes(t *testing.T) {
+	get := method("Get", "User", "User")
+	get.Options = &pb.MethodOptions{Deprecated: proto.Bool(true)}
+	for ext, v := range map[*proto.ExtensionDesc]interface{}{
+		options.E_TimeoutSeconds:  proto.Uint32(5),
+		options.E_SloAvailability: proto.Float64(99.9),
+		options.E_Middleware:      []string{"auth"},
+	} {
+		if err := proto.SetExtension(get.Options, ext, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := generateMux(t, "routes_endpoint", testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", get),
+	))
+	route := decl(t, src, "_Users_routes")
+	for _, want := range []string{
+		`Service: "pkg.Users",`,
+		`Path:    "users/get",`,
+		`Options: map[string]interface{}{"deprecated": true, "goweb.middleware": []string{"auth"}, "goweb.slo_availability": float64(99.9), "goweb.timeout_seconds": uint32(5)},`,
+	} {
+		if !strings.Contains(route, want) {
+			t.Errorf("routes\n%s\nlack %s", route, want)
+		}
+	}
+	if !strings.Contains(src, `router.Get(goweb.JoinPath(prefix, "_routes"), goweb.RoutesHandler(_Users_routes))`) {
+		t.Error("no routes endpoint")
+	}
+	checkDecl(t, src, "UsersRoutes", `
+func UsersRoutes() []goweb.Route {
+	return append([]goweb.Route(nil), _Users_routes...)
+}`)
+}
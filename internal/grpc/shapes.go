@@ -0,0 +1,149 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateBadRequest generates the part of a handler answering err, an
+// error decoding or checking the request in, with 400: its message as
+// text, or, with the debug_errors parameter, a goweb.DebugError
+// describing the JSON of the offending field.
+func (g *grpc) generateBadRequest(method *pb.MethodDescriptorProto, logErr bool) {
+	if g.flag("debug_errors") {
+		g.P("		impl.opts.WriteError(w, r, goweb.DebugError(err, ", g.in(), ", ", g.shapesVar(method.GetInputType()), "), goweb.", g.errorWriter(), ")")
+		g.P("		return")
+		return
+	}
+	g.P("		w.WriteHeader(400)")
+	g.P("		w.Write([]byte(err.Error()))")
+	if logErr {
+		g.P("		log.Println(err.Error())")
+	}
+	g.P("		return")
+}
+
+// shapesVar returns the name of the map of the descriptions of the fields
+// of the message name and of the messages nested in it, see
+// goweb.DebugError, and queues its generation at the end of the file.
+func (g *grpc) shapesVar(name string) string {
+	v := "_shapes" + mangle(name)
+	if !g.passFuncs[v] {
+		g.passFuncs[v] = true
+		g.passQueue = append(g.passQueue, func() { g.generateShapes(name, v) })
+	}
+	return v
+}
+
+func (g *grpc) generateShapes(name, v string) {
+	g.P("var ", v, " = map[string]string{")
+	seen := map[string]bool{name: true}
+	for queue := []string{name}; len(queue) > 0; queue = queue[1:] {
+		msg := queue[0]
+		for _, f := range g.msgs[msg].GetField() {
+			g.P(strconv.Quote(msg[1:]+"."+f.GetName()), ": ", strconv.Quote(g.fieldShape(f)), ",")
+			if m := g.fieldMessage(f); m != "" && !seen[m] {
+				seen[m] = true
+				queue = append(queue, m)
+			}
+		}
+	}
+	g.P("}")
+	g.P()
+}
+
+// fieldShape describes the JSON the field f takes, e.g. "an array, each
+// element being a string".
+func (g *grpc) fieldShape(f *pb.FieldDescriptorProto) string {
+	if entry := g.msgs[f.GetTypeName()]; entry.GetOptions().GetMapEntry() {
+		for _, v := range entry.GetField() {
+			if v.GetNumber() == 2 {
+				return "an object of string keys, each value being " + g.valueShape(v)
+			}
+		}
+	}
+	if f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED {
+		return "an array, each element being " + g.valueShape(f)
+	}
+	return g.valueShape(f)
+}
+
+// valueShape describes the JSON of a single value of the field f.
+func (g *grpc) valueShape(f *pb.FieldDescriptorProto) string {
+	switch f.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		return "a string"
+	case pb.FieldDescriptorProto_TYPE_BYTES:
+		return "a base64 string"
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return "true or false"
+	case pb.FieldDescriptorProto_TYPE_INT32, pb.FieldDescriptorProto_TYPE_SINT32, pb.FieldDescriptorProto_TYPE_SFIXED32:
+		return "an integer"
+	case pb.FieldDescriptorProto_TYPE_UINT32, pb.FieldDescriptorProto_TYPE_FIXED32:
+		return "a non-negative integer"
+	case pb.FieldDescriptorProto_TYPE_INT64, pb.FieldDescriptorProto_TYPE_SINT64, pb.FieldDescriptorProto_TYPE_SFIXED64:
+		return "an integer, as a number or a string"
+	case pb.FieldDescriptorProto_TYPE_UINT64, pb.FieldDescriptorProto_TYPE_FIXED64:
+		return "a non-negative integer, as a number or a string"
+	case pb.FieldDescriptorProto_TYPE_FLOAT, pb.FieldDescriptorProto_TYPE_DOUBLE:
+		return `a number, or "NaN", "Infinity" or "-Infinity"`
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		var names []string
+		if enum, ok := g.gen.ObjectNamed(f.GetTypeName()).(*generator.EnumDescriptor); ok {
+			for _, v := range enum.Value {
+				names = append(names, strconv.Quote(v.GetName()))
+			}
+		}
+		return "one of " + strings.Join(names, ", ") + " of " + f.GetTypeName()[1:] + ", or its number"
+	}
+	switch f.GetTypeName() {
+	case ".google.protobuf.Timestamp":
+		return `an object like {"seconds": 1700000000, "nanos": 0}, seconds since 1970-01-01T00:00:00Z`
+	case ".google.protobuf.Duration":
+		return `an object like {"seconds": 30, "nanos": 0}`
+	case ".google.protobuf.Any":
+		return `an object with the type URL of a message as "@type" and its fields`
+	}
+	var fields []string
+	for _, m := range g.msgs[f.GetTypeName()].GetField() {
+		fields = append(fields, strconv.Quote(m.GetName()))
+	}
+	if len(fields) == 0 {
+		return "an object of " + f.GetTypeName()[1:]
+	}
+	return "an object of " + f.GetTypeName()[1:] + " with the fields " + strings.Join(fields, ", ")
+}
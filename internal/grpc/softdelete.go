@@ -0,0 +1,114 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// softDeleteFlagNames are the bool request fields of AIP-164 and AIP-163
+// that the handlers of soft_delete services also take from the query.
+var softDeleteFlagNames = map[string]bool{"allow_missing": true, "validate_only": true, "show_deleted": true}
+
+// statePass clears the state fields of requests of soft_delete services,
+// which are output only by AIP-216.
+var statePass = &fieldPass{
+	name: "clearState",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		return f.GetName() == "state" && f.GetType() == pb.FieldDescriptorProto_TYPE_ENUM &&
+			f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED
+	},
+	apply: clearField,
+}
+
+// softDelete reports whether the service follows AIP-164, see the
+// soft_delete option.
+func (g *grpc) softDelete() bool {
+	return options.Bool(g.service.GetOptions(), options.E_SoftDelete)
+}
+
+// softDeleteFlags returns the softDeleteFlagNames fields of the request of
+// method if the service is a soft_delete one.
+func (g *grpc) softDeleteFlags(method *pb.MethodDescriptorProto) []*pb.FieldDescriptorProto {
+	if !g.softDelete() {
+		return nil
+	}
+	var flags []*pb.FieldDescriptorProto
+	for _, f := range g.msgs[method.GetInputType()].GetField() {
+		if softDeleteFlagNames[f.GetName()] && f.GetType() == pb.FieldDescriptorProto_TYPE_BOOL &&
+			f.GetLabel() != pb.FieldDescriptorProto_LABEL_REPEATED && f.OneofIndex == nil {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// generateSoftDelete generates the part of a handler of a soft_delete
+// service that sets the flags of the request from the query, where they
+// may also be given without a value, and clears its state fields.
+func (g *grpc) generateSoftDelete(method *pb.MethodDescriptorProto) {
+	in := method.GetInputType()
+	for _, f := range g.softDeleteFlags(method) {
+		v := "v"
+		if g.gen.ObjectNamed(in).File().GetSyntax() != "proto3" {
+			v = "&v"
+		}
+		g.P("	if v, ok, err := goweb.QueryFlag(r.URL.Query(), ", strconv.Quote(f.GetName()), "); err != nil {")
+		g.generateBadRequest(method, false)
+		g.P("	} else if ok {")
+		g.P("		in.", g.goField(in, f).field, " = ", v)
+		g.P("	}")
+	}
+	if g.softDelete() && g.needs(statePass, in) {
+		g.P("	", g.passFunc(statePass, in), "(", g.in(), ")")
+	}
+}
+
+// generateAllowMissing generates the part of the handler of a Delete
+// method of a soft_delete service that answers the deletion of a missing
+// resource with an empty response if the request allows it.
+func (g *grpc) generateAllowMissing(method *pb.MethodDescriptorProto) {
+	if !strings.HasPrefix(method.GetName(), "Delete") {
+		return
+	}
+	for _, f := range g.softDeleteFlags(method) {
+		if f.GetName() == "allow_missing" {
+			g.P("	if in.", g.goField(method.GetInputType(), f).getter, "() && goweb.ErrorStatusOf(err) == 404 {")
+			g.P("		res, err = &", g.typeName(method.GetOutputType()), "{}, nil")
+			g.P("	}")
+		}
+	}
+}
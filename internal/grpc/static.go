@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+)
+
+// staticDir returns the directory of the static parameter, or "".
+func (g *grpc) staticDir() string {
+	dir, _ := g.param("static")
+	return dir
+}
+
+// staticVar returns the name of the embed.FS of the static parameter in
+// the file generated for file.
+func staticVar(file *generator.FileDescriptor) string {
+	return fileVar(file, "static")
+}
+
+// fileVar returns the name of the variable of the file generated for
+// file that holds what suffix names.
+func fileVar(file *generator.FileDescriptor, suffix string) string {
+	base := strings.TrimSuffix(path.Base(file.GetName()), ".proto")
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, base)
+	return "_" + generator.CamelCase(name) + "_" + suffix
+}
+
+// generateStaticFS generates the embed.FS holding the directory of the
+// static parameter, relative to the package of the generated file.
+func (g *grpc) generateStaticFS(file *generator.FileDescriptor) {
+	dir := g.staticDir()
+	embed := g.gen.Import("embed")
+	g.P("// ", staticVar(file), " holds the static files that the muxes of this file serve.")
+	g.P("//")
+	g.P("//go:embed ", strconv.Quote(dir))
+	g.P("var ", staticVar(file), " ", embed.Ident("FS"))
+	g.P()
+}
+
+// generateStaticRoute generates the mount of the static files in the mux
+// of a service, behind its routes.
+func (g *grpc) generateStaticRoute(file *generator.FileDescriptor) {
+	dir := g.staticDir()
+	g.P("router.Handle(goweb.JoinPath(prefix, \"*\"), goweb.Static(", staticVar(file), ", ", strconv.Quote(dir), ", prefix, goweb.StaticOptions{SPA: ", g.flag("spa"), "}))")
+}
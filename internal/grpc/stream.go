@@ -0,0 +1,141 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateServerStream generates the part of the http handler of a
+// server-streaming method that calls the implementation with a stream
+// writing to the response, see goweb.ServerStream.
+func (g *grpc) generateServerStream(servName string, method *pb.MethodDescriptorProto) {
+	opts := method.GetOptions()
+	var fields string
+	if n := options.Uint32(opts, options.E_StreamFlushEvery); n > 1 {
+		fields += "FlushEvery: " + strconv.Itoa(int(n)) + ", "
+	}
+	if options.Bool(opts, options.E_StreamGzip) {
+		fields += "Gzip: true, "
+	}
+	if n := options.Uint32(opts, options.E_StreamKeepaliveSeconds); n > 0 {
+		fields += "KeepAlive: " + strconv.Itoa(int(n)) + "e9, "
+	}
+	if n := options.Uint32(opts, options.E_StreamWriteTimeoutSeconds); n > 0 {
+		fields += "WriteTimeout: " + strconv.Itoa(int(n)) + "e9, "
+	}
+	if options.Bool(opts, options.E_StreamProxySafe) {
+		fields += "ProxySafe: true, "
+	}
+	if g.int64Strings(method) {
+		fields += "Int64Strings: true, "
+	}
+	if p := g.proto3JSON(); p != "" {
+		fields += "JSON: &" + p + ", "
+	}
+	if len(fields) > 0 {
+		fields = fields[:len(fields)-2]
+	}
+	g.generateContext()
+	g.P("	r = r.WithContext(ctx)")
+	g.generateEnrich(method, "ctx")
+	g.generateAuthorize(method, "ctx")
+	g.P("	stream := goweb.NewServerStream(w, r, goweb.StreamOptions{", fields, "})")
+	g.P("	defer stream.Close()")
+	g.P("	if err := impl.handler.", generator.CamelCase(method.GetName()), "(", g.in(), ", ", g.streamType(servName, method), "{stream}); err != nil {")
+	g.P("		stream.Fail(err)")
+	g.P("	}")
+}
+
+// streamType returns the name of the type implementing the
+// <Service>_<Method>Server interface over http.
+func (g *grpc) streamType(servName string, method *pb.MethodDescriptorProto) string {
+	return "_" + servName + "_" + generator.CamelCase(method.GetName()) + "HTTPStream"
+}
+
+// generateStreamType generates the type implementing the
+// <Service>_<Method>Server interface of a server-streaming method on top
+// of a goweb.ServerStream.
+func (g *grpc) generateStreamType(servName string, method *pb.MethodDescriptorProto) {
+	typ := g.streamType(servName, method)
+	g.P("type ", typ, " struct {")
+	g.P("	*goweb.ServerStream")
+	g.P("}")
+	g.P()
+	g.P("var _ ", servName, "_", generator.CamelCase(method.GetName()), "Server = ", typ, "{}")
+	g.P()
+	g.P("func (s ", typ, ") Send(m *", g.typeName(method.GetOutputType()), ") error {")
+	if g.needs(redactPass, method.GetOutputType()) || g.needs(encryptPass, method.GetOutputType()) {
+		g.P("	ctx := s.Context()")
+	}
+	if g.recursive(method.GetOutputType()) {
+		g.P("	if err := goweb.CheckMessageDepth(m, ", g.maxDepth(method.GetOutputType()), "); err != nil {")
+		g.P("		return err")
+		g.P("	}")
+	}
+	if g.finiteFloats(method.GetOutputType()) {
+		g.P("	if err := goweb.CheckFinite(m); err != nil {")
+		g.P("		return err")
+		g.P("	}")
+	}
+	g.generateResponseEnums(method, "m", func() {
+		g.P("		return err")
+	})
+	g.generateResponseFilter(method, "m")
+	if g.needs(encryptPass, method.GetOutputType()) {
+		g.P("	m = goweb.Clone(m).(*", g.typeName(method.GetOutputType()), ")")
+		g.P("	if err := ", g.passFunc(encryptPass, method.GetOutputType()), "(ctx, m); err != nil {")
+		g.P("		return err")
+		g.P("	}")
+	}
+	var id *pb.FieldDescriptorProto
+	for _, f := range g.msgs[method.GetOutputType()].GetField() {
+		if options.Bool(f.GetOptions(), options.E_EventId) {
+			id = f
+		}
+	}
+	if id != nil {
+		g.P("	return s.ServerStream.SendEvent(s.Context(), goweb.EventID(m.", g.goField(method.GetOutputType(), id).getter, "()), m)")
+	} else {
+		g.P("	return s.ServerStream.Send(m)")
+	}
+	g.P("}")
+	g.P()
+	g.P("func (s ", typ, ") SetHeader(md metadata.MD) error  { return s.ServerStream.SetHeader(md) }")
+	g.P("func (s ", typ, ") SendHeader(md metadata.MD) error { return s.ServerStream.SendHeader(md) }")
+	g.P("func (s ", typ, ") SetTrailer(md metadata.MD)       { s.ServerStream.SetTrailer(md) }")
+	g.P()
+}
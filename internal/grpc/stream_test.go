@@ -0,0 +1,112 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// watchFile has a service with a server-streaming method.
+func watchFile() *pb.FileDescriptorProto {
+	watch := method("Watch", "User", "User")
+	watch.ServerStreaming = proto.Bool(true)
+	return testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", watch),
+	)
+}
+
+func TestServerStream(t *testing.T) {
+	src := generateMux(t, "streams", watchFile())
+	checkDecl(t, src, "_Users_WatchHTTPStream", `
+type _Users_WatchHTTPStream struct {
+	*goweb.ServerStream
+}`)
+	checkDecl(t, src, "(_Users_WatchHTTPStream).Send", `
+func (s _Users_WatchHTTPStream) Send(m *User) error {
+	return s.ServerStream.Send(m)
+}`)
+	if !strings.Contains(src, "var _ Users_WatchServer = _Users_WatchHTTPStream{}") {
+		t.Error("no assertion that the stream is a Users_WatchServer")
+	}
+	if !strings.Contains(src, "impl.handler.Watch(&in, _Users_WatchHTTPStream{stream})") {
+		t.Error("the handler does not serve the stream")
+	}
+}
+
+func TestServerStreamUnsupported(t *testing.T) {
+	src := generateMux(t, "", watchFile())
+	if strings.Contains(src, "_Users_WatchHTTPStream") || !strings.Contains(src, "goweb.Unsupported(") {
+		t.Error("server stream served without the streams parameter")
+	}
+}
+
+// uploadFile has a service with a client-streaming method.
+func uploadFile() *pb.FileDescriptorProto {
+	upload := method("Upload", "User", "User")
+	upload.ClientStreaming = proto.Bool(true)
+	return testFile("users.proto",
+		message("User", field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+		service("Users", upload),
+	)
+}
+
+func TestUnsupportedStreams(t *testing.T) {
+	for _, param := range []string{"", "unsupported_streams=501"} {
+		src := generateMux(t, param, uploadFile())
+		handler := decl(t, src, "(*_UsersServer).Upload")
+		for _, want := range []string{"goweb.Unsupported(r, _Users_routes[0], goweb.UnsupportedStreaming)", "w.WriteHeader(501)"} {
+			if !strings.Contains(handler, want) {
+				t.Errorf("%q: the stub has no %s:\n%s", param, want, handler)
+			}
+		}
+	}
+	for _, c := range []struct {
+		param string
+		file  *pb.FileDescriptorProto
+		want  string
+	}{
+		{"unsupported_streams=fail", watchFile(), "method /pkg.Users/Watch is server streaming, which http handlers only serve with the streams parameter"},
+		{"unsupported_streams=fail", uploadFile(), "method /pkg.Users/Upload is client or bidirectional streaming, which http handlers cannot serve"},
+		{"unsupported_streams=ignore", uploadFile(), `parameter unsupported_streams must be 501 or fail, not "ignore"`},
+	} {
+		if err := generateError(t, c.param, c.file); !strings.Contains(err, c.want) {
+			t.Errorf("%s: error %q, want %q", c.param, err, c.want)
+		}
+	}
+	// served server streams are supported
+	generateMux(t, "streams,unsupported_streams=fail", watchFile())
+}
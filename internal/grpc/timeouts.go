@@ -0,0 +1,97 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateTimeout generates the part of the handler of a unary method
+// that sets the deadline of its timeout_seconds option.
+func (g *grpc) generateTimeout(method *pb.MethodDescriptorProto) {
+	if n := options.Uint32(method.GetOptions(), options.E_TimeoutSeconds); n > 0 {
+		g.P("	ctx, cancelTimeout := ", contextPkg, ".WithTimeout(ctx, ", strconv.Itoa(int(n)), "e9)")
+		g.P("	defer cancelTimeout()")
+	}
+}
+
+// generateServerTimeouts generates <Service>ServerTimeouts, the
+// http.Server timeouts that do not cut off the calls of the service, from
+// the timeout_seconds of its unary methods and whether it has methods
+// with long requests or responses, see goweb.CallTimeouts.
+func (g *grpc) generateServerTimeouts(servName string, service *pb.ServiceDescriptorProto, routes []goweb.Route) {
+	var longest uint32
+	bounded, longRequests, longResponses := true, false, false
+	for i, method := range service.Method {
+		g.method = "/" + routes[i].Service + "/" + routes[i].Method
+		opts := method.GetOptions()
+		switch {
+		case method.GetClientStreaming():
+			// Answered with 501.
+		case method.GetServerStreaming():
+			longResponses = longResponses || g.flag("streams") || g.flag("hub")
+		default:
+			longRequests = longRequests || options.Bool(opts, options.E_Upload)
+			longResponses = longResponses || options.Bool(opts, options.E_Download)
+			if n := options.Uint32(opts, options.E_TimeoutSeconds); n == 0 {
+				bounded = false
+			} else if n > longest {
+				longest = n
+			}
+		}
+		g.method = ""
+	}
+	timeout := strconv.Itoa(int(longest)) + "e9"
+	if !bounded || longest == 0 {
+		timeout = "0"
+	}
+	g.P("// ", servName, "ServerTimeouts are the http.Server timeouts that do not cut")
+	g.P("// off calls of the ", servName, " service, e.g. for ", servName, "ServerTimeouts.Apply(server);")
+	g.P("// see goweb.CallTimeouts.")
+	g.P("var ", servName, "ServerTimeouts = goweb.CallTimeouts(", timeout, ", ", longRequests, ", ", longResponses, ")")
+	g.P()
+}
+
+// generateRunner generates Run<Service>, serving the service with a
+// goweb.Runner and its server timeouts.
+func (g *grpc) generateRunner(servName string) {
+	g.P("// Run", servName, " serves the ", servName, " service with impl below the root path")
+	g.P("// with r and ", servName, "ServerTimeouts until ctx is done; see goweb.Runner.")
+	g.P("func Run", servName, "(ctx ", contextPkg, ".Context, r *goweb.Runner, impl ", servName, "Server, opts ...goweb.ServerOption) error {")
+	g.P("	return r.Run(ctx, New", servName, "Mux(impl, \"/\", opts...), ", servName, "ServerTimeouts)")
+	g.P("}")
+	g.P()
+}
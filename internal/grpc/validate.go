@@ -0,0 +1,245 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// validatePass matches the fields with validation rules; the functions of
+// generateValidateFunc check them.
+var validatePass = &fieldPass{
+	name: "validate",
+	match: func(f *pb.FieldDescriptorProto) bool {
+		o := f.GetOptions()
+		return options.Bool(o, options.E_Required) || options.Has(o, options.E_Min) || options.Has(o, options.E_Max) ||
+			options.Uint32(o, options.E_MinLength) > 0 || options.String(o, options.E_Pattern) != "" ||
+			options.Uint32(o, options.E_MinItems) > 0
+	},
+}
+
+// validateFunc returns the name of the function appending the violations
+// of the validation rules of the message name and of the messages nested
+// in it to a list, and queues its generation.
+func (g *grpc) validateFunc(name string) string {
+	fn := "_validate" + mangle(name)
+	if !g.passFuncs[fn] {
+		g.passFuncs[fn] = true
+		g.passQueue = append(g.passQueue, func() { g.generateValidateFunc(name, fn) })
+	}
+	return fn
+}
+
+// generateValidate generates the part of a handler that rejects requests
+// breaking the validation rules of their fields, and then those whose
+// Validate method fails, see goweb.Validate.
+func (g *grpc) generateValidate(inType string) {
+	if g.needs(validatePass, inType) {
+		g.P("	if err := goweb.ValidationError(", g.validateFunc(inType), "(", g.in(), ", \"\", nil)); err != nil {")
+		g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+		g.P("		return")
+		g.P("	}")
+	}
+	g.P("	if err := goweb.Validate(", g.in(), "); err != nil {")
+	g.P("		impl.opts.WriteError(w, r, err, goweb.", g.errorWriter(), ")")
+	g.P("		return")
+	g.P("	}")
+}
+
+func (g *grpc) generateValidateFunc(name, fn string) {
+	g.P("func ", fn, "(m *", g.typeName(name), ", path string, vs []goweb.FieldViolation) []goweb.FieldViolation {")
+	g.P("	if m == nil {")
+	g.P("		return vs")
+	g.P("	}")
+	for _, f := range g.msgs[name].GetField() {
+		m := g.fieldMessage(f)
+		nested := m != "" && g.needs(validatePass, m)
+		if !validatePass.match(f) && !nested {
+			continue
+		}
+		field := "m." + g.goField(name, f).field
+		path := "goweb.FieldPath(path, " + strconv.Quote(f.GetName()) + ")"
+		if f.OneofIndex != nil {
+			// The rules of a field of a oneof apply while it is set.
+			g.P("	if _, ok := ", g.oneofCase(name, f), "; ok {")
+			field = g.oneofCase(name, f) + "." + g.goField(name, f).field
+		}
+		if validatePass.match(f) {
+			g.generateFieldRules(name, f, field, path)
+		}
+		if nested {
+			g.generateValidateCall(f, m, field, path)
+		}
+		if f.OneofIndex != nil {
+			g.P("	}")
+		}
+	}
+	g.P("	return vs")
+	g.P("}")
+	g.P()
+}
+
+// generateValidateCall generates the validation of the message m in field,
+// or of each of its values if f is repeated or a map.
+func (g *grpc) generateValidateCall(f *pb.FieldDescriptorProto, m, field, path string) {
+	switch {
+	case g.msgs[f.GetTypeName()].GetOptions().GetMapEntry():
+		g.P("	for k, v := range ", field, " {")
+		g.P("		vs = ", g.validateFunc(m), "(v, goweb.KeyPath(", path, ", k), vs)")
+		g.P("	}")
+	case f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED:
+		g.P("	for i, v := range ", field, " {")
+		g.P("		vs = ", g.validateFunc(m), "(v, goweb.IndexPath(", path, ", i), vs)")
+		g.P("	}")
+	default:
+		g.P("	vs = ", g.validateFunc(m), "(", field, ", ", path, ", vs)")
+	}
+}
+
+// generateFieldRules generates the checks of the validation rules of the
+// field f of the message msg, held in field, whose path is the expression
+// path.
+func (g *grpc) generateFieldRules(msg string, f *pb.FieldDescriptorProto, field, path string) {
+	o := f.GetOptions()
+	full := msg[1:] + "." + f.GetName()
+	t := f.GetType()
+	repeated := f.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED
+	pointer := !repeated && t != pb.FieldDescriptorProto_TYPE_BYTES && t != pb.FieldDescriptorProto_TYPE_MESSAGE &&
+		f.OneofIndex == nil && g.gen.ObjectNamed(msg).File().GetSyntax() != "proto3"
+	str := t == pb.FieldDescriptorProto_TYPE_STRING
+	number := !str && t != pb.FieldDescriptorProto_TYPE_BYTES && t != pb.FieldDescriptorProto_TYPE_BOOL &&
+		t != pb.FieldDescriptorProto_TYPE_ENUM && t != pb.FieldDescriptorProto_TYPE_MESSAGE && t != pb.FieldDescriptorProto_TYPE_GROUP
+	violation := func(cond, desc string) {
+		g.P("	if ", cond, " {")
+		g.P("		vs = append(vs, goweb.FieldViolation{Field: ", path, ", Description: ", strconv.Quote(desc), "})")
+		g.P("	}")
+	}
+
+	required := ""
+	if options.Bool(o, options.E_Required) && f.OneofIndex != nil {
+		g.gen.Fail("required on", full, "which is a field of a oneof")
+	}
+	if options.Bool(o, options.E_Required) {
+		switch {
+		case pointer || t == pb.FieldDescriptorProto_TYPE_MESSAGE && !repeated:
+			required = field + " == nil"
+		case repeated || str || t == pb.FieldDescriptorProto_TYPE_BYTES:
+			required = "len(" + field + ") == 0"
+		case t == pb.FieldDescriptorProto_TYPE_BOOL:
+			required = "!" + field
+		default:
+			required = field + " == 0"
+		}
+	}
+	var checks [][2]string // conditions of violations of a value v and their descriptions
+	if options.Has(o, options.E_Min) || options.Has(o, options.E_Max) {
+		if !number {
+			g.gen.Fail("min and max of", full, "need a number field")
+		}
+		if options.Has(o, options.E_Min) {
+			min := strconv.FormatFloat(options.Float64(o, options.E_Min), 'g', -1, 64)
+			checks = append(checks, [2]string{"float64(v) < " + min, "must be at least " + min})
+		}
+		if options.Has(o, options.E_Max) {
+			max := strconv.FormatFloat(options.Float64(o, options.E_Max), 'g', -1, 64)
+			checks = append(checks, [2]string{"float64(v) > " + max, "must be at most " + max})
+		}
+	}
+	if n := options.Uint32(o, options.E_MinLength); n > 0 {
+		switch {
+		case str:
+			checks = append(checks, [2]string{"len([]rune(v)) < " + strconv.Itoa(int(n)), "must have at least " + plural(int(n), "character")})
+		case t == pb.FieldDescriptorProto_TYPE_BYTES:
+			checks = append(checks, [2]string{"len(v) < " + strconv.Itoa(int(n)), "must have at least " + plural(int(n), "byte")})
+		default:
+			g.gen.Fail("min_length of", full, "needs a string or bytes field")
+		}
+	}
+	if p := options.String(o, options.E_Pattern); p != "" {
+		if !str {
+			g.gen.Fail("pattern of", full, "needs a string field")
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			g.gen.Fail("pattern of", full, "is invalid:", err.Error())
+		}
+		cond := "!goweb.MatchPattern(" + strconv.Quote(p) + ", v)"
+		if !options.Bool(o, options.E_Required) {
+			cond = "v != \"\" && " + cond
+		}
+		checks = append(checks, [2]string{cond, "must match " + p})
+	}
+	// A missing required value only has that violation.
+	if required != "" && (len(checks) == 0 || repeated || pointer) {
+		violation(required, "is required")
+	}
+	if n := options.Uint32(o, options.E_MinItems); n > 0 {
+		if !repeated {
+			g.gen.Fail("min_items of", full, "needs a repeated or map field")
+		}
+		violation("len("+field+") < "+strconv.Itoa(int(n)), "must have at least "+plural(int(n), "item"))
+	}
+	if len(checks) == 0 {
+		return
+	}
+	switch {
+	case repeated:
+		g.P("	for i, v := range ", field, " {")
+		path = "goweb.IndexPath(" + path + ", i)"
+	case pointer:
+		g.P("	if v := ", field, "; v != nil {")
+		g.P("		v := *v")
+	case required != "":
+		g.P("	if ", required, " {")
+		g.P("		vs = append(vs, goweb.FieldViolation{Field: ", path, ", Description: \"is required\"})")
+		g.P("	} else {")
+		g.P("		v := ", field)
+	default:
+		g.P("	{")
+		g.P("		v := ", field)
+	}
+	for _, c := range checks {
+		violation(c[0], c[1])
+	}
+	g.P("	}")
+}
+
+// plural returns n and the noun, in the plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
@@ -0,0 +1,69 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ekle/protoc-gen-goweb/options"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestValidateOneof(t *testing.T) {
+	src := generateMux(t, "", loginFile(options.E_MinLength, proto.Uint32(2)))
+	checkDecl(t, src, "_validate_pkg_User", `
+func _validate_pkg_User(m *User, path string, vs []goweb.FieldViolation) []goweb.FieldViolation {
+	if m == nil {
+		return vs
+	}
+	if _, ok := m.Login.(*User_Password); ok {
+		{
+			v := m.Login.(*User_Password).Password
+			if len([]rune(v)) < 2 {
+				vs = append(vs, goweb.FieldViolation{Field: goweb.FieldPath(path, "password"), Description: "must have at least 2 characters"})
+			}
+		}
+	}
+	if _, ok := m.Login.(*User_Secret); ok {
+		vs = _validate_pkg_Secret(m.Login.(*User_Secret).Secret, goweb.FieldPath(path, "secret"), vs)
+	}
+	return vs
+}`)
+}
+
+func TestRequiredOneof(t *testing.T) {
+	err := generateError(t, "", loginFile(options.E_Required, proto.Bool(true)))
+	if !strings.Contains(err, "required on pkg.User.password which is a field of a oneof") {
+		t.Errorf("error = %s", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	"github.com/ekle/protoc-gen-goweb/goweb"
+	"github.com/ekle/protoc-gen-goweb/options"
+)
+
+// A versionedService is a service of a file with an api_version.
+type versionedService struct {
+	name    string // CamelCase name of the service
+	version string // e.g. "v2"
+}
+
+// generateVersions generates New<Name>VersionedMux for the services of
+// file that share the media type of their api_version, which serves them
+// behind one prefix and picks the version by the Accept header.
+func (g *grpc) generateVersions(file *generator.FileDescriptor) {
+	groups := map[string][]versionedService{}
+	var bases []string
+	for _, service := range file.FileDescriptorProto.Service {
+		mediaType := options.String(service.GetOptions(), options.E_ApiVersion)
+		if mediaType == "" {
+			continue
+		}
+		base, version, ok := goweb.ParseMediaVersion(mediaType)
+		if !ok {
+			g.gen.Fail("api_version", strconv.Quote(mediaType), "of service", service.GetName(), "is not a media type like application/vnd.myapi.v2+json")
+		}
+		if groups[base] == nil {
+			bases = append(bases, base)
+		}
+		groups[base] = append(groups[base], versionedService{generator.CamelCase(service.GetName()), version})
+	}
+	sort.Strings(bases)
+	for _, base := range bases {
+		g.generateVersionedMux(base, groups[base])
+	}
+}
+
+// versionParam returns the parameter name of the implementation of
+// version in New<Name>VersionedMux.
+func versionParam(version string) string {
+	name := []byte("v")
+	for _, c := range []byte(version) {
+		if 'a' <= c && c <= 'z' || '0' <= c && c <= '9' {
+			name = append(name, c)
+		} else {
+			name = append(name, '_')
+		}
+	}
+	if len(version) > 1 && version[0] == 'v' {
+		name = name[1:]
+	}
+	return string(name)
+}
+
+func (g *grpc) generateVersionedMux(base string, services []versionedService) {
+	name := ""
+	versions := map[string]bool{}
+	for _, s := range services {
+		n, ok := goweb.VersionedName(s.name, s.version)
+		if !ok {
+			g.gen.Fail("service", s.name, "with api_version", s.version, "must end in its version, e.g.", s.name+strings.ToUpper(s.version[:1])+s.version[1:])
+		}
+		if name != "" && n != name {
+			g.gen.Fail("services", s.name, "and", name+"...", "share the api_version media type", base, "but not their name")
+		}
+		if versions[s.version] {
+			g.gen.Fail("api_version", s.version, "of", base, "is used by more than one service")
+		}
+		name, versions[s.version] = n, true
+	}
+	goweb := g.gen.Import(gowebPkgPath)
+	var params []string
+	for _, s := range services {
+		params = append(params, versionParam(s.version)+" "+s.name+"Server")
+	}
+	g.gen.EmitFunc(generator.Func{
+		Doc: "New" + name + "VersionedMux serves the versions of the " + name + " service under prefix,\n" +
+			"picked by the version of the " + base + " media type the Accept header\n" +
+			"asks for; requests without one get version " + services[len(services)-1].version + ".",
+		Name:    "New" + name + "VersionedMux",
+		Params:  "prefix string, " + strings.Join(params, ", "),
+		Results: "*" + goweb.Ident("VersionedMux"),
+		Body: func() {
+			g.P("return &", goweb.Ident("VersionedMux"), "{")
+			g.P("MediaType: ", strconv.Quote(base), ",")
+			g.P("Versions: map[string]", g.gen.Import("net/http").Ident("Handler"), "{")
+			for _, s := range services {
+				g.P(strconv.Quote(s.version), ": New", s.name, "Mux(", versionParam(s.version), ", prefix),")
+			}
+			g.P("},")
+			g.P("Default: ", strconv.Quote(services[len(services)-1].version), ",")
+			g.P("}")
+		},
+	})
+}
@@ -0,0 +1,77 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateWarmup generates <Service>Warmup, which builds the codec caches
+// of the request and response types of a service, see goweb.Warmup. With
+// warmup=init, it also runs at init time.
+func (g *grpc) generateWarmup(servName string, service *pb.ServiceDescriptorProto) {
+	when := g.gen.Param["warmup"]
+	if when != "" && when != "init" {
+		g.gen.Fail("parameter warmup must be empty or init, not", strconv.Quote(when))
+	}
+	seen := map[string]bool{}
+	var msgs []string
+	for _, method := range service.Method {
+		for _, typ := range []string{method.GetInputType(), method.GetOutputType()} {
+			if !seen[typ] {
+				seen[typ] = true
+				msgs = append(msgs, "new("+g.typeName(typ)+")")
+			}
+		}
+	}
+	g.P("// ", servName, "Warmup builds the caches the codecs of the http handlers of")
+	g.P("// the ", servName, " service keep for its request and response types, so the")
+	g.P("// first calls are not slowed down by it; call it before serving.")
+	g.P("func ", servName, "Warmup() error {")
+	g.P("	return goweb.Warmup(")
+	for _, m := range msgs {
+		g.P(m, ",")
+	}
+	g.P("	)")
+	g.P("}")
+	g.P()
+	if when == "init" {
+		g.P("func init() {")
+		g.P("	if err := ", servName, "Warmup(); err != nil {")
+		g.P("		log.Println(err)")
+		g.P("	}")
+		g.P("}")
+		g.P()
+	}
+}
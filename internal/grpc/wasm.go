@@ -0,0 +1,66 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateRequestValidators generates <Service>RequestValidators, the
+// checks of the requests of the unary methods of the service by their
+// handlers, for clients such as WebAssembly modules, see
+// goweb.ExportValidators.
+func (g *grpc) generateRequestValidators(servName string, service *pb.ServiceDescriptorProto) {
+	g.P("// ", servName, "RequestValidators returns the checks of the JSON requests of the")
+	g.P("// unary methods of the ", servName, " service by their handlers, see")
+	g.P("// goweb.ExportValidators.")
+	g.P("func ", servName, "RequestValidators() []goweb.RequestValidator {")
+	g.P("	return []goweb.RequestValidator{")
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		inType := g.typeName(method.GetInputType())
+		g.P("{")
+		g.P("Route: _", servName, "_routes[", i, "],")
+		g.P("New: func() interface{} { return new(", inType, ") },")
+		if g.needs(validatePass, method.GetInputType()) {
+			g.P("Rules: func(in interface{}) []goweb.FieldViolation {")
+			g.P("	return ", g.validateFunc(method.GetInputType()), "(in.(*", inType, "), \"\", nil)")
+			g.P("},")
+		}
+		g.P("},")
+	}
+	g.P("	}")
+	g.P("}")
+	g.P()
+}
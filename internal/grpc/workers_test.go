@@ -0,0 +1,84 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// The files are generated concurrently, but the output does not depend
+// on the number of workers.
+func TestWorkers(t *testing.T) {
+	req := &plugin.CodeGeneratorRequest{}
+	for i := 0; i < 16; i++ {
+		name := fmt.Sprintf("svc%d", i)
+		f := testFile(name+".proto",
+			message("M"+name, field("name", 1, pb.FieldDescriptorProto_TYPE_STRING)),
+			service("S"+name, method("Get", "M"+name, "M"+name)),
+		)
+		// each file waits for the one before it
+		if i > 0 {
+			f.Dependency = []string{fmt.Sprintf("svc%d.proto", i-1)}
+			f.PublicDependency = []int32{0}
+		}
+		req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+		req.ProtoFile = append(req.ProtoFile, f)
+	}
+	var outputs [][]*plugin.CodeGeneratorResponse_File
+	for _, workers := range []int{1, 4, 16} {
+		req.Parameter = proto.String(fmt.Sprintf("plugins=grpc,openapi,workers=%d", workers))
+		files, err := request(t, req)
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		outputs = append(outputs, files)
+	}
+	if n := len(outputs[0]); n != 32 {
+		t.Fatalf("%d files generated, want 32", n)
+	}
+	for i, name := range []string{"svc0.mux.go", "svc15.mux.go", "svc0.openapi.json", "svc15.openapi.json"} {
+		if got := outputs[0][[]int{0, 15, 16, 31}[i]].GetName(); got != name {
+			t.Errorf("file %d = %s, want %s", i, got, name)
+		}
+	}
+	for _, files := range outputs[1:] {
+		if !reflect.DeepEqual(files, outputs[0]) {
+			t.Error("the output depends on the number of workers")
+		}
+	}
+}
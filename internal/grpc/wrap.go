@@ -0,0 +1,133 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpc
+
+import (
+	"strconv"
+
+	"github.com/ekle/protoc-gen-goweb/generator"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// generateWrap generates Wrap<Service>Server, which runs a goweb.Interceptor
+// around the unary methods of a <Service>Server.
+func (g *grpc) generateWrap(servName string, service *pb.ServiceDescriptorProto) {
+	serverType := servName + "Server"
+	wrapType := "_" + servName + "Wrapped"
+
+	g.P("// Wrap", servName, "Server returns a ", serverType, " calling h through the")
+	g.P("// interceptor ic, e.g. the Intercept method of a goweb.Sampler. Streaming")
+	g.P("// methods are passed through unchanged.")
+	g.P("func Wrap", servName, "Server(h ", serverType, ", ic goweb.Interceptor) ", serverType, " {")
+	g.P("	return &", wrapType, "{h, ic}")
+	g.P("}")
+	g.P()
+	g.P("type ", wrapType, " struct {")
+	g.P("	", serverType)
+	g.P("	ic goweb.Interceptor")
+	g.P("}")
+	g.P()
+	for i, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("func (w *", wrapType, ") ", g.clientSignature(method), " {")
+		g.P("	out, err := w.ic(ctx, _", servName, "_routes[", i, "], in, func(ctx ", contextPkg, ".Context, in interface{}) (interface{}, error) {")
+		g.P("		return w.", serverType, ".", methName, "(ctx, in.(*", inType, "))")
+		g.P("	})")
+		g.P("	res, _ := out.(*", outType, ")")
+		g.P("	return res, err")
+		g.P("}")
+		g.P()
+	}
+}
+
+// generateReplay generates Replay<Service>, which feeds captured calls
+// back through the mux of the service.
+func (g *grpc) generateReplay(servName string) {
+	g.P("// Replay", servName, " posts the captured calls of the ", servName, " service to")
+	g.P("// a mux serving h and passes each response to check, see goweb.Replay.")
+	g.P("// Calls of other services are skipped.")
+	g.P("func Replay", servName, "(h ", servName, "Server, calls []*goweb.CapturedCall, check func(c *goweb.CapturedCall, status int, body []byte)) {")
+	g.P("	var own []*goweb.CapturedCall")
+	g.P("	for _, c := range calls {")
+	g.P("		for _, r := range _", servName, "_routes {")
+	g.P("			if c.Method == r.FullMethod() {")
+	g.P("				own = append(own, c)")
+	g.P("			}")
+	g.P("		}")
+	g.P("	}")
+	g.P("	goweb.Replay(New", servName, "Mux(h, \"/\"), \"/\", own, check)")
+	g.P("}")
+	g.P()
+}
+
+// generateDeadLetters generates With<Service>DeadLetters, which wraps a
+// server to keep its calls failing with a server error.
+func (g *grpc) generateDeadLetters(servName string) {
+	serverType := servName + "Server"
+	g.P("// With", servName, "DeadLetters returns a ", serverType, " calling h and passing")
+	g.P("// the unary calls that fail with a server error (5xx) to sink, for")
+	g.P("// replay with Replay", servName, " once the cause is fixed.")
+	g.P("func With", servName, "DeadLetters(h ", serverType, ", sink goweb.DeadLetterSink) ", serverType, " {")
+	g.P("	return Wrap", servName, "Server(h, goweb.DeadLetters(sink))")
+	g.P("}")
+	g.P()
+}
+
+// generateShadow generates With<Service>Shadow, which mirrors the unary
+// calls of a server to a shadow implementation and compares their
+// responses.
+func (g *grpc) generateShadow(servName string, service *pb.ServiceDescriptorProto) {
+	serverType := servName + "Server"
+	g.P("// With", servName, "Shadow returns a ", serverType, " answering with primary and")
+	g.P("// mirroring the unary calls to shadow, whose responses s compares with")
+	g.P("// those of primary, see goweb.Shadow.")
+	g.P("func With", servName, "Shadow(primary, shadow ", serverType, ", s *goweb.Shadow) ", serverType, " {")
+	g.P("	return Wrap", servName, "Server(primary, s.Interceptor(func(ctx ", contextPkg, ".Context, route goweb.Route, in interface{}) (interface{}, error) {")
+	g.P("		switch route.Method {")
+	for _, method := range service.Method {
+		if method.GetServerStreaming() || method.GetClientStreaming() {
+			continue
+		}
+		g.P("		case ", strconv.Quote(method.GetName()), ":")
+		g.P("			return shadow.", generator.CamelCase(method.GetName()), "(ctx, in.(*", g.typeName(method.GetInputType()), "))")
+	}
+	g.P("		}")
+	g.P("		return nil, goweb.ErrorFactory{}.Unimplemented(route.FullMethod())")
+	g.P("	}))")
+	g.P("}")
+	g.P()
+}
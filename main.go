@@ -44,6 +44,16 @@
 // The generated code is documented in the package comment for
 // the library.
 //
+// Run as
+// 	protoc-gen-goweb compat old.pb new.pb
+// it instead compares two descriptor sets and reports the changes that
+// break the HTTP contract of the generated services; see package compat.
+//
+// Run as
+// 	protoc-gen-goweb changelog [-json] old.pb new.pb
+// it writes the changelog of the HTTP API between them, as markdown or as
+// JSON; see compat.Changelog.
+//
 // See the README and documentation for protocol buffers to learn more:
 // 	https://developers.google.com/protocol-buffers/
 package main
@@ -57,6 +67,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compat" {
+		os.Exit(compatMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "changelog" {
+		os.Exit(changelogMain(os.Args[2:]))
+	}
+
 	// Begin by allocating a generator. The request and response structures are stored there
 	// so we can do error handling easily - the response structure contains the field to
 	// report failure.
@@ -85,6 +102,7 @@ func main() {
 	g.BuildTypeNameMap()
 
 	g.GenerateAllFiles()
+	g.ApplyManifest()
 
 	// Send back the results.
 	data, err = proto.Marshal(g.Response)
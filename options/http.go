@@ -0,0 +1,108 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package options
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// E_Http is the google.api.http annotation of a method, as declared in
+// google/api/annotations.proto, e.g.
+//
+//	option (google.api.http) = {get: "/v1/users/{user_id}"};
+//
+// It is read into an HttpRule, written by hand like the other extensions,
+// so that neither the generator nor the services need genproto.
+var E_Http = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*HttpRule)(nil),
+	Field:         72295728,
+	Name:          "google.api.http",
+	Tag:           "bytes,72295728,opt,name=http",
+	Filename:      "google/api/annotations.proto",
+}
+
+// HttpRule mirrors google.api.HttpRule of google/api/http.proto. The verbs
+// are a oneof there; at most one of them is set.
+type HttpRule struct {
+	Selector           string             `protobuf:"bytes,1,opt,name=selector,proto3"`
+	Get                string             `protobuf:"bytes,2,opt,name=get,proto3"`
+	Put                string             `protobuf:"bytes,3,opt,name=put,proto3"`
+	Post               string             `protobuf:"bytes,4,opt,name=post,proto3"`
+	Delete             string             `protobuf:"bytes,5,opt,name=delete,proto3"`
+	Patch              string             `protobuf:"bytes,6,opt,name=patch,proto3"`
+	Body               string             `protobuf:"bytes,7,opt,name=body,proto3"`
+	Custom             *CustomHttpPattern `protobuf:"bytes,8,opt,name=custom,proto3"`
+	AdditionalBindings []*HttpRule        `protobuf:"bytes,11,rep,name=additional_bindings,proto3"`
+	ResponseBody       string             `protobuf:"bytes,12,opt,name=response_body,proto3"`
+}
+
+func (m *HttpRule) Reset()         { *m = HttpRule{} }
+func (m *HttpRule) String() string { return proto.CompactTextString(m) }
+func (*HttpRule) ProtoMessage()    {}
+
+// Pattern returns the verb and the path template of the rule, e.g. "GET"
+// and "/v1/users/{user_id}", or "" and "" if it has none.
+func (m *HttpRule) Pattern() (verb, path string) {
+	switch {
+	case m.Get != "":
+		return "GET", m.Get
+	case m.Put != "":
+		return "PUT", m.Put
+	case m.Post != "":
+		return "POST", m.Post
+	case m.Delete != "":
+		return "DELETE", m.Delete
+	case m.Patch != "":
+		return "PATCH", m.Patch
+	case m.Custom != nil:
+		return m.Custom.Kind, m.Custom.Path
+	}
+	return "", ""
+}
+
+// CustomHttpPattern mirrors google.api.CustomHttpPattern.
+type CustomHttpPattern struct {
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3"`
+	Path string `protobuf:"bytes,2,opt,name=path,proto3"`
+}
+
+func (m *CustomHttpPattern) Reset()         { *m = CustomHttpPattern{} }
+func (m *CustomHttpPattern) String() string { return proto.CompactTextString(m) }
+func (*CustomHttpPattern) ProtoMessage()    {}
+
+// Http returns the google.api.http annotation in opts, or nil.
+func Http(opts proto.Message) *HttpRule {
+	v, _ := get(opts, E_Http).(*HttpRule)
+	return v
+}
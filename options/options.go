@@ -0,0 +1,689 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package options describes the custom options that protoc-gen-goweb
+// understands, declared in goweb.proto. The extension descriptors are
+// written by hand, so that the generator needs no generated code; keep them
+// in sync with goweb.proto.
+//
+// To use the options, import goweb.proto from this directory:
+//
+//	protoc -I$GOPATH/src/github.com/ekle/protoc-gen-goweb/options ...
+//
+// and
+//
+//	import "goweb.proto";
+package options
+
+import (
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// E_Path is the legacy path option of a method, a string path such as
+// "/custom/users/get". The .proto files declare it themselves, in their
+// own packages and with their own names, so it is not registered.
+var E_Path = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10000,
+	Name:          "path",
+	Tag:           "bytes,10000,opt,name=path",
+}
+
+// E_Event marks a method as an event handler; see goweb.proto.
+var E_Event = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10001,
+	Name:          "goweb.event",
+	Tag:           "varint,10001,opt,name=event",
+	Filename:      "goweb.proto",
+}
+
+// E_MqttTopic is the MQTT topic template of a method; see goweb.proto.
+var E_MqttTopic = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10002,
+	Name:          "goweb.mqtt_topic",
+	Tag:           "bytes,10002,opt,name=mqtt_topic,json=mqttTopic",
+	Filename:      "goweb.proto",
+}
+
+// E_MqttQos is the MQTT quality of service level of a method; see goweb.proto.
+var E_MqttQos = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*int32)(nil),
+	Field:         10003,
+	Name:          "goweb.mqtt_qos",
+	Tag:           "varint,10003,opt,name=mqtt_qos,json=mqttQos",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamFlushEvery sets how often the http stream of a method is flushed;
+// see goweb.proto.
+var E_StreamFlushEvery = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10004,
+	Name:          "goweb.stream_flush_every",
+	Tag:           "varint,10004,opt,name=stream_flush_every,json=streamFlushEvery",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamGzip enables gzip for the http stream of a method; see goweb.proto.
+var E_StreamGzip = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10005,
+	Name:          "goweb.stream_gzip",
+	Tag:           "varint,10005,opt,name=stream_gzip,json=streamGzip",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamKeepaliveSeconds sets the keep-alive interval of the http stream
+// of a method; see goweb.proto.
+var E_StreamKeepaliveSeconds = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10006,
+	Name:          "goweb.stream_keepalive_seconds",
+	Tag:           "varint,10006,opt,name=stream_keepalive_seconds,json=streamKeepaliveSeconds",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamWriteTimeoutSeconds sets the write timeout of the http stream of
+// a method; see goweb.proto.
+var E_StreamWriteTimeoutSeconds = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10007,
+	Name:          "goweb.stream_write_timeout_seconds",
+	Tag:           "varint,10007,opt,name=stream_write_timeout_seconds,json=streamWriteTimeoutSeconds",
+	Filename:      "goweb.proto",
+}
+
+// E_Download marks a method as a file download; see goweb.proto.
+var E_Download = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10008,
+	Name:          "goweb.download",
+	Tag:           "varint,10008,opt,name=download",
+	Filename:      "goweb.proto",
+}
+
+// E_Upload marks a method as a file upload; see goweb.proto.
+var E_Upload = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10009,
+	Name:          "goweb.upload",
+	Tag:           "varint,10009,opt,name=upload",
+	Filename:      "goweb.proto",
+}
+
+// E_UploadMaxBytes limits the size of an upload; see goweb.proto.
+var E_UploadMaxBytes = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint64)(nil),
+	Field:         10010,
+	Name:          "goweb.upload_max_bytes",
+	Tag:           "varint,10010,opt,name=upload_max_bytes,json=uploadMaxBytes",
+	Filename:      "goweb.proto",
+}
+
+// E_WebhookSignatureHeader names the header holding the webhook signature
+// of a method; see goweb.proto.
+var E_WebhookSignatureHeader = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10011,
+	Name:          "goweb.webhook_signature_header",
+	Tag:           "bytes,10011,opt,name=webhook_signature_header,json=webhookSignatureHeader",
+	Filename:      "goweb.proto",
+}
+
+// E_WebhookSecret names the webhook secret of a method; see goweb.proto.
+var E_WebhookSecret = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10012,
+	Name:          "goweb.webhook_secret",
+	Tag:           "bytes,10012,opt,name=webhook_secret,json=webhookSecret",
+	Filename:      "goweb.proto",
+}
+
+// E_RawBody keeps the raw request body of a method; see goweb.proto.
+var E_RawBody = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10013,
+	Name:          "goweb.raw_body",
+	Tag:           "varint,10013,opt,name=raw_body,json=rawBody",
+	Filename:      "goweb.proto",
+}
+
+// E_Retryable declares whether a method is safe to retry; see goweb.proto.
+var E_Retryable = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10014,
+	Name:          "goweb.retryable",
+	Tag:           "varint,10014,opt,name=retryable",
+	Filename:      "goweb.proto",
+}
+
+// E_Transactional runs a method in a transaction; see goweb.proto.
+var E_Transactional = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10015,
+	Name:          "goweb.transactional",
+	Tag:           "varint,10015,opt,name=transactional",
+	Filename:      "goweb.proto",
+}
+
+// E_RegionField names the region key of a method; see goweb.proto.
+var E_RegionField = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10016,
+	Name:          "goweb.region_field",
+	Tag:           "bytes,10016,opt,name=region_field,json=regionField",
+	Filename:      "goweb.proto",
+}
+
+// E_Transform names the response transformer of a method; see goweb.proto.
+var E_Transform = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10017,
+	Name:          "goweb.transform",
+	Tag:           "bytes,10017,opt,name=transform",
+	Filename:      "goweb.proto",
+}
+
+// E_Enrich names the request enricher of a method; see goweb.proto.
+var E_Enrich = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10018,
+	Name:          "goweb.enrich",
+	Tag:           "bytes,10018,opt,name=enrich",
+	Filename:      "goweb.proto",
+}
+
+// E_Authorize is the authorization rule of a method; see goweb.proto.
+var E_Authorize = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10019,
+	Name:          "goweb.authorize",
+	Tag:           "bytes,10019,opt,name=authorize",
+	Filename:      "goweb.proto",
+}
+
+// E_Policy is the authorization policy of a method; see goweb.proto.
+var E_Policy = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10020,
+	Name:          "goweb.policy",
+	Tag:           "bytes,10020,opt,name=policy",
+	Filename:      "goweb.proto",
+}
+
+// E_Audit marks a method as auditable; see goweb.proto.
+var E_Audit = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10021,
+	Name:          "goweb.audit",
+	Tag:           "varint,10021,opt,name=audit",
+	Filename:      "goweb.proto",
+}
+
+// E_Session is the part of a method in a browser session; see
+// goweb.proto. Its values are SessionRequired, SessionIssue and
+// SessionRevoke.
+var E_Session = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*int32)(nil),
+	Field:         10022,
+	Name:          "goweb.session",
+	Tag:           "varint,10022,opt,name=session,enum=goweb.SessionAction",
+	Filename:      "goweb.proto",
+}
+
+// E_Link declares the related links of the responses of a method; see
+// goweb.proto.
+var E_Link = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: ([]string)(nil),
+	Field:         10023,
+	Name:          "goweb.link",
+	Tag:           "bytes,10023,rep,name=link",
+	Filename:      "goweb.proto",
+}
+
+// E_SloLatencyMs is the latency objective of a method; see goweb.proto.
+var E_SloLatencyMs = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10024,
+	Name:          "goweb.slo_latency_ms",
+	Tag:           "varint,10024,opt,name=slo_latency_ms",
+	Filename:      "goweb.proto",
+}
+
+// E_SloLatencyPercentile is the percentile of the latency objective of a
+// method; see goweb.proto.
+var E_SloLatencyPercentile = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*float64)(nil),
+	Field:         10025,
+	Name:          "goweb.slo_latency_percentile",
+	Tag:           "fixed64,10025,opt,name=slo_latency_percentile",
+	Filename:      "goweb.proto",
+}
+
+// E_SloAvailability is the availability objective of a method; see
+// goweb.proto.
+var E_SloAvailability = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*float64)(nil),
+	Field:         10026,
+	Name:          "goweb.slo_availability",
+	Tag:           "fixed64,10026,opt,name=slo_availability",
+	Filename:      "goweb.proto",
+}
+
+// E_TimeoutSeconds is the deadline of the calls of a method; see
+// goweb.proto.
+var E_TimeoutSeconds = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10027,
+	Name:          "goweb.timeout_seconds",
+	Tag:           "varint,10027,opt,name=timeout_seconds",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamProxySafe makes the stream of a method survive buffering
+// reverse proxies; see goweb.proto.
+var E_StreamProxySafe = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10028,
+	Name:          "goweb.stream_proxy_safe",
+	Tag:           "varint,10028,opt,name=stream_proxy_safe",
+	Filename:      "goweb.proto",
+}
+
+// E_DryRun makes the implementation of a method handle its dry runs; see
+// goweb.proto.
+var E_DryRun = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10029,
+	Name:          "goweb.dry_run",
+	Tag:           "varint,10029,opt,name=dry_run,json=dryRun",
+	Filename:      "goweb.proto",
+}
+
+// E_CoalesceMs coalesces identical calls of a read method; see
+// goweb.proto.
+var E_CoalesceMs = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10030,
+	Name:          "goweb.coalesce_ms",
+	Tag:           "varint,10030,opt,name=coalesce_ms,json=coalesceMs",
+	Filename:      "goweb.proto",
+}
+
+// E_StreamResponse streams the JSON response of a unary method; see
+// goweb.proto.
+var E_StreamResponse = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10031,
+	Name:          "goweb.stream_response",
+	Tag:           "varint,10031,opt,name=stream_response,json=streamResponse",
+	Filename:      "goweb.proto",
+}
+
+// E_Middleware names the middlewares of a method; see goweb.proto.
+var E_Middleware = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: ([]string)(nil),
+	Field:         10032,
+	Name:          "goweb.middleware",
+	Tag:           "bytes,10032,rep,name=middleware",
+	Filename:      "goweb.proto",
+}
+
+// E_ServiceRegionField names the region key of the methods of a service;
+// see goweb.proto.
+var E_ServiceRegionField = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.ServiceOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10200,
+	Name:          "goweb.service_region_field",
+	Tag:           "bytes,10200,opt,name=service_region_field,json=serviceRegionField",
+	Filename:      "goweb.proto",
+}
+
+// E_ApiVersion is the media type of the API version of a service; see
+// goweb.proto.
+var E_ApiVersion = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.ServiceOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10201,
+	Name:          "goweb.api_version",
+	Tag:           "bytes,10201,opt,name=api_version,json=apiVersion",
+	Filename:      "goweb.proto",
+}
+
+// E_SoftDelete makes a service follow the soft-delete conventions of
+// AIP-164; see goweb.proto.
+var E_SoftDelete = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.ServiceOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10202,
+	Name:          "goweb.soft_delete",
+	Tag:           "varint,10202,opt,name=soft_delete",
+	Filename:      "goweb.proto",
+}
+
+// E_ErrorCode declares an error code of a service; see goweb.proto.
+var E_ErrorCode = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.ServiceOptions)(nil),
+	ExtensionType: ([]string)(nil),
+	Field:         10203,
+	Name:          "goweb.error_code",
+	Tag:           "bytes,10203,rep,name=error_code",
+	Filename:      "goweb.proto",
+}
+
+// E_Redact marks a field as secret; see goweb.proto.
+var E_Redact = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10100,
+	Name:          "goweb.redact",
+	Tag:           "varint,10100,opt,name=redact",
+	Filename:      "goweb.proto",
+}
+
+// E_Visibility restricts a field to requests or responses; see goweb.proto.
+// Its values are OutputOnly and InputOnly.
+var E_Visibility = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*int32)(nil),
+	Field:         10101,
+	Name:          "goweb.visibility",
+	Tag:           "varint,10101,opt,name=visibility,enum=goweb.Visibility",
+	Filename:      "goweb.proto",
+}
+
+// E_Default is the default value of a request field; see goweb.proto.
+var E_Default = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10102,
+	Name:          "goweb.default",
+	Tag:           "bytes,10102,opt,name=default",
+	Filename:      "goweb.proto",
+}
+
+// E_Normalize lists the normalizers of a request field; see goweb.proto.
+var E_Normalize = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10103,
+	Name:          "goweb.normalize",
+	Tag:           "bytes,10103,opt,name=normalize",
+	Filename:      "goweb.proto",
+}
+
+// E_MaxItems limits the elements of a request field; see goweb.proto.
+var E_MaxItems = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10104,
+	Name:          "goweb.max_items",
+	Tag:           "varint,10104,opt,name=max_items,json=maxItems",
+	Filename:      "goweb.proto",
+}
+
+// E_MaxLength limits the length of a request field; see goweb.proto.
+var E_MaxLength = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10105,
+	Name:          "goweb.max_length",
+	Tag:           "varint,10105,opt,name=max_length,json=maxLength",
+	Filename:      "goweb.proto",
+}
+
+// E_EventId marks the event ID field of a streamed message; see goweb.proto.
+var E_EventId = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10106,
+	Name:          "goweb.event_id",
+	Tag:           "varint,10106,opt,name=event_id,json=eventId",
+	Filename:      "goweb.proto",
+}
+
+// E_Filename marks the file name field of a download; see goweb.proto.
+var E_Filename = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10107,
+	Name:          "goweb.filename",
+	Tag:           "varint,10107,opt,name=filename",
+	Filename:      "goweb.proto",
+}
+
+// E_Tenant marks the tenant field of a request; see goweb.proto.
+var E_Tenant = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10108,
+	Name:          "goweb.tenant",
+	Tag:           "varint,10108,opt,name=tenant",
+	Filename:      "goweb.proto",
+}
+
+// E_Encrypt marks a field for encryption; see goweb.proto.
+var E_Encrypt = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10109,
+	Name:          "goweb.encrypt",
+	Tag:           "varint,10109,opt,name=encrypt",
+	Filename:      "goweb.proto",
+}
+
+// E_Required marks a required request field; see goweb.proto.
+var E_Required = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         10110,
+	Name:          "goweb.required",
+	Tag:           "varint,10110,opt,name=required",
+	Filename:      "goweb.proto",
+}
+
+// E_Min is the minimum of a request field; see goweb.proto.
+var E_Min = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*float64)(nil),
+	Field:         10111,
+	Name:          "goweb.min",
+	Tag:           "fixed64,10111,opt,name=min",
+	Filename:      "goweb.proto",
+}
+
+// E_Max is the maximum of a request field; see goweb.proto.
+var E_Max = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*float64)(nil),
+	Field:         10112,
+	Name:          "goweb.max",
+	Tag:           "fixed64,10112,opt,name=max",
+	Filename:      "goweb.proto",
+}
+
+// E_MinLength is the minimum length of a request field; see goweb.proto.
+var E_MinLength = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10113,
+	Name:          "goweb.min_length",
+	Tag:           "varint,10113,opt,name=min_length,json=minLength",
+	Filename:      "goweb.proto",
+}
+
+// E_Pattern is the pattern of a string request field; see goweb.proto.
+var E_Pattern = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*string)(nil),
+	Field:         10114,
+	Name:          "goweb.pattern",
+	Tag:           "bytes,10114,opt,name=pattern",
+	Filename:      "goweb.proto",
+}
+
+// E_MinItems is the minimum number of elements of a request field; see goweb.proto.
+var E_MinItems = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*uint32)(nil),
+	Field:         10115,
+	Name:          "goweb.min_items",
+	Tag:           "varint,10115,opt,name=min_items,json=minItems",
+	Filename:      "goweb.proto",
+}
+
+// The values of the goweb.Visibility enum.
+const (
+	OutputOnly = 1
+	InputOnly  = 2
+)
+
+// The values of the goweb.SessionAction enum.
+const (
+	SessionRequired = 1
+	SessionIssue    = 2
+	SessionRevoke   = 3
+)
+
+// get returns the value of ext in opts, or nil if opts is nil
+// or does not have ext set.
+func get(opts proto.Message, ext *proto.ExtensionDesc) interface{} {
+	if opts == nil || reflect.ValueOf(opts).IsNil() || !proto.HasExtension(opts, ext) {
+		return nil
+	}
+	v, err := proto.GetExtension(opts, ext)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// Bool returns the value of the bool extension ext in opts, or false.
+func Bool(opts proto.Message, ext *proto.ExtensionDesc) bool {
+	v, _ := get(opts, ext).(*bool)
+	return v != nil && *v
+}
+
+// Has reports whether ext is set in opts.
+func Has(opts proto.Message, ext *proto.ExtensionDesc) bool {
+	return get(opts, ext) != nil
+}
+
+// String returns the value of the string extension ext in opts, or "".
+func String(opts proto.Message, ext *proto.ExtensionDesc) string {
+	v, _ := get(opts, ext).(*string)
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// Strings returns the values of the repeated string extension ext in
+// opts, or nil.
+func Strings(opts proto.Message, ext *proto.ExtensionDesc) []string {
+	v, _ := get(opts, ext).([]string)
+	return v
+}
+
+// Int32 returns the value of the int32 extension ext in opts, or 0.
+func Int32(opts proto.Message, ext *proto.ExtensionDesc) int32 {
+	v, _ := get(opts, ext).(*int32)
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// Uint32 returns the value of the uint32 extension ext in opts, or 0.
+func Uint32(opts proto.Message, ext *proto.ExtensionDesc) uint32 {
+	v, _ := get(opts, ext).(*uint32)
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// Uint64 returns the value of the uint64 extension ext in opts, or 0.
+func Uint64(opts proto.Message, ext *proto.ExtensionDesc) uint64 {
+	v, _ := get(opts, ext).(*uint64)
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// Float64 returns the value of the double extension ext in opts, or 0.
+func Float64(opts proto.Message, ext *proto.ExtensionDesc) float64 {
+	v, _ := get(opts, ext).(*float64)
+	if v == nil {
+		return 0
+	}
+	return *v
+}
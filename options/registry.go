@@ -0,0 +1,248 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package options
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// The registry resolves custom options by name, whether declared here or
+// in other .proto files of a generation run (routing, auth, cache or
+// validation options, say), and detects extensions that claim the same
+// field number of the same options message.
+var (
+	registryMu sync.Mutex
+	byNumber   = map[extKey]*proto.ExtensionDesc{}
+	byName     = map[string]*proto.ExtensionDesc{}
+)
+
+type extKey struct {
+	extended reflect.Type
+	field    int32
+}
+
+// A ConflictError reports two extensions with the same field number of
+// the same options message, or two numbers for one name.
+type ConflictError struct {
+	Extended string // e.g. "google.protobuf.MethodOptions"
+	Field    int32
+	Names    [2]string // the registered and the new extension
+}
+
+func (e *ConflictError) Error() string {
+	if e.Names[0] == e.Names[1] {
+		return fmt.Sprintf("options: %s is declared with two field numbers of %s", e.Names[0], e.Extended)
+	}
+	return fmt.Sprintf("options: %s and %s both extend %s with field %d", e.Names[0], e.Names[1], e.Extended, e.Field)
+}
+
+func init() {
+	for _, ext := range []*proto.ExtensionDesc{
+		E_Event, E_MqttTopic, E_MqttQos, E_StreamFlushEvery, E_StreamGzip, E_StreamKeepaliveSeconds,
+		E_StreamWriteTimeoutSeconds, E_Download, E_Upload, E_UploadMaxBytes, E_WebhookSignatureHeader,
+		E_WebhookSecret, E_RawBody, E_Retryable, E_Transactional, E_RegionField, E_Transform, E_Enrich,
+		E_Authorize, E_Policy, E_Audit, E_Session, E_Link, E_SloLatencyMs, E_SloLatencyPercentile,
+		E_SloAvailability, E_TimeoutSeconds, E_StreamProxySafe, E_DryRun, E_CoalesceMs, E_StreamResponse, E_Middleware,
+		E_ServiceRegionField, E_ApiVersion, E_SoftDelete, E_ErrorCode,
+		E_Redact, E_Visibility, E_Default, E_Normalize, E_MaxItems, E_MaxLength, E_EventId, E_Filename,
+		E_Tenant, E_Encrypt, E_Required, E_Min, E_Max, E_MinLength, E_Pattern, E_MinItems, E_Http,
+	} {
+		if err := Register(ext); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds ext to the registry. Registering an extension again is a
+// no-op; another extension with its name or field number is a
+// *ConflictError.
+func Register(ext *proto.ExtensionDesc) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	t := reflect.TypeOf(ext.ExtendedType)
+	key := extKey{t, ext.Field}
+	if old := byNumber[key]; old != nil && old.Name != ext.Name {
+		return &ConflictError{proto.MessageName(ext.ExtendedType), ext.Field, [2]string{old.Name, ext.Name}}
+	}
+	if old := byName[ext.Name]; old != nil && (old.Field != ext.Field || reflect.TypeOf(old.ExtendedType) != t) {
+		return &ConflictError{proto.MessageName(ext.ExtendedType), ext.Field, [2]string{old.Name, ext.Name}}
+	}
+	if byName[ext.Name] == nil {
+		byNumber[key] = ext
+		byName[ext.Name] = ext
+	}
+	return nil
+}
+
+// Lookup returns the registered extension with the full name name (e.g.
+// "goweb.event"), or nil.
+func Lookup(name string) *proto.ExtensionDesc {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return byName[name]
+}
+
+// Extensions returns the registered extensions, ordered by name.
+func Extensions() []*proto.ExtensionDesc {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	exts := make([]*proto.ExtensionDesc, 0, len(byName))
+	for _, ext := range byName {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool { return exts[i].Name < exts[j].Name })
+	return exts
+}
+
+// Value returns the value of the registered extension name in opts (e.g.
+// a *bool), or nil if it is not set, not registered or of a type that
+// cannot be decoded without generated code (messages and repeated
+// fields).
+func Value(opts proto.Message, name string) interface{} {
+	ext := Lookup(name)
+	if ext == nil || ext.ExtensionType == nil || reflect.TypeOf(opts) != reflect.TypeOf(ext.ExtendedType) {
+		return nil
+	}
+	return get(opts, ext)
+}
+
+// The options messages that extensions can extend, by full name.
+var extendable = map[string]proto.Message{
+	".google.protobuf.FileOptions":      (*descriptor.FileOptions)(nil),
+	".google.protobuf.MessageOptions":   (*descriptor.MessageOptions)(nil),
+	".google.protobuf.FieldOptions":     (*descriptor.FieldOptions)(nil),
+	".google.protobuf.OneofOptions":     (*descriptor.OneofOptions)(nil),
+	".google.protobuf.EnumOptions":      (*descriptor.EnumOptions)(nil),
+	".google.protobuf.EnumValueOptions": (*descriptor.EnumValueOptions)(nil),
+	".google.protobuf.ServiceOptions":   (*descriptor.ServiceOptions)(nil),
+	".google.protobuf.MethodOptions":    (*descriptor.MethodOptions)(nil),
+}
+
+// RegisterFile registers the custom options declared in file, the
+// extensions of the google.protobuf options messages at the top level or
+// in messages.
+func RegisterFile(file *descriptor.FileDescriptorProto) error {
+	prefix := ""
+	if pkg := file.GetPackage(); pkg != "" {
+		prefix = pkg + "."
+	}
+	if err := registerFields(file, prefix, file.Extension); err != nil {
+		return err
+	}
+	var walk func(prefix string, msgs []*descriptor.DescriptorProto) error
+	walk = func(prefix string, msgs []*descriptor.DescriptorProto) error {
+		for _, m := range msgs {
+			p := prefix + m.GetName() + "."
+			if err := registerFields(file, p, m.Extension); err != nil {
+				return err
+			}
+			if err := walk(p, m.NestedType); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(prefix, file.MessageType)
+}
+
+func registerFields(file *descriptor.FileDescriptorProto, prefix string, fields []*descriptor.FieldDescriptorProto) error {
+	for _, f := range fields {
+		extended, ok := extendable[f.GetExtendee()]
+		if !ok {
+			continue
+		}
+		if err := Register(extensionDesc(file, prefix, extended, f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extensionDesc returns the descriptor of the extension f, whose
+// ExtensionType is nil for messages and repeated fields.
+func extensionDesc(file *descriptor.FileDescriptorProto, prefix string, extended proto.Message, f *descriptor.FieldDescriptorProto) *proto.ExtensionDesc {
+	var typ interface{}
+	wire := "bytes" // messages
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		typ, wire = (*bool)(nil), "varint"
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		typ, wire = (*string)(nil), "bytes"
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		typ, wire = ([]byte)(nil), "bytes"
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_ENUM:
+		typ, wire = (*int32)(nil), "varint"
+	case descriptor.FieldDescriptorProto_TYPE_SINT32:
+		typ, wire = (*int32)(nil), "zigzag32"
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		typ, wire = (*int32)(nil), "fixed32"
+	case descriptor.FieldDescriptorProto_TYPE_INT64:
+		typ, wire = (*int64)(nil), "varint"
+	case descriptor.FieldDescriptorProto_TYPE_SINT64:
+		typ, wire = (*int64)(nil), "zigzag64"
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		typ, wire = (*int64)(nil), "fixed64"
+	case descriptor.FieldDescriptorProto_TYPE_UINT32:
+		typ, wire = (*uint32)(nil), "varint"
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		typ, wire = (*uint32)(nil), "fixed32"
+	case descriptor.FieldDescriptorProto_TYPE_UINT64:
+		typ, wire = (*uint64)(nil), "varint"
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		typ, wire = (*uint64)(nil), "fixed64"
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		typ, wire = (*float64)(nil), "fixed64"
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		typ, wire = (*float32)(nil), "fixed32"
+	}
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		typ = nil
+	}
+	tag := fmt.Sprintf("%s,%d,opt,name=%s", wire, f.GetNumber(), f.GetName())
+	if json := f.GetJsonName(); json != "" && json != f.GetName() {
+		tag += ",json=" + json
+	}
+	return &proto.ExtensionDesc{
+		ExtendedType:  extended,
+		ExtensionType: typ,
+		Field:         f.GetNumber(),
+		Name:          prefix + f.GetName(),
+		Tag:           tag,
+		Filename:      file.GetName(),
+	}
+}
@@ -0,0 +1,115 @@
+// Go support for Protocol Buffers - Google's data interchange format
+//
+// Copyright 2015 The Go Authors.  All rights reserved.
+// https://github.com/golang/protobuf
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//     * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//     * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//     * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func extensionField(name string, number int32, typ descriptor.FieldDescriptorProto_Type, extendee string) *descriptor.FieldDescriptorProto {
+	return &descriptor.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+		Extendee: proto.String(extendee),
+	}
+}
+
+func TestRegisterFile(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("cache.proto"),
+		Package: proto.String("cache"),
+		Extension: []*descriptor.FieldDescriptorProto{
+			extensionField("ttl_seconds", 50001, descriptor.FieldDescriptorProto_TYPE_UINT32, ".google.protobuf.MethodOptions"),
+		},
+		MessageType: []*descriptor.DescriptorProto{{
+			Name: proto.String("Auth"),
+			Extension: []*descriptor.FieldDescriptorProto{
+				extensionField("scope", 50002, descriptor.FieldDescriptorProto_TYPE_STRING, ".google.protobuf.MethodOptions"),
+			},
+		}},
+	}
+	if err := RegisterFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterFile(file); err != nil {
+		t.Fatalf("registering again: %v", err)
+	}
+
+	opts := &descriptor.MethodOptions{}
+	if err := proto.SetExtension(opts, Lookup("cache.ttl_seconds"), proto.Uint32(60)); err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.SetExtension(opts, Lookup("cache.Auth.scope"), proto.String("admin")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = &descriptor.MethodOptions{}
+	if err := proto.Unmarshal(data, opts); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Value(opts, "cache.ttl_seconds").(*uint32); v == nil || *v != 60 {
+		t.Errorf("ttl_seconds = %v", Value(opts, "cache.ttl_seconds"))
+	}
+	if v, _ := Value(opts, "cache.Auth.scope").(*string); v == nil || *v != "admin" {
+		t.Errorf("scope = %v", Value(opts, "cache.Auth.scope"))
+	}
+	if v := Value(&descriptor.FieldOptions{}, "cache.ttl_seconds"); v != nil {
+		t.Errorf("ttl_seconds of field options = %v", v)
+	}
+}
+
+func TestRegisterConflict(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("routing.proto"),
+		Package: proto.String("routing"),
+		Extension: []*descriptor.FieldDescriptorProto{
+			extensionField("sticky", int32(E_Event.Field), descriptor.FieldDescriptorProto_TYPE_BOOL, ".google.protobuf.MethodOptions"),
+		},
+	}
+	err := RegisterFile(file)
+	if c, ok := err.(*ConflictError); !ok || c.Names != [2]string{"goweb.event", "routing.sticky"} {
+		t.Errorf("RegisterFile = %v", err)
+	}
+	// The same number of another options message does not conflict.
+	file.Extension[0].Extendee = proto.String(".google.protobuf.FieldOptions")
+	if err := RegisterFile(file); err != nil {
+		t.Error(err)
+	}
+}